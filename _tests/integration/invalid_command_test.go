@@ -11,6 +11,6 @@ func Test_InvalidCommand(t *testing.T) {
 	t.Log("Invalid command")
 	{
 		_, err := cmdex.RunCommandAndReturnCombinedStdoutAndStderr(binPath(), "invalidcmd")
-		require.EqualError(t, err, "exit status 1")
+		require.EqualError(t, err, "exit status 64")
 	}
 }
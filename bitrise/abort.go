@@ -0,0 +1,28 @@
+package bitrise
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/bitrise-io/bitrise/exitcode"
+)
+
+// BuildAbortedExitCode is the exit code of an aborted build (see: exitcode.Aborted)
+const BuildAbortedExitCode = exitcode.Aborted
+
+// ErrBuildAborted ...
+var ErrBuildAborted = errors.New("build aborted")
+
+// isAborted is set from the signal handler goroutine, use atomic access
+var isAborted int32
+
+// IsAborted ...
+func IsAborted() bool {
+	return atomic.LoadInt32(&isAborted) == 1
+}
+
+// Abort registers the aborted state of the build.
+// Returns false if the build was already aborted.
+func Abort() bool {
+	return atomic.CompareAndSwapInt32(&isAborted, 0, 1)
+}
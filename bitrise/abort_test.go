@@ -0,0 +1,26 @@
+package bitrise
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbort(t *testing.T) {
+	defer atomic.StoreInt32(&isAborted, 0)
+
+	require.Equal(t, false, IsAborted())
+
+	t.Log("first abort")
+	{
+		require.Equal(t, true, Abort())
+		require.Equal(t, true, IsAborted())
+	}
+
+	t.Log("already aborted")
+	{
+		require.Equal(t, false, Abort())
+		require.Equal(t, true, IsAborted())
+	}
+}
@@ -0,0 +1,407 @@
+package bitrise
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+)
+
+// Artifact upload (see: --artifact-upload): at the end of the run the content of the deploy dir is uploaded
+// to the backend selected by the scheme of the upload URL:
+//  * s3://<bucket>/<prefix>: AWS S3, with the AWS CLI and the ambient AWS credentials (see: the AWS secrets providers)
+//  * gs://<bucket>/<prefix>: Google Cloud Storage, with the GOOGLE_OAUTH_ACCESS_TOKEN env or the GCE metadata server's token
+//  * http(s)://<url>: generic HTTP PUT, with the BITRISE_ARTIFACT_UPLOAD_TOKEN env as bearer token, if set
+//  * file://<dir>: local archive, a .tar.gz of the deploy dir, the expired archives of the dir are removed
+// The files are uploaded under <prefix>/<upload id>/, with the manifest (ArtifactUploadManifestFileName),
+// which lists the files and the retention metadata (see: --artifact-retention-days). The remote backends get the retention
+// as object metadata as well (S3: retention-days tag, GCS: custom time), to be enforced by the bucket's lifecycle rules.
+
+const (
+	// ArtifactUploadManifestFileName ...
+	ArtifactUploadManifestFileName = "bitrise-artifacts.json"
+	// ArtifactUploadTokenEnvKey is the bearer token of the HTTP PUT uploads
+	ArtifactUploadTokenEnvKey = "BITRISE_ARTIFACT_UPLOAD_TOKEN"
+
+	artifactUploadTimeout = 30 * time.Minute
+	artifactUploadIDTime  = "20060102T150405Z"
+)
+
+// ArtifactUploadManifestModel describes the uploaded artifacts of a run
+type ArtifactUploadManifestModel struct {
+	UploadID    string    `json:"upload_id"`
+	Workflow    string    `json:"workflow"`
+	BuildStatus string    `json:"build_status"`
+	CreatedAt   time.Time `json:"created_at"`
+	// RetentionDays is the number of days the artifacts should be kept for, 0 means forever
+	RetentionDays int             `json:"retention_days,omitempty"`
+	ExpiresAt     *time.Time      `json:"expires_at,omitempty"`
+	Artifacts     []ArtifactModel `json:"artifacts"`
+}
+
+// NewArtifactUploadManifest lists the files of the deploy dir,
+// the step which created the file is added from the tracked artifacts.
+func NewArtifactUploadManifest(workflowID, buildStatus, deployDir string, tracked []ArtifactModel, retentionDays int, now time.Time) (ArtifactUploadManifestModel, error) {
+	tracker := &ArtifactTrackerModel{DeployDir: deployDir}
+	files, err := tracker.scan()
+	if err != nil {
+		return ArtifactUploadManifestModel{}, err
+	}
+
+	trackedByPath := map[string]ArtifactModel{}
+	for _, artifact := range tracked {
+		trackedByPath[artifact.Path] = artifact
+	}
+
+	tracker.artifacts = map[string]ArtifactModel{}
+	for pth, state := range files {
+		if pth == ArtifactUploadManifestFileName {
+			continue
+		}
+
+		artifact, isTracked := trackedByPath[pth]
+		if !isTracked || artifact.Size != state.size {
+			checksum, err := fileSHA256(filepath.Join(deployDir, filepath.FromSlash(pth)))
+			if err != nil {
+				return ArtifactUploadManifestModel{}, fmt.Errorf("Failed to calculate the checksum of artifact (%s), error: %s", pth, err)
+			}
+			artifact.Path = pth
+			artifact.Size = state.size
+			artifact.SHA256 = checksum
+		}
+		tracker.artifacts[pth] = artifact
+	}
+
+	workflowName := regexp.MustCompile(`[^a-zA-Z0-9_.-]+`).ReplaceAllString(workflowID, "_")
+	// the random suffix separates the uploads of the concurrent runs of the same workflow
+	suffix, err := randomUploadIDSuffix()
+	if err != nil {
+		return ArtifactUploadManifestModel{}, fmt.Errorf("Failed to generate upload ID, error: %s", err)
+	}
+	manifest := ArtifactUploadManifestModel{
+		UploadID:      fmt.Sprintf("%s-%s-%s", workflowName, now.UTC().Format(artifactUploadIDTime), suffix),
+		Workflow:      workflowID,
+		BuildStatus:   buildStatus,
+		CreatedAt:     now,
+		RetentionDays: retentionDays,
+		Artifacts:     tracker.Artifacts(),
+	}
+	if retentionDays > 0 {
+		expiresAt := now.Add(time.Duration(retentionDays) * 24 * time.Hour)
+		manifest.ExpiresAt = &expiresAt
+	}
+	return manifest, nil
+}
+
+// ArtifactUploader uploads the artifacts of the run to a backend
+type ArtifactUploader interface {
+	// Scheme is the scheme of the upload URLs the uploader handles (s3 for s3://...)
+	Scheme() string
+	// Upload uploads the files of the deploy dir, listed in the manifest, and the manifest
+	// to the destination (the part of the upload URL after <scheme>://)
+	Upload(destination, deployDir string, manifest ArtifactUploadManifestModel) error
+}
+
+// ArtifactUploaders returns the supported artifact upload backends
+func ArtifactUploaders() []ArtifactUploader {
+	return []ArtifactUploader{
+		NewS3ArtifactUploader(),
+		NewGCSArtifactUploader(),
+		NewHTTPArtifactUploader("https"),
+		NewHTTPArtifactUploader("http"),
+		&LocalArtifactUploader{},
+	}
+}
+
+// artifactUploaderForURL returns the uploader of the URL's scheme, and the destination without the scheme
+func artifactUploaderForURL(uploaders []ArtifactUploader, uploadURL string) (ArtifactUploader, string, error) {
+	schemes := []string{}
+	for _, uploader := range uploaders {
+		prefix := uploader.Scheme() + "://"
+		if strings.HasPrefix(uploadURL, prefix) {
+			destination := strings.TrimSuffix(strings.TrimPrefix(uploadURL, prefix), "/")
+			if destination == "" {
+				return nil, "", fmt.Errorf("no destination specified in artifact upload URL (%s)", uploadURL)
+			}
+			return uploader, destination, nil
+		}
+		schemes = append(schemes, prefix)
+	}
+	return nil, "", fmt.Errorf("unsupported artifact upload URL (%s), accepted: %s", uploadURL, strings.Join(schemes, ", "))
+}
+
+// ValidateArtifactUploadURL returns an error if no backend supports the URL
+func ValidateArtifactUploadURL(uploadURL string) error {
+	_, _, err := artifactUploaderForURL(ArtifactUploaders(), uploadURL)
+	return err
+}
+
+func randomUploadIDSuffix() (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(suffix), nil
+}
+
+// UploadArtifacts uploads the artifacts of the manifest with the backend of the upload URL
+func UploadArtifacts(uploadURL, deployDir string, manifest ArtifactUploadManifestModel) error {
+	uploader, destination, err := artifactUploaderForURL(ArtifactUploaders(), uploadURL)
+	if err != nil {
+		return err
+	}
+	return uploader.Upload(destination, deployDir, manifest)
+}
+
+// splitArtifactDestination splits the <bucket>/<prefix> destination
+func splitArtifactDestination(destination string) (string, string) {
+	split := strings.SplitN(destination, "/", 2)
+	if len(split) == 1 {
+		return split[0], ""
+	}
+	return split[0], strings.Trim(split[1], "/")
+}
+
+// artifactObjectName returns the name of the uploaded file: <prefix>/<upload id>/<path>
+func artifactObjectName(prefix string, manifest ArtifactUploadManifestModel, pth string) string {
+	name := manifest.UploadID + "/" + pth
+	if prefix != "" {
+		name = prefix + "/" + name
+	}
+	return name
+}
+
+// uploadArtifactFiles uploads the artifacts and then the manifest with the put function of the backend
+func uploadArtifactFiles(deployDir string, manifest ArtifactUploadManifestModel,
+	put func(pth string, body io.Reader, size int64, checksum string) error) error {
+	for _, artifact := range manifest.Artifacts {
+		if err := func() error {
+			pth := filepath.Join(deployDir, filepath.FromSlash(artifact.Path))
+			file, err := os.Open(pth)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := file.Close(); err != nil {
+					log.Warnf("Failed to close file (%s), error: %s", pth, err)
+				}
+			}()
+			return put(artifact.Path, file, artifact.Size, artifact.SHA256)
+		}(); err != nil {
+			return fmt.Errorf("Failed to upload artifact (%s), error: %s", artifact.Path, err)
+		}
+		log.Debugf("Artifact uploaded: %s", artifact.Path)
+	}
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to serialize artifact manifest, error: %s", err)
+	}
+	if err := put(ArtifactUploadManifestFileName, bytes.NewReader(manifestBytes), int64(len(manifestBytes)), bytesSHA256(manifestBytes)); err != nil {
+		return fmt.Errorf("Failed to upload artifact manifest, error: %s", err)
+	}
+	return nil
+}
+
+// newArtifactUploadRequest returns a PUT request of the body with the known size,
+// the body is not closed by the request (the files are closed by uploadArtifactFiles)
+func newArtifactUploadRequest(url string, body io.Reader, size int64) (*http.Request, error) {
+	if size == 0 {
+		body = bytes.NewReader([]byte{})
+	} else {
+		body = ioutil.NopCloser(body)
+	}
+
+	request, err := http.NewRequest("PUT", url, body)
+	if err != nil {
+		return nil, err
+	}
+	request.ContentLength = size
+	return request, nil
+}
+
+// doArtifactUploadRequest sends the upload request, and checks its response
+func doArtifactUploadRequest(client *http.Client, request *http.Request) error {
+	resp, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Warnf("Failed to close (%s) body", request.URL)
+		}
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		if err != nil {
+			log.Debugf("Failed to read (%s) body, error: %s", request.URL, err)
+		}
+		return fmt.Errorf("upload to (%s) failed, status code: %d, response: %s", request.URL, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// HTTPArtifactUploader uploads the artifacts with HTTP PUT requests: PUT <url>/<upload id>/<path>,
+// the retention metadata is sent in the X-Artifact-Retention-Days and X-Artifact-Expires-At headers.
+type HTTPArtifactUploader struct {
+	scheme string
+	client *http.Client
+}
+
+// NewHTTPArtifactUploader ...
+func NewHTTPArtifactUploader(scheme string) *HTTPArtifactUploader {
+	return &HTTPArtifactUploader{scheme: scheme, client: configs.NewHTTPClient(artifactUploadTimeout)}
+}
+
+// Scheme ...
+func (uploader *HTTPArtifactUploader) Scheme() string {
+	return uploader.scheme
+}
+
+// Upload ...
+func (uploader *HTTPArtifactUploader) Upload(destination, deployDir string, manifest ArtifactUploadManifestModel) error {
+	baseURL := uploader.scheme + "://" + destination
+	token := os.Getenv(ArtifactUploadTokenEnvKey)
+
+	return uploadArtifactFiles(deployDir, manifest, func(pth string, body io.Reader, size int64, checksum string) error {
+		request, err := newArtifactUploadRequest(artifactObjectName(baseURL, manifest, pth), body, size)
+		if err != nil {
+			return err
+		}
+		request.Header.Set("X-Artifact-Sha256", checksum)
+		if manifest.RetentionDays > 0 {
+			request.Header.Set("X-Artifact-Retention-Days", strconv.Itoa(manifest.RetentionDays))
+			request.Header.Set("X-Artifact-Expires-At", manifest.ExpiresAt.UTC().Format(time.RFC3339))
+		}
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		return doArtifactUploadRequest(uploader.client, request)
+	})
+}
+
+// LocalArtifactUploader archives the artifacts into a local directory: <dir>/<upload id>.tar.gz,
+// with the manifest next to it (<dir>/<upload id>.json). The expired archives of the directory are removed.
+type LocalArtifactUploader struct {
+	now func() time.Time
+}
+
+// Scheme ...
+func (uploader *LocalArtifactUploader) Scheme() string {
+	return "file"
+}
+
+// Upload ...
+func (uploader *LocalArtifactUploader) Upload(destination, deployDir string, manifest ArtifactUploadManifestModel) error {
+	if err := os.MkdirAll(destination, 0777); err != nil {
+		return fmt.Errorf("Failed to create artifact archive dir (%s), error: %s", destination, err)
+	}
+
+	archivePth := filepath.Join(destination, manifest.UploadID+".tar.gz")
+	if err := writeArtifactArchive(archivePth, deployDir, manifest); err != nil {
+		if removeErr := os.Remove(archivePth); removeErr != nil && !os.IsNotExist(removeErr) {
+			log.Warnf("Failed to remove artifact archive (%s), error: %s", archivePth, removeErr)
+		}
+		return err
+	}
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to serialize artifact manifest, error: %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(destination, manifest.UploadID+".json"), manifestBytes, 0644); err != nil {
+		return fmt.Errorf("Failed to write artifact manifest, error: %s", err)
+	}
+
+	now := time.Now
+	if uploader.now != nil {
+		now = uploader.now
+	}
+	removeExpiredArtifactArchives(destination, now())
+	return nil
+}
+
+func writeArtifactArchive(pth, deployDir string, manifest ArtifactUploadManifestModel) error {
+	file, err := os.Create(pth)
+	if err != nil {
+		return fmt.Errorf("Failed to create artifact archive (%s), error: %s", pth, err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Warnf("Failed to close artifact archive (%s), error: %s", pth, err)
+		}
+	}()
+
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for _, artifact := range manifest.Artifacts {
+		if err := addBundleFile(tarWriter, artifact.Path, filepath.Join(deployDir, filepath.FromSlash(artifact.Path))); err != nil {
+			return err
+		}
+	}
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to serialize artifact manifest, error: %s", err)
+	}
+	if err := writeBundleFile(tarWriter, ArtifactUploadManifestFileName, 0644, manifestBytes); err != nil {
+		return err
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("Failed to write artifact archive, error: %s", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return fmt.Errorf("Failed to write artifact archive, error: %s", err)
+	}
+	return nil
+}
+
+// removeExpiredArtifactArchives removes the archives (and their manifests) of the dir, which expired before now
+func removeExpiredArtifactArchives(dir string, now time.Time) {
+	manifestPths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		log.Warnf("Failed to list artifact archives, error: %s", err)
+		return
+	}
+
+	for _, manifestPth := range manifestPths {
+		manifestBytes, err := ioutil.ReadFile(manifestPth)
+		if err != nil {
+			log.Warnf("Failed to read artifact manifest (%s), error: %s", manifestPth, err)
+			continue
+		}
+		var manifest ArtifactUploadManifestModel
+		if err := json.Unmarshal(manifestBytes, &manifest); err != nil || manifest.UploadID == "" {
+			// not an artifact manifest
+			continue
+		}
+		if manifest.ExpiresAt == nil || manifest.ExpiresAt.After(now) {
+			continue
+		}
+
+		for _, pth := range []string{filepath.Join(dir, manifest.UploadID+".tar.gz"), manifestPth} {
+			if err := os.Remove(pth); err != nil && !os.IsNotExist(err) {
+				log.Warnf("Failed to remove expired artifact archive (%s), error: %s", pth, err)
+			}
+		}
+		log.Infof("Expired artifact archive removed: %s", manifest.UploadID)
+	}
+}
@@ -0,0 +1,156 @@
+package bitrise
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+)
+
+const (
+	// GCSAccessTokenEnvKey is the OAuth access token of the Google Cloud Storage uploads
+	GCSAccessTokenEnvKey = "GOOGLE_OAUTH_ACCESS_TOKEN"
+
+	gcsEndpoint         = "https://storage.googleapis.com"
+	gcsMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+	gcsMetadataTimeout  = 2 * time.Second
+)
+
+// S3ArtifactUploader uploads the artifacts to AWS S3 (s3://<bucket>/<prefix>) with the AWS CLI (aws s3 cp),
+// with the ambient AWS credentials (see: the AWS secrets providers). The AWS CLI uploads the large files in multiple parts.
+// The retention is set as the retention-days tag of the objects, to be expired by a lifecycle rule of the bucket.
+type S3ArtifactUploader struct {
+	cli *awsCLI
+}
+
+// NewS3ArtifactUploader ...
+func NewS3ArtifactUploader() *S3ArtifactUploader {
+	return &S3ArtifactUploader{cli: newAWSCLI()}
+}
+
+// Scheme ...
+func (uploader *S3ArtifactUploader) Scheme() string {
+	return "s3"
+}
+
+// Upload ...
+func (uploader *S3ArtifactUploader) Upload(destination, deployDir string, manifest ArtifactUploadManifestModel) error {
+	bucket, prefix := splitArtifactDestination(destination)
+
+	return uploadArtifactFiles(deployDir, manifest, func(pth string, body io.Reader, size int64, checksum string) error {
+		key := artifactObjectName(prefix, manifest, pth)
+		metadata := "sha256=" + checksum
+		if manifest.RetentionDays > 0 {
+			metadata += ",retention-days=" + strconv.Itoa(manifest.RetentionDays)
+		}
+
+		// the body is streamed to the AWS CLI (-), the expected size is required to split the large files into parts
+		if _, err := uploader.cli.run(body, "s3", "cp", "-", "s3://"+bucket+"/"+key,
+			"--expected-size", strconv.FormatInt(size, 10), "--metadata", metadata, "--only-show-errors"); err != nil {
+			return err
+		}
+
+		if manifest.RetentionDays > 0 {
+			tagging := fmt.Sprintf("TagSet=[{Key=retention-days,Value=%d}]", manifest.RetentionDays)
+			if _, err := uploader.cli.run(nil, "s3api", "put-object-tagging", "--bucket", bucket, "--key", key, "--tagging", tagging); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GCSArtifactUploader uploads the artifacts to Google Cloud Storage (gs://<bucket>/<prefix>).
+// The expiry is set as the custom time of the objects, to be deleted by a lifecycle rule of the bucket (daysSinceCustomTime).
+type GCSArtifactUploader struct {
+	endpoint string
+	client   *http.Client
+	getToken func() (string, error)
+}
+
+// NewGCSArtifactUploader ...
+func NewGCSArtifactUploader() *GCSArtifactUploader {
+	return &GCSArtifactUploader{
+		endpoint: gcsEndpoint,
+		client:   configs.NewHTTPClient(artifactUploadTimeout),
+		getToken: ambientGCSAccessToken,
+	}
+}
+
+// Scheme ...
+func (uploader *GCSArtifactUploader) Scheme() string {
+	return "gs"
+}
+
+// Upload ...
+func (uploader *GCSArtifactUploader) Upload(destination, deployDir string, manifest ArtifactUploadManifestModel) error {
+	token, err := uploader.getToken()
+	if err != nil {
+		return err
+	}
+
+	bucket, prefix := splitArtifactDestination(destination)
+	bucketURL := strings.TrimSuffix(uploader.endpoint, "/") + "/" + bucket
+
+	return uploadArtifactFiles(deployDir, manifest, func(pth string, body io.Reader, size int64, checksum string) error {
+		request, err := newArtifactUploadRequest(bucketURL+"/"+artifactObjectName(prefix, manifest, pth), body, size)
+		if err != nil {
+			return err
+		}
+		request.Header.Set("Authorization", "Bearer "+token)
+		request.Header.Set("X-Goog-Meta-Sha256", checksum)
+		if manifest.RetentionDays > 0 {
+			request.Header.Set("X-Goog-Meta-Retention-Days", strconv.Itoa(manifest.RetentionDays))
+			request.Header.Set("X-Goog-Custom-Time", manifest.ExpiresAt.UTC().Format(time.RFC3339))
+		}
+		return doArtifactUploadRequest(uploader.client, request)
+	})
+}
+
+// ambientGCSAccessToken returns the token of the GOOGLE_OAUTH_ACCESS_TOKEN env,
+// or the token of the GCE instance's service account from the metadata server
+func ambientGCSAccessToken() (string, error) {
+	if token := os.Getenv(GCSAccessTokenEnvKey); token != "" {
+		return token, nil
+	}
+
+	request, err := http.NewRequest("GET", gcsMetadataTokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := configs.NewHTTPClient(gcsMetadataTimeout).Do(request)
+	if err != nil {
+		return "", fmt.Errorf("no Google Cloud access token found (%s env, metadata server), error: %s", GCSAccessTokenEnvKey, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Warnf("Failed to close (%s) body", gcsMetadataTokenURL)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get the access token from the metadata server, status code: %d", resp.StatusCode)
+	}
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	var response struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(content, &response); err != nil {
+		return "", fmt.Errorf("failed to parse the metadata server's access token, error: %s", err)
+	}
+	return response.AccessToken, nil
+}
@@ -0,0 +1,274 @@
+package bitrise
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedUploadModel struct {
+	Path   string
+	Header http.Header
+	Body   string
+}
+
+func newUploadRecorderServer(t *testing.T) (*httptest.Server, func() []recordedUploadModel) {
+	var mutex sync.Mutex
+	uploads := []recordedUploadModel{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "PUT", r.Method)
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		uploads = append(uploads, recordedUploadModel{Path: r.URL.Path, Header: r.Header, Body: string(body)})
+	}))
+	return server, func() []recordedUploadModel {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return uploads
+	}
+}
+
+func TestArtifactUploaderForURL(t *testing.T) {
+	uploaders := ArtifactUploaders()
+
+	uploader, destination, err := artifactUploaderForURL(uploaders, "s3://my-bucket/builds/")
+	require.NoError(t, err)
+	require.Equal(t, "s3", uploader.Scheme())
+	require.Equal(t, "my-bucket/builds", destination)
+
+	uploader, destination, err = artifactUploaderForURL(uploaders, "https://artifacts.example.com/upload")
+	require.NoError(t, err)
+	require.Equal(t, "https", uploader.Scheme())
+	require.Equal(t, "artifacts.example.com/upload", destination)
+
+	uploader, destination, err = artifactUploaderForURL(uploaders, "file:///var/artifacts")
+	require.NoError(t, err)
+	require.Equal(t, "file", uploader.Scheme())
+	require.Equal(t, "/var/artifacts", destination)
+
+	_, _, err = artifactUploaderForURL(uploaders, "ftp://example.com")
+	require.Error(t, err)
+
+	_, _, err = artifactUploaderForURL(uploaders, "gs://")
+	require.Error(t, err)
+
+	bucket, prefix := splitArtifactDestination("my-bucket/builds/ios")
+	require.Equal(t, "my-bucket", bucket)
+	require.Equal(t, "builds/ios", prefix)
+
+	bucket, prefix = splitArtifactDestination("my-bucket")
+	require.Equal(t, "my-bucket", bucket)
+	require.Equal(t, "", prefix)
+}
+
+func createTestDeployDir(t *testing.T) (string, func()) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__artifact_upload__")
+	require.NoError(t, err)
+
+	deployDir := filepath.Join(tmpDir, "deploy")
+	require.NoError(t, os.MkdirAll(filepath.Join(deployDir, "logs"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(deployDir, "app.ipa"), []byte("hello"), 0666))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(deployDir, "logs", "build.log"), []byte{}, 0666))
+
+	return tmpDir, func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}
+}
+
+func TestNewArtifactUploadManifest(t *testing.T) {
+	tmpDir, cleanup := createTestDeployDir(t)
+	defer cleanup()
+	deployDir := filepath.Join(tmpDir, "deploy")
+
+	now := time.Date(2016, time.October, 16, 10, 30, 0, 0, time.UTC)
+	tracked := []ArtifactModel{{Path: "app.ipa", Size: 5, SHA256: "tracked", StepIdx: 2, StepID: "xcode-archive"}}
+
+	manifest, err := NewArtifactUploadManifest("ci/release", BuildStatusSuccess, deployDir, tracked, 7, now)
+	require.NoError(t, err)
+	require.Regexp(t, "^ci_release-20161016T103000Z-[0-9a-f]{8}$", manifest.UploadID)
+	require.Equal(t, "ci/release", manifest.Workflow)
+	require.Equal(t, BuildStatusSuccess, manifest.BuildStatus)
+	require.Equal(t, 7, manifest.RetentionDays)
+	require.Equal(t, now.Add(7*24*time.Hour), *manifest.ExpiresAt)
+	require.Equal(t, []ArtifactModel{
+		{Path: "app.ipa", Size: 5, SHA256: "tracked", StepIdx: 2, StepID: "xcode-archive"},
+		{Path: "logs/build.log", Size: 0, SHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+	}, manifest.Artifacts)
+
+	otherManifest, err := NewArtifactUploadManifest("ci/release", BuildStatusSuccess, deployDir, tracked, 7, now)
+	require.NoError(t, err)
+	require.NotEqual(t, manifest.UploadID, otherManifest.UploadID)
+
+	manifest, err = NewArtifactUploadManifest("ci", BuildStatusFailed, deployDir, nil, 0, now)
+	require.NoError(t, err)
+	require.Nil(t, manifest.ExpiresAt)
+	require.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", manifest.Artifacts[0].SHA256)
+}
+
+func TestArtifactUploaders(t *testing.T) {
+	tmpDir, cleanup := createTestDeployDir(t)
+	defer cleanup()
+	deployDir := filepath.Join(tmpDir, "deploy")
+
+	now := time.Now()
+	manifest, err := NewArtifactUploadManifest("ci", BuildStatusSuccess, deployDir, nil, 3, now)
+	require.NoError(t, err)
+	uploadID := manifest.UploadID
+
+	t.Log("HTTP PUT")
+	{
+		server, uploads := newUploadRecorderServer(t)
+		defer server.Close()
+
+		require.NoError(t, os.Setenv(ArtifactUploadTokenEnvKey, "secret-token"))
+		defer func() {
+			require.NoError(t, os.Unsetenv(ArtifactUploadTokenEnvKey))
+		}()
+
+		uploader := NewHTTPArtifactUploader("http")
+		require.NoError(t, uploader.Upload(strings.TrimPrefix(server.URL, "http://")+"/upload", deployDir, manifest))
+
+		recorded := uploads()
+		require.Equal(t, 3, len(recorded))
+		require.Equal(t, "/upload/"+uploadID+"/app.ipa", recorded[0].Path)
+		require.Equal(t, "hello", recorded[0].Body)
+		require.Equal(t, "Bearer secret-token", recorded[0].Header.Get("Authorization"))
+		require.Equal(t, "3", recorded[0].Header.Get("X-Artifact-Retention-Days"))
+		require.Equal(t, manifest.ExpiresAt.UTC().Format(time.RFC3339), recorded[0].Header.Get("X-Artifact-Expires-At"))
+		require.Equal(t, "/upload/"+uploadID+"/logs/build.log", recorded[1].Path)
+		require.Equal(t, "", recorded[1].Body)
+		require.Equal(t, "/upload/"+uploadID+"/"+ArtifactUploadManifestFileName, recorded[2].Path)
+
+		var uploadedManifest ArtifactUploadManifestModel
+		require.NoError(t, json.Unmarshal([]byte(recorded[2].Body), &uploadedManifest))
+		require.Equal(t, uploadID, uploadedManifest.UploadID)
+		require.Equal(t, 2, len(uploadedManifest.Artifacts))
+	}
+
+	t.Log("HTTP PUT error")
+	{
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		uploader := NewHTTPArtifactUploader("http")
+		err := uploader.Upload(strings.TrimPrefix(server.URL, "http://"), deployDir, manifest)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "status code: 403")
+	}
+
+	t.Log("S3")
+	{
+		var mutex sync.Mutex
+		commands := []string{}
+		bodies := []string{}
+		uploader := &S3ArtifactUploader{
+			cli: &awsCLI{
+				run: func(stdin io.Reader, args ...string) (string, error) {
+					body := ""
+					if stdin != nil {
+						content, err := ioutil.ReadAll(stdin)
+						require.NoError(t, err)
+						body = string(content)
+					}
+
+					mutex.Lock()
+					defer mutex.Unlock()
+					commands = append(commands, strings.Join(args, " "))
+					bodies = append(bodies, body)
+					return "", nil
+				},
+			},
+		}
+		require.NoError(t, uploader.Upload("my-bucket/builds", deployDir, manifest))
+
+		require.Equal(t, 6, len(commands))
+		require.Equal(t, "s3 cp - s3://my-bucket/builds/"+uploadID+"/app.ipa --expected-size 5 --metadata sha256="+manifest.Artifacts[0].SHA256+",retention-days=3 --only-show-errors", commands[0])
+		require.Equal(t, "hello", bodies[0])
+		require.Equal(t, "s3api put-object-tagging --bucket my-bucket --key builds/"+uploadID+"/app.ipa --tagging TagSet=[{Key=retention-days,Value=3}]", commands[1])
+		require.True(t, strings.HasPrefix(commands[4], "s3 cp - s3://my-bucket/builds/"+uploadID+"/"+ArtifactUploadManifestFileName+" "))
+	}
+
+	t.Log("S3 error")
+	{
+		uploader := &S3ArtifactUploader{
+			cli: &awsCLI{
+				run: func(stdin io.Reader, args ...string) (string, error) {
+					return "", errors.New("aws s3 cp failed, error: Access Denied")
+				},
+			},
+		}
+		err := uploader.Upload("my-bucket", deployDir, manifest)
+		require.EqualError(t, err, "Failed to upload artifact (app.ipa), error: aws s3 cp failed, error: Access Denied")
+	}
+
+	t.Log("GCS")
+	{
+		server, uploads := newUploadRecorderServer(t)
+		defer server.Close()
+
+		uploader := &GCSArtifactUploader{
+			endpoint: server.URL,
+			client:   http.DefaultClient,
+			getToken: func() (string, error) { return "gcs-token", nil },
+		}
+		require.NoError(t, uploader.Upload("my-bucket", deployDir, manifest))
+
+		recorded := uploads()
+		require.Equal(t, 3, len(recorded))
+		require.Equal(t, "/my-bucket/"+uploadID+"/app.ipa", recorded[0].Path)
+		require.Equal(t, "Bearer gcs-token", recorded[0].Header.Get("Authorization"))
+		require.Equal(t, manifest.ExpiresAt.UTC().Format(time.RFC3339), recorded[0].Header.Get("X-Goog-Custom-Time"))
+	}
+
+	t.Log("local archive")
+	{
+		archiveDir := filepath.Join(tmpDir, "archive")
+		require.NoError(t, os.MkdirAll(archiveDir, 0777))
+
+		expiredAt := now.Add(-time.Hour)
+		expired := ArtifactUploadManifestModel{UploadID: "ci-expired", ExpiresAt: &expiredAt}
+		expiredBytes, err := json.Marshal(expired)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(archiveDir, "ci-expired.json"), expiredBytes, 0666))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(archiveDir, "ci-expired.tar.gz"), []byte("old"), 0666))
+
+		uploader := &LocalArtifactUploader{now: func() time.Time { return now }}
+		require.NoError(t, uploader.Upload(archiveDir, deployDir, manifest))
+
+		entries := []string{}
+		require.NoError(t, walkBundle(filepath.Join(archiveDir, uploadID+".tar.gz"), func(header *tar.Header, reader io.Reader) error {
+			entries = append(entries, header.Name)
+			return nil
+		}))
+		require.Equal(t, []string{"app.ipa", "logs/build.log", ArtifactUploadManifestFileName}, entries)
+
+		exist, err := pathutil.IsPathExists(filepath.Join(archiveDir, uploadID+".json"))
+		require.NoError(t, err)
+		require.True(t, exist)
+
+		exist, err = pathutil.IsPathExists(filepath.Join(archiveDir, "ci-expired.tar.gz"))
+		require.NoError(t, err)
+		require.False(t, exist)
+		exist, err = pathutil.IsPathExists(filepath.Join(archiveDir, "ci-expired.json"))
+		require.NoError(t, err)
+		require.False(t, exist)
+	}
+}
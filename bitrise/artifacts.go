@@ -0,0 +1,192 @@
+package bitrise
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/models"
+)
+
+// Artifacts: the files placed into the deploy dir (BITRISE_DEPLOY_DIR) by the steps of the run.
+// The runner creates the deploy dir (a new temp dir for every run, if BITRISE_DEPLOY_DIR is not set),
+// compares its content before and after every step, and lists the new or modified files,
+// with their size and SHA-256 checksum, in the summary and in the build summary JSON (see: --summary-path).
+// The files which were already in the deploy dir before the run are not listed, unless a step modifies them.
+
+// ArtifactModel is a file, placed into the deploy dir by a step
+type ArtifactModel struct {
+	// Path is the path of the file, relative to the deploy dir
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	SHA256  string `json:"sha256"`
+	StepIdx int    `json:"step_idx"`
+	StepID  string `json:"step_id"`
+}
+
+type artifactFileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// ArtifactTrackerModel tracks the files placed into the deploy dir by the steps
+type ArtifactTrackerModel struct {
+	DeployDir string
+
+	files     map[string]artifactFileState
+	artifacts map[string]ArtifactModel
+}
+
+// NewArtifactTracker creates the deploy dir, if it doesn't exist,
+// and starts tracking its content
+func NewArtifactTracker(deployDir string) (*ArtifactTrackerModel, error) {
+	if err := os.MkdirAll(deployDir, 0777); err != nil {
+		CheckDiskFull(err)
+		return nil, fmt.Errorf("Failed to create deploy dir (%s), error: %s", deployDir, err)
+	}
+
+	tracker := &ArtifactTrackerModel{
+		DeployDir: deployDir,
+		artifacts: map[string]ArtifactModel{},
+	}
+	files, err := tracker.scan()
+	if err != nil {
+		return nil, err
+	}
+	tracker.files = files
+	return tracker, nil
+}
+
+func (tracker *ArtifactTrackerModel) scan() (map[string]artifactFileState, error) {
+	files := map[string]artifactFileState{}
+	err := filepath.Walk(tracker.DeployDir, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPth, err := filepath.Rel(tracker.DeployDir, pth)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(relPth)] = artifactFileState{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return map[string]artifactFileState{}, fmt.Errorf("Failed to list deploy dir (%s), error: %s", tracker.DeployDir, err)
+	}
+	return files, nil
+}
+
+func fileSHA256(pth string) (string, error) {
+	file, err := os.Open(pth)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Warnf("Failed to close file (%s), error: %s", pth, err)
+		}
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func bytesSHA256(content []byte) string {
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
+
+// StartStep updates the state of the deploy dir, the changes made outside of the steps are not tracked
+func (tracker *ArtifactTrackerModel) StartStep() {
+	files, err := tracker.scan()
+	if err != nil {
+		log.Warnf("Failed to track artifacts, error: %s", err)
+		return
+	}
+	tracker.files = files
+}
+
+// FinishStep records the files, added or modified by the step in the deploy dir,
+// and forgets the artifacts removed by the step
+func (tracker *ArtifactTrackerModel) FinishStep(stepRunResult models.StepRunResultsModel) {
+	files, err := tracker.scan()
+	if err != nil {
+		log.Warnf("Failed to track artifacts, error: %s", err)
+		return
+	}
+
+	for pth := range tracker.artifacts {
+		if _, exist := files[pth]; !exist {
+			delete(tracker.artifacts, pth)
+		}
+	}
+
+	for pth, state := range files {
+		if previousState, exist := tracker.files[pth]; exist && previousState == state {
+			continue
+		}
+
+		checksum, err := fileSHA256(filepath.Join(tracker.DeployDir, filepath.FromSlash(pth)))
+		if err != nil {
+			log.Warnf("Failed to calculate the checksum of artifact (%s), error: %s", pth, err)
+		}
+		tracker.artifacts[pth] = ArtifactModel{
+			Path:    pth,
+			Size:    state.size,
+			SHA256:  checksum,
+			StepIdx: stepRunResult.Idx,
+			StepID:  stepRunResult.StepInfo.ID,
+		}
+	}
+
+	tracker.files = files
+}
+
+// Artifacts returns the artifacts of the run, ordered by path
+func (tracker *ArtifactTrackerModel) Artifacts() []ArtifactModel {
+	pths := []string{}
+	for pth := range tracker.artifacts {
+		pths = append(pths, pth)
+	}
+	sort.Strings(pths)
+
+	artifacts := []ArtifactModel{}
+	for _, pth := range pths {
+		artifacts = append(artifacts, tracker.artifacts[pth])
+	}
+	return artifacts
+}
+
+func formatArtifactSize(size int64) string {
+	switch {
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+// ArtifactsReport returns the list of the artifacts, with their size, checksum and the step which created them
+func ArtifactsReport(deployDir string, artifacts []ArtifactModel) string {
+	lines := []string{fmt.Sprintf("Artifacts (%s):", deployDir)}
+	for _, artifact := range artifacts {
+		lines = append(lines, fmt.Sprintf("  - %s (%s, sha256: %s, step: %s)",
+			artifact.Path, formatArtifactSize(artifact.Size), artifact.SHA256, artifact.StepID))
+	}
+	return strings.Join(lines, "\n")
+}
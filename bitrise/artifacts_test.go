@@ -0,0 +1,86 @@
+package bitrise
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/pathutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArtifactTracker(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__artifacts__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	deployDir := filepath.Join(tmpDir, "deploy")
+	require.NoError(t, os.MkdirAll(deployDir, 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(deployDir, "preexisting.txt"), []byte("old"), 0666))
+
+	stepResult := func(idx int, id string) models.StepRunResultsModel {
+		return models.StepRunResultsModel{Idx: idx, StepInfo: stepmanModels.StepInfoModel{ID: id}}
+	}
+
+	tracker, err := NewArtifactTracker(deployDir)
+	require.NoError(t, err)
+
+	t.Log("new files of the step")
+	{
+		tracker.StartStep()
+		require.NoError(t, os.MkdirAll(filepath.Join(deployDir, "logs"), 0777))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(deployDir, "app.ipa"), []byte("hello"), 0666))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(deployDir, "logs", "build.log"), []byte("log"), 0666))
+		tracker.FinishStep(stepResult(0, "xcode-archive"))
+
+		require.Equal(t, []ArtifactModel{
+			{Path: "app.ipa", Size: 5, SHA256: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", StepIdx: 0, StepID: "xcode-archive"},
+			{Path: "logs/build.log", Size: 3, SHA256: "836ff184e7b41b1e13cb5fd89fa1de98dbbab99e9d2918913ff43b86a5c7c213", StepIdx: 0, StepID: "xcode-archive"},
+		}, tracker.Artifacts())
+	}
+
+	t.Log("modified and removed files")
+	{
+		tracker.StartStep()
+		require.NoError(t, os.Remove(filepath.Join(deployDir, "logs", "build.log")))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(deployDir, "preexisting.txt"), []byte("new content"), 0666))
+		require.NoError(t, os.Chtimes(filepath.Join(deployDir, "preexisting.txt"), time.Now(), time.Now().Add(time.Hour)))
+		tracker.FinishStep(stepResult(1, "script"))
+
+		artifacts := tracker.Artifacts()
+		require.Equal(t, 2, len(artifacts))
+		require.Equal(t, "app.ipa", artifacts[0].Path)
+		require.Equal(t, "xcode-archive", artifacts[0].StepID)
+		require.Equal(t, "preexisting.txt", artifacts[1].Path)
+		require.Equal(t, int64(11), artifacts[1].Size)
+		require.Equal(t, 1, artifacts[1].StepIdx)
+		require.Equal(t, "script", artifacts[1].StepID)
+	}
+
+	t.Log("report")
+	{
+		require.Equal(t, "Artifacts (/deploy):\n"+
+			"  - app.ipa (5 B, sha256: abc, step: xcode-archive)\n"+
+			"  - big.zip (2.5 MB, sha256: def, step: script)",
+			ArtifactsReport("/deploy", []ArtifactModel{
+				{Path: "app.ipa", Size: 5, SHA256: "abc", StepID: "xcode-archive"},
+				{Path: "big.zip", Size: 2*1024*1024 + 512*1024, SHA256: "def", StepID: "script"},
+			}))
+	}
+
+	t.Log("creates the deploy dir")
+	{
+		newDeployDir := filepath.Join(tmpDir, "new", "deploy")
+		_, err := NewArtifactTracker(newDeployDir)
+		require.NoError(t, err)
+		exist, err := pathutil.IsDirExists(newDeployDir)
+		require.NoError(t, err)
+		require.True(t, exist)
+	}
+}
@@ -0,0 +1,156 @@
+package bitrise
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"gopkg.in/yaml.v2"
+)
+
+// BenchFixtureModel describes the fixed environment of a step benchmark.
+type BenchFixtureModel struct {
+	Envs   []envmanModels.EnvironmentItemModel `json:"envs,omitempty" yaml:"envs,omitempty"`
+	Inputs []envmanModels.EnvironmentItemModel `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+}
+
+// BenchIterationModel ...
+type BenchIterationModel struct {
+	Duration  time.Duration `json:"duration" yaml:"duration"`
+	Success   bool          `json:"success" yaml:"success"`
+	UserCPU   time.Duration `json:"user_cpu" yaml:"user_cpu"`
+	SystemCPU time.Duration `json:"system_cpu" yaml:"system_cpu"`
+}
+
+// BenchDurationStatsModel ...
+type BenchDurationStatsModel struct {
+	Min    time.Duration `json:"min" yaml:"min"`
+	Max    time.Duration `json:"max" yaml:"max"`
+	Mean   time.Duration `json:"mean" yaml:"mean"`
+	Median time.Duration `json:"median" yaml:"median"`
+	P90    time.Duration `json:"p90" yaml:"p90"`
+	StdDev time.Duration `json:"std_dev" yaml:"std_dev"`
+}
+
+// BenchResultModel ...
+type BenchResultModel struct {
+	Step          string                  `json:"step" yaml:"step"`
+	Iterations    []BenchIterationModel   `json:"iterations" yaml:"iterations"`
+	FailedCount   int                     `json:"failed_count" yaml:"failed_count"`
+	Duration      BenchDurationStatsModel `json:"duration" yaml:"duration"`
+	MeanUserCPU   time.Duration           `json:"mean_user_cpu" yaml:"mean_user_cpu"`
+	MeanSystemCPU time.Duration           `json:"mean_system_cpu" yaml:"mean_system_cpu"`
+	// MaxRSSInKB is the largest resident set size of the step processes, over all iterations
+	MaxRSSInKB int64 `json:"max_rss_kb" yaml:"max_rss_kb"`
+}
+
+// ReadBenchFixture ...
+func ReadBenchFixture(pth string) (BenchFixtureModel, error) {
+	bytes, err := fileutil.ReadBytesFromFile(pth)
+	if err != nil {
+		return BenchFixtureModel{}, err
+	}
+
+	fixture := BenchFixtureModel{}
+	if err := yaml.Unmarshal(bytes, &fixture); err != nil {
+		return BenchFixtureModel{}, fmt.Errorf("failed to parse fixture (%s), error: %s", pth, err)
+	}
+
+	for _, envs := range [][]envmanModels.EnvironmentItemModel{fixture.Envs, fixture.Inputs} {
+		for _, env := range envs {
+			if err := env.Normalize(); err != nil {
+				return BenchFixtureModel{}, fmt.Errorf("failed to normalize fixture env, error: %s", err)
+			}
+			if err := env.FillMissingDefaults(); err != nil {
+				return BenchFixtureModel{}, fmt.Errorf("failed to fill fixture env, error: %s", err)
+			}
+			if err := env.Validate(); err != nil {
+				return BenchFixtureModel{}, fmt.Errorf("failed to validate fixture env, error: %s", err)
+			}
+		}
+	}
+
+	return fixture, nil
+}
+
+// CalculateDurationStats ...
+func CalculateDurationStats(durations []time.Duration) BenchDurationStatsModel {
+	if len(durations) == 0 {
+		return BenchDurationStatsModel{}
+	}
+
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Sort(durationSlice(sorted))
+
+	sum := time.Duration(0)
+	for _, duration := range sorted {
+		sum += duration
+	}
+	mean := sum / time.Duration(len(sorted))
+
+	variance := 0.0
+	for _, duration := range sorted {
+		diff := float64(duration - mean)
+		variance += diff * diff
+	}
+	variance = variance / float64(len(sorted))
+
+	return BenchDurationStatsModel{
+		Min:    sorted[0],
+		Max:    sorted[len(sorted)-1],
+		Mean:   mean,
+		Median: percentile(sorted, 50),
+		P90:    percentile(sorted, 90),
+		StdDev: time.Duration(math.Sqrt(variance)),
+	}
+}
+
+// NewBenchResult ...
+func NewBenchResult(step string, iterations []BenchIterationModel, maxRSSInKB int64) BenchResultModel {
+	result := BenchResultModel{
+		Step:       step,
+		Iterations: iterations,
+		MaxRSSInKB: maxRSSInKB,
+	}
+
+	if len(iterations) == 0 {
+		return result
+	}
+
+	durations := []time.Duration{}
+	userCPU := time.Duration(0)
+	systemCPU := time.Duration(0)
+	for _, iteration := range iterations {
+		if !iteration.Success {
+			result.FailedCount++
+		}
+		durations = append(durations, iteration.Duration)
+		userCPU += iteration.UserCPU
+		systemCPU += iteration.SystemCPU
+	}
+
+	result.Duration = CalculateDurationStats(durations)
+	result.MeanUserCPU = userCPU / time.Duration(len(iterations))
+	result.MeanSystemCPU = systemCPU / time.Duration(len(iterations))
+
+	return result
+}
+
+// percentile expects a sorted slice, uses the nearest-rank method
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := int(math.Ceil(float64(p) / 100.0 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+type durationSlice []time.Duration
+
+func (s durationSlice) Len() int           { return len(s) }
+func (s durationSlice) Less(i, j int) bool { return s[i] < s[j] }
+func (s durationSlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -0,0 +1,101 @@
+package bitrise
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateDurationStats(t *testing.T) {
+	t.Log("empty")
+	{
+		stats := CalculateDurationStats([]time.Duration{})
+		require.Equal(t, BenchDurationStatsModel{}, stats)
+	}
+
+	t.Log("single duration")
+	{
+		stats := CalculateDurationStats([]time.Duration{2 * time.Second})
+		require.Equal(t, 2*time.Second, stats.Min)
+		require.Equal(t, 2*time.Second, stats.Max)
+		require.Equal(t, 2*time.Second, stats.Mean)
+		require.Equal(t, 2*time.Second, stats.Median)
+		require.Equal(t, 2*time.Second, stats.P90)
+		require.Equal(t, time.Duration(0), stats.StdDev)
+	}
+
+	t.Log("unordered durations")
+	{
+		durations := []time.Duration{}
+		for _, sec := range []int{5, 1, 10, 3, 2, 4, 9, 7, 6, 8} {
+			durations = append(durations, time.Duration(sec)*time.Second)
+		}
+
+		stats := CalculateDurationStats(durations)
+		require.Equal(t, 1*time.Second, stats.Min)
+		require.Equal(t, 10*time.Second, stats.Max)
+		require.Equal(t, 5500*time.Millisecond, stats.Mean)
+		require.Equal(t, 5*time.Second, stats.Median)
+		require.Equal(t, 9*time.Second, stats.P90)
+		require.Equal(t, time.Duration(2872281323), stats.StdDev)
+
+		// input is not modified
+		require.Equal(t, 5*time.Second, durations[0])
+	}
+}
+
+func TestNewBenchResult(t *testing.T) {
+	iterations := []BenchIterationModel{
+		BenchIterationModel{Duration: 1 * time.Second, Success: true, UserCPU: 200 * time.Millisecond, SystemCPU: 100 * time.Millisecond},
+		BenchIterationModel{Duration: 3 * time.Second, Success: false, UserCPU: 400 * time.Millisecond, SystemCPU: 300 * time.Millisecond},
+	}
+
+	result := NewBenchResult("script", iterations, 1024)
+	require.Equal(t, "script", result.Step)
+	require.Equal(t, 1, result.FailedCount)
+	require.Equal(t, 2*time.Second, result.Duration.Mean)
+	require.Equal(t, 300*time.Millisecond, result.MeanUserCPU)
+	require.Equal(t, 200*time.Millisecond, result.MeanSystemCPU)
+	require.Equal(t, int64(1024), result.MaxRSSInKB)
+}
+
+func TestReadBenchFixture(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("bench")
+	require.NoError(t, err)
+
+	t.Log("valid fixture")
+	{
+		fixturePth := filepath.Join(tmpDir, "fixture.yml")
+		require.NoError(t, fileutil.WriteStringToFile(fixturePth, `envs:
+- GREETING: hello
+inputs:
+- content: echo "$GREETING"
+`))
+
+		fixture, err := ReadBenchFixture(fixturePth)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(fixture.Envs))
+		require.Equal(t, 1, len(fixture.Inputs))
+
+		key, value, err := fixture.Inputs[0].GetKeyValuePair()
+		require.NoError(t, err)
+		require.Equal(t, "content", key)
+		require.Equal(t, `echo "$GREETING"`, value)
+	}
+
+	t.Log("invalid fixture")
+	{
+		fixturePth := filepath.Join(tmpDir, "invalid.yml")
+		require.NoError(t, fileutil.WriteStringToFile(fixturePth, `inputs:
+- content: echo
+  other: value
+`))
+
+		_, err := ReadBenchFixture(fixturePth)
+		require.Error(t, err)
+	}
+}
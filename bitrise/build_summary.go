@@ -0,0 +1,131 @@
+package bitrise
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bitrise-io/bitrise/models"
+)
+
+const (
+	// BuildStatusSuccess ...
+	BuildStatusSuccess = "success"
+	// BuildStatusFailed ...
+	BuildStatusFailed = "failed"
+	// BuildStatusAborted ...
+	BuildStatusAborted = "aborted"
+)
+
+// BuildSummaryStepModel is the machine-readable run result of a step
+type BuildSummaryStepModel struct {
+	Idx        int     `json:"idx"`
+	ID         string  `json:"id"`
+	Title      string  `json:"title"`
+	Version    string  `json:"version"`
+	StepLib    string  `json:"steplib,omitempty"`
+	Status     string  `json:"status"`
+	StatusCode int     `json:"status_code"`
+	ExitCode   int     `json:"exit_code"`
+	RunTime    float64 `json:"run_time_sec"`
+	Error      string  `json:"error,omitempty"`
+	// Deprecation is the deprecation notice of the step (see: StepDeprecationNotice)
+	Deprecation string `json:"deprecation,omitempty"`
+	// SkipReason is the reason of skipping the step (see: StepSkipReason)
+	SkipReason string `json:"skip_reason,omitempty"`
+}
+
+// BuildSummaryModel is the machine-readable summary of the build,
+// written at the end of the run (see: --summary-path), for the wrapper tools.
+type BuildSummaryModel struct {
+	Workflow  string                  `json:"workflow"`
+	Status    string                  `json:"status"`
+	StartTime time.Time               `json:"start_time"`
+	TotalTime float64                 `json:"total_time_sec"`
+	Steps     []BuildSummaryStepModel `json:"steps"`
+	// Artifacts are the files placed into the deploy dir by the steps (see: ArtifactTrackerModel)
+	Artifacts []ArtifactModel `json:"artifacts,omitempty"`
+	// TestResults is the aggregated test summary of the test result dir (see: CollectTestResults)
+	TestResults *TestResultsSummaryModel `json:"test_results,omitempty"`
+}
+
+// StepRunStatusName ...
+func StepRunStatusName(status int) string {
+	switch status {
+	case models.StepRunStatusCodeSuccess:
+		return "success"
+	case models.StepRunStatusCodeFailed:
+		return "failed"
+	case models.StepRunStatusCodeFailedSkippable:
+		return "failed_skippable"
+	case models.StepRunStatusCodeSkipped:
+		return "skipped"
+	case models.StepRunStatusCodeSkippedWithRunIf:
+		return "skipped_with_run_if"
+	}
+	return "unknown"
+}
+
+// BuildStatus returns the status name of the build: success, failed or aborted
+func BuildStatus(buildRunResults models.BuildRunResultsModel) string {
+	if buildRunResults.IsAborted {
+		return BuildStatusAborted
+	} else if buildRunResults.IsBuildFailed() {
+		return BuildStatusFailed
+	}
+	return BuildStatusSuccess
+}
+
+// NewBuildSummary ...
+func NewBuildSummary(workflowID string, buildRunResults models.BuildRunResultsModel, totalTime time.Duration) BuildSummaryModel {
+	status := BuildStatus(buildRunResults)
+
+	steps := []BuildSummaryStepModel{}
+	for _, result := range buildRunResults.OrderedResults() {
+		step := BuildSummaryStepModel{
+			Idx:        result.Idx,
+			ID:         result.StepInfo.ID,
+			Title:      result.StepInfo.Title,
+			Version:    result.StepInfo.Version,
+			StepLib:    result.StepInfo.StepLib,
+			Status:     StepRunStatusName(result.Status),
+			StatusCode: result.Status,
+			ExitCode:   result.ExitCode,
+			RunTime:    result.RunTime.Seconds(),
+
+			Deprecation: StepDeprecationNotice(result.StepInfo),
+			SkipReason:  result.SkipReason,
+		}
+		if result.Error != nil {
+			step.Error = ActivePolicy().Redact(result.Error.Error())
+		}
+		steps = append(steps, step)
+	}
+
+	return BuildSummaryModel{
+		Workflow:  workflowID,
+		Status:    status,
+		StartTime: buildRunResults.StartTime,
+		TotalTime: totalTime.Seconds(),
+		Steps:     steps,
+	}
+}
+
+// WriteBuildSummary ...
+func WriteBuildSummary(pth string, summary BuildSummaryModel) error {
+	bytes, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to serialize build summary, error: %s", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(pth), 0777); err != nil {
+		if CheckDiskFull(err) {
+			return ErrDiskFull
+		}
+		return fmt.Errorf("Failed to create build summary dir, error: %s", err)
+	}
+
+	return WriteEssentialBytesToFile(pth, bytes)
+}
@@ -0,0 +1,77 @@
+package bitrise
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBuildSummary(t *testing.T) {
+	startTime := time.Date(2016, 10, 1, 12, 0, 0, 0, time.UTC)
+	buildRunResults := models.BuildRunResultsModel{
+		StartTime: startTime,
+		SuccessSteps: []models.StepRunResultsModel{
+			models.StepRunResultsModel{
+				StepInfo: stepmanModels.StepInfoModel{ID: "script", Title: "Hello", Version: "1.1.0"},
+				Status:   models.StepRunStatusCodeSuccess,
+				Idx:      0,
+				RunTime:  2 * time.Second,
+			},
+		},
+		FailedSteps: []models.StepRunResultsModel{
+			models.StepRunResultsModel{
+				StepInfo: stepmanModels.StepInfoModel{ID: "script", Title: "Fail", Version: "1.1.0"},
+				Status:   models.StepRunStatusCodeFailed,
+				Idx:      1,
+				RunTime:  500 * time.Millisecond,
+				Error:    errors.New("exit status 2"),
+				ExitCode: 2,
+			},
+		},
+	}
+
+	summary := NewBuildSummary("primary", buildRunResults, 3*time.Second)
+	require.Equal(t, BuildSummaryModel{
+		Workflow:  "primary",
+		Status:    BuildStatusFailed,
+		StartTime: startTime,
+		TotalTime: 3,
+		Steps: []BuildSummaryStepModel{
+			BuildSummaryStepModel{Idx: 0, ID: "script", Title: "Hello", Version: "1.1.0", Status: "success", StatusCode: 0, RunTime: 2},
+			BuildSummaryStepModel{Idx: 1, ID: "script", Title: "Fail", Version: "1.1.0", Status: "failed", StatusCode: 1, ExitCode: 2, RunTime: 0.5, Error: "exit status 2"},
+		},
+	}, summary)
+
+	buildRunResults.IsAborted = true
+	require.Equal(t, BuildStatusAborted, NewBuildSummary("primary", buildRunResults, 3*time.Second).Status)
+
+	require.Equal(t, BuildStatusSuccess, NewBuildSummary("primary", models.BuildRunResultsModel{}, 0).Status)
+}
+
+func TestWriteBuildSummary(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("build_summary")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	pth := filepath.Join(tmpDir, "reports", "summary.json")
+	summary := BuildSummaryModel{Workflow: "primary", Status: BuildStatusSuccess, Steps: []BuildSummaryStepModel{}}
+	require.NoError(t, WriteBuildSummary(pth, summary))
+
+	bytes, err := fileutil.ReadBytesFromFile(pth)
+	require.NoError(t, err)
+
+	var written BuildSummaryModel
+	require.NoError(t, json.Unmarshal(bytes, &written))
+	require.Equal(t, summary, written)
+}
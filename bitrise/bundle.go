@@ -0,0 +1,357 @@
+package bitrise
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/plugins"
+	"github.com/bitrise-io/bitrise/tools"
+	"github.com/bitrise-io/bitrise/utils"
+	"github.com/bitrise-io/bitrise/version"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// Setup bundle (see: bitrise bundle create, bitrise setup --from-bundle): a .tar.gz of the core tools (stepman, envman),
+// the installed plugins and the StepLib snapshot (stepman's home dir) of a provisioned host,
+// which can be installed on an air-gapped host, without any network access.
+// The bundle's manifest.json describes the platform it was created on, it can be installed only on the same platform.
+
+const (
+	// BundleManifestFileName ...
+	BundleManifestFileName = "manifest.json"
+
+	bundleFormatVersion = "1"
+
+	bundleToolsDir   = "tools"
+	bundlePluginsDir = "plugins"
+	bundleStepmanDir = "stepman"
+)
+
+// bundleTools are the core tools included in the bundle
+var bundleTools = []string{"stepman", "envman"}
+
+// BundleManifestModel ...
+type BundleManifestModel struct {
+	FormatVersion  string    `json:"format_version"`
+	BitriseVersion string    `json:"bitrise_version"`
+	OS             string    `json:"os"`
+	Arch           string    `json:"arch"`
+	CreatedAt      time.Time `json:"created_at"`
+	Tools          []string  `json:"tools"`
+	Plugins        []string  `json:"plugins"`
+}
+
+// bundleDirs maps the directories of the bundle to the directories of the host
+func bundleDirs() map[string]string {
+	return map[string]string{
+		bundleToolsDir:   configs.GetBitriseToolsDirPath(),
+		bundlePluginsDir: plugins.GetPluginsDir(),
+		bundleStepmanDir: tools.StepmanHomeDirPath(),
+	}
+}
+
+// CreateBundle writes the bundle of the host's core tools, plugins and StepLib snapshot into the given path
+func CreateBundle(pth string) (BundleManifestModel, error) {
+	manifest := BundleManifestModel{
+		FormatVersion:  bundleFormatVersion,
+		BitriseVersion: version.VERSION,
+		OS:             runtime.GOOS,
+		Arch:           runtime.GOARCH,
+		CreatedAt:      time.Now(),
+		Tools:          bundleTools,
+		Plugins:        []string{},
+	}
+
+	pluginList, err := plugins.InstalledPluginList()
+	if err != nil {
+		return BundleManifestModel{}, fmt.Errorf("Failed to list installed plugins, error: %s", err)
+	}
+	for _, plugin := range pluginList {
+		manifest.Plugins = append(manifest.Plugins, plugin.Name)
+	}
+
+	file, err := os.Create(pth)
+	if err != nil {
+		return BundleManifestModel{}, fmt.Errorf("Failed to create bundle file (%s), error: %s", pth, err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Warnf("Failed to close bundle file (%s), error: %s", pth, err)
+		}
+	}()
+
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return BundleManifestModel{}, fmt.Errorf("Failed to serialize bundle manifest, error: %s", err)
+	}
+	if err := writeBundleFile(tarWriter, BundleManifestFileName, 0644, manifestBytes); err != nil {
+		return BundleManifestModel{}, err
+	}
+
+	for _, tool := range bundleTools {
+		toolPth, err := utils.CheckProgramInstalledPath(tool)
+		if err != nil {
+			return BundleManifestModel{}, fmt.Errorf("%s is not installed, run: bitrise setup", tool)
+		}
+		if err := addBundleFile(tarWriter, bundleToolsDir+"/"+tools.ExecutableFileName(tool), toolPth); err != nil {
+			return BundleManifestModel{}, err
+		}
+	}
+
+	for _, name := range []string{bundlePluginsDir, bundleStepmanDir} {
+		if err := addBundleDir(tarWriter, name, bundleDirs()[name]); err != nil {
+			return BundleManifestModel{}, err
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return BundleManifestModel{}, fmt.Errorf("Failed to write bundle, error: %s", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return BundleManifestModel{}, fmt.Errorf("Failed to write bundle, error: %s", err)
+	}
+
+	return manifest, nil
+}
+
+func writeBundleFile(tarWriter *tar.Writer, name string, mode int64, content []byte) error {
+	header := &tar.Header{
+		Name:     name,
+		Mode:     mode,
+		Size:     int64(len(content)),
+		ModTime:  time.Now(),
+		Typeflag: tar.TypeReg,
+	}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return fmt.Errorf("Failed to write bundle entry (%s), error: %s", name, err)
+	}
+	if _, err := tarWriter.Write(content); err != nil {
+		return fmt.Errorf("Failed to write bundle entry (%s), error: %s", name, err)
+	}
+	return nil
+}
+
+// addBundleFile adds the file (following the symlinks) as the given entry of the bundle
+func addBundleFile(tarWriter *tar.Writer, name, pth string) error {
+	info, err := os.Stat(pth)
+	if err != nil {
+		return fmt.Errorf("Failed to get file info (%s), error: %s", pth, err)
+	}
+
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return fmt.Errorf("Failed to create bundle entry (%s), error: %s", name, err)
+	}
+	header.Name = name
+
+	file, err := os.Open(pth)
+	if err != nil {
+		return fmt.Errorf("Failed to open file (%s), error: %s", pth, err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Warnf("Failed to close file (%s), error: %s", pth, err)
+		}
+	}()
+
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return fmt.Errorf("Failed to write bundle entry (%s), error: %s", name, err)
+	}
+	if _, err := io.Copy(tarWriter, file); err != nil {
+		return fmt.Errorf("Failed to write bundle entry (%s), error: %s", name, err)
+	}
+	return nil
+}
+
+// addBundleDir adds the content of the directory under the given directory of the bundle,
+// a not existing directory is added as an empty one.
+func addBundleDir(tarWriter *tar.Writer, name, dir string) error {
+	if exists, err := pathutil.IsDirExists(dir); err != nil {
+		return fmt.Errorf("Failed to check directory (%s), error: %s", dir, err)
+	} else if !exists {
+		log.Warnf("%s not found, the bundle contains an empty %s directory", dir, name)
+		return tarWriter.WriteHeader(&tar.Header{Name: name + "/", Mode: 0755, ModTime: time.Now(), Typeflag: tar.TypeDir})
+	}
+
+	return filepath.Walk(dir, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPth, err := filepath.Rel(dir, pth)
+		if err != nil {
+			return err
+		}
+		entryName := name
+		if relPth != "." {
+			entryName = name + "/" + filepath.ToSlash(relPth)
+		}
+
+		switch {
+		case info.IsDir():
+			header, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			header.Name = entryName + "/"
+			return tarWriter.WriteHeader(header)
+		case info.Mode().IsRegular():
+			return addBundleFile(tarWriter, entryName, pth)
+		}
+
+		log.Warnf("Skipping %s, only directories and regular files can be bundled", pth)
+		return nil
+	})
+}
+
+// ReadBundleManifest returns the manifest of the bundle
+func ReadBundleManifest(pth string) (BundleManifestModel, error) {
+	manifest := BundleManifestModel{}
+	found := false
+
+	err := walkBundle(pth, func(header *tar.Header, reader io.Reader) error {
+		if header.Name != BundleManifestFileName {
+			return nil
+		}
+
+		manifestBytes, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+			return fmt.Errorf("Failed to parse bundle manifest, error: %s", err)
+		}
+		found = true
+		return nil
+	})
+	if err != nil {
+		return BundleManifestModel{}, err
+	}
+	if !found {
+		return BundleManifestModel{}, fmt.Errorf("invalid bundle (%s): %s not found", pth, BundleManifestFileName)
+	}
+	return manifest, nil
+}
+
+func walkBundle(pth string, fn func(header *tar.Header, reader io.Reader) error) error {
+	file, err := os.Open(pth)
+	if err != nil {
+		return fmt.Errorf("Failed to open bundle (%s), error: %s", pth, err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Warnf("Failed to close bundle (%s), error: %s", pth, err)
+		}
+	}()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("Failed to read bundle (%s), error: %s", pth, err)
+	}
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to read bundle (%s), error: %s", pth, err)
+		}
+		if err := fn(header, tarReader); err != nil {
+			return err
+		}
+	}
+}
+
+// validateBundleManifest checks whether the bundle can be installed on this host
+func validateBundleManifest(manifest BundleManifestModel) error {
+	if manifest.FormatVersion != bundleFormatVersion {
+		return fmt.Errorf("unsupported bundle format version (%s), supported: %s", manifest.FormatVersion, bundleFormatVersion)
+	}
+	if manifest.OS != runtime.GOOS || manifest.Arch != runtime.GOARCH {
+		return fmt.Errorf("the bundle was created on %s/%s, it can't be installed on %s/%s", manifest.OS, manifest.Arch, runtime.GOOS, runtime.GOARCH)
+	}
+	return nil
+}
+
+// extractBundle extracts the content of the bundle's directories into the mapped directories,
+// the files can't be outside of these directories.
+func extractBundle(pth string, dirs map[string]string) error {
+	return walkBundle(pth, func(header *tar.Header, reader io.Reader) error {
+		name := strings.TrimPrefix(header.Name, "./")
+		if name == BundleManifestFileName {
+			return nil
+		}
+
+		split := strings.SplitN(name, "/", 2)
+		targetDir, found := dirs[split[0]]
+		if !found {
+			log.Debugf("Skipping (%s) of the bundle, unknown directory", header.Name)
+			return nil
+		}
+		if len(split) == 1 || filepath.Clean("/"+split[1]) == "/" {
+			return os.MkdirAll(targetDir, 0755)
+		}
+
+		filePth, err := stepArchiveFilePath(targetDir, split[1])
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			return os.MkdirAll(filePth, 0755)
+		case tar.TypeReg, tar.TypeRegA:
+			return writeStepArchiveFile(filePth, os.FileMode(header.Mode)&0777, reader)
+		}
+		log.Debugf("Skipping (%s) of the bundle, unsupported file type", header.Name)
+		return nil
+	})
+}
+
+// RunSetupFromBundle installs the core tools, the plugins and the StepLib snapshot of the bundle,
+// without any network access.
+func RunSetupFromBundle(appVersion, pth string) error {
+	log.Infoln("Setup from bundle:", pth)
+
+	manifest, err := ReadBundleManifest(pth)
+	if err != nil {
+		return err
+	}
+	if err := validateBundleManifest(manifest); err != nil {
+		return err
+	}
+	log.Infof("Bundle created by bitrise %s at %s", manifest.BitriseVersion, manifest.CreatedAt.Format(time.RFC3339))
+
+	if err := extractBundle(pth, bundleDirs()); err != nil {
+		return fmt.Errorf("Failed to install bundle, error: %s", err)
+	}
+
+	log.Infoln("Checking Bitrise Core tools...")
+	if err := checkIsBitriseToolInstalled("envman", minEnvmanVersion, false); err != nil {
+		return fmt.Errorf("Envman of the bundle is not usable: %s", err)
+	}
+	if err := checkIsBitriseToolInstalled("stepman", minStepmanVersion, false); err != nil {
+		return fmt.Errorf("Stepman of the bundle is not usable: %s", err)
+	}
+	for _, plugin := range manifest.Plugins {
+		log.Infof(" * plugin installed: %s", plugin)
+	}
+
+	return finishSetup(appVersion)
+}
@@ -0,0 +1,108 @@
+package bitrise
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestBundle(t *testing.T, pth string, manifest []byte, dirs map[string]string, files map[string]string) {
+	file, err := os.Create(pth)
+	require.NoError(t, err)
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	require.NoError(t, writeBundleFile(tarWriter, BundleManifestFileName, 0644, manifest))
+	for name, dir := range dirs {
+		require.NoError(t, addBundleDir(tarWriter, name, dir))
+	}
+	for name, content := range files {
+		require.NoError(t, writeBundleFile(tarWriter, name, 0644, []byte(content)))
+	}
+
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+	require.NoError(t, file.Close())
+}
+
+func TestBundle(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__bundle__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	sourceDir := filepath.Join(tmpDir, "source")
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "step_collections", "1"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(sourceDir, "routing.json"), []byte(`{"steplib":"1"}`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(sourceDir, "step_collections", "1", "spec.json"), []byte("{}"), 0755))
+
+	manifest := `{"format_version":"1","bitrise_version":"1.0.0","os":"` + runtime.GOOS + `","arch":"` + runtime.GOARCH + `","plugins":["analytics"]}`
+	bundlePth := filepath.Join(tmpDir, "bundle.tar.gz")
+	writeTestBundle(t, bundlePth, []byte(manifest), map[string]string{bundleStepmanDir: sourceDir}, map[string]string{
+		bundleToolsDir + "/envman":      "envman",
+		bundlePluginsDir + "/../../bad": "outside of the plugins dir",
+		"unknown/file":                  "unknown dir",
+	})
+
+	t.Log("manifest")
+	{
+		bundleManifest, err := ReadBundleManifest(bundlePth)
+		require.NoError(t, err)
+		require.Equal(t, "1.0.0", bundleManifest.BitriseVersion)
+		require.Equal(t, []string{"analytics"}, bundleManifest.Plugins)
+		require.NoError(t, validateBundleManifest(bundleManifest))
+
+		bundleManifest.OS = "not-" + runtime.GOOS
+		require.Error(t, validateBundleManifest(bundleManifest))
+	}
+
+	t.Log("extract")
+	{
+		targetDir := filepath.Join(tmpDir, "target")
+		dirs := map[string]string{
+			bundleToolsDir:   filepath.Join(targetDir, "tools"),
+			bundlePluginsDir: filepath.Join(targetDir, "plugins"),
+			bundleStepmanDir: filepath.Join(targetDir, "stepman"),
+		}
+		require.NoError(t, extractBundle(bundlePth, dirs))
+
+		for pth, content := range map[string]string{
+			filepath.Join(targetDir, "tools", "envman"):                               "envman",
+			filepath.Join(targetDir, "stepman", "routing.json"):                       `{"steplib":"1"}`,
+			filepath.Join(targetDir, "stepman", "step_collections", "1", "spec.json"): "{}",
+			filepath.Join(targetDir, "plugins", "bad"):                                "outside of the plugins dir",
+		} {
+			bytes, err := ioutil.ReadFile(pth)
+			require.NoError(t, err)
+			require.Equal(t, content, string(bytes))
+		}
+
+		info, err := os.Stat(filepath.Join(targetDir, "stepman", "step_collections", "1", "spec.json"))
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+		exists, err := pathutil.IsPathExists(filepath.Join(tmpDir, "bad"))
+		require.NoError(t, err)
+		require.False(t, exists)
+	}
+
+	t.Log("not a bundle")
+	{
+		pth := filepath.Join(tmpDir, "not-a-bundle.tar.gz")
+		require.NoError(t, ioutil.WriteFile(pth, []byte("not a bundle"), 0644))
+
+		_, err := ReadBundleManifest(pth)
+		require.Error(t, err)
+
+		_, err = ReadBundleManifest(filepath.Join(tmpDir, "missing.tar.gz"))
+		require.Error(t, err)
+	}
+}
@@ -0,0 +1,158 @@
+package bitrise
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/version"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+const (
+	// configCacheFormatVersion should be bumped if the cached data changes
+	configCacheFormatVersion = "8"
+	configCacheMaxAge        = 7 * 24 * time.Hour
+)
+
+// configCacheItemModel is a parsed, validated config with its validation warnings
+type configCacheItemModel struct {
+	Warnings []string                `json:"warnings"`
+	Config   models.BitriseDataModel `json:"config"`
+}
+
+// ConfigCacheKey returns the cache key of the config content.
+// The CLI version is part of the key, as the parsing and validation rules can change between versions.
+func ConfigCacheKey(configBytes []byte, isJSON bool) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n%t\n", configCacheFormatVersion, version.VERSION, isJSON)
+	hash.Write(configBytes)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func configCacheItemPath(cacheDir, key string) string {
+	return filepath.Join(cacheDir, key+".json")
+}
+
+// ReadCachedConfig returns the cached config of the given key, and false if it's not cached.
+func ReadCachedConfig(cacheDir, key string) (models.BitriseDataModel, []string, bool) {
+	bytes, err := ioutil.ReadFile(configCacheItemPath(cacheDir, key))
+	if err != nil {
+		return models.BitriseDataModel{}, []string{}, false
+	}
+
+	item := configCacheItemModel{}
+	if err := json.Unmarshal(bytes, &item); err != nil {
+		log.Debugf("Invalid config cache item (%s), error: %s", key, err)
+		return models.BitriseDataModel{}, []string{}, false
+	}
+
+	// the envs are typed by Normalize, this is not preserved by the JSON serialization
+	if err := item.Config.Normalize(); err != nil {
+		log.Debugf("Failed to normalize cached config (%s), error: %s", key, err)
+		return models.BitriseDataModel{}, []string{}, false
+	}
+
+	if item.Warnings == nil {
+		item.Warnings = []string{}
+	}
+
+	return item.Config, item.Warnings, true
+}
+
+// CacheConfig stores the parsed and validated config under the given key,
+// and removes the outdated cache items.
+func CacheConfig(cacheDir, key string, config models.BitriseDataModel, warnings []string) error {
+	if err := pathutil.EnsureDirExist(cacheDir); err != nil {
+		return err
+	}
+
+	bytes, err := json.Marshal(configCacheItemModel{
+		Warnings: warnings,
+		Config:   config,
+	})
+	if err != nil {
+		return err
+	}
+
+	// write to a temp file and rename, so a concurrent read never sees a partial item
+	tmpPth := configCacheItemPath(cacheDir, key) + ".tmp"
+	if err := WriteNonEssentialBytesToFile(tmpPth, bytes); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPth, configCacheItemPath(cacheDir, key)); err != nil {
+		return err
+	}
+
+	cleanupConfigCache(cacheDir, time.Now().Add(-configCacheMaxAge))
+
+	return nil
+}
+
+func cleanupConfigCache(cacheDir string, olderThan time.Time) {
+	infos, err := ioutil.ReadDir(cacheDir)
+	if err != nil {
+		return
+	}
+	for _, info := range infos {
+		if info.ModTime().Before(olderThan) {
+			if err := os.Remove(filepath.Join(cacheDir, info.Name())); err != nil {
+				log.Debugf("Failed to remove outdated config cache item, error: %s", err)
+			}
+		}
+	}
+}
+
+// ReadBitriseConfigCached reads the config like ReadBitriseConfig,
+// but parses and validates the config only if it's not found in the config cache.
+func ReadBitriseConfigCached(pth string) (models.BitriseDataModel, []string, error) {
+	return ReadBitriseConfigCachedForWorkflows(pth, nil)
+}
+
+// ReadBitriseConfigCachedForWorkflows reads the config like ReadBitriseConfigCached, if it's cached,
+// otherwise only the given workflows are resolved (see: ConfigModelFromBytesForWorkflows).
+// The config of the given workflows is not cached, the cache holds only the completely validated configs.
+func ReadBitriseConfigCachedForWorkflows(pth string, workflowIDs []string) (models.BitriseDataModel, []string, error) {
+	if configs.IsConfigCacheDisabled() {
+		return readBitriseConfigForWorkflows(pth, workflowIDs)
+	}
+
+	bytes, err := fileutil.ReadBytesFromFile(pth)
+	if err != nil {
+		// ReadBitriseConfig returns the proper error
+		return readBitriseConfigForWorkflows(pth, workflowIDs)
+	}
+
+	format := ConfigFormatOfPath(pth)
+	isJSON := format == ConfigFormatJSON
+	cacheDir := configs.GetBitriseConfigCacheDirPath()
+	key := ConfigCacheKey(bytes, isJSON)
+	if config, warnings, found := ReadCachedConfig(cacheDir, key); found {
+		log.Debugf("Using cached config (%s)", key)
+		return config, warnings, nil
+	}
+
+	if len(workflowIDs) > 0 {
+		return ConfigModelFromBytesForWorkflows(bytes, format, workflowIDs)
+	}
+
+	config, warnings, err := ConfigModelFromBytes(bytes, format)
+	if err != nil {
+		return config, warnings, err
+	}
+
+	if err := CacheConfig(cacheDir, key, config, warnings); err != nil {
+		log.Debugf("Failed to cache config, error: %s", err)
+	}
+
+	return config, warnings, nil
+}
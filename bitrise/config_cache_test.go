@@ -0,0 +1,90 @@
+package bitrise
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigCacheKey(t *testing.T) {
+	configBytes := []byte("format_version: 1.3.0")
+
+	require.Equal(t, ConfigCacheKey(configBytes, false), ConfigCacheKey(configBytes, false))
+	require.NotEqual(t, ConfigCacheKey(configBytes, false), ConfigCacheKey(configBytes, true))
+	require.NotEqual(t, ConfigCacheKey(configBytes, false), ConfigCacheKey([]byte("format_version: 1.4.0"), false))
+}
+
+func TestCacheConfig(t *testing.T) {
+	configStr := `
+format_version: 1.3.0
+default_step_lib_source: "https://github.com/bitrise-io/bitrise-steplib.git"
+
+app:
+  envs:
+  - APP_ENV: app
+    opts:
+      is_expand: false
+
+workflows:
+  primary:
+    before_run:
+    - setup
+    envs:
+    - WF_ENV: wf
+    steps:
+    - script:
+        inputs:
+        - content: echo "hello"
+  setup:
+    steps:
+    - script:
+        inputs:
+        - content: echo "setup"
+        - content: echo "duplicated"
+`
+
+	config, warnings, err := ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(warnings))
+
+	cacheDir, err := pathutil.NormalizedOSTempDirPath("config_cache")
+	require.NoError(t, err)
+
+	key := ConfigCacheKey([]byte(configStr), false)
+
+	t.Log("not cached")
+	{
+		_, _, found := ReadCachedConfig(cacheDir, key)
+		require.Equal(t, false, found)
+	}
+
+	t.Log("cached config is the same as the parsed one")
+	{
+		require.NoError(t, CacheConfig(cacheDir, key, config, warnings))
+
+		cachedConfig, cachedWarnings, found := ReadCachedConfig(cacheDir, key)
+		require.Equal(t, true, found)
+		require.Equal(t, warnings, cachedWarnings)
+		require.Equal(t, config, cachedConfig)
+	}
+
+	t.Log("outdated items are removed")
+	{
+		outdatedPth := filepath.Join(cacheDir, "outdated.json")
+		require.NoError(t, WriteNonEssentialBytesToFile(outdatedPth, []byte("{}")))
+		outdatedTime := time.Now().Add(-2 * configCacheMaxAge)
+		require.NoError(t, os.Chtimes(outdatedPth, outdatedTime, outdatedTime))
+
+		require.NoError(t, CacheConfig(cacheDir, key, config, warnings))
+
+		_, err := os.Stat(outdatedPth)
+		require.Equal(t, true, os.IsNotExist(err))
+
+		_, _, found := ReadCachedConfig(cacheDir, key)
+		require.Equal(t, true, found)
+	}
+}
@@ -0,0 +1,105 @@
+package bitrise
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/models"
+	"gopkg.in/yaml.v2"
+)
+
+// The config can be stored both in YAML (bitrise.yml) and in JSON (bitrise.json) format,
+// every config read and write goes through the codec below, so the two formats round-trip.
+
+const (
+	// ConfigFormatYML ...
+	ConfigFormatYML = "yml"
+	// ConfigFormatJSON ...
+	ConfigFormatJSON = "json"
+)
+
+// ConfigFormatOfPath returns the config format of the file, based on its extension (YAML is the default)
+func ConfigFormatOfPath(pth string) string {
+	if strings.ToLower(filepath.Ext(pth)) == ".json" {
+		return ConfigFormatJSON
+	}
+	return ConfigFormatYML
+}
+
+// DetectConfigFormat returns the config format of the content (base 64 data, stdin, ...), which has no file extension:
+// a JSON config is an object, so it starts with a '{'
+func DetectConfigFormat(content []byte) string {
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")) {
+		return ConfigFormatJSON
+	}
+	return ConfigFormatYML
+}
+
+// PathWithConfigFormat returns the path with the extension of the config format (bitrise.yml -> bitrise.json)
+func PathWithConfigFormat(pth, format string) string {
+	if ConfigFormatOfPath(pth) == format {
+		return pth
+	}
+	return strings.TrimSuffix(pth, filepath.Ext(pth)) + "." + format
+}
+
+// ConfigModelFromBytes parses, normalizes and validates the config in the given format
+func ConfigModelFromBytes(configBytes []byte, format string) (models.BitriseDataModel, []string, error) {
+	return ConfigModelFromBytesForWorkflows(configBytes, format, nil)
+}
+
+// ConfigModelFromBytesForWorkflows parses the config like ConfigModelFromBytes,
+// but normalizes and validates only the given workflows and the workflows referenced by them (e.g. the workflows of a run),
+// the rest of the workflows are removed from the config. Every workflow is kept and resolved, if no workflow is given,
+// or if any of the given workflows does not exist.
+func ConfigModelFromBytesForWorkflows(configBytes []byte, format string, workflowIDs []string) (models.BitriseDataModel, []string, error) {
+	switch format {
+	case ConfigFormatJSON:
+		return configModelFromJSONBytes(configBytes, workflowIDs)
+	case ConfigFormatYML:
+		return configModelFromYAMLBytes(configBytes, workflowIDs)
+	default:
+		return models.BitriseDataModel{}, []string{}, fmt.Errorf("invalid config format: %s", format)
+	}
+}
+
+// MarshalConfig serializes the config in the given format, the JSON output is indented if pretty
+func MarshalConfig(config models.BitriseDataModel, format string, pretty bool) ([]byte, error) {
+	switch format {
+	case ConfigFormatJSON:
+		if pretty {
+			return json.MarshalIndent(config, "", "  ")
+		}
+		return json.Marshal(config)
+	case ConfigFormatYML:
+		return yaml.Marshal(config)
+	default:
+		return []byte{}, fmt.Errorf("invalid config format: %s", format)
+	}
+}
+
+// DecodeConfig decodes and normalizes the config in the given format, without validating it:
+// e.g. a layer of a layered config is valid only after the layers are merged
+func DecodeConfig(configBytes []byte, format string) (models.BitriseDataModel, error) {
+	var config models.BitriseDataModel
+	var err error
+	switch format {
+	case ConfigFormatJSON:
+		err = json.Unmarshal(configBytes, &config)
+	case ConfigFormatYML:
+		err = yaml.Unmarshal(configBytes, &config)
+	default:
+		err = fmt.Errorf("invalid config format: %s", format)
+	}
+	if err != nil {
+		return models.BitriseDataModel{}, err
+	}
+
+	if err := config.Normalize(); err != nil {
+		return models.BitriseDataModel{}, err
+	}
+	return config, nil
+}
@@ -0,0 +1,151 @@
+package bitrise
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFormat(t *testing.T) {
+	require.Equal(t, ConfigFormatYML, ConfigFormatOfPath("bitrise.yml"))
+	require.Equal(t, ConfigFormatYML, ConfigFormatOfPath("/path/to/bitrise.yaml"))
+	require.Equal(t, ConfigFormatJSON, ConfigFormatOfPath("/path/to/bitrise.JSON"))
+
+	require.Equal(t, ConfigFormatJSON, DetectConfigFormat([]byte("\n  {\"format_version\": \"1.3.1\"}")))
+	require.Equal(t, ConfigFormatYML, DetectConfigFormat([]byte("format_version: 1.3.1")))
+
+	require.Equal(t, "/path/to/bitrise.json", PathWithConfigFormat("/path/to/bitrise.yml", ConfigFormatJSON))
+	require.Equal(t, "bitrise.yml", PathWithConfigFormat("bitrise.yml", ConfigFormatYML))
+}
+
+func TestConfigRoundTrip(t *testing.T) {
+	configStr := `
+format_version: 1.3.1
+default_step_lib_source: "https://github.com/bitrise-io/bitrise-steplib.git"
+
+app:
+  envs:
+  - PROJECT: MyApp
+    opts:
+      is_expand: false
+  env_files:
+  - .env
+  - .env.secrets:
+      is_secret: true
+      is_optional: true
+
+trigger_map:
+- push_branch: master
+  workflow: deploy
+- pattern: "*"
+  workflow: primary
+
+step_defaults:
+  script:
+    inputs:
+    - runner_bin: /bin/bash
+
+workflows:
+  _setup:
+    steps:
+    - script:
+        title: setup
+  primary:
+    before_run:
+    - _setup
+    envs:
+    - STAGE: test
+    steps:
+    - script:
+        title: test
+        is_always_run: true
+        inputs:
+        - content: echo "test"
+  deploy:
+    title: Deploy
+    required_secrets:
+    - API_TOKEN
+    inputs:
+      CONFIGURATION:
+        default: Release
+    steps:
+    - script:
+        title: deploy
+  ship:
+    alias_of: deploy
+`
+
+	yamlConfig, warnings, err := ConfigModelFromBytes([]byte(configStr), ConfigFormatYML)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	t.Log("YAML -> JSON -> YAML")
+	{
+		jsonBytes, err := MarshalConfig(yamlConfig, ConfigFormatJSON, true)
+		require.NoError(t, err)
+		require.Equal(t, ConfigFormatJSON, DetectConfigFormat(jsonBytes))
+
+		jsonConfig, warnings, err := ConfigModelFromBytes(jsonBytes, ConfigFormatJSON)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(warnings))
+		require.Equal(t, yamlConfig, jsonConfig)
+
+		yamlBytes, err := MarshalConfig(jsonConfig, ConfigFormatYML, false)
+		require.NoError(t, err)
+
+		config, _, err := ConfigModelFromBytes(yamlBytes, ConfigFormatYML)
+		require.NoError(t, err)
+		require.Equal(t, yamlConfig, config)
+	}
+
+	t.Log("invalid format")
+	{
+		_, _, err := ConfigModelFromBytes([]byte(configStr), "toml")
+		require.EqualError(t, err, "invalid config format: toml")
+
+		_, err = MarshalConfig(yamlConfig, "toml", false)
+		require.EqualError(t, err, "invalid config format: toml")
+	}
+}
+
+func TestConfigModelFromBytesForWorkflows(t *testing.T) {
+	configStr := `format_version: 1.3.1
+default_step_lib_source: "https://github.com/bitrise-io/bitrise-steplib.git"
+workflows:
+  _setup:
+    steps:
+    - script: {}
+  primary:
+    before_run:
+    - _setup
+    steps:
+    - script: {}
+  broken:
+    before_run:
+    - not-existing
+`
+
+	t.Log("only the workflows of the run are resolved")
+	{
+		config, _, err := ConfigModelFromBytesForWorkflows([]byte(configStr), ConfigFormatYML, []string{"primary"})
+		require.NoError(t, err)
+		require.Equal(t, 2, len(config.Workflows))
+		_, found := config.Workflows["_setup"]
+		require.Equal(t, true, found)
+	}
+
+	t.Log("every workflow is resolved")
+	{
+		_, _, err := ConfigModelFromBytes([]byte(configStr), ConfigFormatYML)
+		require.Error(t, err)
+
+		_, _, err = ConfigModelFromBytesForWorkflows([]byte(configStr), ConfigFormatYML, []string{"broken"})
+		require.Error(t, err)
+	}
+
+	t.Log("not existing workflow")
+	{
+		_, _, err := ConfigModelFromBytesForWorkflows([]byte(configStr), ConfigFormatYML, []string{"primary", "deploy"})
+		require.Error(t, err)
+	}
+}
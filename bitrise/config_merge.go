@@ -0,0 +1,64 @@
+package bitrise
+
+import (
+	"fmt"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/fileutil"
+)
+
+// MergeConfigFiles reads the config files and deep-merges them in the given order (see: models.MergeConfigs),
+// every file overlays the previous ones. The merged config is validated.
+// Returns the merged config, the provenance of its values (which file contributed them),
+// the warnings of the validation, and the overridden values (path (overridden by: file)).
+func MergeConfigFiles(pths []string) (models.BitriseDataModel, ConfigProvenanceModel, []string, []string, error) {
+	if len(pths) == 0 {
+		return models.BitriseDataModel{}, ConfigProvenanceModel{}, []string{}, []string{}, fmt.Errorf("no config to merge")
+	}
+
+	merged := models.BitriseDataModel{}
+	provenance := ConfigProvenanceModel{}
+	overrides := []string{}
+	for idx, pth := range pths {
+		bytes, err := fileutil.ReadBytesFromFile(pth)
+		if err != nil {
+			return models.BitriseDataModel{}, ConfigProvenanceModel{}, []string{}, []string{}, fmt.Errorf("Failed to read config (%s), error: %s", pth, err)
+		}
+
+		config, err := DecodeConfig(bytes, ConfigFormatOfPath(pth))
+		if err != nil {
+			return models.BitriseDataModel{}, ConfigProvenanceModel{}, []string{}, []string{}, fmt.Errorf("Failed to parse config (%s), error: %s", pth, err)
+		}
+
+		source := ConfigSourceModel{Type: ConfigSourceTypeFile, Location: pth}
+		if idx == 0 {
+			merged = config
+			provenance = NewConfigProvenance(source)
+			continue
+		}
+
+		var layerOverrides []string
+		merged, layerOverrides, err = models.MergeConfigs(merged, config)
+		if err != nil {
+			return models.BitriseDataModel{}, ConfigProvenanceModel{}, []string{}, []string{}, fmt.Errorf("Failed to merge config (%s), error: %s", pth, err)
+		}
+		for _, path := range layerOverrides {
+			overrides = append(overrides, fmt.Sprintf("%s (overridden by: %s)", path, pth))
+		}
+		if err := provenance.RecordConfig(config, source); err != nil {
+			return models.BitriseDataModel{}, ConfigProvenanceModel{}, []string{}, []string{}, fmt.Errorf("Failed to record the provenance of config (%s), error: %s", pth, err)
+		}
+	}
+
+	// the merged config is validated on a copy, the defaults filled by the validation are not part of the merged config
+	mergedBytes, err := MarshalConfig(merged, ConfigFormatYML, false)
+	if err != nil {
+		return models.BitriseDataModel{}, ConfigProvenanceModel{}, []string{}, overrides, err
+	}
+	_, warnings, err := ConfigModelFromYAMLBytes(mergedBytes)
+	if err != nil {
+		return models.BitriseDataModel{}, ConfigProvenanceModel{}, warnings, overrides, fmt.Errorf("The merged config is not valid: %s", err)
+	}
+
+	return merged, provenance, warnings, overrides, nil
+}
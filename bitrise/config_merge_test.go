@@ -0,0 +1,98 @@
+package bitrise
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeConfigFiles(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("config_merge")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	basePth := filepath.Join(tmpDir, "base.yml")
+	require.NoError(t, fileutil.WriteStringToFile(basePth, `format_version: 1.3.1
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+workflows:
+  _setup:
+    steps:
+    - script:
+        title: setup
+  primary:
+    before_run:
+    - _setup
+    steps:
+    - script:
+        title: test
+`))
+
+	// the overlay alone is not valid: it references a workflow of the base config
+	overlayPth := filepath.Join(tmpDir, "overlay.json")
+	require.NoError(t, fileutil.WriteStringToFile(overlayPth, `{
+  "trigger_map": [{"push_branch": "*", "workflow": "primary"}],
+  "workflows": {
+    "primary": {"envs": [{"STAGE": "ci"}]},
+    "deploy": {"before_run": ["_setup"]}
+  }
+}`))
+
+	t.Log("base and overlay")
+	{
+		config, provenance, warnings, overrides, err := MergeConfigFiles([]string{basePth, overlayPth})
+		require.NoError(t, err)
+		require.Equal(t, 0, len(warnings))
+		require.Equal(t, 0, len(overrides))
+
+		require.Equal(t, 1, len(config.TriggerMap))
+		require.Equal(t, 1, len(config.Workflows["primary"].Steps))
+		require.Equal(t, 1, len(config.Workflows["primary"].Environments))
+		require.Equal(t, 1, len(config.Workflows["deploy"].BeforeRun))
+
+		baseSource := ConfigSourceModel{Type: ConfigSourceTypeFile, Location: basePth}
+		overlaySource := ConfigSourceModel{Type: ConfigSourceTypeFile, Location: overlayPth}
+		require.Equal(t, baseSource, provenance.SourceOf("workflows.primary.steps[0]"))
+		require.Equal(t, baseSource, provenance.SourceOf("workflows.primary.before_run"))
+		require.Equal(t, overlaySource, provenance.SourceOf("workflows.primary.envs.STAGE"))
+		require.Equal(t, overlaySource, provenance.SourceOf("workflows.deploy.before_run"))
+		require.Equal(t, overlaySource, provenance.SourceOf("trigger_map[0].workflow"))
+	}
+
+	t.Log("overrides")
+	{
+		overlay2Pth := filepath.Join(tmpDir, "overlay2.yml")
+		require.NoError(t, fileutil.WriteStringToFile(overlay2Pth, `workflows:
+  primary:
+    envs:
+    - STAGE: release
+`))
+
+		config, provenance, _, overrides, err := MergeConfigFiles([]string{basePth, overlayPth, overlay2Pth})
+		require.NoError(t, err)
+		require.Equal(t, []string{"workflows.primary.envs.STAGE (overridden by: " + overlay2Pth + ")"}, overrides)
+
+		explain, err := provenance.Explain(config, "workflows.primary.envs.STAGE")
+		require.NoError(t, err)
+		require.Equal(t, "release", explain.Value)
+		require.Equal(t, ConfigSourceModel{Type: ConfigSourceTypeFile, Location: overlay2Pth}, explain.Source)
+	}
+
+	t.Log("invalid merged config")
+	{
+		invalidPth := filepath.Join(tmpDir, "invalid.yml")
+		require.NoError(t, fileutil.WriteStringToFile(invalidPth, `workflows:
+  ship:
+    alias_of: missing
+`))
+
+		_, _, _, _, err := MergeConfigFiles([]string{basePth, invalidPth})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "The merged config is not valid")
+	}
+}
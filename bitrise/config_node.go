@@ -0,0 +1,316 @@
+package bitrise
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Node based parsing of the bitrise config (YAML).
+// The config is still decoded into the models with the yaml package,
+// the node tree keeps the location (line, column) and the comments of every element:
+// it is used for reporting precise error locations, and for the commands which edit the config file.
+// The node tree is built from the nodes of the yaml.v3 package (yaml.v2 doesn't provide the locations and the comments),
+// the anchors (&name) and the aliases (*name) are kept as they are, not resolved.
+
+// ConfigNodeKind ...
+type ConfigNodeKind string
+
+const (
+	// ConfigNodeKindMapping ...
+	ConfigNodeKindMapping ConfigNodeKind = "mapping"
+	// ConfigNodeKindSequence ...
+	ConfigNodeKindSequence ConfigNodeKind = "sequence"
+	// ConfigNodeKindScalar ...
+	ConfigNodeKindScalar ConfigNodeKind = "scalar"
+)
+
+// ConfigNodeModel is an element of the config file
+type ConfigNodeModel struct {
+	Kind ConfigNodeKind
+	// Key is the key of the element, if it is a mapping value
+	Key string
+	// Value is the value of a scalar element
+	Value string
+	// Line and Column is the 1 based position of the element (of the key, if the element has a key)
+	Line   int
+	Column int
+	// HeadComments are the comment lines right above the element
+	HeadComments []string
+	// LineComment is the comment at the end of the element's line
+	LineComment string
+	// Anchor is the name of the element's anchor (key: &name)
+	Anchor string
+	// Alias is the name of the referenced anchor, if the element is an alias (key: *name)
+	Alias string
+	// FootComments are the comments after the last element (of the root only)
+	FootComments []string
+	Children     []*ConfigNodeModel
+}
+
+// ConfigParseErrorModel ...
+type ConfigParseErrorModel struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+// Error ...
+func (err ConfigParseErrorModel) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", err.Line, err.Column, err.Message)
+}
+
+// ConfigErrorModel is a config error with the location of the invalid element
+type ConfigErrorModel struct {
+	Line   int
+	Column int
+	Path   string
+	Err    error
+}
+
+// Error ...
+func (err ConfigErrorModel) Error() string {
+	return fmt.Sprintf("%s (line %d, column %d: %s)", err.Err, err.Line, err.Column, err.Path)
+}
+
+// ConfigSyntaxErrorModel is returned if the config is not a valid YAML,
+// Errors lists the problems found by the node parser (see: ParseConfigNodes).
+type ConfigSyntaxErrorModel struct {
+	Err    error
+	Errors []ConfigParseErrorModel
+}
+
+// Error ...
+func (err ConfigSyntaxErrorModel) Error() string {
+	if len(err.Errors) == 0 {
+		return err.Err.Error()
+	}
+
+	lines := []string{err.Err.Error()}
+	for _, parseErr := range err.Errors {
+		lines = append(lines, "- "+parseErr.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Child returns the mapping value with the given key
+func (node *ConfigNodeModel) Child(key string) *ConfigNodeModel {
+	if node == nil || node.Kind != ConfigNodeKindMapping {
+		return nil
+	}
+	for _, child := range node.Children {
+		if child.Key == key {
+			return child
+		}
+	}
+	return nil
+}
+
+// Lookup returns the node at the given path (e.g.: workflows.primary.steps[1].script.inputs[0]),
+// if the full path can not be found the deepest existing node of the path is returned, with false.
+// A key on a list selects the list item with the given key (e.g.: app.envs.MY_ENV, steps.script).
+func (node *ConfigNodeModel) Lookup(path string) (*ConfigNodeModel, bool) {
+	if path == "" {
+		return node, true
+	}
+
+	components, err := parseConfigPath(path)
+	if err != nil {
+		return node, false
+	}
+
+	current := node
+	for idx := 0; idx < len(components); idx++ {
+		component := components[idx]
+		var next *ConfigNodeModel
+
+		switch current.Kind {
+		case ConfigNodeKindMapping:
+			if !component.isIndex {
+				next = current.Child(component.key)
+
+				// keys can contain dots (e.g. step IDs: script@1.1.0)
+				key := component.key
+				for next == nil && idx+1 < len(components) && !components[idx+1].isIndex {
+					idx++
+					key += "." + components[idx].key
+					next = current.Child(key)
+				}
+			}
+		case ConfigNodeKindSequence:
+			if component.isIndex {
+				if component.index < len(current.Children) {
+					next = current.Children[component.index]
+				}
+			} else {
+				for _, item := range current.Children {
+					if child := item.Child(component.key); child != nil {
+						next = child
+						break
+					}
+				}
+			}
+		}
+
+		if next == nil {
+			return current, false
+		}
+		current = next
+	}
+
+	return current, true
+}
+
+// LocateConfigError returns the error with the location of the invalid element, if the error has a config path
+func LocateConfigError(root *ConfigNodeModel, err error) error {
+	pathErr, ok := err.(models.ConfigPathError)
+	if !ok || root == nil {
+		return err
+	}
+
+	node, _ := root.Lookup(pathErr.Path)
+	if node == root {
+		return err
+	}
+
+	return ConfigErrorModel{
+		Line:   node.Line,
+		Column: node.Column,
+		Path:   pathErr.Path,
+		Err:    pathErr.Err,
+	}
+}
+
+// ParseConfigNodes parses the config into a node tree, and returns the problems found in the config:
+// the syntax error reported by the yaml package (parsing stops at the first one), or every duplicated key.
+func ParseConfigNodes(content string) (*ConfigNodeModel, []ConfigParseErrorModel) {
+	root := &ConfigNodeModel{
+		Kind:   ConfigNodeKindMapping,
+		Line:   1,
+		Column: 1,
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &document); err != nil {
+		return root, []ConfigParseErrorModel{configParseError(content, err)}
+	}
+
+	builder := configNodeBuilder{}
+	if len(document.Content) > 0 {
+		root = builder.build(document.Content[0])
+	}
+	root.FootComments = append(builder.takeComments(), configComments(document.FootComment)...)
+
+	return root, builder.errors
+}
+
+var yamlErrorLinePattern = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// configParseError returns the location of the yaml package's syntax error,
+// the yaml package reports only the line, the column is the first non blank character of the line.
+func configParseError(content string, err error) ConfigParseErrorModel {
+	match := yamlErrorLinePattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return ConfigParseErrorModel{Line: 1, Column: 1, Message: err.Error()}
+	}
+
+	line, _ := strconv.Atoi(match[1])
+	column := 1
+	lines := strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n")
+	if line > 0 && line <= len(lines) {
+		column = len(lines[line-1]) - len(strings.TrimLeft(lines[line-1], " \t")) + 1
+	}
+	return ConfigParseErrorModel{Line: line, Column: column, Message: match[2]}
+}
+
+// configNodeBuilder builds the node tree from the yaml package's nodes
+type configNodeBuilder struct {
+	// comments are the foot comments of the previous elements, they are the head comments of the next element
+	comments []string
+	errors   []ConfigParseErrorModel
+}
+
+func (builder *configNodeBuilder) takeComments() []string {
+	comments := builder.comments
+	builder.comments = nil
+	return comments
+}
+
+func (builder *configNodeBuilder) build(node *yaml.Node) *ConfigNodeModel {
+	model := &ConfigNodeModel{
+		Kind:         ConfigNodeKindScalar,
+		Line:         node.Line,
+		Column:       node.Column,
+		HeadComments: append(builder.takeComments(), configComments(node.HeadComment)...),
+		LineComment:  configLineComment(node.LineComment),
+		Anchor:       node.Anchor,
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		model.Kind = ConfigNodeKindMapping
+		for idx := 0; idx+1 < len(node.Content); idx += 2 {
+			keyNode, valueNode := node.Content[idx], node.Content[idx+1]
+
+			if existing := model.Child(keyNode.Value); existing != nil {
+				builder.errors = append(builder.errors, ConfigParseErrorModel{
+					Line:    keyNode.Line,
+					Column:  keyNode.Column,
+					Message: fmt.Sprintf("duplicate key (%s), already defined at line %d", keyNode.Value, existing.Line),
+				})
+			}
+
+			// the element of a mapping entry is positioned at its key, and has the comments of both the key and the value
+			headComments := append(builder.takeComments(), configComments(keyNode.HeadComment)...)
+			child := builder.build(valueNode)
+			child.Key = keyNode.Value
+			child.Line = keyNode.Line
+			child.Column = keyNode.Column
+			child.HeadComments = append(headComments, child.HeadComments...)
+			if child.LineComment == "" {
+				child.LineComment = configLineComment(keyNode.LineComment)
+			}
+			builder.comments = append(builder.comments, configComments(keyNode.FootComment)...)
+
+			model.Children = append(model.Children, child)
+		}
+	case yaml.SequenceNode:
+		model.Kind = ConfigNodeKindSequence
+		for _, itemNode := range node.Content {
+			item := builder.build(itemNode)
+			if node.Style&yaml.FlowStyle == 0 {
+				// the item of a block sequence is positioned at its item marker (-)
+				item.Column = node.Column
+			}
+			model.Children = append(model.Children, item)
+		}
+	case yaml.AliasNode:
+		model.Alias = node.Value
+	default:
+		model.Value = node.Value
+	}
+
+	builder.comments = append(builder.comments, configComments(node.FootComment)...)
+	return model
+}
+
+// configComments returns the comment lines, without the comment markers (#)
+func configComments(comment string) []string {
+	comments := []string{}
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		comments = append(comments, strings.TrimSpace(strings.TrimPrefix(line, "#")))
+	}
+	return comments
+}
+
+func configLineComment(comment string) string {
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(comment), "#"))
+}
@@ -0,0 +1,251 @@
+package bitrise
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfigNodes(t *testing.T) {
+	configStr := `format_version: 1.3.0
+default_step_lib_source: "https://github.com/bitrise-io/bitrise-steplib.git"
+
+app:
+  envs:
+  # app level env
+  - APP_ENV: value # inline comment
+    opts:
+      is_expand: false
+
+workflows:
+  primary:
+    steps:
+    - script:
+        title: First
+        inputs:
+        - content: |
+            #!/bin/bash
+            echo "key: value"
+
+    - script@1.1.0:
+        inputs:
+        - content: >-
+            folded
+            content
+  deploy: {}
+`
+
+	root, errs := ParseConfigNodes(configStr)
+	require.Equal(t, 0, len(errs))
+	require.Equal(t, ConfigNodeKindMapping, root.Kind)
+
+	t.Log("scalars")
+	{
+		node, found := root.Lookup("default_step_lib_source")
+		require.Equal(t, true, found)
+		require.Equal(t, "https://github.com/bitrise-io/bitrise-steplib.git", node.Value)
+		require.Equal(t, 2, node.Line)
+		require.Equal(t, 1, node.Column)
+	}
+
+	t.Log("comments")
+	{
+		node, found := root.Lookup("app.envs[0]")
+		require.Equal(t, true, found)
+		require.Equal(t, []string{"app level env"}, node.HeadComments)
+		require.Equal(t, 7, node.Line)
+		require.Equal(t, 3, node.Column)
+
+		node, found = root.Lookup("app.envs[0].APP_ENV")
+		require.Equal(t, true, found)
+		require.Equal(t, "value", node.Value)
+		require.Equal(t, "inline comment", node.LineComment)
+		require.Equal(t, 5, node.Column)
+
+		node, found = root.Lookup("app.envs.APP_ENV")
+		require.Equal(t, true, found)
+		require.Equal(t, "value", node.Value)
+	}
+
+	t.Log("block scalars")
+	{
+		node, found := root.Lookup("workflows.primary.steps[0].script.inputs[0].content")
+		require.Equal(t, true, found)
+		require.Equal(t, "#!/bin/bash\necho \"key: value\"\n", node.Value)
+
+		node, found = root.Lookup("workflows.primary.steps[1].script@1.1.0.inputs.content")
+		require.Equal(t, true, found)
+		require.Equal(t, "folded content", node.Value)
+		require.Equal(t, 23, node.Line)
+	}
+
+	t.Log("flow values")
+	{
+		node, found := root.Lookup("workflows.deploy")
+		require.Equal(t, true, found)
+		require.Equal(t, ConfigNodeKindMapping, node.Kind)
+		require.Equal(t, 0, len(node.Children))
+	}
+
+	t.Log("not found - returns the deepest node")
+	{
+		node, found := root.Lookup("workflows.primary.steps[5]")
+		require.Equal(t, false, found)
+		require.Equal(t, ConfigNodeKindSequence, node.Kind)
+		require.Equal(t, 13, node.Line)
+	}
+}
+
+func TestParseConfigNodesAnchors(t *testing.T) {
+	configStr := `templates:
+  - &clone
+    git-clone: {}
+  - &script
+    script:
+      title: Script
+workflows:
+  base: &base
+    envs: &envs
+    - STAGE: test
+  primary:
+    <<: *base
+    envs: *envs
+    steps:
+    - *clone
+# end of config
+`
+
+	root, errs := ParseConfigNodes(configStr)
+	require.Equal(t, 0, len(errs), "%v", errs)
+
+	node, found := root.Lookup("templates[0]")
+	require.Equal(t, true, found)
+	require.Equal(t, "clone", node.Anchor)
+	require.Equal(t, ConfigNodeKindMapping, node.Kind)
+
+	node, found = root.Lookup("templates[1].script.title")
+	require.Equal(t, true, found)
+	require.Equal(t, "Script", node.Value)
+
+	node, found = root.Lookup("workflows.base")
+	require.Equal(t, true, found)
+	require.Equal(t, "base", node.Anchor)
+
+	node, found = root.Lookup("workflows.base.envs[0].STAGE")
+	require.Equal(t, true, found)
+	require.Equal(t, "test", node.Value)
+
+	node, found = root.Lookup("workflows.primary.<<")
+	require.Equal(t, true, found)
+	require.Equal(t, "base", node.Alias)
+	require.Equal(t, "", node.Value)
+
+	node, found = root.Lookup("workflows.primary.steps[0]")
+	require.Equal(t, true, found)
+	require.Equal(t, "clone", node.Alias)
+
+	require.Equal(t, []string{"end of config"}, root.FootComments)
+}
+
+func TestParseConfigNodesErrors(t *testing.T) {
+	t.Log("syntax error - parsing stops at the first one")
+	{
+		configStr := `format_version: 1.3.0
+workflows:
+  primary:
+    steps:
+    - script:
+        title: First
+          invalid: indentation
+  not a key value pair
+`
+
+		_, errs := ParseConfigNodes(configStr)
+		require.Equal(t, []ConfigParseErrorModel{
+			ConfigParseErrorModel{Line: 7, Column: 11, Message: "mapping values are not allowed in this context"},
+		}, errs)
+	}
+
+	t.Log("duplicate keys - every one is reported")
+	{
+		configStr := `format_version: 1.3.0
+workflows:
+  primary:
+    title: First
+  primary:
+    title: duplicated
+  deploy:
+    title: Deploy
+    title: Deploy again
+`
+
+		root, errs := ParseConfigNodes(configStr)
+		require.Equal(t, []ConfigParseErrorModel{
+			ConfigParseErrorModel{Line: 5, Column: 3, Message: "duplicate key (primary), already defined at line 3"},
+			ConfigParseErrorModel{Line: 9, Column: 5, Message: "duplicate key (title), already defined at line 8"},
+		}, errs)
+
+		node, found := root.Lookup("workflows.deploy.title")
+		require.Equal(t, true, found)
+		require.Equal(t, "Deploy", node.Value)
+	}
+}
+
+func TestConfigModelFromYAMLBytesErrorLocation(t *testing.T) {
+	t.Log("invalid step")
+	{
+		configStr := `format_version: 1.3.0
+default_step_lib_source: "https://github.com/bitrise-io/bitrise-steplib.git"
+
+workflows:
+  primary:
+    steps:
+    - script:
+        title: valid
+    - script:
+      git-clone:
+`
+
+		_, _, err := ConfigModelFromYAMLBytes([]byte(configStr))
+		require.Error(t, err)
+
+		configErr, ok := err.(ConfigErrorModel)
+		require.Equal(t, true, ok)
+		require.Equal(t, "workflows.primary.steps[1]", configErr.Path)
+		require.Equal(t, 9, configErr.Line)
+		require.Equal(t, 5, configErr.Column)
+	}
+
+	t.Log("missing on_abort workflow")
+	{
+		configStr := `format_version: 1.3.0
+default_step_lib_source: "https://github.com/bitrise-io/bitrise-steplib.git"
+on_abort: cleanup
+
+workflows:
+  primary:
+`
+
+		_, _, err := ConfigModelFromYAMLBytes([]byte(configStr))
+		require.EqualError(t, err, "on_abort workflow (cleanup) does not exist (line 3, column 1: on_abort)")
+	}
+
+	t.Log("invalid yaml")
+	{
+		configStr := `format_version: 1.3.0
+workflows:
+  primary:
+    title: primary
+   deploy:
+`
+
+		_, _, err := ConfigModelFromYAMLBytes([]byte(configStr))
+		require.Error(t, err)
+
+		syntaxErr, ok := err.(ConfigSyntaxErrorModel)
+		require.Equal(t, true, ok)
+		require.Equal(t, []ConfigParseErrorModel{
+			ConfigParseErrorModel{Line: 2, Column: 1, Message: "did not find expected key"},
+		}, syntaxErr.Errors)
+	}
+}
@@ -0,0 +1,508 @@
+package bitrise
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/models"
+	"gopkg.in/yaml.v2"
+)
+
+// Comment and anchor preserving config normalization.
+// The config is normalized on the models (like before), then the normalized config is written
+// in a normalized layout (block style, 2 space indentation, the models' field order; map keys, like the workflow IDs, keep their original order),
+// and the comments and the anchors of the original config are carried over, using the node tree of the original config:
+// an element of the normalized config gets the comments and the anchor of the same element of the original config,
+// an alias (or a merge key) is kept if the normalized value still equals to the anchor's value, otherwise it is expanded.
+// The top level keys, unknown by the models (e.g. a key holding the anchored templates), are kept as they are.
+
+// NormalizeConfigContent normalizes the config (YAML) with the given function,
+// and returns the normalized config, with the comments and the anchors of the original config.
+func NormalizeConfigContent(content []byte, normalize func(config *models.BitriseDataModel) error) ([]byte, []string, error) {
+	config, warnings, err := ConfigModelFromYAMLBytes(content)
+	if err != nil {
+		return []byte{}, warnings, err
+	}
+	if err := normalize(&config); err != nil {
+		return []byte{}, warnings, err
+	}
+
+	root, parseErrors := ParseConfigNodes(string(content))
+	if len(parseErrors) > 0 {
+		return []byte{}, warnings, fmt.Errorf("Failed to parse the config nodes, error: %s", parseErrors[0])
+	}
+	if root.Kind != ConfigNodeKindMapping {
+		return []byte{}, warnings, errors.New("the config is not a mapping")
+	}
+
+	var original interface{}
+	if err := yaml.Unmarshal(content, &original); err != nil {
+		return []byte{}, warnings, err
+	}
+
+	normalizedBytes, err := yaml.Marshal(config)
+	if err != nil {
+		return []byte{}, warnings, err
+	}
+	var normalized yaml.MapSlice
+	if err := yaml.Unmarshal(normalizedBytes, &normalized); err != nil {
+		return []byte{}, warnings, err
+	}
+
+	emitter := configEmitter{
+		content:  strings.Split(strings.Replace(string(content), "\r\n", "\n", -1), "\n"),
+		original: original,
+		anchors:  map[string]interface{}{},
+
+		anchorNodes: map[string]*ConfigNodeModel{},
+	}
+	if err := emitter.emitRoot(normalized, root); err != nil {
+		return []byte{}, warnings, err
+	}
+	return []byte(strings.Join(emitter.lines, "\n") + "\n"), warnings, nil
+}
+
+type configEmitter struct {
+	lines []string
+	// content is the lines of the original config
+	content []string
+	// original is the decoded original config
+	original interface{}
+	// anchors are the values of the emitted anchors
+	anchors map[string]interface{}
+	// anchorNodes are the original elements of the emitted anchors
+	anchorNodes map[string]*ConfigNodeModel
+	// itemPrefix replaces the indentation of the next line (the item marker of a sequence item)
+	itemPrefix string
+}
+
+// canonicalConfigValue returns the value in a comparable form (maps instead of ordered map slices)
+func canonicalConfigValue(value interface{}) interface{} {
+	bytes, err := yaml.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var canonical interface{}
+	if err := yaml.Unmarshal(bytes, &canonical); err != nil {
+		return nil
+	}
+	return canonical
+}
+
+func (emitter *configEmitter) indentation(indent int) string {
+	if emitter.itemPrefix != "" {
+		prefix := emitter.itemPrefix
+		emitter.itemPrefix = ""
+		return prefix
+	}
+	return strings.Repeat(" ", indent)
+}
+
+func (emitter *configEmitter) emitComments(comments []string, indent int) {
+	for _, comment := range comments {
+		line := strings.Repeat(" ", indent) + "#"
+		if comment != "" {
+			line += " " + comment
+		}
+		emitter.lines = append(emitter.lines, line)
+	}
+}
+
+func withLineComment(line string, node *ConfigNodeModel) string {
+	if node != nil && node.LineComment != "" {
+		return line + " # " + node.LineComment
+	}
+	return line
+}
+
+// emitRoot emits the top level keys in the normalized order,
+// the unknown top level keys of the original config are emitted before the next key, which followed them in the original config.
+func (emitter *configEmitter) emitRoot(normalized yaml.MapSlice, root *ConfigNodeModel) error {
+	isKnown := map[string]bool{}
+	for _, item := range normalized {
+		isKnown[fmt.Sprintf("%v", item.Key)] = true
+	}
+
+	// the blocks (mappings and sequences) are separated by an empty line
+	isPreviousBlock := false
+	separate := func(isBlock bool) {
+		if len(emitter.lines) > 0 && (isBlock || isPreviousBlock) {
+			emitter.lines = append(emitter.lines, "")
+		}
+		isPreviousBlock = isBlock
+	}
+
+	emitted := map[int]bool{}
+	emitUnknownBefore := func(limit int) {
+		for idx := 0; idx < limit; idx++ {
+			child := root.Children[idx]
+			if isKnown[child.Key] || emitted[idx] {
+				continue
+			}
+			emitted[idx] = true
+			separate(child.Kind != ConfigNodeKindScalar)
+			emitter.emitRaw(root, idx)
+		}
+	}
+
+	for _, item := range normalized {
+		key := fmt.Sprintf("%v", item.Key)
+		for idx, child := range root.Children {
+			if child.Key == key {
+				emitUnknownBefore(idx)
+			}
+		}
+
+		separate(isConfigBlock(item.Value))
+		if err := emitter.emitEntry(key, item.Value, root.Child(key), 0); err != nil {
+			return err
+		}
+	}
+	emitUnknownBefore(len(root.Children))
+
+	emitter.emitComments(root.FootComments, 0)
+	return nil
+}
+
+func isConfigBlock(value interface{}) bool {
+	switch typed := value.(type) {
+	case yaml.MapSlice:
+		return len(typed) > 0
+	case []interface{}:
+		return len(typed) > 0
+	}
+	return false
+}
+
+// inOriginalOrder returns the entries of a map (yaml sorts the map keys) in the order of the original mapping,
+// the new keys come last. The entries of a struct (which are not sorted) are kept in the models' field order.
+func inOriginalOrder(mapping yaml.MapSlice, node *ConfigNodeModel) yaml.MapSlice {
+	keys := []string{}
+	for _, item := range mapping {
+		keys = append(keys, fmt.Sprintf("%v", item.Key))
+	}
+	if node == nil || !sort.StringsAreSorted(keys) {
+		return mapping
+	}
+
+	ordered := yaml.MapSlice{}
+	isAdded := map[string]bool{}
+	for _, child := range node.Children {
+		for _, item := range mapping {
+			if key := fmt.Sprintf("%v", item.Key); key == child.Key && !isAdded[key] {
+				ordered = append(ordered, item)
+				isAdded[key] = true
+			}
+		}
+	}
+	for _, item := range mapping {
+		if !isAdded[fmt.Sprintf("%v", item.Key)] {
+			ordered = append(ordered, item)
+		}
+	}
+	return ordered
+}
+
+// emitRaw copies the lines of the original top level key, and registers its anchors
+func (emitter *configEmitter) emitRaw(root *ConfigNodeModel, idx int) {
+	child := root.Children[idx]
+	emitter.emitComments(child.HeadComments, 0)
+
+	end := len(emitter.content)
+	if idx+1 < len(root.Children) {
+		end = root.Children[idx+1].Line - 1
+		// the comments above the next key belong to the next key
+		for end > child.Line {
+			text := strings.TrimSpace(emitter.content[end-1])
+			if text != "" && !strings.HasPrefix(text, "#") {
+				break
+			}
+			end--
+		}
+	}
+	for end > child.Line && strings.TrimSpace(emitter.content[end-1]) == "" {
+		end--
+	}
+	emitter.lines = append(emitter.lines, emitter.content[child.Line-1:end]...)
+
+	emitter.registerOriginalAnchors(child, []interface{}{child.Key})
+}
+
+func (emitter *configEmitter) registerOriginalAnchors(node *ConfigNodeModel, path []interface{}) {
+	if node.Anchor != "" {
+		if value, found := lookupConfigValue(emitter.original, path); found {
+			emitter.anchors[node.Anchor] = canonicalConfigValue(value)
+			emitter.anchorNodes[node.Anchor] = node
+		}
+	}
+	for idx, child := range node.Children {
+		var component interface{} = idx
+		if node.Kind == ConfigNodeKindMapping {
+			component = child.Key
+		}
+		emitter.registerOriginalAnchors(child, append(append([]interface{}{}, path...), component))
+	}
+}
+
+func lookupConfigValue(value interface{}, path []interface{}) (interface{}, bool) {
+	for _, component := range path {
+		switch typed := value.(type) {
+		case map[interface{}]interface{}:
+			next, found := typed[component]
+			if !found {
+				return nil, false
+			}
+			value = next
+		case []interface{}:
+			idx, ok := component.(int)
+			if !ok || idx >= len(typed) {
+				return nil, false
+			}
+			value = typed[idx]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// aliasOf returns the alias, if the original element was an alias and the anchor's value equals to the value
+func (emitter *configEmitter) aliasOf(value interface{}, node *ConfigNodeModel) string {
+	if node == nil || node.Alias == "" {
+		return ""
+	}
+	anchorValue, found := emitter.anchors[node.Alias]
+	if !found || !reflect.DeepEqual(anchorValue, canonicalConfigValue(value)) {
+		return ""
+	}
+	return node.Alias
+}
+
+func (emitter *configEmitter) registerAnchor(value interface{}, node *ConfigNodeModel) string {
+	if node == nil || node.Anchor == "" {
+		return ""
+	}
+	emitter.anchors[node.Anchor] = canonicalConfigValue(value)
+	emitter.anchorNodes[node.Anchor] = node
+	return " &" + node.Anchor
+}
+
+func marshalConfigScalar(value interface{}) ([]string, error) {
+	bytes, err := yaml.Marshal(value)
+	if err != nil {
+		return []string{}, err
+	}
+	return strings.Split(strings.TrimSuffix(string(bytes), "\n"), "\n"), nil
+}
+
+// emitScalar emits the scalar after the head ("key:" or "-"), a block scalar's lines are indented under the head
+func (emitter *configEmitter) emitScalar(head string, value interface{}, node *ConfigNodeModel, indent int) error {
+	scalarLines, err := marshalConfigScalar(value)
+	if err != nil {
+		return err
+	}
+
+	emitter.lines = append(emitter.lines, withLineComment(head+" "+scalarLines[0], node))
+	for _, line := range scalarLines[1:] {
+		if line == "" {
+			emitter.lines = append(emitter.lines, "")
+			continue
+		}
+		emitter.lines = append(emitter.lines, strings.Repeat(" ", indent+2)+strings.TrimPrefix(line, "  "))
+	}
+	return nil
+}
+
+// emitEntry emits a mapping entry (key: value) at the given indentation
+func (emitter *configEmitter) emitEntry(key string, value interface{}, node *ConfigNodeModel, indent int) error {
+	if node != nil {
+		emitter.emitComments(node.HeadComments, indent)
+	}
+
+	keyLines, err := marshalConfigScalar(key)
+	if err != nil {
+		return err
+	}
+	head := emitter.indentation(indent) + keyLines[0] + ":"
+
+	if alias := emitter.aliasOf(value, node); alias != "" {
+		emitter.lines = append(emitter.lines, withLineComment(head+" *"+alias, node))
+		return nil
+	}
+	head += emitter.registerAnchor(value, node)
+
+	switch typed := value.(type) {
+	case yaml.MapSlice:
+		if len(typed) > 0 {
+			emitter.lines = append(emitter.lines, withLineComment(head, node))
+			return emitter.emitMapping(typed, node, indent+2)
+		}
+	case []interface{}:
+		if len(typed) > 0 {
+			emitter.lines = append(emitter.lines, withLineComment(head, node))
+			return emitter.emitSequence(typed, node, indent)
+		}
+	}
+	return emitter.emitScalar(head, value, node, indent)
+}
+
+// mergedNode returns the element of the merged mapping, without its comments and anchors (the anchors are already defined),
+// an anchored element becomes an alias of its anchor
+func mergedNode(node *ConfigNodeModel) *ConfigNodeModel {
+	if node == nil {
+		return nil
+	}
+
+	merged := *node
+	merged.HeadComments = nil
+	merged.LineComment = ""
+	if merged.Anchor != "" {
+		merged.Alias = merged.Anchor
+		merged.Anchor = ""
+	}
+	merged.Children = []*ConfigNodeModel{}
+	for _, child := range node.Children {
+		mergedChild := mergedNode(child)
+		mergedChild.HeadComments = child.HeadComments
+		merged.Children = append(merged.Children, mergedChild)
+	}
+	return &merged
+}
+
+// emitMapping emits the entries of the mapping, a merge key of the original mapping is kept,
+// if every merged value is still the same (or is overridden in the original mapping).
+// Otherwise the merged values are expanded, using the elements of the merged mapping as the original elements.
+func (emitter *configEmitter) emitMapping(mapping yaml.MapSlice, node *ConfigNodeModel, indent int) error {
+	isMerged := map[string]bool{}
+	var mergeSource *ConfigNodeModel
+	if mergeNode := node.Child("<<"); mergeNode != nil && mergeNode.Alias != "" {
+		if merged, ok := emitter.anchors[mergeNode.Alias].(map[interface{}]interface{}); ok {
+			isKept := true
+			for key, mergedValue := range merged {
+				keyStr := fmt.Sprintf("%v", key)
+				isFound := false
+				for _, item := range mapping {
+					if fmt.Sprintf("%v", item.Key) != keyStr {
+						continue
+					}
+					isFound = true
+					if node.Child(keyStr) == nil {
+						if reflect.DeepEqual(mergedValue, canonicalConfigValue(item.Value)) {
+							isMerged[keyStr] = true
+						} else {
+							isKept = false
+						}
+					}
+				}
+				isKept = isKept && isFound
+			}
+
+			if isKept {
+				emitter.emitComments(mergeNode.HeadComments, indent)
+				emitter.lines = append(emitter.lines, withLineComment(emitter.indentation(indent)+"<<: *"+mergeNode.Alias, mergeNode))
+			} else {
+				isMerged = map[string]bool{}
+				mergeSource = emitter.anchorNodes[mergeNode.Alias]
+			}
+		}
+	}
+
+	for _, item := range inOriginalOrder(mapping, node) {
+		key := fmt.Sprintf("%v", item.Key)
+		if isMerged[key] {
+			continue
+		}
+
+		original := node.Child(key)
+		if original == nil {
+			original = mergedNode(mergeSource.Child(key))
+		}
+		if err := emitter.emitEntry(key, item.Value, original, indent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// originalSequenceItem returns the original item of the normalized sequence item:
+// a single key mapping item (e.g. a step, an env) is matched by its key (and its occurrence), any other item by its index
+func originalSequenceItem(sequence []interface{}, idx int, node *ConfigNodeModel) *ConfigNodeModel {
+	if node == nil || node.Kind != ConfigNodeKindSequence {
+		return nil
+	}
+
+	if mapping, ok := sequence[idx].(yaml.MapSlice); ok && len(mapping) > 0 {
+		key := fmt.Sprintf("%v", mapping[0].Key)
+		occurrence := 0
+		for _, item := range sequence[:idx] {
+			if itemMapping, ok := item.(yaml.MapSlice); ok && len(itemMapping) > 0 && fmt.Sprintf("%v", itemMapping[0].Key) == key {
+				occurrence++
+			}
+		}
+
+		for _, child := range node.Children {
+			if child.Kind == ConfigNodeKindMapping && len(child.Children) > 0 && child.Children[0].Key == key {
+				if occurrence == 0 {
+					return child
+				}
+				occurrence--
+			}
+		}
+	}
+
+	if idx < len(node.Children) {
+		return node.Children[idx]
+	}
+	return nil
+}
+
+// emitSequence emits the items of the sequence, at the indentation of the sequence's key
+func (emitter *configEmitter) emitSequence(sequence []interface{}, node *ConfigNodeModel, indent int) error {
+	for idx, value := range sequence {
+		item := originalSequenceItem(sequence, idx, node)
+		if item != nil {
+			emitter.emitComments(item.HeadComments, indent)
+		}
+
+		head := emitter.indentation(indent) + "-"
+		if alias := emitter.aliasOf(value, item); alias != "" {
+			emitter.lines = append(emitter.lines, withLineComment(head+" *"+alias, item))
+			continue
+		}
+		anchor := emitter.registerAnchor(value, item)
+
+		switch typed := value.(type) {
+		case yaml.MapSlice:
+			if len(typed) > 0 {
+				if anchor != "" {
+					emitter.lines = append(emitter.lines, head+anchor)
+				} else {
+					emitter.itemPrefix = head + " "
+				}
+				if item != nil && item.Kind != ConfigNodeKindMapping {
+					item = nil
+				}
+				if err := emitter.emitMapping(typed, item, indent+2); err != nil {
+					return err
+				}
+				continue
+			}
+		case []interface{}:
+			if len(typed) > 0 {
+				emitter.lines = append(emitter.lines, head+anchor)
+				if err := emitter.emitSequence(typed, item, indent+2); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if err := emitter.emitScalar(head+anchor, value, item, indent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package bitrise
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeConfigContent(t *testing.T) {
+	configStr := `# The config of MyApp
+format_version: 1.3.1
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+
+# templates, used by the workflows
+templates:
+  clone: &clone
+    git-clone@4.0.11:
+      title: Clone
+
+app:
+  envs:
+  # the project
+  - PROJECT: MyApp # inline
+    opts:
+      is_expand: false
+
+workflows:
+  # the base of the other workflows
+  primary: &base
+    envs: &envs
+    - STAGE: test
+    steps:
+    - *clone
+    - script:
+        inputs:
+        - content: |
+            #!/bin/bash
+            echo "hello"
+  deploy:
+    <<: *base
+    title: Deploy # overrides
+`
+
+	t.Log("comments, anchors, aliases and merge keys are kept")
+	{
+		content, warnings, err := NormalizeConfigContent([]byte(configStr), func(config *models.BitriseDataModel) error {
+			return config.RemoveRedundantFields()
+		})
+		require.NoError(t, err)
+		require.Equal(t, 0, len(warnings))
+		require.Equal(t, `# The config of MyApp
+format_version: 1.3.1
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+
+# templates, used by the workflows
+templates:
+  clone: &clone
+    git-clone@4.0.11:
+      title: Clone
+
+app:
+  envs:
+  # the project
+  - PROJECT: MyApp # inline
+    opts:
+      is_expand: false
+      skip_if_empty: false
+
+workflows:
+  # the base of the other workflows
+  primary: &base
+    envs: &envs
+    - STAGE: test
+    steps:
+    - *clone
+    - script:
+        inputs:
+        - content: |
+            #!/bin/bash
+            echo "hello"
+          opts: {}
+  deploy:
+    <<: *base
+    title: Deploy # overrides
+`, string(content))
+
+		config, _, err := ConfigModelFromYAMLBytes(content)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(config.Workflows["deploy"].Steps))
+	}
+
+	t.Log("an alias is expanded, if the normalized value differs from the anchor's value")
+	{
+		content, _, err := NormalizeConfigContent([]byte(configStr), func(config *models.BitriseDataModel) error {
+			if err := config.RemoveRedundantFields(); err != nil {
+				return err
+			}
+			workflow := config.Workflows["primary"]
+			workflow.Summary = "Primary"
+			config.Workflows["primary"] = workflow
+			return nil
+		})
+		require.NoError(t, err)
+		require.Contains(t, string(content), `  primary: &base
+    summary: Primary
+`)
+		require.Contains(t, string(content), `  deploy:
+    title: Deploy # overrides
+    envs: *envs
+    steps:
+    - *clone
+`)
+	}
+
+	t.Log("invalid config")
+	{
+		_, _, err := NormalizeConfigContent([]byte("format_version: [1"), func(config *models.BitriseDataModel) error {
+			return nil
+		})
+		require.Error(t, err)
+	}
+}
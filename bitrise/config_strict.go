@@ -0,0 +1,52 @@
+package bitrise
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/models"
+	"gopkg.in/yaml.v2"
+)
+
+// Strict config mode: the unknown keys of the config (typos like before_rub, run_iff) are errors,
+// instead of being silently ignored by the YAML / JSON decoder.
+
+// UnknownConfigKeys returns the config paths (e.g. workflows.primary.before_rub) of the keys,
+// which are not known by the config model, in the order of their path.
+func UnknownConfigKeys(configBytes []byte) ([]string, error) {
+	var config interface{}
+	if err := yaml.Unmarshal(configBytes, &config); err != nil {
+		return []string{}, err
+	}
+
+	return models.UnknownConfigKeys(config), nil
+}
+
+// StrictConfigErrors returns an error for every unknown key of the config, with the config path of the key,
+// the errors of a YAML config contain the location (line, column) of the key as well.
+func StrictConfigErrors(configBytes []byte, format string) ([]error, error) {
+	unknownKeys, err := UnknownConfigKeys(configBytes)
+	if err != nil {
+		return []error{}, err
+	}
+
+	var root *ConfigNodeModel
+	if format == ConfigFormatYML {
+		root, _ = ParseConfigNodes(string(configBytes))
+	}
+
+	errs := []error{}
+	for _, pth := range unknownKeys {
+		key := pth
+		if idx := strings.LastIndex(pth, "."); idx >= 0 {
+			key = pth[idx+1:]
+		}
+		err := fmt.Errorf("unknown key (%s)", key)
+		if root == nil {
+			errs = append(errs, fmt.Errorf("%s: %s", err, pth))
+			continue
+		}
+		errs = append(errs, LocateConfigError(root, models.NewConfigPathError(pth, err)))
+	}
+	return errs, nil
+}
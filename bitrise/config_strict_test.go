@@ -0,0 +1,52 @@
+package bitrise
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrictConfigErrors(t *testing.T) {
+	t.Log("yml config")
+	{
+		configStr := `format_version: 1.3.0
+
+workflows:
+  primary:
+    before_rub:
+    - setup
+    steps:
+    - script@1.1.0:
+        run_iff: .IsCI
+`
+		errs, err := StrictConfigErrors([]byte(configStr), ConfigFormatYML)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(errs))
+		require.EqualError(t, errs[0], "unknown key (before_rub) (line 5, column 5: workflows.primary.before_rub)")
+		require.EqualError(t, errs[1], "unknown key (run_iff) (line 9, column 9: workflows.primary.steps[0].script@1.1.0.run_iff)")
+	}
+
+	t.Log("json config")
+	{
+		configStr := `{"format_version": "1.3.0", "workflows": {"primary": {"before_rub": ["setup"]}}}`
+
+		errs, err := StrictConfigErrors([]byte(configStr), ConfigFormatJSON)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(errs))
+		require.EqualError(t, errs[0], "unknown key (before_rub): workflows.primary.before_rub")
+	}
+
+	t.Log("valid config")
+	{
+		configStr := `format_version: 1.3.0
+workflows:
+  primary:
+    steps:
+    - script@1.1.0:
+        run_if: .IsCI
+`
+		errs, err := StrictConfigErrors([]byte(configStr), ConfigFormatYML)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(errs))
+	}
+}
@@ -0,0 +1,157 @@
+package bitrise
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/go-utils/cmdex"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+const crashDiagnosticsDirName = "crash_diagnostics"
+
+// CrashSignalName ...
+func CrashSignalName(signal syscall.Signal) string {
+	if name, found := crashSignalNames[signal]; found {
+		return name
+	}
+	return fmt.Sprintf("signal %d", signal)
+}
+
+// StepCrashSignal returns the signal which killed the step's process, if any.
+// The step either dies directly (the run error holds the signal),
+// or its exit code is reported by a shell (exit code: 128 + signal).
+func StepCrashSignal(exitCode int, err error) (syscall.Signal, bool) {
+	if err == nil {
+		return 0, false
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if waitStatus, ok := exitErr.Sys().(syscall.WaitStatus); ok && waitStatus.Signaled() {
+			signal := waitStatus.Signal()
+			_, isCrash := crashSignalNames[signal]
+			return signal, isCrash
+		}
+	}
+
+	if exitCode > 128 && exitCode < 128+32 {
+		signal := syscall.Signal(exitCode - 128)
+		_, isCrash := crashSignalNames[signal]
+		return signal, isCrash
+	}
+
+	return 0, false
+}
+
+func linuxOOMDiagnostics() string {
+	out, err := cmdex.NewCommand("dmesg").RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return fmt.Sprintf("Failed to read kernel messages (dmesg), error: %s", err)
+	}
+
+	oomRegexp := regexp.MustCompile(`(?i)(out of memory|oom-killer|oom_reaper|killed process)`)
+	lines := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		if oomRegexp.MatchString(line) {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) == 0 {
+		return "No OOM killer message found"
+	}
+	return strings.Join(lines, "\n")
+}
+
+func linuxCoreDumpDiagnostics() string {
+	diagnostics := []string{}
+
+	if content, err := ioutil.ReadFile("/proc/sys/kernel/core_pattern"); err == nil {
+		diagnostics = append(diagnostics, "core pattern: "+strings.TrimSpace(string(content)))
+	}
+	if out, err := cmdex.NewCommand("bash", "-c", "ulimit -c").RunAndReturnTrimmedCombinedOutput(); err == nil {
+		diagnostics = append(diagnostics, "core file size limit (ulimit -c): "+out)
+	}
+
+	return strings.Join(diagnostics, "\n")
+}
+
+func osxCrashReportDiagnostics(since time.Time) string {
+	reportDirs := []string{filepath.Join(pathutil.UserHomeDir(), "Library/Logs/DiagnosticReports"), "/Library/Logs/DiagnosticReports"}
+
+	reports := []string{}
+	for _, reportDir := range reportDirs {
+		fileInfos, err := ioutil.ReadDir(reportDir)
+		if err != nil {
+			continue
+		}
+		for _, fileInfo := range fileInfos {
+			if fileInfo.IsDir() || fileInfo.ModTime().Before(since) {
+				continue
+			}
+			reports = append(reports, filepath.Join(reportDir, fileInfo.Name()))
+		}
+	}
+
+	if len(reports) == 0 {
+		return "No crash report found"
+	}
+	return strings.Join(reports, "\n")
+}
+
+// CollectStepCrashDiagnostics collects the diagnostics available on the host
+// about a step, killed by the given signal.
+func CollectStepCrashDiagnostics(signal syscall.Signal, stepStartTime time.Time) string {
+	sections := []string{
+		fmt.Sprintf("signal: %s (%s)", CrashSignalName(signal), signal.String()),
+		fmt.Sprintf("step started at: %s", stepStartTime.Format(time.RFC3339)),
+	}
+
+	switch runtime.GOOS {
+	case "linux":
+		if signal == syscall.SIGKILL {
+			sections = append(sections, "--- OOM killer (dmesg) ---\n"+linuxOOMDiagnostics())
+		}
+		sections = append(sections, "--- Core dump ---\n"+linuxCoreDumpDiagnostics())
+	case "darwin":
+		sections = append(sections, "--- Crash reports ---\n"+osxCrashReportDiagnostics(stepStartTime))
+	}
+
+	return strings.Join(sections, "\n\n") + "\n"
+}
+
+// SaveStepCrashDiagnostics collects the crash diagnostics into the deploy dir (BITRISE_DEPLOY_DIR),
+// so that it's exported as an artifact of the run, and returns the diagnostic file's path.
+func SaveStepCrashDiagnostics(signal syscall.Signal, stepID string, stepIdx int, stepStartTime time.Time) (string, error) {
+	deployDir := os.Getenv(configs.BitriseDeployDirEnvKey)
+	if deployDir == "" {
+		deployDir = configs.BitriseWorkDirPath
+	}
+
+	diagnosticsDir := filepath.Join(deployDir, crashDiagnosticsDirName)
+	if err := os.MkdirAll(diagnosticsDir, 0777); err != nil {
+		CheckDiskFull(err)
+		return "", err
+	}
+
+	stepID = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`).ReplaceAllString(stepID, "_")
+	pth := filepath.Join(diagnosticsDir, fmt.Sprintf("%d_%s.log", stepIdx, stepID))
+
+	diagnostics := CollectStepCrashDiagnostics(signal, stepStartTime)
+	log.Debugf("[BITRISE_CLI] - Step crash diagnostics:\n%s", diagnostics)
+
+	if err := WriteNonEssentialBytesToFile(pth, []byte(diagnostics)); err != nil {
+		return "", err
+	}
+	return pth, nil
+}
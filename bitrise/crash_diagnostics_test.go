@@ -0,0 +1,55 @@
+package bitrise
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepCrashSignal(t *testing.T) {
+	t.Log("no error")
+	{
+		_, isCrash := StepCrashSignal(0, nil)
+		require.Equal(t, false, isCrash)
+	}
+
+	t.Log("simple failure")
+	{
+		_, isCrash := StepCrashSignal(1, errors.New("exit status 1"))
+		require.Equal(t, false, isCrash)
+	}
+
+	t.Log("shell reported SIGSEGV")
+	{
+		signal, isCrash := StepCrashSignal(139, errors.New("exit status 139"))
+		require.Equal(t, true, isCrash)
+		require.Equal(t, syscall.SIGSEGV, signal)
+		require.Equal(t, "SIGSEGV", CrashSignalName(signal))
+	}
+
+	t.Log("shell reported SIGINT - not a crash")
+	{
+		_, isCrash := StepCrashSignal(130, errors.New("exit status 130"))
+		require.Equal(t, false, isCrash)
+	}
+
+	t.Log("process killed by SIGKILL")
+	{
+		err := exec.Command("bash", "-c", "kill -9 $$").Run()
+		require.Error(t, err)
+
+		signal, isCrash := StepCrashSignal(-1, err)
+		require.Equal(t, true, isCrash)
+		require.Equal(t, syscall.SIGKILL, signal)
+	}
+}
+
+func TestCollectStepCrashDiagnostics(t *testing.T) {
+	diagnostics := CollectStepCrashDiagnostics(syscall.SIGSEGV, time.Now())
+	require.Equal(t, true, strings.HasPrefix(diagnostics, "signal: SIGSEGV"))
+}
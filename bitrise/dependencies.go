@@ -8,16 +8,13 @@ import (
 	"time"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/output"
 	"github.com/bitrise-io/bitrise/plugins"
 	"github.com/bitrise-io/bitrise/tools"
 	"github.com/bitrise-io/bitrise/utils"
 	"github.com/bitrise-io/go-utils/cmdex"
-	"github.com/bitrise-io/go-utils/colorstring"
-	"github.com/bitrise-io/go-utils/progress"
 	"github.com/bitrise-io/go-utils/retry"
 	"github.com/bitrise-io/go-utils/versions"
-	"github.com/bitrise-io/goinp/goinp"
-	stepmanModels "github.com/bitrise-io/stepman/models"
 	ver "github.com/hashicorp/go-version"
 )
 
@@ -73,18 +70,15 @@ func CheckIsPluginInstalled(name string, dependency PluginDependency) error {
 
 	if installOrUpdate {
 		var plugin plugins.Plugin
-		err := progress.SimpleProgressE(".", 2*time.Second, func() error {
-			return retry.Times(2).Wait(5 * time.Second).Try(func(attempt uint) error {
-				if attempt > 0 {
-					fmt.Println()
-					fmt.Print("==> Download failed, retrying ...")
-				}
-				p, _, err := plugins.InstallPlugin(dependency.Source, dependency.Binary, dependency.MinVersion)
-				plugin = p
-				return err
-			})
+		log.Infof("Installing plugin (%s)...", name)
+		err := retry.Times(2).Wait(5 * time.Second).Try(func(attempt uint) error {
+			if attempt > 0 {
+				log.Warnln("==> Download failed, retrying ...")
+			}
+			p, _, err := plugins.InstallPlugin(dependency.Source, dependency.Binary, dependency.MinVersion)
+			plugin = p
+			return err
 		})
-		fmt.Println()
 
 		if err != nil {
 			return fmt.Errorf("Failed to install plugin, error: %s", err)
@@ -99,7 +93,7 @@ func CheckIsPluginInstalled(name string, dependency PluginDependency) error {
 
 	pluginDir := plugins.GetPluginDir(name)
 
-	log.Infof(" * %s Plugin (%s) : %s", colorstring.Green("[OK]"), name, pluginDir)
+	log.Infof(" * %s Plugin (%s) : %s", output.Green("[OK]"), name, pluginDir)
 	log.Infof("        version : %s", currentVersion)
 
 	return nil
@@ -163,7 +157,7 @@ func CheckIsHomebrewInstalled(isFullSetupMode bool) error {
 		}
 	}
 
-	log.Infoln(" * "+colorstring.Green("[OK]")+" Homebrew :", progInstallPth)
+	log.Infoln(" * "+output.Green("[OK]")+" Homebrew :", progInstallPth)
 	log.Infoln("        version :", verStr)
 	return nil
 }
@@ -193,16 +187,16 @@ func PrintInstalledXcodeInfos() error {
 		verStr = strings.Join(strings.Split(verStr, "\n"), " | ")
 	}
 
-	log.Infoln(" * "+colorstring.Green("[OK]")+" xcodebuild path :", progInstallPth)
+	log.Infoln(" * "+output.Green("[OK]")+" xcodebuild path :", progInstallPth)
 	if !isFullXcodeAvailable {
-		log.Infoln("        version (xcodebuild) :", colorstring.Yellowf("%s", verStr))
+		log.Infoln("        version (xcodebuild) :", output.Yellowf("%s", verStr))
 	} else {
 		log.Infoln("        version (xcodebuild) :", verStr)
 	}
 	log.Infoln("        active Xcode (Command Line Tools) path (xcode-select --print-path) :", xcodeSelectPth)
 	if !isFullXcodeAvailable {
-		log.Warn(colorstring.Yellowf("%s", "No Xcode found, only the Xcode Command Line Tools are available!"))
-		log.Warn(colorstring.Yellowf("%s", "Full Xcode is required to build, test and archive iOS apps!"))
+		log.Warn(output.Yellowf("%s", "No Xcode found, only the Xcode Command Line Tools are available!"))
+		log.Warn(output.Yellowf("%s", "Full Xcode is required to build, test and archive iOS apps!"))
 	}
 
 	return nil
@@ -216,17 +210,13 @@ func checkIsBitriseToolInstalled(toolname, minVersion string, isInstall bool) er
 		log.Infoln("You can find more information about "+toolname+" on its official GitHub page:", officialGithub)
 
 		// Install
-		fmt.Print("Installing...")
-		err := progress.SimpleProgressE(".", 2*time.Second, func() error {
-			return retry.Times(2).Wait(5 * time.Second).Try(func(attempt uint) error {
-				if attempt > 0 {
-					fmt.Println()
-					fmt.Print("==> Download failed, retrying ...")
-				}
-				return tools.InstallToolFromGitHub(toolname, "bitrise-io", minVersion)
-			})
+		log.Infoln("Installing...")
+		err := retry.Times(2).Wait(5 * time.Second).Try(func(attempt uint) error {
+			if attempt > 0 {
+				log.Warnln("==> Download failed, retrying ...")
+			}
+			return tools.InstallToolFromGitHub(toolname, "bitrise-io", minVersion)
 		})
-		fmt.Println()
 		if err != nil {
 			return err
 		}
@@ -265,7 +255,7 @@ func checkIsBitriseToolInstalled(toolname, minVersion string, isInstall bool) er
 		return doInstall()
 	}
 
-	log.Infoln(" * "+colorstring.Green("[OK]")+" "+toolname+" :", progInstallPth)
+	log.Infoln(" * "+output.Green("[OK]")+" "+toolname+" :", progInstallPth)
 	log.Infoln("        version :", verStr)
 	return nil
 }
@@ -290,29 +280,6 @@ func CheckIsStepmanInstalled(minStepmanVersion string) error {
 	return nil
 }
 
-func checkIfBrewPackageInstalled(packageName string) bool {
-	cmd := exec.Command("brew", "list", packageName)
-
-	outBytes, err := cmd.CombinedOutput()
-	if err != nil {
-		log.Debugf("%s", outBytes)
-		return false
-	}
-
-	return len(outBytes) > 0
-}
-
-func checkIfAptPackageInstalled(packageName string) bool {
-	cmd := exec.Command("dpkg", "-l", packageName)
-
-	if outBytes, err := cmd.CombinedOutput(); err != nil {
-		log.Debugf("%s", outBytes)
-		return false
-	}
-
-	return true
-}
-
 // DependencyTryCheckTool ...
 func DependencyTryCheckTool(tool string) error {
 	var cmd *exec.Cmd
@@ -345,114 +312,3 @@ func DependencyTryCheckTool(tool string) error {
 
 	return nil
 }
-
-// InstallWithBrewIfNeeded ...
-func InstallWithBrewIfNeeded(brewDep stepmanModels.BrewDepModel, isCIMode bool) error {
-	isDepInstalled := false
-	// First do a "which", to see if the binary is available.
-	// Can be available from another source, not just from brew,
-	// e.g. it's common to use NVM or similar to install and manage the Node.js version.
-	{
-		if out, err := cmdex.RunCommandAndReturnCombinedStdoutAndStderr("which", brewDep.GetBinaryName()); err != nil {
-			if err.Error() == "exit status 1" && out == "" {
-				isDepInstalled = false
-			} else {
-				// unexpected `which` error
-				return fmt.Errorf("which (%s) failed -- out: (%s) err: (%s)", brewDep.Name, out, err)
-			}
-		} else if out != "" {
-			isDepInstalled = true
-		} else {
-			// no error but which's output was empty
-			return fmt.Errorf("which (%s) failed -- no error (exit code 0) but output was empty", brewDep.Name)
-		}
-	}
-
-	// then do a package manager specific lookup
-	{
-		if !isDepInstalled {
-			// which did not find the binary, also check in brew,
-			// whether the package is installed
-			isDepInstalled = checkIfBrewPackageInstalled(brewDep.Name)
-		}
-	}
-
-	if !isDepInstalled {
-		// Tool isn't installed -- install it...
-		if !isCIMode {
-			log.Infof(`This step requires "%s" to be available, but it is not installed.`, brewDep.GetBinaryName())
-			allow, err := goinp.AskForBoolWithDefault(`Would you like to install the "`+brewDep.Name+`" package with brew?`, true)
-			if err != nil {
-				return err
-			}
-			if !allow {
-				return errors.New("(" + brewDep.Name + ") is required for step")
-			}
-		}
-
-		log.Infof("(%s) isn't installed, installing...", brewDep.Name)
-		if cmdOut, err := cmdex.RunCommandAndReturnCombinedStdoutAndStderr("brew", "install", brewDep.Name); err != nil {
-			log.Errorf("brew install %s failed -- out: (%s) err: (%s)", brewDep.Name, cmdOut, err)
-			return err
-		}
-		log.Infof(" * "+colorstring.Green("[OK]")+" %s installed", brewDep.Name)
-	}
-
-	return nil
-}
-
-// InstallWithAptGetIfNeeded ...
-func InstallWithAptGetIfNeeded(aptGetDep stepmanModels.AptGetDepModel, isCIMode bool) error {
-	isDepInstalled := false
-	// First do a "which", to see if the binary is available.
-	// Can be available from another source, not just from brew,
-	// e.g. it's common to use NVM or similar to install and manage the Node.js version.
-	{
-		if out, err := cmdex.RunCommandAndReturnCombinedStdoutAndStderr("which", aptGetDep.GetBinaryName()); err != nil {
-			if err.Error() == "exit status 1" && out == "" {
-				isDepInstalled = false
-			} else {
-				// unexpected `which` error
-				return fmt.Errorf("which (%s) failed -- out: (%s) err: (%s)", aptGetDep.Name, out, err)
-			}
-		} else if out != "" {
-			isDepInstalled = true
-		} else {
-			// no error but which's output was empty
-			return fmt.Errorf("which (%s) failed -- no error (exit code 0) but output was empty", aptGetDep.Name)
-		}
-	}
-
-	// then do a package manager specific lookup
-	{
-		if !isDepInstalled {
-			// which did not find the binary, also check in brew,
-			// whether the package is installed
-			isDepInstalled = checkIfAptPackageInstalled(aptGetDep.Name)
-		}
-	}
-
-	if !isDepInstalled {
-		// Tool isn't installed -- install it...
-		if !isCIMode {
-			log.Infof(`This step requires "%s" to be available, but it is not installed.`, aptGetDep.GetBinaryName())
-			allow, err := goinp.AskForBoolWithDefault(`Would you like to install the "`+aptGetDep.Name+`" package with apt-get?`, true)
-			if err != nil {
-				return err
-			}
-			if !allow {
-				return errors.New("(" + aptGetDep.Name + ") is required for step")
-			}
-		}
-
-		log.Infof("(%s) isn't installed, installing...", aptGetDep.Name)
-		if cmdOut, err := cmdex.RunCommandAndReturnCombinedStdoutAndStderr("sudo", "apt-get", "-y", "install", aptGetDep.Name); err != nil {
-			log.Errorf("sudo apt-get -y install %s failed -- out: (%s) err: (%s)", aptGetDep.Name, cmdOut, err)
-			return err
-		}
-
-		log.Infof(" * "+colorstring.Green("[OK]")+" %s installed", aptGetDep.Name)
-	}
-
-	return nil
-}
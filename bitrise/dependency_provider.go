@@ -0,0 +1,288 @@
+package bitrise
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/go-utils/cmdex"
+	"github.com/bitrise-io/goinp/goinp"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// Dependency providers: the package dependencies of the steps (step.yml's deps) are installed with the package manager
+// of the host, selected by the platform (brew on MacOS, apt-get or dnf on Linux, whichever is available, none on Windows),
+// or by the --dependency-manager flag (BITRISE_DEPENDENCY_MANAGER env), which can also disable the installation (none).
+// The deps.apt_get packages of a step are the Linux packages, installed with dnf as well on the RPM based distributions.
+
+const (
+	// DependencyManagerAuto selects the package manager by the platform
+	DependencyManagerAuto = ""
+	// DependencyManagerBrew ...
+	DependencyManagerBrew = "brew"
+	// DependencyManagerApt ...
+	DependencyManagerApt = "apt"
+	// DependencyManagerDnf ...
+	DependencyManagerDnf = "dnf"
+	// DependencyManagerNone doesn't install the dependencies, they have to be preinstalled
+	DependencyManagerNone = "none"
+)
+
+// DependencyModel is a package dependency of a step
+type DependencyModel struct {
+	// Name is the name of the package
+	Name string
+	// BinName is the name of the package's binary, if it doesn't match the package's name
+	BinName string
+}
+
+// GetBinaryName ...
+func (dep DependencyModel) GetBinaryName() string {
+	if dep.BinName != "" {
+		return dep.BinName
+	}
+	return dep.Name
+}
+
+// DependencyProvider installs the package dependencies of the steps
+type DependencyProvider interface {
+	// Name is the name of the package manager (e.g. brew)
+	Name() string
+	// Dependencies returns the step's dependencies, handled by this provider
+	Dependencies(step stepmanModels.StepModel) []DependencyModel
+	// IsInstalled returns true if the package is installed
+	IsInstalled(dep DependencyModel) bool
+	// Install installs the package
+	Install(dep DependencyModel) error
+}
+
+// BrewDependencyProvider installs the step's deps.brew packages with Homebrew
+type BrewDependencyProvider struct{}
+
+// Name ...
+func (provider BrewDependencyProvider) Name() string {
+	return DependencyManagerBrew
+}
+
+// Dependencies ...
+func (provider BrewDependencyProvider) Dependencies(step stepmanModels.StepModel) []DependencyModel {
+	deps := []DependencyModel{}
+	for _, dep := range step.Deps.Brew {
+		deps = append(deps, DependencyModel{Name: dep.Name, BinName: dep.BinName})
+	}
+	return deps
+}
+
+// IsInstalled ...
+func (provider BrewDependencyProvider) IsInstalled(dep DependencyModel) bool {
+	outBytes, err := exec.Command("brew", "list", dep.Name).CombinedOutput()
+	if err != nil {
+		log.Debugf("%s", outBytes)
+		return false
+	}
+	return len(outBytes) > 0
+}
+
+// Install ...
+func (provider BrewDependencyProvider) Install(dep DependencyModel) error {
+	return runDependencyInstall("brew", "install", dep.Name)
+}
+
+// AptDependencyProvider installs the step's deps.apt_get packages with apt-get
+type AptDependencyProvider struct{}
+
+// Name ...
+func (provider AptDependencyProvider) Name() string {
+	return DependencyManagerApt
+}
+
+// Dependencies ...
+func (provider AptDependencyProvider) Dependencies(step stepmanModels.StepModel) []DependencyModel {
+	return linuxDependencies(step)
+}
+
+// IsInstalled ...
+func (provider AptDependencyProvider) IsInstalled(dep DependencyModel) bool {
+	if outBytes, err := exec.Command("dpkg", "-l", dep.Name).CombinedOutput(); err != nil {
+		log.Debugf("%s", outBytes)
+		return false
+	}
+	return true
+}
+
+// Install ...
+func (provider AptDependencyProvider) Install(dep DependencyModel) error {
+	return runDependencyInstall("sudo", "apt-get", "-y", "install", dep.Name)
+}
+
+// DnfDependencyProvider installs the step's deps.apt_get packages with dnf (Fedora, RHEL, CentOS)
+type DnfDependencyProvider struct{}
+
+// Name ...
+func (provider DnfDependencyProvider) Name() string {
+	return DependencyManagerDnf
+}
+
+// Dependencies ...
+func (provider DnfDependencyProvider) Dependencies(step stepmanModels.StepModel) []DependencyModel {
+	return linuxDependencies(step)
+}
+
+// IsInstalled ...
+func (provider DnfDependencyProvider) IsInstalled(dep DependencyModel) bool {
+	if outBytes, err := exec.Command("rpm", "-q", dep.Name).CombinedOutput(); err != nil {
+		log.Debugf("%s", outBytes)
+		return false
+	}
+	return true
+}
+
+// Install ...
+func (provider DnfDependencyProvider) Install(dep DependencyModel) error {
+	return runDependencyInstall("sudo", "dnf", "-y", "install", dep.Name)
+}
+
+// NoopDependencyProvider doesn't handle any dependency, the dependency installation is disabled
+type NoopDependencyProvider struct{}
+
+// Name ...
+func (provider NoopDependencyProvider) Name() string {
+	return DependencyManagerNone
+}
+
+// Dependencies ...
+func (provider NoopDependencyProvider) Dependencies(step stepmanModels.StepModel) []DependencyModel {
+	return []DependencyModel{}
+}
+
+// IsInstalled ...
+func (provider NoopDependencyProvider) IsInstalled(dep DependencyModel) bool {
+	return true
+}
+
+// Install ...
+func (provider NoopDependencyProvider) Install(dep DependencyModel) error {
+	return nil
+}
+
+func linuxDependencies(step stepmanModels.StepModel) []DependencyModel {
+	deps := []DependencyModel{}
+	for _, dep := range step.Deps.AptGet {
+		deps = append(deps, DependencyModel{Name: dep.Name, BinName: dep.BinName})
+	}
+	return deps
+}
+
+func runDependencyInstall(name string, args ...string) error {
+	cmdStr := strings.Join(append([]string{name}, args...), " ")
+	if cmdOut, err := cmdex.RunCommandAndReturnCombinedStdoutAndStderr(name, args...); err != nil {
+		log.Errorf("%s failed -- out: (%s) err: (%s)", cmdStr, cmdOut, err)
+		return err
+	}
+	return nil
+}
+
+// ParseDependencyManager ...
+func ParseDependencyManager(manager string) (string, error) {
+	switch manager {
+	case DependencyManagerAuto, DependencyManagerBrew, DependencyManagerApt, DependencyManagerDnf, DependencyManagerNone:
+		return manager, nil
+	}
+	return "", fmt.Errorf("invalid dependency manager (%s), accepted: %s", manager,
+		strings.Join([]string{DependencyManagerBrew, DependencyManagerApt, DependencyManagerDnf, DependencyManagerNone}, ", "))
+}
+
+// NewDependencyProvider returns the provider of the dependency manager,
+// or the one available on the platform, if the manager is not specified.
+func NewDependencyProvider(manager, goos string, isAvailable func(tool string) bool) (DependencyProvider, error) {
+	switch manager {
+	case DependencyManagerBrew:
+		return BrewDependencyProvider{}, nil
+	case DependencyManagerApt:
+		return AptDependencyProvider{}, nil
+	case DependencyManagerDnf:
+		return DnfDependencyProvider{}, nil
+	case DependencyManagerNone:
+		return NoopDependencyProvider{}, nil
+	case DependencyManagerAuto:
+	default:
+		return nil, fmt.Errorf("invalid dependency manager (%s)", manager)
+	}
+
+	switch goos {
+	case "darwin":
+		return BrewDependencyProvider{}, nil
+	case "linux":
+		if isAvailable("apt-get") {
+			return AptDependencyProvider{}, nil
+		}
+		if isAvailable("dnf") {
+			return DnfDependencyProvider{}, nil
+		}
+		log.Warnf("Neither apt-get nor dnf found, the step dependencies won't be installed")
+		return NoopDependencyProvider{}, nil
+	case "windows":
+		// the steps' deps are brew and apt-get packages, no Windows package manager is supported
+		return NoopDependencyProvider{}, nil
+	}
+	return nil, errors.New("Unsupported os")
+}
+
+// IsToolAvailable returns true if the tool is in the PATH
+func IsToolAvailable(tool string) bool {
+	_, err := exec.LookPath(tool)
+	return err == nil
+}
+
+// isDependencyBinaryAvailable does a "which", to see if the binary is available.
+// Can be available from another source, not just from the package manager,
+// e.g. it's common to use NVM or similar to install and manage the Node.js version.
+func isDependencyBinaryAvailable(dep DependencyModel) (bool, error) {
+	out, err := cmdex.RunCommandAndReturnCombinedStdoutAndStderr("which", dep.GetBinaryName())
+	if err != nil {
+		if err.Error() == "exit status 1" && out == "" {
+			return false, nil
+		}
+		// unexpected `which` error
+		return false, fmt.Errorf("which (%s) failed -- out: (%s) err: (%s)", dep.Name, out, err)
+	}
+	if out == "" {
+		// no error but which's output was empty
+		return false, fmt.Errorf("which (%s) failed -- no error (exit code 0) but output was empty", dep.Name)
+	}
+	return true, nil
+}
+
+// InstallDependencyIfNeeded installs the package with the provider, if neither its binary,
+// nor the package is installed. Asks for confirmation, if not in CI mode.
+func InstallDependencyIfNeeded(provider DependencyProvider, dep DependencyModel, isCIMode bool) error {
+	isDepInstalled, err := isDependencyBinaryAvailable(dep)
+	if err != nil {
+		return err
+	}
+	if isDepInstalled || provider.IsInstalled(dep) {
+		return nil
+	}
+
+	if !isCIMode {
+		log.Infof(`This step requires "%s" to be available, but it is not installed.`, dep.GetBinaryName())
+		allow, err := goinp.AskForBoolWithDefault(fmt.Sprintf(`Would you like to install the "%s" package with %s?`, dep.Name, provider.Name()), true)
+		if err != nil {
+			return err
+		}
+		if !allow {
+			return errors.New("(" + dep.Name + ") is required for step")
+		}
+	}
+
+	log.Infof("(%s) isn't installed, installing...", dep.Name)
+	if err := provider.Install(dep); err != nil {
+		return err
+	}
+	log.Infof(" * "+output.Green("[OK]")+" %s installed", dep.Name)
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package bitrise
+
+import (
+	"testing"
+
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDependencyProvider(t *testing.T) {
+	available := func(tools ...string) func(string) bool {
+		return func(tool string) bool {
+			for _, availableTool := range tools {
+				if tool == availableTool {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	t.Log("selected by the platform")
+	{
+		for _, testCase := range []struct {
+			goos     string
+			tools    []string
+			expected string
+		}{
+			{goos: "darwin", expected: DependencyManagerBrew},
+			{goos: "linux", tools: []string{"apt-get", "dnf"}, expected: DependencyManagerApt},
+			{goos: "linux", tools: []string{"dnf"}, expected: DependencyManagerDnf},
+			{goos: "linux", expected: DependencyManagerNone},
+		} {
+			provider, err := NewDependencyProvider(DependencyManagerAuto, testCase.goos, available(testCase.tools...))
+			require.NoError(t, err)
+			require.Equal(t, testCase.expected, provider.Name())
+		}
+
+		_, err := NewDependencyProvider(DependencyManagerAuto, "freebsd", available())
+		require.Error(t, err)
+	}
+
+	t.Log("selected by the config")
+	{
+		for _, manager := range []string{DependencyManagerBrew, DependencyManagerApt, DependencyManagerDnf, DependencyManagerNone} {
+			provider, err := NewDependencyProvider(manager, "linux", available("apt-get"))
+			require.NoError(t, err)
+			require.Equal(t, manager, provider.Name())
+		}
+
+		_, err := ParseDependencyManager("yum")
+		require.Error(t, err)
+	}
+}
+
+func TestDependencyProviderDependencies(t *testing.T) {
+	step := stepmanModels.StepModel{
+		Deps: stepmanModels.DepsModel{
+			Brew:   []stepmanModels.BrewDepModel{stepmanModels.BrewDepModel{Name: "awscli", BinName: "aws"}},
+			AptGet: []stepmanModels.AptGetDepModel{stepmanModels.AptGetDepModel{Name: "git"}},
+		},
+	}
+
+	require.Equal(t, []DependencyModel{DependencyModel{Name: "awscli", BinName: "aws"}}, BrewDependencyProvider{}.Dependencies(step))
+	require.Equal(t, []DependencyModel{DependencyModel{Name: "git"}}, AptDependencyProvider{}.Dependencies(step))
+	require.Equal(t, []DependencyModel{DependencyModel{Name: "git"}}, DnfDependencyProvider{}.Dependencies(step))
+	require.Equal(t, []DependencyModel{}, NoopDependencyProvider{}.Dependencies(step))
+
+	require.Equal(t, "aws", DependencyModel{Name: "awscli", BinName: "aws"}.GetBinaryName())
+	require.Equal(t, "git", DependencyModel{Name: "git"}.GetBinaryName())
+}
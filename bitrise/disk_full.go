@@ -0,0 +1,120 @@
+package bitrise
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/go-utils/fileutil"
+)
+
+// ErrDiskFull ...
+var ErrDiskFull = errors.New("no space left on device")
+
+// isDiskFull is also set from the output copying goroutines of the step processes, use atomic access
+var isDiskFull int32
+
+// IsNoSpaceLeftOnDeviceError ...
+func IsNoSpaceLeftOnDeviceError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	cause := err
+	switch typedErr := err.(type) {
+	case *os.PathError:
+		cause = typedErr.Err
+	case *os.LinkError:
+		cause = typedErr.Err
+	case *os.SyscallError:
+		cause = typedErr.Err
+	}
+
+	if errno, ok := cause.(syscall.Errno); ok {
+		return errno == syscall.ENOSPC
+	}
+
+	// errors of the tools (envman, stepman) are only available as text
+	return err == ErrDiskFull || strings.Contains(strings.ToLower(err.Error()), ErrDiskFull.Error())
+}
+
+// IsDiskFull ...
+func IsDiskFull() bool {
+	return atomic.LoadInt32(&isDiskFull) == 1
+}
+
+// CheckDiskFull registers the disk full state if the given error is an ENOSPC error.
+// The diagnostic is printed only for the first occurrence,
+// to not to cascade into a series of secondary errors.
+func CheckDiskFull(err error) bool {
+	if !IsNoSpaceLeftOnDeviceError(err) {
+		return false
+	}
+
+	if atomic.CompareAndSwapInt32(&isDiskFull, 0, 1) {
+		log.Error(output.Red("No space left on device!"))
+		log.Errorf("Failed to write to the disk, error: %s", err)
+		log.Error("Non essential writes (logs, caches, debug artifacts) are disabled from now on,")
+		log.Error(" the remaining steps won't be started.")
+		log.Error("Free up some space and run the build again.")
+	}
+	return true
+}
+
+// WriteNonEssentialBytesToFile writes the file only if the disk is not full.
+// Use it for writes (logs, caches, debug artifacts) which are not required for the build.
+func WriteNonEssentialBytesToFile(pth string, bytes []byte) error {
+	if IsDiskFull() {
+		log.Debugf("[BITRISE_CLI] - Disk is full, skipping write: %s", pth)
+		return nil
+	}
+
+	if err := fileutil.WriteBytesToFile(pth, bytes); err != nil {
+		if CheckDiskFull(err) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// WriteEssentialBytesToFile writes the file even if the disk is full.
+// Use it for writes (e.g. the build summary) which are expected to be produced by every run,
+// on a full disk it returns ErrDiskFull, instead of the raw error of the write.
+func WriteEssentialBytesToFile(pth string, bytes []byte) error {
+	if err := fileutil.WriteBytesToFile(pth, bytes); err != nil {
+		if CheckDiskFull(err) {
+			return ErrDiskFull
+		}
+		return err
+	}
+	return nil
+}
+
+// diskFullGuardedWriter drops the writes once the disk is full,
+// so a stream (e.g. the run log) doesn't fail with a series of ENOSPC errors
+type diskFullGuardedWriter struct {
+	writer io.Writer
+}
+
+// NewDiskFullGuardedWriter returns a writer which writes into the given writer only while the disk is not full
+func NewDiskFullGuardedWriter(writer io.Writer) io.Writer {
+	return &diskFullGuardedWriter{writer: writer}
+}
+
+func (writer *diskFullGuardedWriter) Write(p []byte) (int, error) {
+	if IsDiskFull() {
+		return len(p), nil
+	}
+
+	n, err := writer.writer.Write(p)
+	if err != nil && CheckDiskFull(err) {
+		return len(p), nil
+	}
+	return n, err
+}
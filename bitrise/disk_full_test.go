@@ -0,0 +1,128 @@
+package bitrise
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsNoSpaceLeftOnDeviceError(t *testing.T) {
+	t.Log("nil error")
+	{
+		require.Equal(t, false, IsNoSpaceLeftOnDeviceError(nil))
+	}
+
+	t.Log("errno")
+	{
+		require.Equal(t, true, IsNoSpaceLeftOnDeviceError(syscall.ENOSPC))
+		require.Equal(t, false, IsNoSpaceLeftOnDeviceError(syscall.EACCES))
+	}
+
+	t.Log("path error")
+	{
+		err := &os.PathError{Op: "write", Path: "/tmp/file", Err: syscall.ENOSPC}
+		require.Equal(t, true, IsNoSpaceLeftOnDeviceError(err))
+
+		err = &os.PathError{Op: "write", Path: "/tmp/file", Err: syscall.EACCES}
+		require.Equal(t, false, IsNoSpaceLeftOnDeviceError(err))
+	}
+
+	t.Log("tool output")
+	{
+		err := errors.New("failed to init envstore, error: write /tmp/envstore.yml: No space left on device")
+		require.Equal(t, true, IsNoSpaceLeftOnDeviceError(err))
+
+		err = errors.New("exit status 1")
+		require.Equal(t, false, IsNoSpaceLeftOnDeviceError(err))
+	}
+}
+
+func TestWriteNonEssentialBytesToFile(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("disk_full_test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+		atomic.StoreInt32(&isDiskFull, 0)
+	}()
+
+	t.Log("writes if disk is not full")
+	{
+		pth := filepath.Join(tmpDir, "written.txt")
+		require.NoError(t, WriteNonEssentialBytesToFile(pth, []byte("content")))
+
+		exist, err := pathutil.IsPathExists(pth)
+		require.NoError(t, err)
+		require.Equal(t, true, exist)
+	}
+
+	t.Log("skips if disk is full")
+	{
+		require.Equal(t, true, CheckDiskFull(syscall.ENOSPC))
+		require.Equal(t, true, IsDiskFull())
+
+		pth := filepath.Join(tmpDir, "skipped.txt")
+		require.NoError(t, WriteNonEssentialBytesToFile(pth, []byte("content")))
+
+		exist, err := pathutil.IsPathExists(pth)
+		require.NoError(t, err)
+		require.Equal(t, false, exist)
+	}
+}
+
+type failingWriter struct {
+	err     error
+	written int
+}
+
+func (writer *failingWriter) Write(p []byte) (int, error) {
+	if writer.err != nil {
+		return 0, writer.err
+	}
+	writer.written += len(p)
+	return len(p), nil
+}
+
+func TestDiskFullGuardedWriter(t *testing.T) {
+	defer func() {
+		atomic.StoreInt32(&isDiskFull, 0)
+	}()
+
+	t.Log("writes if disk is not full")
+	{
+		writer := &failingWriter{}
+		n, err := NewDiskFullGuardedWriter(writer).Write([]byte("line\n"))
+		require.NoError(t, err)
+		require.Equal(t, 5, n)
+		require.Equal(t, 5, writer.written)
+	}
+
+	t.Log("returns the other errors")
+	{
+		writer := &failingWriter{err: &os.PathError{Op: "write", Path: "/tmp/file", Err: syscall.EACCES}}
+		_, err := NewDiskFullGuardedWriter(writer).Write([]byte("line\n"))
+		require.Error(t, err)
+		require.Equal(t, false, IsDiskFull())
+	}
+
+	t.Log("drops the writes once the disk is full")
+	{
+		writer := &failingWriter{err: &os.PathError{Op: "write", Path: "/tmp/file", Err: syscall.ENOSPC}}
+		guardedWriter := NewDiskFullGuardedWriter(writer)
+
+		n, err := guardedWriter.Write([]byte("line\n"))
+		require.NoError(t, err)
+		require.Equal(t, 5, n)
+		require.Equal(t, true, IsDiskFull())
+
+		writer.err = nil
+		_, err = guardedWriter.Write([]byte("line\n"))
+		require.NoError(t, err)
+		require.Equal(t, 0, writer.written)
+	}
+}
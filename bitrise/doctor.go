@@ -0,0 +1,337 @@
+package bitrise
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/bitrise/plugins"
+	"github.com/bitrise-io/bitrise/version"
+	"github.com/bitrise-io/go-utils/cmdex"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-utils/versions"
+)
+
+// Doctor (see: bitrise doctor): diagnoses the environment of the CLI - the versions of the required tools,
+// the permissions of the CLI's directories, the reachability of the StepLib and the GitHub releases,
+// the health of the installed plugins and the validity of the config - into a report,
+// which can be attached to support tickets (--format json).
+
+const (
+	// DoctorStatusOK ...
+	DoctorStatusOK = "ok"
+	// DoctorStatusWarning ...
+	DoctorStatusWarning = "warning"
+	// DoctorStatusFailed ...
+	DoctorStatusFailed = "failed"
+
+	// DoctorCategoryTools ...
+	DoctorCategoryTools = "tools"
+	// DoctorCategoryDirectories ...
+	DoctorCategoryDirectories = "directories"
+	// DoctorCategoryNetwork ...
+	DoctorCategoryNetwork = "network"
+	// DoctorCategoryPlugins ...
+	DoctorCategoryPlugins = "plugins"
+	// DoctorCategoryConfig ...
+	DoctorCategoryConfig = "config"
+
+	doctorStepLibURL        = "https://github.com/bitrise-io/bitrise-steplib.git/info/refs?service=git-upload-pack"
+	doctorGitHubReleasesURL = "https://github.com/bitrise-io/envman/releases"
+	doctorNetworkTimeout    = 10 * time.Second
+)
+
+var doctorToolVersionRegexp = regexp.MustCompile(`[0-9]+(\.[0-9]+)+`)
+
+// DoctorCheckModel is the result of a diagnostic check
+type DoctorCheckModel struct {
+	Category string `json:"category"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Message  string `json:"message,omitempty"`
+	// Value is the checked value: the version of a tool, the path of a directory, ...
+	Value string `json:"value,omitempty"`
+}
+
+// DoctorReportModel ...
+type DoctorReportModel struct {
+	Status         string             `json:"status"`
+	BitriseVersion string             `json:"bitrise_version"`
+	OS             string             `json:"os"`
+	Arch           string             `json:"arch"`
+	GeneratedAt    time.Time          `json:"generated_at"`
+	Checks         []DoctorCheckModel `json:"checks"`
+}
+
+// NewDoctorReport returns the report of the checks, its status is the worst status of the checks
+func NewDoctorReport(checks []DoctorCheckModel) DoctorReportModel {
+	status := DoctorStatusOK
+	for _, check := range checks {
+		if check.Status == DoctorStatusFailed {
+			status = DoctorStatusFailed
+		} else if check.Status == DoctorStatusWarning && status == DoctorStatusOK {
+			status = DoctorStatusWarning
+		}
+	}
+
+	return DoctorReportModel{
+		Status:         status,
+		BitriseVersion: version.VERSION,
+		OS:             runtime.GOOS,
+		Arch:           runtime.GOARCH,
+		GeneratedAt:    time.Now(),
+		Checks:         checks,
+	}
+}
+
+// RunDoctorChecks runs the tool, directory, network and plugin checks, the network checks are skipped in offline mode
+func RunDoctorChecks(isOffline bool) []DoctorCheckModel {
+	checks := []DoctorCheckModel{
+		newToolVersionCheck("stepman", minStepmanVersion, true, toolVersionOutput("stepman", "--version")),
+		newToolVersionCheck("envman", minEnvmanVersion, true, toolVersionOutput("envman", "--version")),
+		newToolVersionCheck("git", "", true, toolVersionOutput("git", "--version")),
+	}
+	if runtime.GOOS == "darwin" {
+		checks = append(checks, newToolVersionCheck("xcodebuild", "", false, toolVersionOutput("xcodebuild", "-version")))
+	}
+
+	checks = append(checks,
+		newDirectoryCheck("bitrise home", configs.GetBitriseHomeDirPath()),
+		newDirectoryCheck("tools", configs.GetBitriseToolsDirPath()),
+		newDirectoryCheck("plugins", plugins.GetPluginsDir()),
+		newDirectoryCheck("temporary", os.TempDir()),
+	)
+
+	if !isOffline {
+		client := configs.NewHTTPClient(doctorNetworkTimeout)
+		checks = append(checks,
+			newURLReachableCheck(client, "steplib", doctorStepLibURL),
+			newURLReachableCheck(client, "github releases", doctorGitHubReleasesURL),
+		)
+	}
+
+	return append(checks, newPluginChecks()...)
+}
+
+// toolVersionOutput returns a func, which runs the tool with the version args
+func toolVersionOutput(tool string, args ...string) func() (string, error) {
+	return func() (string, error) {
+		return cmdex.RunCommandAndReturnCombinedStdoutAndStderr(tool, args...)
+	}
+}
+
+// newToolVersionCheck checks the version of the tool (the first version number of the version command's output),
+// a missing optional tool is only a warning.
+func newToolVersionCheck(tool, minVersion string, isRequired bool, versionOutput func() (string, error)) DoctorCheckModel {
+	check := DoctorCheckModel{Category: DoctorCategoryTools, Name: tool}
+
+	failedStatus := DoctorStatusFailed
+	if !isRequired {
+		failedStatus = DoctorStatusWarning
+	}
+
+	out, err := versionOutput()
+	if err != nil {
+		check.Status = failedStatus
+		check.Message = fmt.Sprintf("not installed or not working: %s", err)
+		return check
+	}
+
+	toolVersion := doctorToolVersionRegexp.FindString(out)
+	if toolVersion == "" {
+		check.Status = DoctorStatusWarning
+		check.Message = fmt.Sprintf("failed to parse version from: %s", strings.TrimSpace(out))
+		return check
+	}
+	check.Value = toolVersion
+
+	if minVersion != "" {
+		isOK, err := versions.IsVersionGreaterOrEqual(toolVersion, minVersion)
+		if err != nil {
+			check.Status = DoctorStatusWarning
+			check.Message = fmt.Sprintf("failed to compare version (%s) with the minimum (%s): %s", toolVersion, minVersion, err)
+			return check
+		}
+		if !isOK {
+			check.Status = failedStatus
+			check.Message = fmt.Sprintf("version %s is older than the minimum supported version (%s), run: bitrise setup", toolVersion, minVersion)
+			return check
+		}
+	}
+
+	check.Status = DoctorStatusOK
+	return check
+}
+
+// newDirectoryCheck checks whether the directory is writable, a not yet created directory is fine,
+// if it can be created (its closest existing parent is writable).
+func newDirectoryCheck(name, dir string) DoctorCheckModel {
+	check := DoctorCheckModel{Category: DoctorCategoryDirectories, Name: name, Value: dir}
+
+	existingDir := dir
+	for {
+		exists, err := pathutil.IsDirExists(existingDir)
+		if err != nil {
+			check.Status = DoctorStatusFailed
+			check.Message = fmt.Sprintf("failed to check directory: %s", err)
+			return check
+		}
+		if exists {
+			break
+		}
+
+		parent := filepath.Dir(existingDir)
+		if parent == existingDir {
+			break
+		}
+		existingDir = parent
+	}
+
+	tmpFile, err := ioutil.TempFile(existingDir, ".bitrise-doctor-")
+	if err != nil {
+		check.Status = DoctorStatusFailed
+		check.Message = fmt.Sprintf("not writable: %s", err)
+		return check
+	}
+	if err := tmpFile.Close(); err != nil {
+		check.Status = DoctorStatusWarning
+		check.Message = fmt.Sprintf("failed to close test file: %s", err)
+	}
+	if err := os.Remove(tmpFile.Name()); err != nil {
+		check.Status = DoctorStatusWarning
+		check.Message = fmt.Sprintf("failed to remove test file: %s", err)
+	}
+	if check.Status != "" {
+		return check
+	}
+
+	check.Status = DoctorStatusOK
+	if existingDir != dir {
+		check.Message = "not created yet"
+	}
+	return check
+}
+
+// newURLReachableCheck checks whether the URL is reachable (responds with a non server error status)
+func newURLReachableCheck(client *http.Client, name, url string) DoctorCheckModel {
+	check := DoctorCheckModel{Category: DoctorCategoryNetwork, Name: name, Value: url}
+
+	startTime := time.Now()
+	response, err := client.Get(url)
+	if err != nil {
+		check.Status = DoctorStatusFailed
+		check.Message = fmt.Sprintf("not reachable: %s", err)
+		return check
+	}
+	if err := response.Body.Close(); err != nil {
+		check.Message = fmt.Sprintf("failed to close response body: %s", err)
+	}
+
+	if response.StatusCode >= http.StatusInternalServerError {
+		check.Status = DoctorStatusFailed
+		check.Message = fmt.Sprintf("responded with status: %s", response.Status)
+		return check
+	}
+
+	check.Status = DoctorStatusOK
+	if check.Message == "" {
+		check.Message = fmt.Sprintf("responded in %s", time.Now().Sub(startTime)/time.Millisecond*time.Millisecond)
+	}
+	return check
+}
+
+// newPluginChecks checks whether the executables of the installed plugins exist
+func newPluginChecks() []DoctorCheckModel {
+	pluginList, err := plugins.InstalledPluginList()
+	if err != nil {
+		return []DoctorCheckModel{
+			DoctorCheckModel{Category: DoctorCategoryPlugins, Name: "plugins", Status: DoctorStatusFailed, Message: fmt.Sprintf("failed to list installed plugins: %s", err)},
+		}
+	}
+
+	sort.Sort(pluginsByName(pluginList))
+
+	checks := []DoctorCheckModel{}
+	for _, plugin := range pluginList {
+		check := DoctorCheckModel{Category: DoctorCategoryPlugins, Name: plugin.Name, Status: DoctorStatusOK}
+
+		if pluginVersion, err := plugins.GetPluginVersion(plugin.Name); err != nil {
+			check.Status = DoctorStatusWarning
+			check.Message = fmt.Sprintf("failed to get version: %s", err)
+		} else if pluginVersion != nil {
+			check.Value = pluginVersion.String()
+		} else {
+			check.Value = "local"
+		}
+
+		executablePath, _, err := plugins.GetPluginExecutablePath(plugin.Name)
+		if err != nil {
+			check.Status = DoctorStatusFailed
+			check.Message = fmt.Sprintf("failed to get executable: %s", err)
+		} else if exists, err := pathutil.IsPathExists(executablePath); err != nil || !exists {
+			check.Status = DoctorStatusFailed
+			check.Message = fmt.Sprintf("executable not found (%s), reinstall the plugin", executablePath)
+		}
+
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+type pluginsByName []plugins.Plugin
+
+func (list pluginsByName) Len() int {
+	return len(list)
+}
+
+func (list pluginsByName) Swap(i, j int) {
+	list[i], list[j] = list[j], list[i]
+}
+
+func (list pluginsByName) Less(i, j int) bool {
+	return list[i].Name < list[j].Name
+}
+
+func doctorStatusMarker(status string) string {
+	switch status {
+	case DoctorStatusOK:
+		return output.Green("[OK]")
+	case DoctorStatusWarning:
+		return output.Yellow("[WARNING]")
+	}
+	return output.Red("[FAILED]")
+}
+
+// DoctorReportText returns the human readable report, the checks grouped by category
+func DoctorReportText(report DoctorReportModel) string {
+	lines := []string{fmt.Sprintf("bitrise %s (%s/%s)", report.BitriseVersion, report.OS, report.Arch)}
+
+	category := ""
+	for _, check := range report.Checks {
+		if check.Category != category {
+			category = check.Category
+			lines = append(lines, "", strings.Title(category)+":")
+		}
+
+		line := fmt.Sprintf(" * %s %s", doctorStatusMarker(check.Status), check.Name)
+		if check.Value != "" {
+			line += ": " + check.Value
+		}
+		if check.Message != "" {
+			line += " (" + check.Message + ")"
+		}
+		lines = append(lines, line)
+	}
+
+	lines = append(lines, "", "Status: "+doctorStatusMarker(report.Status))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,98 @@
+package bitrise
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewToolVersionCheck(t *testing.T) {
+	versionOutput := func(out string, err error) func() (string, error) {
+		return func() (string, error) {
+			return out, err
+		}
+	}
+
+	t.Log("supported version")
+	{
+		check := newToolVersionCheck("git", "2.0.0", true, versionOutput("git version 2.39.5\n", nil))
+		require.Equal(t, DoctorCheckModel{Category: DoctorCategoryTools, Name: "git", Status: DoctorStatusOK, Value: "2.39.5"}, check)
+	}
+
+	t.Log("old version")
+	{
+		check := newToolVersionCheck("envman", "1.1.1", true, versionOutput("1.1.0", nil))
+		require.Equal(t, DoctorStatusFailed, check.Status)
+		require.Equal(t, "1.1.0", check.Value)
+		require.Equal(t, "version 1.1.0 is older than the minimum supported version (1.1.1), run: bitrise setup", check.Message)
+	}
+
+	t.Log("missing optional tool")
+	{
+		check := newToolVersionCheck("xcodebuild", "", false, versionOutput("", errors.New("executable file not found")))
+		require.Equal(t, DoctorStatusWarning, check.Status)
+		require.Equal(t, "not installed or not working: executable file not found", check.Message)
+	}
+
+	t.Log("unparsable version")
+	{
+		check := newToolVersionCheck("stepman", "0.9.25", true, versionOutput("unknown", nil))
+		require.Equal(t, DoctorStatusWarning, check.Status)
+	}
+}
+
+func TestNewDirectoryCheck(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__doctor__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	check := newDirectoryCheck("tmp", tmpDir)
+	require.Equal(t, DoctorCheckModel{Category: DoctorCategoryDirectories, Name: "tmp", Status: DoctorStatusOK, Value: tmpDir}, check)
+
+	notCreatedDir := filepath.Join(tmpDir, "not", "created")
+	check = newDirectoryCheck("not created", notCreatedDir)
+	require.Equal(t, DoctorStatusOK, check.Status)
+	require.Equal(t, "not created yet", check.Message)
+}
+
+func TestNewURLReachableCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	check := newURLReachableCheck(http.DefaultClient, "steplib", server.URL+"/steplib")
+	require.Equal(t, DoctorStatusOK, check.Status)
+
+	check = newURLReachableCheck(http.DefaultClient, "steplib", server.URL+"/down")
+	require.Equal(t, DoctorStatusFailed, check.Status)
+	require.Equal(t, "responded with status: 503 Service Unavailable", check.Message)
+}
+
+func TestNewDoctorReport(t *testing.T) {
+	require.Equal(t, DoctorStatusOK, NewDoctorReport([]DoctorCheckModel{}).Status)
+
+	report := NewDoctorReport([]DoctorCheckModel{
+		DoctorCheckModel{Status: DoctorStatusWarning},
+		DoctorCheckModel{Status: DoctorStatusOK},
+	})
+	require.Equal(t, DoctorStatusWarning, report.Status)
+
+	report = NewDoctorReport([]DoctorCheckModel{
+		DoctorCheckModel{Status: DoctorStatusFailed},
+		DoctorCheckModel{Status: DoctorStatusWarning},
+	})
+	require.Equal(t, DoctorStatusFailed, report.Status)
+}
@@ -0,0 +1,130 @@
+package bitrise
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+)
+
+var envFileKeyRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseEnvFileValue returns the value of an env file line:
+// a single quoted value is used as it is, a double quoted value's escape sequences (\n, \", \\) are resolved,
+// the comment (# ...) at the end of an unquoted value is removed.
+func parseEnvFileValue(value string) (string, error) {
+	value = strings.TrimSpace(value)
+
+	switch {
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single quoted value: %s", value)
+		}
+		return value[1 : end+1], nil
+	case strings.HasPrefix(value, `"`):
+		resolved := ""
+		for idx := 1; idx < len(value); idx++ {
+			char := value[idx]
+			if char == '"' {
+				return resolved, nil
+			}
+			if char == '\\' && idx+1 < len(value) {
+				idx++
+				switch value[idx] {
+				case 'n':
+					resolved += "\n"
+				case 't':
+					resolved += "\t"
+				default:
+					resolved += string(value[idx])
+				}
+				continue
+			}
+			resolved += string(char)
+		}
+		return "", fmt.Errorf("unterminated double quoted value: %s", value)
+	}
+
+	if idx := strings.Index(value, " #"); idx >= 0 {
+		value = value[:idx]
+	}
+	return strings.TrimSpace(value), nil
+}
+
+// ParseEnvFile parses the content of a .env file (KEY=value lines, with optional export prefix),
+// the envs are not expanded, the values are used as they are.
+func ParseEnvFile(content string) ([]envmanModels.EnvironmentItemModel, error) {
+	isExpand := false
+
+	environments := []envmanModels.EnvironmentItemModel{}
+	for idx, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		split := strings.SplitN(line, "=", 2)
+		if len(split) != 2 {
+			return []envmanModels.EnvironmentItemModel{}, fmt.Errorf("line %d: missing '='", idx+1)
+		}
+
+		key := strings.TrimSpace(split[0])
+		if !envFileKeyRegexp.MatchString(key) {
+			return []envmanModels.EnvironmentItemModel{}, fmt.Errorf("line %d: invalid key (%s)", idx+1, key)
+		}
+
+		value, err := parseEnvFileValue(split[1])
+		if err != nil {
+			return []envmanModels.EnvironmentItemModel{}, fmt.Errorf("line %d: %s", idx+1, err)
+		}
+
+		environments = append(environments, envmanModels.EnvironmentItemModel{
+			key:                     value,
+			envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{IsExpand: &isExpand},
+		})
+	}
+
+	return environments, nil
+}
+
+// LoadEnvFiles reads the env files (relative paths are relative to the given dir),
+// and returns the envs of the env files (in env file order, so a later env file overrides the previous ones),
+// and the envs of the secret env files, which values have to be redacted.
+func LoadEnvFiles(envFiles []models.EnvFileModel, dir string) ([]envmanModels.EnvironmentItemModel, []envmanModels.EnvironmentItemModel, error) {
+	environments := []envmanModels.EnvironmentItemModel{}
+	secretEnvironments := []envmanModels.EnvironmentItemModel{}
+
+	for _, envFile := range envFiles {
+		pth := envFile.Path
+		if !filepath.IsAbs(pth) {
+			pth = filepath.Join(dir, pth)
+		}
+
+		content, err := ioutil.ReadFile(pth)
+		if err != nil {
+			if os.IsNotExist(err) && envFile.IsOptional {
+				continue
+			}
+			return []envmanModels.EnvironmentItemModel{}, []envmanModels.EnvironmentItemModel{}, fmt.Errorf("Failed to read env file (%s), error: %s", envFile.Path, err)
+		}
+
+		fileEnvironments, err := ParseEnvFile(string(content))
+		if err != nil {
+			return []envmanModels.EnvironmentItemModel{}, []envmanModels.EnvironmentItemModel{}, fmt.Errorf("Failed to parse env file (%s), error: %s", envFile.Path, err)
+		}
+
+		environments = append(environments, fileEnvironments...)
+		if envFile.IsSecret {
+			secretEnvironments = append(secretEnvironments, fileEnvironments...)
+		}
+	}
+
+	return environments, secretEnvironments, nil
+}
@@ -0,0 +1,97 @@
+package bitrise
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func envFileKeyValues(t *testing.T, environments []envmanModels.EnvironmentItemModel) [][]string {
+	keyValues := [][]string{}
+	for _, env := range environments {
+		key, value, err := env.GetKeyValuePair()
+		require.NoError(t, err)
+		keyValues = append(keyValues, []string{key, value})
+	}
+	return keyValues
+}
+
+func TestParseEnvFile(t *testing.T) {
+	t.Log("dotenv syntax")
+	{
+		environments, err := ParseEnvFile(`
+# comment
+export NODE_ENV=production
+API_URL = https://example.com # trailing comment
+SINGLE='$HOME stays # as is'
+DOUBLE="line1\nline2 \"quoted\""
+EMPTY=
+`)
+		require.NoError(t, err)
+		require.Equal(t, [][]string{
+			{"NODE_ENV", "production"},
+			{"API_URL", "https://example.com"},
+			{"SINGLE", "$HOME stays # as is"},
+			{"DOUBLE", "line1\nline2 \"quoted\""},
+			{"EMPTY", ""},
+		}, envFileKeyValues(t, environments))
+
+		options, err := environments[2].GetOptions()
+		require.NoError(t, err)
+		require.Equal(t, false, *options.IsExpand)
+	}
+
+	t.Log("invalid lines")
+	{
+		_, err := ParseEnvFile("FOO=bar\nBAZ")
+		require.EqualError(t, err, "line 2: missing '='")
+
+		_, err = ParseEnvFile("1FOO=bar")
+		require.EqualError(t, err, "line 1: invalid key (1FOO)")
+
+		_, err = ParseEnvFile(`FOO="bar`)
+		require.EqualError(t, err, `line 1: unterminated double quoted value: "bar`)
+	}
+}
+
+func TestLoadEnvFiles(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "env_files")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, ".env"), []byte("NODE_ENV=development\nAPI_URL=https://example.com\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, ".env.local"), []byte("NODE_ENV=test\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, ".env.secrets"), []byte("API_TOKEN=secret-token\n"), 0600))
+
+	t.Log("later env files override the previous ones, secret env files are returned as secrets too")
+	{
+		environments, secretEnvironments, err := LoadEnvFiles([]models.EnvFileModel{
+			models.EnvFileModel{Path: ".env"},
+			models.EnvFileModel{Path: filepath.Join(tmpDir, ".env.local")},
+			models.EnvFileModel{Path: ".env.secrets", IsSecret: true},
+			models.EnvFileModel{Path: ".env.missing", IsOptional: true},
+		}, tmpDir)
+		require.NoError(t, err)
+		require.Equal(t, [][]string{
+			{"NODE_ENV", "development"},
+			{"API_URL", "https://example.com"},
+			{"NODE_ENV", "test"},
+			{"API_TOKEN", "secret-token"},
+		}, envFileKeyValues(t, environments))
+		require.Equal(t, [][]string{{"API_TOKEN", "secret-token"}}, envFileKeyValues(t, secretEnvironments))
+	}
+
+	t.Log("missing env file")
+	{
+		_, _, err := LoadEnvFiles([]models.EnvFileModel{models.EnvFileModel{Path: ".env.missing"}}, tmpDir)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Failed to read env file (.env.missing)")
+	}
+}
@@ -0,0 +1,119 @@
+package bitrise
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+)
+
+// EnvLayerModel is a group of envs of the same source (app envs, a workflow's envs, ...),
+// added to the environment in the order of the layers
+type EnvLayerModel struct {
+	Source       string
+	Environments []envmanModels.EnvironmentItemModel
+	IsSecret     bool
+}
+
+// ResolvedEnvModel is the effective value of an env, and the source which defined it
+type ResolvedEnvModel struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Source   string `json:"source"`
+	IsSecret bool   `json:"is_secret,omitempty"`
+	// OverriddenSources are the sources, which defined the env before, in order
+	OverriddenSources []string `json:"overridden_sources,omitempty"`
+}
+
+// ResolveEnvLayers adds the envs of the layers to the environment, like envman does:
+// the values are expanded (unless is_expand: false) with the envs added before and with the process environment,
+// an empty value with skip_if_empty: true is not added, and a later env overrides the previous one with the same key.
+// The resolved envs are returned in key order.
+func ResolveEnvLayers(layers []EnvLayerModel) ([]ResolvedEnvModel, error) {
+	resolved := map[string]ResolvedEnvModel{}
+	lookup := func(key string) string {
+		if env, found := resolved[key]; found {
+			return env.Value
+		}
+		return os.Getenv(key)
+	}
+
+	for _, layer := range layers {
+		for _, env := range layer.Environments {
+			key, value, err := env.GetKeyValuePair()
+			if err != nil {
+				return []ResolvedEnvModel{}, fmt.Errorf("invalid env in %s, error: %s", layer.Source, err)
+			}
+			options, err := env.GetOptions()
+			if err != nil {
+				return []ResolvedEnvModel{}, fmt.Errorf("invalid env (%s) in %s, error: %s", key, layer.Source, err)
+			}
+
+			if options.IsExpand == nil || *options.IsExpand {
+				value = os.Expand(value, lookup)
+			}
+			if value == "" && options.SkipIfEmpty != nil && *options.SkipIfEmpty {
+				continue
+			}
+
+			overriddenSources := []string{}
+			if previous, found := resolved[key]; found {
+				overriddenSources = append(append(overriddenSources, previous.OverriddenSources...), previous.Source)
+			}
+
+			resolved[key] = ResolvedEnvModel{
+				Key:               key,
+				Value:             value,
+				Source:            layer.Source,
+				IsSecret:          layer.IsSecret,
+				OverriddenSources: overriddenSources,
+			}
+		}
+	}
+
+	keys := []string{}
+	for key := range resolved {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	envs := []ResolvedEnvModel{}
+	for _, key := range keys {
+		env := resolved[key]
+		if len(env.OverriddenSources) == 0 {
+			env.OverriddenSources = nil
+		}
+		envs = append(envs, env)
+	}
+	return envs, nil
+}
+
+// RedactResolvedEnvs masks the values of the secrets, and the secret values in the other envs' values
+func RedactResolvedEnvs(envs []ResolvedEnvModel, layers []EnvLayerModel) []ResolvedEnvModel {
+	secretValues := []string{}
+	for _, layer := range layers {
+		if !layer.IsSecret {
+			continue
+		}
+		for _, env := range layer.Environments {
+			if _, value, err := env.GetKeyValuePair(); err == nil && value != "" {
+				secretValues = append(secretValues, value)
+			}
+		}
+	}
+
+	redacted := []ResolvedEnvModel{}
+	for _, env := range envs {
+		if env.IsSecret {
+			env.Value = redactedValue
+		} else {
+			for _, secret := range secretValues {
+				env.Value = strings.Replace(env.Value, secret, redactedValue, -1)
+			}
+		}
+		redacted = append(redacted, env)
+	}
+	return redacted
+}
@@ -0,0 +1,56 @@
+package bitrise
+
+import (
+	"testing"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveEnvLayers(t *testing.T) {
+	isExpand := false
+	skipIfEmpty := true
+	layers := []EnvLayerModel{
+		EnvLayerModel{Source: "secrets", IsSecret: true, Environments: []envmanModels.EnvironmentItemModel{
+			envmanModels.EnvironmentItemModel{"API_TOKEN": "secret-token"},
+		}},
+		EnvLayerModel{Source: "app.envs", Environments: []envmanModels.EnvironmentItemModel{
+			envmanModels.EnvironmentItemModel{"STAGE": "app"},
+			envmanModels.EnvironmentItemModel{"AUTH_HEADER": "Bearer $API_TOKEN"},
+			envmanModels.EnvironmentItemModel{
+				"LITERAL":               "$STAGE",
+				envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{IsExpand: &isExpand},
+			},
+		}},
+		EnvLayerModel{Source: "workflows.deploy.envs", Environments: []envmanModels.EnvironmentItemModel{
+			envmanModels.EnvironmentItemModel{"STAGE": "deploy-$STAGE"},
+			envmanModels.EnvironmentItemModel{
+				"OPTIONAL":              "",
+				envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{SkipIfEmpty: &skipIfEmpty},
+			},
+		}},
+	}
+
+	t.Log("expanded, overridden envs with their sources")
+	{
+		envs, err := ResolveEnvLayers(layers)
+		require.NoError(t, err)
+		require.Equal(t, []ResolvedEnvModel{
+			ResolvedEnvModel{Key: "API_TOKEN", Value: "secret-token", Source: "secrets", IsSecret: true},
+			ResolvedEnvModel{Key: "AUTH_HEADER", Value: "Bearer secret-token", Source: "app.envs"},
+			ResolvedEnvModel{Key: "LITERAL", Value: "$STAGE", Source: "app.envs"},
+			ResolvedEnvModel{Key: "STAGE", Value: "deploy-app", Source: "workflows.deploy.envs", OverriddenSources: []string{"app.envs"}},
+		}, envs)
+	}
+
+	t.Log("redacted secrets")
+	{
+		envs, err := ResolveEnvLayers(layers)
+		require.NoError(t, err)
+
+		redacted := RedactResolvedEnvs(envs, layers)
+		require.Equal(t, "[REDACTED]", redacted[0].Value)
+		require.Equal(t, "Bearer [REDACTED]", redacted[1].Value)
+		require.Equal(t, "deploy-app", redacted[3].Value)
+	}
+}
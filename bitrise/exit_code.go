@@ -0,0 +1,43 @@
+package bitrise
+
+import (
+	"github.com/bitrise-io/bitrise/exitcode"
+	"github.com/bitrise-io/bitrise/models"
+)
+
+// BuildExitCode returns the exit code of the build (see: exitcode):
+// Aborted if the build was aborted, StepTimedOut if a failed step timed out,
+// StepFailed if any other not skippable step failed, Success otherwise.
+func BuildExitCode(buildRunResults models.BuildRunResultsModel) int {
+	if buildRunResults.IsAborted {
+		return exitcode.Aborted
+	}
+	if !buildRunResults.IsBuildFailed() {
+		return exitcode.Success
+	}
+	for _, stepRunResult := range buildRunResults.FailedSteps {
+		if exitcode.Is(stepRunResult.Error, exitcode.StepTimedOut) {
+			return exitcode.StepTimedOut
+		}
+	}
+	return exitcode.StepFailed
+}
+
+// MultiWorkflowExitCode returns the exit code of a multi workflow run:
+// Aborted if any of the workflows was aborted, otherwise the exit code of the first failed workflow.
+func MultiWorkflowExitCode(results []WorkflowRunResultModel) int {
+	code := exitcode.Success
+	for _, result := range results {
+		resultCode := BuildExitCode(result.BuildRunResults)
+		if result.Error != nil {
+			resultCode = exitcode.Code(result.Error)
+		}
+		if resultCode == exitcode.Aborted {
+			return exitcode.Aborted
+		}
+		if code == exitcode.Success {
+			code = resultCode
+		}
+	}
+	return code
+}
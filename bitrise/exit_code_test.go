@@ -0,0 +1,33 @@
+package bitrise
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/exitcode"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildExitCode(t *testing.T) {
+	failed := models.StepRunResultsModel{Error: errors.New("exit status 1")}
+	timedOut := models.StepRunResultsModel{Error: exitcode.New(exitcode.StepTimedOut, errors.New("step timed out"))}
+
+	require.Equal(t, exitcode.Success, BuildExitCode(models.BuildRunResultsModel{}))
+	require.Equal(t, exitcode.Success, BuildExitCode(models.BuildRunResultsModel{FailedSkippableSteps: []models.StepRunResultsModel{failed}}))
+	require.Equal(t, exitcode.StepFailed, BuildExitCode(models.BuildRunResultsModel{FailedSteps: []models.StepRunResultsModel{failed}}))
+	require.Equal(t, exitcode.StepTimedOut, BuildExitCode(models.BuildRunResultsModel{FailedSteps: []models.StepRunResultsModel{failed, timedOut}}))
+	require.Equal(t, exitcode.Aborted, BuildExitCode(models.BuildRunResultsModel{FailedSteps: []models.StepRunResultsModel{failed}, IsAborted: true}))
+}
+
+func TestMultiWorkflowExitCode(t *testing.T) {
+	success := WorkflowRunResultModel{WorkflowID: "test"}
+	failed := WorkflowRunResultModel{WorkflowID: "deploy", BuildRunResults: models.BuildRunResultsModel{FailedSteps: []models.StepRunResultsModel{models.StepRunResultsModel{}}}}
+	notRun := WorkflowRunResultModel{WorkflowID: "missing", Error: errors.New("workflow (missing) not found")}
+	aborted := WorkflowRunResultModel{WorkflowID: "slow", BuildRunResults: models.BuildRunResultsModel{IsAborted: true}}
+
+	require.Equal(t, exitcode.Success, MultiWorkflowExitCode([]WorkflowRunResultModel{success, success}))
+	require.Equal(t, exitcode.StepFailed, MultiWorkflowExitCode([]WorkflowRunResultModel{success, failed, notRun}))
+	require.Equal(t, exitcode.InternalError, MultiWorkflowExitCode([]WorkflowRunResultModel{notRun, failed}))
+	require.Equal(t, exitcode.Aborted, MultiWorkflowExitCode([]WorkflowRunResultModel{failed, aborted}))
+}
@@ -0,0 +1,320 @@
+package bitrise
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/bitrise/tools"
+	envmanModels "github.com/bitrise-io/envman/models"
+)
+
+const redactedValue = "[REDACTED]"
+
+const (
+	// EnvChangeAdded ...
+	EnvChangeAdded = "added"
+	// EnvChangeChanged ...
+	EnvChangeChanged = "changed"
+	// EnvChangeRemoved ...
+	EnvChangeRemoved = "removed"
+)
+
+// EnvDiffItemModel is an env, added, changed or removed between two states
+type EnvDiffItemModel struct {
+	Key    string
+	Before string
+	After  string
+	Change string
+}
+
+// HTMLReportStepModel ...
+type HTMLReportStepModel struct {
+	Idx      int
+	ID       string
+	Title    string
+	Version  string
+	Status   string
+	ExitCode int
+	Error    string
+	Start    time.Duration
+	RunTime  time.Duration
+	Log      string
+	EnvDiff  []EnvDiffItemModel
+	// Offset and Width are the position of the step on the timeline (in percent)
+	Offset float64
+	Width  float64
+}
+
+// HTMLReportModel collects the logs and the env changes of the steps,
+// for the single-file HTML run report (see: --report-html).
+type HTMLReportModel struct {
+	secretKeys   map[string]bool
+	secretValues []string
+
+	stepLog      *lockedBuffer
+	stepEnvDiff  []EnvDiffItemModel
+	stepLogs     map[int]string
+	stepEnvDiffs map[int][]EnvDiffItemModel
+}
+
+// lockedBuffer is written by both the stdout and the stderr of the step
+type lockedBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.String()
+}
+
+// NewHTMLReport ...
+// The values of the secrets are redacted in the report.
+func NewHTMLReport(secrets []envmanModels.EnvironmentItemModel) *HTMLReportModel {
+	report := &HTMLReportModel{
+		secretKeys:   map[string]bool{},
+		secretValues: []string{},
+		stepLogs:     map[int]string{},
+		stepEnvDiffs: map[int][]EnvDiffItemModel{},
+	}
+
+	for _, secret := range secrets {
+		key, value, err := secret.GetKeyValuePair()
+		if err != nil {
+			continue
+		}
+		report.secretKeys[key] = true
+		if value != "" {
+			report.secretValues = append(report.secretValues, value)
+		}
+	}
+
+	return report
+}
+
+// StartStep starts capturing the output of the next step
+func (report *HTMLReportModel) StartStep() {
+	report.stepLog = &lockedBuffer{}
+	report.stepEnvDiff = []EnvDiffItemModel{}
+	tools.SetStepLogWriter(report.stepLog)
+}
+
+// SetStepEnvDiff sets the env changes of the current step
+func (report *HTMLReportModel) SetStepEnvDiff(environments, stepOutputs []envmanModels.EnvironmentItemModel) {
+	report.stepEnvDiff = StepEnvDiff(environments, stepOutputs)
+}
+
+// FinishStep stops capturing the output of the current step, and stores it with the step's result index
+func (report *HTMLReportModel) FinishStep(resultIdx int) {
+	tools.SetStepLogWriter(nil)
+
+	if report.stepLog != nil {
+		report.stepLogs[resultIdx] = report.redact(output.StripANSI(report.stepLog.String()))
+	}
+
+	envDiff := []EnvDiffItemModel{}
+	for _, item := range report.stepEnvDiff {
+		if report.secretKeys[item.Key] {
+			if item.Before != "" {
+				item.Before = redactedValue
+			}
+			item.After = redactedValue
+		} else {
+			item.Before = report.redact(item.Before)
+			item.After = report.redact(item.After)
+		}
+		envDiff = append(envDiff, item)
+	}
+	report.stepEnvDiffs[resultIdx] = envDiff
+
+	report.stepLog = nil
+	report.stepEnvDiff = []EnvDiffItemModel{}
+}
+
+func (report *HTMLReportModel) redact(str string) string {
+	for _, secret := range report.secretValues {
+		str = strings.Replace(str, secret, redactedValue, -1)
+	}
+	return ActivePolicy().Redact(str)
+}
+
+// StepEnvDiff returns the envs added or changed by the step's outputs,
+// compared to the environments the step was started with.
+func StepEnvDiff(environments, stepOutputs []envmanModels.EnvironmentItemModel) []EnvDiffItemModel {
+	values := map[string]string{}
+	for _, env := range environments {
+		key, value, err := env.GetKeyValuePair()
+		if err != nil {
+			continue
+		}
+		values[key] = value
+	}
+
+	diff := []EnvDiffItemModel{}
+	diffIdx := map[string]int{}
+	for _, env := range stepOutputs {
+		key, value, err := env.GetKeyValuePair()
+		if err != nil {
+			continue
+		}
+
+		before, found := values[key]
+		if found && before == value {
+			continue
+		}
+
+		item := EnvDiffItemModel{Key: key, Before: before, After: value, Change: EnvChangeAdded}
+		if found {
+			item.Change = EnvChangeChanged
+		}
+
+		if idx, isDiffed := diffIdx[key]; isDiffed {
+			diff[idx].After = value
+			continue
+		}
+		diffIdx[key] = len(diff)
+		diff = append(diff, item)
+	}
+
+	return diff
+}
+
+// Steps returns the report data of the steps, in run order
+func (report *HTMLReportModel) Steps(buildRunResults models.BuildRunResultsModel, totalTime time.Duration) []HTMLReportStepModel {
+	steps := []HTMLReportStepModel{}
+
+	start := time.Duration(0)
+	for _, result := range buildRunResults.OrderedResults() {
+		step := HTMLReportStepModel{
+			Idx:      result.Idx,
+			ID:       result.StepInfo.ID,
+			Title:    result.StepInfo.Title,
+			Version:  result.StepInfo.Version,
+			Status:   StepRunStatusName(result.Status),
+			ExitCode: result.ExitCode,
+			Start:    start,
+			RunTime:  result.RunTime,
+			Log:      report.stepLogs[result.Idx],
+			EnvDiff:  report.stepEnvDiffs[result.Idx],
+		}
+		if result.Error != nil {
+			step.Error = report.redact(result.Error.Error())
+		}
+		if totalTime > 0 {
+			step.Offset = 100 * start.Seconds() / totalTime.Seconds()
+			step.Width = 100 * result.RunTime.Seconds() / totalTime.Seconds()
+		}
+		steps = append(steps, step)
+
+		start += result.RunTime
+	}
+
+	return steps
+}
+
+// RenderHTML renders the single-file HTML report of the run
+func (report *HTMLReportModel) RenderHTML(workflowID string, buildRunResults models.BuildRunResultsModel, totalTime time.Duration) ([]byte, error) {
+	status := BuildStatusSuccess
+	if buildRunResults.IsAborted {
+		status = BuildStatusAborted
+	} else if buildRunResults.IsBuildFailed() {
+		status = BuildStatusFailed
+	}
+
+	data := map[string]interface{}{
+		"Workflow":  workflowID,
+		"Status":    status,
+		"StartTime": buildRunResults.StartTime.Format(time.RFC1123),
+		"TotalTime": totalTime,
+		"Steps":     report.Steps(buildRunResults, totalTime),
+	}
+
+	var buffer bytes.Buffer
+	if err := htmlReportTemplate.Execute(&buffer, data); err != nil {
+		return []byte{}, fmt.Errorf("Failed to render HTML report, error: %s", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// WriteHTMLReport ...
+func (report *HTMLReportModel) WriteHTMLReport(pth, workflowID string, buildRunResults models.BuildRunResultsModel, totalTime time.Duration) error {
+	content, err := report.RenderHTML(workflowID, buildRunResults, totalTime)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(pth), 0777); err != nil {
+		if CheckDiskFull(err) {
+			return nil
+		}
+		return fmt.Errorf("Failed to create HTML report dir, error: %s", err)
+	}
+
+	return WriteNonEssentialBytesToFile(pth, content)
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"duration": func(d time.Duration) string {
+		return fmt.Sprintf("%.2f sec", d.Seconds())
+	},
+	"percent": func(value float64) string {
+		return fmt.Sprintf("%.2f%%", value)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>bitrise run: {{.Workflow}} ({{.Status}})</title>
+<style>
+body { font-family: -apple-system, Helvetica, Arial, sans-serif; margin: 24px; color: #2c3e50; }
+h1 { font-size: 22px; }
+.success { color: #2e7d32; } .failed { color: #c62828; } .failed_skippable { color: #ef6c00; }
+.skipped, .skipped_with_run_if, .aborted { color: #757575; }
+.timeline { position: relative; height: 24px; background: #eceff1; margin: 4px 0 16px 0; }
+.timeline div { position: absolute; top: 0; height: 24px; min-width: 2px; }
+.bar-success { background: #66bb6a; } .bar-failed { background: #ef5350; } .bar-failed_skippable { background: #ffa726; }
+.bar-skipped, .bar-skipped_with_run_if { background: #bdbdbd; }
+details { border: 1px solid #cfd8dc; margin: 8px 0; padding: 4px 8px; }
+summary { cursor: pointer; font-weight: bold; }
+pre { background: #263238; color: #eceff1; padding: 8px; overflow-x: auto; white-space: pre-wrap; }
+table { border-collapse: collapse; margin: 8px 0; }
+td, th { border: 1px solid #cfd8dc; padding: 2px 8px; text-align: left; font-family: monospace; }
+</style>
+</head>
+<body>
+<h1>Workflow: {{.Workflow}} - <span class="{{.Status}}">{{.Status}}</span></h1>
+<p>Started: {{.StartTime}}, total runtime: {{duration .TotalTime}}</p>
+<h2>Timeline</h2>
+<div class="timeline">
+{{range .Steps}}<div class="bar-{{.Status}}" style="left: {{percent .Offset}}; width: {{percent .Width}};" title="{{.Title}} ({{duration .RunTime}})"></div>
+{{end}}</div>
+<h2>Steps</h2>
+{{range .Steps}}<details{{if eq .Status "failed"}} open{{end}}>
+<summary><span class="{{.Status}}">[{{.Status}}]</span> {{.Title}}{{if .Version}} ({{.Version}}){{end}} - {{duration .RunTime}}</summary>
+<p>ID: {{.ID}}, started at: +{{duration .Start}}{{if .ExitCode}}, exit code: {{.ExitCode}}{{end}}</p>
+{{if .Error}}<p class="failed">{{.Error}}</p>{{end}}
+{{if .EnvDiff}}<table>
+<tr><th>Env</th><th>Change</th><th>Before</th><th>After</th></tr>
+{{range .EnvDiff}}<tr><td>{{.Key}}</td><td>{{.Change}}</td><td>{{.Before}}</td><td>{{.After}}</td></tr>
+{{end}}</table>{{end}}
+{{if .Log}}<pre>{{.Log}}</pre>{{else}}<p>No output captured.</p>{{end}}
+</details>
+{{end}}
+</body>
+</html>
+`))
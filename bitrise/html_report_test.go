@@ -0,0 +1,103 @@
+package bitrise
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepEnvDiff(t *testing.T) {
+	environments := []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"PROJECT": "App"},
+		envmanModels.EnvironmentItemModel{"CONFIG": "debug"},
+	}
+	stepOutputs := []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"PROJECT": "App"},
+		envmanModels.EnvironmentItemModel{"CONFIG": "release"},
+		envmanModels.EnvironmentItemModel{"IPA_PATH": "./App.ipa"},
+		envmanModels.EnvironmentItemModel{"IPA_PATH": "./Signed.ipa"},
+	}
+
+	require.Equal(t, []EnvDiffItemModel{
+		EnvDiffItemModel{Key: "CONFIG", Before: "debug", After: "release", Change: EnvChangeChanged},
+		EnvDiffItemModel{Key: "IPA_PATH", After: "./Signed.ipa", Change: EnvChangeAdded},
+	}, StepEnvDiff(environments, stepOutputs))
+}
+
+func TestHTMLReport(t *testing.T) {
+	report := NewHTMLReport([]envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"API_TOKEN": "s3cr3t"},
+	})
+
+	report.StartStep()
+	_, err := report.stepLog.Write([]byte("\x1b[32;1mcalling api with s3cr3t\x1b[0m\n"))
+	require.NoError(t, err)
+	report.SetStepEnvDiff([]envmanModels.EnvironmentItemModel{}, []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"API_TOKEN": "other"},
+		envmanModels.EnvironmentItemModel{"RESULT": "ok"},
+	})
+	report.FinishStep(0)
+
+	report.StartStep()
+	report.FinishStep(1)
+
+	buildRunResults := models.BuildRunResultsModel{
+		StartTime: time.Now(),
+		SuccessSteps: []models.StepRunResultsModel{
+			models.StepRunResultsModel{
+				StepInfo: stepmanModels.StepInfoModel{ID: "script", Title: "Call API", Version: "1.1.0"},
+				Status:   models.StepRunStatusCodeSuccess,
+				Idx:      0,
+				RunTime:  3 * time.Second,
+			},
+		},
+		FailedSteps: []models.StepRunResultsModel{
+			models.StepRunResultsModel{
+				StepInfo: stepmanModels.StepInfoModel{ID: "script", Title: "<Deploy>", Version: "1.1.0"},
+				Status:   models.StepRunStatusCodeFailed,
+				Idx:      1,
+				RunTime:  1 * time.Second,
+				Error:    errors.New("exit status 1"),
+				ExitCode: 1,
+			},
+		},
+	}
+
+	t.Log("step data")
+	{
+		steps := report.Steps(buildRunResults, 4*time.Second)
+		require.Equal(t, 2, len(steps))
+
+		require.Equal(t, "calling api with [REDACTED]\n", steps[0].Log)
+		require.Equal(t, []EnvDiffItemModel{
+			EnvDiffItemModel{Key: "API_TOKEN", After: "[REDACTED]", Change: EnvChangeAdded},
+			EnvDiffItemModel{Key: "RESULT", After: "ok", Change: EnvChangeAdded},
+		}, steps[0].EnvDiff)
+		require.Equal(t, float64(0), steps[0].Offset)
+		require.Equal(t, float64(75), steps[0].Width)
+
+		require.Equal(t, "", steps[1].Log)
+		require.Equal(t, 3*time.Second, steps[1].Start)
+		require.Equal(t, float64(75), steps[1].Offset)
+		require.Equal(t, "exit status 1", steps[1].Error)
+	}
+
+	t.Log("rendered html")
+	{
+		content, err := report.RenderHTML("primary", buildRunResults, 4*time.Second)
+		require.NoError(t, err)
+
+		html := string(content)
+		require.Equal(t, true, strings.Contains(html, "Workflow: primary"))
+		require.Equal(t, true, strings.Contains(html, "&lt;Deploy&gt;"))
+		require.Equal(t, true, strings.Contains(html, "calling api with [REDACTED]"))
+		require.Equal(t, false, strings.Contains(html, "s3cr3t"))
+		require.Equal(t, true, strings.Contains(html, "left: 75.00%; width: 25.00%;"))
+	}
+}
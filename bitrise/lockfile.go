@@ -0,0 +1,136 @@
+package bitrise
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// StepLockfileName ...
+	StepLockfileName = "bitrise.lock"
+)
+
+// StepLockModel ...
+type StepLockModel struct {
+	Source     string `json:"source" yaml:"source"`
+	ID         string `json:"id" yaml:"id"`
+	Version    string `json:"version,omitempty" yaml:"version,omitempty"`
+	CommitHash string `json:"commit_hash,omitempty" yaml:"commit_hash,omitempty"`
+}
+
+// StepLockfileModel ...
+// Steps are keyed by the step's composite ID, as it is referenced in the config (e.g.: script, script@1.1.3).
+type StepLockfileModel struct {
+	FormatVersion string                   `json:"format_version" yaml:"format_version"`
+	Steps         map[string]StepLockModel `json:"steps" yaml:"steps"`
+}
+
+// NewStepLockfile ...
+func NewStepLockfile() StepLockfileModel {
+	return StepLockfileModel{
+		FormatVersion: models.Version,
+		Steps:         map[string]StepLockModel{},
+	}
+}
+
+// StepLockfilePathForConfig returns the lockfile path, which belongs to the given config (bitrise.yml) path.
+// The lockfile is placed next to the config,
+// or into the current directory if the config is not a file (e.g. base64 encoded config).
+func StepLockfilePathForConfig(configPth, currentDir string) string {
+	if configPth == "" {
+		return filepath.Join(currentDir, StepLockfileName)
+	}
+	return filepath.Join(filepath.Dir(configPth), StepLockfileName)
+}
+
+// ReadStepLockfile ...
+func ReadStepLockfile(pth string) (StepLockfileModel, bool, error) {
+	if exist, err := pathutil.IsPathExists(pth); err != nil {
+		return StepLockfileModel{}, false, err
+	} else if !exist {
+		return StepLockfileModel{}, false, nil
+	}
+
+	bytes, err := fileutil.ReadBytesFromFile(pth)
+	if err != nil {
+		return StepLockfileModel{}, false, err
+	}
+
+	lockfile := StepLockfileModel{}
+	if err := yaml.Unmarshal(bytes, &lockfile); err != nil {
+		return StepLockfileModel{}, false, fmt.Errorf("failed to parse lockfile (%s), error: %s", pth, err)
+	}
+	if lockfile.Steps == nil {
+		lockfile.Steps = map[string]StepLockModel{}
+	}
+
+	return lockfile, true, nil
+}
+
+// SaveStepLockfile ...
+func SaveStepLockfile(pth string, lockfile StepLockfileModel) error {
+	bytes, err := yaml.Marshal(lockfile)
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteBytesToFile(pth, bytes)
+}
+
+// Merge updates the lockfile with the steps of the other one
+// and keeps the steps of the lockfile, which are not in the other one.
+func (lockfile *StepLockfileModel) Merge(other StepLockfileModel) {
+	if lockfile.Steps == nil {
+		lockfile.Steps = map[string]StepLockModel{}
+	}
+	for compositeStepID, stepLock := range other.Steps {
+		lockfile.Steps[compositeStepID] = stepLock
+	}
+	lockfile.FormatVersion = models.Version
+}
+
+// CheckStep returns an error if the step resolution differs from the locked one.
+func (lockfile StepLockfileModel) CheckStep(compositeStepID string, resolved StepLockModel) error {
+	locked, found := lockfile.Steps[compositeStepID]
+	if !found {
+		return fmt.Errorf("step (%s) not found in the lockfile", compositeStepID)
+	}
+
+	if locked.Source != resolved.Source || locked.ID != resolved.ID {
+		return fmt.Errorf("step (%s) resolved from (%s: %s), but locked to (%s: %s)", compositeStepID, resolved.Source, resolved.ID, locked.Source, locked.ID)
+	}
+	if locked.Version != resolved.Version {
+		return fmt.Errorf("step (%s) resolved to version (%s), but locked to version (%s)", compositeStepID, resolved.Version, locked.Version)
+	}
+	if locked.CommitHash != "" && locked.CommitHash != resolved.CommitHash {
+		if resolved.CommitHash == "" {
+			return fmt.Errorf("step (%s) commit hash could not be resolved, but locked to commit (%s)", compositeStepID, locked.CommitHash)
+		}
+		return fmt.Errorf("step (%s) resolved to commit (%s), but locked to commit (%s)", compositeStepID, resolved.CommitHash, locked.CommitHash)
+	}
+
+	return nil
+}
+
+// CheckStepIDData returns an error if the step, as it's resolved before its activation, differs from the locked one:
+// the step has to be locked, with the same source and ID, and with the same version, if the step has an exact version (see: IsExactStepVersion).
+// The resolved version and commit hash of the step are known after its activation only (see: CheckStep).
+func (lockfile StepLockfileModel) CheckStepIDData(compositeStepID string, stepIDData models.StepIDData) error {
+	locked, found := lockfile.Steps[compositeStepID]
+	if !found {
+		return fmt.Errorf("step (%s) not found in the lockfile", compositeStepID)
+	}
+
+	if locked.Source != stepIDData.SteplibSource || locked.ID != stepIDData.IDorURI {
+		return fmt.Errorf("step (%s) resolved from (%s: %s), but locked to (%s: %s)", compositeStepID, stepIDData.SteplibSource, stepIDData.IDorURI, locked.Source, locked.ID)
+	}
+	if IsExactStepVersion(stepIDData.Version) && locked.Version != stepIDData.Version {
+		return fmt.Errorf("step (%s) resolved to version (%s), but locked to version (%s)", compositeStepID, stepIDData.Version, locked.Version)
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package bitrise
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepLockfilePathForConfig(t *testing.T) {
+	require.Equal(t, "/tmp/project/bitrise.lock", StepLockfilePathForConfig("/tmp/project/bitrise.yml", "/current"))
+	require.Equal(t, "/current/bitrise.lock", StepLockfilePathForConfig("", "/current"))
+}
+
+func TestStepLockfileCheckStep(t *testing.T) {
+	lockfile := NewStepLockfile()
+	lockfile.Steps["script"] = StepLockModel{
+		Source:     "https://github.com/bitrise-io/bitrise-steplib.git",
+		ID:         "script",
+		Version:    "1.1.3",
+		CommitHash: "2b1c4a7",
+	}
+
+	t.Log("same resolution")
+	{
+		require.NoError(t, lockfile.CheckStep("script", lockfile.Steps["script"]))
+	}
+
+	t.Log("not locked step")
+	{
+		require.Error(t, lockfile.CheckStep("script@1.1.2", lockfile.Steps["script"]))
+	}
+
+	t.Log("different version")
+	{
+		resolved := lockfile.Steps["script"]
+		resolved.Version = "1.1.4"
+		require.Error(t, lockfile.CheckStep("script", resolved))
+	}
+
+	t.Log("different commit")
+	{
+		resolved := lockfile.Steps["script"]
+		resolved.CommitHash = "3c2d5b8"
+		require.Error(t, lockfile.CheckStep("script", resolved))
+	}
+}
+
+func TestStepLockfileCheckStepIDData(t *testing.T) {
+	lockfile := NewStepLockfile()
+	lockfile.Steps["script@1"] = StepLockModel{
+		Source:     "https://github.com/bitrise-io/bitrise-steplib.git",
+		ID:         "script",
+		Version:    "1.1.3",
+		CommitHash: "2b1c4a7",
+	}
+	lockfile.Steps["script@1.1.3"] = lockfile.Steps["script@1"]
+
+	stepIDData := models.StepIDData{SteplibSource: "https://github.com/bitrise-io/bitrise-steplib.git", IDorURI: "script", Version: "1.1.3"}
+
+	t.Log("same resolution")
+	{
+		require.NoError(t, lockfile.CheckStepIDData("script@1.1.3", stepIDData))
+	}
+
+	t.Log("version constraint")
+	{
+		constraint := stepIDData
+		constraint.Version = "1.x.x"
+		require.NoError(t, lockfile.CheckStepIDData("script@1", constraint))
+	}
+
+	t.Log("not locked step")
+	{
+		require.Error(t, lockfile.CheckStepIDData("script@1.1.2", stepIDData))
+	}
+
+	t.Log("different version")
+	{
+		resolved := stepIDData
+		resolved.Version = "1.1.4"
+		require.Error(t, lockfile.CheckStepIDData("script@1.1.3", resolved))
+	}
+
+	t.Log("different source")
+	{
+		resolved := stepIDData
+		resolved.SteplibSource = "https://github.com/my-org/steplib.git"
+		require.Error(t, lockfile.CheckStepIDData("script@1.1.3", resolved))
+	}
+}
+
+func TestSaveAndReadStepLockfile(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("step_lockfile_test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	pth := filepath.Join(tmpDir, StepLockfileName)
+
+	t.Log("not existing lockfile")
+	{
+		_, found, err := ReadStepLockfile(pth)
+		require.NoError(t, err)
+		require.Equal(t, false, found)
+	}
+
+	t.Log("save, merge and read")
+	{
+		lockfile := NewStepLockfile()
+		lockfile.Steps["script"] = StepLockModel{Source: "steplib", ID: "script", Version: "1.1.3"}
+		require.NoError(t, SaveStepLockfile(pth, lockfile))
+
+		readLockfile, found, err := ReadStepLockfile(pth)
+		require.NoError(t, err)
+		require.Equal(t, true, found)
+		require.Equal(t, lockfile, readLockfile)
+
+		other := NewStepLockfile()
+		other.Steps["git::https://github.com/bitrise-io/steps-timestamp.git@master"] = StepLockModel{Source: "git", ID: "https://github.com/bitrise-io/steps-timestamp.git", Version: "master", CommitHash: "a1b2c3"}
+		readLockfile.Merge(other)
+		require.Equal(t, 2, len(readLockfile.Steps))
+	}
+}
@@ -0,0 +1,461 @@
+package bitrise
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/bitrise/plugins"
+	"github.com/bitrise-io/bitrise/tools"
+	envmanModels "github.com/bitrise-io/envman/models"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// Live log stream (see: --log-stream): a local Server-Sent Events endpoint (GET /events),
+// which streams the structured log events of the ongoing run, so a browser-based or IDE-integrated viewer
+// can tail the run in real time. Every event is a JSON object: {"seq":1,"type":"step_started","time":"...","data":{...}},
+// the seq is the SSE event id. A viewer connecting later receives the earlier events first,
+// a reconnecting viewer (Last-Event-ID) receives only the events it missed.
+
+const (
+	// LogStreamEventRunStarted ...
+	LogStreamEventRunStarted = "run_started"
+	// LogStreamEventStepStarted ...
+	LogStreamEventStepStarted = "step_started"
+	// LogStreamEventLog is a line of the step's output (source: step) or a message of the CLI (source: cli)
+	LogStreamEventLog = "log"
+	// LogStreamEventStepFinished ...
+	LogStreamEventStepFinished = "step_finished"
+	// LogStreamEventRunFinished ...
+	LogStreamEventRunFinished = "run_finished"
+
+	// logStreamHistoryLimit is the number of events kept for the viewers connecting later
+	logStreamHistoryLimit = 10000
+	// logStreamClientBufferSize is the number of events buffered for a viewer,
+	// a viewer which can't keep up misses the events over it, instead of blocking the run
+	logStreamClientBufferSize = 1024
+	// logStreamCloseTimeout is the time the viewers get to receive the last events, when the stream is closed
+	logStreamCloseTimeout = 2 * time.Second
+)
+
+// LogStreamEventModel ...
+type LogStreamEventModel struct {
+	Seq  int64       `json:"seq"`
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+// LogStreamModel is the live log stream of the run
+type LogStreamModel struct {
+	mutex    sync.Mutex
+	seq      int64
+	history  []LogStreamEventModel
+	clients  map[chan LogStreamEventModel]bool // the connected viewers, false if the viewer's channel is closed
+	closed   bool
+	listener net.Listener
+
+	// step is the ID of the running step, lineBuffer is its unfinished output line
+	step       string
+	lineBuffer bytes.Buffer
+
+	secretValues []string
+
+	clientsWaitGroup sync.WaitGroup
+	now              func() time.Time
+}
+
+// NewLogStream ...
+func NewLogStream() *LogStreamModel {
+	return &LogStreamModel{
+		history: []LogStreamEventModel{},
+		clients: map[chan LogStreamEventModel]bool{},
+		now:     time.Now,
+	}
+}
+
+// SetSecrets sets the secrets of the run, the values of the secrets are redacted in the stream
+func (stream *LogStreamModel) SetSecrets(secrets []envmanModels.EnvironmentItemModel) {
+	secretValues := []string{}
+	for _, secret := range secrets {
+		_, value, err := secret.GetKeyValuePair()
+		if err != nil || value == "" {
+			continue
+		}
+		secretValues = append(secretValues, value)
+	}
+
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	stream.secretValues = secretValues
+}
+
+func (stream *LogStreamModel) redact(str string) string {
+	stream.mutex.Lock()
+	secretValues := stream.secretValues
+	stream.mutex.Unlock()
+
+	for _, secret := range secretValues {
+		str = strings.Replace(str, secret, redactedValue, -1)
+	}
+	return ActivePolicy().Redact(str)
+}
+
+// Start starts serving the stream on the given address (e.g. 127.0.0.1:9090),
+// and returns the address it listens on (the port is chosen by the system, if it's 0).
+func (stream *LogStreamModel) Start(addr string) (string, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("Failed to listen on (%s), error: %s", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/events", stream)
+
+	stream.mutex.Lock()
+	stream.listener = listener
+	stream.mutex.Unlock()
+
+	go func() {
+		if err := http.Serve(listener, mux); err != nil && !stream.isClosed() {
+			log.Warnf("Log stream server stopped, error: %s", err)
+		}
+	}()
+
+	return listener.Addr().String(), nil
+}
+
+func (stream *LogStreamModel) isClosed() bool {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	return stream.closed
+}
+
+// Close ends the stream of the connected viewers (after they received the pending events) and stops the server
+func (stream *LogStreamModel) Close() {
+	stream.flushLine()
+
+	stream.mutex.Lock()
+	if stream.closed {
+		stream.mutex.Unlock()
+		return
+	}
+	stream.closed = true
+	for client := range stream.clients {
+		close(client)
+		stream.clients[client] = false
+	}
+	listener := stream.listener
+	stream.mutex.Unlock()
+
+	done := make(chan bool)
+	go func() {
+		stream.clientsWaitGroup.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(logStreamCloseTimeout):
+	}
+
+	if listener != nil {
+		if err := listener.Close(); err != nil {
+			log.Debugf("Failed to close log stream listener, error: %s", err)
+		}
+	}
+}
+
+// Publish sends the event to the connected viewers, and keeps it for the viewers connecting later
+func (stream *LogStreamModel) Publish(eventType string, data interface{}) {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	if stream.closed {
+		return
+	}
+
+	stream.seq++
+	event := LogStreamEventModel{
+		Seq:  stream.seq,
+		Type: eventType,
+		Time: stream.now(),
+		Data: data,
+	}
+
+	stream.history = append(stream.history, event)
+	if len(stream.history) > logStreamHistoryLimit {
+		stream.history = stream.history[len(stream.history)-logStreamHistoryLimit:]
+	}
+
+	for client := range stream.clients {
+		select {
+		case client <- event:
+		default:
+		}
+	}
+}
+
+// Events returns the events kept for the viewers connecting later, after the given seq
+func (stream *LogStreamModel) Events(afterSeq int64) []LogStreamEventModel {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	return stream.eventsAfter(afterSeq)
+}
+
+func (stream *LogStreamModel) eventsAfter(afterSeq int64) []LogStreamEventModel {
+	events := []LogStreamEventModel{}
+	for _, event := range stream.history {
+		if event.Seq > afterSeq {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// subscribe returns the earlier events (after the given seq) and the channel of the upcoming ones,
+// the channel is closed when the stream is closed.
+func (stream *LogStreamModel) subscribe(afterSeq int64) ([]LogStreamEventModel, chan LogStreamEventModel) {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	client := make(chan LogStreamEventModel, logStreamClientBufferSize)
+	if stream.closed {
+		close(client)
+	} else {
+		stream.clients[client] = true
+		stream.clientsWaitGroup.Add(1)
+	}
+	return stream.eventsAfter(afterSeq), client
+}
+
+func (stream *LogStreamModel) unsubscribe(client chan LogStreamEventModel) {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	if open, found := stream.clients[client]; found {
+		if open {
+			close(client)
+		}
+		delete(stream.clients, client)
+		stream.clientsWaitGroup.Done()
+	}
+}
+
+func writeLogStreamEvent(w http.ResponseWriter, event LogStreamEventModel) error {
+	bytes, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, bytes)
+	return err
+}
+
+// ServeHTTP serves the stream as Server-Sent Events
+func (stream *LogStreamModel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	afterSeq := int64(0)
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		if seq, err := strconv.ParseInt(lastEventID, 10, 64); err == nil {
+			afterSeq = seq
+		}
+	}
+
+	events, client := stream.subscribe(afterSeq)
+	defer stream.unsubscribe(client)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+
+	lastSeq := afterSeq
+	for _, event := range events {
+		if err := writeLogStreamEvent(w, event); err != nil {
+			return
+		}
+		lastSeq = event.Seq
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case event, ok := <-client:
+			if !ok {
+				return
+			}
+			// the event might be part of the replayed history already
+			if event.Seq <= lastSeq {
+				continue
+			}
+			if err := writeLogStreamEvent(w, event); err != nil {
+				return
+			}
+			lastSeq = event.Seq
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// Write streams the output of the running step, line by line (io.Writer, see: tools.SetStepLogStreamWriter)
+func (stream *LogStreamModel) Write(p []byte) (int, error) {
+	stream.mutex.Lock()
+	stream.lineBuffer.Write(p)
+	lines := []string{}
+	for {
+		content := stream.lineBuffer.Bytes()
+		idx := bytes.IndexByte(content, '\n')
+		if idx < 0 {
+			break
+		}
+		lines = append(lines, strings.TrimSuffix(string(content[:idx]), "\r"))
+		stream.lineBuffer.Next(idx + 1)
+	}
+	step := stream.step
+	stream.mutex.Unlock()
+
+	for _, line := range lines {
+		stream.publishStepLine(step, line)
+	}
+	return len(p), nil
+}
+
+func (stream *LogStreamModel) publishStepLine(step, line string) {
+	stream.Publish(LogStreamEventLog, map[string]interface{}{
+		"source": "step",
+		"step":   step,
+		"line":   stream.redact(output.StripANSI(line)),
+	})
+}
+
+// flushLine streams the unfinished output line of the step
+func (stream *LogStreamModel) flushLine() {
+	stream.mutex.Lock()
+	line := stream.lineBuffer.String()
+	stream.lineBuffer.Reset()
+	step := stream.step
+	stream.mutex.Unlock()
+
+	if line != "" {
+		stream.publishStepLine(step, line)
+	}
+}
+
+func (stream *LogStreamModel) setStep(step string) {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	stream.step = step
+}
+
+// Name ... (plugins.InProcessPlugin)
+func (stream *LogStreamModel) Name() string {
+	return "log-stream"
+}
+
+// StepWillStart ... (plugins.StepLifecycleHandler)
+func (stream *LogStreamModel) StepWillStart(stepInfo stepmanModels.StepInfoModel, step stepmanModels.StepModel, idx int) error {
+	stream.flushLine()
+	stream.setStep(stepInfo.ID)
+
+	title := stepInfo.ID
+	if step.Title != nil && *step.Title != "" {
+		title = *step.Title
+	}
+	stream.Publish(LogStreamEventStepStarted, map[string]interface{}{
+		"idx":     idx,
+		"id":      stepInfo.ID,
+		"title":   title,
+		"version": stepInfo.Version,
+	})
+	return nil
+}
+
+// StepDidFinish ... (plugins.StepLifecycleHandler)
+func (stream *LogStreamModel) StepDidFinish(result models.StepRunResultsModel) error {
+	stream.flushLine()
+	stream.setStep("")
+
+	data := map[string]interface{}{
+		"idx":          result.Idx,
+		"id":           result.StepInfo.ID,
+		"title":        result.StepInfo.Title,
+		"status":       StepRunStatusName(result.Status),
+		"exit_code":    result.ExitCode,
+		"run_time_sec": result.RunTime.Seconds(),
+	}
+	if result.Error != nil {
+		data["error"] = stream.redact(result.Error.Error())
+	}
+	stream.Publish(LogStreamEventStepFinished, data)
+	return nil
+}
+
+// HandleEvent ... (plugins.EventHandler)
+func (stream *LogStreamModel) HandleEvent(name plugins.TriggerEventName, payload interface{}) error {
+	if name != plugins.DidFinishRun {
+		return nil
+	}
+
+	buildRunResults, ok := payload.(models.BuildRunResultsModel)
+	if !ok {
+		return nil
+	}
+
+	stream.flushLine()
+	stream.Publish(LogStreamEventRunFinished, map[string]interface{}{
+		"status":    BuildStatus(buildRunResults),
+		"exit_code": BuildExitCode(buildRunResults),
+	})
+	return nil
+}
+
+// Levels ... (logrus.Hook)
+func (stream *LogStreamModel) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire streams the message of the CLI (logrus.Hook)
+func (stream *LogStreamModel) Fire(entry *log.Entry) error {
+	stream.Publish(LogStreamEventLog, map[string]interface{}{
+		"source": "cli",
+		"level":  entry.Level.String(),
+		"line":   stream.redact(output.StripANSI(entry.Message)),
+	})
+	return nil
+}
+
+// StartStepLogStream streams the output of the steps, in addition to the lifecycle events
+func (stream *LogStreamModel) StartStepLogStream() {
+	tools.SetStepLogStreamWriter(stream)
+}
+
+// StopStepLogStream ...
+func (stream *LogStreamModel) StopStepLogStream() {
+	tools.SetStepLogStreamWriter(nil)
+	stream.flushLine()
+}
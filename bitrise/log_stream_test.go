@@ -0,0 +1,130 @@
+package bitrise
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func readLogStreamEvents(t *testing.T, reader *bufio.Reader, count int) []LogStreamEventModel {
+	events := []LogStreamEventModel{}
+	for len(events) < count {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		event := LogStreamEventModel{}
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestLogStreamEvents(t *testing.T) {
+	t.Log("step lifecycle and output lines")
+	{
+		stream := NewLogStream()
+		stream.SetSecrets([]envmanModels.EnvironmentItemModel{envmanModels.EnvironmentItemModel{"API_TOKEN": "s3cr3t"}})
+
+		title := "Script"
+		require.NoError(t, stream.StepWillStart(stepmanModels.StepInfoModel{ID: "script", Version: "1.1.3"}, stepmanModels.StepModel{Title: &title}, 0))
+
+		_, err := stream.Write([]byte("hello\nto"))
+		require.NoError(t, err)
+		_, err = stream.Write([]byte("ken: s3cr3t\r\nunfinished"))
+		require.NoError(t, err)
+
+		require.NoError(t, stream.StepDidFinish(models.StepRunResultsModel{
+			StepInfo: stepmanModels.StepInfoModel{ID: "script", Title: "Script"},
+			Status:   models.StepRunStatusCodeFailed,
+			Idx:      0,
+			ExitCode: 1,
+			RunTime:  2 * time.Second,
+			Error:    errors.New("exit status 1"),
+		}))
+
+		events := stream.Events(0)
+		require.Equal(t, 5, len(events))
+
+		types := []string{}
+		for idx, event := range events {
+			require.Equal(t, int64(idx+1), event.Seq)
+			types = append(types, event.Type)
+		}
+		require.Equal(t, []string{"step_started", "log", "log", "log", "step_finished"}, types)
+
+		require.Equal(t, "Script", events[0].Data.(map[string]interface{})["title"])
+		require.Equal(t, "hello", events[1].Data.(map[string]interface{})["line"])
+		require.Equal(t, "token: [REDACTED]", events[2].Data.(map[string]interface{})["line"])
+		require.Equal(t, "unfinished", events[3].Data.(map[string]interface{})["line"])
+		require.Equal(t, "script", events[3].Data.(map[string]interface{})["step"])
+		require.Equal(t, "failed", events[4].Data.(map[string]interface{})["status"])
+		require.Equal(t, 3, len(stream.Events(2)))
+	}
+
+	t.Log("no events after close")
+	{
+		stream := NewLogStream()
+		stream.Publish(LogStreamEventRunStarted, nil)
+		stream.Close()
+		stream.Publish(LogStreamEventRunFinished, nil)
+		require.Equal(t, 1, len(stream.Events(0)))
+	}
+}
+
+func TestLogStreamServer(t *testing.T) {
+	stream := NewLogStream()
+	addr, err := stream.Start("127.0.0.1:0")
+	require.NoError(t, err)
+
+	stream.Publish(LogStreamEventRunStarted, map[string]interface{}{"workflow": "primary"})
+	stream.Publish(LogStreamEventLog, map[string]interface{}{"source": "cli", "line": "first"})
+
+	t.Log("replays the earlier events, then streams the new ones")
+	{
+		response, err := http.Get("http://" + addr + "/events")
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, response.Body.Close())
+		}()
+		require.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+		reader := bufio.NewReader(response.Body)
+		events := readLogStreamEvents(t, reader, 2)
+		require.Equal(t, LogStreamEventRunStarted, events[0].Type)
+		require.Equal(t, LogStreamEventLog, events[1].Type)
+
+		stream.Publish(LogStreamEventLog, map[string]interface{}{"source": "cli", "line": "second"})
+		events = readLogStreamEvents(t, reader, 1)
+		require.Equal(t, int64(3), events[0].Seq)
+		require.Equal(t, "second", events[0].Data.(map[string]interface{})["line"])
+	}
+
+	t.Log("reconnecting viewer receives the missed events only")
+	{
+		request, err := http.NewRequest("GET", "http://"+addr+"/events", nil)
+		require.NoError(t, err)
+		request.Header.Set("Last-Event-ID", "2")
+
+		response, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, response.Body.Close())
+		}()
+
+		events := readLogStreamEvents(t, bufio.NewReader(response.Body), 1)
+		require.Equal(t, int64(3), events[0].Seq)
+	}
+
+	stream.Close()
+}
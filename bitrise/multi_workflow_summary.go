@@ -0,0 +1,70 @@
+package bitrise
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/output"
+)
+
+// WorkflowRunResultModel is the result of a workflow, run by a multi-workflow run (bitrise run wf1 wf2)
+type WorkflowRunResultModel struct {
+	WorkflowID      string
+	BuildRunResults models.BuildRunResultsModel
+	RunTime         time.Duration
+	// Error is set if the workflow could not be run
+	Error error
+}
+
+// Status ...
+func (result WorkflowRunResultModel) Status() string {
+	switch {
+	case result.Error != nil:
+		return BuildStatusFailed
+	case result.BuildRunResults.IsAborted:
+		return BuildStatusAborted
+	case result.BuildRunResults.IsBuildFailed():
+		return BuildStatusFailed
+	}
+	return BuildStatusSuccess
+}
+
+func getMultiWorkflowSummary(results []WorkflowRunResultModel) string {
+	lines := []string{fmt.Sprintf("Workflows summary, %d workflows:", len(results))}
+
+	runTime := time.Duration(0)
+	for idx, result := range results {
+		runTime += result.RunTime
+
+		status := result.Status()
+		line := fmt.Sprintf("%d. %s: %s, run time: %s", idx+1, result.WorkflowID, strings.ToUpper(status), accessibleRunTime(result.RunTime))
+		if result.Error != nil {
+			line += fmt.Sprintf(", error: %s", result.Error)
+		}
+
+		if !configs.IsAccessibleMode {
+			switch status {
+			case BuildStatusSuccess:
+				line = output.Green(line)
+			case BuildStatusFailed:
+				line = output.Red(line)
+			default:
+				line = output.Yellow(line)
+			}
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, fmt.Sprintf("Total run time: %s", accessibleRunTime(runTime)))
+
+	return strings.Join(lines, "\n")
+}
+
+// PrintMultiWorkflowSummary prints the combined summary of the workflows, run by a multi-workflow run
+func PrintMultiWorkflowSummary(results []WorkflowRunResultModel) {
+	fmt.Println()
+	fmt.Println(getMultiWorkflowSummary(results))
+	fmt.Println()
+}
@@ -0,0 +1,441 @@
+package bitrise
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/cmdex"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// OCI artifact steps (oci://registry/repository:tag): the step's manifest is pulled from the registry
+// (OCI distribution / docker registry v2 API), and its layers are written into the step's directory:
+// a layer with a title annotation (org.opencontainers.image.title, as pushed by oras) is a single file,
+// a tar(.gz) layer is extracted. The artifact has to contain the step.yml.
+// The pulled steps are cached by the manifest's digest (~/.bitrise/cache/oci_steps),
+// and the digest is locked by the step lockfile.
+
+const (
+	ociManifestMediaType      = "application/vnd.oci.image.manifest.v1+json"
+	dockerManifestMediaType   = "application/vnd.docker.distribution.manifest.v2+json"
+	ociTitleAnnotation        = "org.opencontainers.image.title"
+	ociStepsCacheDirName      = "oci_steps"
+	ociRegistryRequestTimeout = 5 * time.Minute
+	ociAuthChallengeHeader    = "Www-Authenticate"
+)
+
+type ociDescriptorModel struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type ociManifestModel struct {
+	SchemaVersion int                  `json:"schemaVersion"`
+	MediaType     string               `json:"mediaType,omitempty"`
+	Layers        []ociDescriptorModel `json:"layers"`
+}
+
+var (
+	ociStepDigestsMutex sync.Mutex
+	ociStepDigests      = map[string]string{}
+)
+
+// OCIStepDigest returns the manifest digest of the activated OCI artifact step, and false if the step was not activated.
+func OCIStepDigest(stepIDData models.StepIDData) (string, bool) {
+	ociStepDigestsMutex.Lock()
+	defer ociStepDigestsMutex.Unlock()
+
+	digest, found := ociStepDigests[stepIDData.IDorURI+"@"+stepIDData.Version]
+	return digest, found
+}
+
+// ActivateOCIStep pulls the OCI artifact step (or uses its cached version) into stepDir,
+// and copies its step.yml to stepYMLPth (if stepYMLPth is not empty).
+func ActivateOCIStep(stepIDData models.StepIDData, stepDir, stepYMLPth string) error {
+	ref, err := stepIDData.OCIStepReference()
+	if err != nil {
+		return err
+	}
+
+	cacheDir := filepath.Join(configs.GetBitriseHomeDirPath(), "cache", ociStepsCacheDirName)
+	digest, cachedStepDir, err := pullOCIStep(ref, cacheDir)
+	if err != nil {
+		return fmt.Errorf("Failed to pull step (%s), error: %s", ref, err)
+	}
+
+	if err := os.MkdirAll(stepDir, 0777); err != nil {
+		return err
+	}
+	if err := cmdex.CopyDir(cachedStepDir, stepDir, true); err != nil {
+		return fmt.Errorf("Failed to copy step (%s), error: %s", ref, err)
+	}
+	if stepYMLPth != "" {
+		if err := cmdex.CopyFile(filepath.Join(cachedStepDir, "step.yml"), stepYMLPth); err != nil {
+			return fmt.Errorf("Failed to copy step.yml of step (%s), error: %s", ref, err)
+		}
+	}
+
+	ociStepDigestsMutex.Lock()
+	ociStepDigests[stepIDData.IDorURI+"@"+stepIDData.Version] = digest
+	ociStepDigestsMutex.Unlock()
+
+	log.Debugf("[BITRISE_CLI] - OCI step activated: (%s) (digest:%s)", ref, digest)
+	return nil
+}
+
+// pullOCIStep returns the manifest digest and the cached directory of the step,
+// the step is pulled only if it's not cached yet.
+func pullOCIStep(ref models.OCIStepReferenceModel, cacheDir string) (string, string, error) {
+	if ref.IsDigest() {
+		stepDir := filepath.Join(cacheDir, strings.Replace(ref.Reference, ":", "-", -1))
+		if exist, err := pathutil.IsDirExists(stepDir); err != nil {
+			return "", "", err
+		} else if exist {
+			log.Debugf("Using cached OCI step (%s)", ref)
+			return ref.Reference, stepDir, nil
+		}
+	}
+
+	client, err := newOCIRegistryClient(ref.Registry)
+	if err != nil {
+		return "", "", err
+	}
+
+	manifest, digest, err := client.manifest(ref.Repository, ref.Reference)
+	if err != nil {
+		return "", "", err
+	}
+	if ref.IsDigest() && digest != ref.Reference {
+		return "", "", fmt.Errorf("manifest digest (%s) doesn't match the referenced digest (%s)", digest, ref.Reference)
+	}
+
+	stepDir := filepath.Join(cacheDir, strings.Replace(digest, ":", "-", -1))
+	if exist, err := pathutil.IsDirExists(stepDir); err != nil {
+		return "", "", err
+	} else if exist {
+		log.Debugf("Using cached OCI step (%s)", ref)
+		return digest, stepDir, nil
+	}
+
+	// the layers are written into a temporary directory, which is moved to its place once complete,
+	// so an interrupted pull doesn't leave a partial step in the cache
+	if err := os.MkdirAll(cacheDir, 0777); err != nil {
+		return "", "", err
+	}
+	tmpDir, err := ioutil.TempDir(cacheDir, "pull-")
+	if err != nil {
+		return "", "", err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			log.Warnf("Failed to remove (%s), error: %s", tmpDir, err)
+		}
+	}()
+
+	for _, layer := range manifest.Layers {
+		blob, err := client.blob(ref.Repository, layer.Digest)
+		if err != nil {
+			return "", "", err
+		}
+		if err := writeOCIStepLayer(layer, blob, tmpDir); err != nil {
+			return "", "", fmt.Errorf("failed to write layer (%s), error: %s", layer.Digest, err)
+		}
+	}
+
+	if exist, err := pathutil.IsPathExists(filepath.Join(tmpDir, "step.yml")); err != nil {
+		return "", "", err
+	} else if !exist {
+		return "", "", fmt.Errorf("the artifact (%s) doesn't contain a step.yml", digest)
+	}
+
+	if err := moveIntoStepCache(tmpDir, stepDir); err != nil {
+		return "", "", err
+	}
+	return digest, stepDir, nil
+}
+
+// moveIntoStepCache moves the complete temporary directory of a step to its place in the cache.
+// The cached steps are addressed by their digest, so if a concurrent run already moved the same step into place,
+// its directory is used, and the temporary one is dropped.
+func moveIntoStepCache(tmpDir, stepDir string) error {
+	renameErr := os.Rename(tmpDir, stepDir)
+	if renameErr == nil {
+		return nil
+	}
+
+	if exist, err := pathutil.IsDirExists(stepDir); err == nil && exist {
+		log.Debugf("The step (%s) was cached by a concurrent run", stepDir)
+		return nil
+	}
+	return renameErr
+}
+
+func writeOCIStepLayer(layer ociDescriptorModel, blob []byte, stepDir string) error {
+	if title := layer.Annotations[ociTitleAnnotation]; title != "" {
+		pth, err := stepArchiveFilePath(stepDir, title)
+		if err != nil {
+			return err
+		}
+
+		mode := os.FileMode(0755)
+		if ext := filepath.Ext(pth); ext == ".yml" || ext == ".yaml" || ext == ".md" {
+			mode = 0644
+		}
+		return writeStepArchiveFile(pth, mode, bytes.NewReader(blob))
+	}
+
+	var reader io.Reader = bytes.NewReader(blob)
+	switch {
+	case strings.HasSuffix(layer.MediaType, "tar+gzip"), strings.HasSuffix(layer.MediaType, "tar.gzip"):
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		reader = gzipReader
+	case strings.HasSuffix(layer.MediaType, ".tar"):
+	default:
+		return fmt.Errorf("unsupported layer media type (%s), without file name annotation", layer.MediaType)
+	}
+
+	return extractStepTar(reader, stepDir)
+}
+
+// ociRegistryClient is a minimal, pull only client of the OCI distribution API,
+// with basic and bearer token authentication.
+type ociRegistryClient struct {
+	baseURL  string
+	username string
+	password string
+	token    string
+	client   *http.Client
+}
+
+func newOCIRegistryClient(registry string) (*ociRegistryClient, error) {
+	username, password, _, err := configs.RegistryCredential(registry)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := "https"
+	host := strings.Split(registry, ":")[0]
+	if host == "localhost" || host == "127.0.0.1" {
+		scheme = "http"
+	}
+
+	return &ociRegistryClient{
+		baseURL:  scheme + "://" + registry,
+		username: username,
+		password: password,
+		client:   configs.NewHTTPClient(ociRegistryRequestTimeout),
+	}, nil
+}
+
+func (c *ociRegistryClient) manifest(repository, reference string) (ociManifestModel, string, error) {
+	body, err := c.get("/v2/"+repository+"/manifests/"+reference, ociManifestMediaType+", "+dockerManifestMediaType)
+	if err != nil {
+		return ociManifestModel{}, "", err
+	}
+
+	var manifest ociManifestModel
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return ociManifestModel{}, "", fmt.Errorf("failed to parse manifest of (%s:%s), error: %s", repository, reference, err)
+	}
+	if manifest.SchemaVersion != 2 {
+		return ociManifestModel{}, "", fmt.Errorf("unsupported manifest schema version (%d) of (%s:%s)", manifest.SchemaVersion, repository, reference)
+	}
+	return manifest, ociDigest(body), nil
+}
+
+func (c *ociRegistryClient) blob(repository, digest string) ([]byte, error) {
+	if !strings.HasPrefix(digest, models.OCIDigestPrefix) {
+		return []byte{}, fmt.Errorf("unsupported blob digest (%s)", digest)
+	}
+
+	body, err := c.get("/v2/"+repository+"/blobs/"+digest, "")
+	if err != nil {
+		return []byte{}, err
+	}
+	if actual := ociDigest(body); actual != digest {
+		return []byte{}, fmt.Errorf("blob digest (%s) doesn't match the expected digest (%s)", actual, digest)
+	}
+	return body, nil
+}
+
+func ociDigest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return models.OCIDigestPrefix + hex.EncodeToString(sum[:])
+}
+
+// get returns the body of the registry's response,
+// on an authentication challenge the request is retried once, with the required authentication.
+func (c *ociRegistryClient) get(pth, accept string) ([]byte, error) {
+	resp, err := c.do(pth, accept)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && c.token == "" {
+		challenge := resp.Header.Get(ociAuthChallengeHeader)
+		c.closeBody(resp)
+
+		if strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+			if err := c.fetchToken(challenge); err != nil {
+				return []byte{}, err
+			}
+		} else if c.username == "" {
+			return []byte{}, fmt.Errorf("registry requires authentication, no credential found for (%s)", c.baseURL)
+		}
+
+		if resp, err = c.do(pth, accept); err != nil {
+			return []byte{}, err
+		}
+	}
+	defer c.closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return []byte{}, fmt.Errorf("failed to get (%s), status code: %d", pth, resp.StatusCode)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (c *ociRegistryClient) do(pth, accept string) (*http.Response, error) {
+	request, err := http.NewRequest("GET", c.baseURL+pth, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		request.Header.Set("Accept", accept)
+	}
+	if c.token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.token)
+	} else if c.username != "" {
+		request.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get (%s), error: %s", pth, err)
+	}
+	return resp, nil
+}
+
+func (c *ociRegistryClient) closeBody(resp *http.Response) {
+	if err := resp.Body.Close(); err != nil {
+		log.Warnf("failed to close (%s) body", resp.Request.URL)
+	}
+}
+
+// fetchToken requests a bearer token from the challenge's realm, for example:
+// Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:my-org/my-step:pull"
+func (c *ociRegistryClient) fetchToken(challenge string) error {
+	params := parseOCIAuthChallenge(challenge)
+	realm := params["realm"]
+	if realm == "" {
+		return fmt.Errorf("invalid authentication challenge (%s), no realm", challenge)
+	}
+
+	query := url.Values{}
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	if scope := params["scope"]; scope != "" {
+		query.Set("scope", scope)
+	}
+	tokenURL := realm
+	if len(query) > 0 {
+		tokenURL += "?" + query.Encode()
+	}
+
+	request, err := http.NewRequest("GET", tokenURL, nil)
+	if err != nil {
+		return err
+	}
+	if c.username != "" {
+		request.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to get registry token, error: %s", err)
+	}
+	defer c.closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get registry token, status code: %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("failed to parse registry token response, error: %s", err)
+	}
+
+	c.token = response.Token
+	if c.token == "" {
+		c.token = response.AccessToken
+	}
+	if c.token == "" {
+		return fmt.Errorf("empty registry token received from (%s)", realm)
+	}
+	return nil
+}
+
+// parseOCIAuthChallenge returns the parameters of a Bearer key="value",... authentication challenge
+func parseOCIAuthChallenge(challenge string) map[string]string {
+	params := map[string]string{}
+	split := strings.SplitN(challenge, " ", 2)
+	if len(split) != 2 {
+		return params
+	}
+
+	rest := split[1]
+	for rest != "" {
+		eqIdx := strings.Index(rest, "=")
+		if eqIdx == -1 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:eqIdx]))
+		rest = rest[eqIdx+1:]
+
+		value := ""
+		if strings.HasPrefix(rest, `"`) {
+			endIdx := strings.Index(rest[1:], `"`)
+			if endIdx == -1 {
+				value = rest[1:]
+				rest = ""
+			} else {
+				value = rest[1 : endIdx+1]
+				rest = rest[endIdx+2:]
+			}
+		} else if commaIdx := strings.Index(rest, ","); commaIdx != -1 {
+			value = rest[:commaIdx]
+			rest = rest[commaIdx:]
+		} else {
+			value = rest
+			rest = ""
+		}
+		params[key] = value
+		rest = strings.TrimLeft(rest, ", ")
+	}
+	return params
+}
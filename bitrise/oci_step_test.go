@@ -0,0 +1,164 @@
+package bitrise
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullOCIStep(t *testing.T) {
+	stepYML := []byte("title: OCI step\n")
+	binLayer := testStepTarGz(t, map[string]string{"bin/step": "#!/bin/bash\necho hello\n"})
+
+	blobs := map[string][]byte{
+		ociDigest(stepYML):  stepYML,
+		ociDigest(binLayer): binLayer,
+	}
+	manifest, err := json.Marshal(ociManifestModel{
+		SchemaVersion: 2,
+		MediaType:     ociManifestMediaType,
+		Layers: []ociDescriptorModel{
+			ociDescriptorModel{
+				MediaType:   "application/vnd.bitrise.step.config.v1+yaml",
+				Digest:      ociDigest(stepYML),
+				Size:        int64(len(stepYML)),
+				Annotations: map[string]string{ociTitleAnnotation: "step.yml"},
+			},
+			ociDescriptorModel{
+				MediaType: "application/vnd.oci.image.layer.v1.tar+gzip",
+				Digest:    ociDigest(binLayer),
+				Size:      int64(len(binLayer)),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	requestCount := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		if r.URL.Path == "/token" {
+			require.Equal(t, "repository:my-org/my-step:pull", r.URL.Query().Get("scope"))
+			_, err := w.Write([]byte(`{"token":"test-token"}`))
+			require.NoError(t, err)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.Header().Set("Www-Authenticate", `Bearer realm="`+server.URL+`/token",service="test",scope="repository:my-org/my-step:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/v2/my-org/my-step/manifests/1.0.0":
+			require.True(t, strings.Contains(r.Header.Get("Accept"), ociManifestMediaType))
+			_, err := w.Write(manifest)
+			require.NoError(t, err)
+		case strings.HasPrefix(r.URL.Path, "/v2/my-org/my-step/blobs/"):
+			blob, found := blobs[strings.TrimPrefix(r.URL.Path, "/v2/my-org/my-step/blobs/")]
+			if !found {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, err := w.Write(blob)
+			require.NoError(t, err)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cacheDir, err := pathutil.NormalizedOSTempDirPath("oci_steps")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(cacheDir))
+	}()
+
+	registry := strings.TrimPrefix(server.URL, "http://")
+
+	t.Log("pull by tag")
+	{
+		digest, stepDir, err := pullOCIStep(models.OCIStepReferenceModel{Registry: registry, Repository: "my-org/my-step", Reference: "1.0.0"}, cacheDir)
+		require.NoError(t, err)
+		require.Equal(t, ociDigest(manifest), digest)
+
+		content, err := fileutil.ReadStringFromFile(filepath.Join(stepDir, "step.yml"))
+		require.NoError(t, err)
+		require.Equal(t, string(stepYML), content)
+
+		info, err := os.Stat(filepath.Join(stepDir, "bin", "step"))
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	}
+
+	t.Log("pull by digest uses the cache")
+	{
+		requestCountBefore := requestCount
+		digest, _, err := pullOCIStep(models.OCIStepReferenceModel{Registry: registry, Repository: "my-org/my-step", Reference: ociDigest(manifest)}, cacheDir)
+		require.NoError(t, err)
+		require.Equal(t, ociDigest(manifest), digest)
+		require.Equal(t, requestCountBefore, requestCount)
+	}
+
+	t.Log("missing tag")
+	{
+		_, _, err := pullOCIStep(models.OCIStepReferenceModel{Registry: registry, Repository: "my-org/my-step", Reference: "2.0.0"}, cacheDir)
+		require.EqualError(t, err, "failed to get (/v2/my-org/my-step/manifests/2.0.0), status code: 404")
+	}
+}
+
+func TestMoveIntoStepCache(t *testing.T) {
+	cacheDir, err := pathutil.NormalizedOSTempDirPath("__step_cache__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(cacheDir))
+	}()
+
+	stepDir := filepath.Join(cacheDir, "sha256-abc")
+
+	t.Log("moved into the cache")
+	{
+		tmpDir := filepath.Join(cacheDir, "pull-1")
+		require.NoError(t, os.MkdirAll(tmpDir, 0777))
+		require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "step.yml"), "title: first\n"))
+		require.NoError(t, moveIntoStepCache(tmpDir, stepDir))
+
+		content, err := fileutil.ReadStringFromFile(filepath.Join(stepDir, "step.yml"))
+		require.NoError(t, err)
+		require.Equal(t, "title: first\n", content)
+	}
+
+	t.Log("already cached by a concurrent run")
+	{
+		tmpDir := filepath.Join(cacheDir, "pull-2")
+		require.NoError(t, os.MkdirAll(tmpDir, 0777))
+		require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "step.yml"), "title: second\n"))
+		require.NoError(t, moveIntoStepCache(tmpDir, stepDir))
+
+		content, err := fileutil.ReadStringFromFile(filepath.Join(stepDir, "step.yml"))
+		require.NoError(t, err)
+		require.Equal(t, "title: first\n", content)
+	}
+
+	t.Log("missing temporary directory")
+	{
+		require.Error(t, moveIntoStepCache(filepath.Join(cacheDir, "pull-3"), filepath.Join(cacheDir, "sha256-def")))
+	}
+}
+
+func TestParseOCIAuthChallenge(t *testing.T) {
+	require.Equal(t, map[string]string{
+		"realm":   "https://ghcr.io/token",
+		"service": "ghcr.io",
+		"scope":   "repository:my-org/my-step:pull",
+	}, parseOCIAuthChallenge(`Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:my-org/my-step:pull"`))
+}
@@ -0,0 +1,198 @@
+package bitrise
+
+import (
+	"path/filepath"
+
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/pathutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+const (
+	// OnboardPrimaryWorkflowID ...
+	OnboardPrimaryWorkflowID = "primary"
+	// OnboardDeployWorkflowID ...
+	OnboardDeployWorkflowID = "deploy"
+	// OnboardSmokeWorkflowID is the workflow, run by the onboarding to check the setup
+	OnboardSmokeWorkflowID = "smoke"
+
+	onboardStepLibSource = "https://github.com/bitrise-io/bitrise-steplib.git"
+)
+
+// ProjectTypeModel is a project type, recognized by the onboarding (bitrise onboard)
+type ProjectTypeModel struct {
+	ID   string
+	Name string
+	// MarkerFiles are glob patterns (relative to the project dir), any of them identifies the project type
+	MarkerFiles []string
+	// TestScript and BuildScript are the commands of the proposed primary and deploy workflows
+	TestScript  string
+	BuildScript string
+}
+
+// OtherProjectType is used if no known project type is detected
+var OtherProjectType = ProjectTypeModel{
+	ID:          "other",
+	Name:        "Other",
+	TestScript:  `echo "Add your test commands here"`,
+	BuildScript: `echo "Add your build and deploy commands here"`,
+}
+
+// KnownProjectTypes are the detected project types, in detection priority order
+var KnownProjectTypes = []ProjectTypeModel{
+	ProjectTypeModel{
+		ID:          "fastlane",
+		Name:        "fastlane",
+		MarkerFiles: []string{"fastlane/Fastfile", "Fastfile"},
+		TestScript:  "bundle exec fastlane test",
+		BuildScript: "bundle exec fastlane beta",
+	},
+	ProjectTypeModel{
+		ID:          "flutter",
+		Name:        "Flutter",
+		MarkerFiles: []string{"pubspec.yaml"},
+		TestScript:  "flutter test",
+		BuildScript: "flutter build apk",
+	},
+	ProjectTypeModel{
+		ID:          "ios",
+		Name:        "iOS / macOS (Xcode)",
+		MarkerFiles: []string{"*.xcworkspace", "*.xcodeproj"},
+		TestScript:  `xcodebuild test -scheme "$XCODE_SCHEME"`,
+		BuildScript: `xcodebuild archive -scheme "$XCODE_SCHEME"`,
+	},
+	ProjectTypeModel{
+		ID:          "android",
+		Name:        "Android (Gradle)",
+		MarkerFiles: []string{"gradlew", "build.gradle", "build.gradle.kts"},
+		TestScript:  "./gradlew test",
+		BuildScript: "./gradlew assembleRelease",
+	},
+	ProjectTypeModel{
+		ID:          "node",
+		Name:        "Node.js",
+		MarkerFiles: []string{"package.json"},
+		TestScript:  "npm install && npm test",
+		BuildScript: "npm install && npm run build",
+	},
+	ProjectTypeModel{
+		ID:          "go",
+		Name:        "Go",
+		MarkerFiles: []string{"go.mod", "Gopkg.toml", "*.go"},
+		TestScript:  "go test ./...",
+		BuildScript: "go build ./...",
+	},
+	ProjectTypeModel{
+		ID:          "ruby",
+		Name:        "Ruby",
+		MarkerFiles: []string{"Gemfile"},
+		TestScript:  "bundle install && bundle exec rake test",
+		BuildScript: "bundle install && bundle exec rake build",
+	},
+}
+
+// DetectProjectTypes returns the known project types, detected in the given directory.
+// OtherProjectType is returned, if none of them detected.
+func DetectProjectTypes(dir string) ([]ProjectTypeModel, error) {
+	detected := []ProjectTypeModel{}
+	for _, projectType := range KnownProjectTypes {
+		for _, pattern := range projectType.MarkerFiles {
+			matches, err := filepath.Glob(filepath.Join(dir, pattern))
+			if err != nil {
+				return []ProjectTypeModel{}, err
+			}
+
+			isFound := false
+			for _, match := range matches {
+				if exist, err := pathutil.IsPathExists(match); err != nil {
+					return []ProjectTypeModel{}, err
+				} else if exist {
+					isFound = true
+					break
+				}
+			}
+			if isFound {
+				detected = append(detected, projectType)
+				break
+			}
+		}
+	}
+
+	if len(detected) == 0 {
+		detected = append(detected, OtherProjectType)
+	}
+	return detected, nil
+}
+
+func onboardScriptStep(title, content string) models.StepListItemModel {
+	return models.StepListItemModel{
+		"script": models.WorkflowStepModel{
+			StepModel: stepmanModels.StepModel{
+				Title: &title,
+				Inputs: []envmanModels.EnvironmentItemModel{
+					envmanModels.EnvironmentItemModel{"content": "#!/bin/bash\nset -ex\n" + content + "\n"},
+				},
+			},
+		},
+	}
+}
+
+// OnboardingConfig returns the proposed config for the project type:
+// a primary (test) workflow for pull requests and pushes, a deploy workflow for the development branch
+// and a smoke workflow, to check the setup.
+func OnboardingConfig(projectType ProjectTypeModel, appTitle, devBranch string) models.BitriseDataModel {
+	smokeScript := `echo "Hello from the ` + projectType.Name + ` project: $BITRISE_APP_TITLE"`
+
+	return models.BitriseDataModel{
+		FormatVersion:        models.Version,
+		DefaultStepLibSource: onboardStepLibSource,
+		Title:                appTitle,
+		Summary:              "Generated by 'bitrise onboard' for a " + projectType.Name + " project.",
+		App: models.AppModel{
+			Environments: []envmanModels.EnvironmentItemModel{
+				envmanModels.EnvironmentItemModel{"BITRISE_APP_TITLE": appTitle},
+				envmanModels.EnvironmentItemModel{"BITRISE_DEV_BRANCH": devBranch},
+			},
+		},
+		TriggerMap: models.TriggerMapModel{
+			models.TriggerMapItemModel{PushBranch: devBranch, WorkflowID: OnboardDeployWorkflowID},
+			models.TriggerMapItemModel{PushBranch: "*", WorkflowID: OnboardPrimaryWorkflowID},
+			models.TriggerMapItemModel{PullRequestSourceBranch: "*", WorkflowID: OnboardPrimaryWorkflowID},
+		},
+		Workflows: map[string]models.WorkflowModel{
+			OnboardPrimaryWorkflowID: models.WorkflowModel{
+				Summary: "Runs the tests, triggered by pushes and pull requests",
+				Steps:   []models.StepListItemModel{onboardScriptStep("Test", projectType.TestScript)},
+			},
+			OnboardDeployWorkflowID: models.WorkflowModel{
+				Summary:   "Runs the tests, then builds and deploys the project, triggered by pushes to the development branch",
+				BeforeRun: models.NewWorkflowRunItems(OnboardPrimaryWorkflowID),
+				Steps:     []models.StepListItemModel{onboardScriptStep("Build and deploy", projectType.BuildScript)},
+			},
+			OnboardSmokeWorkflowID: models.WorkflowModel{
+				Summary: "Checks the bitrise setup, run by 'bitrise onboard'",
+				Steps:   []models.StepListItemModel{onboardScriptStep("Smoke test", smokeScript)},
+			},
+		},
+	}
+}
+
+// SecretsInventoryContent returns the content of a secrets file (.bitrise.secrets.yml) with the given secrets.
+// The secret values are not expanded.
+func SecretsInventoryContent(secrets []envmanModels.EnvironmentItemModel) ([]byte, error) {
+	isExpand := false
+	envs := []envmanModels.EnvironmentItemModel{}
+	for _, secret := range secrets {
+		key, value, err := secret.GetKeyValuePair()
+		if err != nil {
+			return []byte{}, err
+		}
+		envs = append(envs, envmanModels.EnvironmentItemModel{
+			key:                     value,
+			envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{IsExpand: &isExpand},
+		})
+	}
+
+	return generateYAML(envmanModels.EnvsYMLModel{Envs: envs})
+}
@@ -0,0 +1,72 @@
+package bitrise
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectProjectTypes(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("onboard")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(tmpDir)) }()
+
+	t.Log("no known project type")
+	{
+		detected, err := DetectProjectTypes(tmpDir)
+		require.NoError(t, err)
+		require.Equal(t, []ProjectTypeModel{OtherProjectType}, detected)
+	}
+
+	t.Log("glob marker")
+	{
+		require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "App.xcodeproj"), 0777))
+
+		detected, err := DetectProjectTypes(tmpDir)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(detected))
+		require.Equal(t, "ios", detected[0].ID)
+	}
+
+	t.Log("multiple project types, in priority order")
+	{
+		require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "package.json"), "{}"))
+		require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "fastlane"), 0777))
+		require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "fastlane", "Fastfile"), ""))
+
+		detected, err := DetectProjectTypes(tmpDir)
+		require.NoError(t, err)
+
+		ids := []string{}
+		for _, projectType := range detected {
+			ids = append(ids, projectType.ID)
+		}
+		require.Equal(t, []string{"fastlane", "ios", "node"}, ids)
+	}
+}
+
+func TestOnboardingConfig(t *testing.T) {
+	for _, projectType := range append(KnownProjectTypes, OtherProjectType) {
+		t.Logf("project type: %s", projectType.ID)
+
+		config := OnboardingConfig(projectType, "My App", "develop")
+
+		content, err := generateYAML(config)
+		require.NoError(t, err)
+
+		parsed, warnings, err := ConfigModelFromYAMLBytes(content)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(warnings))
+
+		for _, workflowID := range []string{OnboardPrimaryWorkflowID, OnboardDeployWorkflowID, OnboardSmokeWorkflowID} {
+			_, found := parsed.Workflows[workflowID]
+			require.Equal(t, true, found)
+		}
+		require.Equal(t, "develop", parsed.TriggerMap[0].PushBranch)
+		require.Equal(t, OnboardDeployWorkflowID, parsed.TriggerMap[0].WorkflowID)
+	}
+}
@@ -0,0 +1,70 @@
+package bitrise
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Quiet mode (see: --quiet): the console output of every step (its header, the step's output and its result)
+// is captured, and printed only if the step failed. The output of the successful and skipped steps is discarded,
+// so the CI logs of long workflows contain only the failures and the summary.
+
+// OutputCaptureModel captures the output of the process (os.Stdout and os.Stderr, in the order it was written)
+type OutputCaptureModel struct {
+	originalStdout *os.File
+	originalStderr *os.File
+	pipeWriter     *os.File
+	buffer         bytes.Buffer
+	done           chan bool
+}
+
+// StartOutputCapture redirects the output of the process (and of the processes started by it) into a buffer
+func StartOutputCapture() (*OutputCaptureModel, error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create pipe, error: %s", err)
+	}
+
+	capture := &OutputCaptureModel{
+		originalStdout: os.Stdout,
+		originalStderr: os.Stderr,
+		pipeWriter:     writer,
+		done:           make(chan bool),
+	}
+
+	go func() {
+		defer close(capture.done)
+
+		if _, err := io.Copy(&capture.buffer, reader); err != nil {
+			fmt.Fprintf(capture.originalStderr, "Failed to capture output, error: %s\n", err)
+		}
+		if err := reader.Close(); err != nil {
+			fmt.Fprintf(capture.originalStderr, "Failed to close output capture pipe, error: %s\n", err)
+		}
+	}()
+
+	// a single pipe for both, to keep the order of the stdout and stderr messages
+	os.Stdout = writer
+	os.Stderr = writer
+	log.SetOutput(os.Stderr)
+
+	return capture, nil
+}
+
+// Stop restores the output of the process, and returns the captured output
+func (capture *OutputCaptureModel) Stop() []byte {
+	os.Stdout = capture.originalStdout
+	os.Stderr = capture.originalStderr
+	log.SetOutput(os.Stderr)
+
+	if err := capture.pipeWriter.Close(); err != nil {
+		log.Warnf("Failed to close output capture pipe, error: %s", err)
+	}
+	<-capture.done
+
+	return capture.buffer.Bytes()
+}
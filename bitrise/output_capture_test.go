@@ -0,0 +1,28 @@
+package bitrise
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputCapture(t *testing.T) {
+	originalStdout := os.Stdout
+
+	capture, err := StartOutputCapture()
+	require.NoError(t, err)
+
+	fmt.Println("step header")
+	cmd := exec.Command("sh", "-c", "echo step output; echo step error 1>&2")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	require.NoError(t, cmd.Run())
+	fmt.Fprintln(os.Stderr, "step footer")
+
+	captured := capture.Stop()
+	require.Equal(t, originalStdout, os.Stdout)
+	require.Equal(t, "step header\nstep output\nstep error\nstep footer\n", string(captured))
+}
@@ -0,0 +1,133 @@
+package bitrise
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/pointers"
+)
+
+// Step output spill-to-file: the exported step outputs larger than the threshold are written into files,
+// and the output env's value is replaced with the file's path (prefixed with the spilledOutputMarker).
+// This protects the envstore and the exec environment of the following steps from the multi-megabyte payloads.
+// The template functions (getenv, enveq) resolve the spilled outputs to their original value.
+
+const (
+	// SpilledOutputMarker is the prefix of a spilled output env's value, followed by the file's path.
+	SpilledOutputMarker = "bitrise-spilled-output:"
+
+	// defaultOutputSpillThreshold is the max size (in bytes) of an output env's value, which is kept in the envstore
+	defaultOutputSpillThreshold = 20 * 1024
+	spilledOutputsDirName       = "spilled_outputs"
+)
+
+// OutputSpillThresholdFromEnv returns the spill threshold (in bytes),
+// configured by the BITRISE_OUTPUT_SPILL_THRESHOLD env (0 disables the spill).
+func OutputSpillThresholdFromEnv() (int, error) {
+	value := os.Getenv(configs.OutputSpillThresholdEnvKey)
+	if value == "" {
+		return defaultOutputSpillThreshold, nil
+	}
+
+	threshold, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value (%s), error: %s", configs.OutputSpillThresholdEnvKey, value, err)
+	}
+	if threshold < 0 {
+		return 0, fmt.Errorf("invalid %s value (%s), should not be negative", configs.OutputSpillThresholdEnvKey, value)
+	}
+	return threshold, nil
+}
+
+// SpillLargeOutputs spills the outputs larger than the configured threshold
+// into the run's spilled outputs dir.
+func SpillLargeOutputs(outputs []envmanModels.EnvironmentItemModel) ([]envmanModels.EnvironmentItemModel, error) {
+	threshold, err := OutputSpillThresholdFromEnv()
+	if err != nil {
+		return []envmanModels.EnvironmentItemModel{}, err
+	}
+	return spillLargeOutputs(outputs, threshold, filepath.Join(configs.BitriseWorkDirPath, spilledOutputsDirName))
+}
+
+func spillLargeOutputs(outputs []envmanModels.EnvironmentItemModel, threshold int, spillDir string) ([]envmanModels.EnvironmentItemModel, error) {
+	if threshold <= 0 {
+		return outputs, nil
+	}
+
+	spilledOutputs := []envmanModels.EnvironmentItemModel{}
+	for _, output := range outputs {
+		key, value, err := output.GetKeyValuePair()
+		if err != nil {
+			return []envmanModels.EnvironmentItemModel{}, err
+		}
+
+		if len(value) <= threshold || strings.HasPrefix(value, SpilledOutputMarker) {
+			spilledOutputs = append(spilledOutputs, output)
+			continue
+		}
+
+		opts, err := output.GetOptions()
+		if err != nil {
+			return []envmanModels.EnvironmentItemModel{}, err
+		}
+
+		if err := os.MkdirAll(spillDir, 0777); err != nil {
+			return []envmanModels.EnvironmentItemModel{}, fmt.Errorf("Failed to create spilled outputs dir (%s), error: %s", spillDir, err)
+		}
+
+		file, err := ioutil.TempFile(spillDir, key+"-")
+		if err != nil {
+			return []envmanModels.EnvironmentItemModel{}, fmt.Errorf("Failed to create spill file for output (%s), error: %s", key, err)
+		}
+		if _, err := file.WriteString(value); err != nil {
+			if closeErr := file.Close(); closeErr != nil {
+				log.Warnf("Failed to close (%s), error: %s", file.Name(), closeErr)
+			}
+			return []envmanModels.EnvironmentItemModel{}, fmt.Errorf("Failed to write output (%s) into file, error: %s", key, err)
+		}
+		if err := file.Close(); err != nil {
+			return []envmanModels.EnvironmentItemModel{}, fmt.Errorf("Failed to close (%s), error: %s", file.Name(), err)
+		}
+
+		log.Warnf("Output (%s) is larger than %d bytes (%d bytes), spilled to file: %s", key, threshold, len(value), file.Name())
+
+		// the path should not be expanded, the content is already spilled as it is
+		opts.IsExpand = pointers.NewBoolPtr(false)
+		spilledOutputs = append(spilledOutputs, envmanModels.EnvironmentItemModel{
+			key:                     SpilledOutputMarker + file.Name(),
+			envmanModels.OptionsKey: opts,
+		})
+	}
+
+	return spilledOutputs, nil
+}
+
+// SpilledOutputPath returns the path of the spilled output's file, and false if the value is not a spilled output.
+func SpilledOutputPath(value string) (string, bool) {
+	if !strings.HasPrefix(value, SpilledOutputMarker) {
+		return "", false
+	}
+	return strings.TrimPrefix(value, SpilledOutputMarker), true
+}
+
+// ResolveSpilledOutput returns the original value of a spilled output,
+// or the value itself, if it's not a spilled output.
+func ResolveSpilledOutput(value string) (string, error) {
+	pth, isSpilled := SpilledOutputPath(value)
+	if !isSpilled {
+		return value, nil
+	}
+
+	bytes, err := ioutil.ReadFile(pth)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read spilled output (%s), error: %s", pth, err)
+	}
+	return string(bytes), nil
+}
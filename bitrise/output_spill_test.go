@@ -0,0 +1,101 @@
+package bitrise
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpillLargeOutputs(t *testing.T) {
+	spillDir, err := pathutil.NormalizedOSTempDirPath("spilled_outputs")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(spillDir))
+	}()
+
+	largeValue := strings.Repeat("x", 101)
+	outputs := []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"SMALL": "small value"},
+		envmanModels.EnvironmentItemModel{"LARGE": largeValue},
+	}
+
+	t.Log("spill disabled")
+	{
+		spilled, err := spillLargeOutputs(outputs, 0, spillDir)
+		require.NoError(t, err)
+		require.Equal(t, outputs, spilled)
+	}
+
+	t.Log("spill outputs larger than the threshold")
+	{
+		spilled, err := spillLargeOutputs(outputs, 100, spillDir)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(spilled))
+		require.Equal(t, outputs[0], spilled[0])
+
+		key, value, err := spilled[1].GetKeyValuePair()
+		require.NoError(t, err)
+		require.Equal(t, "LARGE", key)
+
+		pth, isSpilled := SpilledOutputPath(value)
+		require.Equal(t, true, isSpilled)
+		require.Equal(t, true, strings.HasPrefix(pth, spillDir))
+
+		opts, err := spilled[1].GetOptions()
+		require.NoError(t, err)
+		require.Equal(t, false, *opts.IsExpand)
+
+		resolved, err := ResolveSpilledOutput(value)
+		require.NoError(t, err)
+		require.Equal(t, largeValue, resolved)
+
+		t.Log("spilled outputs are transparent for the templates")
+		{
+			envList := envmanModels.EnvsJSONListModel{"LARGE": value}
+			result, err := EvaluateTemplateToBool(`{{enveq "LARGE" "`+largeValue+`"}}`, false, false, models.BuildRunResultsModel{}, envList)
+			require.NoError(t, err)
+			require.Equal(t, true, result)
+		}
+
+		t.Log("already spilled outputs are not spilled again")
+		{
+			spilledAgain, err := spillLargeOutputs(spilled, 10, spillDir)
+			require.NoError(t, err)
+			require.Equal(t, spilled[1], spilledAgain[1])
+		}
+	}
+
+	t.Log("not spilled value")
+	{
+		resolved, err := ResolveSpilledOutput("value")
+		require.NoError(t, err)
+		require.Equal(t, "value", resolved)
+	}
+}
+
+func TestOutputSpillThresholdFromEnv(t *testing.T) {
+	originalValue := os.Getenv(configs.OutputSpillThresholdEnvKey)
+	defer func() {
+		require.NoError(t, os.Setenv(configs.OutputSpillThresholdEnvKey, originalValue))
+	}()
+
+	require.NoError(t, os.Setenv(configs.OutputSpillThresholdEnvKey, ""))
+	threshold, err := OutputSpillThresholdFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, defaultOutputSpillThreshold, threshold)
+
+	require.NoError(t, os.Setenv(configs.OutputSpillThresholdEnvKey, "1024"))
+	threshold, err = OutputSpillThresholdFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, 1024, threshold)
+
+	require.NoError(t, os.Setenv(configs.OutputSpillThresholdEnvKey, "-1"))
+	_, err = OutputSpillThresholdFromEnv()
+	require.Error(t, err)
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+// +build !windows
+
+package bitrise
+
+import (
+	"syscall"
+	"time"
+)
+
+var crashSignalNames = map[syscall.Signal]string{
+	syscall.SIGSEGV: "SIGSEGV",
+	syscall.SIGBUS:  "SIGBUS",
+	syscall.SIGILL:  "SIGILL",
+	syscall.SIGFPE:  "SIGFPE",
+	syscall.SIGABRT: "SIGABRT",
+	syscall.SIGKILL: "SIGKILL",
+	syscall.SIGSYS:  "SIGSYS",
+	syscall.SIGTRAP: "SIGTRAP",
+}
+
+// childrenCPUTime returns the CPU time (user + system) of the finished (and waited for) child processes
+func childrenCPUTime() (time.Duration, error) {
+	rusage := syscall.Rusage{}
+	if err := syscall.Getrusage(syscall.RUSAGE_CHILDREN, &rusage); err != nil {
+		return 0, err
+	}
+	return time.Duration(rusage.Utime.Nano() + rusage.Stime.Nano()), nil
+}
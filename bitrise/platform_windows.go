@@ -0,0 +1,27 @@
+//go:build windows
+// +build windows
+
+package bitrise
+
+import (
+	"errors"
+	"syscall"
+	"time"
+)
+
+// crashSignalNames: the processes are not killed by signals on Windows,
+// these are the signals, which can be reported by a shell (e.g. Git Bash) as an exit code
+var crashSignalNames = map[syscall.Signal]string{
+	syscall.SIGSEGV: "SIGSEGV",
+	syscall.SIGBUS:  "SIGBUS",
+	syscall.SIGILL:  "SIGILL",
+	syscall.SIGFPE:  "SIGFPE",
+	syscall.SIGABRT: "SIGABRT",
+	syscall.SIGKILL: "SIGKILL",
+	syscall.SIGTRAP: "SIGTRAP",
+}
+
+// childrenCPUTime is not supported on Windows, the steps' CPU time is not measured
+func childrenCPUTime() (time.Duration, error) {
+	return 0, errors.New("measuring the CPU time of the child processes is not supported on windows")
+}
@@ -0,0 +1,269 @@
+package bitrise
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/plugins"
+	"github.com/bitrise-io/bitrise/tools"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/ryanuber/go-glob"
+)
+
+// Organization policy bundle.
+// The bundle is a YAML file, signed the same way as the tool releases (detached signature, with .sig suffix),
+// fetched from the configured URL at startup and cached for the configured TTL (see: configs.PolicyConfigModel).
+
+const policySignatureURLSuffix = ".sig"
+
+// defaultTelemetryPlugins are the plugins disabled by a policy, which disables the telemetry
+var defaultTelemetryPlugins = []string{"analytics"}
+
+// TelemetryPolicyModel ...
+type TelemetryPolicyModel struct {
+	Enabled *bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	// Plugins are the telemetry plugins, disabled if the telemetry is not enabled (default: analytics)
+	Plugins []string `json:"plugins,omitempty" yaml:"plugins,omitempty"`
+}
+
+// PolicyModel is the organization policy, rolled out by the platform teams to every agent.
+type PolicyModel struct {
+	// StepAllowlist is the list of the allowed step IDs (or URIs), glob patterns are allowed.
+	// The steplib source can be specified with the step-lib-source::step-id format. Every step is allowed if empty.
+	StepAllowlist []string `json:"step_allowlist,omitempty" yaml:"step_allowlist,omitempty"`
+	// RedactionRules are regular expressions, the matching parts of the reports are redacted
+	RedactionRules []string `json:"redaction_rules,omitempty" yaml:"redaction_rules,omitempty"`
+	// RequiredHooks are the event hook plugins, which have to be installed to run a build
+	RequiredHooks []string             `json:"required_hooks,omitempty" yaml:"required_hooks,omitempty"`
+	Telemetry     TelemetryPolicyModel `json:"telemetry,omitempty" yaml:"telemetry,omitempty"`
+	// StepAudit is the minimal audit mode of the activated steps (warn or fail), the stricter one of this and the run's mode is used
+	StepAudit string `json:"step_audit,omitempty" yaml:"step_audit,omitempty"`
+
+	redactionRegexps []*regexp.Regexp
+}
+
+// activePolicy is the organization policy of the run, an empty policy allows everything
+var activePolicy = PolicyModel{}
+
+// SetActivePolicy ...
+func SetActivePolicy(policy PolicyModel) {
+	activePolicy = policy
+}
+
+// ActivePolicy ...
+func ActivePolicy() PolicyModel {
+	return activePolicy
+}
+
+// ParsePolicy ...
+func ParsePolicy(content []byte) (PolicyModel, error) {
+	policy := PolicyModel{}
+	if err := yaml.Unmarshal(content, &policy); err != nil {
+		return PolicyModel{}, fmt.Errorf("failed to parse policy bundle, error: %s", err)
+	}
+
+	for idx, pattern := range policy.StepAllowlist {
+		if pattern == "" {
+			return PolicyModel{}, models.NewConfigPathError(fmt.Sprintf("step_allowlist[%d]", idx), errors.New("empty step pattern"))
+		}
+	}
+
+	for idx, rule := range policy.RedactionRules {
+		re, err := regexp.Compile(rule)
+		if err != nil {
+			return PolicyModel{}, models.NewConfigPathError(fmt.Sprintf("redaction_rules[%d]", idx), fmt.Errorf("invalid redaction rule (%s), error: %s", rule, err))
+		}
+		policy.redactionRegexps = append(policy.redactionRegexps, re)
+	}
+
+	if _, err := ParseStepAuditMode(policy.StepAudit); err != nil {
+		return PolicyModel{}, models.NewConfigPathError("step_audit", err)
+	}
+
+	return policy, nil
+}
+
+// IsStepAllowed ...
+func (policy PolicyModel) IsStepAllowed(stepIDData models.StepIDData) bool {
+	if len(policy.StepAllowlist) == 0 {
+		return true
+	}
+
+	for _, pattern := range policy.StepAllowlist {
+		if glob.Glob(pattern, stepIDData.IDorURI) || glob.Glob(pattern, stepIDData.SteplibSource+"::"+stepIDData.IDorURI) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact replaces the parts of the given string, which match any of the redaction rules
+func (policy PolicyModel) Redact(str string) string {
+	for _, re := range policy.redactionRegexps {
+		str = re.ReplaceAllString(str, redactedValue)
+	}
+	return str
+}
+
+// IsTelemetryEnabled ...
+func (policy PolicyModel) IsTelemetryEnabled() bool {
+	return policy.Telemetry.Enabled == nil || *policy.Telemetry.Enabled
+}
+
+// TelemetryPlugins ...
+func (policy PolicyModel) TelemetryPlugins() []string {
+	if len(policy.Telemetry.Plugins) == 0 {
+		return defaultTelemetryPlugins
+	}
+	return policy.Telemetry.Plugins
+}
+
+// CheckRequiredHooks checks whether the required event hook plugins are installed
+func (policy PolicyModel) CheckRequiredHooks() error {
+	for _, name := range policy.RequiredHooks {
+		plugin, found, err := plugins.LoadPlugin(name)
+		if err != nil {
+			return fmt.Errorf("Failed to load required hook plugin (%s), error: %s", name, err)
+		}
+		if !found {
+			return fmt.Errorf("required hook plugin (%s) is not installed", name)
+		}
+		if plugin.TriggerEvent == "" {
+			return fmt.Errorf("required hook plugin (%s) is not an event hook", name)
+		}
+	}
+	return nil
+}
+
+// FetchPolicy returns the organization policy bundle, from the cache, if it's not older than the TTL,
+// otherwise it's downloaded from the configured URL.
+// The cached bundle is used (with a warning), if the download fails and the bundle is not older than the max stale age.
+func FetchPolicy(policyConfig configs.PolicyConfigModel) (PolicyModel, error) {
+	if policyConfig.PublicKey == "" {
+		return PolicyModel{}, errors.New("no public key configured for the policy bundle signature verification")
+	}
+
+	publicKey, err := tools.ParseToolsSigningPublicKey(policyConfig.PublicKey)
+	if err != nil {
+		return PolicyModel{}, err
+	}
+
+	return fetchPolicy(policyConfig.URL, policyConfig.TTL(), policyConfig.MaxStale(), configs.GetBitrisePolicyCacheDirPath(), func(content, signature []byte) error {
+		return tools.VerifyToolSignature(content, signature, publicKey)
+	})
+}
+
+type policySignatureVerifier func(content, signature []byte) error
+
+func fetchPolicy(url string, ttl, maxStale time.Duration, cacheDir string, verify policySignatureVerifier) (PolicyModel, error) {
+	bundlePth := filepath.Join(cacheDir, fmt.Sprintf("%x.yml", sha256.Sum256([]byte(url))))
+	signaturePth := bundlePth + policySignatureURLSuffix
+
+	if info, err := os.Stat(bundlePth); err == nil && time.Now().Sub(info.ModTime()) < ttl {
+		policy, err := readPolicyBundle(bundlePth, signaturePth, verify)
+		if err == nil {
+			log.Debugf("Using cached policy bundle: %s", bundlePth)
+			return policy, nil
+		}
+		log.Warnf("Invalid cached policy bundle, error: %s", err)
+	}
+
+	policy, content, signature, err := downloadPolicyBundle(url, verify)
+	if err != nil {
+		info, statErr := os.Stat(bundlePth)
+		if statErr != nil {
+			return PolicyModel{}, err
+		}
+		if age := time.Now().Sub(info.ModTime()); age > maxStale {
+			return PolicyModel{}, fmt.Errorf("%s, and the cached policy bundle is too old (%s), the max stale age is %s", err, age.Round(time.Second), maxStale)
+		}
+		if cachedPolicy, cacheErr := readPolicyBundle(bundlePth, signaturePth, verify); cacheErr == nil {
+			log.Warnf("Failed to fetch policy bundle, using the cached one, error: %s", err)
+			return cachedPolicy, nil
+		}
+		return PolicyModel{}, err
+	}
+
+	if err := os.MkdirAll(cacheDir, 0777); err != nil {
+		log.Warnf("Failed to create policy cache dir, error: %s", err)
+		return policy, nil
+	}
+	if err := fileutil.WriteBytesToFile(signaturePth, signature); err != nil {
+		log.Warnf("Failed to cache policy bundle signature, error: %s", err)
+	} else if err := fileutil.WriteBytesToFile(bundlePth, content); err != nil {
+		log.Warnf("Failed to cache policy bundle, error: %s", err)
+	}
+
+	return policy, nil
+}
+
+func readPolicyBundle(bundlePth, signaturePth string, verify policySignatureVerifier) (PolicyModel, error) {
+	content, err := fileutil.ReadBytesFromFile(bundlePth)
+	if err != nil {
+		return PolicyModel{}, err
+	}
+
+	signature, err := fileutil.ReadBytesFromFile(signaturePth)
+	if err != nil {
+		return PolicyModel{}, err
+	}
+
+	if err := verify(content, signature); err != nil {
+		return PolicyModel{}, fmt.Errorf("failed to verify policy bundle signature, error: %s", err)
+	}
+
+	return ParsePolicy(content)
+}
+
+func downloadPolicyBundle(url string, verify policySignatureVerifier) (PolicyModel, []byte, []byte, error) {
+	content, err := downloadPolicyFile(url)
+	if err != nil {
+		return PolicyModel{}, []byte{}, []byte{}, err
+	}
+
+	signature, err := downloadPolicyFile(url + policySignatureURLSuffix)
+	if err != nil {
+		return PolicyModel{}, []byte{}, []byte{}, err
+	}
+
+	if err := verify(content, signature); err != nil {
+		return PolicyModel{}, []byte{}, []byte{}, fmt.Errorf("failed to verify policy bundle signature, error: %s", err)
+	}
+
+	policy, err := ParsePolicy(content)
+	if err != nil {
+		return PolicyModel{}, []byte{}, []byte{}, err
+	}
+
+	return policy, content, signature, nil
+}
+
+func downloadPolicyFile(url string) ([]byte, error) {
+	resp, err := configs.HTTPClient().Get(url)
+	if err != nil {
+		return []byte{}, fmt.Errorf("failed to download (%s), error: %s", url, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Warnf("failed to close (%s) body", url)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return []byte{}, fmt.Errorf("failed to download (%s), status code: %d", url, resp.StatusCode)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
@@ -0,0 +1,163 @@
+package bitrise
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicyBundle = `
+step_allowlist:
+- script
+- https://github.com/bitrise-io/bitrise-steplib.git::xcode-*
+redaction_rules:
+- "ghp_[A-Za-z0-9]+"
+required_hooks:
+- audit
+telemetry:
+  enabled: false
+`
+
+func TestParsePolicy(t *testing.T) {
+	t.Log("valid policy")
+	{
+		policy, err := ParsePolicy([]byte(testPolicyBundle))
+		require.NoError(t, err)
+
+		steplib := "https://github.com/bitrise-io/bitrise-steplib.git"
+		require.Equal(t, true, policy.IsStepAllowed(models.StepIDData{SteplibSource: steplib, IDorURI: "script", Version: "1.1.0"}))
+		require.Equal(t, true, policy.IsStepAllowed(models.StepIDData{SteplibSource: steplib, IDorURI: "xcode-archive"}))
+		require.Equal(t, false, policy.IsStepAllowed(models.StepIDData{SteplibSource: "https://example.com/steplib.git", IDorURI: "xcode-archive"}))
+		require.Equal(t, false, policy.IsStepAllowed(models.StepIDData{SteplibSource: "git", IDorURI: "https://github.com/org/step.git"}))
+
+		require.Equal(t, "token: [REDACTED]", policy.Redact("token: ghp_abc123"))
+		require.Equal(t, false, policy.IsTelemetryEnabled())
+		require.Equal(t, []string{"analytics"}, policy.TelemetryPlugins())
+		require.Equal(t, []string{"audit"}, policy.RequiredHooks)
+	}
+
+	t.Log("empty policy allows everything")
+	{
+		policy, err := ParsePolicy([]byte(""))
+		require.NoError(t, err)
+		require.Equal(t, true, policy.IsStepAllowed(models.StepIDData{SteplibSource: "git", IDorURI: "https://github.com/org/step.git"}))
+		require.Equal(t, true, policy.IsTelemetryEnabled())
+		require.Equal(t, "ghp_abc123", policy.Redact("ghp_abc123"))
+	}
+
+	t.Log("invalid redaction rule")
+	{
+		_, err := ParsePolicy([]byte("redaction_rules:\n- \"[a-\"\n"))
+		require.Error(t, err)
+
+		pathErr, ok := err.(models.ConfigPathError)
+		require.Equal(t, true, ok)
+		require.Equal(t, "redaction_rules[0]", pathErr.Path)
+	}
+
+	t.Log("invalid step audit mode")
+	{
+		_, err := ParsePolicy([]byte("step_audit: strict\n"))
+		require.Error(t, err)
+
+		pathErr, ok := err.(models.ConfigPathError)
+		require.Equal(t, true, ok)
+		require.Equal(t, "step_audit", pathErr.Path)
+	}
+}
+
+func TestFetchPolicy(t *testing.T) {
+	downloadCount := 0
+	isServerDown := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isServerDown {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/policy.yml":
+			downloadCount++
+			_, err := w.Write([]byte(testPolicyBundle))
+			require.NoError(t, err)
+		case "/policy.yml.sig":
+			_, err := w.Write([]byte("valid"))
+			require.NoError(t, err)
+		case "/unsigned.yml":
+			_, err := w.Write([]byte(testPolicyBundle))
+			require.NoError(t, err)
+		case "/unsigned.yml.sig":
+			_, err := w.Write([]byte("invalid"))
+			require.NoError(t, err)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	verify := func(content, signature []byte) error {
+		if string(signature) != "valid" {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+
+	cacheDir, err := pathutil.NormalizedOSTempDirPath("policy_cache")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(cacheDir))
+	}()
+
+	t.Log("downloaded, then served from the cache")
+	{
+		policy, err := fetchPolicy(server.URL+"/policy.yml", time.Hour, time.Hour, cacheDir, verify)
+		require.NoError(t, err)
+		require.Equal(t, []string{"audit"}, policy.RequiredHooks)
+		require.Equal(t, 1, downloadCount)
+
+		policy, err = fetchPolicy(server.URL+"/policy.yml", time.Hour, time.Hour, cacheDir, verify)
+		require.NoError(t, err)
+		require.Equal(t, []string{"audit"}, policy.RequiredHooks)
+		require.Equal(t, 1, downloadCount)
+	}
+
+	t.Log("expired cache is downloaded again")
+	{
+		_, err := fetchPolicy(server.URL+"/policy.yml", 0, time.Hour, cacheDir, verify)
+		require.NoError(t, err)
+		require.Equal(t, 2, downloadCount)
+	}
+
+	t.Log("expired cache is used, if the download fails")
+	{
+		isServerDown = true
+		policy, err := fetchPolicy(server.URL+"/policy.yml", 0, time.Hour, cacheDir, verify)
+		isServerDown = false
+
+		require.NoError(t, err)
+		require.Equal(t, []string{"audit"}, policy.RequiredHooks)
+	}
+
+	t.Log("too old cache is not used, if the download fails")
+	{
+		isServerDown = true
+		_, err := fetchPolicy(server.URL+"/policy.yml", 0, 0, cacheDir, verify)
+		isServerDown = false
+
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "the cached policy bundle is too old")
+	}
+
+	t.Log("invalid signature")
+	{
+		_, err := fetchPolicy(server.URL+"/unsigned.yml", time.Hour, time.Hour, cacheDir, verify)
+		require.EqualError(t, err, "failed to verify policy bundle signature, error: invalid signature")
+	}
+}
@@ -0,0 +1,48 @@
+package bitrise
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// caffeinateCommand holds a power management assertion, until the given process exits or the command is killed
+func caffeinateCommand(pid int) *exec.Cmd {
+	return exec.Command("caffeinate", "-i", "-w", strconv.Itoa(pid))
+}
+
+// PreventSleep prevents the host from idle sleeping, until the returned release function is called.
+// On macOS it holds a power management assertion (via caffeinate),
+// which is bound to the bitrise process, so it's released even if bitrise is killed.
+// On other platforms it's a no-op.
+func PreventSleep() (func(), error) {
+	return preventSleep(runtime.GOOS, caffeinateCommand)
+}
+
+func preventSleep(goos string, command func(pid int) *exec.Cmd) (func(), error) {
+	noop := func() {}
+
+	if goos != "darwin" {
+		return noop, nil
+	}
+
+	caffeinate := command(os.Getpid())
+	if err := caffeinate.Start(); err != nil {
+		return noop, err
+	}
+	log.Debugf("[BITRISE_CLI] - Sleep prevention assertion created (caffeinate pid: %d)", caffeinate.Process.Pid)
+
+	return func() {
+		if err := caffeinate.Process.Kill(); err != nil {
+			log.Warnf("Failed to release sleep prevention assertion, error: %s", err)
+			return
+		}
+		if err := caffeinate.Wait(); err != nil {
+			log.Debugf("[BITRISE_CLI] - caffeinate exited: %s", err)
+		}
+		log.Debugln("[BITRISE_CLI] - Sleep prevention assertion released")
+	}, nil
+}
@@ -0,0 +1,44 @@
+package bitrise
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreventSleep(t *testing.T) {
+	t.Log("no-op on other platforms")
+	{
+		isStarted := false
+		release, err := preventSleep("linux", func(pid int) *exec.Cmd {
+			isStarted = true
+			return exec.Command("sleep", "60")
+		})
+		require.NoError(t, err)
+		require.Equal(t, false, isStarted)
+		release()
+	}
+
+	t.Log("holds the assertion until released on macOS")
+	{
+		var cmd *exec.Cmd
+		release, err := preventSleep("darwin", func(pid int) *exec.Cmd {
+			cmd = exec.Command("sleep", "60")
+			return cmd
+		})
+		require.NoError(t, err)
+		require.Nil(t, cmd.ProcessState)
+
+		release()
+		require.NotNil(t, cmd.ProcessState)
+	}
+
+	t.Log("caffeinate is not available")
+	{
+		_, err := preventSleep("darwin", func(pid int) *exec.Cmd {
+			return exec.Command("caffeinate-not-found")
+		})
+		require.Error(t, err)
+	}
+}
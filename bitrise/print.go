@@ -7,9 +7,10 @@ import (
 	"unicode/utf8"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
 	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/output"
 	"github.com/bitrise-io/bitrise/toolkits"
-	"github.com/bitrise-io/go-utils/colorstring"
 	"github.com/bitrise-io/go-utils/stringutil"
 	"github.com/bitrise-io/go-utils/versions"
 	stepmanModels "github.com/bitrise-io/stepman/models"
@@ -45,7 +46,7 @@ func getTrimmedStepName(stepRunResult models.StepRunResultsModel) string {
 	stepInfo := stepRunResult.StepInfo
 
 	title := stepInfo.Title
-	if stepInfo.GlobalInfo.RemovalDate != "" {
+	if IsStepDeprecated(stepInfo) {
 		title = fmt.Sprintf("[Deprecated] %s", title)
 	}
 
@@ -194,7 +195,11 @@ func getRunningStepHeaderSubSection(step stepmanModels.StepModel, stepInfo stepm
 		}
 	}
 
-	return fmt.Sprintf("%s\n%s\n%s\n%s\n%s", idRow, versionRow, collectionRow, toolkitRow, timeRow)
+	subSection := fmt.Sprintf("%s\n%s\n%s\n%s\n%s", idRow, versionRow, collectionRow, toolkitRow, timeRow)
+	if deprecationNotice := StepDeprecationNotice(stepInfo); deprecationNotice != "" {
+		subSection += "\n" + getDeprecateNotesRows(deprecationNotice)
+	}
+	return subSection
 }
 
 func getRunningStepFooterMainSection(stepRunResult models.StepRunResultsModel) string {
@@ -204,24 +209,24 @@ func getRunningStepFooterMainSection(stepRunResult models.StepRunResultsModel) s
 
 	icon := ""
 	title := getTrimmedStepName(stepRunResult)
-	coloringFunc := colorstring.Green
+	coloringFunc := output.Green
 
 	switch stepRunResult.Status {
 	case models.StepRunStatusCodeSuccess:
 		icon = "✓"
-		coloringFunc = colorstring.Green
+		coloringFunc = output.Green
 		break
 	case models.StepRunStatusCodeFailed:
 		icon = "x"
-		coloringFunc = colorstring.Red
+		coloringFunc = output.Red
 		break
 	case models.StepRunStatusCodeFailedSkippable:
 		icon = "!"
-		coloringFunc = colorstring.Yellow
+		coloringFunc = output.Yellow
 		break
 	case models.StepRunStatusCodeSkipped, models.StepRunStatusCodeSkippedWithRunIf:
 		icon = "-"
-		coloringFunc = colorstring.Blue
+		coloringFunc = output.Blue
 		break
 	default:
 		log.Error("Unkown result code")
@@ -234,7 +239,7 @@ func getRunningStepFooterMainSection(stepRunResult models.StepRunResultsModel) s
 	coloredTitle := title
 	if strings.HasPrefix(title, "[Deprecated]") {
 		title := strings.TrimPrefix(title, "[Deprecated]")
-		coloredTitle = fmt.Sprintf("%s%s", colorstring.Red("[Deprecated]"), coloringFunc(title))
+		coloredTitle = fmt.Sprintf("%s%s", output.Red("[Deprecated]"), coloringFunc(title))
 	} else {
 		coloredTitle = coloringFunc(title)
 	}
@@ -261,7 +266,9 @@ func getDeprecateNotesRows(notes string) string {
 	colorDeprecateNote := func(line string) string {
 		if strings.HasPrefix(line, "Removal notes:") {
 			line = strings.TrimPrefix(line, "Removal notes:")
-			line = fmt.Sprintf("%s%s", colorstring.Red("Removal notes:"), line)
+			line = fmt.Sprintf("%s%s", output.Red("Removal notes:"), line)
+		} else if strings.HasPrefix(line, "Deprecated") {
+			line = output.Red(line)
 		}
 		return line
 	}
@@ -363,7 +370,7 @@ func getRunningStepFooterSubSection(stepRunResult models.StepRunResultsModel) st
 	deprecateNotesRow := ""
 	if removalDate != "" {
 		removalDateValue := removalDate
-		removalDateKey := colorstring.Red("Removal date:")
+		removalDateKey := output.Red("Removal date:")
 
 		removalDateRow = fmt.Sprintf("| Removal date: %s |", removalDateValue)
 		charDiff := len(removalDateRow) - stepRunSummaryBoxWidthInChars
@@ -400,7 +407,7 @@ func getRunningStepFooterSubSection(stepRunResult models.StepRunResultsModel) st
 		var coloringFunc func(...interface{}) string
 		supportURL := stepInfo.SupportURL
 		if supportURL == "" {
-			coloringFunc = colorstring.Yellow
+			coloringFunc = output.Yellow
 			supportURL = "Not provided"
 		}
 
@@ -431,7 +438,7 @@ func getRunningStepFooterSubSection(stepRunResult models.StepRunResultsModel) st
 		coloringFunc = nil
 		sourceCodeURL := stepInfo.SourceCodeURL
 		if sourceCodeURL == "" {
-			coloringFunc = colorstring.Yellow
+			coloringFunc = output.Yellow
 			sourceCodeURL = "Not provided"
 		}
 
@@ -479,6 +486,16 @@ func getRunningStepFooterSubSection(stepRunResult models.StepRunResultsModel) st
 		content = fmt.Sprintf("%s", sourceRow)
 	}
 
+	// Skip reason
+	if stepRunResult.SkipReason != "" {
+		skipReasonRow := getDeprecateNotesRows(fmt.Sprintf("Skip reason: %s", stepRunResult.SkipReason))
+		if content != "" {
+			content = fmt.Sprintf("%s\n%s", content, skipReasonRow)
+		} else {
+			content = fmt.Sprintf("%s", skipReasonRow)
+		}
+	}
+
 	// Deprecation
 	if removalDate != "" {
 		if content != "" {
@@ -501,6 +518,11 @@ func getRunningStepFooterSubSection(stepRunResult models.StepRunResultsModel) st
 
 // PrintRunningStepHeader ...
 func PrintRunningStepHeader(stepInfo stepmanModels.StepInfoModel, step stepmanModels.StepModel, idx int) {
+	if configs.IsAccessibleMode {
+		fmt.Println(getAccessibleStepHeader(stepInfo, step))
+		return
+	}
+
 	sep := fmt.Sprintf("+%s+", strings.Repeat("-", stepRunSummaryBoxWidthInChars-2))
 
 	fmt.Println(sep)
@@ -513,6 +535,12 @@ func PrintRunningStepHeader(stepInfo stepmanModels.StepInfoModel, step stepmanMo
 
 // PrintRunningStepFooter ..
 func PrintRunningStepFooter(stepRunResult models.StepRunResultsModel, isLastStepInWorkflow bool) {
+	if configs.IsAccessibleMode {
+		fmt.Println(getAccessibleStepResult(stepRunResult, "Step"))
+		fmt.Println()
+		return
+	}
+
 	iconBoxWidth := len("   ")
 	timeBoxWidth := len(" time (s) ")
 	titleBoxWidth := stepRunSummaryBoxWidthInChars - 4 - iconBoxWidth - timeBoxWidth
@@ -523,7 +551,7 @@ func PrintRunningStepFooter(stepRunResult models.StepRunResultsModel, isLastStep
 	fmt.Println(sep)
 	fmt.Println(getRunningStepFooterMainSection(stepRunResult))
 	fmt.Println(sep)
-	if stepRunResult.Error != nil || stepRunResult.StepInfo.GlobalInfo.RemovalDate != "" {
+	if stepRunResult.Error != nil || stepRunResult.StepInfo.GlobalInfo.RemovalDate != "" || stepRunResult.SkipReason != "" {
 		footerSubSection := getRunningStepFooterSubSection(stepRunResult)
 		if footerSubSection != "" {
 			fmt.Println(footerSubSection)
@@ -540,13 +568,44 @@ func PrintRunningStepFooter(stepRunResult models.StepRunResultsModel, isLastStep
 
 // PrintRunningWorkflow ...
 func PrintRunningWorkflow(title string) {
+	PrintRunningWorkflowWithMeta(title, "", []string{})
+}
+
+// PrintRunningWorkflowWithMeta prints the workflow header, with the workflow's owner and tags (if any)
+func PrintRunningWorkflowWithMeta(title, owner string, tags []string) {
+	if configs.IsAccessibleMode {
+		fmt.Println()
+		fmt.Printf("Switching to workflow: %s.\n", title)
+		if owner != "" {
+			fmt.Printf("Workflow owner: %s.\n", owner)
+		}
+		if len(tags) > 0 {
+			fmt.Printf("Workflow tags: %s.\n", strings.Join(tags, ", "))
+		}
+		fmt.Println()
+		return
+	}
+
 	fmt.Println()
-	log.Infoln(colorstring.Blue("Switching to workflow:"), title)
+	log.Infoln(output.Blue("Switching to workflow:"), title)
+	if owner != "" {
+		log.Infoln(output.Blue("Owner:"), owner)
+	}
+	if len(tags) > 0 {
+		log.Infoln(output.Blue("Tags:"), strings.Join(tags, ", "))
+	}
 	fmt.Println()
 }
 
 // PrintSummary ...
 func PrintSummary(buildRunResults models.BuildRunResultsModel) {
+	if configs.IsAccessibleMode {
+		fmt.Println()
+		fmt.Println(getAccessibleSummary(buildRunResults))
+		fmt.Println()
+		return
+	}
+
 	iconBoxWidth := len("   ")
 	timeBoxWidth := len(" time (s) ")
 	titleBoxWidth := stepRunSummaryBoxWidthInChars - 4 - iconBoxWidth - timeBoxWidth
@@ -568,7 +627,7 @@ func PrintSummary(buildRunResults models.BuildRunResultsModel) {
 		tmpTime = tmpTime.Add(stepRunResult.RunTime)
 		fmt.Println(getRunningStepFooterMainSection(stepRunResult))
 		fmt.Printf("+%s+%s+%s+\n", strings.Repeat("-", iconBoxWidth), strings.Repeat("-", titleBoxWidth), strings.Repeat("-", timeBoxWidth))
-		if stepRunResult.Error != nil || stepRunResult.StepInfo.GlobalInfo.RemovalDate != "" {
+		if stepRunResult.Error != nil || stepRunResult.StepInfo.GlobalInfo.RemovalDate != "" || stepRunResult.SkipReason != "" {
 			footerSubSection := getRunningStepFooterSubSection(stepRunResult)
 			if footerSubSection != "" {
 				fmt.Println(footerSubSection)
@@ -0,0 +1,117 @@
+package bitrise
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/toolkits"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// Accessible output mode (see: configs.IsAccessibleMode).
+// Every statement is a single, self-contained line, without box drawings, icons and colors,
+// the step statuses are spelled out.
+
+// AccessibleStepStatus returns the textual status marker of the step run status
+func AccessibleStepStatus(status int) string {
+	switch status {
+	case models.StepRunStatusCodeSuccess:
+		return "SUCCESS"
+	case models.StepRunStatusCodeFailed:
+		return "FAILED"
+	case models.StepRunStatusCodeFailedSkippable:
+		return "FAILED, SKIPPABLE"
+	case models.StepRunStatusCodeSkipped:
+		return "SKIPPED"
+	case models.StepRunStatusCodeSkippedWithRunIf:
+		return "SKIPPED BY RUN_IF"
+	}
+	return "UNKNOWN"
+}
+
+func accessibleRunTime(runTime time.Duration) string {
+	runTimeStr, err := FormattedSecondsToMax8Chars(runTime)
+	if err != nil {
+		return "more than 999 hours"
+	}
+	return runTimeStr
+}
+
+func getAccessibleStepHeader(stepInfo stepmanModels.StepInfoModel, step stepmanModels.StepModel) string {
+	details := []string{"ID: " + stepInfo.ID}
+	if stepInfo.Version != "" {
+		details = append(details, "version: "+stepInfo.Version)
+	}
+	if stepInfo.StepLib != "" {
+		details = append(details, "collection: "+stepInfo.StepLib)
+	}
+	details = append(details, "toolkit: "+toolkits.ToolkitForStep(step).ToolkitName())
+
+	header := fmt.Sprintf("Step started: %s.\nStep details: %s.", stepInfo.Title, strings.Join(details, ", "))
+	if deprecationNotice := StepDeprecationNotice(stepInfo); deprecationNotice != "" {
+		header += fmt.Sprintf("\nStep warning: %s.", strings.TrimSuffix(deprecationNotice, "."))
+	}
+	return header
+}
+
+// getAccessibleStepResult returns the result statements of the step,
+// each of them starts with the given label (e.g. Step 2)
+func getAccessibleStepResult(stepRunResult models.StepRunResultsModel, label string) string {
+	stepInfo := stepRunResult.StepInfo
+
+	lines := []string{}
+
+	result := fmt.Sprintf("%s result: %s. %s, run time: %s", label, AccessibleStepStatus(stepRunResult.Status), stepInfo.Title, accessibleRunTime(stepRunResult.RunTime))
+	if stepRunResult.Status == models.StepRunStatusCodeFailed || stepRunResult.Status == models.StepRunStatusCodeFailedSkippable {
+		result += fmt.Sprintf(", exit code: %d", stepRunResult.ExitCode)
+	}
+	lines = append(lines, result+".")
+
+	if stepRunResult.Error != nil {
+		lines = append(lines, fmt.Sprintf("%s error: %s", label, stepRunResult.Error))
+		if stepInfo.SupportURL != "" {
+			lines = append(lines, fmt.Sprintf("%s issue tracker: %s", label, stepInfo.SupportURL))
+		}
+		if stepInfo.SourceCodeURL != "" {
+			lines = append(lines, fmt.Sprintf("%s source: %s", label, stepInfo.SourceCodeURL))
+		}
+	}
+	if stepRunResult.SkipReason != "" {
+		lines = append(lines, fmt.Sprintf("%s skip reason: %s", label, stepRunResult.SkipReason))
+	}
+	if stepInfo.GlobalInfo.RemovalDate != "" {
+		deprecation := fmt.Sprintf("%s is deprecated, removal date: %s", label, stepInfo.GlobalInfo.RemovalDate)
+		if stepInfo.GlobalInfo.DeprecateNotes != "" {
+			deprecation += ", notes: " + stepInfo.GlobalInfo.DeprecateNotes
+		}
+		lines = append(lines, deprecation)
+	}
+	if isUpdateAvailable(stepInfo) {
+		lines = append(lines, fmt.Sprintf("%s update available: %s to %s", label, stepInfo.Version, stepInfo.Latest))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func getAccessibleSummary(buildRunResults models.BuildRunResultsModel) string {
+	orderedResults := buildRunResults.OrderedResults()
+
+	lines := []string{fmt.Sprintf("Build summary, %d steps:", len(orderedResults))}
+	runTime := time.Duration(0)
+	for idx, stepRunResult := range orderedResults {
+		runTime += stepRunResult.RunTime
+		lines = append(lines, getAccessibleStepResult(stepRunResult, fmt.Sprintf("Step %d", idx+1)))
+	}
+
+	status := BuildStatusSuccess
+	if buildRunResults.IsAborted {
+		status = BuildStatusAborted
+	} else if buildRunResults.IsBuildFailed() {
+		status = BuildStatusFailed
+	}
+	lines = append(lines, fmt.Sprintf("Build %s, total run time: %s.", strings.ToUpper(status), accessibleRunTime(runTime)))
+
+	return strings.Join(lines, "\n")
+}
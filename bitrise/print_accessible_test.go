@@ -0,0 +1,68 @@
+package bitrise
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/bitrise/models"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAccessibleStepResult(t *testing.T) {
+	t.Log("success")
+	{
+		result := models.StepRunResultsModel{
+			StepInfo: stepmanModels.StepInfoModel{ID: "script", Title: "Hello", Version: "1.1.0"},
+			Status:   models.StepRunStatusCodeSuccess,
+			RunTime:  2 * time.Second,
+		}
+		require.Equal(t, "Step result: SUCCESS. Hello, run time: 2.00 sec.", getAccessibleStepResult(result, "Step"))
+	}
+
+	t.Log("failed, with error and deprecation")
+	{
+		result := models.StepRunResultsModel{
+			StepInfo: stepmanModels.StepInfoModel{
+				ID:         "script",
+				Title:      "Fail",
+				SupportURL: "https://issues.example.com",
+				GlobalInfo: stepmanModels.GlobalStepInfoModel{RemovalDate: "2017-01-01"},
+			},
+			Status:   models.StepRunStatusCodeFailed,
+			RunTime:  500 * time.Millisecond,
+			Error:    errors.New("exit status 2"),
+			ExitCode: 2,
+		}
+		require.Equal(t, `Step 2 result: FAILED. Fail, run time: 0.50 sec, exit code: 2.
+Step 2 error: exit status 2
+Step 2 issue tracker: https://issues.example.com
+Step 2 is deprecated, removal date: 2017-01-01`, getAccessibleStepResult(result, "Step 2"))
+	}
+}
+
+func TestGetAccessibleSummary(t *testing.T) {
+	buildRunResults := models.BuildRunResultsModel{
+		SuccessSteps: []models.StepRunResultsModel{
+			models.StepRunResultsModel{
+				StepInfo: stepmanModels.StepInfoModel{Title: "First"},
+				Status:   models.StepRunStatusCodeSuccess,
+				Idx:      0,
+				RunTime:  time.Second,
+			},
+		},
+		SkippedSteps: []models.StepRunResultsModel{
+			models.StepRunResultsModel{
+				StepInfo: stepmanModels.StepInfoModel{Title: "Second"},
+				Status:   models.StepRunStatusCodeSkippedWithRunIf,
+				Idx:      1,
+			},
+		},
+	}
+
+	require.Equal(t, `Build summary, 2 steps:
+Step 1 result: SUCCESS. First, run time: 1.00 sec.
+Step 2 result: SKIPPED BY RUN_IF. Second, run time: 0.00 sec.
+Build SUCCESS, total run time: 1.00 sec.`, getAccessibleSummary(buildRunResults))
+}
@@ -0,0 +1,483 @@
+package bitrise
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+)
+
+const (
+	// ConfigSourceTypeFile ...
+	ConfigSourceTypeFile = "file"
+	// ConfigSourceTypeFlag ...
+	ConfigSourceTypeFlag = "flag"
+	// ConfigSourceTypeDefault ...
+	ConfigSourceTypeDefault = "default"
+	// ConfigSourceTypeStepDefaults ...
+	ConfigSourceTypeStepDefaults = "step_defaults"
+	// ConfigSourceTypeStepBundle ...
+	ConfigSourceTypeStepBundle = "step_bundle"
+)
+
+// ConfigSourceModel ...
+type ConfigSourceModel struct {
+	Type     string `json:"type" yaml:"type"`
+	Location string `json:"location,omitempty" yaml:"location,omitempty"`
+}
+
+// ConfigProvenanceItemModel ...
+type ConfigProvenanceItemModel struct {
+	Path   string            `json:"path" yaml:"path"`
+	Source ConfigSourceModel `json:"source" yaml:"source"`
+}
+
+// ConfigProvenanceModel ...
+// Items are ordered: a later item overrides every earlier one
+// with the same or a shorter (parent) path.
+type ConfigProvenanceModel struct {
+	Items []ConfigProvenanceItemModel `json:"items" yaml:"items"`
+}
+
+// ConfigExplainModel ...
+type ConfigExplainModel struct {
+	Path   string            `json:"path" yaml:"path"`
+	Value  interface{}       `json:"value" yaml:"value"`
+	Source ConfigSourceModel `json:"source" yaml:"source"`
+}
+
+// String ...
+func (source ConfigSourceModel) String() string {
+	if source.Location == "" {
+		return source.Type
+	}
+	return fmt.Sprintf("%s (%s)", source.Type, source.Location)
+}
+
+// NewConfigProvenance ...
+func NewConfigProvenance(source ConfigSourceModel) ConfigProvenanceModel {
+	return ConfigProvenanceModel{
+		Items: []ConfigProvenanceItemModel{
+			ConfigProvenanceItemModel{Path: "", Source: source},
+		},
+	}
+}
+
+// Record ...
+func (provenance *ConfigProvenanceModel) Record(path string, source ConfigSourceModel) {
+	provenance.Items = append(provenance.Items, ConfigProvenanceItemModel{Path: path, Source: source})
+}
+
+// RecordConfig records the source of every value, defined by the config
+// (e.g. the values of an overlay config, merged into the base config, see: models.MergeConfigs).
+// The envs are recorded by env key, the trigger map items by index, the other lists as a whole.
+func (provenance *ConfigProvenanceModel) RecordConfig(config models.BitriseDataModel, source ConfigSourceModel) error {
+	tree, err := configTree(config)
+	if err != nil {
+		return err
+	}
+	provenance.recordConfigTree("", tree, source)
+	return nil
+}
+
+func (provenance *ConfigProvenanceModel) recordConfigTree(path string, node interface{}, source ConfigSourceModel) {
+	switch typedNode := node.(type) {
+	case map[string]interface{}:
+		keys := []string{}
+		for key := range typedNode {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			provenance.recordConfigTree(childPath, typedNode[key], source)
+		}
+	case []interface{}:
+		if path == "trigger_map" {
+			for idx := range typedNode {
+				provenance.Record(fmt.Sprintf("%s[%d]", path, idx), source)
+			}
+			return
+		}
+		if keys, isEnvList := envListKeys(typedNode); isEnvList {
+			for _, key := range keys {
+				provenance.Record(path+"."+key, source)
+			}
+			return
+		}
+		provenance.Record(path, source)
+	default:
+		provenance.Record(path, source)
+	}
+}
+
+// envListKeys returns the keys of the env list items ({KEY: value, opts: {...}})
+func envListKeys(list []interface{}) ([]string, bool) {
+	if len(list) == 0 {
+		return []string{}, false
+	}
+
+	keys := []string{}
+	for _, item := range list {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			return []string{}, false
+		}
+
+		key := ""
+		for itemKey := range itemMap {
+			if itemKey == envmanModels.OptionsKey {
+				continue
+			}
+			if key != "" {
+				return []string{}, false
+			}
+			key = itemKey
+		}
+		if key == "" {
+			return []string{}, false
+		}
+		keys = append(keys, key)
+	}
+	return keys, true
+}
+
+// RecordStepBundles records the source of the steps, the step bundle references of the config expand to (see: models.ExpandStepBundles).
+// Has to be called before the expansion: the expanded steps are recorded at their index in the expanded step list,
+// their inputs, overridden by the reference, are recorded with the source of the reference
+// (only for the steps, which have the input: by the bundle's or by the step's inputs).
+func (provenance *ConfigProvenanceModel) RecordStepBundles(config models.BitriseDataModel) error {
+	workflowIDs := []string{}
+	for workflowID := range config.Workflows {
+		workflowIDs = append(workflowIDs, workflowID)
+	}
+	sort.Strings(workflowIDs)
+
+	for _, workflowID := range workflowIDs {
+		expandedIdx := 0
+		for idx, stepListItem := range config.Workflows[workflowID].Steps {
+			stepID, step, err := models.GetStepIDStepDataPair(stepListItem)
+			if err != nil {
+				return models.NewConfigPathError(fmt.Sprintf("workflows.%s.steps[%d]", workflowID, idx), err)
+			}
+			if !models.IsStepBundleReference(stepID) {
+				expandedIdx++
+				continue
+			}
+
+			bundleID := models.StepBundleIDFromReference(stepID)
+			bundle, found := config.StepBundles[bundleID]
+			if !found {
+				return models.NewConfigPathError(fmt.Sprintf("workflows.%s.steps[%d]", workflowID, idx), fmt.Errorf("step bundle (%s) does not exist", bundleID))
+			}
+
+			bundleInputKeys, err := inputKeys(bundle.Inputs)
+			if err != nil {
+				return models.NewConfigPathError("step_bundles."+bundleID, err)
+			}
+
+			referenceSource := provenance.SourceOf(fmt.Sprintf("workflows.%s.steps[%d]", workflowID, idx))
+			bundleSource := ConfigSourceModel{Type: ConfigSourceTypeStepBundle, Location: "step_bundles." + bundleID}
+			for bundleStepIdx, bundleStepListItem := range bundle.Steps {
+				_, bundleStep, err := models.GetStepIDStepDataPair(bundleStepListItem)
+				if err != nil {
+					return models.NewConfigPathError(fmt.Sprintf("step_bundles.%s.steps[%d]", bundleID, bundleStepIdx), err)
+				}
+				stepInputKeys, err := inputKeys(bundleStep.Inputs)
+				if err != nil {
+					return models.NewConfigPathError(fmt.Sprintf("step_bundles.%s.steps[%d]", bundleID, bundleStepIdx), err)
+				}
+
+				stepPath := fmt.Sprintf("workflows.%s.steps[%d]", workflowID, expandedIdx)
+				provenance.Record(stepPath, bundleSource)
+				for _, input := range step.Inputs {
+					key, _, err := input.GetKeyValuePair()
+					if err != nil {
+						return models.NewConfigPathError(fmt.Sprintf("workflows.%s.steps[%d].%s", workflowID, idx, stepID), err)
+					}
+					if !bundleInputKeys[key] && !stepInputKeys[key] {
+						continue
+					}
+					provenance.Record(stepPath+".inputs."+key, referenceSource)
+				}
+				expandedIdx++
+			}
+		}
+	}
+	return nil
+}
+
+func inputKeys(inputs []envmanModels.EnvironmentItemModel) (map[string]bool, error) {
+	keys := map[string]bool{}
+	for _, input := range inputs {
+		key, _, err := input.GetKeyValuePair()
+		if err != nil {
+			return map[string]bool{}, err
+		}
+		keys[key] = true
+	}
+	return keys, nil
+}
+
+// RecordStepDefaults records the source of the inputs, the step_defaults add to the steps of the config (see: models.ApplyStepDefaults).
+// Has to be called before the step defaults are applied, and after the step bundles are expanded.
+func (provenance *ConfigProvenanceModel) RecordStepDefaults(config models.BitriseDataModel) error {
+	if len(config.StepDefaults) == 0 {
+		return nil
+	}
+
+	workflowIDs := []string{}
+	for workflowID := range config.Workflows {
+		workflowIDs = append(workflowIDs, workflowID)
+	}
+	sort.Strings(workflowIDs)
+
+	for _, workflowID := range workflowIDs {
+		for idx, stepListItem := range config.Workflows[workflowID].Steps {
+			compositeStepID, step, err := models.GetStepIDStepDataPair(stepListItem)
+			if err != nil {
+				return models.NewConfigPathError(fmt.Sprintf("workflows.%s.steps[%d]", workflowID, idx), err)
+			}
+
+			defaultsID := config.StepDefaultsID(compositeStepID)
+			defaults, found := config.StepDefaults[defaultsID]
+			if !found {
+				continue
+			}
+
+			definedKeys := map[string]bool{}
+			for _, input := range step.Inputs {
+				key, _, err := input.GetKeyValuePair()
+				if err != nil {
+					return models.NewConfigPathError(fmt.Sprintf("workflows.%s.steps[%d].%s", workflowID, idx, compositeStepID), err)
+				}
+				definedKeys[key] = true
+			}
+
+			for _, input := range defaults.Inputs {
+				key, _, err := input.GetKeyValuePair()
+				if err != nil {
+					return models.NewConfigPathError("step_defaults."+defaultsID, err)
+				}
+				if definedKeys[key] {
+					continue
+				}
+				provenance.Record(fmt.Sprintf("workflows.%s.steps[%d].inputs.%s", workflowID, idx, key), ConfigSourceModel{
+					Type:     ConfigSourceTypeStepDefaults,
+					Location: "step_defaults." + defaultsID,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// SourceOf ...
+func (provenance ConfigProvenanceModel) SourceOf(path string) ConfigSourceModel {
+	source := ConfigSourceModel{Type: ConfigSourceTypeDefault}
+	for _, item := range provenance.Items {
+		if isConfigPathPrefix(item.Path, path) {
+			source = item.Source
+		}
+	}
+	return source
+}
+
+// Explain ...
+func (provenance ConfigProvenanceModel) Explain(config models.BitriseDataModel, path string) (ConfigExplainModel, error) {
+	value, canonicalPath, err := ResolveConfigPath(config, path)
+	if err != nil {
+		return ConfigExplainModel{}, err
+	}
+
+	return ConfigExplainModel{
+		Path:   canonicalPath,
+		Value:  value,
+		Source: provenance.SourceOf(canonicalPath),
+	}, nil
+}
+
+// SaveConfigProvenance ...
+func SaveConfigProvenance(pth string, provenance ConfigProvenanceModel) error {
+	bytes, err := json.MarshalIndent(provenance, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteNonEssentialBytesToFile(pth, bytes)
+}
+
+func isConfigPathPrefix(prefix, path string) bool {
+	if prefix == "" || prefix == path {
+		return true
+	}
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	next := path[len(prefix)]
+	return next == '.' || next == '['
+}
+
+type configPathComponent struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+func parseConfigPath(path string) ([]configPathComponent, error) {
+	components := []configPathComponent{}
+	for _, part := range strings.Split(path, ".") {
+		if part == "" {
+			return []configPathComponent{}, fmt.Errorf("invalid config path (%s): empty component", path)
+		}
+
+		key := part
+		indexes := []string{}
+		if idx := strings.Index(part, "["); idx != -1 {
+			key = part[:idx]
+			rest := part[idx:]
+			for rest != "" {
+				if !strings.HasPrefix(rest, "[") {
+					return []configPathComponent{}, fmt.Errorf("invalid config path (%s): malformed index in (%s)", path, part)
+				}
+				end := strings.Index(rest, "]")
+				if end == -1 {
+					return []configPathComponent{}, fmt.Errorf("invalid config path (%s): unclosed index in (%s)", path, part)
+				}
+				indexes = append(indexes, rest[1:end])
+				rest = rest[end+1:]
+			}
+		}
+
+		if key != "" {
+			components = append(components, configPathComponent{key: key})
+		}
+		for _, indexStr := range indexes {
+			index, err := strconv.Atoi(indexStr)
+			if err != nil || index < 0 {
+				return []configPathComponent{}, fmt.Errorf("invalid config path (%s): invalid index (%s)", path, indexStr)
+			}
+			components = append(components, configPathComponent{index: index, isIndex: true})
+		}
+	}
+	return components, nil
+}
+
+func configTree(config models.BitriseDataModel) (interface{}, error) {
+	bytes, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree interface{}
+	if err := json.Unmarshal(bytes, &tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// ResolveConfigPath returns the value found at the given path
+// (e.g.: workflows.deploy.steps[3].inputs.team_id) and the path in canonical form.
+// Step list items can be addressed without the step ID,
+// env lists (envs, inputs, outputs) can be addressed by env key.
+func ResolveConfigPath(config models.BitriseDataModel, path string) (interface{}, string, error) {
+	components, err := parseConfigPath(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	node, err := configTree(config)
+	if err != nil {
+		return nil, "", err
+	}
+
+	canonicalPath := ""
+	isStepListItem := false
+	parentKey := ""
+	for _, component := range components {
+		if component.isIndex {
+			list, ok := node.([]interface{})
+			if !ok {
+				return nil, "", fmt.Errorf("(%s) is not a list", canonicalPath)
+			}
+			if component.index >= len(list) {
+				return nil, "", fmt.Errorf("(%s) has only %d items", canonicalPath, len(list))
+			}
+			node = list[component.index]
+			canonicalPath = fmt.Sprintf("%s[%d]", canonicalPath, component.index)
+			isStepListItem = (parentKey == "steps")
+			continue
+		}
+
+		switch typedNode := node.(type) {
+		case map[string]interface{}:
+			if isStepListItem {
+				isStepListItem = false
+				if step, ok := stepListItemBody(typedNode); ok {
+					if _, isStepID := typedNode[component.key]; isStepID {
+						// the step ID is optional in the path, it is not part of the canonical form
+						node = step
+						continue
+					}
+					typedNode = step
+				}
+			}
+
+			value, found := typedNode[component.key]
+			if !found {
+				return nil, "", fmt.Errorf("no (%s) found at (%s)", component.key, canonicalPath)
+			}
+			node = value
+		case []interface{}:
+			found := false
+			for _, item := range typedNode {
+				itemMap, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if value, ok := itemMap[component.key]; ok {
+					node = value
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, "", fmt.Errorf("no item with key (%s) found in (%s)", component.key, canonicalPath)
+			}
+		default:
+			return nil, "", fmt.Errorf("(%s) has no child (%s)", canonicalPath, component.key)
+		}
+
+		if canonicalPath == "" {
+			canonicalPath = component.key
+		} else {
+			canonicalPath = canonicalPath + "." + component.key
+		}
+		parentKey = component.key
+	}
+
+	return node, canonicalPath, nil
+}
+
+func stepListItemBody(item map[string]interface{}) (map[string]interface{}, bool) {
+	if len(item) != 1 {
+		return map[string]interface{}{}, false
+	}
+	for _, step := range item {
+		stepMap, ok := step.(map[string]interface{})
+		if ok {
+			return stepMap, true
+		}
+		if step == nil {
+			return map[string]interface{}{}, true
+		}
+	}
+	return map[string]interface{}{}, false
+}
@@ -0,0 +1,104 @@
+package bitrise
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveConfigPath(t *testing.T) {
+	configStr := `
+format_version: 1.3.1
+default_step_lib_source: "https://github.com/bitrise-io/bitrise-steplib.git"
+
+workflows:
+  deploy:
+    envs:
+    - ENV_KEY: env value
+    steps:
+    - script:
+        title: first
+    - xcode-archive:
+        inputs:
+        - team_id: ABCD1234
+        - export_method: app-store
+`
+	config, warnings, err := ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	t.Log("step input - without step id")
+	{
+		value, canonicalPath, err := ResolveConfigPath(config, "workflows.deploy.steps[1].inputs.team_id")
+		require.NoError(t, err)
+		require.Equal(t, "ABCD1234", value)
+		require.Equal(t, "workflows.deploy.steps[1].inputs.team_id", canonicalPath)
+	}
+
+	t.Log("step input - with step id")
+	{
+		value, canonicalPath, err := ResolveConfigPath(config, "workflows.deploy.steps[1].xcode-archive.inputs.export_method")
+		require.NoError(t, err)
+		require.Equal(t, "app-store", value)
+		require.Equal(t, "workflows.deploy.steps[1].inputs.export_method", canonicalPath)
+	}
+
+	t.Log("workflow env")
+	{
+		value, canonicalPath, err := ResolveConfigPath(config, "workflows.deploy.envs.ENV_KEY")
+		require.NoError(t, err)
+		require.Equal(t, "env value", value)
+		require.Equal(t, "workflows.deploy.envs.ENV_KEY", canonicalPath)
+	}
+
+	t.Log("out of range index")
+	{
+		_, _, err := ResolveConfigPath(config, "workflows.deploy.steps[3].inputs.team_id")
+		require.Error(t, err)
+	}
+
+	t.Log("missing key")
+	{
+		_, _, err := ResolveConfigPath(config, "workflows.primary")
+		require.Error(t, err)
+	}
+
+	t.Log("invalid path")
+	{
+		_, _, err := ResolveConfigPath(config, "workflows..deploy")
+		require.Error(t, err)
+
+		_, _, err = ResolveConfigPath(config, "workflows.deploy.steps[a]")
+		require.Error(t, err)
+
+		_, _, err = ResolveConfigPath(config, "workflows.deploy.steps[1")
+		require.Error(t, err)
+	}
+}
+
+func TestConfigProvenanceSourceOf(t *testing.T) {
+	fileSource := ConfigSourceModel{Type: ConfigSourceTypeFile, Location: "bitrise.yml"}
+	flagSource := ConfigSourceModel{Type: ConfigSourceTypeFlag, Location: "--env"}
+
+	provenance := NewConfigProvenance(fileSource)
+	provenance.Record("workflows.deploy.steps[1]", flagSource)
+
+	t.Log("root source")
+	{
+		require.Equal(t, fileSource, provenance.SourceOf("format_version"))
+		require.Equal(t, fileSource, provenance.SourceOf("workflows.deploy.steps[0].title"))
+		require.Equal(t, fileSource, provenance.SourceOf("workflows.deploy.steps[10]"))
+	}
+
+	t.Log("override")
+	{
+		require.Equal(t, flagSource, provenance.SourceOf("workflows.deploy.steps[1]"))
+		require.Equal(t, flagSource, provenance.SourceOf("workflows.deploy.steps[1].inputs.team_id"))
+	}
+
+	t.Log("later record wins")
+	{
+		provenance.Record("workflows.deploy", fileSource)
+		require.Equal(t, fileSource, provenance.SourceOf("workflows.deploy.steps[1].inputs.team_id"))
+	}
+}
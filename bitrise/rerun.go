@@ -0,0 +1,231 @@
+package bitrise
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/pathutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// Rerun of the failed steps (see: --rerun-failed).
+// The summary of the last run of every workflow is stored (see: configs.GetBitriseLastRunDirPath),
+// with its steps and the envs from before the first failed step. A rerun runs only the steps which failed in the last run,
+// and their dependencies: the later steps, which declare an output referenced by the inputs of a rerun step
+// (the outputs of the earlier steps are part of the stored envs). The other steps are skipped,
+// and the stored envs are restored before the first rerun step.
+// The steps of the rerun have to match the steps of the last run (workflow, index and step ID), the rerun is refused otherwise.
+
+// ErrStepNotRerun is the skip error of the steps, which did not fail in the last run
+var ErrStepNotRerun = errors.New("the step did not fail in the last run (--rerun-failed)")
+
+// ErrRerunRefused is the skip error of the steps, after a step which doesn't match the step of the last run
+var ErrRerunRefused = errors.New("the rerun was refused, the workflow has changed since the last run (--rerun-failed)")
+
+// inputEnvReferenceRegexp matches the env references of an input value: $KEY or ${KEY}
+var inputEnvReferenceRegexp = regexp.MustCompile(`\$\{?([a-zA-Z_][a-zA-Z0-9_]*)\}?`)
+
+// LastRunStepModel is a step of the last run, with the envs it exchanges with the other steps
+type LastRunStepModel struct {
+	Workflow string `json:"workflow"`
+	// Idx is the index of the step in the run (see: models.StepRunResultsModel.Idx)
+	Idx int `json:"idx"`
+	// ID is the step ID, as it is in the workflow (e.g. script@1)
+	ID         string `json:"id"`
+	StatusCode int    `json:"status_code"`
+	// Outputs are the keys of the outputs declared by the step
+	Outputs []string `json:"outputs,omitempty"`
+	// InputEnvs are the keys of the envs referenced by the inputs of the step
+	InputEnvs []string `json:"input_envs,omitempty"`
+}
+
+// NewLastRunStep ...
+func NewLastRunStep(workflowID string, idx int, stepID string, statusCode int, step stepmanModels.StepModel) LastRunStepModel {
+	lastRunStep := LastRunStepModel{
+		Workflow:   workflowID,
+		Idx:        idx,
+		ID:         stepID,
+		StatusCode: statusCode,
+	}
+
+	for _, output := range step.Outputs {
+		if key, _, err := output.GetKeyValuePair(); err == nil {
+			lastRunStep.Outputs = append(lastRunStep.Outputs, key)
+		}
+	}
+
+	referenced := map[string]bool{}
+	for _, input := range step.Inputs {
+		_, value, err := input.GetKeyValuePair()
+		if err != nil {
+			continue
+		}
+		for _, match := range inputEnvReferenceRegexp.FindAllStringSubmatch(value, -1) {
+			if !referenced[match[1]] {
+				referenced[match[1]] = true
+				lastRunStep.InputEnvs = append(lastRunStep.InputEnvs, match[1])
+			}
+		}
+	}
+
+	return lastRunStep
+}
+
+func (lastRunStep LastRunStepModel) isFailed() bool {
+	return lastRunStep.StatusCode == models.StepRunStatusCodeFailed
+}
+
+func (lastRunStep LastRunStepModel) isOutput(key string) bool {
+	for _, output := range lastRunStep.Outputs {
+		if output == key {
+			return true
+		}
+	}
+	return false
+}
+
+// LastRunModel is the stored result of the last run of a workflow
+type LastRunModel struct {
+	Summary BuildSummaryModel  `json:"summary"`
+	Steps   []LastRunStepModel `json:"steps,omitempty"`
+	// Environments are the envs from before the first failed step, without the secrets
+	Environments []envmanModels.EnvironmentItemModel `json:"environments,omitempty"`
+}
+
+// LastRunKey identifies the runs of the same workflow of the same project
+func LastRunKey(projectDir, workflowID string) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n", projectDir, workflowID)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func lastRunPath(dir, key string) string {
+	return filepath.Join(dir, key+".json")
+}
+
+// WriteLastRun stores the result of the run, as the last run of the workflow
+func WriteLastRun(dir, key string, lastRun LastRunModel) error {
+	if err := pathutil.EnsureDirExist(dir); err != nil {
+		return err
+	}
+
+	bytes, err := json.Marshal(lastRun)
+	if err != nil {
+		return fmt.Errorf("Failed to serialize the last run, error: %s", err)
+	}
+	return ioutil.WriteFile(lastRunPath(dir, key), bytes, 0600)
+}
+
+// ReadLastRun returns the stored last run of the workflow
+func ReadLastRun(dir, key string) (LastRunModel, error) {
+	bytes, err := ioutil.ReadFile(lastRunPath(dir, key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return LastRunModel{}, errors.New("the workflow has no stored last run")
+		}
+		return LastRunModel{}, err
+	}
+
+	var lastRun LastRunModel
+	if err := json.Unmarshal(bytes, &lastRun); err != nil {
+		return LastRunModel{}, fmt.Errorf("Failed to parse the last run, error: %s", err)
+	}
+	return lastRun, nil
+}
+
+// Step returns the step of the last run with the given index
+func (lastRun LastRunModel) Step(idx int) (LastRunStepModel, bool) {
+	for _, step := range lastRun.Steps {
+		if step.Idx == idx {
+			return step, true
+		}
+	}
+	return LastRunStepModel{}, false
+}
+
+// HasFailedSteps ...
+func (lastRun LastRunModel) HasFailedSteps() bool {
+	for _, step := range lastRun.Steps {
+		if step.isFailed() {
+			return true
+		}
+	}
+	return false
+}
+
+// RerunSteps returns the indexes of the steps to rerun: the failed steps and their dependencies.
+// The dependency of a step is the last step before it (and after the first failed step), which declares an output
+// referenced by the step's inputs, the dependencies of the dependencies are rerun as well.
+func (lastRun LastRunModel) RerunSteps() map[int]bool {
+	rerunSteps := map[int]bool{}
+	firstFailedIdx := -1
+	for _, step := range lastRun.Steps {
+		if step.isFailed() {
+			rerunSteps[step.Idx] = true
+			if firstFailedIdx < 0 || step.Idx < firstFailedIdx {
+				firstFailedIdx = step.Idx
+			}
+		}
+	}
+
+	for isChanged := true; isChanged; {
+		isChanged = false
+		for _, step := range lastRun.Steps {
+			if !rerunSteps[step.Idx] {
+				continue
+			}
+			for _, key := range step.InputEnvs {
+				if dependency, found := lastRun.lastOutputStep(key, firstFailedIdx, step.Idx); found && !rerunSteps[dependency.Idx] {
+					rerunSteps[dependency.Idx] = true
+					isChanged = true
+				}
+			}
+		}
+	}
+	return rerunSteps
+}
+
+// lastOutputStep returns the last step between the given indexes (exclusive), which declares the output
+func (lastRun LastRunModel) lastOutputStep(key string, afterIdx, beforeIdx int) (LastRunStepModel, bool) {
+	found := false
+	outputStep := LastRunStepModel{}
+	for _, step := range lastRun.Steps {
+		if step.Idx <= afterIdx || step.Idx >= beforeIdx || !step.isOutput(key) {
+			continue
+		}
+		if !found || step.Idx > outputStep.Idx {
+			outputStep = step
+			found = true
+		}
+	}
+	return outputStep, found
+}
+
+// RemoveSecretEnvironments returns the envs without the given secret envs (by key),
+// the secrets are not stored, they are provided by the rerun again.
+func RemoveSecretEnvironments(environments, secrets []envmanModels.EnvironmentItemModel) []envmanModels.EnvironmentItemModel {
+	secretKeys := map[string]bool{}
+	for _, secret := range secrets {
+		if key, _, err := secret.GetKeyValuePair(); err == nil {
+			secretKeys[key] = true
+		}
+	}
+
+	filtered := []envmanModels.EnvironmentItemModel{}
+	for _, env := range environments {
+		if key, _, err := env.GetKeyValuePair(); err == nil && secretKeys[key] {
+			continue
+		}
+		filtered = append(filtered, env)
+	}
+	return filtered
+}
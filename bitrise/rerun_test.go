@@ -0,0 +1,114 @@
+package bitrise
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/pathutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastRun(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__last_run__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	key := LastRunKey("/project", "primary")
+	require.NotEqual(t, key, LastRunKey("/project", "deploy"))
+
+	t.Log("no last run")
+	{
+		_, err := ReadLastRun(tmpDir, key)
+		require.Error(t, err)
+	}
+
+	t.Log("write and read")
+	{
+		lastRun := LastRunModel{
+			Summary: BuildSummaryModel{Workflow: "primary"},
+			Steps: []LastRunStepModel{
+				LastRunStepModel{Workflow: "primary", Idx: 0, ID: "script@1", StatusCode: models.StepRunStatusCodeSuccess},
+				LastRunStepModel{Workflow: "primary", Idx: 1, ID: "xcode-test@2", StatusCode: models.StepRunStatusCodeFailed},
+				LastRunStepModel{Workflow: "primary", Idx: 2, ID: "slack@3", StatusCode: models.StepRunStatusCodeFailedSkippable},
+			},
+			Environments: []envmanModels.EnvironmentItemModel{
+				envmanModels.EnvironmentItemModel{"PROJECT_PATH": "./ios"},
+			},
+		}
+		require.NoError(t, WriteLastRun(tmpDir, key, lastRun))
+
+		readLastRun, err := ReadLastRun(tmpDir, key)
+		require.NoError(t, err)
+		require.True(t, readLastRun.HasFailedSteps())
+		require.Equal(t, 1, len(readLastRun.Environments))
+
+		step, found := readLastRun.Step(1)
+		require.True(t, found)
+		require.Equal(t, "xcode-test@2", step.ID)
+		_, found = readLastRun.Step(3)
+		require.False(t, found)
+
+		require.Equal(t, map[int]bool{1: true}, readLastRun.RerunSteps())
+	}
+}
+
+func TestNewLastRunStep(t *testing.T) {
+	step := stepmanModels.StepModel{
+		Inputs: []envmanModels.EnvironmentItemModel{
+			envmanModels.EnvironmentItemModel{"ipa_path": "$BITRISE_IPA_PATH"},
+			envmanModels.EnvironmentItemModel{"message": "${BITRISE_APP_TITLE} built ($BITRISE_IPA_PATH)"},
+			envmanModels.EnvironmentItemModel{"channel": "#builds"},
+		},
+		Outputs: []envmanModels.EnvironmentItemModel{
+			envmanModels.EnvironmentItemModel{"BITRISE_PUBLIC_INSTALL_PAGE_URL": ""},
+		},
+	}
+
+	require.Equal(t, LastRunStepModel{
+		Workflow:   "deploy",
+		Idx:        2,
+		ID:         "deploy-to-bitrise-io@2",
+		StatusCode: models.StepRunStatusCodeFailed,
+		Outputs:    []string{"BITRISE_PUBLIC_INSTALL_PAGE_URL"},
+		InputEnvs:  []string{"BITRISE_IPA_PATH", "BITRISE_APP_TITLE"},
+	}, NewLastRunStep("deploy", 2, "deploy-to-bitrise-io@2", models.StepRunStatusCodeFailed, step))
+}
+
+func TestRerunSteps(t *testing.T) {
+	lastRun := LastRunModel{
+		Steps: []LastRunStepModel{
+			LastRunStepModel{Idx: 0, ID: "git-clone", StatusCode: models.StepRunStatusCodeSuccess, Outputs: []string{"GIT_COMMIT"}},
+			LastRunStepModel{Idx: 1, ID: "xcode-test", StatusCode: models.StepRunStatusCodeFailed, InputEnvs: []string{"GIT_COMMIT"}},
+			LastRunStepModel{Idx: 2, ID: "xcode-archive", StatusCode: models.StepRunStatusCodeSkipped, Outputs: []string{"IPA_PATH"}, InputEnvs: []string{"VERSION"}},
+			LastRunStepModel{Idx: 3, ID: "set-version", StatusCode: models.StepRunStatusCodeSkipped, Outputs: []string{"VERSION"}},
+			LastRunStepModel{Idx: 4, ID: "deploy", StatusCode: models.StepRunStatusCodeFailed, InputEnvs: []string{"IPA_PATH"}},
+			LastRunStepModel{Idx: 5, ID: "cache-push", StatusCode: models.StepRunStatusCodeSuccess, Outputs: []string{"IPA_PATH"}},
+		},
+	}
+
+	// git-clone: before the first failed step, its outputs are restored with the envs
+	// xcode-archive: declares the IPA_PATH input of deploy, set-version: runs after xcode-archive, not a dependency
+	// cache-push: runs after deploy, not a dependency
+	require.Equal(t, map[int]bool{1: true, 2: true, 4: true}, lastRun.RerunSteps())
+
+	require.Equal(t, map[int]bool{}, LastRunModel{}.RerunSteps())
+}
+
+func TestRemoveSecretEnvironments(t *testing.T) {
+	environments := []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"API_TOKEN": "secret"},
+		envmanModels.EnvironmentItemModel{"PROJECT_PATH": "./ios"},
+	}
+	secrets := []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"API_TOKEN": "secret"},
+	}
+
+	require.Equal(t, []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"PROJECT_PATH": "./ios"},
+	}, RemoveSecretEnvironments(environments, secrets))
+}
@@ -0,0 +1,233 @@
+package bitrise
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/go-utils/cmdex"
+)
+
+const (
+	defaultGovernorPollInterval = 5 * time.Second
+	defaultGovernorMaxPause     = 10 * time.Minute
+)
+
+// ResourceUsageModel ...
+type ResourceUsageModel struct {
+	// LoadPerCPU is the 1 minute load average divided by the number of CPUs
+	LoadPerCPU float64
+	// MemoryPercent is the used memory in percent of the total memory
+	MemoryPercent float64
+}
+
+// ResourceGovernorModel pauses the run (between steps) while the host is under resource pressure.
+// A zero threshold means the given resource is not checked.
+type ResourceGovernorModel struct {
+	MaxLoadPerCPU    float64
+	MaxMemoryPercent float64
+	PollInterval     time.Duration
+	MaxPause         time.Duration
+
+	readUsage func() (ResourceUsageModel, error)
+}
+
+// NewResourceGovernorFromEnv returns the governor configured by the
+// BITRISE_GOVERNOR_MAX_LOAD and BITRISE_GOVERNOR_MAX_MEMORY_PERCENT envs,
+// and false if the governor is not enabled.
+func NewResourceGovernorFromEnv() (ResourceGovernorModel, bool, error) {
+	governor := ResourceGovernorModel{
+		PollInterval: defaultGovernorPollInterval,
+		MaxPause:     defaultGovernorMaxPause,
+		readUsage:    ReadResourceUsage,
+	}
+
+	parseThreshold := func(envKey string) (float64, error) {
+		value := os.Getenv(envKey)
+		if value == "" {
+			return 0, nil
+		}
+		threshold, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s value (%s), error: %s", envKey, value, err)
+		}
+		if threshold < 0 {
+			return 0, fmt.Errorf("invalid %s value (%s), should not be negative", envKey, value)
+		}
+		return threshold, nil
+	}
+
+	maxLoad, err := parseThreshold(configs.GovernorMaxLoadEnvKey)
+	if err != nil {
+		return ResourceGovernorModel{}, false, err
+	}
+	maxMemory, err := parseThreshold(configs.GovernorMaxMemoryPercentEnvKey)
+	if err != nil {
+		return ResourceGovernorModel{}, false, err
+	}
+
+	governor.MaxLoadPerCPU = maxLoad
+	governor.MaxMemoryPercent = maxMemory
+
+	return governor, (maxLoad > 0 || maxMemory > 0), nil
+}
+
+func (governor ResourceGovernorModel) pressure(usage ResourceUsageModel) string {
+	reasons := []string{}
+	if governor.MaxLoadPerCPU > 0 && usage.LoadPerCPU > governor.MaxLoadPerCPU {
+		reasons = append(reasons, fmt.Sprintf("load per CPU: %.2f > %.2f", usage.LoadPerCPU, governor.MaxLoadPerCPU))
+	}
+	if governor.MaxMemoryPercent > 0 && usage.MemoryPercent > governor.MaxMemoryPercent {
+		reasons = append(reasons, fmt.Sprintf("memory usage: %.1f%% > %.1f%%", usage.MemoryPercent, governor.MaxMemoryPercent))
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// WaitForResources blocks while the host is under resource pressure, but at most for MaxPause.
+// Returns the time spent with waiting.
+func (governor ResourceGovernorModel) WaitForResources() time.Duration {
+	readUsage := governor.readUsage
+	if readUsage == nil {
+		readUsage = ReadResourceUsage
+	}
+
+	startTime := time.Now()
+	isPaused := false
+	for {
+		usage, err := readUsage()
+		if err != nil {
+			log.Warnf("Failed to read host resource usage, error: %s", err)
+			break
+		}
+
+		pressure := governor.pressure(usage)
+		if pressure == "" {
+			break
+		}
+
+		if !isPaused {
+			isPaused = true
+			log.Warnf("Host is under resource pressure (%s), pausing the build...", pressure)
+		}
+
+		if time.Since(startTime) >= governor.MaxPause {
+			log.Warnf("Host is still under resource pressure after %s, continuing the build", governor.MaxPause)
+			break
+		}
+
+		time.Sleep(governor.PollInterval)
+	}
+
+	waited := time.Since(startTime)
+	if isPaused {
+		log.Infoln(output.Green("Resuming the build"), fmt.Sprintf("(paused for %s)", waited))
+	}
+	return waited
+}
+
+// ReadResourceUsage ...
+func ReadResourceUsage() (ResourceUsageModel, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return readLinuxResourceUsage()
+	case "darwin":
+		return readOSXResourceUsage()
+	}
+	return ResourceUsageModel{}, fmt.Errorf("reading resource usage is not supported on %s", runtime.GOOS)
+}
+
+func readLinuxResourceUsage() (ResourceUsageModel, error) {
+	loadavgBytes, err := ioutil.ReadFile("/proc/loadavg")
+	if err != nil {
+		return ResourceUsageModel{}, err
+	}
+	load, err := parseLoadAverage(string(loadavgBytes))
+	if err != nil {
+		return ResourceUsageModel{}, err
+	}
+
+	meminfoBytes, err := ioutil.ReadFile("/proc/meminfo")
+	if err != nil {
+		return ResourceUsageModel{}, err
+	}
+	memoryPercent, err := parseLinuxMeminfo(string(meminfoBytes))
+	if err != nil {
+		return ResourceUsageModel{}, err
+	}
+
+	return ResourceUsageModel{
+		LoadPerCPU:    load / float64(runtime.NumCPU()),
+		MemoryPercent: memoryPercent,
+	}, nil
+}
+
+func readOSXResourceUsage() (ResourceUsageModel, error) {
+	out, err := cmdex.NewCommand("sysctl", "-n", "vm.loadavg").RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return ResourceUsageModel{}, fmt.Errorf("failed to read load average, error: %s", err)
+	}
+	load, err := parseLoadAverage(strings.Trim(out, "{} "))
+	if err != nil {
+		return ResourceUsageModel{}, err
+	}
+
+	// kern.memorystatus_level is the percentage of the free memory
+	out, err = cmdex.NewCommand("sysctl", "-n", "kern.memorystatus_level").RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return ResourceUsageModel{}, fmt.Errorf("failed to read memory status, error: %s", err)
+	}
+	freePercent, err := strconv.ParseFloat(out, 64)
+	if err != nil {
+		return ResourceUsageModel{}, fmt.Errorf("failed to parse memory status (%s), error: %s", out, err)
+	}
+
+	return ResourceUsageModel{
+		LoadPerCPU:    load / float64(runtime.NumCPU()),
+		MemoryPercent: 100 - freePercent,
+	}, nil
+}
+
+func parseLoadAverage(loadavg string) (float64, error) {
+	fields := strings.Fields(loadavg)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("failed to parse load average (%s)", loadavg)
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse load average (%s), error: %s", loadavg, err)
+	}
+	return load, nil
+}
+
+func parseLinuxMeminfo(meminfo string) (float64, error) {
+	values := map[string]float64{}
+	for _, line := range strings.Split(meminfo, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		values[strings.TrimSuffix(fields[0], ":")] = value
+	}
+
+	total, found := values["MemTotal"]
+	if !found || total == 0 {
+		return 0, fmt.Errorf("MemTotal not found in meminfo")
+	}
+	available, found := values["MemAvailable"]
+	if !found {
+		available = values["MemFree"] + values["Buffers"] + values["Cached"]
+	}
+
+	return (total - available) / total * 100, nil
+}
@@ -0,0 +1,109 @@
+package bitrise
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLinuxMeminfo(t *testing.T) {
+	t.Log("MemAvailable")
+	{
+		meminfo := `MemTotal:        1000 kB
+MemFree:          100 kB
+MemAvailable:     250 kB
+Buffers:           10 kB
+Cached:            20 kB`
+		percent, err := parseLinuxMeminfo(meminfo)
+		require.NoError(t, err)
+		require.Equal(t, 75.0, percent)
+	}
+
+	t.Log("without MemAvailable")
+	{
+		meminfo := `MemTotal:        1000 kB
+MemFree:          100 kB
+Buffers:           50 kB
+Cached:            50 kB`
+		percent, err := parseLinuxMeminfo(meminfo)
+		require.NoError(t, err)
+		require.Equal(t, 80.0, percent)
+	}
+
+	t.Log("invalid")
+	{
+		_, err := parseLinuxMeminfo("")
+		require.Error(t, err)
+	}
+}
+
+func TestParseLoadAverage(t *testing.T) {
+	load, err := parseLoadAverage("1.50 0.75 0.25 1/123 4567")
+	require.NoError(t, err)
+	require.Equal(t, 1.5, load)
+
+	_, err = parseLoadAverage("")
+	require.Error(t, err)
+}
+
+func TestWaitForResources(t *testing.T) {
+	t.Log("no pressure")
+	{
+		governor := ResourceGovernorModel{
+			MaxLoadPerCPU: 1.0,
+			PollInterval:  time.Millisecond,
+			MaxPause:      time.Second,
+			readUsage: func() (ResourceUsageModel, error) {
+				return ResourceUsageModel{LoadPerCPU: 0.5, MemoryPercent: 99}, nil
+			},
+		}
+		require.Equal(t, true, governor.WaitForResources() < time.Second)
+	}
+
+	t.Log("pressure goes away")
+	{
+		reads := 0
+		governor := ResourceGovernorModel{
+			MaxMemoryPercent: 80,
+			PollInterval:     time.Millisecond,
+			MaxPause:         time.Second,
+			readUsage: func() (ResourceUsageModel, error) {
+				reads++
+				if reads < 3 {
+					return ResourceUsageModel{MemoryPercent: 90}, nil
+				}
+				return ResourceUsageModel{MemoryPercent: 50}, nil
+			},
+		}
+		governor.WaitForResources()
+		require.Equal(t, 3, reads)
+	}
+
+	t.Log("max pause")
+	{
+		governor := ResourceGovernorModel{
+			MaxLoadPerCPU: 1.0,
+			PollInterval:  time.Millisecond,
+			MaxPause:      10 * time.Millisecond,
+			readUsage: func() (ResourceUsageModel, error) {
+				return ResourceUsageModel{LoadPerCPU: 2.0}, nil
+			},
+		}
+		require.Equal(t, true, governor.WaitForResources() >= 10*time.Millisecond)
+	}
+
+	t.Log("read error")
+	{
+		governor := ResourceGovernorModel{
+			MaxLoadPerCPU: 1.0,
+			PollInterval:  time.Millisecond,
+			MaxPause:      time.Second,
+			readUsage: func() (ResourceUsageModel, error) {
+				return ResourceUsageModel{}, errors.New("failed")
+			},
+		}
+		require.Equal(t, true, governor.WaitForResources() < time.Second)
+	}
+}
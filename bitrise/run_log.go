@@ -0,0 +1,347 @@
+package bitrise
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/output"
+)
+
+// Run log (see: --log-dir): the whole output of the run (the CLI's and the steps' output, printed to the console)
+// is written into a per-run directory (<log-dir>/<timestamp>/bitrise.log) as well, without the colors.
+// The log file is rotated once it reaches the max size (bitrise.log.1 is the previous part, bitrise.log.2 the one before),
+// and only the given number of the latest run directories are kept, so unattended agents keep a usable history.
+// The log file is not written anymore once the disk is full, the console output is not affected.
+
+const (
+	// RunLogFileName ...
+	RunLogFileName = "bitrise.log"
+	// DefaultRunLogMaxSizeMB ...
+	DefaultRunLogMaxSizeMB = 10
+	// DefaultRunLogRetention ...
+	DefaultRunLogRetention = 10
+
+	runLogDirTimeFormat = "20060102-150405"
+)
+
+var runLogDirNameRegexp = regexp.MustCompile(`^\d{8}-\d{6}(-\d+)?$`)
+
+// rotatingFileWriter writes into the file, and rotates it once it would grow over maxSize
+type rotatingFileWriter struct {
+	mutex   sync.Mutex
+	pth     string
+	maxSize int64
+	file    *os.File
+	size    int64
+}
+
+func newRotatingFileWriter(pth string, maxSize int64) (*rotatingFileWriter, error) {
+	file, err := os.OpenFile(pth, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	size := int64(0)
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
+
+	return &rotatingFileWriter{pth: pth, maxSize: maxSize, file: file, size: size}, nil
+}
+
+func (writer *rotatingFileWriter) rotatedPath(idx int) string {
+	return fmt.Sprintf("%s.%d", writer.pth, idx)
+}
+
+// rotate moves the current file to <pth>.1 (and the earlier parts one index up), then starts a new file
+func (writer *rotatingFileWriter) rotate() error {
+	if err := writer.file.Close(); err != nil {
+		return err
+	}
+
+	lastIdx := 0
+	for {
+		if _, err := os.Stat(writer.rotatedPath(lastIdx + 1)); err != nil {
+			break
+		}
+		lastIdx++
+	}
+	for idx := lastIdx; idx > 0; idx-- {
+		if err := os.Rename(writer.rotatedPath(idx), writer.rotatedPath(idx+1)); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(writer.pth, writer.rotatedPath(1)); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(writer.pth, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	writer.file = file
+	writer.size = 0
+	return nil
+}
+
+func (writer *rotatingFileWriter) Write(p []byte) (int, error) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	if writer.maxSize > 0 && writer.size > 0 && writer.size+int64(len(p)) > writer.maxSize {
+		if err := writer.rotate(); err != nil {
+			return 0, fmt.Errorf("Failed to rotate log file (%s), error: %s", writer.pth, err)
+		}
+	}
+
+	n, err := writer.file.Write(p)
+	writer.size += int64(n)
+	return n, err
+}
+
+func (writer *rotatingFileWriter) Close() error {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	return writer.file.Close()
+}
+
+// plainLineWriter writes the complete lines, without the colors (ANSI escape sequences), into the writer.
+// It never fails, so the console output is not blocked by a failing log file: the first error is kept,
+// and returned by Flush.
+type plainLineWriter struct {
+	writer io.Writer
+	buffer bytes.Buffer
+	err    error
+}
+
+func (writer *plainLineWriter) Write(p []byte) (int, error) {
+	if writer.err != nil {
+		return len(p), nil
+	}
+	writer.buffer.Write(p)
+
+	content := writer.buffer.Bytes()
+	idx := bytes.LastIndexByte(content, '\n')
+	if idx < 0 {
+		return len(p), nil
+	}
+
+	lines := output.StripANSI(string(content[:idx+1]))
+	writer.buffer.Next(idx + 1)
+	if _, err := io.WriteString(writer.writer, lines); err != nil {
+		writer.err = err
+	}
+	return len(p), nil
+}
+
+// Flush writes the unfinished line, and returns the first error of the writer
+func (writer *plainLineWriter) Flush() error {
+	if writer.err != nil || writer.buffer.Len() == 0 {
+		return writer.err
+	}
+
+	line := output.StripANSI(writer.buffer.String())
+	writer.buffer.Reset()
+	_, err := io.WriteString(writer.writer, line+"\n")
+	return err
+}
+
+// newRunLogDir creates the directory of the run, named after the start time of the run
+func newRunLogDir(logDir string, startTime time.Time) (string, error) {
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return "", fmt.Errorf("Failed to create log dir (%s), error: %s", logDir, err)
+	}
+
+	name := startTime.Format(runLogDirTimeFormat)
+	for idx := 1; ; idx++ {
+		pth := filepath.Join(logDir, name)
+		if err := os.Mkdir(pth, 0755); err == nil {
+			return pth, nil
+		} else if !os.IsExist(err) {
+			return "", fmt.Errorf("Failed to create run log dir (%s), error: %s", pth, err)
+		}
+		name = startTime.Format(runLogDirTimeFormat) + "-" + strconv.Itoa(idx)
+	}
+}
+
+type runLogDirNames []string
+
+func (names runLogDirNames) Len() int {
+	return len(names)
+}
+
+func (names runLogDirNames) Swap(i, j int) {
+	names[i], names[j] = names[j], names[i]
+}
+
+// Less orders the run log dirs by start time, then by the index of the runs started in the same second
+func (names runLogDirNames) Less(i, j int) bool {
+	timeI, idxI := splitRunLogDirName(names[i])
+	timeJ, idxJ := splitRunLogDirName(names[j])
+	if timeI != timeJ {
+		return timeI < timeJ
+	}
+	return idxI < idxJ
+}
+
+func splitRunLogDirName(name string) (string, int) {
+	timePart := name[:len(runLogDirTimeFormat)]
+	idx := 0
+	if len(name) > len(runLogDirTimeFormat) {
+		idx, _ = strconv.Atoi(name[len(runLogDirTimeFormat)+1:])
+	}
+	return timePart, idx
+}
+
+// CleanupRunLogDirs removes the oldest run log directories of the log dir, keeping the latest retention ones
+func CleanupRunLogDirs(logDir string, retention int) error {
+	if retention <= 0 {
+		return nil
+	}
+
+	infos, err := ioutil.ReadDir(logDir)
+	if err != nil {
+		return fmt.Errorf("Failed to list log dir (%s), error: %s", logDir, err)
+	}
+
+	names := []string{}
+	for _, info := range infos {
+		if info.IsDir() && runLogDirNameRegexp.MatchString(info.Name()) {
+			names = append(names, info.Name())
+		}
+	}
+	if len(names) <= retention {
+		return nil
+	}
+
+	sort.Sort(runLogDirNames(names))
+	for _, name := range names[:len(names)-retention] {
+		if err := os.RemoveAll(filepath.Join(logDir, name)); err != nil {
+			return fmt.Errorf("Failed to remove run log dir (%s), error: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// RunLogModel tees the output of the process (os.Stdout and os.Stderr) into the run's log file
+type RunLogModel struct {
+	// Dir is the directory of the run's log files
+	Dir string
+
+	file           *rotatingFileWriter
+	originalStdout *os.File
+	originalStderr *os.File
+	pipeWriters    []*os.File
+	copyWaitGroup  sync.WaitGroup
+}
+
+// StartRunLog creates the run's directory in the log dir (removing the oldest ones over the retention count),
+// and starts writing the output of the process into its log file.
+// The log file is rotated over maxSize bytes (0 disables the rotation).
+func StartRunLog(logDir string, maxSize int64, retention int, startTime time.Time) (*RunLogModel, error) {
+	dir, err := newRunLogDir(logDir, startTime)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := CleanupRunLogDirs(logDir, retention); err != nil {
+		log.Warnf("Failed to remove old run logs, error: %s", err)
+	}
+
+	file, err := newRotatingFileWriter(filepath.Join(dir, RunLogFileName), maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create run log file, error: %s", err)
+	}
+
+	runLog := &RunLogModel{
+		Dir:            dir,
+		file:           file,
+		originalStdout: os.Stdout,
+		originalStderr: os.Stderr,
+	}
+
+	stdout, err := runLog.tee(os.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := runLog.tee(os.Stderr)
+	if err != nil {
+		if closeErr := stdout.Close(); closeErr != nil {
+			log.Debugf("Failed to close stdout pipe, error: %s", closeErr)
+		}
+		return nil, err
+	}
+
+	os.Stdout = stdout
+	os.Stderr = stderr
+	log.SetOutput(os.Stderr)
+
+	return runLog, nil
+}
+
+// tee returns the writer end of a pipe, which is copied into the console (the original file) and the log file
+func (runLog *RunLogModel) tee(console *os.File) (*os.File, error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create pipe, error: %s", err)
+	}
+	runLog.pipeWriters = append(runLog.pipeWriters, writer)
+
+	logWriter := &plainLineWriter{writer: NewDiskFullGuardedWriter(runLog.file)}
+	runLog.copyWaitGroup.Add(1)
+	go func() {
+		defer runLog.copyWaitGroup.Done()
+
+		if _, err := io.Copy(io.MultiWriter(console, logWriter), reader); err != nil {
+			fmt.Fprintf(console, "Failed to write run log, error: %s\n", err)
+		}
+		if err := logWriter.Flush(); err != nil {
+			fmt.Fprintf(console, "Failed to write run log, error: %s\n", err)
+		}
+		if err := reader.Close(); err != nil {
+			fmt.Fprintf(console, "Failed to close run log pipe, error: %s\n", err)
+		}
+	}()
+
+	return writer, nil
+}
+
+// Write writes the output into the log file only, without printing it to the console
+// (e.g. the discarded output of the quiet mode)
+func (runLog *RunLogModel) Write(p []byte) (int, error) {
+	writer := &plainLineWriter{writer: NewDiskFullGuardedWriter(runLog.file)}
+	if _, err := writer.Write(p); err != nil {
+		return 0, err
+	}
+	if err := writer.Flush(); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close restores the console output, and finishes writing the log file
+func (runLog *RunLogModel) Close() error {
+	os.Stdout = runLog.originalStdout
+	os.Stderr = runLog.originalStderr
+	log.SetOutput(os.Stderr)
+
+	for _, writer := range runLog.pipeWriters {
+		if err := writer.Close(); err != nil {
+			return fmt.Errorf("Failed to close run log pipe, error: %s", err)
+		}
+	}
+	runLog.copyWaitGroup.Wait()
+
+	return runLog.file.Close()
+}
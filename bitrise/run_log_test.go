@@ -0,0 +1,116 @@
+package bitrise
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFileWriter(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__run_log__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	pth := filepath.Join(tmpDir, RunLogFileName)
+	writer, err := newRotatingFileWriter(pth, 10)
+	require.NoError(t, err)
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		_, err := writer.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	for pth, content := range map[string]string{
+		pth:        "third\n",
+		pth + ".1": "second\n",
+		pth + ".2": "first\n",
+	} {
+		bytes, err := ioutil.ReadFile(pth)
+		require.NoError(t, err)
+		require.Equal(t, content, string(bytes))
+	}
+}
+
+func TestPlainLineWriter(t *testing.T) {
+	buffer := bytes.Buffer{}
+	writer := &plainLineWriter{writer: &buffer}
+
+	_, err := writer.Write([]byte("\x1b[32;1mgreen\x1b[0m li"))
+	require.NoError(t, err)
+	require.Equal(t, "", buffer.String())
+
+	_, err = writer.Write([]byte("ne\nunfinished"))
+	require.NoError(t, err)
+	require.Equal(t, "green line\n", buffer.String())
+
+	require.NoError(t, writer.Flush())
+	require.Equal(t, "green line\nunfinished\n", buffer.String())
+}
+
+func TestCleanupRunLogDirs(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__run_log__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	for _, name := range []string{"20170101-120000", "20170101-120000-1", "20170101-120000-10", "20170102-080000", "not-a-run"} {
+		require.NoError(t, os.Mkdir(filepath.Join(tmpDir, name), 0755))
+	}
+
+	require.NoError(t, CleanupRunLogDirs(tmpDir, 2))
+
+	infos, err := ioutil.ReadDir(tmpDir)
+	require.NoError(t, err)
+	names := []string{}
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	sort.Strings(names)
+	require.Equal(t, []string{"20170101-120000-10", "20170102-080000", "not-a-run"}, names)
+}
+
+func TestStartRunLog(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__run_log__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	startTime := time.Date(2017, 1, 2, 8, 0, 0, 0, time.UTC)
+	runLog, err := StartRunLog(tmpDir, 0, 1, startTime)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(tmpDir, "20170102-080000"), runLog.Dir)
+
+	fmt.Println("to stdout")
+	fmt.Fprint(os.Stderr, "to stderr")
+	require.NoError(t, runLog.Close())
+
+	content, err := ioutil.ReadFile(filepath.Join(runLog.Dir, RunLogFileName))
+	require.NoError(t, err)
+	require.Contains(t, string(content), "to stdout\n")
+	require.Contains(t, string(content), "to stderr\n")
+
+	t.Log("runs started in the same second, over the retention count")
+	{
+		runLog, err := StartRunLog(tmpDir, 0, 1, startTime)
+		require.NoError(t, err)
+		require.NoError(t, runLog.Close())
+		require.Equal(t, filepath.Join(tmpDir, "20170102-080000-1"), runLog.Dir)
+
+		infos, err := ioutil.ReadDir(tmpDir)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(infos))
+	}
+}
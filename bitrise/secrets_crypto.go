@@ -0,0 +1,142 @@
+package bitrise
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// Encrypted secrets file.
+// The secrets file (.bitrise.secrets.yml) can be stored encrypted with the user's local key (see: configs.GetBitriseSecretsKeyPath),
+// it's decrypted in memory, when the secrets are read.
+// Format: the encryptedSecretsHeader line, followed by the base64 encoded AES-256-GCM nonce and ciphertext.
+
+const (
+	encryptedSecretsHeader = "# bitrise encrypted secrets v1"
+	secretsKeySize         = 32
+)
+
+// IsEncryptedSecrets ...
+func IsEncryptedSecrets(content []byte) bool {
+	return bytes.HasPrefix(content, []byte(encryptedSecretsHeader+"\n"))
+}
+
+// ReadSecretsKey reads the base64 encoded secrets key from the given path
+func ReadSecretsKey(pth string) ([]byte, error) {
+	content, err := fileutil.ReadStringFromFile(pth)
+	if err != nil {
+		return []byte{}, fmt.Errorf("Failed to read secrets key (%s), error: %s", pth, err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(content))
+	if err != nil {
+		return []byte{}, fmt.Errorf("Failed to decode secrets key (%s), error: %s", pth, err)
+	}
+	if len(key) != secretsKeySize {
+		return []byte{}, fmt.Errorf("invalid secrets key (%s): should be %d bytes long", pth, secretsKeySize)
+	}
+	return key, nil
+}
+
+// ReadOrCreateSecretsKey reads the secrets key from the given path, or generates a new one, if it does not exist yet
+func ReadOrCreateSecretsKey(pth string) ([]byte, error) {
+	if exist, err := pathutil.IsPathExists(pth); err != nil {
+		return []byte{}, err
+	} else if exist {
+		return ReadSecretsKey(pth)
+	}
+
+	key := make([]byte, secretsKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return []byte{}, fmt.Errorf("Failed to generate secrets key, error: %s", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(pth), 0700); err != nil {
+		return []byte{}, fmt.Errorf("Failed to create secrets key dir, error: %s", err)
+	}
+	if err := fileutil.WriteStringToFileWithPermission(pth, base64.StdEncoding.EncodeToString(key)+"\n", 0600); err != nil {
+		return []byte{}, fmt.Errorf("Failed to write secrets key (%s), error: %s", pth, err)
+	}
+	return key, nil
+}
+
+// EncryptSecrets encrypts the content of a secrets file with the given key
+func EncryptSecrets(content, key []byte) ([]byte, error) {
+	gcm, err := newSecretsCipher(key)
+	if err != nil {
+		return []byte{}, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return []byte{}, fmt.Errorf("Failed to generate nonce, error: %s", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, content, nil)
+	return []byte(encryptedSecretsHeader + "\n" + base64.StdEncoding.EncodeToString(sealed) + "\n"), nil
+}
+
+// DecryptSecrets decrypts the content of an encrypted secrets file with the given key
+func DecryptSecrets(content, key []byte) ([]byte, error) {
+	if !IsEncryptedSecrets(content) {
+		return []byte{}, errors.New("not an encrypted secrets file")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content[len(encryptedSecretsHeader)+1:])))
+	if err != nil {
+		return []byte{}, fmt.Errorf("Failed to decode encrypted secrets, error: %s", err)
+	}
+
+	gcm, err := newSecretsCipher(key)
+	if err != nil {
+		return []byte{}, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return []byte{}, errors.New("encrypted secrets are too short")
+	}
+
+	decrypted, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return []byte{}, fmt.Errorf("Failed to decrypt secrets (invalid secrets key?), error: %s", err)
+	}
+	return decrypted, nil
+}
+
+// decryptSecretsIfEncrypted returns the decrypted content, if it's encrypted (with the local secrets key, with age or with SOPS),
+// otherwise the content as it is
+func decryptSecretsIfEncrypted(content []byte) ([]byte, error) {
+	if IsAgeEncryptedSecrets(content) {
+		return DecryptAgeSecrets(content, configs.GetAgeKeyFilePath())
+	}
+	if IsSOPSEncryptedSecrets(content) {
+		return DecryptSOPSSecrets(content, configs.GetAgeKeyFilePath())
+	}
+	if !IsEncryptedSecrets(content) {
+		return content, nil
+	}
+
+	key, err := ReadSecretsKey(configs.GetBitriseSecretsKeyPath())
+	if err != nil {
+		return []byte{}, err
+	}
+	return DecryptSecrets(content, key)
+}
+
+func newSecretsCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create cipher, error: %s", err)
+	}
+	return cipher.NewGCM(block)
+}
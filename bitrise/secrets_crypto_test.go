@@ -0,0 +1,97 @@
+package bitrise
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/configs"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecryptSecrets(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("secrets_crypto")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, os.RemoveAll(tmpDir)) }()
+
+	keyPth := filepath.Join(tmpDir, "keys", "secrets.key")
+	key, err := ReadOrCreateSecretsKey(keyPth)
+	require.NoError(t, err)
+	require.Equal(t, secretsKeySize, len(key))
+
+	t.Log("the created key is reused")
+	{
+		sameKey, err := ReadOrCreateSecretsKey(keyPth)
+		require.NoError(t, err)
+		require.Equal(t, key, sameKey)
+
+		info, err := os.Stat(keyPth)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	content := []byte("envs:\n- API_TOKEN: my-secret-token\n")
+
+	encrypted, err := EncryptSecrets(content, key)
+	require.NoError(t, err)
+	require.Equal(t, true, IsEncryptedSecrets(encrypted))
+	require.NotContains(t, string(encrypted), "my-secret-token")
+	require.Equal(t, false, IsEncryptedSecrets(content))
+
+	t.Log("decrypt")
+	{
+		decrypted, err := DecryptSecrets(encrypted, key)
+		require.NoError(t, err)
+		require.Equal(t, content, decrypted)
+	}
+
+	t.Log("decrypt with another key")
+	{
+		otherKey, err := ReadOrCreateSecretsKey(filepath.Join(tmpDir, "other.key"))
+		require.NoError(t, err)
+
+		_, err = DecryptSecrets(encrypted, otherKey)
+		require.Error(t, err)
+	}
+
+	t.Log("encrypted secrets file is decrypted with the local key")
+	{
+		require.NoError(t, os.Setenv(configs.SecretsKeyPathEnvKey, keyPth))
+		defer func() { require.NoError(t, os.Unsetenv(configs.SecretsKeyPathEnvKey)) }()
+
+		secretsPth := filepath.Join(tmpDir, ".bitrise.secrets.yml")
+		require.NoError(t, fileutil.WriteBytesToFile(secretsPth, encrypted))
+
+		envs, err := CollectEnvironmentsFromFile(secretsPth)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(envs))
+
+		key, value, err := envs[0].GetKeyValuePair()
+		require.NoError(t, err)
+		require.Equal(t, "API_TOKEN", key)
+		require.Equal(t, "my-secret-token", value)
+	}
+}
+
+func TestSecretsInventoryContent(t *testing.T) {
+	content, err := SecretsInventoryContent([]envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"PASSWORD": "pa$$word"},
+	})
+	require.NoError(t, err)
+
+	inventory, err := InventoryModelFromYAMLBytes(content)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(inventory.Envs))
+
+	key, value, err := inventory.Envs[0].GetKeyValuePair()
+	require.NoError(t, err)
+	require.Equal(t, "PASSWORD", key)
+	require.Equal(t, "pa$$word", value)
+
+	options, err := inventory.Envs[0].GetOptions()
+	require.NoError(t, err)
+	require.Equal(t, false, *options.IsExpand)
+}
@@ -0,0 +1,76 @@
+package bitrise
+
+import (
+	"fmt"
+	"strings"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+)
+
+// Secrets providers.
+// A secret's value in the secrets file can be a reference (<scheme>://<reference>) to a secret, stored in an external secrets backend,
+// the references are resolved by the provider of the scheme when the secrets are read, so the values are never written to disk.
+
+// SecretsProvider fetches the referenced secrets from a secrets backend
+type SecretsProvider interface {
+	// Scheme is the scheme of the references the provider resolves (vault for vault://...)
+	Scheme() string
+	// Fetch returns the value of the secret, referenced by the part of the reference after <scheme>://
+	Fetch(reference string) (string, error)
+}
+
+// SecretsProviders returns the supported secrets providers
+func SecretsProviders() []SecretsProvider {
+	aws := newAWSCLI()
+	return []SecretsProvider{
+		NewVaultSecretsProvider(),
+		&AWSSecretsManagerProvider{cli: aws},
+		&AWSParameterStoreProvider{cli: aws},
+	}
+}
+
+// secretsProviderForValue returns the provider of the secret reference and the reference without the scheme,
+// or false if the value is not a secret reference.
+func secretsProviderForValue(providers []SecretsProvider, value string) (SecretsProvider, string, bool) {
+	for _, provider := range providers {
+		prefix := provider.Scheme() + "://"
+		if strings.HasPrefix(value, prefix) {
+			return provider, strings.TrimPrefix(value, prefix), true
+		}
+	}
+	return nil, "", false
+}
+
+// ResolveSecretReferences replaces the secret references in the values of the secrets with the fetched secrets,
+// the options of the secrets are kept.
+func ResolveSecretReferences(secrets []envmanModels.EnvironmentItemModel, providers []SecretsProvider) ([]envmanModels.EnvironmentItemModel, error) {
+	resolved := []envmanModels.EnvironmentItemModel{}
+	for _, secret := range secrets {
+		key, value, err := secret.GetKeyValuePair()
+		if err != nil {
+			return []envmanModels.EnvironmentItemModel{}, err
+		}
+
+		provider, reference, isReference := secretsProviderForValue(providers, value)
+		if !isReference {
+			resolved = append(resolved, secret)
+			continue
+		}
+
+		options, err := secret.GetOptions()
+		if err != nil {
+			return []envmanModels.EnvironmentItemModel{}, err
+		}
+
+		fetched, err := provider.Fetch(reference)
+		if err != nil {
+			return []envmanModels.EnvironmentItemModel{}, fmt.Errorf("Failed to fetch secret (%s) from %s, error: %s", key, provider.Scheme(), err)
+		}
+
+		resolved = append(resolved, envmanModels.EnvironmentItemModel{
+			key:                     fetched,
+			envmanModels.OptionsKey: options,
+		})
+	}
+	return resolved, nil
+}
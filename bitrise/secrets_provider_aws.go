@@ -0,0 +1,167 @@
+package bitrise
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/errorutil"
+)
+
+// AWS secrets providers: Secrets Manager (aws-sm://<name>[#<json key>]) and SSM Parameter Store (ssm://<path>).
+// The secrets are fetched with the AWS CLI (aws), so the AWS tools resolve the region and the ambient credentials
+// of the host, with the default credential chain: the AWS_* envs, the profiles of ~/.aws/config and ~/.aws/credentials
+// (incl. SSO, credential_process and assume role profiles), web identity tokens, and the ECS task and EC2 instance roles.
+// The AWS CLI signs the requests as well, the CLI doesn't re-implement the AWS protocols.
+
+// awsCLI runs the AWS CLI commands of the AWS secrets providers and of the S3 artifact uploader
+type awsCLI struct {
+	// run runs the aws command with the args and the stdin (if not nil), and returns its output
+	run func(stdin io.Reader, args ...string) (string, error)
+}
+
+func newAWSCLI() *awsCLI {
+	return &awsCLI{run: runAWSCommand}
+}
+
+func runAWSCommand(stdin io.Reader, args ...string) (string, error) {
+	if _, err := exec.LookPath("aws"); err != nil {
+		return "", errors.New("the AWS CLI (aws) is not installed, it is required to access AWS")
+	}
+
+	var outBuffer bytes.Buffer
+	var errBuffer bytes.Buffer
+
+	command := exec.Command("aws", args...)
+	command.Stdin = stdin
+	command.Stdout = &outBuffer
+	command.Stderr = &errBuffer
+	if err := command.Run(); err != nil {
+		if errorutil.IsExitStatusError(err) && errBuffer.Len() > 0 {
+			return "", fmt.Errorf("aws %s failed, error: %s", awsCommandName(args), strings.TrimSpace(errBuffer.String()))
+		}
+		return "", fmt.Errorf("aws %s failed, error: %s", awsCommandName(args), err)
+	}
+	return outBuffer.String(), nil
+}
+
+// awsCommandName returns the service and the command of the AWS CLI args (e.g. ssm get-parameter)
+func awsCommandName(args []string) string {
+	if len(args) > 2 {
+		args = args[:2]
+	}
+	return strings.Join(args, " ")
+}
+
+// awsRegionFromARN returns the region of the ARN (arn:aws:<service>:<region>:...), or an empty string
+func awsRegionFromARN(reference string) string {
+	split := strings.Split(reference, ":")
+	if len(split) < 6 || split[0] != "arn" {
+		return ""
+	}
+	return split[3]
+}
+
+// callJSON runs the AWS CLI command with JSON output, and decodes the output into the response model.
+// The region of the ARN (if the reference is an ARN) overrides the configured region.
+func (cli *awsCLI) callJSON(reference string, responseModel interface{}, args ...string) error {
+	args = append(args, "--output", "json")
+	if region := awsRegionFromARN(reference); region != "" {
+		args = append(args, "--region", region)
+	}
+
+	out, err := cli.run(nil, args...)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal([]byte(out), responseModel); err != nil {
+		return fmt.Errorf("failed to parse the aws %s response, error: %s", awsCommandName(args), err)
+	}
+	return nil
+}
+
+// AWSSecretsManagerProvider fetches the secrets from AWS Secrets Manager.
+// Reference format: aws-sm://<secret name or ARN>, or aws-sm://<secret name or ARN>#<key> for a key of a JSON secret.
+type AWSSecretsManagerProvider struct {
+	cli *awsCLI
+}
+
+// Scheme ...
+func (provider *AWSSecretsManagerProvider) Scheme() string {
+	return "aws-sm"
+}
+
+// Fetch ...
+func (provider *AWSSecretsManagerProvider) Fetch(reference string) (string, error) {
+	secretID, key := reference, ""
+	if idx := strings.LastIndex(reference, "#"); idx >= 0 {
+		secretID, key = reference[:idx], reference[idx+1:]
+	}
+	if secretID == "" {
+		return "", fmt.Errorf("invalid aws-sm reference (aws-sm://%s), should be: aws-sm://<name>[#<key>]", reference)
+	}
+
+	var response struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := provider.cli.callJSON(secretID, &response, "secretsmanager", "get-secret-value", "--secret-id", secretID); err != nil {
+		return "", err
+	}
+	if response.SecretString == nil {
+		return "", fmt.Errorf("secret (%s) has no string value", secretID)
+	}
+	if key == "" {
+		return *response.SecretString, nil
+	}
+
+	values := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(*response.SecretString), &values); err != nil {
+		return "", fmt.Errorf("secret (%s) is not a JSON object, error: %s", secretID, err)
+	}
+	value, found := values[key]
+	if !found {
+		return "", fmt.Errorf("key (%s) not found in secret (%s)", key, secretID)
+	}
+	if str, isString := value.(string); isString {
+		return str, nil
+	}
+	return fmt.Sprintf("%v", value), nil
+}
+
+// AWSParameterStoreProvider fetches the (decrypted) parameters from AWS Systems Manager Parameter Store.
+// Reference format: ssm://<parameter path or ARN>, the leading / of the path can be omitted (ssm://my-app/api-token).
+type AWSParameterStoreProvider struct {
+	cli *awsCLI
+}
+
+// Scheme ...
+func (provider *AWSParameterStoreProvider) Scheme() string {
+	return "ssm"
+}
+
+// Fetch ...
+func (provider *AWSParameterStoreProvider) Fetch(reference string) (string, error) {
+	if reference == "" {
+		return "", errors.New("invalid ssm reference (ssm://), should be: ssm://<path>")
+	}
+
+	name := reference
+	if awsRegionFromARN(reference) == "" && strings.Contains(name, "/") && !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+
+	var response struct {
+		Parameter struct {
+			Value string `json:"Value"`
+		} `json:"Parameter"`
+	}
+	if err := provider.cli.callJSON(reference, &response, "ssm", "get-parameter", "--name", name, "--with-decryption"); err != nil {
+		return "", err
+	}
+	return response.Parameter.Value, nil
+}
@@ -0,0 +1,79 @@
+package bitrise
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAWSSecretsProviders(t *testing.T) {
+	commands := []string{}
+	cli := &awsCLI{
+		run: func(stdin io.Reader, args ...string) (string, error) {
+			command := strings.Join(args, " ")
+			commands = append(commands, command)
+
+			switch command {
+			case "secretsmanager get-secret-value --secret-id my-app/api-token --output json":
+				return `{"Name": "my-app/api-token", "SecretString": "sm-token"}`, nil
+			case "secretsmanager get-secret-value --secret-id my-app/config --output json":
+				return `{"Name": "my-app/config", "SecretString": "{\"user\": \"ci\", \"password\": \"sm-password\"}"}`, nil
+			case "secretsmanager get-secret-value --secret-id arn:aws:secretsmanager:us-east-2:123456789012:secret:my-app --output json --region us-east-2":
+				return `{"Name": "my-app", "SecretString": "arn-token"}`, nil
+			case "ssm get-parameter --name /my-app/api-token --with-decryption --output json":
+				return `{"Parameter": {"Name": "/my-app/api-token", "Type": "SecureString", "Value": "ssm-token"}}`, nil
+			case "ssm get-parameter --name /my-app/invalid --with-decryption --output json":
+				return `invalid`, nil
+			}
+			return "", errors.New("aws " + awsCommandName(args) + " failed, error: ResourceNotFoundException")
+		},
+	}
+
+	secretsManager := &AWSSecretsManagerProvider{cli: cli}
+	parameterStore := &AWSParameterStoreProvider{cli: cli}
+
+	t.Log("Secrets Manager")
+	{
+		value, err := secretsManager.Fetch("my-app/api-token")
+		require.NoError(t, err)
+		require.Equal(t, "sm-token", value)
+
+		value, err = secretsManager.Fetch("my-app/config#password")
+		require.NoError(t, err)
+		require.Equal(t, "sm-password", value)
+
+		value, err = secretsManager.Fetch("arn:aws:secretsmanager:us-east-2:123456789012:secret:my-app")
+		require.NoError(t, err)
+		require.Equal(t, "arn-token", value)
+
+		_, err = secretsManager.Fetch("my-app/config#missing")
+		require.EqualError(t, err, "key (missing) not found in secret (my-app/config)")
+
+		_, err = secretsManager.Fetch("my-app/missing")
+		require.EqualError(t, err, "aws secretsmanager get-secret-value failed, error: ResourceNotFoundException")
+	}
+
+	t.Log("Parameter Store")
+	{
+		value, err := parameterStore.Fetch("my-app/api-token")
+		require.NoError(t, err)
+		require.Equal(t, "ssm-token", value)
+
+		value, err = parameterStore.Fetch("/my-app/api-token")
+		require.NoError(t, err)
+		require.Equal(t, "ssm-token", value)
+
+		_, err = parameterStore.Fetch("my-app/invalid")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to parse the aws ssm get-parameter response")
+	}
+
+	t.Log("region of the ARN")
+	{
+		require.Equal(t, "us-east-2", awsRegionFromARN("arn:aws:secretsmanager:us-east-2:123456789012:secret:my-app"))
+		require.Equal(t, "", awsRegionFromARN("my-app/api-token"))
+	}
+}
@@ -0,0 +1,120 @@
+package bitrise
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func testVaultServer(t *testing.T, requestCount *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requestCount++
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/secret/data/my-app":
+			fmt.Fprint(w, `{"data": {"data": {"api_token": "kv2-token", "port": 8080}, "metadata": {"version": 3}}}`)
+		case "/v1/kv/my-app":
+			fmt.Fprint(w, `{"data": {"api_token": "kv1-token"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestVaultSecretsProvider(t *testing.T) {
+	requestCount := 0
+	server := testVaultServer(t, &requestCount)
+	defer server.Close()
+
+	provider := NewVaultSecretsProvider()
+	provider.address = server.URL
+	provider.token = "test-token"
+
+	t.Log("KV version 2, the secrets of a path are fetched once")
+	{
+		value, err := provider.Fetch("secret/data/my-app#api_token")
+		require.NoError(t, err)
+		require.Equal(t, "kv2-token", value)
+
+		value, err = provider.Fetch("/secret/data/my-app#port")
+		require.NoError(t, err)
+		require.Equal(t, "8080", value)
+		require.Equal(t, 1, requestCount)
+	}
+
+	t.Log("KV version 1")
+	{
+		value, err := provider.Fetch("kv/my-app#api_token")
+		require.NoError(t, err)
+		require.Equal(t, "kv1-token", value)
+	}
+
+	t.Log("errors")
+	{
+		_, err := provider.Fetch("secret/data/my-app#missing")
+		require.EqualError(t, err, "key (missing) not found at (secret/data/my-app)")
+
+		_, err = provider.Fetch("secret/data/other#api_token")
+		require.EqualError(t, err, "failed to read (secret/data/other), status code: 404")
+
+		_, err = provider.Fetch("secret/data/my-app")
+		require.EqualError(t, err, "invalid vault reference (vault://secret/data/my-app), should be: vault://<path>#<key>")
+
+		provider.token = ""
+		_, err = provider.Fetch("secret/data/my-app#api_token")
+		require.EqualError(t, err, "VAULT_TOKEN not set")
+	}
+}
+
+func TestResolveSecretReferences(t *testing.T) {
+	requestCount := 0
+	server := testVaultServer(t, &requestCount)
+	defer server.Close()
+
+	provider := NewVaultSecretsProvider()
+	provider.address = server.URL
+	provider.token = "test-token"
+
+	isExpand := false
+	secrets := []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"PLAIN": "plain-value"},
+		envmanModels.EnvironmentItemModel{
+			"API_TOKEN":             "vault://secret/data/my-app#api_token",
+			envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{IsExpand: &isExpand},
+		},
+	}
+
+	t.Log("references are resolved, the options are kept")
+	{
+		resolved, err := ResolveSecretReferences(secrets, []SecretsProvider{provider})
+		require.NoError(t, err)
+		require.Equal(t, 2, len(resolved))
+		require.Equal(t, secrets[0], resolved[0])
+
+		key, value, err := resolved[1].GetKeyValuePair()
+		require.NoError(t, err)
+		require.Equal(t, "API_TOKEN", key)
+		require.Equal(t, "kv2-token", value)
+
+		options, err := resolved[1].GetOptions()
+		require.NoError(t, err)
+		require.Equal(t, false, *options.IsExpand)
+	}
+
+	t.Log("failed fetch")
+	{
+		provider.token = "invalid-token"
+		provider.cache = map[string]map[string]interface{}{}
+
+		_, err := ResolveSecretReferences(secrets, []SecretsProvider{provider})
+		require.EqualError(t, err, "Failed to fetch secret (API_TOKEN) from vault, error: failed to read (secret/data/my-app), status code: 403")
+	}
+}
@@ -0,0 +1,141 @@
+package bitrise
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+)
+
+const (
+	// VaultAddrEnvKey is the address of the Vault server (https://vault.example.com:8200)
+	VaultAddrEnvKey = "VAULT_ADDR"
+	// VaultTokenEnvKey is the token to authenticate with
+	VaultTokenEnvKey = "VAULT_TOKEN"
+	// VaultNamespaceEnvKey is the Vault Enterprise namespace, optional
+	VaultNamespaceEnvKey = "VAULT_NAMESPACE"
+
+	vaultRequestTimeout = 30 * time.Second
+)
+
+// VaultSecretsProvider fetches the secrets from HashiCorp Vault (KV secrets engine, version 1 or 2).
+// Reference format: vault://<path>#<key>, for example: vault://secret/data/my-app#api_token
+// The secrets of the same path are fetched only once.
+type VaultSecretsProvider struct {
+	address   string
+	token     string
+	namespace string
+	client    *http.Client
+	cache     map[string]map[string]interface{}
+}
+
+// NewVaultSecretsProvider creates a Vault secrets provider, configured by the VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE envs
+func NewVaultSecretsProvider() *VaultSecretsProvider {
+	return &VaultSecretsProvider{
+		address:   os.Getenv(VaultAddrEnvKey),
+		token:     os.Getenv(VaultTokenEnvKey),
+		namespace: os.Getenv(VaultNamespaceEnvKey),
+		client:    configs.NewHTTPClient(vaultRequestTimeout),
+		cache:     map[string]map[string]interface{}{},
+	}
+}
+
+// Scheme ...
+func (provider *VaultSecretsProvider) Scheme() string {
+	return "vault"
+}
+
+// parseVaultReference splits the reference (<path>#<key>) to the secret's path and key
+func parseVaultReference(reference string) (string, string, error) {
+	split := strings.Split(reference, "#")
+	if len(split) != 2 || split[0] == "" || split[1] == "" {
+		return "", "", fmt.Errorf("invalid vault reference (vault://%s), should be: vault://<path>#<key>", reference)
+	}
+	return strings.Trim(split[0], "/"), split[1], nil
+}
+
+type vaultSecretResponseModel struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+// readPath returns the key-value pairs, stored at the path
+func (provider *VaultSecretsProvider) readPath(pth string) (map[string]interface{}, error) {
+	if data, found := provider.cache[pth]; found {
+		return data, nil
+	}
+
+	url := strings.TrimSuffix(provider.address, "/") + "/v1/" + pth
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+	request.Header.Set("X-Vault-Token", provider.token)
+	if provider.namespace != "" {
+		request.Header.Set("X-Vault-Namespace", provider.namespace)
+	}
+
+	resp, err := provider.client.Do(request)
+	if err != nil {
+		return map[string]interface{}{}, fmt.Errorf("failed to read (%s), error: %s", pth, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Warnf("failed to close (%s) body", url)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return map[string]interface{}{}, fmt.Errorf("failed to read (%s), status code: %d", pth, resp.StatusCode)
+	}
+
+	var response vaultSecretResponseModel
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return map[string]interface{}{}, fmt.Errorf("failed to parse the response of (%s), error: %s", pth, err)
+	}
+
+	data := response.Data
+	// KV version 2 wraps the key-value pairs: {"data": {"data": {...}, "metadata": {...}}}
+	if nested, isNested := data["data"].(map[string]interface{}); isNested {
+		if _, hasMetadata := data["metadata"]; hasMetadata {
+			data = nested
+		}
+	}
+
+	provider.cache[pth] = data
+	return data, nil
+}
+
+// Fetch ...
+func (provider *VaultSecretsProvider) Fetch(reference string) (string, error) {
+	if provider.address == "" {
+		return "", errors.New(VaultAddrEnvKey + " not set")
+	}
+	if provider.token == "" {
+		return "", errors.New(VaultTokenEnvKey + " not set")
+	}
+
+	pth, key, err := parseVaultReference(reference)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := provider.readPath(pth)
+	if err != nil {
+		return "", err
+	}
+
+	value, found := data[key]
+	if !found {
+		return "", fmt.Errorf("key (%s) not found at (%s)", key, pth)
+	}
+	if str, isString := value.(string); isString {
+		return str, nil
+	}
+	return fmt.Sprintf("%v", value), nil
+}
@@ -0,0 +1,81 @@
+package bitrise
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/go-utils/cmdex"
+	"gopkg.in/yaml.v2"
+)
+
+// age and SOPS encrypted secrets files.
+// The secrets file can be encrypted with age (binary or armored format), or with SOPS (YAML format),
+// it's decrypted with the age / sops command line tool, the decrypted content is only kept in memory.
+// The age identity is read from the key file (see: configs.GetAgeKeyFilePath).
+
+const (
+	ageBinaryHeader = "age-encryption.org/v1\n"
+	ageArmorHeader  = "-----BEGIN AGE ENCRYPTED FILE-----"
+)
+
+var (
+	ageCommand  = "age"
+	sopsCommand = "sops"
+)
+
+// IsAgeEncryptedSecrets ...
+func IsAgeEncryptedSecrets(content []byte) bool {
+	return bytes.HasPrefix(content, []byte(ageBinaryHeader)) ||
+		bytes.HasPrefix(bytes.TrimSpace(content), []byte(ageArmorHeader))
+}
+
+// IsSOPSEncryptedSecrets returns true if the content is a SOPS encrypted YAML file (has sops metadata with a mac)
+func IsSOPSEncryptedSecrets(content []byte) bool {
+	var file struct {
+		SOPS map[string]interface{} `yaml:"sops"`
+	}
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return false
+	}
+	_, hasMac := file.SOPS["mac"]
+	return hasMac
+}
+
+func runDecryptCommand(command *cmdex.CommandModel, content []byte) ([]byte, error) {
+	var outBuffer bytes.Buffer
+	var errBuffer bytes.Buffer
+	command.SetStdin(bytes.NewReader(content)).SetStdout(&outBuffer).SetStderr(&errBuffer)
+
+	if err := command.Run(); err != nil {
+		return []byte{}, fmt.Errorf("%s, details: %s", err, strings.TrimSpace(errBuffer.String()))
+	}
+	return outBuffer.Bytes(), nil
+}
+
+// DecryptAgeSecrets decrypts the age encrypted content with the identities of the key file
+func DecryptAgeSecrets(content []byte, keyFilePath string) ([]byte, error) {
+	command := cmdex.NewCommand(ageCommand, "--decrypt", "--identity", keyFilePath)
+	decrypted, err := runDecryptCommand(command, content)
+	if err != nil {
+		return []byte{}, fmt.Errorf("Failed to decrypt age encrypted secrets (key file: %s), error: %s", keyFilePath, err)
+	}
+	return decrypted, nil
+}
+
+// DecryptSOPSSecrets decrypts the SOPS encrypted YAML content.
+// Besides the age key file, SOPS can use any of its configured key sources (PGP, KMS, ...).
+func DecryptSOPSSecrets(content []byte, keyFilePath string) ([]byte, error) {
+	command := cmdex.NewCommand(sopsCommand, "--decrypt", "--input-type", "yaml", "--output-type", "yaml", "/dev/stdin")
+	if os.Getenv(configs.SOPSAgeKeyFileEnvKey) == "" {
+		command.AppendEnvs([]string{configs.SOPSAgeKeyFileEnvKey + "=" + keyFilePath})
+	}
+
+	decrypted, err := runDecryptCommand(command, content)
+	if err != nil {
+		return []byte{}, fmt.Errorf("Failed to decrypt SOPS encrypted secrets, error: %s", err)
+	}
+	return decrypted, nil
+}
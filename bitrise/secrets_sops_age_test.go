@@ -0,0 +1,81 @@
+package bitrise
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsAgeAndSOPSEncryptedSecrets(t *testing.T) {
+	require.Equal(t, true, IsAgeEncryptedSecrets([]byte("age-encryption.org/v1\n-> X25519 abc\n")))
+	require.Equal(t, true, IsAgeEncryptedSecrets([]byte("\n-----BEGIN AGE ENCRYPTED FILE-----\nYWdl\n-----END AGE ENCRYPTED FILE-----\n")))
+	require.Equal(t, false, IsAgeEncryptedSecrets([]byte("envs:\n- API_TOKEN: token\n")))
+
+	require.Equal(t, true, IsSOPSEncryptedSecrets([]byte(`envs:
+- API_TOKEN: ENC[AES256_GCM,data:abc,iv:def,tag:ghi,type:str]
+sops:
+  age:
+  - recipient: age1abc
+  mac: ENC[AES256_GCM,data:abc,iv:def,tag:ghi,type:str]
+  version: 3.8.1
+`)))
+	require.Equal(t, false, IsSOPSEncryptedSecrets([]byte("envs:\n- API_TOKEN: token\n")))
+	require.Equal(t, false, IsSOPSEncryptedSecrets([]byte("age-encryption.org/v1\n")))
+}
+
+func TestDecryptAgeAndSOPSSecrets(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "secrets_sops_age")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	// the fake tools print the key file they got, and the decrypted secrets
+	fakeAge := filepath.Join(tmpDir, "age")
+	require.NoError(t, ioutil.WriteFile(fakeAge, []byte(`#!/bin/sh
+[ "$1" = "--decrypt" ] && [ "$2" = "--identity" ] || exit 1
+[ -f "$3" ] || { echo "no identity file" >&2; exit 1; }
+echo "envs:"
+echo "- KEY_FILE: $3"
+`), 0700))
+	fakeSOPS := filepath.Join(tmpDir, "sops")
+	require.NoError(t, ioutil.WriteFile(fakeSOPS, []byte(`#!/bin/sh
+cat > /dev/null
+echo "envs:"
+echo "- KEY_FILE: $SOPS_AGE_KEY_FILE"
+`), 0700))
+
+	keyFilePath := filepath.Join(tmpDir, "keys.txt")
+	require.NoError(t, ioutil.WriteFile(keyFilePath, []byte("AGE-SECRET-KEY-1TEST\n"), 0600))
+
+	originalAgeCommand, originalSOPSCommand := ageCommand, sopsCommand
+	ageCommand, sopsCommand = fakeAge, fakeSOPS
+	defer func() {
+		ageCommand, sopsCommand = originalAgeCommand, originalSOPSCommand
+	}()
+
+	require.NoError(t, os.Setenv(configs.AgeKeyFileEnvKey, keyFilePath))
+	defer func() { require.NoError(t, os.Unsetenv(configs.AgeKeyFileEnvKey)) }()
+
+	t.Log("age")
+	{
+		decrypted, err := decryptSecretsIfEncrypted([]byte("age-encryption.org/v1\nencrypted"))
+		require.NoError(t, err)
+		require.Equal(t, "envs:\n- KEY_FILE: "+keyFilePath+"\n", string(decrypted))
+
+		_, err = DecryptAgeSecrets([]byte("age-encryption.org/v1\nencrypted"), filepath.Join(tmpDir, "missing.txt"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no identity file")
+	}
+
+	t.Log("SOPS")
+	{
+		decrypted, err := decryptSecretsIfEncrypted([]byte("envs:\n- API_TOKEN: ENC[AES256_GCM,data:abc,type:str]\nsops:\n  mac: ENC[AES256_GCM,data:abc,type:str]\n"))
+		require.NoError(t, err)
+		require.Equal(t, "envs:\n- KEY_FILE: "+keyFilePath+"\n", string(decrypted))
+	}
+}
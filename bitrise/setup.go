@@ -7,8 +7,9 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/output"
 	"github.com/bitrise-io/bitrise/toolkits"
-	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/bitrise-io/bitrise/utils"
 )
 
 const (
@@ -41,16 +42,36 @@ var LinuxPluginDependencyMap = map[string]PluginDependency{
 	},
 }
 
+// SetupMode ...
+type SetupMode string
+
+const (
+	// SetupModeDefault installs the core tools (stepman, envman), the default plugins and the toolkits,
+	// and checks the platform specific tools (e.g. Homebrew and Xcode on MacOS)
+	SetupModeDefault SetupMode = "default"
+	// SetupModeMinimal installs the core tools only, for containerized environments,
+	// where the optional dependencies are preinstalled or unwanted
+	SetupModeMinimal SetupMode = "minimal"
+	// SetupModeFull is the default setup, with the more thorough checks (e.g. brew doctor)
+	SetupModeFull SetupMode = "full"
+)
+
 // RunSetup ...
-func RunSetup(appVersion string, isFullSetupMode bool) error {
+func RunSetup(appVersion string, mode SetupMode) error {
 	log.Infoln("Setup")
-	log.Infof("Full setup: %v", isFullSetupMode)
+	log.Infof("Setup mode: %s", mode)
 	log.Infoln("Detected OS:", runtime.GOOS)
 
 	if err := doSetupBitriseCoreTools(); err != nil {
 		return fmt.Errorf("Failed to do common/platform independent setup, error: %s", err)
 	}
 
+	if mode == SetupModeMinimal {
+		log.Infoln("Minimal setup: the platform specific tools, the default plugins and the toolkits are skipped")
+		return finishSetup(appVersion)
+	}
+
+	isFullSetupMode := (mode == SetupModeFull)
 	switch runtime.GOOS {
 	case "darwin":
 		if err := doSetupOnOSX(isFullSetupMode); err != nil {
@@ -60,6 +81,10 @@ func RunSetup(appVersion string, isFullSetupMode bool) error {
 		if err := doSetupOnLinux(); err != nil {
 			return fmt.Errorf("Failed to do Linux specific setup, error: %s", err)
 		}
+	case "windows":
+		if err := doSetupOnWindows(); err != nil {
+			return fmt.Errorf("Failed to do Windows specific setup, error: %s", err)
+		}
 	default:
 		return errors.New("unsupported platform :(")
 	}
@@ -68,6 +93,11 @@ func RunSetup(appVersion string, isFullSetupMode bool) error {
 		return fmt.Errorf("Failed to do Toolkits setup, error: %s", err)
 	}
 
+	return finishSetup(appVersion)
+}
+
+// finishSetup saves the setup's success for the version of the CLI
+func finishSetup(appVersion string) error {
 	log.Infoln("All the required tools are installed!")
 
 	if err := configs.SaveSetupSuccessForVersion(appVersion); err != nil {
@@ -108,7 +138,7 @@ func doSetupToolkits() error {
 			return fmt.Errorf("Toolkit (%s) still reports that it isn't (properly) installed", toolkitName)
 		}
 
-		log.Infoln(" * "+colorstring.Green("[OK]")+" "+toolkitName+" :", checkResult.Path)
+		log.Infoln(" * "+output.Green("[OK]")+" "+toolkitName+" :", checkResult.Path)
 		log.Infoln("        version :", checkResult.Version)
 	}
 
@@ -153,10 +183,36 @@ func doSetupOnOSX(isMinimalSetupMode bool) error {
 	return nil
 }
 
+// doSetupOnWindows checks the tools required to run the steps on Windows,
+// the default plugins are not installed, they are not available for Windows.
+func doSetupOnWindows() error {
+	log.Infoln("Doing Windows specific setup")
+	log.Infoln("Checking required tools...")
+
+	for _, tool := range []string{"git", "powershell"} {
+		toolPth, err := utils.CheckProgramInstalledPath(tool)
+		if err != nil {
+			return fmt.Errorf("%s is not installed, it's required to run the steps", tool)
+		}
+		log.Infof(" * "+output.Green("[OK]")+" %s : %s", tool, toolPth)
+	}
+
+	if _, err := utils.CheckProgramInstalledPath("bash"); err != nil {
+		log.Warnf("bash is not installed (e.g. Git Bash), only the steps with PowerShell or batch entry file can run")
+	}
+
+	return nil
+}
+
 func doSetupOnLinux() error {
 	log.Infoln("Doing Linux specific setup")
 	log.Infoln("Checking required tools...")
 
+	if runtime.GOARCH != "amd64" {
+		log.Infof("The default plugins are not available for %s, skipping them", runtime.GOARCH)
+		return nil
+	}
+
 	for pluginName, pluginDependency := range LinuxPluginDependencyMap {
 		if err := CheckIsPluginInstalled(pluginName, pluginDependency); err != nil {
 			return fmt.Errorf("Plugin (%s) failed to install: %s", pluginName, err)
@@ -0,0 +1,44 @@
+package bitrise
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/bitrise-io/bitrise/models"
+)
+
+// pullRequestRunIfPattern matches the run_if expressions, which depend on the pull request mode
+var pullRequestRunIfPattern = regexp.MustCompile(`\.IsPR\b|\.PullRequest`)
+
+// StepSkipReason returns the reason of skipping the step, shown in the build summary:
+// the skip error (e.g. the build was aborted), the run_if expression (or the pull request mode exclusion),
+// the failed step (if the step is not is_always_run), or the run_on_failure_only option.
+// Returns an empty string if the step was not skipped.
+func StepSkipReason(step models.WorkflowStepModel, runIf string, status int, err error, buildRunResults models.BuildRunResultsModel, isPullRequestMode bool) string {
+	switch status {
+	case models.StepRunStatusCodeSkippedWithRunIf:
+		if isPullRequestMode && pullRequestRunIfPattern.MatchString(runIf) {
+			return fmt.Sprintf("excluded in pull request mode (run_if: %s)", runIf)
+		}
+		return fmt.Sprintf("run_if evaluated to false (run_if: %s)", runIf)
+	case models.StepRunStatusCodeSkipped:
+		if err != nil {
+			return err.Error()
+		}
+
+		if !buildRunResults.IsBuildFailed() {
+			if step.RunOnFailureOnly != nil && *step.RunOnFailureOnly {
+				return "the build is not failing, and the step is run_on_failure_only"
+			}
+			return "skipped"
+		}
+
+		failedStep := buildRunResults.FailedSteps[len(buildRunResults.FailedSteps)-1].StepInfo
+		failedStepTitle := failedStep.Title
+		if failedStepTitle == "" {
+			failedStepTitle = failedStep.ID
+		}
+		return fmt.Sprintf("a previous step (%s) failed, and the step is not is_always_run", failedStepTitle)
+	}
+	return ""
+}
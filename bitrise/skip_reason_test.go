@@ -0,0 +1,54 @@
+package bitrise
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/pointers"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepSkipReason(t *testing.T) {
+	failedBuild := models.BuildRunResultsModel{
+		FailedSteps: []models.StepRunResultsModel{
+			models.StepRunResultsModel{StepInfo: stepmanModels.StepInfoModel{ID: "script", Title: "Unit tests"}},
+		},
+	}
+
+	t.Log("not skipped")
+	{
+		require.Equal(t, "", StepSkipReason(models.WorkflowStepModel{}, "", models.StepRunStatusCodeSuccess, nil, models.BuildRunResultsModel{}, false))
+	}
+
+	t.Log("run_if")
+	{
+		reason := StepSkipReason(models.WorkflowStepModel{}, "{{.IsCI}}", models.StepRunStatusCodeSkippedWithRunIf, nil, models.BuildRunResultsModel{}, true)
+		require.Equal(t, "run_if evaluated to false (run_if: {{.IsCI}})", reason)
+	}
+
+	t.Log("pull request mode exclusion")
+	{
+		reason := StepSkipReason(models.WorkflowStepModel{}, "{{not .IsPR}}", models.StepRunStatusCodeSkippedWithRunIf, nil, models.BuildRunResultsModel{}, true)
+		require.Equal(t, "excluded in pull request mode (run_if: {{not .IsPR}})", reason)
+	}
+
+	t.Log("skip error")
+	{
+		reason := StepSkipReason(models.WorkflowStepModel{}, "", models.StepRunStatusCodeSkipped, ErrBuildAborted, failedBuild, false)
+		require.Equal(t, ErrBuildAborted.Error(), reason)
+	}
+
+	t.Log("previous step failed")
+	{
+		reason := StepSkipReason(models.WorkflowStepModel{}, "", models.StepRunStatusCodeSkipped, nil, failedBuild, false)
+		require.Equal(t, "a previous step (Unit tests) failed, and the step is not is_always_run", reason)
+	}
+
+	t.Log("run_on_failure_only")
+	{
+		step := models.WorkflowStepModel{RunOnFailureOnly: pointers.NewBoolPtr(true)}
+		reason := StepSkipReason(step, "", models.StepRunStatusCodeSkipped, nil, models.BuildRunResultsModel{}, false)
+		require.Equal(t, "the build is not failing, and the step is run_on_failure_only", reason)
+	}
+}
@@ -0,0 +1,320 @@
+package bitrise
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/cmdex"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// Archive steps (archive::https://host/step-1.2.3.tar.gz@sha256:<checksum>): the archive is downloaded,
+// its checksum is verified, then it's extracted into the step's directory.
+// If the archive's step.yml is not in its root, but in its single top level directory
+// (e.g. step-1.2.3/step.yml, as in the generated source archives), that directory is the step's directory.
+// The extracted steps are cached by their checksum (~/.bitrise/cache/archive_steps).
+
+const (
+	archiveStepsCacheDirName     = "archive_steps"
+	archiveStepDownloadTimeout   = 5 * time.Minute
+	archiveStepMaxDownloadedSize = 512 * 1024 * 1024
+)
+
+// ActivateArchiveStep downloads and extracts the archive step (or uses its cached version) into stepDir,
+// and copies its step.yml to stepYMLPth (if stepYMLPth is not empty).
+func ActivateArchiveStep(stepIDData models.StepIDData, stepDir, stepYMLPth string) error {
+	checksum, err := stepIDData.ArchiveStepChecksum()
+	if err != nil {
+		return err
+	}
+
+	cacheDir := filepath.Join(configs.GetBitriseHomeDirPath(), "cache", archiveStepsCacheDirName)
+	cachedStepDir, err := downloadArchiveStep(stepIDData.IDorURI, checksum, cacheDir)
+	if err != nil {
+		return fmt.Errorf("Failed to download step archive (%s), error: %s", stepIDData.IDorURI, err)
+	}
+
+	if err := os.MkdirAll(stepDir, 0777); err != nil {
+		return err
+	}
+	if err := cmdex.CopyDir(cachedStepDir, stepDir, true); err != nil {
+		return fmt.Errorf("Failed to copy step (%s), error: %s", stepIDData.IDorURI, err)
+	}
+	if stepYMLPth != "" {
+		if err := cmdex.CopyFile(filepath.Join(cachedStepDir, "step.yml"), stepYMLPth); err != nil {
+			return fmt.Errorf("Failed to copy step.yml of step (%s), error: %s", stepIDData.IDorURI, err)
+		}
+	}
+
+	log.Debugf("[BITRISE_CLI] - Archive step activated: (%s) (checksum:%s)", stepIDData.IDorURI, checksum)
+	return nil
+}
+
+// downloadArchiveStep returns the cached directory of the step, the archive is downloaded only if it's not cached yet.
+func downloadArchiveStep(archiveURL, checksum, cacheDir string) (string, error) {
+	stepDir := filepath.Join(cacheDir, strings.Replace(checksum, ":", "-", -1))
+	if exist, err := pathutil.IsDirExists(stepDir); err != nil {
+		return "", err
+	} else if exist {
+		log.Debugf("Using cached archive step (%s)", archiveURL)
+		return stepDir, nil
+	}
+
+	// downloaded and extracted into a temporary directory, which is moved to its place once complete,
+	// so a failed extraction doesn't leave a partial step in the cache
+	if err := os.MkdirAll(cacheDir, 0777); err != nil {
+		return "", err
+	}
+	tmpDir, err := ioutil.TempDir(cacheDir, "extract-")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			log.Warnf("Failed to remove (%s), error: %s", tmpDir, err)
+		}
+	}()
+
+	archivePth := filepath.Join(tmpDir, "archive")
+	actual, err := downloadStepArchive(archiveURL, archivePth)
+	if err != nil {
+		return "", err
+	}
+	if actual != checksum {
+		return "", fmt.Errorf("checksum (%s) doesn't match the expected checksum (%s)", actual, checksum)
+	}
+
+	extractDir := filepath.Join(tmpDir, "step")
+	if err := os.MkdirAll(extractDir, 0777); err != nil {
+		return "", err
+	}
+	if err := extractStepArchive(archiveURL, archivePth, extractDir); err != nil {
+		return "", fmt.Errorf("failed to extract, error: %s", err)
+	}
+
+	extractedStepDir, err := archiveStepRootDir(extractDir)
+	if err != nil {
+		return "", err
+	}
+	if err := moveIntoStepCache(extractedStepDir, stepDir); err != nil {
+		return "", err
+	}
+	return stepDir, nil
+}
+
+// downloadStepArchive streams the archive into the file, and returns its checksum (sha256:<hex>)
+func downloadStepArchive(archiveURL, pth string) (string, error) {
+	client := configs.NewHTTPClient(archiveStepDownloadTimeout)
+	resp, err := client.Get(archiveURL)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Warnf("failed to close (%s) body", archiveURL)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	file, err := os.Create(pth)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(file, hash), io.LimitReader(resp.Body, archiveStepMaxDownloadedSize+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	if written > archiveStepMaxDownloadedSize {
+		return "", fmt.Errorf("archive is larger than %d bytes", archiveStepMaxDownloadedSize)
+	}
+	return models.OCIDigestPrefix + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// archiveStepRootDir returns the directory of the extracted archive, which contains the step.yml:
+// the extraction directory itself, or its single top level directory.
+func archiveStepRootDir(dir string) (string, error) {
+	if exist, err := pathutil.IsPathExists(filepath.Join(dir, "step.yml")); err != nil {
+		return "", err
+	} else if exist {
+		return dir, nil
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		subDir := filepath.Join(dir, entries[0].Name())
+		if exist, err := pathutil.IsPathExists(filepath.Join(subDir, "step.yml")); err != nil {
+			return "", err
+		} else if exist {
+			return subDir, nil
+		}
+	}
+	return "", fmt.Errorf("the archive doesn't contain a step.yml")
+}
+
+// extractStepArchive extracts the downloaded archive (archivePth) into the dir, the archive type is detected by the URL
+func extractStepArchive(archiveURL, archivePth, dir string) error {
+	urlPth := strings.ToLower(archiveURL)
+	if idx := strings.IndexAny(urlPth, "?#"); idx != -1 {
+		urlPth = urlPth[:idx]
+	}
+
+	switch {
+	case strings.HasSuffix(urlPth, ".tar.gz"), strings.HasSuffix(urlPth, ".tgz"), strings.HasSuffix(urlPth, ".tar"):
+		file, err := os.Open(archivePth)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := file.Close(); err != nil {
+				log.Warnf("Failed to close (%s), error: %s", archivePth, err)
+			}
+		}()
+
+		if strings.HasSuffix(urlPth, ".tar") {
+			return extractStepTar(file, dir)
+		}
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		return extractStepTar(gzipReader, dir)
+	case strings.HasSuffix(urlPth, ".zip"):
+		return extractStepZip(archivePth, dir)
+	}
+	return fmt.Errorf("unsupported archive type (%s), supported: .tar.gz, .tgz, .tar, .zip", archiveURL)
+}
+
+// stepArchiveFilePath returns the path of the archive's file in the step's directory,
+// the file can't be outside of the step's directory.
+func stepArchiveFilePath(stepDir, name string) (string, error) {
+	cleaned := filepath.Clean("/" + name)
+	if cleaned == "/" {
+		return "", fmt.Errorf("invalid file name (%s)", name)
+	}
+	return filepath.Join(stepDir, cleaned), nil
+}
+
+func writeStepArchiveFile(pth string, mode os.FileMode, reader io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(pth), 0777); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(pth, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, reader); err != nil {
+		if closeErr := file.Close(); closeErr != nil {
+			log.Warnf("Failed to close (%s), error: %s", pth, closeErr)
+		}
+		return err
+	}
+	return file.Close()
+}
+
+func extractStepTar(reader io.Reader, stepDir string) error {
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if filepath.Clean("/"+header.Name) == "/" {
+			continue
+		}
+		pth, err := stepArchiveFilePath(stepDir, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(pth, 0777); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := writeStepArchiveFile(pth, os.FileMode(header.Mode)&0777, tarReader); err != nil {
+				return err
+			}
+		default:
+			log.Debugf("Skipping (%s) of the step archive, unsupported file type", header.Name)
+		}
+	}
+}
+
+func extractStepZip(archivePth, stepDir string) error {
+	zipReader, err := zip.OpenReader(archivePth)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := zipReader.Close(); err != nil {
+			log.Warnf("Failed to close (%s), error: %s", archivePth, err)
+		}
+	}()
+
+	for _, file := range zipReader.File {
+		if filepath.Clean("/"+file.Name) == "/" {
+			continue
+		}
+		pth, err := stepArchiveFilePath(stepDir, file.Name)
+		if err != nil {
+			return err
+		}
+
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(pth, 0777); err != nil {
+				return err
+			}
+			continue
+		}
+		if !file.Mode().IsRegular() {
+			log.Debugf("Skipping (%s) of the step archive, unsupported file type", file.Name)
+			continue
+		}
+
+		mode := file.Mode().Perm()
+		if mode == 0 {
+			mode = 0644
+		}
+		fileReader, err := file.Open()
+		if err != nil {
+			return err
+		}
+		writeErr := writeStepArchiveFile(pth, mode, fileReader)
+		if err := fileReader.Close(); err != nil {
+			log.Warnf("Failed to close (%s), error: %s", file.Name, err)
+		}
+		if writeErr != nil {
+			return writeErr
+		}
+	}
+	return nil
+}
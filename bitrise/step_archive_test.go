@@ -0,0 +1,126 @@
+package bitrise
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func testStepTarGz(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, content := range files {
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tarWriter.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+	return buf.Bytes()
+}
+
+func testStepZip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	for name, content := range files {
+		writer, err := zipWriter.Create(name)
+		require.NoError(t, err)
+		_, err = writer.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zipWriter.Close())
+	return buf.Bytes()
+}
+
+func TestDownloadArchiveStep(t *testing.T) {
+	archives := map[string][]byte{
+		"/my-step-1.2.3.tar.gz": testStepTarGz(t, map[string]string{
+			"my-step-1.2.3/step.yml": "title: Archive step\n",
+			"my-step-1.2.3/step.sh":  "#!/bin/bash\necho hello\n",
+		}),
+		"/my-step.zip":       testStepZip(t, map[string]string{"step.yml": "title: Zip step\n"}),
+		"/no-step-yml.zip":   testStepZip(t, map[string]string{"README.md": "# Step\n"}),
+		"/my-step.tar.bz2":   []byte("bz2"),
+		"/modified.tar.gz":   testStepTarGz(t, map[string]string{"step.yml": "title: Modified\n"}),
+		"/unmodified.tar.gz": testStepTarGz(t, map[string]string{"step.yml": "title: Unmodified\n"}),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		archive, found := archives[r.URL.Path]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, err := w.Write(archive)
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	cacheDir, err := pathutil.NormalizedOSTempDirPath("archive_steps")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(cacheDir))
+	}()
+
+	t.Log("tar.gz with a top level directory")
+	{
+		stepDir, err := downloadArchiveStep(server.URL+"/my-step-1.2.3.tar.gz", ociDigest(archives["/my-step-1.2.3.tar.gz"]), cacheDir)
+		require.NoError(t, err)
+
+		content, err := fileutil.ReadStringFromFile(filepath.Join(stepDir, "step.yml"))
+		require.NoError(t, err)
+		require.Equal(t, "title: Archive step\n", content)
+
+		info, err := os.Stat(filepath.Join(stepDir, "step.sh"))
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	}
+
+	t.Log("zip")
+	{
+		stepDir, err := downloadArchiveStep(server.URL+"/my-step.zip", ociDigest(archives["/my-step.zip"]), cacheDir)
+		require.NoError(t, err)
+
+		content, err := fileutil.ReadStringFromFile(filepath.Join(stepDir, "step.yml"))
+		require.NoError(t, err)
+		require.Equal(t, "title: Zip step\n", content)
+	}
+
+	t.Log("checksum mismatch")
+	{
+		_, err := downloadArchiveStep(server.URL+"/modified.tar.gz", ociDigest(archives["/unmodified.tar.gz"]), cacheDir)
+		require.EqualError(t, err, "checksum ("+ociDigest(archives["/modified.tar.gz"])+") doesn't match the expected checksum ("+ociDigest(archives["/unmodified.tar.gz"])+")")
+	}
+
+	t.Log("invalid archives")
+	{
+		_, err := downloadArchiveStep(server.URL+"/no-step-yml.zip", ociDigest(archives["/no-step-yml.zip"]), cacheDir)
+		require.EqualError(t, err, "the archive doesn't contain a step.yml")
+
+		_, err = downloadArchiveStep(server.URL+"/my-step.tar.bz2", ociDigest(archives["/my-step.tar.bz2"]), cacheDir)
+		require.EqualError(t, err, "failed to extract, error: unsupported archive type ("+server.URL+"/my-step.tar.bz2), supported: .tar.gz, .tgz, .tar, .zip")
+
+		_, err = downloadArchiveStep(server.URL+"/missing.zip", ociDigest([]byte{}), cacheDir)
+		require.EqualError(t, err, "status code: 404")
+	}
+}
+
+func TestStepArchiveFilePath(t *testing.T) {
+	pth, err := stepArchiveFilePath("/tmp/step", "../../etc/passwd")
+	require.NoError(t, err)
+	require.Equal(t, "/tmp/step/etc/passwd", pth)
+
+	_, err = stepArchiveFilePath("/tmp/step", "..")
+	require.Error(t, err)
+}
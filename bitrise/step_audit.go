@@ -0,0 +1,76 @@
+package bitrise
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// Step audit during activation: the activated step's step.yml is audited the same way as stepman audits
+// a step before sharing it, and the step's entry file has to exist,
+// so a step with a malformed step.yml or with a missing entry file doesn't run.
+
+const (
+	// StepAuditModeOff - the steps are not audited
+	StepAuditModeOff = ""
+	// StepAuditModeWarn - the audit problems are printed as warnings
+	StepAuditModeWarn = "warn"
+	// StepAuditModeFail - the step fails, if it has any audit problem
+	StepAuditModeFail = "fail"
+)
+
+// ParseStepAuditMode ...
+func ParseStepAuditMode(mode string) (string, error) {
+	switch mode {
+	case StepAuditModeOff, StepAuditModeWarn, StepAuditModeFail:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid step audit mode (%s), accepted: %s, %s", mode, StepAuditModeWarn, StepAuditModeFail)
+}
+
+// StricterStepAuditMode returns the stricter one of the given audit modes
+func StricterStepAuditMode(mode, otherMode string) string {
+	if mode == StepAuditModeFail || otherMode == StepAuditModeFail {
+		return StepAuditModeFail
+	}
+	if mode == StepAuditModeWarn || otherMode == StepAuditModeWarn {
+		return StepAuditModeWarn
+	}
+	return StepAuditModeOff
+}
+
+// AuditStep returns the audit problems of the activated step:
+// the problems reported by stepman's audit of the step.yml (specStep), and the missing entry file of the step's toolkit.
+func AuditStep(specStep stepmanModels.StepModel, stepDir string) ([]string, error) {
+	problems := []string{}
+	if err := specStep.AuditBeforeShare(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if specStep.Toolkit != nil && specStep.Toolkit.Go != nil {
+		if specStep.Toolkit.Go.PackageName == "" {
+			problems = append(problems, "Invalid step: missing or empty required 'toolkit.go.package_name' property")
+		}
+		goFiles, err := filepath.Glob(filepath.Join(stepDir, "*.go"))
+		if err != nil {
+			return []string{}, err
+		}
+		if len(goFiles) == 0 {
+			problems = append(problems, fmt.Sprintf("Invalid step: no Go source file found in the step's directory (%s)", stepDir))
+		}
+		return problems, nil
+	}
+
+	entryFile := "step.sh"
+	if specStep.Toolkit != nil && specStep.Toolkit.Bash != nil && specStep.Toolkit.Bash.EntryFile != "" {
+		entryFile = specStep.Toolkit.Bash.EntryFile
+	}
+	if exist, err := pathutil.IsPathExists(filepath.Join(stepDir, entryFile)); err != nil {
+		return []string{}, err
+	} else if !exist {
+		problems = append(problems, fmt.Sprintf("Invalid step: entry file (%s) not found in the step's directory (%s)", entryFile, stepDir))
+	}
+	return problems, nil
+}
@@ -0,0 +1,81 @@
+package bitrise
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStricterStepAuditMode(t *testing.T) {
+	require.Equal(t, StepAuditModeOff, StricterStepAuditMode(StepAuditModeOff, StepAuditModeOff))
+	require.Equal(t, StepAuditModeWarn, StricterStepAuditMode(StepAuditModeOff, StepAuditModeWarn))
+	require.Equal(t, StepAuditModeFail, StricterStepAuditMode(StepAuditModeFail, StepAuditModeWarn))
+
+	_, err := ParseStepAuditMode("strict")
+	require.EqualError(t, err, "invalid step audit mode (strict), accepted: warn, fail")
+}
+
+func TestAuditStep(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("step_audit")
+	require.NoError(t, err)
+
+	t.Log("valid bash step")
+	{
+		stepDir := filepath.Join(tmpDir, "valid")
+		_, err := WriteStepScaffold(StepScaffoldModel{ID: "valid", Title: "Valid", Website: "https://example.com", Toolkit: StepToolkitBash}, stepDir)
+		require.NoError(t, err)
+
+		specStep, err := ReadSpecStep(filepath.Join(stepDir, "step.yml"))
+		require.NoError(t, err)
+		problems, err := AuditStep(specStep, stepDir)
+		require.NoError(t, err)
+		require.Equal(t, []string{}, problems)
+	}
+
+	t.Log("missing website and entry file")
+	{
+		stepDir := filepath.Join(tmpDir, "invalid")
+		require.NoError(t, pathutil.EnsureDirExist(stepDir))
+		require.NoError(t, fileutil.WriteStringToFile(filepath.Join(stepDir, "step.yml"), `
+title: Invalid
+summary: Invalid step
+toolkit:
+  bash:
+    entry_file: run.sh
+`))
+
+		specStep, err := ReadSpecStep(filepath.Join(stepDir, "step.yml"))
+		require.NoError(t, err)
+		problems, err := AuditStep(specStep, stepDir)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"Invalid step: missing or empty required 'website' property",
+			"Invalid step: entry file (run.sh) not found in the step's directory (" + stepDir + ")",
+		}, problems)
+	}
+
+	t.Log("go step without source")
+	{
+		stepDir := filepath.Join(tmpDir, "go")
+		require.NoError(t, pathutil.EnsureDirExist(stepDir))
+		require.NoError(t, fileutil.WriteStringToFile(filepath.Join(stepDir, "step.yml"), `
+title: Go
+summary: Go step
+website: https://example.com
+toolkit:
+  go:
+    package_name: github.com/user/steps-go
+`))
+
+		specStep, err := ReadSpecStep(filepath.Join(stepDir, "step.yml"))
+		require.NoError(t, err)
+		problems, err := AuditStep(specStep, stepDir)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"Invalid step: no Go source file found in the step's directory (" + stepDir + ")",
+		}, problems)
+	}
+}
@@ -0,0 +1,80 @@
+package bitrise
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"gopkg.in/yaml.v2"
+)
+
+// Step deprecation: a step is deprecated if its StepLib step group has a removal date or deprecate notes
+// (reported by stepman, as the step info's global info), or if its step.yml has a removal or replacement notice.
+
+// stepYMLDeprecationModel is the removal or replacement notice of a step.yml, these fields are not part of the step model
+type stepYMLDeprecationModel struct {
+	RemovalDate    string `yaml:"removal_date,omitempty"`
+	DeprecateNotes string `yaml:"deprecate_notes,omitempty"`
+	ReplacedBy     string `yaml:"replaced_by,omitempty"`
+}
+
+// ReadStepYMLDeprecation returns the removal or replacement notice of the given step.yml (removal_date, deprecate_notes, replaced_by)
+func ReadStepYMLDeprecation(pth string) (stepmanModels.GlobalStepInfoModel, error) {
+	bytes, err := fileutil.ReadBytesFromFile(pth)
+	if err != nil {
+		return stepmanModels.GlobalStepInfoModel{}, err
+	}
+
+	deprecation := stepYMLDeprecationModel{}
+	if err := yaml.Unmarshal(bytes, &deprecation); err != nil {
+		return stepmanModels.GlobalStepInfoModel{}, fmt.Errorf("Failed to parse step.yml (%s), error: %s", pth, err)
+	}
+
+	notes := []string{}
+	if deprecation.DeprecateNotes != "" {
+		notes = append(notes, deprecation.DeprecateNotes)
+	}
+	if deprecation.ReplacedBy != "" {
+		notes = append(notes, "Replaced by: "+deprecation.ReplacedBy)
+	}
+
+	return stepmanModels.GlobalStepInfoModel{
+		RemovalDate:    deprecation.RemovalDate,
+		DeprecateNotes: strings.Join(notes, "\n"),
+	}, nil
+}
+
+// MergeStepDeprecation fills the deprecation info, reported by stepman, with the step.yml's notice,
+// the info reported by stepman has priority.
+func MergeStepDeprecation(info, stepYMLInfo stepmanModels.GlobalStepInfoModel) stepmanModels.GlobalStepInfoModel {
+	if info.RemovalDate == "" {
+		info.RemovalDate = stepYMLInfo.RemovalDate
+	}
+	if info.DeprecateNotes == "" {
+		info.DeprecateNotes = stepYMLInfo.DeprecateNotes
+	}
+	return info
+}
+
+// IsStepDeprecated ...
+func IsStepDeprecated(stepInfo stepmanModels.StepInfoModel) bool {
+	return stepInfo.GlobalInfo.RemovalDate != "" || stepInfo.GlobalInfo.DeprecateNotes != ""
+}
+
+// StepDeprecationNotice returns the single line deprecation notice of the step, or an empty string,
+// if the step is not deprecated (e.g.: Deprecated, will be removed: 2017-01-01. Use the new-step instead)
+func StepDeprecationNotice(stepInfo stepmanModels.StepInfoModel) string {
+	if !IsStepDeprecated(stepInfo) {
+		return ""
+	}
+
+	notice := "Deprecated"
+	if stepInfo.GlobalInfo.RemovalDate != "" {
+		notice += ", will be removed: " + stepInfo.GlobalInfo.RemovalDate
+	}
+	if stepInfo.GlobalInfo.DeprecateNotes != "" {
+		notice += ". " + strings.Join(strings.Fields(stepInfo.GlobalInfo.DeprecateNotes), " ")
+	}
+	return notice
+}
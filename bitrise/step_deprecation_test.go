@@ -0,0 +1,105 @@
+package bitrise
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadStepYMLDeprecation(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("step_deprecation")
+	require.NoError(t, err)
+
+	t.Log("step.yml with removal and replacement notice")
+	{
+		pth := filepath.Join(tmpDir, "deprecated.yml")
+		require.NoError(t, fileutil.WriteStringToFile(pth, `title: Old step
+removal_date: "2017-01-01"
+deprecate_notes: Not maintained anymore.
+replaced_by: new-step
+`))
+
+		deprecation, err := ReadStepYMLDeprecation(pth)
+		require.NoError(t, err)
+		require.Equal(t, stepmanModels.GlobalStepInfoModel{
+			RemovalDate:    "2017-01-01",
+			DeprecateNotes: "Not maintained anymore.\nReplaced by: new-step",
+		}, deprecation)
+	}
+
+	t.Log("step.yml without notice")
+	{
+		pth := filepath.Join(tmpDir, "step.yml")
+		require.NoError(t, fileutil.WriteStringToFile(pth, "title: Step\n"))
+
+		deprecation, err := ReadStepYMLDeprecation(pth)
+		require.NoError(t, err)
+		require.Equal(t, stepmanModels.GlobalStepInfoModel{}, deprecation)
+	}
+}
+
+func TestMergeStepDeprecation(t *testing.T) {
+	stepmanInfo := stepmanModels.GlobalStepInfoModel{RemovalDate: "2017-01-01"}
+	stepYMLInfo := stepmanModels.GlobalStepInfoModel{RemovalDate: "2018-01-01", DeprecateNotes: "Replaced by: new-step"}
+
+	require.Equal(t, stepmanModels.GlobalStepInfoModel{
+		RemovalDate:    "2017-01-01",
+		DeprecateNotes: "Replaced by: new-step",
+	}, MergeStepDeprecation(stepmanInfo, stepYMLInfo))
+}
+
+func TestStepDeprecationNotice(t *testing.T) {
+	t.Log("not deprecated")
+	{
+		stepInfo := stepmanModels.StepInfoModel{ID: "script"}
+		require.Equal(t, false, IsStepDeprecated(stepInfo))
+		require.Equal(t, "", StepDeprecationNotice(stepInfo))
+	}
+
+	t.Log("removal date and notes")
+	{
+		stepInfo := stepmanModels.StepInfoModel{
+			ID: "old-step",
+			GlobalInfo: stepmanModels.GlobalStepInfoModel{
+				RemovalDate:    "2017-01-01",
+				DeprecateNotes: "Not maintained anymore.\nReplaced by: new-step",
+			},
+		}
+		require.Equal(t, true, IsStepDeprecated(stepInfo))
+		require.Equal(t, "Deprecated, will be removed: 2017-01-01. Not maintained anymore. Replaced by: new-step", StepDeprecationNotice(stepInfo))
+	}
+
+	t.Log("notes only")
+	{
+		stepInfo := stepmanModels.StepInfoModel{
+			ID:         "old-step",
+			GlobalInfo: stepmanModels.GlobalStepInfoModel{DeprecateNotes: "Replaced by: new-step"},
+		}
+		require.Equal(t, true, IsStepDeprecated(stepInfo))
+		require.Equal(t, "Deprecated. Replaced by: new-step", StepDeprecationNotice(stepInfo))
+	}
+
+	t.Log("build summary")
+	{
+		buildRunResults := models.BuildRunResultsModel{
+			SuccessSteps: []models.StepRunResultsModel{
+				models.StepRunResultsModel{
+					StepInfo: stepmanModels.StepInfoModel{
+						ID:         "old-step",
+						GlobalInfo: stepmanModels.GlobalStepInfoModel{DeprecateNotes: "Replaced by: new-step"},
+					},
+					Status: models.StepRunStatusCodeSuccess,
+				},
+			},
+		}
+		summary := NewBuildSummary("primary", buildRunResults, time.Second)
+		require.Equal(t, 1, len(summary.Steps))
+		require.Equal(t, "Deprecated. Replaced by: new-step", summary.Steps[0].Deprecation)
+	}
+}
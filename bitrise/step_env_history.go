@@ -0,0 +1,232 @@
+package bitrise
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// Step env history.
+// The resolved inputs and environment of the successful step runs are stored (see: configs.GetBitriseStepEnvHistoryDirPath),
+// if the same step fails later, its inputs and environment are diffed against the last successful run.
+
+const maxStepEnvDiffValueLength = 120
+
+// volatileStepEnvKeys change in every build, they are not part of the snapshots
+var volatileStepEnvKeys = map[string]bool{
+	"BITRISE_BUILD_NUMBER":            true,
+	"BITRISE_BUILD_SLUG":              true,
+	"BITRISE_BUILD_URL":               true,
+	"BITRISE_BUILD_TRIGGER_TIMESTAMP": true,
+}
+
+// StepEnvSnapshotModel is the resolved inputs and environment of a step run
+type StepEnvSnapshotModel struct {
+	StepID       string            `json:"step_id"`
+	Version      string            `json:"version,omitempty"`
+	Time         time.Time         `json:"time"`
+	Inputs       map[string]string `json:"inputs"`
+	Environments map[string]string `json:"environments"`
+}
+
+// StepEnvHistoryModel stores the snapshots of the successful step runs.
+// The secret values are not stored, only their fingerprints, so their changes are still detected.
+type StepEnvHistoryModel struct {
+	dir          string
+	secretKeys   map[string]bool
+	secretValues []string
+}
+
+// NewStepEnvHistory ...
+func NewStepEnvHistory(dir string, secrets []envmanModels.EnvironmentItemModel) *StepEnvHistoryModel {
+	history := &StepEnvHistoryModel{
+		dir:          dir,
+		secretKeys:   map[string]bool{},
+		secretValues: []string{},
+	}
+
+	for _, secret := range secrets {
+		key, value, err := secret.GetKeyValuePair()
+		if err != nil {
+			continue
+		}
+		history.secretKeys[key] = true
+		if value != "" {
+			history.secretValues = append(history.secretValues, value)
+		}
+	}
+
+	return history
+}
+
+// StepEnvHistoryKey identifies the same step across the runs:
+// by the project dir, the workflow, and the step's title and ID
+func StepEnvHistoryKey(projectDir, workflowID, stepTitle, stepID string) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n%s\n%s\n", projectDir, workflowID, stepTitle, stepID)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (history *StepEnvHistoryModel) snapshotPath(key string) string {
+	return filepath.Join(history.dir, key+".json")
+}
+
+func (history *StepEnvHistoryModel) redact(key, value string) string {
+	if history.secretKeys[key] {
+		hash := sha256.Sum256([]byte(value))
+		return fmt.Sprintf("%s (fingerprint: %s)", redactedValue, hex.EncodeToString(hash[:])[:12])
+	}
+
+	for _, secret := range history.secretValues {
+		value = strings.Replace(value, secret, redactedValue, -1)
+	}
+	return ActivePolicy().Redact(value)
+}
+
+// NewSnapshot creates the snapshot of the step run from the step's resolved environment,
+// the envs of the given input keys are the step's inputs.
+func (history *StepEnvHistoryModel) NewSnapshot(stepID, version string, inputKeys []string, envs envmanModels.EnvsJSONListModel) StepEnvSnapshotModel {
+	isInput := map[string]bool{}
+	for _, key := range inputKeys {
+		isInput[key] = true
+	}
+
+	snapshot := StepEnvSnapshotModel{
+		StepID:       stepID,
+		Version:      version,
+		Time:         time.Now(),
+		Inputs:       map[string]string{},
+		Environments: map[string]string{},
+	}
+	for key, value := range envs {
+		if volatileStepEnvKeys[key] {
+			continue
+		}
+
+		if isInput[key] {
+			snapshot.Inputs[key] = history.redact(key, value)
+		} else {
+			snapshot.Environments[key] = history.redact(key, value)
+		}
+	}
+
+	return snapshot
+}
+
+// Save stores the snapshot of a successful step run, as the last successful run of the step
+func (history *StepEnvHistoryModel) Save(key string, snapshot StepEnvSnapshotModel) error {
+	if err := pathutil.EnsureDirExist(history.dir); err != nil {
+		return err
+	}
+
+	bytes, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(history.snapshotPath(key), bytes, 0600)
+}
+
+// LastSuccessful returns the snapshot of the step's last successful run, and false if there is none.
+func (history *StepEnvHistoryModel) LastSuccessful(key string) (StepEnvSnapshotModel, bool) {
+	bytes, err := ioutil.ReadFile(history.snapshotPath(key))
+	if err != nil {
+		return StepEnvSnapshotModel{}, false
+	}
+
+	snapshot := StepEnvSnapshotModel{}
+	if err := json.Unmarshal(bytes, &snapshot); err != nil {
+		return StepEnvSnapshotModel{}, false
+	}
+	return snapshot, true
+}
+
+// diffEnvValues returns the added, changed and removed envs, in key order
+func diffEnvValues(before, after map[string]string) []EnvDiffItemModel {
+	keys := []string{}
+	for key := range before {
+		keys = append(keys, key)
+	}
+	for key := range after {
+		if _, found := before[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	diff := []EnvDiffItemModel{}
+	for _, key := range keys {
+		beforeValue, isBefore := before[key]
+		afterValue, isAfter := after[key]
+
+		switch {
+		case isBefore && isAfter && beforeValue != afterValue:
+			diff = append(diff, EnvDiffItemModel{Key: key, Before: beforeValue, After: afterValue, Change: EnvChangeChanged})
+		case !isBefore && isAfter:
+			diff = append(diff, EnvDiffItemModel{Key: key, After: afterValue, Change: EnvChangeAdded})
+		case isBefore && !isAfter:
+			diff = append(diff, EnvDiffItemModel{Key: key, Before: beforeValue, Change: EnvChangeRemoved})
+		}
+	}
+
+	return diff
+}
+
+func truncatedEnvValue(value string) string {
+	value = strings.Replace(value, "\n", `\n`, -1)
+	if len(value) > maxStepEnvDiffValueLength {
+		return value[:maxStepEnvDiffValueLength] + "..."
+	}
+	return value
+}
+
+func formattedEnvDiff(diff []EnvDiffItemModel) []string {
+	lines := []string{}
+	for _, item := range diff {
+		switch item.Change {
+		case EnvChangeAdded:
+			lines = append(lines, fmt.Sprintf("  + %s: %s", item.Key, truncatedEnvValue(item.After)))
+		case EnvChangeChanged:
+			lines = append(lines, fmt.Sprintf("  ~ %s: %s -> %s", item.Key, truncatedEnvValue(item.Before), truncatedEnvValue(item.After)))
+		case EnvChangeRemoved:
+			lines = append(lines, fmt.Sprintf("  - %s: %s", item.Key, truncatedEnvValue(item.Before)))
+		}
+	}
+	return lines
+}
+
+// FormattedStepEnvDiff returns the changes of the step's inputs and environment (and version),
+// compared to its last successful run
+func FormattedStepEnvDiff(lastSuccessful, current StepEnvSnapshotModel) string {
+	lines := []string{fmt.Sprintf("Changes since the last successful run of the step (%s):", lastSuccessful.Time.Format(time.RFC3339))}
+
+	if lastSuccessful.Version != current.Version {
+		lines = append(lines, fmt.Sprintf("Version: %s -> %s", lastSuccessful.Version, current.Version))
+	}
+
+	inputsDiff := diffEnvValues(lastSuccessful.Inputs, current.Inputs)
+	if len(inputsDiff) > 0 {
+		lines = append(lines, "Inputs:")
+		lines = append(lines, formattedEnvDiff(inputsDiff)...)
+	}
+
+	envsDiff := diffEnvValues(lastSuccessful.Environments, current.Environments)
+	if len(envsDiff) > 0 {
+		lines = append(lines, "Environment:")
+		lines = append(lines, formattedEnvDiff(envsDiff)...)
+	}
+
+	if len(lines) == 1 {
+		lines = append(lines, "No version, input or environment changes")
+	}
+
+	return strings.Join(lines, "\n")
+}
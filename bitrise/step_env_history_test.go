@@ -0,0 +1,86 @@
+package bitrise
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepEnvHistory(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "step_env_history")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	history := NewStepEnvHistory(tmpDir, []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"API_TOKEN": "secret-token"},
+	})
+	key := StepEnvHistoryKey("/project", "primary", "Build", "xcode-archive")
+
+	t.Log("no successful run yet")
+	{
+		_, found := history.LastSuccessful(key)
+		require.Equal(t, false, found)
+	}
+
+	t.Log("secrets are not stored, volatile envs are skipped")
+	{
+		snapshot := history.NewSnapshot("xcode-archive", "2.0.0", []string{"scheme"}, envmanModels.EnvsJSONListModel{
+			"scheme":               "App",
+			"API_TOKEN":            "secret-token",
+			"AUTH_HEADER":          "Bearer secret-token",
+			"BITRISE_BUILD_NUMBER": "12",
+			"CONFIG":               "Release",
+		})
+
+		require.Equal(t, map[string]string{"scheme": "App"}, snapshot.Inputs)
+		require.Equal(t, 3, len(snapshot.Environments))
+		require.Equal(t, "Bearer [REDACTED]", snapshot.Environments["AUTH_HEADER"])
+		require.True(t, strings.HasPrefix(snapshot.Environments["API_TOKEN"], "[REDACTED] (fingerprint: "))
+		require.False(t, strings.Contains(snapshot.Environments["API_TOKEN"], "secret-token"))
+
+		require.NoError(t, history.Save(key, snapshot))
+
+		lastSuccessful, found := history.LastSuccessful(key)
+		require.Equal(t, true, found)
+		require.Equal(t, snapshot.Inputs, lastSuccessful.Inputs)
+		require.Equal(t, snapshot.Environments, lastSuccessful.Environments)
+	}
+
+	t.Log("diff against the last successful run")
+	{
+		lastSuccessful, found := history.LastSuccessful(key)
+		require.Equal(t, true, found)
+
+		otherHistory := NewStepEnvHistory(tmpDir, []envmanModels.EnvironmentItemModel{
+			envmanModels.EnvironmentItemModel{"API_TOKEN": "other-token"},
+		})
+		current := otherHistory.NewSnapshot("xcode-archive", "2.1.0", []string{"scheme", "export_method"}, envmanModels.EnvsJSONListModel{
+			"scheme":        "App-Beta",
+			"export_method": "ad-hoc",
+			"API_TOKEN":     "other-token",
+			"AUTH_HEADER":   "Bearer [REDACTED]",
+		})
+
+		diff := FormattedStepEnvDiff(lastSuccessful, current)
+		require.Contains(t, diff, "Version: 2.0.0 -> 2.1.0")
+		require.Contains(t, diff, "  + export_method: ad-hoc")
+		require.Contains(t, diff, "  ~ scheme: App -> App-Beta")
+		require.Contains(t, diff, "  ~ API_TOKEN: [REDACTED] (fingerprint: ")
+		require.Contains(t, diff, "  - CONFIG: Release")
+		require.NotContains(t, diff, "AUTH_HEADER")
+		require.NotContains(t, diff, "other-token")
+	}
+
+	t.Log("no changes")
+	{
+		lastSuccessful, _ := history.LastSuccessful(key)
+		diff := FormattedStepEnvDiff(lastSuccessful, lastSuccessful)
+		require.Contains(t, diff, "No version, input or environment changes")
+	}
+}
@@ -0,0 +1,123 @@
+package bitrise
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/pathutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"gopkg.in/yaml.v2"
+)
+
+// Local step test harness (bitrise step test): the local step is run by a generated, single step workflow,
+// so it's activated and executed exactly as the runner would do it (toolkit, dependencies, envstore),
+// then the envs exported by the step are checked against the expected outputs.
+
+// StepTestWorkflowID is the ID of the generated workflow, which runs the tested step
+const StepTestWorkflowID = "step-test"
+
+// StepOutputAssertionModel is an expected output of the tested step:
+// the step has to export the output, with the given value (if HasValue) or with any non empty value.
+type StepOutputAssertionModel struct {
+	Key      string
+	Value    string
+	HasValue bool
+}
+
+// ParseStepTestInputs returns the step inputs of the given KEY=VALUE items
+func ParseStepTestInputs(items []string) ([]envmanModels.EnvironmentItemModel, error) {
+	inputs := []envmanModels.EnvironmentItemModel{}
+	for _, item := range items {
+		split := strings.SplitN(item, "=", 2)
+		if len(split) != 2 || split[0] == "" {
+			return []envmanModels.EnvironmentItemModel{}, fmt.Errorf("invalid input (%s), should be in KEY=VALUE format", item)
+		}
+		inputs = append(inputs, envmanModels.EnvironmentItemModel{split[0]: split[1]})
+	}
+	return inputs, nil
+}
+
+// ParseStepOutputAssertions returns the expected outputs of the given KEY=VALUE or KEY items
+func ParseStepOutputAssertions(items []string) ([]StepOutputAssertionModel, error) {
+	assertions := []StepOutputAssertionModel{}
+	for _, item := range items {
+		split := strings.SplitN(item, "=", 2)
+		if split[0] == "" {
+			return []StepOutputAssertionModel{}, fmt.Errorf("invalid output (%s), should be in KEY=VALUE or KEY format", item)
+		}
+
+		assertion := StepOutputAssertionModel{Key: split[0]}
+		if len(split) == 2 {
+			assertion.Value = split[1]
+			assertion.HasValue = true
+		}
+		assertions = append(assertions, assertion)
+	}
+	return assertions, nil
+}
+
+// StepTestConfig returns the config, which runs the step of the given directory with the given inputs,
+// in its StepTestWorkflowID workflow.
+func StepTestConfig(stepDir string, inputs []envmanModels.EnvironmentItemModel) (models.BitriseDataModel, error) {
+	absStepDir, err := pathutil.AbsPath(stepDir)
+	if err != nil {
+		return models.BitriseDataModel{}, fmt.Errorf("Failed to get absolute path of step dir (%s), error: %s", stepDir, err)
+	}
+	if exist, err := pathutil.IsPathExists(filepath.Join(absStepDir, "step.yml")); err != nil {
+		return models.BitriseDataModel{}, err
+	} else if !exist {
+		return models.BitriseDataModel{}, fmt.Errorf("No step.yml found in step dir (%s)", absStepDir)
+	}
+
+	config := models.BitriseDataModel{
+		FormatVersion: models.Version,
+		Workflows: map[string]models.WorkflowModel{
+			StepTestWorkflowID: models.WorkflowModel{
+				Steps: []models.StepListItemModel{
+					models.StepListItemModel{"path::" + absStepDir: models.WorkflowStepModel{StepModel: stepmanModels.StepModel{Inputs: inputs}}},
+				},
+			},
+		},
+	}
+
+	configBytes, err := yaml.Marshal(config)
+	if err != nil {
+		return models.BitriseDataModel{}, fmt.Errorf("Failed to serialize step test config, error: %s", err)
+	}
+
+	config, warnings, err := ConfigModelFromYAMLBytes(configBytes)
+	if err != nil {
+		return models.BitriseDataModel{}, fmt.Errorf("Invalid step test config, error: %s", err)
+	}
+	if len(warnings) > 0 {
+		return models.BitriseDataModel{}, fmt.Errorf("Invalid step test config, warnings: %s", strings.Join(warnings, ", "))
+	}
+	return config, nil
+}
+
+// CheckStepOutputAssertions returns the failed assertions, checked against the envs of the finished run
+// (the last value of an env is the effective one).
+func CheckStepOutputAssertions(assertions []StepOutputAssertionModel, environments []envmanModels.EnvironmentItemModel) ([]string, error) {
+	values := map[string]string{}
+	for _, env := range environments {
+		key, value, err := env.GetKeyValuePair()
+		if err != nil {
+			return []string{}, err
+		}
+		values[key] = value
+	}
+
+	failures := []string{}
+	for _, assertion := range assertions {
+		value, found := values[assertion.Key]
+		if !found || (!assertion.HasValue && value == "") {
+			failures = append(failures, fmt.Sprintf("output (%s) is not exported", assertion.Key))
+		} else if assertion.HasValue && value != assertion.Value {
+			failures = append(failures, fmt.Sprintf("output (%s) value (%s) is not the expected value (%s)", assertion.Key, value, assertion.Value))
+		}
+	}
+	return failures, nil
+}
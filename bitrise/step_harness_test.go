@@ -0,0 +1,74 @@
+package bitrise
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStepTestInputs(t *testing.T) {
+	inputs, err := ParseStepTestInputs([]string{"mode=debug", "message=a=b", "empty="})
+	require.NoError(t, err)
+	require.Equal(t, []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"mode": "debug"},
+		envmanModels.EnvironmentItemModel{"message": "a=b"},
+		envmanModels.EnvironmentItemModel{"empty": ""},
+	}, inputs)
+
+	_, err = ParseStepTestInputs([]string{"mode"})
+	require.EqualError(t, err, "invalid input (mode), should be in KEY=VALUE format")
+}
+
+func TestCheckStepOutputAssertions(t *testing.T) {
+	assertions, err := ParseStepOutputAssertions([]string{"OUT_PATH", "OUT_MODE=release", "OUT_MISSING", "OUT_EMPTY"})
+	require.NoError(t, err)
+	require.Equal(t, StepOutputAssertionModel{Key: "OUT_MODE", Value: "release", HasValue: true}, assertions[1])
+
+	failures, err := CheckStepOutputAssertions(assertions, []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"OUT_PATH": "/tmp/out"},
+		envmanModels.EnvironmentItemModel{"OUT_MODE": "release"},
+		envmanModels.EnvironmentItemModel{"OUT_MODE": "debug"},
+		envmanModels.EnvironmentItemModel{"OUT_EMPTY": ""},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"output (OUT_MODE) value (debug) is not the expected value (release)",
+		"output (OUT_MISSING) is not exported",
+		"output (OUT_EMPTY) is not exported",
+	}, failures)
+
+	_, err = ParseStepOutputAssertions([]string{"=value"})
+	require.EqualError(t, err, "invalid output (=value), should be in KEY=VALUE or KEY format")
+}
+
+func TestStepTestConfig(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("step_harness")
+	require.NoError(t, err)
+
+	_, err = StepTestConfig(tmpDir, []envmanModels.EnvironmentItemModel{})
+	require.EqualError(t, err, "No step.yml found in step dir ("+tmpDir+")")
+
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "step.yml"), testStepInputsStepYML))
+	config, err := StepTestConfig(tmpDir, []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"api_token": "token"},
+	})
+	require.NoError(t, err)
+
+	workflow, found := config.Workflows[StepTestWorkflowID]
+	require.True(t, found)
+	require.Equal(t, 1, len(workflow.Steps))
+
+	stepID, step, err := models.GetStepIDStepDataPair(workflow.Steps[0])
+	require.NoError(t, err)
+	require.Equal(t, "path::"+tmpDir, stepID)
+	require.Equal(t, 1, len(step.Inputs))
+	key, value, err := step.Inputs[0].GetKeyValuePair()
+	require.NoError(t, err)
+	require.Equal(t, "api_token", key)
+	require.Equal(t, "token", value)
+}
@@ -0,0 +1,180 @@
+package bitrise
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/tools"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// Step input validation: the inputs of the workflow steps are checked against the step's definition (step.yml),
+// before the workflow runs, so a misconfigured step fails the build before any of the steps is executed,
+// instead of at the step's runtime.
+
+// ValidateStepInputs checks the inputs of the workflow step against the inputs, defined by the step:
+// every input has to be defined by the step, the values have to respect the input's value_options
+// and the required inputs have to have a value (set by the workflow step, or by the input's default value).
+// The values referencing an env ($ENV_KEY) are resolved at runtime, these are not checked against the value_options.
+func ValidateStepInputs(specStep, workflowStep stepmanModels.StepModel) ([]string, error) {
+	specValues := map[string]string{}
+	specValueOptions := map[string][]string{}
+	specKeys := []string{}
+	requiredKeys := map[string]bool{}
+	for _, input := range specStep.Inputs {
+		key, value, err := input.GetKeyValuePair()
+		if err != nil {
+			return []string{}, err
+		}
+		options, err := input.GetOptions()
+		if err != nil {
+			return []string{}, err
+		}
+
+		specKeys = append(specKeys, key)
+		specValues[key] = value
+		specValueOptions[key] = options.ValueOptions
+		requiredKeys[key] = options.IsRequired != nil && *options.IsRequired
+	}
+
+	issues := []string{}
+	values := map[string]string{}
+	for key, value := range specValues {
+		values[key] = value
+	}
+	for _, input := range workflowStep.Inputs {
+		key, value, err := input.GetKeyValuePair()
+		if err != nil {
+			return []string{}, err
+		}
+
+		if _, found := specValues[key]; !found {
+			issues = append(issues, fmt.Sprintf("input (%s) is not defined by the step", key))
+			continue
+		}
+		values[key] = value
+
+		valueOptions := specValueOptions[key]
+		if value == "" || len(valueOptions) == 0 || strings.Contains(value, "$") {
+			continue
+		}
+		isValueOption := false
+		for _, option := range valueOptions {
+			if option == value {
+				isValueOption = true
+				break
+			}
+		}
+		if !isValueOption {
+			issues = append(issues, fmt.Sprintf("input (%s) value (%s) is not one of the value_options: %s",
+				key, value, strings.Join(valueOptions, ", ")))
+		}
+	}
+
+	for _, key := range specKeys {
+		if requiredKeys[key] && values[key] == "" {
+			issues = append(issues, fmt.Sprintf("required input (%s) is not set", key))
+		}
+	}
+
+	return issues, nil
+}
+
+// StepDefinition returns the definition (step.yml) of the given step,
+// read from the local StepLib, or from the local step's directory.
+// The git, OCI artifact, archive and the steplib independent steps are not checked (found is false),
+// these would have to be cloned / pulled first, or these don't have a definition at all.
+func StepDefinition(stepIDData models.StepIDData) (stepmanModels.StepModel, bool, error) {
+	switch stepIDData.SteplibSource {
+	case "path":
+		stepYMLPth := filepath.Join(stepIDData.IDorURI, "step.yml")
+		specStep, err := ReadSpecStep(stepYMLPth)
+		if err != nil {
+			return stepmanModels.StepModel{}, false, err
+		}
+		return specStep, true, nil
+	case "git", "_", "", models.OCIStepSource, models.ArchiveStepSource:
+		return stepmanModels.StepModel{}, false, nil
+	}
+
+	if err := tools.StepmanSetup(stepIDData.SteplibSource); err != nil {
+		return stepmanModels.StepModel{}, false, fmt.Errorf("Failed to setup steplib (%s), error: %s", stepIDData.SteplibSource, err)
+	}
+	stepIDData, err := ResolveStepVersion(stepIDData)
+	if err != nil {
+		return stepmanModels.StepModel{}, false, err
+	}
+	specStep, err := tools.StepmanStepLibStep(stepIDData.SteplibSource, stepIDData.IDorURI, stepIDData.Version)
+	if err != nil {
+		return stepmanModels.StepModel{}, false, err
+	}
+	return specStep, true, nil
+}
+
+// ValidateWorkflowStepInputs validates the step inputs (see: ValidateStepInputs)
+// of every step of the given workflows.
+// The returned issues are prefixed with the workflow ID, the step's index and its ID.
+// The steps, whose definition can not be fetched (e.g. the StepLib is not reachable) are not checked,
+// these are reported as warnings, the step's activation will fail with the actual error, if the step runs.
+func ValidateWorkflowStepInputs(config models.BitriseDataModel, workflowIDs []string) ([]string, []string, error) {
+	issues := []string{}
+	warnings := []string{}
+	for _, workflowID := range workflowIDs {
+		workflow, found := config.Workflows[workflowID]
+		if !found {
+			return []string{}, []string{}, fmt.Errorf("Specified Workflow (%s) does not exist!", workflowID)
+		}
+
+		for idx, stepListItem := range workflow.Steps {
+			compositeStepIDStr, workflowStep, err := models.GetStepIDStepDataPair(stepListItem)
+			if err != nil {
+				return []string{}, []string{}, err
+			}
+			stepIDData, err := models.CreateStepIDDataFromString(compositeStepIDStr, config.DefaultStepLibSource)
+			if err != nil {
+				return []string{}, []string{}, err
+			}
+			if sources := config.StepLibSearchList(); len(sources) > 1 {
+				if stepIDData, err = ResolveStepIDData(compositeStepIDStr, sources); err != nil {
+					warnings = append(warnings, fmt.Sprintf("workflow (%s) step #%d (%s): inputs are not checked, failed to resolve the step's StepLib, error: %s",
+						workflowID, idx+1, compositeStepIDStr, err))
+					continue
+				}
+			}
+
+			specStep, found, err := StepDefinition(stepIDData)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("workflow (%s) step #%d (%s): inputs are not checked, failed to get the step's definition, error: %s",
+					workflowID, idx+1, compositeStepIDStr, err))
+				continue
+			}
+			if !found {
+				log.Debugf("Step (%s) has no definition to check its inputs against", compositeStepIDStr)
+				continue
+			}
+
+			stepIssues, err := ValidateStepInputs(specStep, workflowStep.StepModel)
+			if err != nil {
+				return []string{}, []string{}, fmt.Errorf("Failed to validate the inputs of step (%s), error: %s", compositeStepIDStr, err)
+			}
+			for _, issue := range stepIssues {
+				issues = append(issues, fmt.Sprintf("workflow (%s) step #%d (%s): %s", workflowID, idx+1, compositeStepIDStr, issue))
+			}
+		}
+	}
+	return issues, warnings, nil
+}
+
+// SortedWorkflowIDs returns the IDs of the config's workflows, in alphabetical order.
+func SortedWorkflowIDs(config models.BitriseDataModel) []string {
+	workflowIDs := []string{}
+	for workflowID := range config.Workflows {
+		workflowIDs = append(workflowIDs, workflowID)
+	}
+	sort.Strings(workflowIDs)
+	return workflowIDs
+}
@@ -0,0 +1,146 @@
+package bitrise
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+const testStepInputsStepYML = `
+title: Test step
+inputs:
+- mode: debug
+  opts:
+    value_options:
+    - debug
+    - release
+- api_token:
+  opts:
+    is_required: true
+- message:
+`
+
+func testSpecStep(t *testing.T) stepmanModels.StepModel {
+	specStep := stepmanModels.StepModel{}
+	require.NoError(t, yaml.Unmarshal([]byte(testStepInputsStepYML), &specStep))
+	require.NoError(t, specStep.Normalize())
+	require.NoError(t, specStep.FillMissingDefaults())
+	return specStep
+}
+
+func testWorkflowStep(t *testing.T, stepYML string) stepmanModels.StepModel {
+	workflowStep := stepmanModels.StepModel{}
+	require.NoError(t, yaml.Unmarshal([]byte(stepYML), &workflowStep))
+	require.NoError(t, workflowStep.Normalize())
+	return workflowStep
+}
+
+func TestValidateStepInputs(t *testing.T) {
+	t.Log("valid inputs")
+	{
+		workflowStep := testWorkflowStep(t, `
+inputs:
+- mode: release
+- api_token: $API_TOKEN
+`)
+		issues, err := ValidateStepInputs(testSpecStep(t), workflowStep)
+		require.NoError(t, err)
+		require.Equal(t, []string{}, issues)
+	}
+
+	t.Log("env reference is not checked against the value_options")
+	{
+		workflowStep := testWorkflowStep(t, `
+inputs:
+- mode: $BUILD_MODE
+- api_token: token
+`)
+		issues, err := ValidateStepInputs(testSpecStep(t), workflowStep)
+		require.NoError(t, err)
+		require.Equal(t, []string{}, issues)
+	}
+
+	t.Log("unknown input, invalid value option and missing required input")
+	{
+		workflowStep := testWorkflowStep(t, `
+inputs:
+- mode: profile
+- mesage: typo
+`)
+		issues, err := ValidateStepInputs(testSpecStep(t), workflowStep)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"input (mode) value (profile) is not one of the value_options: debug, release",
+			"input (mesage) is not defined by the step",
+			"required input (api_token) is not set",
+		}, issues)
+	}
+}
+
+func TestValidateWorkflowStepInputs(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("step_input_validation")
+	require.NoError(t, err)
+	stepDir := filepath.Join(tmpDir, "my-step")
+	require.NoError(t, pathutil.EnsureDirExist(stepDir))
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(stepDir, "step.yml"), testStepInputsStepYML))
+
+	configStr := `
+format_version: 1.3.0
+
+workflows:
+  before:
+    steps:
+    - path::` + stepDir + `:
+        inputs:
+        - api_token: token
+  primary:
+    before_run:
+    - before
+    steps:
+    - script:
+        inputs:
+        - not_checked: value
+    - path::` + stepDir + `:
+        inputs:
+        - mode: profile
+`
+	config, warnings, err := ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+	config.DefaultStepLibSource = "_"
+
+	issues, warnings, err := ValidateWorkflowStepInputs(config, []string{"before", "primary"})
+	require.NoError(t, err)
+	require.Equal(t, []string{}, warnings)
+	require.Equal(t, []string{
+		"workflow (primary) step #2 (path::" + stepDir + "): input (mode) value (profile) is not one of the value_options: debug, release",
+		"workflow (primary) step #2 (path::" + stepDir + "): required input (api_token) is not set",
+	}, issues)
+
+	t.Log("missing workflow")
+	{
+		_, _, err := ValidateWorkflowStepInputs(config, []string{"missing"})
+		require.EqualError(t, err, "Specified Workflow (missing) does not exist!")
+	}
+
+	t.Log("missing step definition is a warning")
+	{
+		config.Workflows["primary"] = models.WorkflowModel{
+			Steps: []models.StepListItemModel{
+				models.StepListItemModel{"path::" + filepath.Join(tmpDir, "missing-step"): models.WorkflowStepModel{}},
+			},
+		}
+
+		issues, warnings, err := ValidateWorkflowStepInputs(config, []string{"primary"})
+		require.NoError(t, err)
+		require.Equal(t, []string{}, issues)
+		require.Equal(t, 1, len(warnings))
+		require.Contains(t, warnings[0], "workflow (primary) step #1 (path::"+filepath.Join(tmpDir, "missing-step")+"): inputs are not checked")
+	}
+}
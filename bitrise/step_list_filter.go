@@ -0,0 +1,170 @@
+package bitrise
+
+import (
+	"sort"
+	"strings"
+
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	ver "github.com/hashicorp/go-version"
+)
+
+// Filtered step list: the steps of the StepLib spec, filtered by the latest version's properties:
+//  * category: one of the step's type_tags
+//  * maintainer: the owner of the step's source repository (e.g. bitrise-io, of github.com/bitrise-io/steps-script)
+//  * toolkit: bash (default) or go
+//  * deprecated: whether the step is deprecated (has a removal date or deprecate notes)
+// The string filters are case insensitive, an empty filter matches every step.
+
+// StepListFilterModel ...
+type StepListFilterModel struct {
+	Category   string
+	Maintainer string
+	Toolkit    string
+	// Deprecated is nil, if the steps are not filtered by deprecation
+	Deprecated *bool
+}
+
+// IsEmpty ...
+func (filter StepListFilterModel) IsEmpty() bool {
+	return filter.Category == "" && filter.Maintainer == "" && filter.Toolkit == "" && filter.Deprecated == nil
+}
+
+// StepListEntryModel is a step of the filtered step list
+type StepListEntryModel struct {
+	ID         string   `json:"id"`
+	Version    string   `json:"version"`
+	Title      string   `json:"title,omitempty"`
+	Summary    string   `json:"summary,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+	Maintainer string   `json:"maintainer,omitempty"`
+	Toolkit    string   `json:"toolkit"`
+	Deprecated bool     `json:"deprecated,omitempty"`
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// StepToolkitName returns the name of the step's toolkit (bash, if the step doesn't declare any)
+func StepToolkitName(step stepmanModels.StepModel) string {
+	if step.Toolkit != nil && step.Toolkit.Go != nil {
+		return StepToolkitGo
+	}
+	return StepToolkitBash
+}
+
+// StepMaintainer returns the owner of the step's source repository, or an empty string, if it can't be determined
+func StepMaintainer(step stepmanModels.StepModel) string {
+	sourceURL := step.Source.Git
+	if sourceURL == "" {
+		sourceURL = stringValue(step.SourceCodeURL)
+	}
+	if sourceURL == "" {
+		return ""
+	}
+
+	// https://github.com/owner/repo.git, git@github.com:owner/repo.git
+	pth := sourceURL
+	if idx := strings.Index(pth, "://"); idx != -1 {
+		pth = pth[idx+3:]
+	} else if idx := strings.Index(pth, ":"); idx != -1 {
+		pth = "host/" + pth[idx+1:]
+	}
+
+	split := strings.Split(strings.Trim(pth, "/"), "/")
+	if len(split) < 3 {
+		return ""
+	}
+	return split[1]
+}
+
+func latestStepVersion(stepGroup stepmanModels.StepGroupModel) (string, stepmanModels.StepModel, bool) {
+	if step, found := stepGroup.Versions[stepGroup.LatestVersionNumber]; found {
+		return stepGroup.LatestVersionNumber, step, true
+	}
+
+	var latest *ver.Version
+	latestStr := ""
+	for versionStr := range stepGroup.Versions {
+		version, err := ver.NewVersion(versionStr)
+		if err != nil {
+			continue
+		}
+		if latest == nil || version.GreaterThan(latest) {
+			latest = version
+			latestStr = versionStr
+		}
+	}
+	if latest == nil {
+		return "", stepmanModels.StepModel{}, false
+	}
+	return latestStr, stepGroup.Versions[latestStr], true
+}
+
+// newStepListEntry returns the list entry of the step's latest version, and the latest version's definition
+func newStepListEntry(stepID string, stepGroup stepmanModels.StepGroupModel) (StepListEntryModel, stepmanModels.StepModel, bool) {
+	version, step, found := latestStepVersion(stepGroup)
+	if !found {
+		return StepListEntryModel{}, stepmanModels.StepModel{}, false
+	}
+
+	return StepListEntryModel{
+		ID:         stepID,
+		Version:    version,
+		Title:      stringValue(step.Title),
+		Summary:    stringValue(step.Summary),
+		Categories: step.TypeTags,
+		Maintainer: StepMaintainer(step),
+		Toolkit:    StepToolkitName(step),
+		Deprecated: stepGroup.Info.RemovalDate != "" || stepGroup.Info.DeprecateNotes != "",
+	}, step, true
+}
+
+func (filter StepListFilterModel) matches(entry StepListEntryModel) bool {
+	if filter.Category != "" {
+		found := false
+		for _, category := range entry.Categories {
+			if strings.EqualFold(category, filter.Category) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if filter.Maintainer != "" && !strings.EqualFold(entry.Maintainer, filter.Maintainer) {
+		return false
+	}
+	if filter.Toolkit != "" && !strings.EqualFold(entry.Toolkit, filter.Toolkit) {
+		return false
+	}
+	if filter.Deprecated != nil && entry.Deprecated != *filter.Deprecated {
+		return false
+	}
+	return true
+}
+
+// FilterStepLibSteps returns the steps of the StepLib spec, which match the filter, ordered by step ID
+func FilterStepLibSteps(spec stepmanModels.StepCollectionModel, filter StepListFilterModel) []StepListEntryModel {
+	stepIDs := []string{}
+	for stepID := range spec.Steps {
+		stepIDs = append(stepIDs, stepID)
+	}
+	sort.Strings(stepIDs)
+
+	entries := []StepListEntryModel{}
+	for _, stepID := range stepIDs {
+		entry, _, found := newStepListEntry(stepID, spec.Steps[stepID])
+		if !found {
+			continue
+		}
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
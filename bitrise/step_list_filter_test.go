@@ -0,0 +1,78 @@
+package bitrise
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-utils/pointers"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepMaintainer(t *testing.T) {
+	require.Equal(t, "bitrise-io", StepMaintainer(stepmanModels.StepModel{Source: stepmanModels.StepSourceModel{Git: "https://github.com/bitrise-io/steps-script.git"}}))
+	require.Equal(t, "my-org", StepMaintainer(stepmanModels.StepModel{Source: stepmanModels.StepSourceModel{Git: "git@github.com:my-org/steps-build.git"}}))
+	require.Equal(t, "other-org", StepMaintainer(stepmanModels.StepModel{SourceCodeURL: pointers.NewStringPtr("https://gitlab.com/other-org/steps-deploy")}))
+	require.Equal(t, "", StepMaintainer(stepmanModels.StepModel{}))
+}
+
+func TestFilterStepLibSteps(t *testing.T) {
+	spec := stepmanModels.StepCollectionModel{
+		Steps: stepmanModels.StepHash{
+			"script": stepmanModels.StepGroupModel{
+				LatestVersionNumber: "1.1.3",
+				Versions: map[string]stepmanModels.StepModel{
+					"1.1.3": stepmanModels.StepModel{
+						Title:    pointers.NewStringPtr("Script"),
+						TypeTags: []string{"utility"},
+						Source:   stepmanModels.StepSourceModel{Git: "https://github.com/bitrise-io/steps-script.git"},
+					},
+				},
+			},
+			"go-build": stepmanModels.StepGroupModel{
+				Versions: map[string]stepmanModels.StepModel{
+					"0.9.0": stepmanModels.StepModel{TypeTags: []string{"build"}},
+					"1.0.0": stepmanModels.StepModel{
+						TypeTags: []string{"Build", "utility"},
+						Toolkit:  &stepmanModels.StepToolkitModel{Go: &stepmanModels.GoStepToolkitModel{PackageName: "github.com/my-org/go-build"}},
+						Source:   stepmanModels.StepSourceModel{Git: "https://github.com/my-org/steps-go-build.git"},
+					},
+				},
+			},
+			"old-deploy": stepmanModels.StepGroupModel{
+				Info:                stepmanModels.StepGroupInfoModel{DeprecateNotes: "Use deploy instead"},
+				LatestVersionNumber: "2.0.0",
+				Versions: map[string]stepmanModels.StepModel{
+					"2.0.0": stepmanModels.StepModel{
+						TypeTags: []string{"deploy"},
+						Source:   stepmanModels.StepSourceModel{Git: "https://github.com/bitrise-io/steps-old-deploy.git"},
+					},
+				},
+			},
+		},
+	}
+
+	stepIDs := func(entries []StepListEntryModel) []string {
+		ids := []string{}
+		for _, entry := range entries {
+			ids = append(ids, entry.ID)
+		}
+		return ids
+	}
+
+	all := FilterStepLibSteps(spec, StepListFilterModel{})
+	require.Equal(t, []string{"go-build", "old-deploy", "script"}, stepIDs(all))
+	require.Equal(t, StepListEntryModel{
+		ID:         "go-build",
+		Version:    "1.0.0",
+		Categories: []string{"Build", "utility"},
+		Maintainer: "my-org",
+		Toolkit:    "go",
+	}, all[0])
+
+	require.Equal(t, []string{"go-build", "script"}, stepIDs(FilterStepLibSteps(spec, StepListFilterModel{Category: "utility"})))
+	require.Equal(t, []string{"go-build"}, stepIDs(FilterStepLibSteps(spec, StepListFilterModel{Category: "build"})))
+	require.Equal(t, []string{"old-deploy", "script"}, stepIDs(FilterStepLibSteps(spec, StepListFilterModel{Maintainer: "Bitrise-IO"})))
+	require.Equal(t, []string{"old-deploy", "script"}, stepIDs(FilterStepLibSteps(spec, StepListFilterModel{Toolkit: "bash"})))
+	require.Equal(t, []string{"old-deploy"}, stepIDs(FilterStepLibSteps(spec, StepListFilterModel{Deprecated: pointers.NewBoolPtr(true)})))
+	require.Equal(t, []string{"script"}, stepIDs(FilterStepLibSteps(spec, StepListFilterModel{Maintainer: "bitrise-io", Deprecated: pointers.NewBoolPtr(false)})))
+}
@@ -0,0 +1,56 @@
+package bitrise
+
+import (
+	envmanModels "github.com/bitrise-io/envman/models"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// Step output contract: the outputs marked as required (opts: is_required: true),
+// either in the step.yml or in the workflow step's outputs, have to be exported by the step.
+// A successful step, which doesn't export one of its required outputs fails,
+// so a later step doesn't run with a silently missing env.
+
+// ExpectedStepOutputs returns the keys of the required outputs of the steps (e.g. the merged step and the workflow step),
+// in declaration order, without duplicates.
+func ExpectedStepOutputs(steps ...stepmanModels.StepModel) ([]string, error) {
+	keys := []string{}
+	collected := map[string]bool{}
+	for _, step := range steps {
+		for _, output := range step.Outputs {
+			key, _, err := output.GetKeyValuePair()
+			if err != nil {
+				return []string{}, err
+			}
+			options, err := output.GetOptions()
+			if err != nil {
+				return []string{}, err
+			}
+
+			if options.IsRequired != nil && *options.IsRequired && !collected[key] {
+				collected[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys, nil
+}
+
+// MissingStepOutputs returns the expected output keys, which are not exported by the step (not found in the step's output envs).
+func MissingStepOutputs(expectedKeys []string, outputs []envmanModels.EnvironmentItemModel) ([]string, error) {
+	exported := map[string]bool{}
+	for _, output := range outputs {
+		key, _, err := output.GetKeyValuePair()
+		if err != nil {
+			return []string{}, err
+		}
+		exported[key] = true
+	}
+
+	missing := []string{}
+	for _, key := range expectedKeys {
+		if !exported[key] {
+			missing = append(missing, key)
+		}
+	}
+	return missing, nil
+}
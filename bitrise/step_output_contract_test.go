@@ -0,0 +1,68 @@
+package bitrise
+
+import (
+	"testing"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestExpectedStepOutputs(t *testing.T) {
+	step := stepmanModels.StepModel{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+outputs:
+- BUILD_PATH:
+  opts:
+    is_required: true
+- OPTIONAL_LOG:
+`), &step))
+	require.NoError(t, step.Normalize())
+
+	workflowStep := stepmanModels.StepModel{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+outputs:
+- BUILD_PATH:
+  opts:
+    is_required: true
+- OPTIONAL_LOG:
+  opts:
+    is_required: true
+`), &workflowStep))
+	require.NoError(t, workflowStep.Normalize())
+
+	t.Log("step.yml outputs")
+	{
+		keys, err := ExpectedStepOutputs(step)
+		require.NoError(t, err)
+		require.Equal(t, []string{"BUILD_PATH"}, keys)
+	}
+
+	t.Log("step.yml and workflow step outputs")
+	{
+		keys, err := ExpectedStepOutputs(step, workflowStep)
+		require.NoError(t, err)
+		require.Equal(t, []string{"BUILD_PATH", "OPTIONAL_LOG"}, keys)
+	}
+}
+
+func TestMissingStepOutputs(t *testing.T) {
+	outputs := []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"BUILD_PATH": "./build"},
+	}
+
+	t.Log("every expected output is exported")
+	{
+		missing, err := MissingStepOutputs([]string{"BUILD_PATH"}, outputs)
+		require.NoError(t, err)
+		require.Equal(t, []string{}, missing)
+	}
+
+	t.Log("missing output")
+	{
+		missing, err := MissingStepOutputs([]string{"BUILD_PATH", "OPTIONAL_LOG"}, outputs)
+		require.NoError(t, err)
+		require.Equal(t, []string{"OPTIONAL_LOG"}, missing)
+	}
+}
@@ -0,0 +1,289 @@
+package bitrise
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/tools"
+	"github.com/bitrise-io/go-utils/cmdex"
+)
+
+// Step pre-activation: before running a workflow, the referenced steps are activated (downloaded / cloned)
+// concurrently, so the network-bound activation doesn't serialize with the step executions.
+// The steps are activated into their own directory, the run copies the step from there,
+// instead of activating it again. A failed pre-activation is not an error,
+// the step will be activated the usual way, when it runs.
+
+const (
+	defaultStepPreActivationWorkers = 4
+	preActivatedStepsDirName        = "preactivated_steps"
+)
+
+// PreActivatedStepModel ...
+type PreActivatedStepModel struct {
+	StepDir string
+	// StepYMLPth is empty for the steplib independent steps
+	StepYMLPth string
+}
+
+var (
+	preActivatedStepsMutex sync.Mutex
+	preActivatedSteps      = map[string]PreActivatedStepModel{}
+)
+
+func preActivationKey(stepIDData models.StepIDData) string {
+	return stepIDData.SteplibSource + "::" + stepIDData.IDorURI + "@" + stepIDData.Version
+}
+
+// StepPreActivationWorkersFromEnv returns the worker count of the pre-activation,
+// configured by the BITRISE_STEP_PREACTIVATION_WORKERS env (0 disables the pre-activation).
+func StepPreActivationWorkersFromEnv() (int, error) {
+	value := os.Getenv(configs.StepPreActivationWorkersEnvKey)
+	if value == "" {
+		return defaultStepPreActivationWorkers, nil
+	}
+
+	workers, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value (%s), error: %s", configs.StepPreActivationWorkersEnvKey, value, err)
+	}
+	if workers < 0 {
+		return 0, fmt.Errorf("invalid %s value (%s), should not be negative", configs.StepPreActivationWorkersEnvKey, value)
+	}
+	return workers, nil
+}
+
+// CollectWorkflowSteps returns the unique steps of the workflow, including its before and after run workflows.
+func CollectWorkflowSteps(config models.BitriseDataModel, workflowID string) ([]models.StepIDData, error) {
+	stepIDDatas := []models.StepIDData{}
+	collected := map[string]bool{}
+
+	var collect func(workflowID string) error
+	collect = func(workflowID string) error {
+		workflow, found := config.Workflows[workflowID]
+		if !found {
+			return fmt.Errorf("Specified Workflow (%s) does not exist!", workflowID)
+		}
+
+		for _, beforeWorkflow := range workflow.BeforeRun {
+			if err := collect(beforeWorkflow.WorkflowID); err != nil {
+				return err
+			}
+		}
+
+		for _, stepListItem := range workflow.Steps {
+			compositeStepIDStr, _, err := models.GetStepIDStepDataPair(stepListItem)
+			if err != nil {
+				return err
+			}
+			stepIDData, err := models.CreateStepIDDataFromString(compositeStepIDStr, config.DefaultStepLibSource)
+			if err != nil {
+				return err
+			}
+
+			key := preActivationKey(stepIDData)
+			if !collected[key] {
+				collected[key] = true
+				stepIDDatas = append(stepIDDatas, stepIDData)
+			}
+		}
+
+		for _, afterWorkflow := range workflow.AfterRun {
+			if err := collect(afterWorkflow.WorkflowID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := collect(workflowID); err != nil {
+		return []models.StepIDData{}, err
+	}
+	return stepIDDatas, nil
+}
+
+// isPreActivatable returns false for the local steps (nothing to download)
+// and for the steplib steps without version or with a version constraint
+// (the StepLib might be updated, before the latest / matching version is resolved).
+func isPreActivatable(stepIDData models.StepIDData) bool {
+	switch stepIDData.SteplibSource {
+	case "", "path":
+		return false
+	case "git", "_", models.OCIStepSource, models.ArchiveStepSource:
+		return true
+	}
+	return stepIDData.Version != "" && !IsStepVersionConstraint(stepIDData.Version)
+}
+
+func preActivateStep(stepIDData models.StepIDData, dir string) (PreActivatedStepModel, error) {
+	stepDir := filepath.Join(dir, "step_src")
+	stepYMLPth := filepath.Join(dir, "step.yml")
+
+	switch stepIDData.SteplibSource {
+	case "git":
+		if err := tools.GitCloneTagOrBranch(stepIDData.IDorURI, stepDir, stepIDData.Version); err != nil {
+			return PreActivatedStepModel{}, err
+		}
+		stepYMLPth = filepath.Join(stepDir, "step.yml")
+	case "_":
+		if err := tools.GitCloneTagOrBranch(stepIDData.IDorURI, stepDir, stepIDData.Version); err != nil {
+			return PreActivatedStepModel{}, err
+		}
+		stepYMLPth = ""
+	case models.OCIStepSource:
+		if err := ActivateOCIStep(stepIDData, stepDir, stepYMLPth); err != nil {
+			return PreActivatedStepModel{}, err
+		}
+	case models.ArchiveStepSource:
+		if err := ActivateArchiveStep(stepIDData, stepDir, stepYMLPth); err != nil {
+			return PreActivatedStepModel{}, err
+		}
+	default:
+		if err := tools.StepmanActivate(stepIDData.SteplibSource, stepIDData.IDorURI, stepIDData.Version, stepDir, stepYMLPth); err != nil {
+			return PreActivatedStepModel{}, err
+		}
+	}
+
+	return PreActivatedStepModel{StepDir: stepDir, StepYMLPth: stepYMLPth}, nil
+}
+
+// PreActivateSteps activates the given steps concurrently, with the given number of workers.
+// Returns the number of the pre-activated steps.
+func PreActivateSteps(stepIDDatas []models.StepIDData, workerCount int) int {
+	if workerCount <= 0 {
+		return 0
+	}
+
+	toActivate := []models.StepIDData{}
+	setupSteplibs := map[string]bool{}
+	for _, stepIDData := range stepIDDatas {
+		if !isPreActivatable(stepIDData) {
+			continue
+		}
+		if _, found := PreActivatedStep(stepIDData); found {
+			continue
+		}
+
+		if stepIDData.SteplibSource != "git" && stepIDData.SteplibSource != "_" && stepIDData.SteplibSource != models.OCIStepSource && stepIDData.SteplibSource != models.ArchiveStepSource {
+			// the StepLib setup is not safe to run concurrently, for the same StepLib
+			isSetup, checked := setupSteplibs[stepIDData.SteplibSource]
+			if !checked {
+				if err := tools.StepmanSetup(stepIDData.SteplibSource); err != nil {
+					log.Debugf("Failed to setup StepLib (%s) for pre-activation, error: %s", stepIDData.SteplibSource, err)
+				} else {
+					isSetup = true
+				}
+				setupSteplibs[stepIDData.SteplibSource] = isSetup
+			}
+			if !isSetup {
+				continue
+			}
+		}
+
+		toActivate = append(toActivate, stepIDData)
+	}
+
+	if len(toActivate) == 0 {
+		return 0
+	}
+	if workerCount > len(toActivate) {
+		workerCount = len(toActivate)
+	}
+
+	log.Infof("Pre-activating %d steps ...", len(toActivate))
+
+	preActivationDir := filepath.Join(configs.BitriseWorkDirPath, preActivatedStepsDirName)
+
+	idxChan := make(chan int)
+	var wg sync.WaitGroup
+	var countMutex sync.Mutex
+	count := 0
+	for i := 0; i < workerCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range idxChan {
+				stepIDData := toActivate[idx]
+				dir := filepath.Join(preActivationDir, strconv.Itoa(idx))
+
+				preActivated, err := preActivateStep(stepIDData, dir)
+				if err != nil {
+					log.Debugf("Failed to pre-activate step (%s), error: %s", preActivationKey(stepIDData), err)
+					if err := os.RemoveAll(dir); err != nil {
+						log.Warnf("Failed to remove (%s), error: %s", dir, err)
+					}
+					continue
+				}
+
+				preActivatedStepsMutex.Lock()
+				preActivatedSteps[preActivationKey(stepIDData)] = preActivated
+				preActivatedStepsMutex.Unlock()
+
+				countMutex.Lock()
+				count++
+				countMutex.Unlock()
+			}
+		}()
+	}
+
+	for idx := range toActivate {
+		idxChan <- idx
+	}
+	close(idxChan)
+	wg.Wait()
+
+	log.Debugf("%d of %d steps pre-activated", count, len(toActivate))
+
+	return count
+}
+
+// PreActivatedStep returns the pre-activated step, and false if the step was not pre-activated.
+func PreActivatedStep(stepIDData models.StepIDData) (PreActivatedStepModel, bool) {
+	preActivatedStepsMutex.Lock()
+	defer preActivatedStepsMutex.Unlock()
+
+	preActivated, found := preActivatedSteps[preActivationKey(stepIDData)]
+	return preActivated, found
+}
+
+// ActivatePreActivatedStep copies the pre-activated step's source into stepDir,
+// and its step.yml to stepYMLPth (if stepYMLPth is not empty).
+// Returns false if the step was not pre-activated.
+func ActivatePreActivatedStep(stepIDData models.StepIDData, stepDir, stepYMLPth string) (bool, error) {
+	preActivated, found := PreActivatedStep(stepIDData)
+	if !found {
+		return false, nil
+	}
+
+	if err := os.MkdirAll(stepDir, 0777); err != nil {
+		return true, err
+	}
+	if err := cmdex.CopyDir(preActivated.StepDir, stepDir, true); err != nil {
+		return true, fmt.Errorf("Failed to copy pre-activated step source, error: %s", err)
+	}
+
+	if stepYMLPth != "" && preActivated.StepYMLPth != "" {
+		if err := cmdex.CopyFile(preActivated.StepYMLPth, stepYMLPth); err != nil {
+			return true, fmt.Errorf("Failed to copy pre-activated step.yml, error: %s", err)
+		}
+	}
+
+	log.Debugf("[BITRISE_CLI] - Using pre-activated step: (%s)", preActivationKey(stepIDData))
+	return true, nil
+}
+
+// CleanupPreActivatedSteps ...
+func CleanupPreActivatedSteps() error {
+	preActivatedStepsMutex.Lock()
+	defer preActivatedStepsMutex.Unlock()
+
+	preActivatedSteps = map[string]PreActivatedStepModel{}
+	return os.RemoveAll(filepath.Join(configs.BitriseWorkDirPath, preActivatedStepsDirName))
+}
@@ -0,0 +1,152 @@
+package bitrise
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/cmdex"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectWorkflowSteps(t *testing.T) {
+	configStr := `
+format_version: 1.3.0
+default_step_lib_source: "https://github.com/bitrise-io/bitrise-steplib.git"
+
+workflows:
+  before:
+    steps:
+    - script@1.1.0:
+  after:
+    steps:
+    - path::./my-step:
+    - script@1.1.0:
+  primary:
+    before_run:
+    - before
+    after_run:
+    - after
+    steps:
+    - git::https://github.com/bitrise-io/steps-script.git@master:
+    - script@1.1.0:
+    - script:
+`
+	config, warnings, err := ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	stepIDDatas, err := CollectWorkflowSteps(config, "primary")
+	require.NoError(t, err)
+	require.Equal(t, []models.StepIDData{
+		models.StepIDData{SteplibSource: "https://github.com/bitrise-io/bitrise-steplib.git", IDorURI: "script", Version: "1.1.0"},
+		models.StepIDData{SteplibSource: "git", IDorURI: "https://github.com/bitrise-io/steps-script.git", Version: "master"},
+		models.StepIDData{SteplibSource: "https://github.com/bitrise-io/bitrise-steplib.git", IDorURI: "script", Version: ""},
+		models.StepIDData{SteplibSource: "path", IDorURI: "./my-step", Version: ""},
+	}, stepIDDatas)
+
+	require.Equal(t, true, isPreActivatable(stepIDDatas[0]))
+	require.Equal(t, true, isPreActivatable(stepIDDatas[1]))
+	require.Equal(t, false, isPreActivatable(stepIDDatas[2]))
+	require.Equal(t, false, isPreActivatable(stepIDDatas[3]))
+
+	_, err = CollectWorkflowSteps(config, "not-exist")
+	require.Error(t, err)
+}
+
+func TestStepPreActivationWorkersFromEnv(t *testing.T) {
+	originalValue := os.Getenv(configs.StepPreActivationWorkersEnvKey)
+	defer func() {
+		require.NoError(t, os.Setenv(configs.StepPreActivationWorkersEnvKey, originalValue))
+	}()
+
+	require.NoError(t, os.Setenv(configs.StepPreActivationWorkersEnvKey, ""))
+	workers, err := StepPreActivationWorkersFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, defaultStepPreActivationWorkers, workers)
+
+	require.NoError(t, os.Setenv(configs.StepPreActivationWorkersEnvKey, "0"))
+	workers, err = StepPreActivationWorkersFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, 0, workers)
+
+	require.NoError(t, os.Setenv(configs.StepPreActivationWorkersEnvKey, "-1"))
+	_, err = StepPreActivationWorkersFromEnv()
+	require.Error(t, err)
+
+	require.NoError(t, os.Setenv(configs.StepPreActivationWorkersEnvKey, "many"))
+	_, err = StepPreActivationWorkersFromEnv()
+	require.Error(t, err)
+}
+
+func TestPreActivateSteps(t *testing.T) {
+	require.NoError(t, configs.InitPaths())
+
+	stepRepoPth, err := pathutil.NormalizedOSTempDirPath("step_repo")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(stepRepoPth))
+	}()
+
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(stepRepoPth, "step.yml"), "title: My step\n"))
+	for _, args := range [][]string{
+		[]string{"init", "-q"},
+		[]string{"add", "."},
+		[]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+		[]string{"tag", "1.0.0"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = stepRepoPth
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	stepIDDatas := []models.StepIDData{
+		models.StepIDData{SteplibSource: "git", IDorURI: stepRepoPth, Version: "1.0.0"},
+		models.StepIDData{SteplibSource: "_", IDorURI: stepRepoPth, Version: "1.0.0"},
+		models.StepIDData{SteplibSource: "git", IDorURI: stepRepoPth, Version: "not-exist"},
+		models.StepIDData{SteplibSource: "path", IDorURI: stepRepoPth},
+	}
+
+	require.Equal(t, 0, PreActivateSteps(stepIDDatas, 0))
+
+	require.Equal(t, 2, PreActivateSteps(stepIDDatas, 2))
+	defer func() {
+		require.NoError(t, CleanupPreActivatedSteps())
+	}()
+
+	preActivated, found := PreActivatedStep(stepIDDatas[0])
+	require.Equal(t, true, found)
+	content, err := fileutil.ReadStringFromFile(preActivated.StepYMLPth)
+	require.NoError(t, err)
+	require.Equal(t, "title: My step\n", content)
+
+	preActivated, found = PreActivatedStep(stepIDDatas[1])
+	require.Equal(t, true, found)
+	require.Equal(t, "", preActivated.StepYMLPth)
+
+	_, found = PreActivatedStep(stepIDDatas[2])
+	require.Equal(t, false, found)
+	_, found = PreActivatedStep(stepIDDatas[3])
+	require.Equal(t, false, found)
+
+	if _, err := exec.LookPath("rsync"); err == nil {
+		stepDir, err := pathutil.NormalizedOSTempDirPath("step_src")
+		require.NoError(t, err)
+		stepYMLPth := filepath.Join(stepDir, "..", "current_step.yml")
+
+		isPreActivated, err := ActivatePreActivatedStep(stepIDDatas[0], stepDir, stepYMLPth)
+		require.NoError(t, err)
+		require.Equal(t, true, isPreActivated)
+		require.NoError(t, cmdex.RemoveFile(stepYMLPth))
+	}
+
+	isPreActivated, err := ActivatePreActivatedStep(stepIDDatas[2], "", "")
+	require.NoError(t, err)
+	require.Equal(t, false, isPreActivated)
+}
@@ -0,0 +1,204 @@
+package bitrise
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/bitrise/models"
+)
+
+// Step timing profile (see: --profile): the wall-clock time of every step, split into phases:
+//  * activation: resolving and activating the step (git clone, StepLib / archive / OCI download, reading the step.yml)
+//  * prepare: installing the step's dependencies and preparing its toolkit (e.g. compiling a Go step)
+//  * execution: running the step
+// and the CPU time (user + system) of the step's processes, so the slowest steps of a build can be ranked by cost.
+
+const (
+	// StepPhaseActivation ...
+	StepPhaseActivation = "activation"
+	// StepPhasePrepare ...
+	StepPhasePrepare = "prepare"
+	// StepPhaseExecution ...
+	StepPhaseExecution = "execution"
+)
+
+// StepProfileEntryModel is the timing profile of a step run
+type StepProfileEntryModel struct {
+	Idx            int     `json:"idx"`
+	ID             string  `json:"id"`
+	Title          string  `json:"title"`
+	Status         string  `json:"status"`
+	TotalTime      float64 `json:"total_time_sec"`
+	ActivationTime float64 `json:"activation_time_sec"`
+	PrepareTime    float64 `json:"prepare_time_sec"`
+	ExecutionTime  float64 `json:"execution_time_sec"`
+	// CPUTime is the CPU time of the step's finished child processes, if it can be measured
+	CPUTime *float64 `json:"cpu_time_sec,omitempty"`
+}
+
+// StepProfileModel collects the timing profiles of the steps of the run
+type StepProfileModel struct {
+	Steps []StepProfileEntryModel
+
+	stepStart  time.Time
+	phase      string
+	phaseStart time.Time
+	phaseTimes map[string]time.Duration
+	cpuStart   *time.Duration
+
+	now     func() time.Time
+	cpuTime func() (time.Duration, error)
+}
+
+// NewStepProfile ...
+func NewStepProfile() *StepProfileModel {
+	return &StepProfileModel{
+		Steps:      []StepProfileEntryModel{},
+		phaseTimes: map[string]time.Duration{},
+		now:        time.Now,
+		cpuTime:    childrenCPUTime,
+	}
+}
+
+// StartStep starts the profile of the next step, in its activation phase
+func (profile *StepProfileModel) StartStep() {
+	now := profile.now()
+	profile.stepStart = now
+	profile.phase = StepPhaseActivation
+	profile.phaseStart = now
+	profile.phaseTimes = map[string]time.Duration{}
+
+	profile.cpuStart = nil
+	if cpuTime, err := profile.cpuTime(); err == nil {
+		profile.cpuStart = &cpuTime
+	}
+}
+
+// StartPhase finishes the current phase of the step, and starts the given one
+func (profile *StepProfileModel) StartPhase(phase string) {
+	now := profile.now()
+	profile.phaseTimes[profile.phase] += now.Sub(profile.phaseStart)
+	profile.phase = phase
+	profile.phaseStart = now
+}
+
+// FinishStep finishes the profile of the step
+func (profile *StepProfileModel) FinishStep(stepRunResult models.StepRunResultsModel) {
+	now := profile.now()
+	profile.phaseTimes[profile.phase] += now.Sub(profile.phaseStart)
+
+	entry := StepProfileEntryModel{
+		Idx:            stepRunResult.Idx,
+		ID:             stepRunResult.StepInfo.ID,
+		Title:          stepRunResult.StepInfo.Title,
+		Status:         StepRunStatusName(stepRunResult.Status),
+		TotalTime:      now.Sub(profile.stepStart).Seconds(),
+		ActivationTime: profile.phaseTimes[StepPhaseActivation].Seconds(),
+		PrepareTime:    profile.phaseTimes[StepPhasePrepare].Seconds(),
+		ExecutionTime:  profile.phaseTimes[StepPhaseExecution].Seconds(),
+	}
+	if profile.cpuStart != nil {
+		if cpuTime, err := profile.cpuTime(); err == nil {
+			seconds := (cpuTime - *profile.cpuStart).Seconds()
+			entry.CPUTime = &seconds
+		}
+	}
+
+	profile.Steps = append(profile.Steps, entry)
+}
+
+type stepProfileEntriesByCost []StepProfileEntryModel
+
+func (entries stepProfileEntriesByCost) Len() int {
+	return len(entries)
+}
+
+func (entries stepProfileEntriesByCost) Swap(i, j int) {
+	entries[i], entries[j] = entries[j], entries[i]
+}
+
+func (entries stepProfileEntriesByCost) Less(i, j int) bool {
+	if entries[i].TotalTime != entries[j].TotalTime {
+		return entries[i].TotalTime > entries[j].TotalTime
+	}
+	return entries[i].Idx < entries[j].Idx
+}
+
+// SlowestSteps returns the profiles of the steps, the slowest step first
+func (profile *StepProfileModel) SlowestSteps() []StepProfileEntryModel {
+	entries := append([]StepProfileEntryModel{}, profile.Steps...)
+	sort.Sort(stepProfileEntriesByCost(entries))
+	return entries
+}
+
+func formatProfileSeconds(seconds float64) string {
+	return fmt.Sprintf("%.2fs", seconds)
+}
+
+func formatProfileShare(seconds, totalSeconds float64) string {
+	if totalSeconds <= 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.0f%%", seconds/totalSeconds*100)
+}
+
+// StepProfileReport returns the slowest steps report, with the time spent in each phase of the run.
+// Only the first limit steps are listed, if limit is greater than 0.
+func StepProfileReport(profile *StepProfileModel, limit int) string {
+	entries := profile.SlowestSteps()
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	lines := []string{"Slowest steps:"}
+	for rank, entry := range entries {
+		name := entry.Title
+		if name == "" {
+			name = entry.ID
+		}
+		line := fmt.Sprintf("%2d. %s (%s): %s total, activation: %s, prepare: %s, execution: %s",
+			rank+1, name, entry.Status, formatProfileSeconds(entry.TotalTime),
+			formatProfileSeconds(entry.ActivationTime), formatProfileSeconds(entry.PrepareTime), formatProfileSeconds(entry.ExecutionTime))
+		if entry.CPUTime != nil {
+			line += ", cpu: " + formatProfileSeconds(*entry.CPUTime)
+		}
+		lines = append(lines, line)
+	}
+
+	totalTime, activationTime, prepareTime, executionTime := 0.0, 0.0, 0.0, 0.0
+	for _, entry := range profile.Steps {
+		totalTime += entry.TotalTime
+		activationTime += entry.ActivationTime
+		prepareTime += entry.PrepareTime
+		executionTime += entry.ExecutionTime
+	}
+	lines = append(lines, fmt.Sprintf("Time by phase (%d steps, %s): activation: %s (%s), prepare: %s (%s), execution: %s (%s)",
+		len(profile.Steps), formatProfileSeconds(totalTime),
+		formatProfileSeconds(activationTime), formatProfileShare(activationTime, totalTime),
+		formatProfileSeconds(prepareTime), formatProfileShare(prepareTime, totalTime),
+		formatProfileSeconds(executionTime), formatProfileShare(executionTime, totalTime)))
+
+	return strings.Join(lines, "\n")
+}
+
+// WriteStepProfile writes the step profiles (the slowest step first) as JSON
+func WriteStepProfile(pth string, profile *StepProfileModel) error {
+	bytes, err := json.MarshalIndent(profile.SlowestSteps(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to serialize step profile, error: %s", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(pth), 0777); err != nil {
+		if CheckDiskFull(err) {
+			return nil
+		}
+		return fmt.Errorf("Failed to create step profile dir, error: %s", err)
+	}
+
+	return WriteNonEssentialBytesToFile(pth, bytes)
+}
@@ -0,0 +1,101 @@
+package bitrise
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func testStepProfile(cpuTimeErr error) (*StepProfileModel, func(time.Duration)) {
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	cpuTime := time.Duration(0)
+
+	profile := NewStepProfile()
+	profile.now = func() time.Time {
+		return now
+	}
+	profile.cpuTime = func() (time.Duration, error) {
+		return cpuTime, cpuTimeErr
+	}
+
+	advance := func(duration time.Duration) {
+		now = now.Add(duration)
+		cpuTime += duration / 2
+	}
+	return profile, advance
+}
+
+func TestStepProfile(t *testing.T) {
+	profile, advance := testStepProfile(nil)
+
+	// git-clone: slow activation
+	profile.StartStep()
+	advance(3 * time.Second)
+	profile.StartPhase(StepPhasePrepare)
+	advance(1 * time.Second)
+	profile.StartPhase(StepPhaseExecution)
+	advance(2 * time.Second)
+	profile.FinishStep(models.StepRunResultsModel{Idx: 0, StepInfo: stepmanModels.StepInfoModel{ID: "git-clone", Title: "Git Clone"}, Status: models.StepRunStatusCodeSuccess})
+
+	// script: fails before the execution
+	profile.StartStep()
+	advance(1 * time.Second)
+	profile.FinishStep(models.StepRunResultsModel{Idx: 1, StepInfo: stepmanModels.StepInfoModel{ID: "script"}, Status: models.StepRunStatusCodeFailed})
+
+	// xcode-test: slow execution
+	profile.StartStep()
+	advance(500 * time.Millisecond)
+	profile.StartPhase(StepPhasePrepare)
+	profile.StartPhase(StepPhaseExecution)
+	advance(10 * time.Second)
+	profile.FinishStep(models.StepRunResultsModel{Idx: 2, StepInfo: stepmanModels.StepInfoModel{ID: "xcode-test", Title: "Xcode Test"}, Status: models.StepRunStatusCodeSuccess})
+
+	slowest := profile.SlowestSteps()
+	require.Equal(t, 3, len(slowest))
+	require.Equal(t, "xcode-test", slowest[0].ID)
+	require.Equal(t, 10.5, slowest[0].TotalTime)
+	require.Equal(t, 0.5, slowest[0].ActivationTime)
+	require.Equal(t, 0.0, slowest[0].PrepareTime)
+	require.Equal(t, 10.0, slowest[0].ExecutionTime)
+	require.Equal(t, 5.25, *slowest[0].CPUTime)
+	require.Equal(t, "git-clone", slowest[1].ID)
+	require.Equal(t, "script", slowest[2].ID)
+	require.Equal(t, "failed", slowest[2].Status)
+
+	require.Equal(t, `Slowest steps:
+ 1. Xcode Test (success): 10.50s total, activation: 0.50s, prepare: 0.00s, execution: 10.00s, cpu: 5.25s
+ 2. Git Clone (success): 6.00s total, activation: 3.00s, prepare: 1.00s, execution: 2.00s, cpu: 3.00s
+Time by phase (3 steps, 17.50s): activation: 4.50s (26%), prepare: 1.00s (6%), execution: 12.00s (69%)`, StepProfileReport(profile, 2))
+}
+
+func TestStepProfileWithoutCPUTime(t *testing.T) {
+	profile, advance := testStepProfile(errors.New("not supported"))
+
+	profile.StartStep()
+	advance(time.Second)
+	profile.FinishStep(models.StepRunResultsModel{StepInfo: stepmanModels.StepInfoModel{ID: "script"}})
+
+	require.Nil(t, profile.Steps[0].CPUTime)
+	require.Equal(t, `Slowest steps:
+ 1. script (success): 1.00s total, activation: 1.00s, prepare: 0.00s, execution: 0.00s
+Time by phase (1 steps, 1.00s): activation: 1.00s (100%), prepare: 0.00s (0%), execution: 0.00s (0%)`, StepProfileReport(profile, 0))
+
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("step_profile")
+	require.NoError(t, err)
+	pth := filepath.Join(tmpDir, "profile", "steps.json")
+	require.NoError(t, WriteStepProfile(pth, profile))
+
+	content, err := fileutil.ReadBytesFromFile(pth)
+	require.NoError(t, err)
+	entries := []StepProfileEntryModel{}
+	require.NoError(t, json.Unmarshal(content, &entries))
+	require.Equal(t, profile.Steps, entries)
+}
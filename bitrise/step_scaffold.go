@@ -0,0 +1,344 @@
+package bitrise
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"text/template"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"gopkg.in/yaml.v2"
+)
+
+// Step scaffolding (bitrise step create): generates the files of a new step repository
+// (step.yml, the step's source in the selected toolkit, a test workflow and a README),
+// so step authors don't have to copy and hand-edit an existing step.
+
+const (
+	// StepToolkitBash ...
+	StepToolkitBash = "bash"
+	// StepToolkitGo ...
+	StepToolkitGo = "go"
+)
+
+// StepScaffoldToolkits ...
+var StepScaffoldToolkits = []string{StepToolkitBash, StepToolkitGo}
+
+var stepScaffoldIDRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// StepScaffoldModel is the description of the step to generate
+type StepScaffoldModel struct {
+	ID          string
+	Title       string
+	Summary     string
+	Description string
+	Website     string
+	Toolkit     string
+	// GoPackageName - the step's Go package (e.g. github.com/user/steps-my-step), required for the go toolkit
+	GoPackageName string
+}
+
+// Validate ...
+func (scaffold StepScaffoldModel) Validate() error {
+	if scaffold.ID == "" {
+		return fmt.Errorf("missing step ID")
+	}
+	if !stepScaffoldIDRegexp.MatchString(scaffold.ID) {
+		return fmt.Errorf("invalid step ID (%s), only lowercase letters, numbers and dashes are allowed", scaffold.ID)
+	}
+	if scaffold.Title == "" {
+		return fmt.Errorf("missing step title")
+	}
+
+	switch scaffold.Toolkit {
+	case StepToolkitBash:
+	case StepToolkitGo:
+		if scaffold.GoPackageName == "" {
+			return fmt.Errorf("missing Go package name, required for the %s toolkit", StepToolkitGo)
+		}
+	default:
+		return fmt.Errorf("invalid toolkit (%s), accepted: %s, %s", scaffold.Toolkit, StepToolkitBash, StepToolkitGo)
+	}
+	return nil
+}
+
+// EntryFile returns the step's source file, generated for the selected toolkit
+func (scaffold StepScaffoldModel) EntryFile() string {
+	if scaffold.Toolkit == StepToolkitGo {
+		return "main.go"
+	}
+	return "step.sh"
+}
+
+const stepScaffoldStepYMLTemplate = `title: {{ yamlString .Title }}
+summary: {{ yamlString .Summary }}
+description: {{ yamlString .Description }}
+{{- if .Website }}
+website: {{ yamlString .Website }}
+source_code_url: {{ yamlString .Website }}
+support_url: {{ yamlString (printf "%s/issues" .Website) }}
+{{- end }}
+host_os_tags:
+- osx-10.10
+- ubuntu-16.04
+type_tags:
+- utility
+is_requires_admin_user: false
+is_always_run: false
+is_skippable: false
+toolkit:
+{{- if eq .Toolkit "go" }}
+  go:
+    package_name: {{ yamlString .GoPackageName }}
+{{- else }}
+  bash:
+    entry_file: step.sh
+{{- end }}
+inputs:
+- example_input: "hello"
+  opts:
+    title: Example input
+    summary: An example input of the step.
+    description: |-
+      An example input of the step, the step prints its value.
+    is_required: true
+outputs:
+- EXAMPLE_STEP_OUTPUT:
+  opts:
+    title: Example output
+    summary: An example output of the step.
+    description: |-
+      The value of the example_input, exported by the step.
+`
+
+const stepScaffoldBashTemplate = `#!/bin/bash
+set -ex
+
+echo "This is the value specified for the input 'example_input': ${example_input}"
+
+#
+# --- Export the outputs of the step:
+#  envman adds the output to the environment of the next steps.
+envman add --key EXAMPLE_STEP_OUTPUT --value "${example_input}"
+
+#
+# --- Exit codes:
+#  The exit code of the script determines whether the step is successful (0) or failed (any other code).
+`
+
+const stepScaffoldGoTemplate = `package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// exportEnv adds the output to the environment of the next steps, with envman
+func exportEnv(key, value string) error {
+	cmd := exec.Command("envman", "add", "--key", key, "--value", value)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func main() {
+	exampleInput := os.Getenv("example_input")
+	fmt.Printf("This is the value specified for the input 'example_input': %s\n", exampleInput)
+
+	if err := exportEnv("EXAMPLE_STEP_OUTPUT", exampleInput); err != nil {
+		fmt.Printf("Failed to export output (EXAMPLE_STEP_OUTPUT), error: %s\n", err)
+		os.Exit(1)
+	}
+}
+`
+
+const stepScaffoldConfigTemplate = `format_version: 1.4.0
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+
+app:
+  envs:
+  - STEP_ID: {{ yamlString .ID }}
+
+workflows:
+  test:
+    summary: Runs the step from the current directory
+    steps:
+    - path::./:
+        title: Step Test
+        inputs:
+        - example_input: "test value"
+    - script:
+        title: Check the step's output
+        inputs:
+        - content: |-
+            #!/bin/bash
+            set -ex
+            if [ "$EXAMPLE_STEP_OUTPUT" != "test value" ] ; then
+              echo "Unexpected EXAMPLE_STEP_OUTPUT: $EXAMPLE_STEP_OUTPUT"
+              exit 1
+            fi
+`
+
+const stepScaffoldReadmeTemplate = `# {{ .Title }}
+
+{{ .Summary }}
+
+{{ .Description }}
+
+## Inputs
+
+| Key | Description | Required |
+| --- | --- | --- |
+| ` + "`example_input`" + ` | An example input of the step, the step prints its value. | yes |
+
+## Outputs
+
+| Key | Description |
+| --- | --- |
+| ` + "`EXAMPLE_STEP_OUTPUT`" + ` | The value of the example_input, exported by the step. |
+
+## How to test the step locally
+
+Run the test workflow of the step's ` + "`bitrise.yml`" + `:
+
+` + "```" + `
+bitrise run test
+` + "```" + `
+
+The ` + "`test`" + ` workflow runs the step from the current directory (` + "`path::./`" + `)
+and checks its output.
+`
+
+const stepScaffoldGitignore = `.bitrise*
+`
+
+func yamlString(value string) (string, error) {
+	bytes, err := yaml.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes[:len(bytes)-1]), nil
+}
+
+func renderStepScaffoldTemplate(name, content string, scaffold StepScaffoldModel) (string, error) {
+	tmpl, err := template.New(name).Funcs(template.FuncMap{"yamlString": yamlString}).Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse template (%s), error: %s", name, err)
+	}
+
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, scaffold); err != nil {
+		return "", fmt.Errorf("Failed to render template (%s), error: %s", name, err)
+	}
+	return buffer.String(), nil
+}
+
+// StepScaffoldFiles returns the content of the new step repository's files, by their path relative to the repository's root.
+// The generated step.yml is validated, the same way as the step.yml of a local (path::) step.
+func StepScaffoldFiles(scaffold StepScaffoldModel) (map[string]string, error) {
+	if err := scaffold.Validate(); err != nil {
+		return map[string]string{}, err
+	}
+	if scaffold.Summary == "" {
+		scaffold.Summary = scaffold.Title
+	}
+	if scaffold.Description == "" {
+		scaffold.Description = scaffold.Summary
+	}
+
+	entryTemplate := stepScaffoldBashTemplate
+	if scaffold.Toolkit == StepToolkitGo {
+		entryTemplate = stepScaffoldGoTemplate
+	}
+
+	templates := map[string]string{
+		"step.yml":           stepScaffoldStepYMLTemplate,
+		scaffold.EntryFile(): entryTemplate,
+		"bitrise.yml":        stepScaffoldConfigTemplate,
+		"README.md":          stepScaffoldReadmeTemplate,
+		".gitignore":         stepScaffoldGitignore,
+	}
+
+	files := map[string]string{}
+	for name, content := range templates {
+		rendered, err := renderStepScaffoldTemplate(name, content, scaffold)
+		if err != nil {
+			return map[string]string{}, err
+		}
+		files[name] = rendered
+	}
+
+	if err := validateScaffoldStepYML(files["step.yml"]); err != nil {
+		return map[string]string{}, fmt.Errorf("Generated step.yml is invalid, error: %s", err)
+	}
+	if _, warnings, err := ConfigModelFromYAMLBytes([]byte(files["bitrise.yml"])); err != nil {
+		return map[string]string{}, fmt.Errorf("Generated bitrise.yml is invalid, error: %s", err)
+	} else if len(warnings) > 0 {
+		return map[string]string{}, fmt.Errorf("Generated bitrise.yml is invalid, warnings: %v", warnings)
+	}
+
+	return files, nil
+}
+
+func validateScaffoldStepYML(content string) error {
+	var stepModel stepmanModels.StepModel
+	if err := yaml.Unmarshal([]byte(content), &stepModel); err != nil {
+		return err
+	}
+	if err := stepModel.Normalize(); err != nil {
+		return err
+	}
+	return stepModel.ValidateInputAndOutputEnvs(true)
+}
+
+// WriteStepScaffold generates the step's files (see: StepScaffoldFiles) into the given directory,
+// and returns the written files' paths. The directory must not exist, or it has to be empty.
+func WriteStepScaffold(scaffold StepScaffoldModel, dir string) ([]string, error) {
+	files, err := StepScaffoldFiles(scaffold)
+	if err != nil {
+		return []string{}, err
+	}
+
+	if exist, err := pathutil.IsDirExists(dir); err != nil {
+		return []string{}, err
+	} else if exist {
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return []string{}, err
+		}
+		if len(infos) > 0 {
+			return []string{}, fmt.Errorf("Directory (%s) already exists and it's not empty", dir)
+		}
+	}
+	if err := pathutil.EnsureDirExist(dir); err != nil {
+		return []string{}, fmt.Errorf("Failed to create directory (%s), error: %s", dir, err)
+	}
+
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pths := []string{}
+	for _, name := range names {
+		pth := filepath.Join(dir, name)
+		if err := fileutil.WriteStringToFile(pth, files[name]); err != nil {
+			return []string{}, fmt.Errorf("Failed to write file (%s), error: %s", pth, err)
+		}
+		if name == "step.sh" {
+			if err := os.Chmod(pth, 0755); err != nil {
+				return []string{}, fmt.Errorf("Failed to make file (%s) executable, error: %s", pth, err)
+			}
+		}
+		pths = append(pths, pth)
+	}
+	return pths, nil
+}
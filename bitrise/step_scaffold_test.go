@@ -0,0 +1,90 @@
+package bitrise
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepScaffoldModelValidate(t *testing.T) {
+	require.NoError(t, StepScaffoldModel{ID: "my-step", Title: "My Step", Toolkit: StepToolkitBash}.Validate())
+	require.NoError(t, StepScaffoldModel{ID: "my-step", Title: "My Step", Toolkit: StepToolkitGo, GoPackageName: "github.com/user/steps-my-step"}.Validate())
+
+	require.EqualError(t, StepScaffoldModel{Title: "My Step", Toolkit: StepToolkitBash}.Validate(), "missing step ID")
+	require.EqualError(t, StepScaffoldModel{ID: "My Step", Title: "My Step", Toolkit: StepToolkitBash}.Validate(),
+		"invalid step ID (My Step), only lowercase letters, numbers and dashes are allowed")
+	require.EqualError(t, StepScaffoldModel{ID: "my-step", Title: "My Step", Toolkit: StepToolkitGo}.Validate(),
+		"missing Go package name, required for the go toolkit")
+	require.EqualError(t, StepScaffoldModel{ID: "my-step", Title: "My Step", Toolkit: "ruby"}.Validate(),
+		"invalid toolkit (ruby), accepted: bash, go")
+}
+
+func TestStepScaffoldFiles(t *testing.T) {
+	t.Log("bash toolkit")
+	{
+		files, err := StepScaffoldFiles(StepScaffoldModel{
+			ID:      "my-step",
+			Title:   "My: Step",
+			Website: "https://github.com/user/steps-my-step",
+			Toolkit: StepToolkitBash,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 5, len(files))
+		require.Contains(t, files, "step.sh")
+		require.Contains(t, files, "README.md")
+		require.Contains(t, files, ".gitignore")
+		require.Contains(t, files["step.yml"], "title: 'My: Step'")
+		require.Contains(t, files["step.yml"], "support_url: https://github.com/user/steps-my-step/issues")
+		require.Contains(t, files["step.yml"], "  bash:\n    entry_file: step.sh\n")
+		require.Contains(t, files["bitrise.yml"], "- path::./:")
+	}
+
+	t.Log("go toolkit")
+	{
+		files, err := StepScaffoldFiles(StepScaffoldModel{
+			ID:            "my-step",
+			Title:         "My Step",
+			Toolkit:       StepToolkitGo,
+			GoPackageName: "github.com/user/steps-my-step",
+		})
+		require.NoError(t, err)
+		require.Contains(t, files, "main.go")
+		require.NotContains(t, files, "step.sh")
+		require.Contains(t, files["step.yml"], "  go:\n    package_name: github.com/user/steps-my-step\n")
+	}
+}
+
+func TestWriteStepScaffold(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("step_scaffold")
+	require.NoError(t, err)
+	stepDir := filepath.Join(tmpDir, "steps-my-step")
+
+	pths, err := WriteStepScaffold(StepScaffoldModel{ID: "my-step", Title: "My Step", Toolkit: StepToolkitBash}, stepDir)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(stepDir, ".gitignore"),
+		filepath.Join(stepDir, "README.md"),
+		filepath.Join(stepDir, "bitrise.yml"),
+		filepath.Join(stepDir, "step.sh"),
+		filepath.Join(stepDir, "step.yml"),
+	}, pths)
+
+	_, err = ReadSpecStep(filepath.Join(stepDir, "step.yml"))
+	require.NoError(t, err)
+
+	info, err := os.Stat(filepath.Join(stepDir, "step.sh"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	t.Log("existing, not empty directory is not overwritten")
+	{
+		require.NoError(t, fileutil.WriteStringToFile(filepath.Join(stepDir, "step.yml"), "title: Old"))
+
+		_, err := WriteStepScaffold(StepScaffoldModel{ID: "my-step", Title: "My Step", Toolkit: StepToolkitBash}, stepDir)
+		require.EqualError(t, err, "Directory ("+stepDir+") already exists and it's not empty")
+	}
+}
@@ -0,0 +1,88 @@
+package bitrise
+
+import (
+	"sort"
+	"strings"
+
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// Step search: the query's words are searched (case insensitively) in the ID, title, summary and description
+// of the steps' latest version, every word has to be found in at least one of them.
+// The results are ordered by relevance: a match in the ID weighs more than a match in the title,
+// which weighs more than a match in the summary, and so on.
+
+const (
+	stepSearchIDWeight          = 8
+	stepSearchTitleWeight       = 4
+	stepSearchSummaryWeight     = 2
+	stepSearchDescriptionWeight = 1
+)
+
+// StepSearchResultModel ...
+type StepSearchResultModel struct {
+	StepListEntryModel
+	Score int `json:"score"`
+}
+
+type stepSearchResults []StepSearchResultModel
+
+func (results stepSearchResults) Len() int      { return len(results) }
+func (results stepSearchResults) Swap(i, j int) { results[i], results[j] = results[j], results[i] }
+func (results stepSearchResults) Less(i, j int) bool {
+	if results[i].Score != results[j].Score {
+		return results[i].Score > results[j].Score
+	}
+	return results[i].ID < results[j].ID
+}
+
+// stepSearchScore returns the relevance of the step for the query words, 0 if any of the words is not found
+func stepSearchScore(words []string, stepID, title, summary, description string) int {
+	fields := []struct {
+		value  string
+		weight int
+	}{
+		{strings.ToLower(stepID), stepSearchIDWeight},
+		{strings.ToLower(title), stepSearchTitleWeight},
+		{strings.ToLower(summary), stepSearchSummaryWeight},
+		{strings.ToLower(description), stepSearchDescriptionWeight},
+	}
+
+	score := 0
+	for _, word := range words {
+		wordScore := 0
+		for _, field := range fields {
+			if strings.Contains(field.value, word) {
+				wordScore += field.weight
+			}
+		}
+		if wordScore == 0 {
+			return 0
+		}
+		score += wordScore
+	}
+	return score
+}
+
+// SearchStepLibSteps returns the steps of the StepLib spec, which match the query, ordered by relevance
+func SearchStepLibSteps(spec stepmanModels.StepCollectionModel, query string) []StepSearchResultModel {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return []StepSearchResultModel{}
+	}
+
+	results := stepSearchResults{}
+	for stepID, stepGroup := range spec.Steps {
+		entry, step, found := newStepListEntry(stepID, stepGroup)
+		if !found {
+			continue
+		}
+
+		score := stepSearchScore(words, stepID, entry.Title, entry.Summary, stringValue(step.Description))
+		if score > 0 {
+			results = append(results, StepSearchResultModel{StepListEntryModel: entry, Score: score})
+		}
+	}
+	sort.Sort(results)
+	return results
+}
@@ -0,0 +1,52 @@
+package bitrise
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-utils/pointers"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchStepLibSteps(t *testing.T) {
+	spec := stepmanModels.StepCollectionModel{
+		Steps: stepmanModels.StepHash{
+			"slack": stepmanModels.StepGroupModel{
+				LatestVersionNumber: "2.0.0",
+				Versions: map[string]stepmanModels.StepModel{
+					"2.0.0": stepmanModels.StepModel{
+						Title:   pointers.NewStringPtr("Send a Slack message"),
+						Summary: pointers.NewStringPtr("Sends a message to a Slack channel"),
+					},
+				},
+			},
+			"notify": stepmanModels.StepGroupModel{
+				LatestVersionNumber: "1.0.0",
+				Versions: map[string]stepmanModels.StepModel{
+					"1.0.0": stepmanModels.StepModel{
+						Title:       pointers.NewStringPtr("Notify"),
+						Description: pointers.NewStringPtr("Sends a notification by email, or as a Slack MESSAGE."),
+					},
+				},
+			},
+			"script": stepmanModels.StepGroupModel{
+				LatestVersionNumber: "1.1.3",
+				Versions: map[string]stepmanModels.StepModel{
+					"1.1.3": stepmanModels.StepModel{Title: pointers.NewStringPtr("Script")},
+				},
+			},
+		},
+	}
+
+	results := SearchStepLibSteps(spec, "slack message")
+	require.Equal(t, 2, len(results))
+	require.Equal(t, "slack", results[0].ID)
+	require.Equal(t, "2.0.0", results[0].Version)
+	require.Equal(t, (8+4+2)+(4+2), results[0].Score)
+	require.Equal(t, "notify", results[1].ID)
+	require.Equal(t, 1+1, results[1].Score)
+
+	require.Equal(t, 1, len(SearchStepLibSteps(spec, "email")))
+	require.Equal(t, 0, len(SearchStepLibSteps(spec, "slack deploy")))
+	require.Equal(t, 0, len(SearchStepLibSteps(spec, "  ")))
+}
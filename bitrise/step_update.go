@@ -0,0 +1,81 @@
+package bitrise
+
+import (
+	"fmt"
+	"strings"
+
+	ver "github.com/hashicorp/go-version"
+)
+
+// LatestAllowedStepVersion returns the highest version from the available versions,
+// which is greater than the current version.
+// If allowMajor is false, only versions with the same major version are considered.
+// Returns an empty string if there is no newer version.
+func LatestAllowedStepVersion(availableVersions []string, currentVersion string, allowMajor bool) (string, error) {
+	current, err := ver.NewVersion(currentVersion)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse version (%s), error: %s", currentVersion, err)
+	}
+
+	var latest *ver.Version
+	latestStr := ""
+	for _, versionStr := range availableVersions {
+		version, err := ver.NewVersion(versionStr)
+		if err != nil {
+			// not semver versions can't be compared
+			continue
+		}
+		if version.Prerelease() != "" {
+			continue
+		}
+		if !allowMajor && version.Segments()[0] != current.Segments()[0] {
+			continue
+		}
+		if !version.GreaterThan(current) {
+			continue
+		}
+		if latest == nil || version.GreaterThan(latest) {
+			latest = version
+			latestStr = versionStr
+		}
+	}
+
+	return latestStr, nil
+}
+
+// RewriteStepVersions replaces the step references (composite step IDs) in the config content,
+// without touching the rest of the config, so the formatting and the comments are kept.
+// Returns the new content and the number of replaced references.
+func RewriteStepVersions(content string, updates map[string]string) (string, int) {
+	lines := strings.Split(content, "\n")
+	count := 0
+
+	for idx, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if !strings.HasPrefix(trimmed, "-") {
+			continue
+		}
+
+		item := strings.TrimLeft(trimmed[1:], " \t")
+		keyStart := len(line) - len(item)
+
+		for oldKey, newKey := range updates {
+			for _, quote := range []string{"", `"`, "'"} {
+				quotedOldKey := quote + oldKey + quote
+				if !strings.HasPrefix(item, quotedOldKey+":") {
+					continue
+				}
+
+				rest := item[len(quotedOldKey)+1:]
+				if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+					continue
+				}
+
+				lines[idx] = line[:keyStart] + quote + newKey + quote + ":" + rest
+				count++
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n"), count
+}
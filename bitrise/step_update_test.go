@@ -0,0 +1,72 @@
+package bitrise
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestAllowedStepVersion(t *testing.T) {
+	versions := []string{"1.0.0", "1.1.0", "1.1.3", "2.0.0", "2.1.0-beta", "not-semver"}
+
+	t.Log("minor update")
+	{
+		latest, err := LatestAllowedStepVersion(versions, "1.0.0", false)
+		require.NoError(t, err)
+		require.Equal(t, "1.1.3", latest)
+	}
+
+	t.Log("major update")
+	{
+		latest, err := LatestAllowedStepVersion(versions, "1.0.0", true)
+		require.NoError(t, err)
+		require.Equal(t, "2.0.0", latest)
+	}
+
+	t.Log("up to date")
+	{
+		latest, err := LatestAllowedStepVersion(versions, "2.0.0", true)
+		require.NoError(t, err)
+		require.Equal(t, "", latest)
+	}
+
+	t.Log("invalid current version")
+	{
+		_, err := LatestAllowedStepVersion(versions, "master", true)
+		require.Error(t, err)
+	}
+}
+
+func TestRewriteStepVersions(t *testing.T) {
+	content := `format_version: 1.3.1
+workflows:
+  primary:
+    steps:
+    # keep this comment
+    - script@1.1.0:
+        title: script@1.1.0
+    - "https://github.com/bitrise-io/bitrise-steplib.git::git-clone@3.0.0":
+    - script@1.1.0: {}
+    - timestamp@0.9.0:
+`
+	updates := map[string]string{
+		"script@1.1.0": "script@1.1.3",
+		"https://github.com/bitrise-io/bitrise-steplib.git::git-clone@3.0.0": "https://github.com/bitrise-io/bitrise-steplib.git::git-clone@3.4.1",
+	}
+
+	expected := `format_version: 1.3.1
+workflows:
+  primary:
+    steps:
+    # keep this comment
+    - script@1.1.3:
+        title: script@1.1.0
+    - "https://github.com/bitrise-io/bitrise-steplib.git::git-clone@3.4.1":
+    - script@1.1.3: {}
+    - timestamp@0.9.0:
+`
+
+	newContent, count := RewriteStepVersions(content, updates)
+	require.Equal(t, expected, newContent)
+	require.Equal(t, 3, count)
+}
@@ -0,0 +1,227 @@
+package bitrise
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/tools"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	ver "github.com/hashicorp/go-version"
+)
+
+// Step version constraints: a StepLib step can be referenced with a version range, instead of an exact version:
+//  * caret range: script@^1.2 (>= 1.2.0, < 2.0.0; for 0.x versions: ^0.3 is >= 0.3.0, < 0.4.0)
+//  * tilde range: script@~2.0 (>= 2.0.0, < 2.1.0; ~2 is >= 2.0.0, < 3.0.0)
+//  * wildcard: script@1.x, script@1.2.x (x, X and * are accepted)
+// The constraint is resolved to the highest matching (not pre-release) version of the StepLib,
+// once per run, so every reference of the same constraint runs the same version.
+
+// stepVersionRangeModel is the [Lower, Upper) version range of a constraint, Upper is nil, if the range is not bounded
+type stepVersionRangeModel struct {
+	Lower *ver.Version
+	Upper *ver.Version
+}
+
+func (versionRange stepVersionRangeModel) contains(version *ver.Version) bool {
+	if version.LessThan(versionRange.Lower) {
+		return false
+	}
+	return versionRange.Upper == nil || version.LessThan(versionRange.Upper)
+}
+
+func isStepVersionWildcard(segment string) bool {
+	return segment == "x" || segment == "X" || segment == "*"
+}
+
+// IsStepVersionConstraint returns true if the step version is a version constraint (^1.2, ~2.0, 1.x), instead of an exact version
+func IsStepVersionConstraint(version string) bool {
+	if strings.HasPrefix(version, "^") || strings.HasPrefix(version, "~") {
+		return true
+	}
+	for _, segment := range strings.Split(version, ".") {
+		if isStepVersionWildcard(segment) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExactStepVersion returns true if the step version is an exact version (1.2.3), instead of a version constraint,
+// a major or minor version lock (1, 1.2), or the latest version (empty version)
+func IsExactStepVersion(version string) bool {
+	return !IsStepVersionConstraint(version) && len(strings.Split(version, ".")) >= 3
+}
+
+func newStepVersion(segments []int) *ver.Version {
+	segmentStrs := []string{}
+	for _, segment := range segments {
+		segmentStrs = append(segmentStrs, strconv.Itoa(segment))
+	}
+	// the segments are validated numbers, the version is always valid
+	return ver.Must(ver.NewVersion(strings.Join(segmentStrs, ".")))
+}
+
+func parseStepVersionConstraint(constraint string) (stepVersionRangeModel, error) {
+	invalidErr := fmt.Errorf("invalid version constraint (%s), accepted formats: ^1.2, ~1.2, 1.x", constraint)
+
+	operator := ""
+	versionStr := constraint
+	if strings.HasPrefix(constraint, "^") || strings.HasPrefix(constraint, "~") {
+		operator = constraint[:1]
+		versionStr = constraint[1:]
+	}
+
+	segments := []int{}
+	hasWildcard := false
+	for _, segmentStr := range strings.Split(versionStr, ".") {
+		if isStepVersionWildcard(segmentStr) {
+			hasWildcard = true
+			continue
+		}
+		if hasWildcard {
+			// a number can't follow a wildcard (1.x.2)
+			return stepVersionRangeModel{}, invalidErr
+		}
+		segment, err := strconv.Atoi(segmentStr)
+		if err != nil || segment < 0 {
+			return stepVersionRangeModel{}, invalidErr
+		}
+		segments = append(segments, segment)
+	}
+	if len(segments) > 3 || (operator != "" && (hasWildcard || len(segments) == 0)) {
+		return stepVersionRangeModel{}, invalidErr
+	}
+
+	if len(segments) == 0 {
+		// x: any version
+		return stepVersionRangeModel{Lower: newStepVersion([]int{0})}, nil
+	}
+
+	// the index of the segment, which is incremented for the upper bound
+	bumpIdx := 0
+	switch operator {
+	case "^":
+		// the first non zero segment can't change
+		for bumpIdx < len(segments)-1 && segments[bumpIdx] == 0 {
+			bumpIdx++
+		}
+	case "~":
+		if len(segments) > 1 {
+			bumpIdx = 1
+		}
+	default:
+		if !hasWildcard {
+			return stepVersionRangeModel{}, invalidErr
+		}
+		bumpIdx = len(segments) - 1
+	}
+
+	upperSegments := append([]int{}, segments[:bumpIdx+1]...)
+	upperSegments[bumpIdx]++
+
+	return stepVersionRangeModel{
+		Lower: newStepVersion(segments),
+		Upper: newStepVersion(upperSegments),
+	}, nil
+}
+
+// ResolveStepVersionConstraint returns the highest (not pre-release) version of the available versions, which matches the constraint,
+// or an empty string if none of them matches.
+func ResolveStepVersionConstraint(availableVersions []string, constraint string) (string, error) {
+	versionRange, err := parseStepVersionConstraint(constraint)
+	if err != nil {
+		return "", err
+	}
+
+	var highest *ver.Version
+	highestStr := ""
+	for _, versionStr := range availableVersions {
+		version, err := ver.NewVersion(versionStr)
+		if err != nil {
+			// not semver versions can't be compared
+			continue
+		}
+		if version.Prerelease() != "" || !versionRange.contains(version) {
+			continue
+		}
+		if highest == nil || version.GreaterThan(highest) {
+			highest = version
+			highestStr = versionStr
+		}
+	}
+	return highestStr, nil
+}
+
+// StepLibStepVersions returns the available versions of the step in the StepLib spec
+func StepLibStepVersions(spec stepmanModels.StepCollectionModel, stepID string) []string {
+	versions := []string{}
+	stepGroup, found := spec.Steps[stepID]
+	if !found {
+		return versions
+	}
+	for version := range stepGroup.Versions {
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+// resolvedStepVersions caches the resolved version constraints of the run, by StepLib, step ID and constraint
+var resolvedStepVersions = map[string]string{}
+
+// ResolveStepVersion returns the step ID data with the version constraint (if any) resolved to the matching StepLib step version.
+// The StepLib is updated, if none of its local versions matches the constraint.
+func ResolveStepVersion(stepIDData models.StepIDData) (models.StepIDData, error) {
+	switch stepIDData.SteplibSource {
+	case "path", "git", "_", "", models.OCIStepSource, models.ArchiveStepSource:
+		return stepIDData, nil
+	}
+	if !IsStepVersionConstraint(stepIDData.Version) {
+		return stepIDData, nil
+	}
+
+	cacheKey := stepIDData.SteplibSource + "::" + stepIDData.IDorURI + "@" + stepIDData.Version
+	if version, found := resolvedStepVersions[cacheKey]; found {
+		stepIDData.Version = version
+		return stepIDData, nil
+	}
+
+	if _, err := parseStepVersionConstraint(stepIDData.Version); err != nil {
+		return models.StepIDData{}, fmt.Errorf("Step (%s): %s", stepIDData.IDorURI, err)
+	}
+
+	if err := tools.StepmanSetup(stepIDData.SteplibSource); err != nil {
+		return models.StepIDData{}, fmt.Errorf("Failed to setup steplib (%s), error: %s", stepIDData.SteplibSource, err)
+	}
+
+	version := ""
+	for _, update := range []bool{false, true} {
+		if update {
+			log.Infof("No version of step (%s) matches (%s) in the local StepLib -- Updating ...", stepIDData.IDorURI, stepIDData.Version)
+			if err := tools.StepmanUpdate(stepIDData.SteplibSource); err != nil {
+				return models.StepIDData{}, fmt.Errorf("Failed to update steplib (%s), error: %s", stepIDData.SteplibSource, err)
+			}
+		}
+
+		spec, err := tools.StepmanStepLibSpec(stepIDData.SteplibSource)
+		if err != nil {
+			return models.StepIDData{}, err
+		}
+		if version, err = ResolveStepVersionConstraint(StepLibStepVersions(spec, stepIDData.IDorURI), stepIDData.Version); err != nil {
+			return models.StepIDData{}, err
+		}
+		if version != "" {
+			break
+		}
+	}
+	if version == "" {
+		return models.StepIDData{}, fmt.Errorf("No version of step (%s) matches the version constraint (%s) in StepLib (%s)", stepIDData.IDorURI, stepIDData.Version, stepIDData.SteplibSource)
+	}
+
+	log.Infof("Step (%s) version constraint (%s) resolved to version (%s)", stepIDData.IDorURI, stepIDData.Version, version)
+	resolvedStepVersions[cacheKey] = version
+	stepIDData.Version = version
+	return stepIDData, nil
+}
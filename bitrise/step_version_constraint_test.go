@@ -0,0 +1,54 @@
+package bitrise
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsStepVersionConstraint(t *testing.T) {
+	for _, constraint := range []string{"^1.2", "~2.0", "1.x", "1.2.X", "*"} {
+		require.Equal(t, true, IsStepVersionConstraint(constraint), constraint)
+	}
+	for _, version := range []string{"", "1.2.3", "2.0", "master"} {
+		require.Equal(t, false, IsStepVersionConstraint(version), version)
+	}
+}
+
+func TestIsExactStepVersion(t *testing.T) {
+	for _, version := range []string{"1.2.3", "2.0.0-beta"} {
+		require.Equal(t, true, IsExactStepVersion(version), version)
+	}
+	for _, version := range []string{"", "1", "1.2", "1.2.x", "^1.2", "master"} {
+		require.Equal(t, false, IsExactStepVersion(version), version)
+	}
+}
+
+func TestResolveStepVersionConstraint(t *testing.T) {
+	availableVersions := []string{"0.3.0", "0.3.5", "0.4.0", "1.1.0", "1.2.0", "1.2.5", "1.9.0", "2.0.0", "2.0.3", "2.1.0", "3.0.0-beta", "invalid"}
+
+	for constraint, expected := range map[string]string{
+		"^1.2":   "1.9.0",
+		"^1.2.6": "1.9.0",
+		"^0.3":   "0.3.5",
+		"^2":     "2.1.0",
+		"~2.0":   "2.0.3",
+		"~1":     "1.9.0",
+		"~1.2.1": "1.2.5",
+		"1.x":    "1.9.0",
+		"1.2.x":  "1.2.5",
+		"2.*":    "2.1.0",
+		"x":      "2.1.0",
+		"^3.0":   "",
+		"~1.3":   "",
+	} {
+		version, err := ResolveStepVersionConstraint(availableVersions, constraint)
+		require.NoError(t, err, constraint)
+		require.Equal(t, expected, version, constraint)
+	}
+
+	for _, constraint := range []string{"^", "~x", "^1.x", "1.x.2", "^1.2.3.4", "^a.b", "1.2"} {
+		_, err := ResolveStepVersionConstraint(availableVersions, constraint)
+		require.Error(t, err, constraint)
+	}
+}
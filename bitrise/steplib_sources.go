@@ -0,0 +1,102 @@
+package bitrise
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/tools"
+)
+
+// StepLib source resolution: a step without an explicit StepLib source is activated from the first StepLib
+// of the config's StepLib search list (see: models.BitriseDataModel.StepLibSearchList), which has the step.
+// A StepLib, which can't be set up, fails the resolution, instead of falling back to the next StepLib,
+// so a step of a lower precedence StepLib (e.g. the public one) can't silently replace the step of an unreachable one.
+
+// stepLibStepChecker returns whether the StepLib has the step (with the given version, or any version, if empty)
+type stepLibStepChecker func(source, stepID, version string, update bool) (bool, error)
+
+// resolvedStepLibSources caches the resolved StepLib sources, by the step's ID and version
+var resolvedStepLibSources = map[string]string{}
+
+// ResolveStepIDData returns the step ID data of the given composite step ID,
+// the StepLib source of a step without an explicit source is the first StepLib of the sources, which has the step.
+func ResolveStepIDData(compositeStepIDStr string, sources []string) (models.StepIDData, error) {
+	return resolveStepIDData(compositeStepIDStr, sources, hasStepLibStep)
+}
+
+func resolveStepIDData(compositeStepIDStr string, sources []string, hasStep stepLibStepChecker) (models.StepIDData, error) {
+	defaultSource := ""
+	if len(sources) > 0 {
+		defaultSource = sources[0]
+	}
+
+	stepIDData, err := models.CreateStepIDDataFromString(compositeStepIDStr, defaultSource)
+	if err != nil || len(sources) < 2 || strings.Contains(compositeStepIDStr, "::") || stepIDData.SteplibSource == models.OCIStepSource {
+		return stepIDData, err
+	}
+
+	source, err := resolveStepLibSource(sources, stepIDData.IDorURI, stepIDData.Version, hasStep)
+	if err != nil {
+		return models.StepIDData{}, err
+	}
+	stepIDData.SteplibSource = source
+	return stepIDData, nil
+}
+
+func resolveStepLibSource(sources []string, stepID, version string, hasStep stepLibStepChecker) (string, error) {
+	cacheKey := stepID + "@" + version
+	if source, found := resolvedStepLibSources[cacheKey]; found {
+		return source, nil
+	}
+
+	// the local StepLib specs are searched first, the StepLibs are updated only if the step is not found in any of them
+	for _, update := range []bool{false, true} {
+		for _, source := range sources {
+			found, err := hasStep(source, stepID, version, update)
+			if err != nil {
+				return "", err
+			}
+			if found {
+				log.Debugf("Step (%s) resolved from StepLib (%s)", cacheKey, source)
+				resolvedStepLibSources[cacheKey] = source
+				return source, nil
+			}
+		}
+	}
+
+	if version != "" {
+		return "", fmt.Errorf("Step (%s@%s) not found in any of the StepLibs: %s", stepID, version, strings.Join(sources, ", "))
+	}
+	return "", fmt.Errorf("Step (%s) not found in any of the StepLibs: %s", stepID, strings.Join(sources, ", "))
+}
+
+func hasStepLibStep(source, stepID, version string, update bool) (bool, error) {
+	if err := tools.StepmanSetup(source); err != nil {
+		return false, fmt.Errorf("Failed to setup steplib (%s), error: %s", source, err)
+	}
+	if update {
+		if err := tools.StepmanUpdate(source); err != nil {
+			return false, fmt.Errorf("Failed to update steplib (%s), error: %s", source, err)
+		}
+	}
+
+	if IsStepVersionConstraint(version) {
+		spec, err := tools.StepmanStepLibSpec(source)
+		if err != nil {
+			return false, err
+		}
+		matchingVersion, err := ResolveStepVersionConstraint(StepLibStepVersions(spec, stepID), version)
+		if err != nil {
+			return false, err
+		}
+		return matchingVersion != "", nil
+	}
+
+	if _, err := tools.StepmanStepLibStepInfo(source, stepID, version); err != nil {
+		log.Debugf("Step (%s@%s) not found in StepLib (%s), error: %s", stepID, version, source, err)
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,77 @@
+package bitrise
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveStepIDData(t *testing.T) {
+	internal := "https://git.example.com/steplib.git"
+	public := "https://github.com/bitrise-io/bitrise-steplib.git"
+	sources := []string{internal, public}
+
+	updates := 0
+	hasStep := func(source, stepID, version string, update bool) (bool, error) {
+		if update {
+			updates++
+		}
+		switch stepID {
+		case "internal-step", "script":
+			return source == internal || stepID == "script", nil
+		case "new-step":
+			return update && source == public, nil
+		case "broken-step":
+			return false, errors.New("failed to setup steplib")
+		}
+		return false, nil
+	}
+
+	defer func() {
+		resolvedStepLibSources = map[string]string{}
+	}()
+
+	t.Log("first StepLib having the step wins")
+	{
+		stepIDData, err := resolveStepIDData("script@1.1.0", sources, hasStep)
+		require.NoError(t, err)
+		require.Equal(t, internal, stepIDData.SteplibSource)
+		require.Equal(t, "script", stepIDData.IDorURI)
+		require.Equal(t, "1.1.0", stepIDData.Version)
+	}
+
+	t.Log("explicit source is not resolved")
+	{
+		stepIDData, err := resolveStepIDData(public+"::script@1.1.0", sources, hasStep)
+		require.NoError(t, err)
+		require.Equal(t, public, stepIDData.SteplibSource)
+	}
+
+	t.Log("the StepLibs are updated, if the step is not found")
+	{
+		stepIDData, err := resolveStepIDData("new-step", sources, hasStep)
+		require.NoError(t, err)
+		require.Equal(t, public, stepIDData.SteplibSource)
+		require.Equal(t, 2, updates)
+	}
+
+	t.Log("not found")
+	{
+		_, err := resolveStepIDData("missing-step@2.0.0", sources, hasStep)
+		require.EqualError(t, err, "Step (missing-step@2.0.0) not found in any of the StepLibs: "+internal+", "+public)
+	}
+
+	t.Log("StepLib error fails the resolution")
+	{
+		_, err := resolveStepIDData("broken-step", sources, hasStep)
+		require.EqualError(t, err, "failed to setup steplib")
+	}
+
+	t.Log("single StepLib is not searched")
+	{
+		stepIDData, err := resolveStepIDData("broken-step", []string{public}, hasStep)
+		require.NoError(t, err)
+		require.Equal(t, public, stepIDData.SteplibSource)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"text/template"
 
+	log "github.com/Sirupsen/logrus"
 	"github.com/bitrise-io/bitrise/models"
 	envmanModels "github.com/bitrise-io/envman/models"
 	"github.com/bitrise-io/goinp/goinp"
@@ -23,14 +24,23 @@ type TemplateDataModel struct {
 }
 
 func getEnv(key string, envList envmanModels.EnvsJSONListModel) string {
+	value := os.Getenv(key)
 	if len(envList) > 0 {
-		for aKey, value := range envList {
+		for aKey, aValue := range envList {
 			if aKey == key {
-				return value
+				value = aValue
+				break
 			}
 		}
 	}
-	return os.Getenv(key)
+
+	// spilled outputs are transparent for the templates
+	resolved, err := ResolveSpilledOutput(value)
+	if err != nil {
+		log.Warnf("%s", err)
+		return value
+	}
+	return resolved
 }
 
 func createTemplateDataModel(isCI, isPR bool, buildResults models.BuildRunResultsModel) TemplateDataModel {
@@ -0,0 +1,264 @@
+package bitrise
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Test results (BITRISE_TEST_RESULT_DIR): the steps place their test results into the test result dir,
+// created by the runner (a new temp dir for every run, if BITRISE_TEST_RESULT_DIR is not set).
+// At the end of the run the dir is scanned (recursively), the JUnit XML files (*.xml) and the Xcode result bundles (*.xcresult)
+// are aggregated into a test summary (test counts and failures), printed after the build summary
+// and included in the build summary JSON (see: --summary-path).
+
+// testResultsFailureListLimit is the number of failures listed in the test summary report
+const testResultsFailureListLimit = 20
+
+// TestFailureModel is a failed test case
+type TestFailureModel struct {
+	Suite   string `json:"suite,omitempty"`
+	Name    string `json:"name"`
+	Message string `json:"message,omitempty"`
+	// File is the result file of the test case, relative to the test result dir
+	File string `json:"file"`
+}
+
+// TestResultsSummaryModel is the aggregated test summary of the run
+type TestResultsSummaryModel struct {
+	Total    int                `json:"total"`
+	Passed   int                `json:"passed"`
+	Failed   int                `json:"failed"`
+	Skipped  int                `json:"skipped"`
+	Files    []string           `json:"files"`
+	Failures []TestFailureModel `json:"failures,omitempty"`
+}
+
+type junitFailureModel struct {
+	Message string `xml:"message,attr"`
+	Content string `xml:",chardata"`
+}
+
+type junitTestCaseModel struct {
+	Name      string             `xml:"name,attr"`
+	ClassName string             `xml:"classname,attr"`
+	Failure   *junitFailureModel `xml:"failure"`
+	Error     *junitFailureModel `xml:"error"`
+	Skipped   *struct{}          `xml:"skipped"`
+}
+
+type junitTestSuiteModel struct {
+	XMLName    xml.Name
+	Name       string                `xml:"name,attr"`
+	TestCases  []junitTestCaseModel  `xml:"testcase"`
+	TestSuites []junitTestSuiteModel `xml:"testsuite"`
+}
+
+// xcresultRunner returns the JSON summary of the Xcode result bundle
+var xcresultRunner = func(pth string) ([]byte, error) {
+	args := []string{"xcresulttool", "get", "--format", "json", "--path", pth}
+	out, err := exec.Command("xcrun", args...).Output()
+	if err != nil {
+		// Xcode 16+ requires the --legacy flag for the object API
+		out, err = exec.Command("xcrun", append(args, "--legacy")...).Output()
+	}
+	return out, err
+}
+
+func (summary *TestResultsSummaryModel) addJUnitSuite(suite junitTestSuiteModel, file string) {
+	for _, testCase := range suite.TestCases {
+		summary.Total++
+
+		failure := testCase.Failure
+		if failure == nil {
+			failure = testCase.Error
+		}
+
+		switch {
+		case failure != nil:
+			summary.Failed++
+
+			message := strings.TrimSpace(failure.Message)
+			if message == "" {
+				message = strings.TrimSpace(strings.SplitN(strings.TrimSpace(failure.Content), "\n", 2)[0])
+			}
+			suiteName := suite.Name
+			if testCase.ClassName != "" {
+				suiteName = testCase.ClassName
+			}
+			summary.Failures = append(summary.Failures, TestFailureModel{Suite: suiteName, Name: testCase.Name, Message: message, File: file})
+		case testCase.Skipped != nil:
+			summary.Skipped++
+		default:
+			summary.Passed++
+		}
+	}
+
+	for _, nested := range suite.TestSuites {
+		summary.addJUnitSuite(nested, file)
+	}
+}
+
+// addJUnitFile adds the test cases of the JUnit XML file, returns false if the file is not a JUnit report
+func (summary *TestResultsSummaryModel) addJUnitFile(pth, file string) (bool, error) {
+	content, err := ioutil.ReadFile(pth)
+	if err != nil {
+		return false, err
+	}
+
+	var root junitTestSuiteModel
+	if err := xml.Unmarshal(content, &root); err != nil {
+		return false, fmt.Errorf("Failed to parse JUnit XML (%s), error: %s", file, err)
+	}
+
+	switch root.XMLName.Local {
+	case "testsuites":
+		for _, suite := range root.TestSuites {
+			summary.addJUnitSuite(suite, file)
+		}
+	case "testsuite":
+		summary.addJUnitSuite(root, file)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+type xcresultValueModel struct {
+	Value string `json:"_value"`
+}
+
+// addXCResultBundle adds the test counts and failures of the Xcode result bundle's summary
+func (summary *TestResultsSummaryModel) addXCResultBundle(pth, file string) error {
+	out, err := xcresultRunner(pth)
+	if err != nil {
+		return fmt.Errorf("Failed to read Xcode result bundle (%s), error: %s", file, err)
+	}
+
+	var result struct {
+		Metrics struct {
+			TestsCount        *xcresultValueModel `json:"testsCount"`
+			TestsFailedCount  *xcresultValueModel `json:"testsFailedCount"`
+			TestsSkippedCount *xcresultValueModel `json:"testsSkippedCount"`
+		} `json:"metrics"`
+		Issues struct {
+			TestFailureSummaries struct {
+				Values []struct {
+					TestCaseName xcresultValueModel `json:"testCaseName"`
+					Message      xcresultValueModel `json:"message"`
+				} `json:"_values"`
+			} `json:"testFailureSummaries"`
+		} `json:"issues"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return fmt.Errorf("Failed to parse Xcode result bundle summary (%s), error: %s", file, err)
+	}
+
+	count := func(value *xcresultValueModel) int {
+		if value == nil {
+			return 0
+		}
+		var n int
+		if _, err := fmt.Sscanf(value.Value, "%d", &n); err != nil {
+			return 0
+		}
+		return n
+	}
+
+	total, failed, skipped := count(result.Metrics.TestsCount), count(result.Metrics.TestsFailedCount), count(result.Metrics.TestsSkippedCount)
+	summary.Total += total
+	summary.Failed += failed
+	summary.Skipped += skipped
+	summary.Passed += total - failed - skipped
+
+	for _, failure := range result.Issues.TestFailureSummaries.Values {
+		suite, name := "", failure.TestCaseName.Value
+		if split := strings.SplitN(name, ".", 2); len(split) == 2 {
+			suite, name = split[0], split[1]
+		}
+		summary.Failures = append(summary.Failures, TestFailureModel{Suite: suite, Name: name, Message: failure.Message.Value, File: file})
+	}
+	return nil
+}
+
+// CollectTestResults aggregates the JUnit XML files and Xcode result bundles of the test result dir.
+// Returns nil if the dir has no test results.
+func CollectTestResults(testResultDir string) (*TestResultsSummaryModel, error) {
+	if _, err := os.Stat(testResultDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	summary := &TestResultsSummaryModel{Files: []string{}, Failures: []TestFailureModel{}}
+	err := filepath.Walk(testResultDir, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		file, err := filepath.Rel(testResultDir, pth)
+		if err != nil {
+			return err
+		}
+		file = filepath.ToSlash(file)
+
+		switch {
+		case info.IsDir() && strings.HasSuffix(info.Name(), ".xcresult"):
+			if err := summary.addXCResultBundle(pth, file); err != nil {
+				log.Warnf("%s", err)
+			} else {
+				summary.Files = append(summary.Files, file)
+			}
+			return filepath.SkipDir
+		case info.Mode().IsRegular() && strings.EqualFold(filepath.Ext(pth), ".xml"):
+			isJUnit, err := summary.addJUnitFile(pth, file)
+			if err != nil {
+				log.Warnf("%s", err)
+			} else if isJUnit {
+				summary.Files = append(summary.Files, file)
+			} else {
+				log.Debugf("Not a JUnit XML: %s", file)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to scan test result dir (%s), error: %s", testResultDir, err)
+	}
+
+	if len(summary.Files) == 0 {
+		return nil, nil
+	}
+	sort.Strings(summary.Files)
+	return summary, nil
+}
+
+// TestResultsReport returns the test counts and the first failures of the test summary
+func TestResultsReport(summary TestResultsSummaryModel) string {
+	lines := []string{fmt.Sprintf("Test results: %d tests, %d passed, %d failed, %d skipped (%d result files)",
+		summary.Total, summary.Passed, summary.Failed, summary.Skipped, len(summary.Files))}
+
+	for idx, failure := range summary.Failures {
+		if idx == testResultsFailureListLimit {
+			lines = append(lines, fmt.Sprintf("  ... and %d more failures", len(summary.Failures)-testResultsFailureListLimit))
+			break
+		}
+
+		name := failure.Name
+		if failure.Suite != "" {
+			name = failure.Suite + "." + name
+		}
+		line := "  - " + name
+		if failure.Message != "" {
+			line += ": " + failure.Message
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,137 @@
+package bitrise
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+const testJUnitXML = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="LoginTests" tests="3">
+    <testcase name="testLogin" classname="app.LoginTests" time="0.1"/>
+    <testcase name="testLogout" classname="app.LoginTests" time="0.2">
+      <failure message="expected true, got false">stack trace</failure>
+    </testcase>
+    <testcase name="testSkipped" classname="app.LoginTests">
+      <skipped/>
+    </testcase>
+  </testsuite>
+  <testsuite name="ApiTests">
+    <testcase name="testTimeout">
+      <error>connection timed out
+at ApiTests.swift:12</error>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+
+const testXCResultJSON = `{
+  "metrics": {
+    "testsCount": {"_value": "10"},
+    "testsFailedCount": {"_value": "1"},
+    "testsSkippedCount": {"_value": "2"}
+  },
+  "issues": {
+    "testFailureSummaries": {
+      "_values": [
+        {"testCaseName": {"_value": "CartTests.testCheckout()"}, "message": {"_value": "XCTAssertEqual failed"}}
+      ]
+    }
+  }
+}`
+
+func TestCollectTestResults(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__test_results__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	originalXCResultRunner := xcresultRunner
+	defer func() {
+		xcresultRunner = originalXCResultRunner
+	}()
+
+	t.Log("no test results")
+	{
+		summary, err := CollectTestResults(tmpDir)
+		require.NoError(t, err)
+		require.Nil(t, summary)
+
+		summary, err = CollectTestResults(filepath.Join(tmpDir, "not-exist"))
+		require.NoError(t, err)
+		require.Nil(t, summary)
+	}
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "unit_tests"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "unit_tests", "junit.xml"), []byte(testJUnitXML), 0666))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "unit_tests", "config.xml"), []byte("<config/>"), 0666))
+
+	t.Log("JUnit XML")
+	{
+		summary, err := CollectTestResults(tmpDir)
+		require.NoError(t, err)
+		require.Equal(t, &TestResultsSummaryModel{
+			Total:   4,
+			Passed:  1,
+			Failed:  2,
+			Skipped: 1,
+			Files:   []string{"unit_tests/junit.xml"},
+			Failures: []TestFailureModel{
+				{Suite: "app.LoginTests", Name: "testLogout", Message: "expected true, got false", File: "unit_tests/junit.xml"},
+				{Suite: "ApiTests", Name: "testTimeout", Message: "connection timed out", File: "unit_tests/junit.xml"},
+			},
+		}, summary)
+
+		require.Equal(t, "Test results: 4 tests, 1 passed, 2 failed, 1 skipped (1 result files)\n"+
+			"  - app.LoginTests.testLogout: expected true, got false\n"+
+			"  - ApiTests.testTimeout: connection timed out", TestResultsReport(*summary))
+	}
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "ui_tests", "Test.xcresult", "Data"), 0777))
+
+	t.Log("Xcode result bundle")
+	{
+		xcresultRunner = func(pth string) ([]byte, error) {
+			require.Equal(t, filepath.Join(tmpDir, "ui_tests", "Test.xcresult"), pth)
+			return []byte(testXCResultJSON), nil
+		}
+
+		summary, err := CollectTestResults(tmpDir)
+		require.NoError(t, err)
+		require.Equal(t, 14, summary.Total)
+		require.Equal(t, 8, summary.Passed)
+		require.Equal(t, 3, summary.Failed)
+		require.Equal(t, 3, summary.Skipped)
+		require.Equal(t, []string{"ui_tests/Test.xcresult", "unit_tests/junit.xml"}, summary.Files)
+		require.Equal(t, TestFailureModel{Suite: "CartTests", Name: "testCheckout()", Message: "XCTAssertEqual failed", File: "ui_tests/Test.xcresult"}, summary.Failures[0])
+	}
+
+	t.Log("unreadable Xcode result bundle is skipped")
+	{
+		xcresultRunner = func(pth string) ([]byte, error) {
+			return nil, errors.New("xcrun: not found")
+		}
+
+		summary, err := CollectTestResults(tmpDir)
+		require.NoError(t, err)
+		require.Equal(t, 4, summary.Total)
+		require.Equal(t, []string{"unit_tests/junit.xml"}, summary.Files)
+	}
+
+	t.Log("failure list limit")
+	{
+		summary := TestResultsSummaryModel{}
+		for i := 0; i < testResultsFailureListLimit+3; i++ {
+			summary.Failures = append(summary.Failures, TestFailureModel{Name: "test"})
+		}
+		report := TestResultsReport(summary)
+		require.Contains(t, report, "  ... and 3 more failures")
+	}
+}
@@ -0,0 +1,153 @@
+package bitrise
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bitrise-io/bitrise/exitcode"
+)
+
+// Timeouts of the run.
+// Workflow timeout (timeout_secs): the time limit of running a workflow's steps.
+// Build timeout (--timeout): the time limit of the whole invocation (every chained workflow).
+// Once a timeout is exceeded, the running step is aborted and fails with the timeout error (exitcode.StepTimedOut).
+// The rest of the steps are skipped as after any failed step, the is_always_run steps still run.
+// The build timeout has a grace period for these cleanup steps, once the grace period is exceeded too,
+// the running step is aborted and the rest of the steps are skipped, then the build finishes (summary, reports) as usual.
+// The reporting has a deadline too (BuildTimeoutReportingSecs), as a hang outside of a step process (e.g. an activation,
+// a plugin or an upload) is not aborted by killing the running step, the CLI exits once the deadline is exceeded.
+
+// DefaultBuildTimeoutGraceSecs is the default grace period of the build timeout, in seconds
+const DefaultBuildTimeoutGraceSecs = 60
+
+// BuildTimeoutReportingSecs is the time limit of finishing the build after the grace period of the build timeout, in seconds
+const BuildTimeoutReportingSecs = 60
+
+// ErrBuildTimeoutGraceExpired is the error of the steps aborted or skipped, once the grace period of the build timeout is exceeded
+var ErrBuildTimeoutGraceExpired = errors.New("the build timed out, and the grace period of the is_always_run steps is exceeded")
+
+const (
+	timeoutRunning = iota
+	timeoutExpired
+	timeoutReported
+)
+
+// TimeoutModel tracks a timeout of the run (the workflow's or the build's)
+type TimeoutModel struct {
+	name    string
+	timeout time.Duration
+	timer   *time.Timer
+	// state is set from the timer goroutine, use atomic access
+	state int32
+
+	graceMutex     sync.Mutex
+	graceTimer     *time.Timer
+	reportingTimer *time.Timer
+	isStopped      bool
+	// graceExpired is set from the grace timer goroutine, use atomic access
+	graceExpired int32
+}
+
+func (timeoutModel *TimeoutModel) start(onExpire func()) *TimeoutModel {
+	timeoutModel.timer = time.AfterFunc(timeoutModel.timeout, func() {
+		if atomic.CompareAndSwapInt32(&timeoutModel.state, timeoutRunning, timeoutExpired) {
+			onExpire()
+		}
+	})
+	return timeoutModel
+}
+
+// StartWorkflowTimeout starts the timeout of the workflow,
+// onExpire is called (on the timer's goroutine) once the timeout is exceeded, to abort the running step.
+// Returns nil if the workflow has no timeout.
+func StartWorkflowTimeout(timeoutSecs int, onExpire func()) *TimeoutModel {
+	if timeoutSecs <= 0 {
+		return nil
+	}
+	workflowTimeout := &TimeoutModel{name: "workflow", timeout: time.Duration(timeoutSecs) * time.Second}
+	return workflowTimeout.start(onExpire)
+}
+
+// StartBuildTimeout starts the timeout of the build, onExpire is called once the timeout is exceeded (see: StartWorkflowTimeout),
+// onGraceExpire once the grace period after the timeout is exceeded as well, to abort the running step,
+// and onReportingExpire once the build did not finish in reportingSecs after the grace period, to exit the CLI.
+// Returns nil if the timeout is not set.
+func StartBuildTimeout(timeoutSecs, graceSecs, reportingSecs int, onExpire, onGraceExpire, onReportingExpire func()) *TimeoutModel {
+	if timeoutSecs <= 0 {
+		return nil
+	}
+
+	buildTimeout := &TimeoutModel{name: "build", timeout: time.Duration(timeoutSecs) * time.Second}
+	return buildTimeout.start(func() {
+		buildTimeout.graceMutex.Lock()
+		if !buildTimeout.isStopped {
+			buildTimeout.graceTimer = time.AfterFunc(time.Duration(graceSecs)*time.Second, func() {
+				atomic.StoreInt32(&buildTimeout.graceExpired, 1)
+
+				buildTimeout.graceMutex.Lock()
+				if !buildTimeout.isStopped {
+					buildTimeout.reportingTimer = time.AfterFunc(time.Duration(reportingSecs)*time.Second, onReportingExpire)
+				}
+				buildTimeout.graceMutex.Unlock()
+
+				onGraceExpire()
+			})
+		}
+		buildTimeout.graceMutex.Unlock()
+
+		onExpire()
+	})
+}
+
+// Stop stops the timeout (and its grace period and reporting deadline), once the build finished
+func (timeoutModel *TimeoutModel) Stop() {
+	if timeoutModel == nil {
+		return
+	}
+	timeoutModel.timer.Stop()
+
+	timeoutModel.graceMutex.Lock()
+	defer timeoutModel.graceMutex.Unlock()
+
+	timeoutModel.isStopped = true
+	if timeoutModel.graceTimer != nil {
+		timeoutModel.graceTimer.Stop()
+	}
+	if timeoutModel.reportingTimer != nil {
+		timeoutModel.reportingTimer.Stop()
+	}
+}
+
+// IsExpired returns true if the timeout is exceeded (false for a nil timeout)
+func (timeoutModel *TimeoutModel) IsExpired() bool {
+	if timeoutModel == nil {
+		return false
+	}
+	return atomic.LoadInt32(&timeoutModel.state) != timeoutRunning
+}
+
+// IsGraceExpired returns true if the grace period after the timeout is exceeded too (false for a nil timeout),
+// the steps should not run anymore.
+func (timeoutModel *TimeoutModel) IsGraceExpired() bool {
+	if timeoutModel == nil {
+		return false
+	}
+	return atomic.LoadInt32(&timeoutModel.graceExpired) == 1
+}
+
+// ReportExpired returns true only for the first call after the timeout is exceeded:
+// the step, which is reported as timed out (the running step, or the next step which would not run on a failed build).
+// The later steps run as after any failed step.
+func (timeoutModel *TimeoutModel) ReportExpired() bool {
+	if timeoutModel == nil {
+		return false
+	}
+	return atomic.CompareAndSwapInt32(&timeoutModel.state, timeoutExpired, timeoutReported)
+}
+
+// Err returns the error of the timed out step
+func (timeoutModel *TimeoutModel) Err() error {
+	return exitcode.Errorf(exitcode.StepTimedOut, "the %s timed out (timeout: %s)", timeoutModel.name, timeoutModel.timeout)
+}
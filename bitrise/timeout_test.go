@@ -0,0 +1,139 @@
+package bitrise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/bitrise/exitcode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowTimeout(t *testing.T) {
+	t.Log("no timeout")
+	{
+		workflowTimeout := StartWorkflowTimeout(0, func() {})
+		require.Nil(t, workflowTimeout)
+		require.False(t, workflowTimeout.IsExpired())
+		require.False(t, workflowTimeout.ReportExpired())
+		workflowTimeout.Stop()
+	}
+
+	t.Log("not expired")
+	{
+		workflowTimeout := StartWorkflowTimeout(60, func() {})
+		require.False(t, workflowTimeout.IsExpired())
+		require.False(t, workflowTimeout.ReportExpired())
+		workflowTimeout.Stop()
+	}
+
+	t.Log("expired")
+	{
+		expired := make(chan bool, 1)
+		workflowTimeout := StartWorkflowTimeout(1, func() {
+			expired <- true
+		})
+		defer workflowTimeout.Stop()
+
+		select {
+		case <-expired:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the timeout did not expire")
+		}
+
+		require.True(t, workflowTimeout.IsExpired())
+		require.True(t, workflowTimeout.ReportExpired())
+		require.False(t, workflowTimeout.ReportExpired())
+		require.True(t, workflowTimeout.IsExpired())
+
+		err := workflowTimeout.Err()
+		require.EqualError(t, err, "the workflow timed out (timeout: 1s)")
+		require.True(t, exitcode.Is(err, exitcode.StepTimedOut))
+	}
+}
+
+func TestBuildTimeout(t *testing.T) {
+	t.Log("no timeout")
+	{
+		buildTimeout := StartBuildTimeout(0, 10, 10, func() {}, func() {}, func() {})
+		require.Nil(t, buildTimeout)
+		require.False(t, buildTimeout.IsGraceExpired())
+	}
+
+	t.Log("expired, then the grace period expired")
+	{
+		expired := make(chan bool, 1)
+		graceExpired := make(chan bool, 1)
+		reportingExpired := make(chan bool, 1)
+		buildTimeout := StartBuildTimeout(1, 1, 1, func() {
+			expired <- true
+		}, func() {
+			graceExpired <- true
+		}, func() {
+			reportingExpired <- true
+		})
+		defer buildTimeout.Stop()
+
+		select {
+		case <-expired:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the timeout did not expire")
+		}
+		require.True(t, buildTimeout.ReportExpired())
+		require.EqualError(t, buildTimeout.Err(), "the build timed out (timeout: 1s)")
+		require.False(t, buildTimeout.IsGraceExpired())
+
+		select {
+		case <-graceExpired:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the grace period did not expire")
+		}
+		require.True(t, buildTimeout.IsGraceExpired())
+
+		select {
+		case <-reportingExpired:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the reporting deadline did not expire")
+		}
+	}
+
+	t.Log("stopped before the reporting deadline")
+	{
+		graceExpired := make(chan bool, 1)
+		reportingExpired := make(chan bool, 1)
+		buildTimeout := StartBuildTimeout(1, 1, 1, func() {}, func() {
+			graceExpired <- true
+		}, func() {
+			reportingExpired <- true
+		})
+
+		<-graceExpired
+		buildTimeout.Stop()
+
+		select {
+		case <-reportingExpired:
+			t.Fatal("the reporting deadline expired after the timeout was stopped")
+		case <-time.After(2 * time.Second):
+		}
+	}
+
+	t.Log("stopped in the grace period")
+	{
+		expired := make(chan bool, 1)
+		graceExpired := make(chan bool, 1)
+		buildTimeout := StartBuildTimeout(1, 1, 1, func() {
+			expired <- true
+		}, func() {
+			graceExpired <- true
+		}, func() {})
+
+		<-expired
+		buildTimeout.Stop()
+
+		select {
+		case <-graceExpired:
+			t.Fatal("the grace period expired after the timeout was stopped")
+		case <-time.After(2 * time.Second):
+		}
+		require.False(t, buildTimeout.IsGraceExpired())
+	}
+}
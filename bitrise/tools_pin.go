@@ -0,0 +1,91 @@
+package bitrise
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/tools"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-utils/retry"
+	"github.com/bitrise-io/go-utils/versions"
+)
+
+// PinnedToolModel ...
+type PinnedToolModel struct {
+	Name       string
+	Version    string
+	MinVersion string
+}
+
+// PinnedTools returns the tool versions pinned by the config
+func PinnedTools(toolsConfig *models.ToolsModel) []PinnedToolModel {
+	pinned := []PinnedToolModel{}
+	if toolsConfig == nil {
+		return pinned
+	}
+
+	if toolsConfig.Stepman != "" {
+		pinned = append(pinned, PinnedToolModel{Name: "stepman", Version: toolsConfig.Stepman, MinVersion: minStepmanVersion})
+	}
+	if toolsConfig.Envman != "" {
+		pinned = append(pinned, PinnedToolModel{Name: "envman", Version: toolsConfig.Envman, MinVersion: minEnvmanVersion})
+	}
+	return pinned
+}
+
+// SetupPinnedTools installs the tool versions pinned by the config (if not installed yet),
+// and makes them the ones used by the CLI, instead of the globally installed versions.
+func SetupPinnedTools(toolsConfig *models.ToolsModel) error {
+	for _, tool := range PinnedTools(toolsConfig) {
+		if tool.Version == tools.LatestToolVersion {
+			version, err := tools.ResolveToolVersion(tool.Name, "bitrise-io", tool.Version)
+			if err != nil {
+				return err
+			}
+			log.Debugf("Latest %s version: %s", tool.Name, version)
+			tool.Version = version
+		}
+
+		isSupported, err := versions.IsVersionGreaterOrEqual(tool.Version, tool.MinVersion)
+		if err != nil {
+			return fmt.Errorf("Failed to compare %s versions, error: %s", tool.Name, err)
+		}
+		if !isSupported {
+			return fmt.Errorf("pinned %s version (%s) is lower than the minimum supported version (%s)", tool.Name, tool.Version, tool.MinVersion)
+		}
+
+		binDirPth := configs.GetBitriseToolVersionDirPath(tool.Name, tool.Version)
+		binPth := filepath.Join(binDirPth, tool.Name)
+
+		if exist, err := pathutil.IsPathExists(binPth); err != nil {
+			return err
+		} else if !exist {
+			log.Infof("Installing pinned %s (%s) ...", tool.Name, tool.Version)
+
+			toolName, toolVersion := tool.Name, tool.Version
+			if err := retry.Times(2).Wait(5 * time.Second).Try(func(attempt uint) error {
+				if attempt > 0 {
+					log.Warnf("Download failed, retrying ...")
+				}
+				return tools.InstallToolVersionFromGitHub(toolName, "bitrise-io", toolVersion)
+			}); err != nil {
+				if removeErr := os.RemoveAll(binDirPth); removeErr != nil {
+					log.Warnf("Failed to remove (%s), error: %s", binDirPth, removeErr)
+				}
+				return fmt.Errorf("Failed to install pinned %s (%s), error: %s", tool.Name, tool.Version, err)
+			}
+		}
+
+		if err := tools.UsePinnedTool(tool.Name, binDirPth); err != nil {
+			return err
+		}
+		log.Debugf("Using pinned %s (%s): %s", tool.Name, tool.Version, binPth)
+	}
+
+	return nil
+}
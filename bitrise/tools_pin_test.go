@@ -0,0 +1,34 @@
+package bitrise
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPinnedTools(t *testing.T) {
+	require.Equal(t, []PinnedToolModel{}, PinnedTools(nil))
+	require.Equal(t, []PinnedToolModel{}, PinnedTools(&models.ToolsModel{}))
+	require.Equal(t, []PinnedToolModel{
+		PinnedToolModel{Name: "stepman", Version: "0.9.30", MinVersion: minStepmanVersion},
+		PinnedToolModel{Name: "envman", Version: "1.1.3", MinVersion: minEnvmanVersion},
+	}, PinnedTools(&models.ToolsModel{Stepman: "0.9.30", Envman: "1.1.3"}))
+}
+
+func TestSetupPinnedTools(t *testing.T) {
+	t.Log("version lower than the minimum supported")
+	{
+		err := SetupPinnedTools(&models.ToolsModel{Envman: "0.9.0"})
+		require.EqualError(t, err, "pinned envman version (0.9.0) is lower than the minimum supported version ("+minEnvmanVersion+")")
+		require.Equal(t, false, tools.IsToolPinned("envman"))
+	}
+
+	t.Log("no pinned tools")
+	{
+		require.NoError(t, SetupPinnedTools(nil))
+		require.Equal(t, false, tools.IsToolPinned("stepman"))
+		require.Equal(t, false, tools.IsToolPinned("envman"))
+	}
+}
@@ -24,6 +24,9 @@ import (
 
 // InventoryModelFromYAMLBytes ...
 func InventoryModelFromYAMLBytes(inventoryBytes []byte) (inventory envmanModels.EnvsYMLModel, err error) {
+	if inventoryBytes, err = decryptSecretsIfEncrypted(inventoryBytes); err != nil {
+		return
+	}
 	if err = yaml.Unmarshal(inventoryBytes, &inventory); err != nil {
 		return
 	}
@@ -50,6 +53,11 @@ func CollectEnvironmentsFromFile(pth string) ([]envmanModels.EnvironmentItemMode
 		return []envmanModels.EnvironmentItemModel{}, err
 	}
 
+	bytes, err = decryptSecretsIfEncrypted(bytes)
+	if err != nil {
+		return []envmanModels.EnvironmentItemModel{}, err
+	}
+
 	var envstore envmanModels.EnvsYMLModel
 	if err := yaml.Unmarshal(bytes, &envstore); err != nil {
 		return []envmanModels.EnvironmentItemModel{}, err
@@ -70,6 +78,27 @@ func CollectEnvironmentsFromFile(pth string) ([]envmanModels.EnvironmentItemMode
 	return envstore.Envs, nil
 }
 
+// FilterExportedEnvironments returns the environments listed in exports.
+// Used for the steps with isolated envs, to propagate only the explicitly exported outputs.
+func FilterExportedEnvironments(environments []envmanModels.EnvironmentItemModel, exports []string) ([]envmanModels.EnvironmentItemModel, error) {
+	exportMap := map[string]bool{}
+	for _, key := range exports {
+		exportMap[key] = true
+	}
+
+	exported := []envmanModels.EnvironmentItemModel{}
+	for _, env := range environments {
+		key, _, err := env.GetKeyValuePair()
+		if err != nil {
+			return []envmanModels.EnvironmentItemModel{}, err
+		}
+		if exportMap[key] {
+			exported = append(exported, env)
+		}
+	}
+	return exported, nil
+}
+
 // ExportEnvironmentsList ...
 func ExportEnvironmentsList(envsList []envmanModels.EnvironmentItemModel) error {
 	log.Debugln("[BITRISE_CLI] - Exporting environments:", envsList)
@@ -179,9 +208,9 @@ func FormattedSecondsToMax8Chars(t time.Duration) (string, error) {
 	return "", fmt.Errorf("time (%f hour) greater then max allowed (999 hour)", hour)
 }
 
-// SaveConfigToFile ...
+// SaveConfigToFile saves the config in the format of the file (see: ConfigFormatOfPath)
 func SaveConfigToFile(pth string, bitriseConf models.BitriseDataModel) error {
-	contBytes, err := generateYAML(bitriseConf)
+	contBytes, err := MarshalConfig(bitriseConf, ConfigFormatOfPath(pth), true)
 	if err != nil {
 		return err
 	}
@@ -199,11 +228,24 @@ func generateYAML(v interface{}) ([]byte, error) {
 	return bytes, nil
 }
 
-func normalizeValidateFillMissingDefaults(bitriseData *models.BitriseDataModel) ([]string, error) {
+// normalizeValidateFillMissingDefaults resolves the config.
+// If workflow IDs are given, only these workflows (and the workflows referenced by them) are kept and resolved,
+// see: models.BitriseDataModel.ScopeToWorkflows.
+func normalizeValidateFillMissingDefaults(bitriseData *models.BitriseDataModel, workflowIDs []string) ([]string, error) {
+	migrations, err := bitriseData.MigrateLegacyFormat()
+	if err != nil {
+		return []string{}, err
+	}
+	if len(workflowIDs) > 0 && !bitriseData.ScopeToWorkflows(workflowIDs) {
+		log.Debugf("Not every workflow (%s) exists, resolving every workflow", strings.Join(workflowIDs, ", "))
+	}
 	if err := bitriseData.Normalize(); err != nil {
 		return []string{}, err
 	}
 	warnings, err := bitriseData.Validate()
+	for _, migration := range migrations {
+		warnings = append(warnings, "legacy config converted: "+migration.String())
+	}
 	if err != nil {
 		return warnings, err
 	}
@@ -214,13 +256,22 @@ func normalizeValidateFillMissingDefaults(bitriseData *models.BitriseDataModel)
 }
 
 // ConfigModelFromYAMLBytes ...
+// The returned error contains the location (line, column) of the invalid config element, if it can be determined.
 func ConfigModelFromYAMLBytes(configBytes []byte) (bitriseData models.BitriseDataModel, warnings []string, err error) {
+	return configModelFromYAMLBytes(configBytes, nil)
+}
+
+func configModelFromYAMLBytes(configBytes []byte, workflowIDs []string) (bitriseData models.BitriseDataModel, warnings []string, err error) {
 	if err = yaml.Unmarshal(configBytes, &bitriseData); err != nil {
+		_, parseErrors := ParseConfigNodes(string(configBytes))
+		err = ConfigSyntaxErrorModel{Err: err, Errors: parseErrors}
 		return
 	}
 
-	warnings, err = normalizeValidateFillMissingDefaults(&bitriseData)
+	warnings, err = normalizeValidateFillMissingDefaults(&bitriseData, workflowIDs)
 	if err != nil {
+		root, _ := ParseConfigNodes(string(configBytes))
+		err = LocateConfigError(root, err)
 		return
 	}
 
@@ -229,10 +280,14 @@ func ConfigModelFromYAMLBytes(configBytes []byte) (bitriseData models.BitriseDat
 
 // ConfigModelFromJSONBytes ...
 func ConfigModelFromJSONBytes(configBytes []byte) (bitriseData models.BitriseDataModel, warnings []string, err error) {
+	return configModelFromJSONBytes(configBytes, nil)
+}
+
+func configModelFromJSONBytes(configBytes []byte, workflowIDs []string) (bitriseData models.BitriseDataModel, warnings []string, err error) {
 	if err = json.Unmarshal(configBytes, &bitriseData); err != nil {
 		return
 	}
-	warnings, err = normalizeValidateFillMissingDefaults(&bitriseData)
+	warnings, err = normalizeValidateFillMissingDefaults(&bitriseData, workflowIDs)
 	if err != nil {
 		return
 	}
@@ -242,6 +297,10 @@ func ConfigModelFromJSONBytes(configBytes []byte) (bitriseData models.BitriseDat
 
 // ReadBitriseConfig ...
 func ReadBitriseConfig(pth string) (models.BitriseDataModel, []string, error) {
+	return readBitriseConfigForWorkflows(pth, nil)
+}
+
+func readBitriseConfigForWorkflows(pth string, workflowIDs []string) (models.BitriseDataModel, []string, error) {
 	log.Debugln("-> ReadBitriseConfig")
 	if isExists, err := pathutil.IsPathExists(pth); err != nil {
 		return models.BitriseDataModel{}, []string{}, err
@@ -254,13 +313,9 @@ func ReadBitriseConfig(pth string) (models.BitriseDataModel, []string, error) {
 		return models.BitriseDataModel{}, []string{}, err
 	}
 
-	if strings.HasSuffix(pth, ".json") {
-		log.Debugln("=> Using JSON parser for: ", pth)
-		return ConfigModelFromJSONBytes(bytes)
-	}
-
-	log.Debugln("=> Using YAML parser for: ", pth)
-	return ConfigModelFromYAMLBytes(bytes)
+	format := ConfigFormatOfPath(pth)
+	log.Debugf("=> Using %s parser for: %s", format, pth)
+	return ConfigModelFromBytesForWorkflows(bytes, format, workflowIDs)
 }
 
 // ReadSpecStep ...
@@ -361,12 +416,36 @@ func isDependencySliceWithSameElements(s1, s2 []stepmanModels.DependencyModel) b
 	return len(m) == 0
 }
 
+// removeWorkflowStepRedundantFields removes the workflow specific step fields (which are not part of the step.yml),
+// if they have the default value
+func removeWorkflowStepRedundantFields(workflowStep *models.WorkflowStepModel) {
+	if workflowStep.RunOnFailureOnly != nil && *workflowStep.RunOnFailureOnly == models.DefaultRunOnFailureOnly {
+		workflowStep.RunOnFailureOnly = nil
+	}
+	if workflowStep.IsolateEnvs != nil && *workflowStep.IsolateEnvs == models.DefaultIsolateEnvs {
+		workflowStep.IsolateEnvs = nil
+	}
+	if len(workflowStep.Exports) == 0 {
+		workflowStep.Exports = nil
+	}
+	if workflowStep.WorkingDir != nil && *workflowStep.WorkingDir == "" {
+		workflowStep.WorkingDir = nil
+	}
+	if workflowStep.ResourceLimits != nil && *workflowStep.ResourceLimits == (models.ResourceLimitsModel{}) {
+		workflowStep.ResourceLimits = nil
+	}
+}
+
 func removeStepDefaultsAndFillStepOutputs(stepListItem *models.StepListItemModel, defaultStepLibSource string) error {
 	// Create stepIDData
 	compositeStepIDStr, workflowStep, err := models.GetStepIDStepDataPair(*stepListItem)
 	if err != nil {
 		return err
 	}
+
+	removeWorkflowStepRedundantFields(&workflowStep)
+	(*stepListItem)[compositeStepIDStr] = workflowStep
+
 	stepIDData, err := models.CreateStepIDDataFromString(compositeStepIDStr, defaultStepLibSource)
 	if err != nil {
 		return err
@@ -392,7 +471,7 @@ func removeStepDefaultsAndFillStepOutputs(stepListItem *models.StepListItemModel
 			return err
 		}
 	} else if stepIDData.SteplibSource == "git" {
-		if err := cmdex.GitCloneTagOrBranch(stepIDData.IDorURI, tempStepCloneDirPath, stepIDData.Version); err != nil {
+		if err := tools.GitCloneTagOrBranch(stepIDData.IDorURI, tempStepCloneDirPath, stepIDData.Version); err != nil {
 			return err
 		}
 		if err := cmdex.CopyFile(filepath.Join(tempStepCloneDirPath, "step.yml"), tempStepYMLFilePath); err != nil {
@@ -401,6 +480,14 @@ func removeStepDefaultsAndFillStepOutputs(stepListItem *models.StepListItemModel
 	} else if stepIDData.SteplibSource == "_" {
 		// Steplib independent steps are completly defined in workflow
 		tempStepYMLFilePath = ""
+	} else if stepIDData.SteplibSource == models.OCIStepSource {
+		if err := ActivateOCIStep(stepIDData, tempStepCloneDirPath, tempStepYMLFilePath); err != nil {
+			return err
+		}
+	} else if stepIDData.SteplibSource == models.ArchiveStepSource {
+		if err := ActivateArchiveStep(stepIDData, tempStepCloneDirPath, tempStepYMLFilePath); err != nil {
+			return err
+		}
 	} else if stepIDData.SteplibSource != "" {
 		if err := tools.StepmanSetup(stepIDData.SteplibSource); err != nil {
 			return err
@@ -604,6 +691,21 @@ func removeStepDefaultsAndFillStepOutputs(stepListItem *models.StepListItemModel
 func RemoveConfigRedundantFieldsAndFillStepOutputs(config *models.BitriseDataModel) error {
 	for _, workflow := range config.Workflows {
 		for _, stepListItem := range workflow.Steps {
+			compositeStepID, _, err := models.GetStepIDStepDataPair(stepListItem)
+			if err != nil {
+				return err
+			}
+			if models.IsStepBundleReference(compositeStepID) {
+				continue
+			}
+
+			if err := removeStepDefaultsAndFillStepOutputs(&stepListItem, config.DefaultStepLibSource); err != nil {
+				return err
+			}
+		}
+	}
+	for _, bundle := range config.StepBundles {
+		for _, stepListItem := range bundle.Steps {
 			if err := removeStepDefaultsAndFillStepOutputs(&stepListItem, config.DefaultStepLibSource); err != nil {
 				return err
 			}
@@ -6,6 +6,9 @@ import (
 	"time"
 
 	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/pointers"
 	stepmanModels "github.com/bitrise-io/stepman/models"
 	"github.com/stretchr/testify/require"
 )
@@ -80,6 +83,31 @@ func TestTimeToFormattedSeconds(t *testing.T) {
 	}
 }
 
+func TestFilterExportedEnvironments(t *testing.T) {
+	environments := []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"BUILD_PATH": "path/to/build"},
+		envmanModels.EnvironmentItemModel{"TMP_DIR": "tmp"},
+		envmanModels.EnvironmentItemModel{"BUILD_NUMBER": "42"},
+	}
+
+	t.Log("no exports")
+	{
+		exported, err := FilterExportedEnvironments(environments, []string{})
+		require.NoError(t, err)
+		require.Equal(t, 0, len(exported))
+	}
+
+	t.Log("exports keeps the order of the environments")
+	{
+		exported, err := FilterExportedEnvironments(environments, []string{"BUILD_NUMBER", "BUILD_PATH", "NOT_EXISTING"})
+		require.NoError(t, err)
+		require.Equal(t, []envmanModels.EnvironmentItemModel{
+			envmanModels.EnvironmentItemModel{"BUILD_PATH": "path/to/build"},
+			envmanModels.EnvironmentItemModel{"BUILD_NUMBER": "42"},
+		}, exported)
+	}
+}
+
 func TestRemoveConfigRedundantFieldsAndFillStepOutputs(t *testing.T) {
 	// setup
 	require.NoError(t, configs.InitPaths())
@@ -155,6 +183,35 @@ func TestRemoveConfigRedundantFieldsAndFillStepOutputs(t *testing.T) {
 
 }
 
+func TestRemoveWorkflowStepRedundantFields(t *testing.T) {
+	t.Log("default values")
+	{
+		step := models.WorkflowStepModel{
+			RunOnFailureOnly: pointers.NewBoolPtr(false),
+			IsolateEnvs:      pointers.NewBoolPtr(false),
+			Exports:          []string{},
+			WorkingDir:       pointers.NewStringPtr(""),
+			ResourceLimits:   &models.ResourceLimitsModel{},
+		}
+		removeWorkflowStepRedundantFields(&step)
+		require.Equal(t, models.WorkflowStepModel{}, step)
+	}
+
+	t.Log("non default values")
+	{
+		step := models.WorkflowStepModel{
+			RunOnFailureOnly: pointers.NewBoolPtr(true),
+			IsolateEnvs:      pointers.NewBoolPtr(true),
+			Exports:          []string{"BITRISE_IPA_PATH"},
+			WorkingDir:       pointers.NewStringPtr("./ios"),
+			ResourceLimits:   &models.ResourceLimitsModel{CPUs: 2},
+		}
+		expected := step
+		removeWorkflowStepRedundantFields(&step)
+		require.Equal(t, expected, step)
+	}
+}
+
 func TestSsStringSliceWithSameElements(t *testing.T) {
 	s1 := []string{}
 	s2 := []string{}
@@ -265,6 +322,31 @@ workflows:
 	require.Equal(t, 6, len(workflow.Steps))
 }
 
+func TestConfigModelFromYAMLBytesLegacyFormat(t *testing.T) {
+	configStr := `
+format_version: 1.2.0
+default_step_lib_source: "https://github.com/bitrise-io/bitrise-steplib.git"
+
+trigger_map:
+- pattern: "*"
+  is_pull_request_allowed: true
+  workflow: primary
+
+workflows:
+  primary:
+`
+	config, warnings, err := ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"legacy config converted: trigger_map[0]: pattern (*) converted to push_branch (*) and pull_request_source_branch (*) items (format version 1.3.0)",
+	}, warnings)
+
+	require.Equal(t, models.TriggerMapModel{
+		models.TriggerMapItemModel{PushBranch: "*", WorkflowID: "primary"},
+		models.TriggerMapItemModel{PullRequestSourceBranch: "*", WorkflowID: "primary"},
+	}, config.TriggerMap)
+}
+
 func TestConfigModelFromJSONBytes(t *testing.T) {
 	configStr := `
 {
@@ -432,3 +514,40 @@ func TestConfigModelFromJSONBytesNormalize(t *testing.T) {
 	_, err = json.MarshalIndent(config, "", "\t")
 	require.NoError(t, err)
 }
+
+func TestConfigModelFromYAMLBytesStepDefaults(t *testing.T) {
+	configStr := `
+format_version: 1.3.0
+default_step_lib_source: "https://github.com/bitrise-io/bitrise-steplib.git"
+
+step_defaults:
+  script:
+    inputs:
+    - content: echo "default"
+      opts:
+        is_expand: false
+
+workflows:
+  primary:
+    steps:
+    - script:
+`
+	config, warnings, err := ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	require.NoError(t, config.ApplyStepDefaults())
+
+	_, step, err := models.GetStepIDStepDataPair(config.Workflows["primary"].Steps[0])
+	require.NoError(t, err)
+	require.Equal(t, 1, len(step.Inputs))
+
+	key, value, err := step.Inputs[0].GetKeyValuePair()
+	require.NoError(t, err)
+	require.Equal(t, "content", key)
+	require.Equal(t, `echo "default"`, value)
+
+	opts, err := step.Inputs[0].GetOptions()
+	require.NoError(t, err)
+	require.Equal(t, false, *opts.IsExpand)
+}
@@ -0,0 +1,127 @@
+package bitrise
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// Working dir (working_dir): the default working dir of the steps can be set at app and workflow level,
+// and can be overridden by the step's working_dir. The resolved working dir is the step's BITRISE_SOURCE_DIR,
+// so the steps' source dir inputs (defaulting to $BITRISE_SOURCE_DIR) don't have to be set on every step.
+
+// WorkflowWorkingDir returns the working_dir of the workflow, or the app's working_dir, if the workflow does not define one
+func WorkflowWorkingDir(config models.BitriseDataModel, workflow models.WorkflowModel) string {
+	if workflow.WorkingDir != "" {
+		return workflow.WorkingDir
+	}
+	return config.App.WorkingDir
+}
+
+// ExpandWorkingDir expands the env references of the working dir with the environments and with the process environment.
+// A relative working dir is relative to the current dir.
+func ExpandWorkingDir(workingDir string, environments []envmanModels.EnvironmentItemModel) (string, error) {
+	resolved, err := ResolveEnvLayers([]EnvLayerModel{{Source: "envs", Environments: environments}})
+	if err != nil {
+		return "", err
+	}
+	values := map[string]string{}
+	for _, env := range resolved {
+		values[env.Key] = env.Value
+	}
+
+	pth := os.Expand(workingDir, func(key string) string {
+		if value, found := values[key]; found {
+			return value
+		}
+		return os.Getenv(key)
+	})
+	if pth == "" {
+		return "", fmt.Errorf("working dir (%s) expanded to an empty path", workingDir)
+	}
+
+	if pth == "~" || strings.HasPrefix(pth, "~/") {
+		pth = filepath.Join(pathutil.UserHomeDir(), strings.TrimPrefix(pth, "~"))
+	} else if !filepath.IsAbs(pth) && configs.CurrentDir != "" {
+		pth = filepath.Join(configs.CurrentDir, pth)
+	}
+	return filepath.Abs(pth)
+}
+
+// ResolveWorkingDir expands the working dir (see: ExpandWorkingDir) and checks that it is an existing directory
+func ResolveWorkingDir(workingDir string, environments []envmanModels.EnvironmentItemModel) (string, error) {
+	pth, err := ExpandWorkingDir(workingDir, environments)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(pth)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("working dir (%s) does not exist", pth)
+	} else if err != nil {
+		return "", fmt.Errorf("Failed to check working dir (%s), error: %s", pth, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("working dir (%s) is not a directory", pth)
+	}
+	return pth, nil
+}
+
+// ValidateWorkingDirs checks the app, workflow and step level working dirs of the workflows, run by the given workflow,
+// with the environments available at the start of the run (and with the workflow's env_files envs and envs).
+// A step's working dir, which references an env not available at the start of the run (e.g. the output of a previous step),
+// is checked only when the step runs.
+func ValidateWorkingDirs(config models.BitriseDataModel, workflowID string, environments []envmanModels.EnvironmentItemModel,
+	workflowEnvFileEnvironments map[string][]envmanModels.EnvironmentItemModel) error {
+	for _, id := range models.WorkflowChain(config, workflowID) {
+		workflow := config.Workflows[id]
+		workflowEnvironments := append(append([]envmanModels.EnvironmentItemModel{}, environments...), workflowEnvFileEnvironments[id]...)
+		workflowEnvironments = append(workflowEnvironments, workflow.Environments...)
+
+		if workingDir := WorkflowWorkingDir(config, workflow); workingDir != "" {
+			if _, err := ResolveWorkingDir(workingDir, workflowEnvironments); err != nil {
+				return fmt.Errorf("Invalid working_dir of workflow (%s), error: %s", id, err)
+			}
+		}
+
+		for idx, stepListItem := range workflow.Steps {
+			stepID, step, err := models.GetStepIDStepDataPair(stepListItem)
+			if err != nil || step.WorkingDir == nil || *step.WorkingDir == "" {
+				continue
+			}
+			if !isWorkingDirEnvsAvailable(*step.WorkingDir, workflowEnvironments) {
+				continue
+			}
+			if _, err := ResolveWorkingDir(*step.WorkingDir, workflowEnvironments); err != nil {
+				return fmt.Errorf("Invalid working_dir of step (%s) of workflow (%s) (steps[%d]), error: %s", stepID, id, idx, err)
+			}
+		}
+	}
+	return nil
+}
+
+// isWorkingDirEnvsAvailable returns true if every env referenced by the working dir is defined by the environments,
+// or by the process environment
+func isWorkingDirEnvsAvailable(workingDir string, environments []envmanModels.EnvironmentItemModel) bool {
+	keys := map[string]bool{}
+	for _, env := range environments {
+		if key, _, err := env.GetKeyValuePair(); err == nil {
+			keys[key] = true
+		}
+	}
+
+	isAvailable := true
+	os.Expand(workingDir, func(key string) string {
+		if _, found := os.LookupEnv(key); !found && !keys[key] {
+			isAvailable = false
+		}
+		return ""
+	})
+	return isAvailable
+}
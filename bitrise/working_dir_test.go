@@ -0,0 +1,142 @@
+package bitrise
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-utils/pointers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveWorkingDir(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__working_dir__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "ios"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, "README.md"), []byte("readme"), 0666))
+
+	originalCurrentDir := configs.CurrentDir
+	configs.CurrentDir = tmpDir
+	defer func() {
+		configs.CurrentDir = originalCurrentDir
+	}()
+
+	environments := []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"PROJECT_ROOT": tmpDir},
+		envmanModels.EnvironmentItemModel{"IOS_DIR": "$PROJECT_ROOT/ios"},
+	}
+
+	t.Log("env expansion")
+	{
+		pth, err := ResolveWorkingDir("$IOS_DIR", environments)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(tmpDir, "ios"), pth)
+	}
+
+	t.Log("relative to the current dir")
+	{
+		pth, err := ResolveWorkingDir("./ios", nil)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(tmpDir, "ios"), pth)
+	}
+
+	t.Log("invalid working dirs")
+	{
+		_, err := ResolveWorkingDir("android", environments)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "does not exist")
+
+		_, err = ResolveWorkingDir("$PROJECT_ROOT/README.md", environments)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is not a directory")
+
+		_, err = ResolveWorkingDir("$NOT_DEFINED_WORKING_DIR", environments)
+		require.Error(t, err)
+	}
+}
+
+func TestValidateWorkingDirs(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__working_dir__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "ios"), 0777))
+
+	environments := []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"PROJECT_ROOT": tmpDir},
+	}
+
+	config := models.BitriseDataModel{
+		App: models.AppModel{WorkingDir: "$PROJECT_ROOT"},
+		Workflows: map[string]models.WorkflowModel{
+			"setup": models.WorkflowModel{},
+			"ios": models.WorkflowModel{
+				BeforeRun:    []models.WorkflowRunItemModel{{WorkflowID: "setup"}},
+				Environments: []envmanModels.EnvironmentItemModel{{"PLATFORM": "ios"}},
+				WorkingDir:   "$PROJECT_ROOT/$PLATFORM",
+			},
+			"android": models.WorkflowModel{
+				BeforeRun:  []models.WorkflowRunItemModel{{WorkflowID: "setup"}},
+				WorkingDir: "$PROJECT_ROOT/android",
+			},
+		},
+	}
+
+	require.Equal(t, "$PROJECT_ROOT", WorkflowWorkingDir(config, config.Workflows["setup"]))
+	require.Equal(t, "$PROJECT_ROOT/$PLATFORM", WorkflowWorkingDir(config, config.Workflows["ios"]))
+
+	require.NoError(t, ValidateWorkingDirs(config, "ios", environments, nil))
+
+	err = ValidateWorkingDirs(config, "android", environments, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Invalid working_dir of workflow (android)")
+
+	t.Log("env_files envs of the workflow")
+	{
+		require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "android", "app"), 0777))
+		android := config.Workflows["android"]
+		android.WorkingDir = "$PROJECT_ROOT/$ANDROID_DIR/app"
+		config.Workflows["android"] = android
+
+		err = ValidateWorkingDirs(config, "android", environments, nil)
+		require.Error(t, err)
+
+		envFileEnvironments := map[string][]envmanModels.EnvironmentItemModel{
+			"android": []envmanModels.EnvironmentItemModel{{"ANDROID_DIR": "android"}},
+		}
+		require.NoError(t, ValidateWorkingDirs(config, "android", environments, envFileEnvironments))
+		require.NoError(t, os.RemoveAll(filepath.Join(tmpDir, "android")))
+	}
+
+	t.Log("step level working dir")
+	{
+		config.Workflows["ios"] = models.WorkflowModel{
+			Environments: []envmanModels.EnvironmentItemModel{{"PLATFORM": "ios"}},
+			Steps: []models.StepListItemModel{
+				models.StepListItemModel{"script": models.WorkflowStepModel{WorkingDir: pointers.NewStringPtr("$PROJECT_ROOT/$PLATFORM")}},
+				// references the output of a previous step, checked when the step runs
+				models.StepListItemModel{"script": models.WorkflowStepModel{WorkingDir: pointers.NewStringPtr("$BITRISE_UNPACKED_DIR")}},
+			},
+		}
+		require.NoError(t, ValidateWorkingDirs(config, "ios", environments, nil))
+
+		config.Workflows["ios"] = models.WorkflowModel{
+			Steps: []models.StepListItemModel{
+				models.StepListItemModel{"script": models.WorkflowStepModel{WorkingDir: pointers.NewStringPtr("$PROJECT_ROOT/android")}},
+			},
+		}
+		err = ValidateWorkingDirs(config, "ios", environments, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Invalid working_dir of step (script) of workflow (ios) (steps[0])")
+	}
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/bitrise/tools"
+	envmanModels "github.com/bitrise-io/envman/models"
+)
+
+// isOnAbortWorkflowRunning is true while the cleanup (on_abort) workflow of an aborted build runs,
+// the steps of this workflow should not be skipped
+var isOnAbortWorkflowRunning = false
+
+// registerAbortHandler aborts the build on the first SIGINT/SIGTERM: the current step's process group is killed
+// and the rest of the steps are skipped. A further signal (e.g. during the on_abort workflow) exits immediately.
+// Returns a function to unregister the handler.
+func registerAbortHandler() func() {
+	signals := make(chan os.Signal, 1)
+	done := make(chan bool)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				isFirstSignal := bitrise.Abort()
+				if isFirstSignal {
+					fmt.Println()
+					log.Warnf("Received signal (%s), aborting the build...", sig)
+				} else {
+					log.Warnf("Received signal (%s) again, exiting immediately", sig)
+				}
+
+				if err := tools.KillRunningProcessGroup(); err != nil {
+					log.Errorf("Failed to kill the running step, error: %s", err)
+				}
+
+				if !isFirstSignal {
+					os.Exit(bitrise.BuildAbortedExitCode)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+func runOnAbortWorkflow(bitriseConfig models.BitriseDataModel, environments []envmanModels.EnvironmentItemModel) {
+	if bitriseConfig.OnAbort == "" {
+		return
+	}
+
+	workflow, found := bitriseConfig.Workflows[bitriseConfig.OnAbort]
+	if !found {
+		log.Errorf("on_abort workflow (%s) does not exist", bitriseConfig.OnAbort)
+		return
+	}
+	if workflow.Title == "" {
+		workflow.Title = bitriseConfig.OnAbort
+	}
+
+	lastWorkflowID, err := lastWorkflowIDInConfig(bitriseConfig.OnAbort, bitriseConfig)
+	if err != nil {
+		log.Errorf("Failed to get last workflow id of the on_abort workflow, error: %s", err)
+		return
+	}
+
+	fmt.Println()
+	log.Infoln(output.Yellow("Running on_abort workflow: " + bitriseConfig.OnAbort))
+
+	isOnAbortWorkflowRunning = true
+	defer func() {
+		isOnAbortWorkflowRunning = false
+	}()
+
+	buildRunResults := models.BuildRunResultsModel{
+		StartTime:      time.Now(),
+		StepmanUpdates: map[string]int{},
+	}
+
+	buildRunResults, err = activateAndRunWorkflow(newWorkflowRunContext(nil, nil), bitriseConfig.OnAbort, workflow, bitriseConfig, buildRunResults, &environments, lastWorkflowID)
+	if err != nil {
+		log.Errorf("Failed to run on_abort workflow, error: %s", err)
+		return
+	}
+
+	bitrise.PrintSummary(buildRunResults)
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/bitrise/tools"
+	envmanModels "github.com/bitrise-io/envman/models"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/urfave/cli"
+)
+
+const defaultBenchIterations = 10
+
+// benchResourceUsageModel is the resource usage of the finished (and waited for) child processes (see: childrenResourceUsage)
+type benchResourceUsageModel struct {
+	UserCPU    time.Duration
+	SystemCPU  time.Duration
+	MaxRSSInKB int64
+}
+
+func benchWorkflow(stepID string, fixture bitrise.BenchFixtureModel) models.WorkflowModel {
+	step := models.WorkflowStepModel{
+		StepModel: stepmanModels.StepModel{
+			Inputs: fixture.Inputs,
+		},
+	}
+	return models.WorkflowModel{
+		Title: "bench",
+		Steps: []models.StepListItemModel{
+			models.StepListItemModel{stepID: step},
+		},
+	}
+}
+
+func runBenchIteration(stepID string, fixture bitrise.BenchFixtureModel) (bitrise.BenchIterationModel, error) {
+	if err := tools.EnvmanInit(); err != nil {
+		return bitrise.BenchIterationModel{}, fmt.Errorf("Failed to run envman init, error: %s", err)
+	}
+
+	usageBefore, err := childrenResourceUsage()
+	if err != nil {
+		return bitrise.BenchIterationModel{}, fmt.Errorf("Failed to read resource usage, error: %s", err)
+	}
+
+	environments := append([]envmanModels.EnvironmentItemModel{}, fixture.Envs...)
+	buildRunResults := models.BuildRunResultsModel{
+		StartTime:      time.Now(),
+		StepmanUpdates: map[string]int{},
+	}
+	buildRunResults = activateAndRunSteps(newWorkflowRunContext(nil, nil), "bench", benchWorkflow(stepID, fixture), defaultStepLibSource, buildRunResults, &environments, true)
+
+	usageAfter, err := childrenResourceUsage()
+	if err != nil {
+		return bitrise.BenchIterationModel{}, fmt.Errorf("Failed to read resource usage, error: %s", err)
+	}
+
+	results := buildRunResults.OrderedResults()
+	if len(results) == 0 {
+		return bitrise.BenchIterationModel{}, fmt.Errorf("No step run result")
+	}
+	result := results[0]
+
+	return bitrise.BenchIterationModel{
+		Duration:  result.RunTime,
+		Success:   result.Status == models.StepRunStatusCodeSuccess,
+		UserCPU:   usageAfter.UserCPU - usageBefore.UserCPU,
+		SystemCPU: usageAfter.SystemCPU - usageBefore.SystemCPU,
+	}, nil
+}
+
+func printBenchResult(result bitrise.BenchResultModel) {
+	fmt.Println()
+	log.Infof("Benchmark of step: %s (%d iterations)", output.Blue(result.Step), len(result.Iterations))
+	if result.FailedCount > 0 {
+		log.Warnf("%d iteration(s) failed", result.FailedCount)
+	}
+	fmt.Println()
+	log.Infoln("Duration:")
+	log.Infof(" min:     %s", result.Duration.Min)
+	log.Infof(" max:     %s", result.Duration.Max)
+	log.Infof(" mean:    %s", result.Duration.Mean)
+	log.Infof(" median:  %s", result.Duration.Median)
+	log.Infof(" p90:     %s", result.Duration.P90)
+	log.Infof(" std dev: %s", result.Duration.StdDev)
+	fmt.Println()
+	log.Infoln("Resource usage:")
+	log.Infof(" mean user CPU:   %s", result.MeanUserCPU)
+	log.Infof(" mean system CPU: %s", result.MeanSystemCPU)
+	log.Infof(" max RSS:         %d KB", result.MaxRSSInKB)
+}
+
+func bench(c *cli.Context) error {
+	// Expand cli.Context
+	stepID := c.String(StepKey)
+	fixturePth := c.String(InputsKey)
+	iterations := c.Int(IterationsKey)
+	//
+
+	// Input validation
+	if stepID == "" {
+		log.Fatalf("Missing required input: %s", StepKey)
+	}
+
+	if iterations < 1 {
+		log.Fatalf("Invalid number of iterations: %d", iterations)
+	}
+
+	fixture := bitrise.BenchFixtureModel{}
+	if fixturePth != "" {
+		f, err := bitrise.ReadBenchFixture(fixturePth)
+		if err != nil {
+			log.Fatalf("Failed to read inputs fixture, error: %s", err)
+		}
+		fixture = f
+	}
+
+	// Envman setup
+	if err := os.Setenv(configs.EnvstorePathEnvKey, configs.OutputEnvstorePath); err != nil {
+		log.Fatalf("Failed to add env, error: %s", err)
+	}
+	if err := os.Setenv(configs.FormattedOutputPathEnvKey, configs.FormattedOutputPath); err != nil {
+		log.Fatalf("Failed to add env, error: %s", err)
+	}
+
+	if err := initStepLocks(); err != nil {
+		log.Fatalf("Failed to init step locks, error: %s", err)
+	}
+	resourceGovernor = nil
+
+	// Main
+	benchIterations := []bitrise.BenchIterationModel{}
+	for i := 0; i < iterations; i++ {
+		log.Infof("Iteration %d/%d", i+1, iterations)
+
+		iteration, err := runBenchIteration(stepID, fixture)
+		if err != nil {
+			log.Fatalf("Failed to run iteration %d, error: %s", i+1, err)
+		}
+		benchIterations = append(benchIterations, iteration)
+	}
+
+	maxRSSInKB := int64(0)
+	if usage, err := childrenResourceUsage(); err != nil {
+		log.Warnf("Failed to read resource usage, error: %s", err)
+	} else {
+		maxRSSInKB = usage.MaxRSSInKB
+	}
+
+	result := bitrise.NewBenchResult(stepID, benchIterations, maxRSSInKB)
+
+	printBenchResult(result)
+
+	if result.FailedCount > 0 {
+		os.Exit(1)
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+// +build !windows
+
+package cli
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+)
+
+func childrenResourceUsage() (benchResourceUsageModel, error) {
+	rusage := syscall.Rusage{}
+	if err := syscall.Getrusage(syscall.RUSAGE_CHILDREN, &rusage); err != nil {
+		return benchResourceUsageModel{}, err
+	}
+
+	maxRSSInKB := int64(rusage.Maxrss)
+	if runtime.GOOS == "darwin" {
+		// reported in bytes on OS X
+		maxRSSInKB = maxRSSInKB / 1024
+	}
+
+	return benchResourceUsageModel{
+		UserCPU:    time.Duration(syscall.TimevalToNsec(rusage.Utime)),
+		SystemCPU:  time.Duration(syscall.TimevalToNsec(rusage.Stime)),
+		MaxRSSInKB: maxRSSInKB,
+	}, nil
+}
@@ -0,0 +1,28 @@
+package cli
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/urfave/cli"
+)
+
+func bundleCreate(c *cli.Context) error {
+	pth := c.String(OuputPathKey)
+	if pth == "" {
+		log.Fatalf("Missing required input: %s", OuputPathKey)
+	}
+
+	manifest, err := bitrise.CreateBundle(pth)
+	if err != nil {
+		log.Fatalf("Failed to create bundle, error: %s", err)
+	}
+
+	log.Infof("%s bundle created: %s", output.Green("[OK]"), pth)
+	log.Infof(" * platform: %s/%s", manifest.OS, manifest.Arch)
+	log.Infof(" * tools: %v", manifest.Tools)
+	log.Infof(" * plugins: %v", manifest.Plugins)
+	log.Infoln("Install it with: bitrise setup --from-bundle " + pth)
+
+	return nil
+}
@@ -8,12 +8,35 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/exitcode"
+	"github.com/bitrise-io/bitrise/output"
 	"github.com/bitrise-io/bitrise/plugins"
 	"github.com/bitrise-io/bitrise/version"
 	"github.com/urfave/cli"
 )
 
+// plainLogFormatter prints the log messages without colors, for the accessible output mode and the plain theme
+type plainLogFormatter struct {
+	log.TextFormatter
+}
+
+func (formatter *plainLogFormatter) Format(entry *log.Entry) ([]byte, error) {
+	entry.Message = output.StripANSI(entry.Message)
+	return formatter.TextFormatter.Format(entry)
+}
+
 func initLogFormatter() {
+	if configs.IsAccessibleMode || !output.IsANSIEnabled() {
+		log.SetFormatter(&plainLogFormatter{
+			TextFormatter: log.TextFormatter{
+				FullTimestamp:   true,
+				DisableColors:   true,
+				TimestampFormat: "15:04:05",
+			},
+		})
+		return
+	}
+
 	log.SetFormatter(&log.TextFormatter{
 		FullTimestamp:   true,
 		ForceColors:     true,
@@ -27,6 +50,29 @@ func before(c *cli.Context) error {
 		use log.Fatal to avoid print help.
 	*/
 
+	// Accessible output mode?
+	if configs.IsAccessibleOutputRequested() {
+		// set for other tools, as an ENV
+		if err := os.Setenv(configs.AccessibleOutputEnvKey, "true"); err != nil {
+			log.Fatalf("Failed to set %s env, error: %s", configs.AccessibleOutputEnvKey, err)
+		}
+		configs.IsAccessibleMode = true
+	}
+
+	// Console theme
+	if err := output.ConfigureTheme(c.String(ThemeKey), c.Bool(NoANSIKey) || configs.IsAccessibleMode); err != nil {
+		log.Fatalf("Failed to configure console theme, error: %s", err)
+	}
+	// set for other tools (and the steps), as an ENV
+	if err := os.Setenv(output.ThemeEnvKey, output.ActiveTheme().Name); err != nil {
+		log.Fatalf("Failed to set %s env, error: %s", output.ThemeEnvKey, err)
+	}
+	if !output.IsANSIEnabled() && os.Getenv(output.NoColorEnvKey) == "" {
+		if err := os.Setenv(output.NoColorEnvKey, "1"); err != nil {
+			log.Fatalf("Failed to set %s env, error: %s", output.NoColorEnvKey, err)
+		}
+	}
+
 	initLogFormatter()
 	initHelpAndVersionFlags()
 	initAppHelpTemplate()
@@ -82,6 +128,10 @@ func before(c *cli.Context) error {
 		log.Fatalf("Failed to initialize required plugin paths, error: %s", err)
 	}
 
+	if err := startProfiling(c.Bool(CPUProfileKey), c.Bool(MemProfileKey), c.Bool(TraceKey)); err != nil {
+		log.Fatalf("Failed to start profiling, error: %s", err)
+	}
+
 	// Pull Request Mode check
 	if c.Bool(PRKey) {
 		// if PR mode indicated make sure we set the related env
@@ -113,6 +163,8 @@ func printVersion(c *cli.Context) {
 func Run() {
 	// Parse cl
 	cli.VersionPrinter = printVersion
+	cli.OsExiter = exitcode.Exit
+	exitcode.RegisterFatalExitHandler()
 
 	app := cli.NewApp()
 	app.Name = path.Base(os.Args[0])
@@ -148,13 +200,15 @@ func Run() {
 			if err := cli.ShowAppHelp(c); err != nil {
 				return fmt.Errorf("Failed to show help, error: %s", err)
 			}
-			return errors.New("")
+			return exitcode.New(exitcode.Usage, errors.New(""))
 		}
 
 		return nil
 	}
 
-	if err := app.Run(os.Args); err != nil {
-		log.Fatal(err)
+	err := app.Run(os.Args)
+	stopProfiling()
+	if err != nil {
+		exitcode.Fatal(exitcode.Code(err), err)
 	}
 }
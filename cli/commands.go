@@ -10,6 +10,10 @@ const (
 
 	// WorkflowKey ...
 	WorkflowKey = "workflow"
+	// WorkflowsKey ...
+	WorkflowsKey = "workflows"
+	// ShareEnvsKey ...
+	ShareEnvsKey = "share-envs"
 
 	// PatternKey ...
 	PatternKey = "pattern"
@@ -19,6 +23,10 @@ const (
 	PRSourceBranchKey = "pr-source-branch"
 	// PRTargetBranchKey ...
 	PRTargetBranchKey = "pr-target-branch"
+	// PRDraftKey ...
+	PRDraftKey = "pr-draft"
+	// ExplainKey ...
+	ExplainKey = "explain"
 
 	// ConfigKey ...
 	ConfigKey = "config"
@@ -27,6 +35,8 @@ const (
 
 	// OuputFormatKey ...
 	OuputFormatKey = "format"
+	// RevealKey ...
+	RevealKey = "reveal"
 )
 
 var (
@@ -39,6 +49,38 @@ var (
 			Flags: []cli.Flag{
 				flMinimalSetup,
 				flFullModeSteup,
+				flFromBundle,
+			},
+		},
+		{
+			Name:  "bundle",
+			Usage: "Offline setup bundle handling.",
+			Subcommands: []cli.Command{
+				{
+					Name:   "create",
+					Usage:  "Create a setup bundle of the installed tools, plugins and StepLib snapshot, for 'bitrise setup --from-bundle'.",
+					Action: bundleCreate,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  OuputPathKey + ", o",
+							Usage: "Path of the bundle file.",
+							Value: "bitrise-bundle.tar.gz",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:   "doctor",
+			Usage:  "Diagnoses the host: tool versions, directory permissions, network reachability, plugins and the config.",
+			Action: doctor,
+			Flags: []cli.Flag{
+				flConfig,
+				flConfigBase64,
+				flInventory,
+				flInventoryBase64,
+				cli.StringFlag{Name: OuputFormatKey, Usage: "Output format. Accepted: raw (default), json (to attach to support tickets)."},
+				cli.BoolFlag{Name: OfflineKey, Usage: "Skip the network checks."},
 			},
 		},
 		{
@@ -47,6 +89,11 @@ var (
 			Usage:   "Generates a Workflow/app config file in the current directory, which then can be run immediately.",
 			Action:  initConfig,
 		},
+		{
+			Name:   "onboard",
+			Usage:  "Guided first-run setup: detects the project type, proposes workflows and a trigger map, sets up the secrets (encrypted) and runs a smoke workflow.",
+			Action: onboard,
+		},
 		{
 			Name:   "version",
 			Usage:  "Prints the version",
@@ -66,7 +113,11 @@ var (
 				flConfigBase64,
 				flInventory,
 				flInventoryBase64,
-				flFormat,
+				cli.StringFlag{Name: OuputFormatKey, Usage: "Output format. Accepted: raw (default), json, junit (exits with a non zero code, if the config or the secrets are invalid)."},
+				cli.BoolFlag{Name: WatchKey, Usage: "Re-validates the config and secrets on every change of the files, until interrupted."},
+				cli.BoolFlag{Name: LintKey, Usage: "Also reports the structural problems of the config file (e.g. duplicated keys), with their locations."},
+				cli.BoolFlag{Name: DeepKey, Usage: "Also validates the step inputs against the steps' definitions (step.yml), fetched via stepman."},
+				flStrict,
 			},
 		},
 		{
@@ -77,8 +128,34 @@ var (
 			Flags: []cli.Flag{
 				// cli params
 				cli.StringFlag{Name: WorkflowKey, Usage: "workflow id to run."},
-				cli.StringFlag{Name: ConfigKey + ", " + configShortKey, Usage: "Path where the workflow config file is located."},
-				cli.StringFlag{Name: InventoryKey + ", " + inventoryShortKey, Usage: "Path of the inventory file."},
+				cli.StringFlag{Name: WorkflowsKey, Usage: "Comma separated list of the workflows to run sequentially (or: bitrise run wf1 wf2)."},
+				cli.BoolFlag{Name: ShareEnvsKey, Usage: "Make the envs of a workflow available for the next one, if multiple workflows are run."},
+				cli.StringFlag{Name: ConfigKey + ", " + configShortKey, Usage: "Path where the workflow config file is located, or - to read it from stdin."},
+				cli.StringFlag{Name: InventoryKey + ", " + inventoryShortKey, Usage: "Path of the inventory file, or - to read it from stdin."},
+
+				cli.BoolFlag{Name: LockedKey, Usage: "Fail if a step would resolve to a different version than the one in the step lockfile (bitrise.lock)."},
+				cli.BoolFlag{Name: UpdateLockKey, Usage: "Write the resolved step versions into the step lockfile (bitrise.lock)."},
+				flSummaryPath,
+				flInput,
+				flEnv,
+				flEnvFile,
+				flStrict,
+				flNoDeprecated,
+				flStepAudit,
+				flDependencyManager,
+				flArtifactUpload,
+				flArtifactRetentionDays,
+				flTimeout,
+				flTimeoutGrace,
+				flReportHTML,
+				flProfile,
+				flProfilePath,
+				flLogStream,
+				flQuiet,
+				flLogDir,
+				flLogMaxSize,
+				flLogRetention,
+				flRerunFailed,
 
 				// cli params used in CI mode
 				cli.StringFlag{Name: JSONParamsKey, Usage: "Specify command flags with json string-string hash."},
@@ -99,15 +176,17 @@ var (
 			Flags: []cli.Flag{
 				// cli params
 				cli.StringFlag{Name: PatternKey, Usage: "trigger pattern."},
-				cli.StringFlag{Name: ConfigKey + ", " + configShortKey, Usage: "Path where the workflow config file is located."},
-				cli.StringFlag{Name: InventoryKey + ", " + inventoryShortKey, Usage: "Path of the inventory file."},
+				cli.StringFlag{Name: ConfigKey + ", " + configShortKey, Usage: "Path where the workflow config file is located, or - to read it from stdin."},
+				cli.StringFlag{Name: InventoryKey + ", " + inventoryShortKey, Usage: "Path of the inventory file, or - to read it from stdin."},
 
 				cli.StringFlag{Name: PushBranchKey, Usage: "Git push branch name."},
 				cli.StringFlag{Name: PRSourceBranchKey, Usage: "Git pull request source branch name."},
 				cli.StringFlag{Name: PRTargetBranchKey, Usage: "Git pull request target branch name."},
+				cli.BoolFlag{Name: PRDraftKey, Usage: "The pull request is a draft."},
 				cli.StringFlag{Name: TagKey, Usage: "Git tag name."},
 
 				cli.StringFlag{Name: OuputFormatKey, Usage: "Output format. Accepted: json, yml."},
+				cli.BoolFlag{Name: ExplainKey, Usage: "Print every evaluated trigger map item, why it matched or not, and the selected workflow in JSON format."},
 
 				// cli params used in CI mode
 				cli.StringFlag{Name: JSONParamsKey, Usage: "Specify command flags with json string-string hash."},
@@ -129,14 +208,37 @@ var (
 			Flags: []cli.Flag{
 				// cli params
 				cli.StringFlag{Name: PatternKey, Usage: "trigger pattern."},
-				cli.StringFlag{Name: ConfigKey + ", " + configShortKey, Usage: "Path where the workflow config file is located."},
-				cli.StringFlag{Name: InventoryKey + ", " + inventoryShortKey, Usage: "Path of the inventory file."},
+				cli.StringFlag{Name: ConfigKey + ", " + configShortKey, Usage: "Path where the workflow config file is located, or - to read it from stdin."},
+				cli.StringFlag{Name: InventoryKey + ", " + inventoryShortKey, Usage: "Path of the inventory file, or - to read it from stdin."},
 
 				cli.StringFlag{Name: PushBranchKey, Usage: "Git push branch name."},
 				cli.StringFlag{Name: PRSourceBranchKey, Usage: "Git pull request source branch name."},
 				cli.StringFlag{Name: PRTargetBranchKey, Usage: "Git pull request target branch name."},
+				cli.BoolFlag{Name: PRDraftKey, Usage: "The pull request is a draft."},
 				cli.StringFlag{Name: TagKey, Usage: "Git tag name."},
 
+				cli.BoolFlag{Name: LockedKey, Usage: "Fail if a step would resolve to a different version than the one in the step lockfile (bitrise.lock)."},
+				cli.BoolFlag{Name: UpdateLockKey, Usage: "Write the resolved step versions into the step lockfile (bitrise.lock)."},
+				flSummaryPath,
+				flInput,
+				flEnv,
+				flEnvFile,
+				flStrict,
+				flNoDeprecated,
+				flStepAudit,
+				flDependencyManager,
+				flArtifactUpload,
+				flArtifactRetentionDays,
+				flTimeout,
+				flTimeoutGrace,
+				flProfile,
+				flProfilePath,
+				flLogStream,
+				flQuiet,
+				flLogDir,
+				flLogMaxSize,
+				flLogRetention,
+
 				// cli params used in CI mode
 				cli.StringFlag{Name: JSONParamsKey, Usage: "Specify command flags with json string-string hash."},
 				cli.StringFlag{Name: JSONParamsBase64Key, Usage: "Specify command flags with base64 encoded json string-string hash."},
@@ -162,6 +264,22 @@ var (
 				flPretty,
 			},
 		},
+		{
+			Name:      "merge",
+			Usage:     "Deep-merges layered configs (base.yml overlay.yml ...) and prints the merged config.",
+			ArgsUsage: "BASE_CONFIG OVERLAY_CONFIG [OVERLAY_CONFIG...]",
+			Description: `Every config overlays the previous ones, the overlay wins every conflict:
+   - app envs, workflow envs, step_defaults and step bundle inputs are merged by key
+   - workflows and step bundles are merged by ID: the overlay's title, summary and description,
+     and the overlay's before_run, after_run and steps lists (if set) replace the base ones
+   - trigger_map: the overlay's items come first, a base item with the same trigger is dropped
+   - env_files and required_secrets are merged, format_version is the higher version`,
+			Action: merge,
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: OuputFormatKey, Usage: "Output format. Accepted: yml (default), json."},
+				cli.StringFlag{Name: OuputPathKey, Usage: "Write the merged config to the given path, instead of the standard output."},
+			},
+		},
 		{
 			Name:   "normalize",
 			Usage:  "Normalize the bitrise configuration.",
@@ -170,6 +288,47 @@ var (
 				flPath,
 				flConfig,
 				flConfigBase64,
+				cli.StringFlag{Name: OuputFormatKey, Usage: "Format of the normalized config. Accepted: json, yml. Saved next to the config, if it differs from the config's format (default: the config's format)."},
+			},
+		},
+		{
+			Name:  "step",
+			Usage: "Step development commands.",
+			Subcommands: []cli.Command{
+				{
+					Name:   "create",
+					Usage:  "Scaffolds a new step repository (step.yml, step source in the selected toolkit, test workflow, README). The details, which are not specified by flags, are asked, unless in CI mode (--ci).",
+					Action: stepCreate,
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: IDKey, Usage: "ID of the step (lowercase letters, numbers and dashes)."},
+						cli.StringFlag{Name: StepTitleKey, Usage: "Title of the step (default: the step's ID)."},
+						cli.StringFlag{Name: StepSummaryKey, Usage: "One line summary of the step."},
+						cli.StringFlag{Name: StepWebsiteKey, Usage: "Website (source code URL) of the step."},
+						cli.StringFlag{Name: StepToolkitKey, Usage: "Toolkit of the step. Accepted: bash (default in CI mode), go."},
+						cli.StringFlag{Name: StepGoPackageKey, Usage: "Go package name of the step, required for the go toolkit (e.g. github.com/user/steps-my-step)."},
+						cli.StringFlag{Name: StepDirKey, Usage: "Directory of the new step repository, must not exist or be empty (default: ./steps-<id>)."},
+					},
+				},
+				{
+					Name:   "test",
+					Usage:  "Runs the local step standalone, exactly as the runner would (toolkit, dependencies, envstore), and checks its outputs.",
+					Action: stepTest,
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: StepTestDirKey, Usage: "Directory of the step (containing its step.yml) (default: the current directory)."},
+						cli.StringSliceFlag{Name: StepInputKey, Usage: "Input of the step, in KEY=VALUE format. Can be specified multiple times."},
+						cli.StringSliceFlag{Name: StepOutputKey, Usage: "Expected output of the step, in KEY=VALUE format, or KEY to expect any non empty value. Can be specified multiple times."},
+					},
+				},
+				{
+					Name:      "search",
+					Usage:     "Searches the titles, summaries and descriptions of the StepLib's steps (in the locally cached StepLib spec).",
+					ArgsUsage: "QUERY",
+					Action:    stepSearch,
+					Flags: []cli.Flag{
+						flCollection,
+						cli.StringFlag{Name: OuputFormatKey, Usage: "Output format. Accepted: raw (default), json."},
+					},
+				},
 			},
 		},
 		{
@@ -179,6 +338,10 @@ var (
 			Flags: []cli.Flag{
 				flCollection,
 				flFormat,
+				cli.StringFlag{Name: CategoryKey, Usage: "List only the steps of the category (type tag)."},
+				cli.StringFlag{Name: MaintainerKey, Usage: "List only the steps maintained by the owner of the step's source repository (e.g. bitrise-io)."},
+				cli.StringFlag{Name: ToolkitKey, Usage: "List only the steps of the toolkit. Accepted: bash, go."},
+				cli.StringFlag{Name: DeprecatedKey, Usage: "List only the deprecated (true) or the not deprecated (false) steps."},
 			},
 		},
 		{
@@ -189,11 +352,46 @@ var (
 			Flags: []cli.Flag{
 				flCollection,
 				flVersion,
-				flFormat,
+				cli.StringFlag{Name: OuputFormatKey, Usage: "Output format. Accepted: raw (default), json, markdown."},
 				flShort,
 				flStepYML,
 			},
 		},
+		{
+			Name:      "explain",
+			Usage:     "Renders the documentation of a step (description, inputs, outputs and an example config).",
+			ArgsUsage: "STEP_ID[@VERSION]",
+			Action:    explain,
+			Flags: []cli.Flag{
+				flConfig,
+				flConfigBase64,
+				flCollection,
+				flVersion,
+				flStepYML,
+				cli.BoolFlag{Name: ExampleKey, Usage: "Print only a paste-ready YAML block of the step."},
+			},
+		},
+		{
+			Name:   "step-update",
+			Usage:  "Updates the version pins of the StepLib steps in the config to the latest available versions.",
+			Action: stepUpdate,
+			Flags: []cli.Flag{
+				flPath,
+				flConfig,
+				cli.BoolFlag{Name: DryRunKey, Usage: "Only print the available updates, don't modify the config."},
+				cli.BoolFlag{Name: MajorKey, Usage: "Allow major version updates, by default only the minor and patch version updates are allowed."},
+			},
+		},
+		{
+			Name:   "bench",
+			Usage:  "Runs a single step repeatedly with a fixed environment, and reports its duration distribution and resource usage.",
+			Action: bench,
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: StepKey, Usage: "Step to benchmark (step reference, e.g. script or script@1.1.3)."},
+				cli.StringFlag{Name: InputsKey, Usage: "Fixture file (yml) with the step inputs (inputs) and the environment (envs) of the runs."},
+				cli.IntFlag{Name: IterationsKey, Value: defaultBenchIterations, Usage: "Number of iterations."},
+			},
+		},
 		{
 			Name:   "workflows",
 			Usage:  "List of available workflows in config.",
@@ -202,17 +400,59 @@ var (
 				flPath,
 				flConfig,
 				flConfigBase64,
-				flFormat,
+				cli.StringFlag{Name: OuputFormatKey, Usage: "Output format. Accepted: raw (default), json."},
 				cli.BoolFlag{
 					Name:  MinimalModeKey,
 					Usage: "Print only workflow summary.",
 				},
+				cli.StringSliceFlag{
+					Name:  TagKey,
+					Usage: "List only the workflows with the given tag. Can be specified multiple times, the workflows must have every given tag.",
+				},
+			},
+		},
+		{
+			Name:      "envs",
+			Usage:     "Prints the resolved environment of a workflow's steps, and where the envs come from.",
+			ArgsUsage: "WORKFLOW",
+			Action:    envs,
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: WorkflowKey, Usage: "workflow id."},
+				cli.StringFlag{Name: ConfigKey + ", " + configShortKey, Usage: "Path where the workflow config file is located, or - to read it from stdin."},
+				cli.StringFlag{Name: InventoryKey + ", " + inventoryShortKey, Usage: "Path of the inventory file, or - to read it from stdin."},
+				cli.StringFlag{Name: ConfigBase64Key, Usage: "base64 encoded config data."},
+				cli.StringFlag{Name: InventoryBase64Key, Usage: "base64 encoded inventory data."},
+				flEnv,
+				flEnvFile,
+				cli.StringFlag{Name: OuputFormatKey, Usage: "Output format. Accepted: raw (default), json, dotenv."},
+				cli.BoolFlag{Name: RevealKey, Usage: "Print the values of the secrets, instead of masking them."},
+			},
+		},
+		{
+			Name:  "config",
+			Usage: "Config related commands.",
+			Subcommands: []cli.Command{
+				{
+					Name:      "explain",
+					Usage:     "Prints the effective value of a config path and where it comes from.",
+					ArgsUsage: "workflows.WORKFLOW.steps[IDX].inputs.KEY",
+					Action:    configExplain,
+					Flags: []cli.Flag{
+						flPath,
+						flConfig,
+						flConfigBase64,
+						flFormat,
+					},
+				},
 			},
 		},
 		{
 			Name:   "share",
 			Usage:  "Publish your step.",
 			Action: share,
+			Flags: []cli.Flag{
+				flNonInteractive,
+			},
 			Subcommands: []cli.Command{
 				{
 					Name:   "start",
@@ -220,6 +460,7 @@ var (
 					Action: start,
 					Flags: []cli.Flag{
 						flCollection,
+						flNonInteractive,
 					},
 				},
 				{
@@ -230,17 +471,35 @@ var (
 						flTag,
 						flGit,
 						flStepID,
+						flNonInteractive,
 					},
 				},
 				{
 					Name:   "audit",
 					Usage:  "Validates the step collection.",
 					Action: shareAudit,
+					Flags: []cli.Flag{
+						flNonInteractive,
+					},
 				},
 				{
 					Name:   "finish",
 					Usage:  "Finish up.",
 					Action: finish,
+					Flags: []cli.Flag{
+						flNonInteractive,
+					},
+				},
+				{
+					Name:   "publish",
+					Usage:  "Publishes the step non-interactively, every parameter is a flag: runs the start, create, audit and finish steps of the share.",
+					Action: sharePublish,
+					Flags: []cli.Flag{
+						flCollection,
+						flTag,
+						flGit,
+						flStepID,
+					},
 				},
 			},
 		},
@@ -255,7 +514,7 @@ var (
 					Flags: []cli.Flag{
 						cli.StringFlag{
 							Name:  "source",
-							Usage: "Plugin source url.",
+							Usage: "Plugin source url or local directory path.",
 						},
 						cli.StringFlag{
 							Name:  "bin-source",
@@ -265,6 +524,10 @@ var (
 							Name:  "version",
 							Usage: "Plugin version tag.",
 						},
+						cli.BoolFlag{
+							Name:  "link",
+							Usage: "Symlink the plugin source instead of copying it (local source only).",
+						},
 					},
 				},
 				{
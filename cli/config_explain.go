@@ -0,0 +1,243 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/bitrise/plugins"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+const configProvenanceFileName = "config_provenance.json"
+
+func configProvenanceFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath string) bitrise.ConfigProvenanceModel {
+	if bitriseConfigBase64Data != "" {
+		return bitrise.NewConfigProvenance(bitrise.ConfigSourceModel{
+			Type:     bitrise.ConfigSourceTypeFlag,
+			Location: "--" + ConfigBase64Key,
+		})
+	}
+	if bitriseConfigPath == StdinPath {
+		return bitrise.NewConfigProvenance(bitrise.ConfigSourceModel{
+			Type:     bitrise.ConfigSourceTypeFlag,
+			Location: "--" + ConfigKey + " " + StdinPath,
+		})
+	}
+
+	if pth, err := GetBitriseConfigFilePath(bitriseConfigPath); err == nil {
+		if absPth, err := filepath.Abs(pth); err == nil {
+			pth = absPth
+		}
+		bitriseConfigPath = pth
+	}
+
+	return bitrise.NewConfigProvenance(bitrise.ConfigSourceModel{
+		Type:     bitrise.ConfigSourceTypeFile,
+		Location: bitriseConfigPath,
+	})
+}
+
+// effectiveConfigWithProvenance expands the step bundles, resolves the plugin step kinds and applies the step defaults of the config,
+// the same way the run does (see: runWorkflowWithConfiguration), and records the provenance of the values, added by them.
+func effectiveConfigWithProvenance(config models.BitriseDataModel, provenance *bitrise.ConfigProvenanceModel) (models.BitriseDataModel, error) {
+	if err := provenance.RecordStepBundles(config); err != nil {
+		return models.BitriseDataModel{}, fmt.Errorf("Failed to expand step bundles, error: %s", err)
+	}
+	if err := config.ExpandStepBundles(); err != nil {
+		return models.BitriseDataModel{}, fmt.Errorf("Failed to expand step bundles, error: %s", err)
+	}
+
+	config, err := plugins.ResolveStepKinds(config)
+	if err != nil {
+		return models.BitriseDataModel{}, fmt.Errorf("Failed to resolve plugin step kinds, error: %s", err)
+	}
+
+	if err := provenance.RecordStepDefaults(config); err != nil {
+		return models.BitriseDataModel{}, fmt.Errorf("Failed to apply step defaults, error: %s", err)
+	}
+	if err := config.ApplyStepDefaults(); err != nil {
+		return models.BitriseDataModel{}, fmt.Errorf("Failed to apply step defaults, error: %s", err)
+	}
+
+	return config, nil
+}
+
+// recordCLIEnvironments records the source of the envs of the --env-file and --env flags (see: parseCLIEnvironments),
+// these envs override the app envs.
+func recordCLIEnvironments(provenance *bitrise.ConfigProvenanceModel, envs, envFilePaths []string) error {
+	for _, pth := range envFilePaths {
+		environments, _, err := bitrise.LoadEnvFiles([]models.EnvFileModel{models.EnvFileModel{Path: pth}}, "")
+		if err != nil {
+			return err
+		}
+		for _, env := range environments {
+			key, _, err := env.GetKeyValuePair()
+			if err != nil {
+				return err
+			}
+			provenance.Record("app.envs."+key, bitrise.ConfigSourceModel{Type: bitrise.ConfigSourceTypeFile, Location: pth})
+		}
+	}
+
+	for _, env := range envs {
+		split := strings.SplitN(env, "=", 2)
+		provenance.Record("app.envs."+split[0], bitrise.ConfigSourceModel{Type: bitrise.ConfigSourceTypeFlag, Location: "--" + EnvKey})
+	}
+	return nil
+}
+
+// recordWorkflowInputs records the source of the workflow inputs, set by the --input flags
+func recordWorkflowInputs(provenance *bitrise.ConfigProvenanceModel, inputValuesByWorkflow map[string]map[string]string) {
+	workflowIDs := []string{}
+	for workflowID := range inputValuesByWorkflow {
+		workflowIDs = append(workflowIDs, workflowID)
+	}
+	sort.Strings(workflowIDs)
+
+	for _, workflowID := range workflowIDs {
+		names := []string{}
+		for name := range inputValuesByWorkflow[workflowID] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			provenance.Record(fmt.Sprintf("workflows.%s.inputs.%s", workflowID, name), bitrise.ConfigSourceModel{Type: bitrise.ConfigSourceTypeFlag, Location: "--" + InputKey})
+		}
+	}
+}
+
+// runProvenance is the provenance of the config of the run (see: runConfigProvenance),
+// the values added by resolving the config are recorded when the run resolves it (see: effectiveRunConfig).
+// nil if the provenance of the run is not saved.
+var runProvenance *bitrise.ConfigProvenanceModel
+
+// runConfigProvenance returns the provenance of the config of the run: the config (file or flag), the --env-file, --env and --input flags.
+func runConfigProvenance(
+	bitriseConfigBase64Data, bitriseConfigPath string,
+	envs, envFilePaths []string,
+	inputValuesByWorkflow map[string]map[string]string) (bitrise.ConfigProvenanceModel, error) {
+
+	provenance := configProvenanceFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath)
+
+	if err := recordCLIEnvironments(&provenance, envs, envFilePaths); err != nil {
+		return bitrise.ConfigProvenanceModel{}, fmt.Errorf("Failed to parse envs, error: %s", err)
+	}
+	recordWorkflowInputs(&provenance, inputValuesByWorkflow)
+
+	return provenance, nil
+}
+
+// effectiveRunConfig returns the effective config of the run (see: effectiveConfigWithProvenance),
+// and saves the provenance of its values, if the provenance of the run is recorded (see: runProvenance).
+func effectiveRunConfig(config models.BitriseDataModel) (models.BitriseDataModel, error) {
+	if runProvenance == nil {
+		provenance := bitrise.NewConfigProvenance(bitrise.ConfigSourceModel{Type: bitrise.ConfigSourceTypeDefault})
+		return effectiveConfigWithProvenance(config, &provenance)
+	}
+
+	provenance := bitrise.ConfigProvenanceModel{Items: append([]bitrise.ConfigProvenanceItemModel{}, runProvenance.Items...)}
+	config, err := effectiveConfigWithProvenance(config, &provenance)
+	if err != nil {
+		return models.BitriseDataModel{}, err
+	}
+	saveConfigProvenance(provenance)
+
+	return config, nil
+}
+
+func saveConfigProvenance(provenance bitrise.ConfigProvenanceModel) {
+	pth := filepath.Join(configs.BitriseWorkDirPath, configProvenanceFileName)
+	if err := bitrise.SaveConfigProvenance(pth, provenance); err != nil {
+		log.Warnf("Failed to save config provenance, error: %s", err)
+	}
+}
+
+func printConfigExplain(explain bitrise.ConfigExplainModel, format string) error {
+	switch format {
+	case output.FormatRaw:
+		valueBytes, err := yaml.Marshal(explain.Value)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s: %s\n", output.Blue("path"), explain.Path)
+		fmt.Printf("%s: %s\n", output.Blue("source"), explain.Source.String())
+		fmt.Printf("%s:\n%s", output.Blue("value"), string(valueBytes))
+	case output.FormatJSON:
+		bytes, err := json.Marshal(explain)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(bytes))
+	default:
+		return fmt.Errorf("Invalid output format: %s", format)
+	}
+	return nil
+}
+
+func configExplain(c *cli.Context) error {
+	warnings := []string{}
+
+	// Expand cli.Context
+	bitriseConfigBase64Data := c.String(ConfigBase64Key)
+
+	bitriseConfigPath := c.String(ConfigKey)
+	deprecatedBitriseConfigPath := c.String(PathKey)
+	if bitriseConfigPath == "" && deprecatedBitriseConfigPath != "" {
+		warnings = append(warnings, "'path' key is deprecated, use 'config' instead!")
+		bitriseConfigPath = deprecatedBitriseConfigPath
+	}
+
+	format := c.String(OuputFormatKey)
+
+	configPath := ""
+	if len(c.Args()) > 0 {
+		configPath = c.Args()[0]
+	}
+	//
+
+	// Input validation
+	if format == "" {
+		format = output.FormatRaw
+	} else if !(format == output.FormatRaw || format == output.FormatJSON) {
+		registerFatal(fmt.Sprintf("Invalid format: %s", format), warnings, output.FormatJSON)
+	}
+
+	if configPath == "" {
+		registerFatal("No config path specified (example: workflows.deploy.steps[3].inputs.team_id)", warnings, format)
+	}
+
+	// Config validation
+	bitriseConfig, warns, err := CreateBitriseConfigFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath)
+	warnings = append(warnings, warns...)
+	if err != nil {
+		registerFatal(fmt.Sprintf("Failed to create bitrise config, err: %s", err), warnings, format)
+	}
+
+	provenance := configProvenanceFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath)
+	bitriseConfig, err = effectiveConfigWithProvenance(bitriseConfig, &provenance)
+	if err != nil {
+		registerFatal(fmt.Sprintf("Failed to resolve bitrise config, err: %s", err), warnings, format)
+	}
+
+	explain, err := provenance.Explain(bitriseConfig, configPath)
+	if err != nil {
+		registerFatal(fmt.Sprintf("Failed to explain (%s), err: %s", configPath, err), warnings, format)
+	}
+
+	if err := printConfigExplain(explain, format); err != nil {
+		registerFatal(fmt.Sprintf("Failed to print explanation, err: %s", err), warnings, format)
+	}
+
+	return nil
+}
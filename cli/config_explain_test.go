@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunConfigProvenance(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("config_provenance")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	configPth := filepath.Join(tmpDir, "bitrise.yml")
+	require.NoError(t, fileutil.WriteStringToFile(configPth, `format_version: 1.3.1
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+app:
+  envs:
+  - STAGE: dev
+step_defaults:
+  xcode-archive:
+    inputs:
+    - team_id: DEFAULT_TEAM
+step_bundles:
+  install_deps:
+    inputs:
+    - NODE_VERSION: "8"
+    steps:
+    - nvm:
+        inputs:
+        - nvm_dir: ~/.nvm
+    - script:
+        inputs:
+        - content: npm install
+workflows:
+  deploy:
+    inputs:
+      channel:
+        default: beta
+    steps:
+    - bundle::install_deps:
+        inputs:
+        - NODE_VERSION: "10"
+        - nvm_dir: /opt/nvm
+    - xcode-archive@2.0.0:
+        inputs:
+        - export_method: app-store
+`))
+
+	envFilePth := filepath.Join(tmpDir, ".env")
+	require.NoError(t, fileutil.WriteStringToFile(envFilePth, "TOKEN=abc\n"))
+
+	bitriseConfig, _, err := CreateBitriseConfigFromCLIParams("", configPth)
+	require.NoError(t, err)
+
+	provenance, err := runConfigProvenance("", configPth,
+		[]string{"STAGE=staging"}, []string{envFilePth}, map[string]map[string]string{"deploy": map[string]string{"channel": "stable"}})
+	require.NoError(t, err)
+
+	config, err := effectiveConfigWithProvenance(bitriseConfig, &provenance)
+	require.NoError(t, err)
+
+	fileSource := bitrise.ConfigSourceModel{Type: bitrise.ConfigSourceTypeFile, Location: configPth}
+
+	t.Log("config file")
+	{
+		explain, err := provenance.Explain(config, "workflows.deploy.steps[2].inputs.export_method")
+		require.NoError(t, err)
+		require.Equal(t, "app-store", explain.Value)
+		require.Equal(t, fileSource, explain.Source)
+	}
+
+	t.Log("step bundle")
+	{
+		explain, err := provenance.Explain(config, "workflows.deploy.steps[1].inputs.content")
+		require.NoError(t, err)
+		require.Equal(t, "npm install", explain.Value)
+		require.Equal(t, bitrise.ConfigSourceModel{Type: bitrise.ConfigSourceTypeStepBundle, Location: "step_bundles.install_deps"}, explain.Source)
+
+		// overridden by the bundle reference of the workflow
+		explain, err = provenance.Explain(config, "workflows.deploy.steps[1].inputs.NODE_VERSION")
+		require.NoError(t, err)
+		require.Equal(t, "10", explain.Value)
+		require.Equal(t, fileSource, explain.Source)
+
+		// the override of a step's input is recorded only for that step
+		explain, err = provenance.Explain(config, "workflows.deploy.steps[0].inputs.nvm_dir")
+		require.NoError(t, err)
+		require.Equal(t, "/opt/nvm", explain.Value)
+		require.Equal(t, fileSource, explain.Source)
+		require.Equal(t, bitrise.ConfigSourceModel{Type: bitrise.ConfigSourceTypeStepBundle, Location: "step_bundles.install_deps"},
+			provenance.SourceOf("workflows.deploy.steps[1].inputs.nvm_dir"))
+	}
+
+	t.Log("step defaults")
+	{
+		explain, err := provenance.Explain(config, "workflows.deploy.steps[2].inputs.team_id")
+		require.NoError(t, err)
+		require.Equal(t, "DEFAULT_TEAM", explain.Value)
+		require.Equal(t, bitrise.ConfigSourceModel{Type: bitrise.ConfigSourceTypeStepDefaults, Location: "step_defaults.xcode-archive"}, explain.Source)
+	}
+
+	t.Log("--env, --env-file and --input flags")
+	{
+		require.Equal(t, bitrise.ConfigSourceModel{Type: bitrise.ConfigSourceTypeFlag, Location: "--env"}, provenance.SourceOf("app.envs.STAGE"))
+		require.Equal(t, bitrise.ConfigSourceModel{Type: bitrise.ConfigSourceTypeFile, Location: envFilePth}, provenance.SourceOf("app.envs.TOKEN"))
+		require.Equal(t, bitrise.ConfigSourceModel{Type: bitrise.ConfigSourceTypeFlag, Location: "--input"}, provenance.SourceOf("workflows.deploy.inputs.channel"))
+	}
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/urfave/cli"
+)
+
+// newValidationItemCheck returns the doctor check of a validated file (config or secrets)
+func newValidationItemCheck(name string, item *ValidationItemModel) bitrise.DoctorCheckModel {
+	check := bitrise.DoctorCheckModel{Category: bitrise.DoctorCategoryConfig, Name: name}
+	if item == nil {
+		check.Status = bitrise.DoctorStatusOK
+		check.Message = "not found, skipped"
+		return check
+	}
+
+	switch {
+	case !item.IsValid:
+		check.Status = bitrise.DoctorStatusFailed
+		check.Message = item.Error
+	case len(item.Warnings) > 0:
+		check.Status = bitrise.DoctorStatusWarning
+		check.Message = strings.Join(item.Warnings, ", ")
+	default:
+		check.Status = bitrise.DoctorStatusOK
+	}
+	return check
+}
+
+// configDoctorChecks validates the config and the secrets (if found)
+func configDoctorChecks(bitriseConfigBase64Data, bitriseConfigPath, inventoryBase64Data, inventoryPath string) []bitrise.DoctorCheckModel {
+	validation, err := validateConfigAndSecrets(bitriseConfigBase64Data, bitriseConfigPath, inventoryBase64Data, inventoryPath, false, false)
+	if err != nil {
+		return []bitrise.DoctorCheckModel{
+			bitrise.DoctorCheckModel{Category: bitrise.DoctorCategoryConfig, Name: "config", Status: bitrise.DoctorStatusFailed, Message: err.Error()},
+		}
+	}
+
+	return []bitrise.DoctorCheckModel{
+		newValidationItemCheck("config", validation.Config),
+		newValidationItemCheck("secrets", validation.Secrets),
+	}
+}
+
+func doctor(c *cli.Context) error {
+	format := c.String(OuputFormatKey)
+	if format == "" {
+		format = output.FormatRaw
+	} else if !(format == output.FormatRaw || format == output.FormatJSON) {
+		registerFatal(fmt.Sprintf("Invalid format: %s", format), []string{}, output.FormatJSON)
+	}
+
+	checks := bitrise.RunDoctorChecks(c.Bool(OfflineKey))
+	checks = append(checks, configDoctorChecks(c.String(ConfigBase64Key), c.String(ConfigKey), c.String(InventoryBase64Key), c.String(InventoryKey))...)
+	report := bitrise.NewDoctorReport(checks)
+
+	switch format {
+	case output.FormatRaw:
+		fmt.Println(bitrise.DoctorReportText(report))
+	case output.FormatJSON:
+		bytes, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to serialize doctor report, error: %s", err)
+		}
+		fmt.Println(string(bytes))
+	}
+
+	// a failed check fails the command, so the doctor can be used as a pre-flight check in scripts
+	if report.Status == bitrise.DoctorStatusFailed {
+		os.Exit(1)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/output"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/urfave/cli"
+)
+
+const (
+	// FormatDotenv prints the envs in .env format
+	FormatDotenv = "dotenv"
+)
+
+// defaultEnvKeys are the envs, set by the CLI for every run
+var defaultEnvKeys = []string{
+	configs.CIModeEnvKey,
+	configs.PRModeEnvKey,
+	configs.BitriseSourceDirEnvKey,
+	configs.BitriseDeployDirEnvKey,
+	configs.BitriseTestResultDirEnvKey,
+	configs.BitriseCacheDirEnvKey,
+}
+
+func envFileLayers(envFiles []models.EnvFileModel, source string) ([]bitrise.EnvLayerModel, error) {
+	layers := []bitrise.EnvLayerModel{}
+	for _, envFile := range envFiles {
+		environments, _, err := bitrise.LoadEnvFiles([]models.EnvFileModel{envFile}, configs.CurrentDir)
+		if err != nil {
+			return []bitrise.EnvLayerModel{}, err
+		}
+		layers = append(layers, bitrise.EnvLayerModel{
+			Source:       fmt.Sprintf("%s (%s)", source, envFile.Path),
+			Environments: environments,
+			IsSecret:     envFile.IsSecret,
+		})
+	}
+	return layers, nil
+}
+
+// workflowEnvLayers returns the env layers of the workflow's steps, in the order of the run:
+// the default envs, the secrets, the app env files and envs, the --env-file and --env envs, the workflow inputs,
+// then the env files and envs of the before_run workflows and of the workflow.
+func workflowEnvLayers(bitriseConfig models.BitriseDataModel, workflowID string, secrets []envmanModels.EnvironmentItemModel) ([]bitrise.EnvLayerModel, error) {
+	isExpand := false
+	workflowTitle := bitriseConfig.Workflows[workflowID].Title
+	if workflowTitle == "" {
+		workflowTitle = workflowID
+	}
+	defaults := []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"BITRISE_TRIGGERED_WORKFLOW_ID": workflowID},
+		envmanModels.EnvironmentItemModel{"BITRISE_TRIGGERED_WORKFLOW_TITLE": workflowTitle},
+	}
+	for _, key := range defaultEnvKeys {
+		if value := os.Getenv(key); value != "" {
+			defaults = append(defaults, envmanModels.EnvironmentItemModel{
+				key:                     value,
+				envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{IsExpand: &isExpand},
+			})
+		}
+	}
+
+	layers := []bitrise.EnvLayerModel{
+		bitrise.EnvLayerModel{Source: "defaults", Environments: defaults},
+		bitrise.EnvLayerModel{Source: "secrets", Environments: secrets, IsSecret: true},
+	}
+
+	appEnvFileLayers, err := envFileLayers(bitriseConfig.App.EnvFiles, "app.env_files")
+	if err != nil {
+		return []bitrise.EnvLayerModel{}, err
+	}
+	layers = append(layers, appEnvFileLayers...)
+	layers = append(layers, bitrise.EnvLayerModel{Source: "app.envs", Environments: bitriseConfig.App.Environments})
+	if len(cliEnvironments) > 0 {
+		layers = append(layers, bitrise.EnvLayerModel{Source: "--env", Environments: cliEnvironments})
+	}
+
+	if inputEnvironments, err := models.ResolveWorkflowInputs(bitriseConfig, workflowID, map[string]string{}); err != nil {
+		log.Warnf("Failed to resolve the default values of the workflow inputs, error: %s", err)
+	} else {
+		layers = append(layers, bitrise.EnvLayerModel{Source: "workflow inputs", Environments: inputEnvironments})
+	}
+
+	for _, chainWorkflowID := range models.WorkflowChain(bitriseConfig, workflowID) {
+		workflow := bitriseConfig.Workflows[chainWorkflowID]
+
+		workflowEnvFileLayers, err := envFileLayers(workflow.EnvFiles, "workflows."+chainWorkflowID+".env_files")
+		if err != nil {
+			return []bitrise.EnvLayerModel{}, err
+		}
+		layers = append(layers, workflowEnvFileLayers...)
+		layers = append(layers, bitrise.EnvLayerModel{Source: "workflows." + chainWorkflowID + ".envs", Environments: workflow.Environments})
+
+		// the after_run workflows run after the workflow's steps
+		if chainWorkflowID == workflowID {
+			break
+		}
+	}
+
+	return layers, nil
+}
+
+func printEnvsTable(envs []bitrise.ResolvedEnvModel) {
+	rows := [][]string{[]string{"KEY", "VALUE", "SOURCE"}}
+	for _, env := range envs {
+		source := env.Source
+		if len(env.OverriddenSources) > 0 {
+			source += " (overrides: " + strings.Join(env.OverriddenSources, ", ") + ")"
+		}
+		rows = append(rows, []string{env.Key, strings.Replace(env.Value, "\n", `\n`, -1), source})
+	}
+
+	widths := []int{0, 0}
+	for _, row := range rows {
+		for idx := range widths {
+			if len(row[idx]) > widths[idx] {
+				widths[idx] = len(row[idx])
+			}
+		}
+	}
+
+	for _, row := range rows {
+		fmt.Printf("%-*s  %-*s  %s\n", widths[0], row[0], widths[1], row[1], row[2])
+	}
+}
+
+func dotenvValue(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+func printEnvs(envs []bitrise.ResolvedEnvModel, format string) error {
+	switch format {
+	case output.FormatRaw:
+		printEnvsTable(envs)
+	case output.FormatJSON:
+		bytes, err := json.Marshal(envs)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(bytes))
+	case FormatDotenv:
+		for _, env := range envs {
+			fmt.Printf("%s=%s\n", env.Key, dotenvValue(env.Value))
+		}
+	default:
+		return fmt.Errorf("Invalid output format: %s", format)
+	}
+	return nil
+}
+
+func envs(c *cli.Context) error {
+	warnings := []string{}
+
+	// Expand cli.Context
+	workflowID := c.String(WorkflowKey)
+	if workflowID == "" && len(c.Args()) > 0 {
+		workflowID = c.Args()[0]
+	}
+
+	bitriseConfigBase64Data := c.String(ConfigBase64Key)
+	bitriseConfigPath := c.String(ConfigKey)
+	inventoryBase64Data := c.String(InventoryBase64Key)
+	inventoryPath := c.String(InventoryKey)
+
+	format := c.String(OuputFormatKey)
+	if format == "" {
+		format = output.FormatRaw
+	}
+	isReveal := c.Bool(RevealKey)
+	//
+
+	// Input validation
+	if format != output.FormatRaw && format != output.FormatJSON && format != FormatDotenv {
+		registerFatal(fmt.Sprintf("Invalid format: %s", format), warnings, output.FormatJSON)
+	}
+	if workflowID == "" {
+		registerFatal("No workflow specified", warnings, format)
+	}
+
+	bitriseConfig, warns, err := CreateBitriseConfigFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath)
+	warnings = append(warnings, warns...)
+	if err != nil {
+		registerFatal(fmt.Sprintf("Failed to create bitrise config, error: %s", err), warnings, format)
+	}
+
+	if workflowID, err = bitriseConfig.ResolveWorkflowAlias(workflowID); err != nil {
+		registerFatal(err.Error(), warnings, format)
+	}
+
+	inventoryEnvironments, err := CreateInventoryFromCLIParams(inventoryBase64Data, inventoryPath)
+	if err != nil {
+		registerFatal(fmt.Sprintf("Failed to create inventory, error: %s", err), warnings, format)
+	}
+
+	if err := registerCLIEnvironments(c.StringSlice(EnvKey), c.StringSlice(EnvFileKey)); err != nil {
+		registerFatal(fmt.Sprintf("Failed to parse envs, error: %s", err), warnings, format)
+	}
+	//
+
+	layers, err := workflowEnvLayers(bitriseConfig, workflowID, inventoryEnvironments)
+	if err != nil {
+		registerFatal(fmt.Sprintf("Failed to collect the envs, error: %s", err), warnings, format)
+	}
+
+	resolvedEnvs, err := bitrise.ResolveEnvLayers(layers)
+	if err != nil {
+		registerFatal(fmt.Sprintf("Failed to resolve the envs, error: %s", err), warnings, format)
+	}
+	if !isReveal {
+		resolvedEnvs = bitrise.RedactResolvedEnvs(resolvedEnvs, layers)
+	}
+
+	if format == output.FormatRaw {
+		for _, warning := range warnings {
+			log.Warnf("warning: %s", warning)
+		}
+	}
+
+	if err := printEnvs(resolvedEnvs, format); err != nil {
+		registerFatal(fmt.Sprintf("Failed to print the envs, error: %s", err), warnings, format)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/bitrise/bitrise"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowEnvLayers(t *testing.T) {
+	configStr := `
+format_version: 1.3.1
+app:
+  envs:
+  - STAGE: app
+workflows:
+  _setup:
+    envs:
+    - STAGE: setup
+  _cleanup:
+    envs:
+    - STAGE: cleanup
+  deploy:
+    title: Deploy
+    before_run:
+    - _setup
+    after_run:
+    - _cleanup
+    inputs:
+      CONFIGURATION:
+        default: Release
+    envs:
+    - DEPLOY_TARGET: $CONFIGURATION-$STAGE
+`
+	config, warnings, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	secrets := []envmanModels.EnvironmentItemModel{envmanModels.EnvironmentItemModel{"API_TOKEN": "secret-token"}}
+	layers, err := workflowEnvLayers(config, "deploy", secrets)
+	require.NoError(t, err)
+
+	sources := []string{}
+	for _, layer := range layers {
+		sources = append(sources, layer.Source)
+	}
+	require.Equal(t, []string{"defaults", "secrets", "app.envs", "workflow inputs", "workflows._setup.envs", "workflows.deploy.envs"}, sources)
+
+	envs, err := bitrise.ResolveEnvLayers(layers)
+	require.NoError(t, err)
+
+	values := map[string]bitrise.ResolvedEnvModel{}
+	for _, env := range envs {
+		values[env.Key] = env
+	}
+	require.Equal(t, "Deploy", values["BITRISE_TRIGGERED_WORKFLOW_TITLE"].Value)
+	require.Equal(t, "Release-setup", values["DEPLOY_TARGET"].Value)
+	require.Equal(t, "workflows._setup.envs", values["STAGE"].Source)
+	require.Equal(t, []string{"app.envs"}, values["STAGE"].OverriddenSources)
+	require.Equal(t, true, values["API_TOKEN"].IsSecret)
+}
+
+func TestDotenvValue(t *testing.T) {
+	require.Equal(t, `"plain"`, dotenvValue("plain"))
+	require.Equal(t, `"line1\nline2 \"quoted\" C:\\dir"`, dotenvValue("line1\nline2 \"quoted\" C:\\dir"))
+}
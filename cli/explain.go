@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/bitrise/tools"
+	envmanModels "github.com/bitrise-io/envman/models"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+func indentLines(str, indent string) string {
+	lines := strings.Split(strings.TrimRight(str, "\n"), "\n")
+	for idx, line := range lines {
+		if line != "" {
+			lines[idx] = indent + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// stepReference returns the step reference, to use in the config (e.g. script@1.1.3)
+func stepReference(info stepmanModels.StepInfoModel, stepYMLPth string) string {
+	if stepYMLPth != "" {
+		return "path::" + filepath.Dir(stepYMLPth)
+	}
+
+	reference := info.ID
+	if info.StepLib != "" && info.StepLib != defaultStepLibSource {
+		reference = info.StepLib + "::" + reference
+	}
+	if info.Version != "" {
+		reference += "@" + info.Version
+	}
+	return reference
+}
+
+// stepExampleYML returns a paste-ready step list item, with the step's inputs and their default values
+func stepExampleYML(info stepmanModels.StepInfoModel, stepReference string) (string, error) {
+	inputs := []envmanModels.EnvironmentItemModel{}
+	for _, input := range info.Inputs {
+		inputs = append(inputs, envmanModels.EnvironmentItemModel{input.Key: input.DefaultValue})
+	}
+
+	bytes, err := yaml.Marshal([]models.StepListItemModel{
+		models.StepListItemModel{stepReference: models.WorkflowStepModel{StepModel: stepmanModels.StepModel{Inputs: inputs}}},
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+func renderEnvInfos(envInfos []stepmanModels.EnvInfoModel) string {
+	lines := []string{}
+	for _, envInfo := range envInfos {
+		line := "- " + output.Blue(envInfo.Key)
+		if envInfo.Title != "" {
+			line += ": " + envInfo.Title
+		}
+		lines = append(lines, line)
+
+		if envInfo.DefaultValue != "" {
+			lines = append(lines, indentLines("default: "+envInfo.DefaultValue, "  "))
+		}
+		if len(envInfo.ValueOptions) > 0 {
+			lines = append(lines, "  value options: "+strings.Join(envInfo.ValueOptions, ", "))
+		}
+		if envInfo.Description != "" {
+			lines = append(lines, indentLines(envInfo.Description, "  "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderStepDocumentation renders the step's documentation for the terminal
+func renderStepDocumentation(info stepmanModels.StepInfoModel, example string) string {
+	sections := []string{}
+
+	header := []string{output.Green(info.Title)}
+	versionLine := "ID: " + info.ID
+	if info.Version != "" {
+		versionLine += ", version: " + info.Version
+	}
+	if info.Latest != "" && info.Latest != info.Version {
+		versionLine += " " + output.Yellow(fmt.Sprintf("(latest: %s)", info.Latest))
+	}
+	header = append(header, versionLine)
+	if info.StepLib != "" {
+		header = append(header, "StepLib: "+info.StepLib)
+	}
+	if info.SourceCodeURL != "" {
+		header = append(header, "Source code: "+info.SourceCodeURL)
+	}
+	if info.SupportURL != "" {
+		header = append(header, "Support: "+info.SupportURL)
+	}
+	if deprecationNotice := bitrise.StepDeprecationNotice(info); deprecationNotice != "" {
+		header = append(header, output.Red(deprecationNotice))
+	}
+	sections = append(sections, strings.Join(header, "\n"))
+
+	if info.Description != "" {
+		sections = append(sections, output.Blue("Description")+"\n"+indentLines(info.Description, "  "))
+	}
+
+	if len(info.Inputs) > 0 {
+		sections = append(sections, output.Blue("Inputs")+"\n"+indentLines(renderEnvInfos(info.Inputs), "  "))
+	}
+
+	if len(info.Outputs) > 0 {
+		sections = append(sections, output.Blue("Outputs")+"\n"+indentLines(renderEnvInfos(info.Outputs), "  "))
+	}
+
+	if example != "" {
+		sections = append(sections, output.Blue("Example")+"\n"+indentLines(example, "  "))
+	}
+
+	return strings.Join(sections, "\n\n") + "\n"
+}
+
+func explain(c *cli.Context) error {
+	// Expand cli.Context
+	bitriseConfigBase64Data := c.String(ConfigBase64Key)
+	bitriseConfigPath := c.String(ConfigKey)
+
+	stepYMLPth := c.String(StepYMLKey)
+	collectionURI := c.String(CollectionKey)
+	version := c.String(VersionKey)
+	isExample := c.Bool(ExampleKey)
+
+	id := ""
+	if len(c.Args()) > 0 {
+		id = c.Args()[0]
+	}
+	if id == "" && stepYMLPth == "" {
+		return fmt.Errorf("No step specified")
+	}
+	if split := strings.Split(id, "@"); len(split) == 2 && version == "" {
+		id = split[0]
+		version = split[1]
+	}
+	//
+
+	var info stepmanModels.StepInfoModel
+	if stepYMLPth != "" {
+		stepInfo, err := tools.StepmanLocalStepInfo(stepYMLPth)
+		if err != nil {
+			return fmt.Errorf("Failed to get step info (yml path: %s), error: %s", stepYMLPth, err)
+		}
+		if stepInfo.ID == "" {
+			stepInfo.ID = filepath.Base(filepath.Dir(stepYMLPth))
+		}
+		info = stepInfo
+	} else {
+		if collectionURI == "" {
+			collectionURI = defaultStepLibSource
+			if bitriseConfig, _, err := CreateBitriseConfigFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath); err == nil && bitriseConfig.DefaultStepLibSource != "" {
+				collectionURI = bitriseConfig.DefaultStepLibSource
+			}
+		}
+
+		stepInfo, err := tools.StepmanStepLibStepInfo(collectionURI, id, version)
+		if err != nil {
+			return fmt.Errorf("Failed to get step info, error: %s", err)
+		}
+		info = stepInfo
+	}
+
+	example, err := stepExampleYML(info, stepReference(info, stepYMLPth))
+	if err != nil {
+		return fmt.Errorf("Failed to create example config, error: %s", err)
+	}
+
+	if isExample {
+		fmt.Print(example)
+		return nil
+	}
+
+	fmt.Print(renderStepDocumentation(info, example))
+	return nil
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func testStepInfo() stepmanModels.StepInfoModel {
+	return stepmanModels.StepInfoModel{
+		ID:          "xcode-archive",
+		Title:       "Xcode Archive",
+		Version:     "2.0.0",
+		Latest:      "2.1.0",
+		Description: "Creates an archive.\nExports an IPA.",
+		StepLib:     defaultStepLibSource,
+		Inputs: []stepmanModels.EnvInfoModel{
+			stepmanModels.EnvInfoModel{Key: "project_path", Title: "Project path", DefaultValue: "$BITRISE_PROJECT_PATH"},
+			stepmanModels.EnvInfoModel{Key: "export_method", DefaultValue: "development", ValueOptions: []string{"app-store", "development"}},
+			stepmanModels.EnvInfoModel{Key: "team_id"},
+		},
+		Outputs: []stepmanModels.EnvInfoModel{
+			stepmanModels.EnvInfoModel{Key: "BITRISE_IPA_PATH", Description: "The created IPA."},
+		},
+	}
+}
+
+func TestStepReference(t *testing.T) {
+	info := testStepInfo()
+	require.Equal(t, "xcode-archive@2.0.0", stepReference(info, ""))
+
+	info.StepLib = "https://github.com/org/steplib.git"
+	require.Equal(t, "https://github.com/org/steplib.git::xcode-archive@2.0.0", stepReference(info, ""))
+
+	require.Equal(t, "path::steps/my-step", stepReference(info, "./steps/my-step/step.yml"))
+}
+
+func TestStepExampleYML(t *testing.T) {
+	example, err := stepExampleYML(testStepInfo(), "xcode-archive@2.0.0")
+	require.NoError(t, err)
+	require.Equal(t, `- xcode-archive@2.0.0:
+    inputs:
+    - project_path: $BITRISE_PROJECT_PATH
+    - export_method: development
+    - team_id: ""
+`, example)
+
+	var stepList []map[string]stepmanModels.StepModel
+	require.NoError(t, yaml.Unmarshal([]byte(example), &stepList))
+	require.Equal(t, 3, len(stepList[0]["xcode-archive@2.0.0"].Inputs))
+}
+
+func TestRenderStepDocumentation(t *testing.T) {
+	doc := renderStepDocumentation(testStepInfo(), "- xcode-archive@2.0.0: {}\n")
+
+	require.Equal(t, true, strings.Contains(doc, "ID: xcode-archive, version: 2.0.0"))
+	require.Equal(t, true, strings.Contains(doc, "(latest: 2.1.0)"))
+	require.Equal(t, true, strings.Contains(doc, "  Creates an archive.\n  Exports an IPA."))
+	require.Equal(t, true, strings.Contains(doc, "    value options: app-store, development"))
+	require.Equal(t, true, strings.Contains(doc, "    The created IPA."))
+	require.Equal(t, true, strings.Contains(doc, "  - xcode-archive@2.0.0: {}"))
+}
@@ -1,11 +1,11 @@
 package cli
 
 import (
-	"encoding/json"
-
-	"gopkg.in/yaml.v2"
+	"strings"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/exitcode"
 	"github.com/bitrise-io/bitrise/output"
 	"github.com/bitrise-io/go-utils/fileutil"
 	"github.com/urfave/cli"
@@ -40,29 +40,19 @@ func export(c *cli.Context) error {
 		log.Warnf("warning: %s", warning)
 	}
 	if err != nil {
-		log.Fatalf("Failed to create bitrise config, error: %s", err)
+		exitcode.Fatalf(exitcode.ConfigInvalid, "Failed to create bitrise config, error: %s", err)
 	}
 
 	// serialize
-	configBytes := []byte{}
-	if outFormat == output.FormatJSON {
-		if prettyFormat {
-			configBytes, err = json.MarshalIndent(bitriseConfig, "", "\t")
-		} else {
-			configBytes, err = json.Marshal(bitriseConfig)
-		}
-		if err != nil {
-			log.Fatalf("Failed to generate config JSON, error: %s", err)
-		}
-	} else if outFormat == output.FormatYML {
-		configBytes, err = yaml.Marshal(bitriseConfig)
-		if err != nil {
-			log.Fatalf("Failed to generate config YML, error: %s", err)
-		}
-	} else {
+	if outFormat != output.FormatJSON && outFormat != output.FormatYML {
 		log.Fatalf("Invalid output format: %s", outFormat)
 	}
 
+	configBytes, err := bitrise.MarshalConfig(bitriseConfig, outFormat, prettyFormat)
+	if err != nil {
+		log.Fatalf("Failed to generate config %s, error: %s", strings.ToUpper(outFormat), err)
+	}
+
 	// write to file
 	if err := fileutil.WriteBytesToFile(outfilePth, configBytes); err != nil {
 		log.Fatalf("Failed to write file (%s), error: %s", outfilePth, err)
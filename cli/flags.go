@@ -1,7 +1,9 @@
 package cli
 
 import (
+	"github.com/bitrise-io/bitrise/bitrise"
 	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/output"
 	"github.com/urfave/cli"
 )
 
@@ -15,6 +17,17 @@ const (
 	// DebugModeKey ...
 	DebugModeKey = "debug"
 
+	// CPUProfileKey ...
+	CPUProfileKey = "cpuprofile"
+	// MemProfileKey ...
+	MemProfileKey = "memprofile"
+	// TraceKey ...
+	TraceKey = "trace"
+	// ThemeKey ...
+	ThemeKey = "theme"
+	// NoANSIKey ...
+	NoANSIKey = "no-ansi"
+
 	// LogLevelKey ...
 	LogLevelKey      = "loglevel"
 	logLevelKeyShort = "l"
@@ -49,6 +62,8 @@ const (
 	MinimalModeKey = "minimal"
 	// FullModeKey ...
 	FullModeKey = "full"
+	// FromBundleKey ...
+	FromBundleKey = "from-bundle"
 
 	ouputFormatKeyShort = "f"
 	// OuputPathKey ...
@@ -74,6 +89,95 @@ const (
 	GitKey = "git"
 	// StepIDKey ...
 	StepIDKey = "stepid"
+	// NonInteractiveKey ...
+	NonInteractiveKey = "non-interactive"
+
+	// LockedKey ...
+	LockedKey = "locked"
+	// UpdateLockKey ...
+	UpdateLockKey = "update-lock"
+	// SummaryPathKey ...
+	SummaryPathKey = "summary-path"
+	// ReportHTMLKey ...
+	ReportHTMLKey = "report-html"
+	// ProfileKey ...
+	ProfileKey = "profile"
+	// ProfilePathKey ...
+	ProfilePathKey = "profile-path"
+	// LogStreamKey ...
+	LogStreamKey = "log-stream"
+	// QuietKey ...
+	QuietKey = "quiet"
+	// LogDirKey ...
+	LogDirKey = "log-dir"
+	// LogMaxSizeKey ...
+	LogMaxSizeKey = "log-max-size"
+	// LogRetentionKey ...
+	LogRetentionKey = "log-retention"
+	// RerunFailedKey ...
+	RerunFailedKey = "rerun-failed"
+	// InputKey ...
+	InputKey = "input"
+	// EnvKey ...
+	EnvKey = "env"
+	// EnvFileKey ...
+	EnvFileKey = "env-file"
+
+	//
+	// Explain
+
+	// ExampleKey ...
+	ExampleKey = "example"
+
+	//
+	// Step update
+
+	// DryRunKey ...
+	DryRunKey = "dry-run"
+	// MajorKey ...
+	MajorKey = "major"
+
+	//
+	// Bench
+
+	// StepKey ...
+	StepKey = "step"
+	// InputsKey ...
+	InputsKey = "inputs"
+	// IterationsKey ...
+	IterationsKey = "n"
+
+	//
+	// Validate
+
+	// WatchKey ...
+	WatchKey = "watch"
+	// LintKey ...
+	LintKey = "lint"
+	// DeepKey ...
+	DeepKey = "deep"
+	// StrictKey ...
+	StrictKey = "strict"
+	// NoDeprecatedKey ...
+	NoDeprecatedKey = "no-deprecated"
+	// StepAuditKey ...
+	StepAuditKey = "step-audit"
+	// DependencyManagerKey ...
+	DependencyManagerKey = "dependency-manager"
+	// ArtifactUploadKey ...
+	ArtifactUploadKey = "artifact-upload"
+	// ArtifactRetentionDaysKey ...
+	ArtifactRetentionDaysKey = "artifact-retention-days"
+	// TimeoutKey ...
+	TimeoutKey = "timeout"
+	// TimeoutGraceKey ...
+	TimeoutGraceKey = "timeout-grace"
+
+	//
+	// Doctor
+
+	// OfflineKey ...
+	OfflineKey = "offline"
 )
 
 var (
@@ -97,11 +201,41 @@ var (
 		Name:  PRKey,
 		Usage: "If true bitrise runs in pull request mode.",
 	}
+	// Profiling flags, for diagnosing the CLI itself
+	flCPUProfile = cli.BoolFlag{
+		Name:   CPUProfileKey,
+		Usage:  "Write a CPU profile of the CLI into the debug dir.",
+		Hidden: true,
+	}
+	flMemProfile = cli.BoolFlag{
+		Name:   MemProfileKey,
+		Usage:  "Write a heap profile of the CLI into the debug dir.",
+		Hidden: true,
+	}
+	flTrace = cli.BoolFlag{
+		Name:   TraceKey,
+		Usage:  "Write an execution trace of the CLI into the debug dir.",
+		Hidden: true,
+	}
+	flTheme = cli.StringFlag{
+		Name:   ThemeKey,
+		Usage:  "Console theme (options: default, high-contrast, plain).",
+		EnvVar: output.ThemeEnvKey,
+	}
+	flNoANSI = cli.BoolFlag{
+		Name:  NoANSIKey,
+		Usage: "Disable the colors and every other ANSI escape sequence of the console output (same as the plain theme, or the NO_COLOR env).",
+	}
 	flags = []cli.Flag{
 		flLogLevel,
 		flDebugMode,
 		flTool,
 		flPRMode,
+		flTheme,
+		flNoANSI,
+		flCPUProfile,
+		flMemProfile,
+		flTrace,
 	}
 	// Command flags
 	flOutputFormat = cli.StringFlag{
@@ -112,6 +246,104 @@ var (
 		Name:  PathKey + ", " + pathKeyShort,
 		Usage: "[Deprecated!!! Use 'config'] Path where the workflow config file is located.",
 	}
+	flSummaryPath = cli.StringFlag{
+		Name:   SummaryPathKey,
+		Usage:  "Write the build summary (JSON) to the given path, at the end of the run.",
+		EnvVar: configs.BuildSummaryPathEnvKey,
+	}
+	flInput = cli.StringSliceFlag{
+		Name:  InputKey,
+		Usage: "Input value of the workflow, in KEY=VALUE format. Can be specified multiple times.",
+	}
+	flEnv = cli.StringSliceFlag{
+		Name:  EnvKey,
+		Usage: "Env to add to the run (after the secrets and the app envs), in KEY=VALUE format. Can be specified multiple times.",
+	}
+	flEnvFile = cli.StringSliceFlag{
+		Name:  EnvFileKey,
+		Usage: "Path of a .env file, which envs are added to the run (before the --env envs). Can be specified multiple times.",
+	}
+	flStrict = cli.BoolFlag{
+		Name:   StrictKey,
+		Usage:  "Fail on the unknown config keys (e.g. typos like before_rub), instead of ignoring them.",
+		EnvVar: configs.StrictConfigEnvKey,
+	}
+	flNoDeprecated = cli.BoolFlag{
+		Name:  NoDeprecatedKey,
+		Usage: "Fail the deprecated steps (removal date, deprecate notes or replacement notice), instead of running them.",
+	}
+	flStepAudit = cli.StringFlag{
+		Name:   StepAuditKey,
+		Usage:  "Audit the activated steps (step.yml and entry file), like stepman does before sharing a step. Accepted: warn, fail.",
+		EnvVar: configs.StepAuditModeEnvKey,
+	}
+	flDependencyManager = cli.StringFlag{
+		Name:   DependencyManagerKey,
+		Usage:  "Package manager of the step dependencies, selected by the platform if not set. Accepted: brew, apt, dnf, none (don't install the dependencies).",
+		EnvVar: configs.DependencyManagerEnvKey,
+	}
+	flArtifactUpload = cli.StringFlag{
+		Name:   ArtifactUploadKey,
+		Usage:  "Upload the content of the deploy dir at the end of the run. Accepted: s3://<bucket>/<prefix>, gs://<bucket>/<prefix>, http(s)://<url> (PUT), file://<dir> (.tar.gz archive).",
+		EnvVar: configs.ArtifactUploadURLEnvKey,
+	}
+	flArtifactRetentionDays = cli.IntFlag{
+		Name:   ArtifactRetentionDaysKey,
+		Usage:  "Number of days the uploaded artifacts should be kept for (see: --artifact-upload). 0 keeps them forever.",
+		EnvVar: configs.ArtifactRetentionDaysEnvKey,
+	}
+	flTimeout = cli.IntFlag{
+		Name:   TimeoutKey,
+		Usage:  "Time limit of the whole run (every chained workflow), in seconds. Once exceeded, the running step is aborted and only the is_always_run steps run. 0 means no limit.",
+		EnvVar: configs.BuildTimeoutEnvKey,
+	}
+	flTimeoutGrace = cli.IntFlag{
+		Name:   TimeoutGraceKey,
+		Usage:  "Time the is_always_run steps get to finish, once the run timed out (see: --timeout), in seconds. The CLI exits once it's exceeded.",
+		Value:  bitrise.DefaultBuildTimeoutGraceSecs,
+		EnvVar: configs.BuildTimeoutGraceEnvKey,
+	}
+	flReportHTML = cli.StringFlag{
+		Name:   ReportHTMLKey,
+		Usage:  "Write a single-file HTML report (step timeline, step logs and env changes) to the given path, at the end of the run.",
+		EnvVar: configs.HTMLReportPathEnvKey,
+	}
+	flProfile = cli.BoolFlag{
+		Name:  ProfileKey,
+		Usage: "Print the slowest steps at the end of the run, with the time spent activating (cloning, downloading), preparing (compiling) and running each step.",
+	}
+	flProfilePath = cli.StringFlag{
+		Name:  ProfilePathKey,
+		Usage: "Write the step timing profile (JSON, the slowest step first) to the given path, at the end of the run.",
+	}
+	flLogStream = cli.StringFlag{
+		Name:   LogStreamKey,
+		Usage:  "Stream the structured log events of the run (Server-Sent Events, GET /events) on the given address, e.g. 127.0.0.1:9090.",
+		EnvVar: configs.LogStreamAddressEnvKey,
+	}
+	flQuiet = cli.BoolFlag{
+		Name:  QuietKey,
+		Usage: "Print the output of the failed steps and the summary only, the output of the successful steps is discarded.",
+	}
+	flRerunFailed = cli.BoolFlag{
+		Name:  RerunFailedKey,
+		Usage: "Run only the steps which failed in the last run of the workflow (and the steps whose outputs they use), with the envs from before the first failed step.",
+	}
+	flLogDir = cli.StringFlag{
+		Name:   LogDirKey,
+		Usage:  "Write the whole output of the run into a per-run directory (<log-dir>/<timestamp>/bitrise.log) as well.",
+		EnvVar: configs.RunLogDirEnvKey,
+	}
+	flLogMaxSize = cli.IntFlag{
+		Name:  LogMaxSizeKey,
+		Usage: "Rotate the run's log file (see: --log-dir) at the given size, in MB. 0 disables the rotation.",
+		Value: bitrise.DefaultRunLogMaxSizeMB,
+	}
+	flLogRetention = cli.IntFlag{
+		Name:  LogRetentionKey,
+		Usage: "Number of the latest per-run log directories kept in the log dir (see: --log-dir). 0 keeps every run's logs.",
+		Value: bitrise.DefaultRunLogRetention,
+	}
 	flCollection = cli.StringFlag{
 		Name:   CollectionKey + ", " + collectionKeyShort,
 		Usage:  "Collection of step.",
@@ -119,7 +351,7 @@ var (
 	}
 	flConfig = cli.StringFlag{
 		Name:  ConfigKey + ", " + configShortKey,
-		Usage: "Path where the workflow config file is located.",
+		Usage: "Path where the workflow config file is located, or - to read it from stdin.",
 	}
 	flConfigBase64 = cli.StringFlag{
 		Name:  ConfigBase64Key,
@@ -127,7 +359,7 @@ var (
 	}
 	flInventory = cli.StringFlag{
 		Name:  InventoryKey + ", " + inventoryShortKey,
-		Usage: "Path of the inventory file.",
+		Usage: "Path of the inventory file, or - to read it from stdin.",
 	}
 	flInventoryBase64 = cli.StringFlag{
 		Name:  InventoryBase64Key,
@@ -136,12 +368,16 @@ var (
 	// Setup
 	flMinimalSetup = cli.BoolFlag{
 		Name:  MinimalModeKey,
-		Usage: "[Deprecated!!!] Minimal setup mode: skips more thorough checking, like brew doctor.",
+		Usage: "Minimal setup mode: installs only stepman and envman, skips the platform specific tools (e.g. Homebrew), the default plugins and the toolkits.",
 	}
 	flFullModeSteup = cli.BoolFlag{
 		Name:  FullModeKey,
 		Usage: "Full setup mode: also calls 'brew doctor'.",
 	}
+	flFromBundle = cli.StringFlag{
+		Name:  FromBundleKey,
+		Usage: "Path of a setup bundle (created by 'bitrise bundle create'), installs its tools, plugins and StepLib snapshot without any network access.",
+	}
 	// Export
 	flFormat = cli.StringFlag{
 		Name:  OuputFormatKey,
@@ -185,6 +421,10 @@ var (
 		Name:  StepIDKey,
 		Usage: "ID of the step.",
 	}
+	flNonInteractive = cli.BoolFlag{
+		Name:  NonInteractiveKey,
+		Usage: "Don't ask for any user input, fail instead (the default in CI mode).",
+	}
 )
 
 func initHelpAndVersionFlags() {
@@ -6,8 +6,9 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/exitcode"
 	"github.com/bitrise-io/bitrise/models"
-	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/bitrise-io/bitrise/output"
 	"github.com/bitrise-io/go-utils/fileutil"
 	"github.com/bitrise-io/go-utils/pathutil"
 	"github.com/bitrise-io/goinp/goinp"
@@ -163,7 +164,7 @@ func initConfig(c *cli.Context) error {
 		log.Warnf("warning: %s", warning)
 	}
 	if err != nil {
-		log.Fatalf("Invalid Bitrise YML, error: %s", err)
+		exitcode.Fatalf(exitcode.ConfigInvalid, "Invalid Bitrise YML, error: %s", err)
 	}
 
 	if err := fileutil.WriteStringToFile(bitriseConfigFileRelPath, bitriseConfContent); err != nil {
@@ -192,7 +193,7 @@ func initConfig(c *cli.Context) error {
 		fmt.Println("This way you can safely commit and share your configuration file")
 		fmt.Println(" and ignore this secrets file, so nobody else will")
 		fmt.Println(" know about your secrets.")
-		fmt.Println(colorstring.Yellow("You should NEVER commit this secrets file into your repository!!"))
+		fmt.Println(output.Yellow("You should NEVER commit this secrets file into your repository!!"))
 		fmt.Println()
 	}
 
@@ -202,7 +203,7 @@ func initConfig(c *cli.Context) error {
 	if err := addToGitignore(".bitrise*"); err != nil {
 		log.Fatalf("Failed to add .gitignore pattern, error: %s", err)
 	}
-	fmt.Println(colorstring.Green("For your convenience we added the pattern '.bitrise*' to your .gitignore file"))
+	fmt.Println(output.Green("For your convenience we added the pattern '.bitrise*' to your .gitignore file"))
 	fmt.Println(" to make it sure that no secrets or temporary work directories will be")
 	fmt.Println(" committed into your repository.")
 
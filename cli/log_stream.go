@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/bitrise/plugins"
+)
+
+// logStream is the live log stream of the run, if requested (see: --log-stream)
+var logStream *bitrise.LogStreamModel
+
+// startLogStream starts serving the live log stream on configs.LogStreamAddress, if set
+func startLogStream() error {
+	if configs.LogStreamAddress == "" {
+		return nil
+	}
+
+	stream := bitrise.NewLogStream()
+	addr, err := stream.Start(configs.LogStreamAddress)
+	if err != nil {
+		return err
+	}
+
+	if err := plugins.RegisterInProcessPlugin(stream); err != nil {
+		stream.Close()
+		return fmt.Errorf("Failed to register log stream, error: %s", err)
+	}
+	log.AddHook(stream)
+	stream.StartStepLogStream()
+
+	logStream = stream
+	log.Infof("Streaming the run's log events on: %s", output.Cyanf("http://%s/events", addr))
+	return nil
+}
+
+// stopLogStream ends the live log stream, has to be called before the CLI exits
+func stopLogStream() {
+	if logStream == nil {
+		return
+	}
+
+	stream := logStream
+	logStream = nil
+
+	// the CLI messages are not streamed after the stream is closed (the log hook can't be removed)
+	stream.StopStepLogStream()
+	plugins.UnregisterInProcessPlugin(stream.Name())
+	stream.Close()
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/exitcode"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/urfave/cli"
+)
+
+func merge(c *cli.Context) error {
+	// Expand cli.Context
+	configPths := c.Args()
+	outfilePth := c.String(OuputPathKey)
+	format := c.String(OuputFormatKey)
+	//
+
+	// Input validation
+	if len(configPths) < 2 {
+		log.Fatal("At least two configs are required: bitrise merge base.yml overlay.yml [overlay.yml...]")
+	}
+	if format == "" {
+		format = output.FormatYML
+	}
+	if format != output.FormatYML && format != output.FormatJSON {
+		log.Fatalf("Invalid output format: %s", format)
+	}
+
+	mergedConfig, provenance, warnings, overrides, err := bitrise.MergeConfigFiles(configPths)
+	for _, warning := range warnings {
+		log.Warnf("warning: %s", warning)
+	}
+	if err != nil {
+		exitcode.Fatalf(exitcode.ConfigInvalid, "Failed to merge configs, error: %s", err)
+	}
+	for _, override := range overrides {
+		log.Infof("override: %s", override)
+	}
+	saveConfigProvenance(provenance)
+
+	configBytes, err := bitrise.MarshalConfig(mergedConfig, format, true)
+	if err != nil {
+		log.Fatalf("Failed to generate merged config, error: %s", err)
+	}
+
+	if outfilePth == "" {
+		fmt.Print(string(configBytes))
+		if format == output.FormatJSON {
+			fmt.Println()
+		}
+		return nil
+	}
+
+	if err := fileutil.WriteBytesToFile(outfilePth, configBytes); err != nil {
+		log.Fatalf("Failed to write file (%s), error: %s", outfilePth, err)
+	}
+	log.Infof("Done, saved to path: %s", outfilePth)
+
+	return nil
+}
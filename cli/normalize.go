@@ -3,6 +3,10 @@ package cli
 import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/exitcode"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/go-utils/fileutil"
 	"github.com/urfave/cli"
 )
 
@@ -16,9 +20,14 @@ func normalize(c *cli.Context) error {
 		log.Warn("'path' key is deprecated, use 'config' instead!")
 		bitriseConfigPath = deprecatedBitriseConfigPath
 	}
+
+	format := c.String(OuputFormatKey)
 	//
 
 	// Input validation
+	if bitriseConfigPath == StdinPath {
+		log.Fatal("The config can not be read from stdin, normalize writes the config file")
+	}
 	bitriseConfigPath, err := GetBitriseConfigFilePath(bitriseConfigPath)
 	if err != nil {
 		log.Fatalf("Failed to get bitrise config path, error: %s", err)
@@ -27,24 +36,63 @@ func normalize(c *cli.Context) error {
 		log.Fatal("No bitrise config path defined!")
 	}
 
+	// the normalized config is saved in the given format, next to the config (bitrise.yml -> bitrise.json)
+	outputPath := bitriseConfigPath
+	if format != "" {
+		if format != output.FormatJSON && format != output.FormatYML {
+			log.Fatalf("Invalid output format: %s", format)
+		}
+		outputPath = bitrise.PathWithConfigFormat(bitriseConfigPath, format)
+	}
+
 	// Config validation
 	bitriseConfig, warnings, err := CreateBitriseConfigFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath)
 	for _, warning := range warnings {
 		log.Warnf("warning: %s", warning)
 	}
 	if err != nil {
-		log.Fatalf("Failed to create bitrise config, error: %s", err)
+		exitcode.Fatalf(exitcode.ConfigInvalid, "Failed to create bitrise config, error: %s", err)
 	}
 
 	// Normalize
+	if bitriseConfigBase64Data == "" &&
+		bitrise.ConfigFormatOfPath(bitriseConfigPath) == bitrise.ConfigFormatYML && bitrise.ConfigFormatOfPath(outputPath) == bitrise.ConfigFormatYML {
+		// keep the comments and the anchors of the YAML config
+		content, err := fileutil.ReadBytesFromFile(bitriseConfigPath)
+		if err != nil {
+			log.Fatalf("Failed to read config, error: %s", err)
+		}
+
+		var normalizeErr error
+		normalizedContent, _, err := bitrise.NormalizeConfigContent(content, func(config *models.BitriseDataModel) error {
+			normalizeErr = bitrise.RemoveConfigRedundantFieldsAndFillStepOutputs(config)
+			return normalizeErr
+		})
+		if normalizeErr != nil {
+			log.Fatalf("Failed to remove redundant fields, error: %s", normalizeErr)
+		}
+		if err == nil {
+			if err := fileutil.WriteBytesToFile(outputPath, normalizedContent); err != nil {
+				log.Fatalf("Failed to save config to file, error: %s", err)
+			}
+
+			log.Info("Redundant fields removed")
+			return nil
+		}
+		log.Warnf("Failed to keep the comments and the anchors of the config, error: %s", err)
+	}
+
 	if err := bitrise.RemoveConfigRedundantFieldsAndFillStepOutputs(&bitriseConfig); err != nil {
 		log.Fatalf("Failed to remove redundant fields, error: %s", err)
 	}
-	if err := bitrise.SaveConfigToFile(bitriseConfigPath, bitriseConfig); err != nil {
+	if err := bitrise.SaveConfigToFile(outputPath, bitriseConfig); err != nil {
 		log.Fatalf("Failed to save config to file, error: %s", err)
 	}
 
 	log.Info("Redundant fields removed")
+	if outputPath != bitriseConfigPath {
+		log.Infof("Normalized config saved to: %s", outputPath)
+	}
 
 	return nil
 }
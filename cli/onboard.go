@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/bitrise/version"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/goinp/goinp"
+	"github.com/urfave/cli"
+)
+
+// selectProjectType asks the user to choose from the detected project types
+func selectProjectType(detected []bitrise.ProjectTypeModel) (bitrise.ProjectTypeModel, error) {
+	if len(detected) == 1 {
+		fmt.Printf("Detected project type: %s\n", output.Green(detected[0].Name))
+		return detected[0], nil
+	}
+
+	names := []string{}
+	for _, projectType := range detected {
+		names = append(names, projectType.Name)
+	}
+
+	selected, err := goinp.SelectFromStrings("Multiple project types detected, which one do you want to set up?", names)
+	if err != nil {
+		return bitrise.ProjectTypeModel{}, err
+	}
+	for _, projectType := range detected {
+		if projectType.Name == selected {
+			return projectType, nil
+		}
+	}
+	return bitrise.ProjectTypeModel{}, fmt.Errorf("invalid project type: %s", selected)
+}
+
+// askForSecrets asks the secret envs (e.g. API tokens) of the project
+func askForSecrets() ([]envmanModels.EnvironmentItemModel, error) {
+	secrets := []envmanModels.EnvironmentItemModel{}
+	for {
+		ask := "Do you want to add a secret env (e.g. an API token)?"
+		if len(secrets) > 0 {
+			ask = "Do you want to add another secret env?"
+		}
+		if val, err := goinp.AskForBoolWithDefault(ask, false); err != nil {
+			return []envmanModels.EnvironmentItemModel{}, err
+		} else if !val {
+			return secrets, nil
+		}
+
+		key, err := goinp.AskForString("Key of the secret env (e.g. MY_API_TOKEN):")
+		if err != nil {
+			return []envmanModels.EnvironmentItemModel{}, err
+		}
+		if key == "" {
+			log.Warn("Empty key, secret skipped")
+			continue
+		}
+
+		value, err := goinp.AskForString("Value of the secret env:")
+		if err != nil {
+			return []envmanModels.EnvironmentItemModel{}, err
+		}
+
+		secrets = append(secrets, envmanModels.EnvironmentItemModel{key: value})
+	}
+}
+
+// saveEncryptedSecrets saves the secrets file, encrypted with the local secrets key
+func saveEncryptedSecrets(pth string, secrets []envmanModels.EnvironmentItemModel) (bool, error) {
+	content, err := bitrise.SecretsInventoryContent(secrets)
+	if err != nil {
+		return false, fmt.Errorf("Failed to create secrets file content, error: %s", err)
+	}
+
+	key, err := bitrise.ReadOrCreateSecretsKey(configs.GetBitriseSecretsKeyPath())
+	if err != nil {
+		return false, err
+	}
+
+	encrypted, err := bitrise.EncryptSecrets(content, key)
+	if err != nil {
+		return false, fmt.Errorf("Failed to encrypt secrets, error: %s", err)
+	}
+
+	return saveSecretsToFile(pth, string(encrypted))
+}
+
+func printOnboardingConfig(config models.BitriseDataModel) {
+	workflowIDs := []string{}
+	for workflowID := range config.Workflows {
+		workflowIDs = append(workflowIDs, workflowID)
+	}
+	sort.Strings(workflowIDs)
+
+	fmt.Println()
+	fmt.Println(output.Blue("Proposed workflows:"))
+	for _, workflowID := range workflowIDs {
+		fmt.Printf("- %s: %s\n", output.Green(workflowID), config.Workflows[workflowID].Summary)
+	}
+
+	fmt.Println()
+	fmt.Println(output.Blue("Proposed trigger map:"))
+	for _, item := range config.TriggerMap {
+		if item.PushBranch != "" {
+			fmt.Printf("- push to branch %s -> %s\n", item.PushBranch, item.WorkflowID)
+		} else if item.PullRequestSourceBranch != "" {
+			fmt.Printf("- pull request from branch %s -> %s\n", item.PullRequestSourceBranch, item.WorkflowID)
+		}
+	}
+	fmt.Println()
+}
+
+func onboard(c *cli.Context) error {
+	PrintBitriseHeaderASCIIArt(version.VERSION)
+
+	fmt.Println("Welcome to bitrise! This wizard sets up a bitrise config for your project,")
+	fmt.Println(" stores your secrets encrypted and runs a smoke workflow to check the setup.")
+	fmt.Println()
+
+	bitriseConfigFileRelPath := "./" + DefaultBitriseConfigFileName
+	bitriseSecretsFileRelPath := "./" + DefaultSecretsFileName
+
+	if exists, err := pathutil.IsPathExists(bitriseConfigFileRelPath); err != nil {
+		log.Fatalf("Failed to check path (%s), error: %s", bitriseConfigFileRelPath, err)
+	} else if exists {
+		ask := fmt.Sprintf("A config file already exists at %s - do you want to overwrite it?", bitriseConfigFileRelPath)
+		if val, err := goinp.AskForBoolWithDefault(ask, false); err != nil {
+			log.Fatalf("Failed to ask for input, error: %s", err)
+		} else if !val {
+			log.Info("Onboarding canceled, existing file won't be overwritten.")
+			os.Exit(0)
+		}
+	}
+
+	// Project type
+	detected, err := bitrise.DetectProjectTypes(configs.CurrentDir)
+	if err != nil {
+		log.Fatalf("Failed to detect project type, error: %s", err)
+	}
+	projectType, err := selectProjectType(detected)
+	if err != nil {
+		log.Fatalf("Failed to select project type, error: %s", err)
+	}
+
+	// Project details
+	appTitle, err := goinp.AskForStringWithDefault("What's the title of your project?", filepath.Base(configs.CurrentDir))
+	if err != nil {
+		log.Fatalf("Failed to ask for input, error: %s", err)
+	}
+	devBranch, err := goinp.AskForStringWithDefault("What's your development branch's name?", "master")
+	if err != nil {
+		log.Fatalf("Failed to ask for input, error: %s", err)
+	}
+
+	// Workflows and trigger map
+	bitriseConfig := bitrise.OnboardingConfig(projectType, appTitle, devBranch)
+	printOnboardingConfig(bitriseConfig)
+
+	if val, err := goinp.AskForBoolWithDefault("Do you want to save this configuration?", true); err != nil {
+		log.Fatalf("Failed to ask for input, error: %s", err)
+	} else if !val {
+		log.Info("Onboarding canceled.")
+		os.Exit(0)
+	}
+
+	if err := bitrise.SaveConfigToFile(bitriseConfigFileRelPath, bitriseConfig); err != nil {
+		log.Fatalf("Failed to save the bitrise config file, error: %s", err)
+	}
+	fmt.Println(output.Green("Config saved to " + bitriseConfigFileRelPath))
+	fmt.Println()
+
+	// Secrets
+	secrets, err := askForSecrets()
+	if err != nil {
+		log.Fatalf("Failed to ask for input, error: %s", err)
+	}
+	if len(secrets) > 0 {
+		if saved, err := saveEncryptedSecrets(bitriseSecretsFileRelPath, secrets); err != nil {
+			log.Fatalf("Failed to save the secrets file, error: %s", err)
+		} else if saved {
+			fmt.Println(output.Green("Secrets saved (encrypted) to " + bitriseSecretsFileRelPath))
+			fmt.Println("The secrets are encrypted with your local key: " + configs.GetBitriseSecretsKeyPath())
+			fmt.Println(output.Yellow("Keep this key safe, the secrets can't be decrypted without it!"))
+		}
+		fmt.Println()
+	}
+
+	if err := addToGitignore(".bitrise*"); err != nil {
+		log.Fatalf("Failed to add .gitignore pattern, error: %s", err)
+	}
+	fmt.Println(output.Green("For your convenience we added the pattern '.bitrise*' to your .gitignore file"))
+	fmt.Println()
+
+	// Smoke workflow
+	smokeSucceeded := false
+	if val, err := goinp.AskForBoolWithDefault("Do you want to run the smoke workflow now?", true); err != nil {
+		log.Fatalf("Failed to ask for input, error: %s", err)
+	} else if val {
+		smokeSucceeded = runOnboardingSmokeWorkflow(bitriseConfigFileRelPath, bitriseSecretsFileRelPath)
+	}
+
+	// Next steps
+	fmt.Println()
+	if smokeSucceeded {
+		fmt.Println(output.Green("Hurray, your setup works!"))
+	}
+	fmt.Println(output.Blue("Next steps:"))
+	fmt.Println("- Review the test and build commands in " + DefaultBitriseConfigFileName)
+	fmt.Printf("- Run the tests: bitrise run %s\n", bitrise.OnboardPrimaryWorkflowID)
+	fmt.Printf("- Build and deploy: bitrise run %s\n", bitrise.OnboardDeployWorkflowID)
+	fmt.Println("- Check which workflow a push would trigger: bitrise trigger-check --push-branch " + devBranch)
+	fmt.Println("- Find more steps: bitrise step-list, and read about one: bitrise explain STEP_ID")
+	fmt.Println("- Validate your config after editing it: bitrise validate")
+
+	return nil
+}
+
+func runOnboardingSmokeWorkflow(bitriseConfigPath, secretsPath string) bool {
+	bitriseConfig, warnings, err := CreateBitriseConfigFromCLIParams("", bitriseConfigPath)
+	for _, warning := range warnings {
+		log.Warnf("warning: %s", warning)
+	}
+	if err != nil {
+		log.Errorf("Failed to read the bitrise config, error: %s", err)
+		return false
+	}
+
+	inventoryEnvironments := []envmanModels.EnvironmentItemModel{}
+	if exist, err := pathutil.IsPathExists(secretsPath); err != nil {
+		log.Errorf("Failed to check path (%s), error: %s", secretsPath, err)
+		return false
+	} else if exist {
+		if inventoryEnvironments, err = CreateInventoryFromCLIParams("", secretsPath); err != nil {
+			log.Errorf("Failed to read the secrets, error: %s", err)
+			return false
+		}
+	}
+
+	buildRunResults, err := runWorkflowWithSetup(bitriseConfig, inventoryEnvironments, bitrise.OnboardSmokeWorkflowID, nil)
+	if err != nil {
+		log.Error(err)
+		return false
+	}
+	if buildRunResults.IsBuildFailed() || buildRunResults.IsAborted {
+		log.Error("The smoke workflow failed, check the logs above")
+		return false
+	}
+	return true
+}
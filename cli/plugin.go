@@ -4,8 +4,8 @@ import (
 	"fmt"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/output"
 	"github.com/bitrise-io/bitrise/plugins"
-	"github.com/bitrise-io/go-utils/colorstring"
 	"github.com/urfave/cli"
 )
 
@@ -18,21 +18,41 @@ func pluginInstall(c *cli.Context) error {
 
 	pluginBinary := c.String("bin-source")
 	pluginVersionTag := c.String("version")
+	isLink := c.Bool("link")
+
+	if isLink {
+		if _, isLocal := plugins.LocalPluginSourceDir(pluginSource); !isLocal {
+			log.Fatalf("Only local plugin sources can be linked, (%s) is not a local directory", pluginSource)
+		}
+		if pluginVersionTag != "" {
+			log.Warnf("Linked plugins are not versioned, ignoring version (%s)", pluginVersionTag)
+		}
+	}
 
 	// Install
-	if pluginVersionTag == "" {
-		log.Infof("=> Installing plugin from (%s) with latest version...", pluginSource)
+	var plugin plugins.Plugin
+	var version string
+	var err error
+
+	if isLink {
+		log.Infof("=> Linking plugin from (%s)...", pluginSource)
+
+		plugin, version, err = plugins.LinkPlugin(pluginSource, pluginBinary)
 	} else {
-		log.Infof("=> Installing plugin (%s) with version (%s)...", pluginSource, pluginVersionTag)
-	}
+		if pluginVersionTag == "" {
+			log.Infof("=> Installing plugin from (%s) with latest version...", pluginSource)
+		} else {
+			log.Infof("=> Installing plugin (%s) with version (%s)...", pluginSource, pluginVersionTag)
+		}
 
-	plugin, version, err := plugins.InstallPlugin(pluginSource, pluginBinary, pluginVersionTag)
+		plugin, version, err = plugins.InstallPlugin(pluginSource, pluginBinary, pluginVersionTag)
+	}
 	if err != nil {
 		log.Fatalf("Failed to install plugin from (%s), error: %s", pluginSource, err)
 	}
 
 	fmt.Println()
-	log.Infoln(colorstring.Greenf("Plugin (%s) with version (%s) installed ", plugin.Name, version))
+	log.Infoln(output.Greenf("Plugin (%s) with version (%s) installed ", plugin.Name, version))
 
 	if len(plugin.Description) > 0 {
 		fmt.Println()
@@ -75,7 +95,7 @@ func pluginDelete(c *cli.Context) error {
 	}
 
 	fmt.Println()
-	log.Infof(colorstring.Greenf("Plugin (%s) with version (%s) deleted", name, version))
+	log.Infof(output.Greenf("Plugin (%s) with version (%s) deleted", name, version))
 
 	return nil
 }
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/plugins"
+	"github.com/bitrise-io/bitrise/tools"
+)
+
+// registerOrgPolicy fetches the organization policy bundle, if configured,
+// and activates it for the run (only the run and the trigger commands use the policy).
+func registerOrgPolicy() error {
+	policyConfig, err := configs.PolicyConfig()
+	if err != nil {
+		return err
+	}
+	if policyConfig.URL == "" {
+		return nil
+	}
+
+	policy, err := bitrise.FetchPolicy(policyConfig)
+	if err != nil {
+		return err
+	}
+
+	if !policy.IsTelemetryEnabled() {
+		for _, name := range policy.TelemetryPlugins() {
+			log.Debugf("Telemetry plugin (%s) disabled by the organization policy", name)
+			plugins.DisableEventPlugin(name)
+		}
+	}
+
+	if len(policy.RedactionRules) > 0 {
+		tools.SetStepOutputRedactor(policy.Redact)
+	}
+
+	bitrise.SetActivePolicy(policy)
+	log.Debugf("Organization policy loaded from: %s", policyConfig.URL)
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/exitcode"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+const (
+	cpuProfileFileName = "cpu.pprof"
+	memProfileFileName = "heap.pprof"
+	traceFileName      = "trace.out"
+)
+
+// stopProfiling finishes and writes the enabled profiles,
+// has to be called before the CLI exits (it's called by the exit handler on a fatal error, see: exitcode.RegisterExitHandler)
+var stopProfiling = func() {}
+
+func startProfiling(isCPUProfile, isMemProfile, isTrace bool) error {
+	if !isCPUProfile && !isMemProfile && !isTrace {
+		return nil
+	}
+
+	if err := pathutil.EnsureDirExist(configs.BitriseDebugDirPath); err != nil {
+		return fmt.Errorf("Failed to create debug dir (%s), error: %s", configs.BitriseDebugDirPath, err)
+	}
+
+	stopFuncs := []func(){}
+	stop := func() {
+		for _, stopFunc := range stopFuncs {
+			stopFunc()
+		}
+		log.Infof("Profiles saved to: %s", configs.BitriseDebugDirPath)
+	}
+
+	if isCPUProfile {
+		pth := filepath.Join(configs.BitriseDebugDirPath, cpuProfileFileName)
+		file, err := os.Create(pth)
+		if err != nil {
+			return fmt.Errorf("Failed to create CPU profile file, error: %s", err)
+		}
+		if err := pprof.StartCPUProfile(file); err != nil {
+			return fmt.Errorf("Failed to start CPU profile, error: %s", err)
+		}
+		stopFuncs = append(stopFuncs, func() {
+			pprof.StopCPUProfile()
+			if err := file.Close(); err != nil {
+				log.Warnf("Failed to close CPU profile file, error: %s", err)
+			}
+		})
+	}
+
+	if isTrace {
+		pth := filepath.Join(configs.BitriseDebugDirPath, traceFileName)
+		file, err := os.Create(pth)
+		if err != nil {
+			return fmt.Errorf("Failed to create trace file, error: %s", err)
+		}
+		if err := trace.Start(file); err != nil {
+			return fmt.Errorf("Failed to start trace, error: %s", err)
+		}
+		stopFuncs = append(stopFuncs, func() {
+			trace.Stop()
+			if err := file.Close(); err != nil {
+				log.Warnf("Failed to close trace file, error: %s", err)
+			}
+		})
+	}
+
+	if isMemProfile {
+		// heap profile is a snapshot, written at exit
+		stopFuncs = append(stopFuncs, func() {
+			pth := filepath.Join(configs.BitriseDebugDirPath, memProfileFileName)
+			file, err := os.Create(pth)
+			if err != nil {
+				log.Warnf("Failed to create heap profile file, error: %s", err)
+				return
+			}
+			defer func() {
+				if err := file.Close(); err != nil {
+					log.Warnf("Failed to close heap profile file, error: %s", err)
+				}
+			}()
+
+			runtime.GC()
+			if err := pprof.WriteHeapProfile(file); err != nil {
+				log.Warnf("Failed to write heap profile, error: %s", err)
+			}
+		})
+	}
+
+	isStopped := false
+	stopProfiling = func() {
+		if isStopped {
+			return
+		}
+		isStopped = true
+		stop()
+	}
+	exitcode.RegisterExitHandler(stopProfiling)
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+)
+
+// stepOutputCapture captures the console output of the running step, in quiet mode (see: --quiet)
+var stepOutputCapture *bitrise.OutputCaptureModel
+
+// registerQuietMode enables the quiet mode, and hides the info messages of the CLI (unless a more verbose log level is set)
+func registerQuietMode(isQuiet bool) error {
+	configs.IsQuietMode = isQuiet
+	if !isQuiet {
+		return nil
+	}
+
+	if log.GetLevel() == log.InfoLevel {
+		log.SetLevel(log.WarnLevel)
+		if err := os.Setenv(configs.LogLevelEnvKey, log.WarnLevel.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startStepOutputCapture starts capturing the console output of the next step, in quiet mode
+func startStepOutputCapture() {
+	if !configs.IsQuietMode {
+		return
+	}
+
+	if stepOutputCapture != nil {
+		// the previous step's result was not registered, keep its output
+		if _, err := os.Stdout.Write(stepOutputCapture.Stop()); err != nil {
+			log.Warnf("Failed to print step output, error: %s", err)
+		}
+		stepOutputCapture = nil
+	}
+
+	capture, err := bitrise.StartOutputCapture()
+	if err != nil {
+		log.Warnf("Failed to capture step output, the output is printed, error: %s", err)
+		return
+	}
+	stepOutputCapture = capture
+}
+
+// finishStepOutputCapture prints the captured output of the step, if it failed (even if skippable),
+// the output of the other steps is written into the run log only (see: --log-dir).
+func finishStepOutputCapture(resultCode int) {
+	if stepOutputCapture == nil {
+		return
+	}
+
+	stepOutput := stepOutputCapture.Stop()
+	stepOutputCapture = nil
+
+	switch resultCode {
+	case models.StepRunStatusCodeFailed, models.StepRunStatusCodeFailedSkippable:
+		if _, err := os.Stdout.Write(stepOutput); err != nil {
+			log.Warnf("Failed to print step output, error: %s", err)
+		}
+	default:
+		if runLog != nil {
+			if _, err := runLog.Write(stepOutput); err != nil {
+				log.Warnf("Failed to write step output into the run log, error: %s", err)
+			}
+		}
+	}
+}
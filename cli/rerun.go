@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+)
+
+// workflowRunContext is the state of a workflow run (incl. its before_run and after_run workflows),
+// shared by the steps of the run: the state of the failed steps and of the rerun (see: --rerun-failed)
+type workflowRunContext struct {
+	// rerunLastRun is the last run of the workflow, if only its failed steps are run, nil otherwise
+	rerunLastRun *bitrise.LastRunModel
+	// rerunSteps are the indexes of the steps to rerun (see: bitrise.LastRunModel.RerunSteps)
+	rerunSteps map[int]bool
+	// isRerunRefused is true, once a step of the run doesn't match the step of the last run
+	isRerunRefused bool
+	// isRerunEnvironmentsRestored is true, if the envs of the last run are restored (before the first rerun step)
+	isRerunEnvironmentsRestored bool
+
+	// secretEnvironments are the secrets of the run, they are not stored with the envs of the last run
+	secretEnvironments []envmanModels.EnvironmentItemModel
+	// stepStartEnvironments are the envs of the run, at the start of the current step
+	stepStartEnvironments []envmanModels.EnvironmentItemModel
+	// firstFailedStepEnvironments are the envs from before the first failed step of the run, nil if no step failed
+	firstFailedStepEnvironments []envmanModels.EnvironmentItemModel
+	// steps are the steps of the run, stored with the last run
+	steps []bitrise.LastRunStepModel
+}
+
+// newWorkflowRunContext returns the context of a run, rerunLastRun is the last run of the workflow,
+// if only its failed steps have to be run (see: readRerunLastRun), nil otherwise
+func newWorkflowRunContext(rerunLastRun *bitrise.LastRunModel, secretEnvironments []envmanModels.EnvironmentItemModel) *workflowRunContext {
+	runContext := &workflowRunContext{
+		rerunLastRun:       rerunLastRun,
+		secretEnvironments: secretEnvironments,
+	}
+	if rerunLastRun != nil {
+		runContext.rerunSteps = rerunLastRun.RerunSteps()
+	}
+	return runContext
+}
+
+// readRerunLastRun returns the last run of the workflow, if only its failed steps have to be run, nil otherwise
+func readRerunLastRun(isRerunFailed bool, workflowID string) (*bitrise.LastRunModel, error) {
+	if !isRerunFailed {
+		return nil, nil
+	}
+
+	lastRun, err := bitrise.ReadLastRun(configs.GetBitriseLastRunDirPath(), bitrise.LastRunKey(configs.CurrentDir, workflowID))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the last run of the workflow (%s), error: %s", workflowID, err)
+	}
+	if !lastRun.HasFailedSteps() {
+		return nil, fmt.Errorf("No step failed in the last run of the workflow (%s)", workflowID)
+	}
+	return &lastRun, nil
+}
+
+// isStepRerun returns false if the step has to be skipped, as it's not a failed step (or a dependency of one) of the last run,
+// and restores the envs of the last run before the first rerun step.
+// Returns an error if the step doesn't match the step of the last run (by workflow, index and step ID),
+// the rerun is refused then, the later steps are skipped with bitrise.ErrRerunRefused.
+func (runContext *workflowRunContext) isStepRerun(workflowID string, stepIdx int, stepID string, environments *[]envmanModels.EnvironmentItemModel) (bool, error) {
+	if runContext.rerunLastRun == nil {
+		return true, nil
+	}
+	if runContext.isRerunRefused {
+		return false, bitrise.ErrRerunRefused
+	}
+
+	lastRunStep, found := runContext.rerunLastRun.Step(stepIdx)
+	if !found || lastRunStep.Workflow != workflowID || lastRunStep.ID != stepID {
+		runContext.isRerunRefused = true
+		if !found {
+			return false, fmt.Errorf("The step (%s) of the workflow (%s) was not run in the last run, the workflow has changed since, run it without --%s",
+				stepID, workflowID, RerunFailedKey)
+		}
+		return false, fmt.Errorf("The step (%s) of the workflow (%s) is not the step (%s) of the workflow (%s) of the last run, the workflow has changed since, run it without --%s",
+			stepID, workflowID, lastRunStep.ID, lastRunStep.Workflow, RerunFailedKey)
+	}
+
+	if !runContext.rerunSteps[stepIdx] {
+		return false, nil
+	}
+
+	if !runContext.isRerunEnvironmentsRestored {
+		restoredEnvironments := append([]envmanModels.EnvironmentItemModel{}, runContext.secretEnvironments...)
+		*environments = append(restoredEnvironments, runContext.rerunLastRun.Environments...)
+		runContext.isRerunEnvironmentsRestored = true
+	}
+	return true, nil
+}
+
+// registerStepStart stores the envs from the start of the step
+func (runContext *workflowRunContext) registerStepStart(environments []envmanModels.EnvironmentItemModel) {
+	runContext.stepStartEnvironments = append([]envmanModels.EnvironmentItemModel{}, environments...)
+}
+
+// registerStepResult stores the step, and the envs from before the step, if this is the first failed step of the run
+func (runContext *workflowRunContext) registerStepResult(step bitrise.LastRunStepModel) {
+	runContext.steps = append(runContext.steps, step)
+	if step.StatusCode == models.StepRunStatusCodeFailed && runContext.firstFailedStepEnvironments == nil {
+		runContext.firstFailedStepEnvironments = runContext.stepStartEnvironments
+	}
+}
+
+// writeLastRun stores the result of the run, as the last run of the workflow (see: --rerun-failed).
+// The run of a refused rerun is not stored, the last run is kept.
+func (runContext *workflowRunContext) writeLastRun(workflowID string, summary bitrise.BuildSummaryModel) {
+	if runContext.isRerunRefused {
+		return
+	}
+
+	lastRun := bitrise.LastRunModel{
+		Summary:      summary,
+		Steps:        runContext.steps,
+		Environments: bitrise.RemoveSecretEnvironments(runContext.firstFailedStepEnvironments, runContext.secretEnvironments),
+	}
+
+	if err := bitrise.WriteLastRun(configs.GetBitriseLastRunDirPath(), bitrise.LastRunKey(configs.CurrentDir, workflowID), lastRun); err != nil {
+		log.Warnf("Failed to store the last run, error: %s", err)
+	}
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsStepRerun(t *testing.T) {
+	lastRun := &bitrise.LastRunModel{
+		Steps: []bitrise.LastRunStepModel{
+			bitrise.LastRunStepModel{Workflow: "primary", Idx: 0, ID: "script@1", StatusCode: models.StepRunStatusCodeSuccess},
+			bitrise.LastRunStepModel{Workflow: "primary", Idx: 1, ID: "xcode-test@2", StatusCode: models.StepRunStatusCodeFailed},
+		},
+		Environments: []envmanModels.EnvironmentItemModel{
+			envmanModels.EnvironmentItemModel{"PROJECT_PATH": "./ios"},
+		},
+	}
+	secrets := []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"API_TOKEN": "secret"},
+	}
+
+	t.Log("not a rerun")
+	{
+		runContext := newWorkflowRunContext(nil, secrets)
+		isRerun, err := runContext.isStepRerun("primary", 0, "script@1", &[]envmanModels.EnvironmentItemModel{})
+		require.NoError(t, err)
+		require.True(t, isRerun)
+	}
+
+	t.Log("only the failed step is rerun, with the envs of the last run")
+	{
+		runContext := newWorkflowRunContext(lastRun, secrets)
+		environments := []envmanModels.EnvironmentItemModel{
+			envmanModels.EnvironmentItemModel{"API_TOKEN": "secret"},
+		}
+
+		isRerun, err := runContext.isStepRerun("primary", 0, "script@1", &environments)
+		require.NoError(t, err)
+		require.False(t, isRerun)
+
+		isRerun, err = runContext.isStepRerun("primary", 1, "xcode-test@2", &environments)
+		require.NoError(t, err)
+		require.True(t, isRerun)
+		require.Equal(t, append(append([]envmanModels.EnvironmentItemModel{}, secrets...), lastRun.Environments...), environments)
+	}
+
+	t.Log("the workflow has changed since the last run")
+	{
+		runContext := newWorkflowRunContext(lastRun, secrets)
+
+		isRerun, err := runContext.isStepRerun("primary", 0, "git-clone@4", &[]envmanModels.EnvironmentItemModel{})
+		require.Error(t, err)
+		require.False(t, isRerun)
+
+		// the later steps are skipped
+		isRerun, err = runContext.isStepRerun("primary", 1, "xcode-test@2", &[]envmanModels.EnvironmentItemModel{})
+		require.Equal(t, bitrise.ErrRerunRefused, err)
+		require.False(t, isRerun)
+	}
+
+	t.Log("the step was not run in the last run")
+	{
+		runContext := newWorkflowRunContext(lastRun, secrets)
+
+		_, err := runContext.isStepRerun("deploy", 2, "deploy-to-bitrise-io@2", &[]envmanModels.EnvironmentItemModel{})
+		require.Error(t, err)
+	}
+}
@@ -10,10 +10,11 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/bitrise-io/bitrise/bitrise"
 	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/exitcode"
 	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/output"
 	"github.com/bitrise-io/bitrise/version"
 	envmanModels "github.com/bitrise-io/envman/models"
-	"github.com/bitrise-io/go-utils/colorstring"
 	"github.com/bitrise-io/go-utils/pointers"
 	"github.com/urfave/cli"
 )
@@ -21,6 +22,8 @@ import (
 const (
 	// DefaultBitriseConfigFileName ...
 	DefaultBitriseConfigFileName = "bitrise.yml"
+	// DefaultBitriseJSONConfigFileName is used if there is no DefaultBitriseConfigFileName in the current dir
+	DefaultBitriseJSONConfigFileName = "bitrise.json"
 	// DefaultSecretsFileName ...
 	DefaultSecretsFileName = ".bitrise.secrets.yml"
 
@@ -65,7 +68,11 @@ func printAvailableWorkflows(config models.BitriseDataModel) {
 	if len(workflowNames) > 0 {
 		log.Infoln("The following workflows are available:")
 		for _, wfName := range workflowNames {
-			log.Infoln(" * " + wfName)
+			if aliasOf := config.Workflows[wfName].AliasOf; aliasOf != "" {
+				log.Infoln(" * " + wfName + " (alias of " + aliasOf + ")")
+			} else {
+				log.Infoln(" * " + wfName)
+			}
 		}
 
 		fmt.Println()
@@ -88,27 +95,68 @@ func printAvailableWorkflows(config models.BitriseDataModel) {
 	}
 }
 
-func runAndExit(bitriseConfig models.BitriseDataModel, inventoryEnvironments []envmanModels.EnvironmentItemModel, workflowToRunID string) {
-	if workflowToRunID == "" {
-		log.Fatal("No workflow id specified")
+// resolveWorkflowAlias returns the workflow to run, if the given workflow is an alias of another workflow
+func resolveWorkflowAlias(config models.BitriseDataModel, workflowID string) (string, error) {
+	resolvedWorkflowID, err := config.ResolveWorkflowAlias(workflowID)
+	if err != nil {
+		return "", err
 	}
+	if resolvedWorkflowID != workflowID {
+		log.Infof("workflow (%s) is an alias of workflow (%s)", workflowID, resolvedWorkflowID)
+	}
+	return resolvedWorkflowID, nil
+}
 
+// runWorkflowWithSetup performs the setup (if it was not done for this version yet),
+// installs the tools pinned in the config, and runs the workflow
+// (only the failed steps of rerunLastRun, if it's not nil, see: --rerun-failed).
+func runWorkflowWithSetup(bitriseConfig models.BitriseDataModel, inventoryEnvironments []envmanModels.EnvironmentItemModel, workflowToRunID string, rerunLastRun *bitrise.LastRunModel) (models.BuildRunResultsModel, error) {
 	if !configs.CheckIsSetupWasDoneForVersion(version.VERSION) {
-		log.Warnln(colorstring.Yellow("Setup was not performed for this version of bitrise, doing it now..."))
-		if err := bitrise.RunSetup(version.VERSION, false); err != nil {
-			log.Fatalf("Setup failed, error: %s", err)
+		log.Warnln(output.Yellow("Setup was not performed for this version of bitrise, doing it now..."))
+		if err := bitrise.RunSetup(version.VERSION, bitrise.SetupModeDefault); err != nil {
+			return models.BuildRunResultsModel{}, fmt.Errorf("Setup failed, error: %w", err)
 		}
 	}
 
+	if err := bitrise.SetupPinnedTools(bitriseConfig.Tools); err != nil {
+		return models.BuildRunResultsModel{}, fmt.Errorf("Failed to setup the tool versions pinned in the config, error: %w", err)
+	}
+
 	startTime := time.Now()
 
 	// Run selected configuration
-	if buildRunResults, err := runWorkflowWithConfiguration(startTime, workflowToRunID, bitriseConfig, inventoryEnvironments); err != nil {
-		log.Fatalf("Failed to run workflow, error: %s", err)
-	} else if buildRunResults.IsBuildFailed() {
-		os.Exit(1)
+	buildRunResults, err := runWorkflowWithConfigurationAndRerun(startTime, workflowToRunID, bitriseConfig, inventoryEnvironments, rerunLastRun)
+	if err != nil {
+		return buildRunResults, fmt.Errorf("Failed to run workflow, error: %w", err)
 	}
-	os.Exit(0)
+	return buildRunResults, nil
+}
+
+func runAndExit(bitriseConfig models.BitriseDataModel, inventoryEnvironments []envmanModels.EnvironmentItemModel, workflowToRunID string, rerunLastRun *bitrise.LastRunModel) {
+	if workflowToRunID == "" {
+		exitcode.Fatal(exitcode.Usage, "No workflow id specified")
+	}
+
+	if err := startRunLog(); err != nil {
+		log.Fatalf("Failed to start run log, error: %s", err)
+	}
+
+	if err := startLogStream(); err != nil {
+		log.Fatalf("Failed to start log stream, error: %s", err)
+	}
+
+	startBuildTimeout()
+	buildRunResults, err := runWorkflowWithSetup(bitriseConfig, inventoryEnvironments, workflowToRunID, rerunLastRun)
+	if err != nil {
+		exitcode.Fatal(exitcode.Code(err), err)
+	}
+	stopBuildTimeout()
+	exitCode := bitrise.BuildExitCode(buildRunResults)
+
+	stopLogStream()
+	stopRunLog()
+	stopProfiling()
+	os.Exit(exitCode)
 }
 
 // --------------------
@@ -116,7 +164,9 @@ func runAndExit(bitriseConfig models.BitriseDataModel, inventoryEnvironments []e
 // --------------------
 
 func run(c *cli.Context) error {
-	PrintBitriseHeaderASCIIArt(version.VERSION)
+	if !c.Bool(QuietKey) {
+		PrintBitriseHeaderASCIIArt(version.VERSION)
+	}
 
 	//
 	// Expand cli.Context
@@ -162,31 +212,62 @@ func run(c *cli.Context) error {
 	// Inventory validation
 	inventoryEnvironments, err := CreateInventoryFromCLIParams(runParams.InventoryBase64Data, runParams.InventoryPath)
 	if err != nil {
-		log.Fatalf("Failed to create inventory, error: %s", err)
+		exitcode.Fatalf(exitcode.ConfigInvalid, "Failed to create inventory, error: %s", err)
 	}
 
+	workflowIDs := parseWorkflowsToRun(c.String(WorkflowsKey), c.Args())
+	isMultiWorkflowRun := c.String(WorkflowsKey) != "" || len(c.Args()) > 1
+
 	// Config validation
-	bitriseConfig, warnings, err := CreateBitriseConfigFromCLIParams(runParams.BitriseConfigBase64Data, runParams.BitriseConfigPath)
+	// only the workflows of the run are resolved, not every workflow of the config
+	workflowIDsToResolve := workflowIDs
+	if !isMultiWorkflowRun {
+		workflowIDsToResolve = nil
+		if runParams.WorkflowToRunID != "" {
+			workflowIDsToResolve = []string{runParams.WorkflowToRunID}
+		}
+	}
+
+	configs.IsStrictConfigMode = c.Bool(StrictKey)
+	bitriseConfig, warnings, err := createBitriseConfigForWorkflowsFromCLIParams(runParams.BitriseConfigBase64Data, runParams.BitriseConfigPath, workflowIDsToResolve)
 	for _, warning := range warnings {
 		log.Warnf("warning: %s", warning)
 	}
 	if err != nil {
-		log.Fatalf("Failed to create bitrise config, error: %s", err)
+		exitcode.Fatalf(exitcode.ConfigInvalid, "Failed to create bitrise config, error: %s", err)
+	}
+
+	if isMultiWorkflowRun {
+		if err := validateWorkflowsToRun(bitriseConfig, workflowIDs); err != nil {
+			log.Error(err)
+			printAvailableWorkflows(bitriseConfig)
+			os.Exit(exitcode.Usage)
+		}
+		for idx, workflowID := range workflowIDs {
+			if workflowIDs[idx], err = resolveWorkflowAlias(bitriseConfig, workflowID); err != nil {
+				exitcode.Fatal(exitcode.Usage, err)
+			}
+		}
+		if len(workflowIDs) == 0 {
+			log.Error("No workfow specified!")
+			printAvailableWorkflows(bitriseConfig)
+			os.Exit(exitcode.Usage)
+		}
 	}
 
 	// Workflow id validation
-	if runParams.WorkflowToRunID == "" {
+	if !isMultiWorkflowRun && runParams.WorkflowToRunID == "" {
 		// no workflow specified
 		//  list all the available ones and then exit
 		log.Error("No workfow specified!")
 		printAvailableWorkflows(bitriseConfig)
-		os.Exit(1)
+		os.Exit(exitcode.Usage)
 	}
-	if strings.HasPrefix(runParams.WorkflowToRunID, "_") {
+	if !isMultiWorkflowRun && strings.HasPrefix(runParams.WorkflowToRunID, "_") {
 		// util workflow specified
 		//  print about util workflows and then exit
 		printAboutUtilityWorkflows()
-		os.Exit(1)
+		os.Exit(exitcode.Usage)
 	}
 	//
 
@@ -210,9 +291,96 @@ func run(c *cli.Context) error {
 		log.Fatalf("Failed to register  CI mode, error: %s", err)
 	}
 
-	log.Infoln(colorstring.Green("Running workflow:"), runParams.WorkflowToRunID)
+	configs.BuildSummaryPath = c.String(SummaryPathKey)
+	configs.HTMLReportPath = c.String(ReportHTMLKey)
+	configs.IsStepProfileMode = c.Bool(ProfileKey)
+	configs.StepProfilePath = c.String(ProfilePathKey)
+	configs.LogStreamAddress = c.String(LogStreamKey)
+	if err := registerQuietMode(c.Bool(QuietKey)); err != nil {
+		log.Fatalf("Failed to register quiet mode, error: %s", err)
+	}
+	configs.RunLogDir = c.String(LogDirKey)
+	configs.RunLogMaxSizeMB = c.Int(LogMaxSizeKey)
+	configs.RunLogRetention = c.Int(LogRetentionKey)
+	configs.IsNoDeprecatedMode = c.Bool(NoDeprecatedKey)
+	if err := registerOrgPolicy(); err != nil {
+		exitcode.Fatalf(exitcode.ConfigInvalid, "Failed to load organization policy, error: %s", err)
+	}
+	if err := registerStepAuditMode(c.String(StepAuditKey)); err != nil {
+		exitcode.Fatalf(exitcode.Usage, "Failed to register step audit mode, error: %s", err)
+	}
+	if err := registerDependencyManager(c.String(DependencyManagerKey)); err != nil {
+		exitcode.Fatalf(exitcode.Usage, "Failed to register dependency manager, error: %s", err)
+	}
+	if err := registerArtifactUpload(c.String(ArtifactUploadKey), c.Int(ArtifactRetentionDaysKey)); err != nil {
+		exitcode.Fatalf(exitcode.Usage, "Failed to register artifact upload, error: %s", err)
+	}
+	if err := registerBuildTimeout(c.Int(TimeoutKey), c.Int(TimeoutGraceKey)); err != nil {
+		exitcode.Fatalf(exitcode.Usage, "Failed to register build timeout, error: %s", err)
+	}
+
+	if err := registerLockedMode(c.Bool(LockedKey), c.Bool(UpdateLockKey), runParams.BitriseConfigBase64Data, runParams.BitriseConfigPath); err != nil {
+		exitcode.Fatalf(exitcode.Usage, "Failed to register locked mode, error: %s", err)
+	}
+
+	if err := registerCLIEnvironments(c.StringSlice(EnvKey), c.StringSlice(EnvFileKey)); err != nil {
+		exitcode.Fatalf(exitcode.Usage, "Failed to parse envs, error: %s", err)
+	}
+
+	inputValues, err := parseWorkflowInputValues(c.StringSlice(InputKey))
+	if err != nil {
+		exitcode.Fatalf(exitcode.Usage, "Failed to parse workflow inputs, error: %s", err)
+	}
+
+	if isMultiWorkflowRun {
+		if c.Bool(RerunFailedKey) {
+			exitcode.Fatalf(exitcode.Usage, "The --%s flag can not be used, if multiple workflows are run", RerunFailedKey)
+		}
+
+		inputValuesByWorkflow, err := splitWorkflowInputValues(bitriseConfig, workflowIDs, inputValues)
+		if err != nil {
+			exitcode.Fatalf(exitcode.ConfigInvalid, "Invalid workflow inputs, error: %s", err)
+		}
+
+		provenance, err := runConfigProvenance(runParams.BitriseConfigBase64Data, runParams.BitriseConfigPath,
+			c.StringSlice(EnvKey), c.StringSlice(EnvFileKey), inputValuesByWorkflow)
+		if err != nil {
+			exitcode.Fatalf(exitcode.Usage, "Failed to record config provenance, error: %s", err)
+		}
+		runProvenance = &provenance
+
+		log.Infoln(output.Green("Running workflows:"), strings.Join(workflowIDs, ", "))
+
+		runMultipleAndExit(bitriseConfig, inventoryEnvironments, workflowIDs, inputValuesByWorkflow, c.Bool(ShareEnvsKey))
+		return nil
+	}
+
+	workflowToRunID, err = resolveWorkflowAlias(bitriseConfig, runParams.WorkflowToRunID)
+	if err != nil {
+		log.Error(err)
+		printAvailableWorkflows(bitriseConfig)
+		os.Exit(exitcode.Usage)
+	}
+
+	if err := registerWorkflowInputs(bitriseConfig, workflowToRunID, inputValues); err != nil {
+		exitcode.Fatalf(exitcode.ConfigInvalid, "Invalid workflow inputs, error: %s", err)
+	}
+
+	provenance, err := runConfigProvenance(runParams.BitriseConfigBase64Data, runParams.BitriseConfigPath,
+		c.StringSlice(EnvKey), c.StringSlice(EnvFileKey), map[string]map[string]string{workflowToRunID: inputValues})
+	if err != nil {
+		exitcode.Fatalf(exitcode.Usage, "Failed to record config provenance, error: %s", err)
+	}
+	runProvenance = &provenance
+
+	rerunLastRun, err := readRerunLastRun(c.Bool(RerunFailedKey), workflowToRunID)
+	if err != nil {
+		exitcode.Fatal(exitcode.Usage, err)
+	}
+
+	log.Infoln(output.Green("Running workflow:"), workflowToRunID)
 
-	runAndExit(bitriseConfig, inventoryEnvironments, runParams.WorkflowToRunID)
+	runAndExit(bitriseConfig, inventoryEnvironments, workflowToRunID, rerunLastRun)
 	//
 
 	return nil
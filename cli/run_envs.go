@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+)
+
+// cliEnvironments are the envs of the --env-file and --env flags,
+// added to the envs of the run after the secrets and the app envs
+var cliEnvironments = []envmanModels.EnvironmentItemModel{}
+
+// parseCLIEnvironments returns the envs of the --env-file flags (in flag order), followed by the envs of the --env KEY=VALUE flags.
+// The values are not expanded, the shell already did it.
+func parseCLIEnvironments(envs, envFilePaths []string) ([]envmanModels.EnvironmentItemModel, error) {
+	envFiles := []models.EnvFileModel{}
+	for _, pth := range envFilePaths {
+		envFiles = append(envFiles, models.EnvFileModel{Path: pth})
+	}
+
+	// relative env file paths are relative to the working directory
+	environments, _, err := bitrise.LoadEnvFiles(envFiles, "")
+	if err != nil {
+		return []envmanModels.EnvironmentItemModel{}, err
+	}
+
+	isExpand := false
+	for _, env := range envs {
+		split := strings.SplitN(env, "=", 2)
+		if len(split) != 2 || split[0] == "" {
+			return []envmanModels.EnvironmentItemModel{}, fmt.Errorf("invalid env (%s), should be in KEY=VALUE format", env)
+		}
+		environments = append(environments, envmanModels.EnvironmentItemModel{
+			split[0]:                split[1],
+			envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{IsExpand: &isExpand},
+		})
+	}
+	return environments, nil
+}
+
+func registerCLIEnvironments(envs, envFilePaths []string) error {
+	environments, err := parseCLIEnvironments(envs, envFilePaths)
+	if err != nil {
+		return err
+	}
+	cliEnvironments = environments
+	return nil
+}
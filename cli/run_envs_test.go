@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCLIEnvironments(t *testing.T) {
+	t.Log("envs")
+	{
+		environments, err := parseCLIEnvironments([]string{"STAGE=staging", "URL=http://host?a=b", "EMPTY="}, []string{})
+		require.NoError(t, err)
+		require.Equal(t, 3, len(environments))
+
+		key, value, err := environments[1].GetKeyValuePair()
+		require.NoError(t, err)
+		require.Equal(t, "URL", key)
+		require.Equal(t, "http://host?a=b", value)
+
+		options, err := environments[1].GetOptions()
+		require.NoError(t, err)
+		require.Equal(t, false, *options.IsExpand)
+
+		key, value, err = environments[2].GetKeyValuePair()
+		require.NoError(t, err)
+		require.Equal(t, "EMPTY", key)
+		require.Equal(t, "", value)
+	}
+
+	t.Log("env files, then envs")
+	{
+		tmpDir, err := pathutil.NormalizedOSTempDirPath("cli_envs")
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, os.RemoveAll(tmpDir))
+		}()
+
+		envFilePth := filepath.Join(tmpDir, ".env")
+		require.NoError(t, fileutil.WriteStringToFile(envFilePth, "STAGE=from-file\nTOKEN=abc\n"))
+
+		environments, err := parseCLIEnvironments([]string{"STAGE=from-flag"}, []string{envFilePth})
+		require.NoError(t, err)
+
+		keys := []string{}
+		for _, env := range environments {
+			key, _, err := env.GetKeyValuePair()
+			require.NoError(t, err)
+			keys = append(keys, key)
+		}
+		require.Equal(t, []string{"STAGE", "TOKEN", "STAGE"}, keys)
+		require.Equal(t, envmanModels.EnvironmentItemModel{"STAGE": "from-flag", envmanModels.OptionsKey: environments[2][envmanModels.OptionsKey]}, environments[2])
+	}
+
+	t.Log("invalid env")
+	{
+		_, err := parseCLIEnvironments([]string{"STAGE"}, []string{})
+		require.EqualError(t, err, "invalid env (STAGE), should be in KEY=VALUE format")
+
+		_, err = parseCLIEnvironments([]string{"=value"}, []string{})
+		require.Error(t, err)
+	}
+
+	t.Log("missing env file")
+	{
+		_, err := parseCLIEnvironments([]string{}, []string{"/not/existing/.env"})
+		require.Error(t, err)
+	}
+}
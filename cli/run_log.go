@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+)
+
+// runLog writes the output of the run into the run's log file, if requested (see: --log-dir)
+var runLog *bitrise.RunLogModel
+
+// startRunLog starts writing the output of the run into a new directory of configs.RunLogDir, if set
+func startRunLog() error {
+	if configs.RunLogDir == "" {
+		return nil
+	}
+
+	maxSize := int64(configs.RunLogMaxSizeMB) * 1024 * 1024
+	runLogModel, err := bitrise.StartRunLog(configs.RunLogDir, maxSize, configs.RunLogRetention, time.Now())
+	if err != nil {
+		return err
+	}
+
+	runLog = runLogModel
+	log.Infof("Run log: %s", runLog.Dir)
+	return nil
+}
+
+// stopRunLog finishes the run's log file, has to be called before the CLI exits
+func stopRunLog() {
+	if runLog == nil {
+		return
+	}
+
+	if err := runLog.Close(); err != nil {
+		log.Warnf("Failed to finish run log, error: %s", err)
+	}
+	runLog = nil
+}
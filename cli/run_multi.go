@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/output"
+	envmanModels "github.com/bitrise-io/envman/models"
+)
+
+// parseWorkflowsToRun returns the workflows of a multi-workflow run:
+// the comma separated --workflows list, or the command's args
+func parseWorkflowsToRun(workflowsFlag string, args []string) []string {
+	workflowIDs := []string{}
+	if workflowsFlag != "" {
+		for _, workflowID := range strings.Split(workflowsFlag, ",") {
+			if workflowID = strings.TrimSpace(workflowID); workflowID != "" {
+				workflowIDs = append(workflowIDs, workflowID)
+			}
+		}
+		return workflowIDs
+	}
+	return append(workflowIDs, args...)
+}
+
+// validateWorkflowsToRun checks whether the workflows exist and none of them is a utility workflow
+func validateWorkflowsToRun(bitriseConfig models.BitriseDataModel, workflowIDs []string) error {
+	for _, workflowID := range workflowIDs {
+		if strings.HasPrefix(workflowID, "_") {
+			return fmt.Errorf("utility workflow (%s) can't be triggered directly", workflowID)
+		}
+		if _, found := bitriseConfig.Workflows[workflowID]; !found {
+			return fmt.Errorf("workflow (%s) does not exist", workflowID)
+		}
+	}
+	return nil
+}
+
+// workflowScopedPath inserts the workflow ID before the extension of the path (summary.json -> summary.primary.json),
+// so that the workflows of a multi-workflow run don't overwrite each other's reports
+func workflowScopedPath(pth, workflowID string) string {
+	if pth == "" {
+		return ""
+	}
+	ext := filepath.Ext(pth)
+	return strings.TrimSuffix(pth, ext) + "." + workflowID + ext
+}
+
+// splitWorkflowInputValues returns the input values of each workflow:
+// a workflow gets the values of the inputs, declared by it (or by its before_run and after_run workflows).
+// Every value has to be declared by at least one of the workflows.
+func splitWorkflowInputValues(bitriseConfig models.BitriseDataModel, workflowIDs []string, values map[string]string) (map[string]map[string]string, error) {
+	valuesByWorkflow := map[string]map[string]string{}
+	isDeclared := map[string]bool{}
+	for _, workflowID := range workflowIDs {
+		workflowValues := map[string]string{}
+		for _, key := range models.DeclaredWorkflowInputKeys(bitriseConfig, workflowID) {
+			if value, found := values[key]; found {
+				workflowValues[key] = value
+				isDeclared[key] = true
+			}
+		}
+		valuesByWorkflow[workflowID] = workflowValues
+	}
+
+	for key := range values {
+		if !isDeclared[key] {
+			return map[string]map[string]string{}, fmt.Errorf("input (%s) is not declared by any of the workflows (%s)", key, strings.Join(workflowIDs, ", "))
+		}
+	}
+	return valuesByWorkflow, nil
+}
+
+// runMultipleWorkflows runs the workflows sequentially, each of them as a separate build.
+// With shared envs the envs at the end of a workflow are available for the next one,
+// otherwise every workflow starts with the app envs and the secrets only.
+// The workflows are run even if a previous one failed, only an abort (or the build timeout) stops the run.
+func runMultipleWorkflows(bitriseConfig models.BitriseDataModel, inventoryEnvironments []envmanModels.EnvironmentItemModel,
+	workflowIDs []string, inputValues map[string]map[string]string, isShareEnvs bool) []bitrise.WorkflowRunResultModel {
+
+	buildSummaryPath := configs.BuildSummaryPath
+	htmlReportPath := configs.HTMLReportPath
+	stepProfilePath := configs.StepProfilePath
+	defer func() {
+		configs.BuildSummaryPath = buildSummaryPath
+		configs.HTMLReportPath = htmlReportPath
+		configs.StepProfilePath = stepProfilePath
+		carriedOverEnvironments = []envmanModels.EnvironmentItemModel{}
+	}()
+
+	results := []bitrise.WorkflowRunResultModel{}
+	for idx, workflowID := range workflowIDs {
+		log.Infoln(output.Greenf("Running workflow (%d/%d):", idx+1, len(workflowIDs)), workflowID)
+
+		configs.BuildSummaryPath = workflowScopedPath(buildSummaryPath, workflowID)
+		configs.HTMLReportPath = workflowScopedPath(htmlReportPath, workflowID)
+		configs.StepProfilePath = workflowScopedPath(stepProfilePath, workflowID)
+
+		result := bitrise.WorkflowRunResultModel{WorkflowID: workflowID}
+		startTime := time.Now()
+
+		if err := registerWorkflowInputs(bitriseConfig, workflowID, inputValues[workflowID]); err != nil {
+			result.Error = fmt.Errorf("Invalid workflow inputs, error: %s", err)
+		} else {
+			lastRunEnvironments = []envmanModels.EnvironmentItemModel{}
+			result.BuildRunResults, result.Error = runWorkflowWithSetup(bitriseConfig, inventoryEnvironments, workflowID, nil)
+			if isShareEnvs {
+				carriedOverEnvironments = append([]envmanModels.EnvironmentItemModel{}, lastRunEnvironments...)
+			}
+		}
+
+		result.RunTime = time.Now().Sub(startTime)
+		if result.Error != nil {
+			log.Error(result.Error)
+		}
+		results = append(results, result)
+
+		if result.BuildRunResults.IsAborted || buildTimeout.IsExpired() {
+			break
+		}
+	}
+
+	return results
+}
+
+func runMultipleAndExit(bitriseConfig models.BitriseDataModel, inventoryEnvironments []envmanModels.EnvironmentItemModel,
+	workflowIDs []string, inputValues map[string]map[string]string, isShareEnvs bool) {
+
+	if err := startRunLog(); err != nil {
+		log.Fatalf("Failed to start run log, error: %s", err)
+	}
+
+	if err := startLogStream(); err != nil {
+		log.Fatalf("Failed to start log stream, error: %s", err)
+	}
+
+	startBuildTimeout()
+	results := runMultipleWorkflows(bitriseConfig, inventoryEnvironments, workflowIDs, inputValues, isShareEnvs)
+	stopBuildTimeout()
+	bitrise.PrintMultiWorkflowSummary(results)
+
+	exitCode := bitrise.MultiWorkflowExitCode(results)
+
+	stopLogStream()
+	stopRunLog()
+	stopProfiling()
+	os.Exit(exitCode)
+}
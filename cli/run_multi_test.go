@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWorkflowsToRun(t *testing.T) {
+	t.Log("workflows flag")
+	{
+		require.Equal(t, []string{"test", "deploy"}, parseWorkflowsToRun("test, deploy,", []string{"ignored"}))
+	}
+
+	t.Log("args")
+	{
+		require.Equal(t, []string{"test", "deploy"}, parseWorkflowsToRun("", []string{"test", "deploy"}))
+	}
+
+	t.Log("none")
+	{
+		require.Equal(t, []string{}, parseWorkflowsToRun("", []string{}))
+	}
+}
+
+func TestWorkflowScopedPath(t *testing.T) {
+	require.Equal(t, "/tmp/summary.test.json", workflowScopedPath("/tmp/summary.json", "test"))
+	require.Equal(t, "/tmp/report.test", workflowScopedPath("/tmp/report", "test"))
+	require.Equal(t, "", workflowScopedPath("", "test"))
+}
+
+func TestSplitWorkflowInputValues(t *testing.T) {
+	configStr := `
+format_version: 1.3.1
+workflows:
+  setup:
+    inputs:
+      XCODE_SCHEME:
+        is_required: true
+  test:
+    before_run:
+    - setup
+    inputs:
+      TEST_PLAN:
+        default: All
+  deploy:
+    before_run:
+    - setup
+    inputs:
+      CONFIGURATION:
+        default: Release
+`
+	config, warnings, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	t.Log("every workflow gets the values of its declared inputs")
+	{
+		valuesByWorkflow, err := splitWorkflowInputValues(config, []string{"test", "deploy"}, map[string]string{
+			"XCODE_SCHEME":  "App",
+			"TEST_PLAN":     "Smoke",
+			"CONFIGURATION": "Debug",
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"XCODE_SCHEME": "App", "TEST_PLAN": "Smoke"}, valuesByWorkflow["test"])
+		require.Equal(t, map[string]string{"XCODE_SCHEME": "App", "CONFIGURATION": "Debug"}, valuesByWorkflow["deploy"])
+	}
+
+	t.Log("value of an input, not declared by any of the workflows")
+	{
+		_, err := splitWorkflowInputValues(config, []string{"test"}, map[string]string{"CONFIGURATION": "Debug"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "input (CONFIGURATION) is not declared by any of the workflows (test)")
+	}
+}
+
+func TestCarriedOverEnvironments(t *testing.T) {
+	configStr := `
+format_version: 1.3.1
+workflows:
+  first:
+    envs:
+    - FIRST_ENV: first
+  second:
+    envs:
+    - SECOND_ENV: second
+`
+	require.NoError(t, configs.InitPaths())
+
+	config, warnings, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	defer func() {
+		carriedOverEnvironments = []envmanModels.EnvironmentItemModel{}
+		lastRunEnvironments = []envmanModels.EnvironmentItemModel{}
+	}()
+
+	hasEnv := func(environments []envmanModels.EnvironmentItemModel, key string) bool {
+		for _, env := range environments {
+			if envKey, _, err := env.GetKeyValuePair(); err == nil && envKey == key {
+				return true
+			}
+		}
+		return false
+	}
+
+	_, err = runWorkflowWithConfiguration(time.Now(), "first", config, []envmanModels.EnvironmentItemModel{})
+	require.NoError(t, err)
+	require.True(t, hasEnv(lastRunEnvironments, "FIRST_ENV"))
+
+	t.Log("carried over envs are available for the next workflow")
+	{
+		carriedOverEnvironments = append([]envmanModels.EnvironmentItemModel{}, lastRunEnvironments...)
+
+		_, err := runWorkflowWithConfiguration(time.Now(), "second", config, []envmanModels.EnvironmentItemModel{})
+		require.NoError(t, err)
+		require.True(t, hasEnv(lastRunEnvironments, "FIRST_ENV"))
+		require.True(t, hasEnv(lastRunEnvironments, "SECOND_ENV"))
+	}
+
+	t.Log("without carried over envs")
+	{
+		carriedOverEnvironments = []envmanModels.EnvironmentItemModel{}
+
+		_, err := runWorkflowWithConfiguration(time.Now(), "second", config, []envmanModels.EnvironmentItemModel{})
+		require.NoError(t, err)
+		require.False(t, hasEnv(lastRunEnvironments, "FIRST_ENV"))
+		require.True(t, hasEnv(lastRunEnvironments, "SECOND_ENV"))
+	}
+}
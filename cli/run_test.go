@@ -9,8 +9,10 @@ import (
 
 	"github.com/bitrise-io/bitrise/bitrise"
 	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/exitcode"
 	"github.com/bitrise-io/bitrise/models"
 	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/fileutil"
 	"github.com/bitrise-io/go-utils/pathutil"
 	"github.com/stretchr/testify/require"
 )
@@ -674,8 +676,8 @@ func Test0Steps3WorkflowsBeforeAfter(t *testing.T) {
 	afterWorkflow := models.WorkflowModel{}
 
 	workflow := models.WorkflowModel{
-		BeforeRun: []string{"before"},
-		AfterRun:  []string{"after"},
+		BeforeRun: models.NewWorkflowRunItems("before"),
+		AfterRun:  models.NewWorkflowRunItems("after"),
 	}
 
 	config := models.BitriseDataModel{
@@ -696,7 +698,7 @@ func Test0Steps3WorkflowsBeforeAfter(t *testing.T) {
 		StepmanUpdates: map[string]int{},
 	}
 
-	buildRunResults, err = activateAndRunWorkflow("target", workflow, config, buildRunResults, &[]envmanModels.EnvironmentItemModel{}, "")
+	buildRunResults, err = activateAndRunWorkflow(newWorkflowRunContext(nil, nil), "target", workflow, config, buildRunResults, &[]envmanModels.EnvironmentItemModel{}, "")
 	require.NoError(t, err)
 	require.Equal(t, 0, len(buildRunResults.SuccessSteps))
 	require.Equal(t, 0, len(buildRunResults.FailedSteps))
@@ -717,14 +719,14 @@ func Test0Steps3WorkflowsCircularDependency(t *testing.T) {
 	defer func() { require.NoError(t, os.Unsetenv("STEPLIB_BUILD_STATUS")) }()
 
 	beforeWorkflow := models.WorkflowModel{
-		BeforeRun: []string{"target"},
+		BeforeRun: models.NewWorkflowRunItems("target"),
 	}
 
 	afterWorkflow := models.WorkflowModel{}
 
 	workflow := models.WorkflowModel{
-		BeforeRun: []string{"before"},
-		AfterRun:  []string{"after"},
+		BeforeRun: models.NewWorkflowRunItems("before"),
+		AfterRun:  models.NewWorkflowRunItems("after"),
 	}
 
 	config := models.BitriseDataModel{
@@ -744,6 +746,59 @@ func Test0Steps3WorkflowsCircularDependency(t *testing.T) {
 	require.Equal(t, "0", os.Getenv("STEPLIB_BUILD_STATUS"))
 }
 
+// Test - Bitrise activateAndRunWorkflow
+// Before and after workflows with run_if conditions, the workflows with false condition should be skipped
+func TestConditionalBeforeAfterWorkflows(t *testing.T) {
+	configStr := `
+format_version: 1.3.0
+default_step_lib_source: "https://github.com/bitrise-io/bitrise-steplib.git"
+
+workflows:
+  target:
+    before_run:
+    - before
+    - before_main:
+        run_if: '{{enveq "BRANCH" "main"}}'
+    after_run:
+    - after_feature:
+        run_if: '{{enveq "BRANCH" "feature"}}'
+  before:
+    envs:
+    - RUN_BEFORE: "true"
+  before_main:
+    envs:
+    - RUN_BEFORE_MAIN: "true"
+  after_feature:
+    envs:
+    - RUN_AFTER_FEATURE: "true"
+`
+
+	require.NoError(t, configs.InitPaths())
+
+	config, warnings, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	buildRunResults := models.BuildRunResultsModel{
+		StartTime:      time.Now(),
+		StepmanUpdates: map[string]int{},
+	}
+
+	environments := []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"BRANCH": "main"},
+	}
+	_, err = activateAndRunWorkflow(newWorkflowRunContext(nil, nil), "target", config.Workflows["target"], config, buildRunResults, &environments, "")
+	require.NoError(t, err)
+
+	keys := []string{}
+	for _, env := range environments {
+		key, _, err := env.GetKeyValuePair()
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	require.Equal(t, []string{"BRANCH", "RUN_BEFORE", "RUN_BEFORE_MAIN"}, keys)
+}
+
 // Test - Bitrise activateAndRunWorkflow
 // Trivial test with 1 workflow
 func Test1Workflows(t *testing.T) {
@@ -791,7 +846,7 @@ workflows:
 		StepmanUpdates: map[string]int{},
 	}
 
-	buildRunResults, err = activateAndRunWorkflow("trivial_fail", workflow, config, buildRunResults, &[]envmanModels.EnvironmentItemModel{}, "")
+	buildRunResults, err = activateAndRunWorkflow(newWorkflowRunContext(nil, nil), "trivial_fail", workflow, config, buildRunResults, &[]envmanModels.EnvironmentItemModel{}, "")
 	require.NoError(t, err)
 	require.Equal(t, 3, len(buildRunResults.SuccessSteps))
 	require.Equal(t, 1, len(buildRunResults.FailedSteps))
@@ -1480,3 +1535,145 @@ workflows:
 	require.NoError(t, err)
 	require.Equal(t, "after2", last)
 }
+
+func TestEnvFiles(t *testing.T) {
+	require.NoError(t, configs.InitPaths())
+
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("env_files")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "app.env"), "STAGE=app-file\nAPP_ONLY=app-file\n"))
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "workflow.env"), "STAGE=workflow-file\nWORKFLOW_ONLY=workflow-file\n"))
+
+	configStr := fmt.Sprintf(`
+format_version: 1.3.1
+app:
+  env_files:
+  - %s
+  envs:
+  - APP_ONLY: app-env
+workflows:
+  test:
+    env_files:
+    - %s
+`, filepath.Join(tmpDir, "app.env"), filepath.Join(tmpDir, "workflow.env"))
+
+	config, warnings, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	_, err = runWorkflowWithConfiguration(time.Now(), "test", config, []envmanModels.EnvironmentItemModel{})
+	require.NoError(t, err)
+
+	// later envs override the previous ones: app env files, app envs, workflow env files, workflow envs
+	values := map[string]string{}
+	for _, env := range lastRunEnvironments {
+		key, value, err := env.GetKeyValuePair()
+		require.NoError(t, err)
+		values[key] = value
+	}
+	require.Equal(t, "workflow-file", values["STAGE"])
+	require.Equal(t, "app-env", values["APP_ONLY"])
+	require.Equal(t, "workflow-file", values["WORKFLOW_ONLY"])
+}
+
+func TestRequiredSecretsPreflight(t *testing.T) {
+	configStr := `
+format_version: 1.3.1
+workflows:
+  deploy:
+    required_secrets:
+    - BITRISE_TEST_DEPLOY_TOKEN
+`
+	require.NoError(t, configs.InitPaths())
+
+	config, warnings, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	t.Log("missing secret")
+	{
+		_, err := runWorkflowWithConfiguration(time.Now(), "deploy", config, []envmanModels.EnvironmentItemModel{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "- BITRISE_TEST_DEPLOY_TOKEN (required by: workflow (deploy))")
+		require.True(t, exitcode.Is(err, exitcode.ConfigInvalid))
+	}
+
+	t.Log("secret provided by the inventory")
+	{
+		inventory := []envmanModels.EnvironmentItemModel{envmanModels.EnvironmentItemModel{"BITRISE_TEST_DEPLOY_TOKEN": "token"}}
+		_, err := runWorkflowWithConfiguration(time.Now(), "deploy", config, inventory)
+		require.NoError(t, err)
+	}
+}
+
+func TestRunConfigErrorExitCode(t *testing.T) {
+	configStr := `
+format_version: 1.3.1
+step_bundles:
+  install:
+    steps:
+    - script: {}
+workflows:
+  test:
+    steps:
+    - bundle::install: {}
+`
+	require.NoError(t, configs.InitPaths())
+
+	config, _, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+
+	t.Log("invalid config")
+	{
+		invalidConfig := config
+		invalidConfig.StepBundles = map[string]models.StepBundleModel{}
+		_, err := runWorkflowWithConfiguration(time.Now(), "test", invalidConfig, []envmanModels.EnvironmentItemModel{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "step bundle (install) does not exist")
+		require.True(t, exitcode.Is(err, exitcode.ConfigInvalid))
+	}
+
+	t.Log("not existing workflow")
+	{
+		_, err := runWorkflowWithConfiguration(time.Now(), "not_defined", config, []envmanModels.EnvironmentItemModel{})
+		require.Error(t, err)
+		require.True(t, exitcode.Is(err, exitcode.Usage))
+	}
+}
+
+func TestSecretReferencesResolvedOnlyForRun(t *testing.T) {
+	configStr := `
+format_version: 1.3.1
+workflows:
+  deploy:
+`
+	require.NoError(t, configs.InitPaths())
+
+	originalVaultAddr := os.Getenv(bitrise.VaultAddrEnvKey)
+	require.NoError(t, os.Unsetenv(bitrise.VaultAddrEnvKey))
+	defer func() {
+		require.NoError(t, os.Setenv(bitrise.VaultAddrEnvKey, originalVaultAddr))
+	}()
+
+	inventoryPth := filepath.Join(t.TempDir(), ".bitrise.secrets.yml")
+	require.NoError(t, fileutil.WriteStringToFile(inventoryPth, "envs:\n- DEPLOY_TOKEN: vault://secret/app#token\n"))
+
+	config, warnings, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	t.Log("the inventory keeps the secret references")
+	inventory, err := CreateInventoryFromCLIParams("", inventoryPth)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(inventory))
+	_, value, err := inventory[0].GetKeyValuePair()
+	require.NoError(t, err)
+	require.Equal(t, "vault://secret/app#token", value)
+
+	t.Log("the run resolves the secret references")
+	_, err = runWorkflowWithConfiguration(time.Now(), "deploy", config, inventory)
+	require.EqualError(t, err, "Failed to resolve secret references, error: Failed to fetch secret (DEPLOY_TOKEN) from vault, error: VAULT_ADDR not set")
+}
@@ -21,6 +21,8 @@ type RunAndTriggerParamsModel struct {
 	PRSourceBranch string `json:"pr-source-branch"`
 	PRTargetBranch string `json:"pr-target-branch"`
 	Tag            string `json:"tag"`
+	// PRDraft is true if the pull request is a draft
+	PRDraft bool `json:"pr-draft"`
 
 	// Trigger Check Params
 	Format string `json:"format"`
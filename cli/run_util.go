@@ -13,14 +13,16 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/bitrise-io/bitrise/bitrise"
 	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/exitcode"
 	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/output"
 	"github.com/bitrise-io/bitrise/plugins"
 	"github.com/bitrise-io/bitrise/toolkits"
 	"github.com/bitrise-io/bitrise/tools"
 	envmanModels "github.com/bitrise-io/envman/models"
 	"github.com/bitrise-io/go-utils/cmdex"
-	"github.com/bitrise-io/go-utils/colorstring"
 	"github.com/bitrise-io/go-utils/errorutil"
+	"github.com/bitrise-io/go-utils/fileutil"
 	"github.com/bitrise-io/go-utils/pathutil"
 	"github.com/bitrise-io/go-utils/versions"
 	stepmanModels "github.com/bitrise-io/stepman/models"
@@ -59,7 +61,7 @@ func registerPrMode(isPRMode bool) error {
 	configs.IsPullRequestMode = isPRMode
 
 	if isPRMode {
-		log.Info(colorstring.Yellow("bitrise runs in PR mode"))
+		log.Info(output.Yellow("bitrise runs in PR mode"))
 		return os.Setenv(configs.PRModeEnvKey, "true")
 	}
 	return os.Setenv(configs.PRModeEnvKey, "false")
@@ -94,7 +96,7 @@ func registerCIMode(isCIMode bool) error {
 	configs.IsCIMode = isCIMode
 
 	if isCIMode {
-		log.Info(colorstring.Yellow("bitrise runs in CI mode"))
+		log.Info(output.Yellow("bitrise runs in CI mode"))
 		return os.Setenv(configs.CIModeEnvKey, "true")
 	}
 	return os.Setenv(configs.CIModeEnvKey, "false")
@@ -102,12 +104,16 @@ func registerCIMode(isCIMode bool) error {
 
 // GetBitriseConfigFromBase64Data ...
 func GetBitriseConfigFromBase64Data(configBase64Str string) (models.BitriseDataModel, []string, error) {
+	return getBitriseConfigFromBase64Data(configBase64Str, nil)
+}
+
+func getBitriseConfigFromBase64Data(configBase64Str string, workflowIDs []string) (models.BitriseDataModel, []string, error) {
 	configBase64Bytes, err := base64.StdEncoding.DecodeString(configBase64Str)
 	if err != nil {
 		return models.BitriseDataModel{}, []string{}, fmt.Errorf("Failed to decode base 64 string, error: %s", err)
 	}
 
-	config, warnings, err := bitrise.ConfigModelFromYAMLBytes(configBase64Bytes)
+	config, warnings, err := bitrise.ConfigModelFromBytesForWorkflows(configBase64Bytes, bitrise.DetectConfigFormat(configBase64Bytes), workflowIDs)
 	if err != nil {
 		return models.BitriseDataModel{}, warnings, fmt.Errorf("Failed to parse bitrise config, error: %s", err)
 	}
@@ -118,15 +124,17 @@ func GetBitriseConfigFromBase64Data(configBase64Str string) (models.BitriseDataM
 // GetBitriseConfigFilePath ...
 func GetBitriseConfigFilePath(bitriseConfigPath string) (string, error) {
 	if bitriseConfigPath == "" {
-		log.Debugln("[BITRISE_CLI] - Workflow path not defined, searching for " + DefaultBitriseConfigFileName + " in current folder...")
-
-		bitriseConfigPath = filepath.Join(configs.CurrentDir, DefaultBitriseConfigFileName)
-
-		if exist, err := pathutil.IsPathExists(bitriseConfigPath); err != nil {
-			return "", err
-		} else if !exist {
-			return "", errors.New("No workflow yml found")
+		log.Debugln("[BITRISE_CLI] - Workflow path not defined, searching for " + DefaultBitriseConfigFileName + " or " + DefaultBitriseJSONConfigFileName + " in current folder...")
+
+		for _, fileName := range []string{DefaultBitriseConfigFileName, DefaultBitriseJSONConfigFileName} {
+			pth := filepath.Join(configs.CurrentDir, fileName)
+			if exist, err := pathutil.IsPathExists(pth); err != nil {
+				return "", err
+			} else if exist {
+				return pth, nil
+			}
 		}
+		return "", errors.New("No workflow yml found")
 	}
 
 	return bitriseConfigPath, nil
@@ -134,16 +142,35 @@ func GetBitriseConfigFilePath(bitriseConfigPath string) (string, error) {
 
 // CreateBitriseConfigFromCLIParams ...
 func CreateBitriseConfigFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath string) (models.BitriseDataModel, []string, error) {
+	return createBitriseConfigForWorkflowsFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath, nil)
+}
+
+// createBitriseConfigForWorkflowsFromCLIParams creates the config like CreateBitriseConfigFromCLIParams,
+// but only the given workflows (and the workflows referenced by them) are resolved and kept in the config,
+// see: bitrise.ConfigModelFromBytesForWorkflows
+func createBitriseConfigForWorkflowsFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath string, workflowIDs []string) (models.BitriseDataModel, []string, error) {
 	bitriseConfig := models.BitriseDataModel{}
 	warnings := []string{}
 
 	if bitriseConfigBase64Data != "" {
-		config, warns, err := GetBitriseConfigFromBase64Data(bitriseConfigBase64Data)
+		config, warns, err := getBitriseConfigFromBase64Data(bitriseConfigBase64Data, workflowIDs)
 		warnings = warns
 		if err != nil {
 			return models.BitriseDataModel{}, warnings, fmt.Errorf("Failed to get config (bitrise.yml) from base 64 data, err: %s", err)
 		}
 		bitriseConfig = config
+	} else if bitriseConfigPath == StdinPath {
+		configBytes, err := readStdinFor(ConfigKey)
+		if err != nil {
+			return models.BitriseDataModel{}, []string{}, err
+		}
+
+		config, warns, err := bitrise.ConfigModelFromBytesForWorkflows(configBytes, bitrise.DetectConfigFormat(configBytes), workflowIDs)
+		warnings = warns
+		if err != nil {
+			return models.BitriseDataModel{}, warnings, fmt.Errorf("Config (stdin) is not valid: %s", err)
+		}
+		bitriseConfig = config
 	} else {
 		bitriseConfigPath, err := GetBitriseConfigFilePath(bitriseConfigPath)
 		if err != nil {
@@ -153,7 +180,7 @@ func CreateBitriseConfigFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath
 			return models.BitriseDataModel{}, []string{}, errors.New("Failed to get config (bitrise.yml) path: empty bitriseConfigPath")
 		}
 
-		config, warns, err := bitrise.ReadBitriseConfig(bitriseConfigPath)
+		config, warns, err := bitrise.ReadBitriseConfigCachedForWorkflows(bitriseConfigPath, workflowIDs)
 		warnings = warns
 		if err != nil {
 			return models.BitriseDataModel{}, warnings, fmt.Errorf("Config (path:%s) is not valid: %s", bitriseConfigPath, err)
@@ -172,9 +199,64 @@ func CreateBitriseConfigFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath
 		return models.BitriseDataModel{}, warnings, errors.New("This bitrise.yml was created with and for a newer version of bitrise CLI, please upgrade your bitrise CLI to use this bitrise.yml!")
 	}
 
+	if configs.IsStrictConfigMode {
+		if err := checkStrictConfig(bitriseConfigBase64Data, bitriseConfigPath); err != nil {
+			return models.BitriseDataModel{}, warnings, err
+		}
+	}
+
 	return bitriseConfig, warnings, nil
 }
 
+// readBitriseConfigBytes returns the raw content of the config, given by the cli params, and its format.
+func readBitriseConfigBytes(bitriseConfigBase64Data, bitriseConfigPath string) ([]byte, string, error) {
+	if bitriseConfigBase64Data != "" {
+		bytes, err := base64.StdEncoding.DecodeString(bitriseConfigBase64Data)
+		if err != nil {
+			return []byte{}, "", fmt.Errorf("Failed to decode base 64 string, error: %s", err)
+		}
+		return bytes, bitrise.DetectConfigFormat(bytes), nil
+	} else if bitriseConfigPath == StdinPath {
+		bytes, err := readStdinFor(ConfigKey)
+		if err != nil {
+			return []byte{}, "", err
+		}
+		return bytes, bitrise.DetectConfigFormat(bytes), nil
+	}
+
+	pth, err := GetBitriseConfigFilePath(bitriseConfigPath)
+	if err != nil {
+		return []byte{}, "", err
+	}
+	bytes, err := fileutil.ReadBytesFromFile(pth)
+	if err != nil {
+		return []byte{}, "", err
+	}
+	return bytes, bitrise.ConfigFormatOfPath(pth), nil
+}
+
+// checkStrictConfig returns an error, listing the unknown keys of the config (strict config mode)
+func checkStrictConfig(bitriseConfigBase64Data, bitriseConfigPath string) error {
+	configBytes, format, err := readBitriseConfigBytes(bitriseConfigBase64Data, bitriseConfigPath)
+	if err != nil {
+		return err
+	}
+
+	strictErrs, err := bitrise.StrictConfigErrors(configBytes, format)
+	if err != nil {
+		return fmt.Errorf("Failed to check the config keys, error: %s", err)
+	}
+	if len(strictErrs) == 0 {
+		return nil
+	}
+
+	lines := []string{"Config has unknown keys (strict mode):"}
+	for _, strictErr := range strictErrs {
+		lines = append(lines, "- "+strictErr.Error())
+	}
+	return errors.New(strings.Join(lines, "\n"))
+}
+
 // GetInventoryFromBase64Data ...
 func GetInventoryFromBase64Data(inventoryBase64Str string) ([]envmanModels.EnvironmentItemModel, error) {
 	inventoryBase64Bytes, err := base64.StdEncoding.DecodeString(inventoryBase64Str)
@@ -216,6 +298,17 @@ func CreateInventoryFromCLIParams(inventoryBase64Data, inventoryPath string) ([]
 			return []envmanModels.EnvironmentItemModel{}, fmt.Errorf("Failed to get inventory from base 64 data, err: %s", err)
 		}
 		inventoryEnvironments = inventory
+	} else if inventoryPath == StdinPath {
+		inventoryBytes, err := readStdinFor(InventoryKey)
+		if err != nil {
+			return []envmanModels.EnvironmentItemModel{}, err
+		}
+
+		inventory, err := bitrise.InventoryModelFromYAMLBytes(inventoryBytes)
+		if err != nil {
+			return []envmanModels.EnvironmentItemModel{}, fmt.Errorf("Invalid invetory format (stdin): %s", err)
+		}
+		inventoryEnvironments = inventory.Envs
 	} else {
 		inventoryPath, err := GetInventoryFilePath(inventoryPath)
 		if err != nil {
@@ -264,29 +357,22 @@ func checkAndInstallStepDependencies(step stepmanModels.StepModel) error {
 			if err := bitrise.DependencyTryCheckTool(checkOnlyDep.Name); err != nil {
 				return err
 			}
-			log.Infof(" * "+colorstring.Green("[OK]")+" Step dependency (%s) installed, available.", checkOnlyDep.Name)
+			log.Infof(" * "+output.Green("[OK]")+" Step dependency (%s) installed, available.", checkOnlyDep.Name)
 		}
 
-		switch runtime.GOOS {
-		case "darwin":
-			for _, brewDep := range step.Deps.Brew {
-				if err := bitrise.InstallWithBrewIfNeeded(brewDep, configs.IsCIMode); err != nil {
-					log.Infof("Failed to install (%s) with brew", brewDep.Name)
-					return err
-				}
-				log.Infof(" * "+colorstring.Green("[OK]")+" Step dependency (%s) installed, available.", brewDep.GetBinaryName())
-			}
-		case "linux":
-			for _, aptGetDep := range step.Deps.AptGet {
-				log.Infof("Start installing (%s) with apt-get", aptGetDep.Name)
-				if err := bitrise.InstallWithAptGetIfNeeded(aptGetDep, configs.IsCIMode); err != nil {
-					log.Infof("Failed to install (%s) with apt-get", aptGetDep.Name)
-					return err
-				}
-				log.Infof(" * "+colorstring.Green("[OK]")+" Step dependency (%s) installed, available.", aptGetDep.GetBinaryName())
+		provider, err := bitrise.NewDependencyProvider(configs.DependencyManager, runtime.GOOS, bitrise.IsToolAvailable)
+		if err != nil {
+			return err
+		}
+		if provider.Name() == bitrise.DependencyManagerNone {
+			log.Debugf(" * Step dependencies are not installed, the dependency manager is disabled")
+		}
+		for _, dep := range provider.Dependencies(step) {
+			if err := bitrise.InstallDependencyIfNeeded(provider, dep, configs.IsCIMode); err != nil {
+				log.Infof("Failed to install (%s) with %s", dep.Name, provider.Name())
+				return err
 			}
-		default:
-			return errors.New("Unsupported os")
+			log.Infof(" * "+output.Green("[OK]")+" Step dependency (%s) installed, available.", dep.GetBinaryName())
 		}
 	} else if len(step.Dependencies) > 0 {
 		log.Info("Deprecated dependencies found")
@@ -296,8 +382,8 @@ func checkAndInstallStepDependencies(step stepmanModels.StepModel) error {
 			isSkippedBecauseOfPlatform := false
 			switch dep.Manager {
 			case depManagerBrew:
-				if runtime.GOOS == "darwin" {
-					err := bitrise.InstallWithBrewIfNeeded(stepmanModels.BrewDepModel{Name: dep.Name}, configs.IsCIMode)
+				if runtime.GOOS == "darwin" && configs.DependencyManager != bitrise.DependencyManagerNone {
+					err := bitrise.InstallDependencyIfNeeded(bitrise.BrewDependencyProvider{}, bitrise.DependencyModel{Name: dep.Name}, configs.IsCIMode)
 					if err != nil {
 						return err
 					}
@@ -318,7 +404,7 @@ func checkAndInstallStepDependencies(step stepmanModels.StepModel) error {
 			if isSkippedBecauseOfPlatform {
 				log.Debugf(" * Dependency (%s) skipped, manager (%s) not supported on this platform (%s)", dep.Name, dep.Manager, runtime.GOOS)
 			} else {
-				log.Infof(" * "+colorstring.Green("[OK]")+" Step dependency (%s) installed, available.", dep.Name)
+				log.Infof(" * "+output.Green("[OK]")+" Step dependency (%s) installed, available.", dep.Name)
 			}
 		}
 	}
@@ -326,36 +412,60 @@ func checkAndInstallStepDependencies(step stepmanModels.StepModel) error {
 	return nil
 }
 
-func executeStep(step stepmanModels.StepModel, sIDData models.StepIDData, stepAbsDirPath, bitriseSourceDir string) (int, error) {
-	toolkitForStep := toolkits.ToolkitForStep(step)
+func executeStep(step models.WorkflowStepModel, sIDData models.StepIDData, stepAbsDirPath, bitriseSourceDir string) (int, error) {
+	toolkitForStep := toolkits.ToolkitForStep(step.StepModel)
 	toolkitName := toolkitForStep.ToolkitName()
 
-	if err := toolkitForStep.PrepareForStepRun(step, sIDData, stepAbsDirPath); err != nil {
+	if err := toolkitForStep.PrepareForStepRun(step.StepModel, sIDData, stepAbsDirPath); err != nil {
 		return 1, fmt.Errorf("Failed to prepare the step for execution through the required toolkit (%s), error: %s",
 			toolkitName, err)
 	}
 
-	cmd, err := toolkitForStep.StepRunCommandArguments(step, sIDData, stepAbsDirPath)
+	cmd, err := toolkitForStep.StepRunCommandArguments(step.StepModel, sIDData, stepAbsDirPath)
 	if err != nil {
 		return 1, fmt.Errorf("Toolkit (%s) rejected the step, error: %s",
 			toolkitName, err)
 	}
 
+	if stepProfile != nil {
+		stepProfile.StartPhase(bitrise.StepPhaseExecution)
+	}
+
+	if step.ResourceLimits != nil {
+		return tools.EnvmanRunWithResourceLimits(configs.InputEnvstorePath, bitriseSourceDir, cmd, *step.ResourceLimits)
+	}
 	return tools.EnvmanRun(configs.InputEnvstorePath, bitriseSourceDir, cmd)
 }
 
-func runStep(step stepmanModels.StepModel, stepIDData models.StepIDData, stepDir string, environments []envmanModels.EnvironmentItemModel, buildRunResults models.BuildRunResultsModel) (int, []envmanModels.EnvironmentItemModel, error) {
+func runStep(step models.WorkflowStepModel, stepIDData models.StepIDData, stepDir, workingDir string, environments []envmanModels.EnvironmentItemModel, buildRunResults models.BuildRunResultsModel) (int, []envmanModels.EnvironmentItemModel, error) {
 	log.Debugf("[BITRISE_CLI] - Try running step: %s (%s)", stepIDData.IDorURI, stepIDData.Version)
 
+	if stepProfile != nil {
+		stepProfile.StartPhase(bitrise.StepPhasePrepare)
+	}
+
 	// Check & Install Step Dependencies
 	// [!] Make sure this happens BEFORE the Toolkit Bootstrap,
 	// so that if a Toolkit requires/allows the use of additional dependencies
 	// required for the step (e.g. a brew installed OpenSSH) it can be done
 	// with a Toolkit+Deps
-	if err := checkAndInstallStepDependencies(step); err != nil {
+	if err := checkAndInstallStepDependencies(step.StepModel); err != nil {
 		return 1, []envmanModels.EnvironmentItemModel{}, fmt.Errorf("Failed to install Step dependency, error: %s", err)
 	}
 
+	// Resolve the working dir: the step's working_dir overrides the workflow's (or app's) working_dir
+	if step.WorkingDir != nil && *step.WorkingDir != "" {
+		workingDir = *step.WorkingDir
+	}
+	if workingDir != "" {
+		workingDirPth, err := bitrise.ResolveWorkingDir(workingDir, environments)
+		if err != nil {
+			return 1, []envmanModels.EnvironmentItemModel{}, fmt.Errorf("Failed to resolve the working dir of the Step, error: %s", err)
+		}
+		log.Debugf("[BITRISE_CLI] - Step working dir: %s", workingDirPth)
+		environments = append(environments, envmanModels.EnvironmentItemModel{configs.BitriseSourceDirEnvKey: workingDirPth})
+	}
+
 	// Collect step inputs
 	if err := tools.EnvmanInitAtPath(configs.InputEnvstorePath); err != nil {
 		return 1, []envmanModels.EnvironmentItemModel{}, fmt.Errorf("Failed to init envman for the Step, error: %s", err)
@@ -423,6 +533,11 @@ func runStep(step stepmanModels.StepModel, stepIDData models.StepIDData, stepDir
 			return 1, []envmanModels.EnvironmentItemModel{}, envErr
 		}
 
+		stepOutputs, envErr = bitrise.SpillLargeOutputs(stepOutputs)
+		if envErr != nil {
+			return 1, []envmanModels.EnvironmentItemModel{}, envErr
+		}
+
 		return exit, stepOutputs, err
 	}
 
@@ -431,25 +546,53 @@ func runStep(step stepmanModels.StepModel, stepIDData models.StepIDData, stepDir
 		return 1, []envmanModels.EnvironmentItemModel{}, err
 	}
 
+	stepOutputs, err = bitrise.SpillLargeOutputs(stepOutputs)
+	if err != nil {
+		return 1, []envmanModels.EnvironmentItemModel{}, err
+	}
+
 	log.Debugf("[BITRISE_CLI] - Step executed: %s (%s)", stepIDData.IDorURI, stepIDData.Version)
 
 	return 0, stepOutputs, nil
 }
 
-func activateAndRunSteps(workflow models.WorkflowModel, defaultStepLibSource string, buildRunResults models.BuildRunResultsModel, environments *[]envmanModels.EnvironmentItemModel, isLastWorkflow bool) models.BuildRunResultsModel {
+// isStepRunWithBuildStatus returns whether the step has to run with the current build status:
+// if the build is failing, only the is_always_run and run_on_failure_only steps run,
+// if the build is not failing, every step runs, except the run_on_failure_only steps.
+func isStepRunWithBuildStatus(step models.WorkflowStepModel, isBuildFailed bool) bool {
+	isAlwaysRun := stepmanModels.DefaultIsAlwaysRun
+	if step.IsAlwaysRun != nil {
+		isAlwaysRun = *step.IsAlwaysRun
+	}
+	runOnFailureOnly := models.DefaultRunOnFailureOnly
+	if step.RunOnFailureOnly != nil {
+		runOnFailureOnly = *step.RunOnFailureOnly
+	}
+
+	if isBuildFailed {
+		return isAlwaysRun || runOnFailureOnly
+	}
+	return !runOnFailureOnly
+}
+
+func activateAndRunSteps(runContext *workflowRunContext, workflowID string, workflow models.WorkflowModel, defaultStepLibSource string, buildRunResults models.BuildRunResultsModel, environments *[]envmanModels.EnvironmentItemModel, isLastWorkflow bool) models.BuildRunResultsModel {
 	log.Debugln("[BITRISE_CLI] - Activating and running steps")
 
 	// ------------------------------------------
 	// In function global variables - These are global for easy use in local register step run result methods.
 	var stepStartTime time.Time
+	// the step ID of the current step, as it is in the workflow
+	var compositeStepID string
 
 	// ------------------------------------------
 	// In function method - Registration methods, for register step run results.
-	registerStepRunResults := func(step stepmanModels.StepModel, stepInfoPtr stepmanModels.StepInfoModel,
+	registerStepRunResults := func(step models.WorkflowStepModel, stepInfoPtr stepmanModels.StepInfoModel,
 		stepIdxPtr int, runIf string, resultCode, exitCode int, err error, isLastStep, printStepHeader bool) {
 
+		defer finishStepOutputCapture(resultCode)
+
 		if printStepHeader {
-			bitrise.PrintRunningStepHeader(stepInfoPtr, step, stepIdxPtr)
+			bitrise.PrintRunningStepHeader(stepInfoPtr, step.StepModel, stepIdxPtr)
 		}
 
 		stepInfoCopy := stepmanModels.StepInfoModel{
@@ -457,6 +600,7 @@ func activateAndRunSteps(workflow models.WorkflowModel, defaultStepLibSource str
 			Title:         stepInfoPtr.Title,
 			Version:       stepInfoPtr.Version,
 			Latest:        stepInfoPtr.Latest,
+			StepLib:       stepInfoPtr.StepLib,
 			SupportURL:    stepInfoPtr.SupportURL,
 			SourceCodeURL: stepInfoPtr.SourceCodeURL,
 			GlobalInfo:    stepInfoPtr.GlobalInfo,
@@ -469,11 +613,14 @@ func activateAndRunSteps(workflow models.WorkflowModel, defaultStepLibSource str
 			RunTime:  time.Now().Sub(stepStartTime),
 			Error:    err,
 			ExitCode: exitCode,
+
+			SkipReason: bitrise.StepSkipReason(step, runIf, resultCode, err, buildRunResults, configs.IsPullRequestMode),
 		}
 
 		isExitStatusError := true
 		if err != nil {
 			isExitStatusError = errorutil.IsExitStatusError(err)
+			bitrise.CheckDiskFull(err)
 		}
 
 		switch resultCode {
@@ -497,14 +644,20 @@ func activateAndRunSteps(workflow models.WorkflowModel, defaultStepLibSource str
 			buildRunResults.FailedSkippableSteps = append(buildRunResults.FailedSkippableSteps, stepResults)
 			break
 		case models.StepRunStatusCodeSkipped:
-			log.Warnf("A previous step failed, and this step (%s) was not marked as IsAlwaysRun, skipped", stepInfoCopy.Title)
+			if err != nil {
+				log.Warnf("Step (%s) skipped, error: %s", stepInfoCopy.Title, err)
+			} else if step.RunOnFailureOnly != nil && *step.RunOnFailureOnly && !buildRunResults.IsBuildFailed() {
+				log.Warnf("The build is not failing, and this step (%s) was marked as RunOnFailureOnly, skipped", stepInfoCopy.Title)
+			} else {
+				log.Warnf("A previous step failed, and this step (%s) was not marked as IsAlwaysRun, skipped", stepInfoCopy.Title)
+			}
 
 			buildRunResults.SkippedSteps = append(buildRunResults.SkippedSteps, stepResults)
 			break
 		case models.StepRunStatusCodeSkippedWithRunIf:
 			log.Warn("The step's (" + stepInfoCopy.Title + ") Run-If expression evaluated to false - skipping")
 			if runIf != "" {
-				log.Info("The Run-If expression was: ", colorstring.Blue(runIf))
+				log.Info("The Run-If expression was: ", output.Blue(runIf))
 			}
 
 			buildRunResults.SkippedSteps = append(buildRunResults.SkippedSteps, stepResults)
@@ -514,17 +667,101 @@ func activateAndRunSteps(workflow models.WorkflowModel, defaultStepLibSource str
 			return
 		}
 
+		runContext.registerStepResult(bitrise.NewLastRunStep(workflowID, stepResults.Idx, compositeStepID, resultCode, step.StepModel))
+
+		if htmlReport != nil {
+			htmlReport.FinishStep(stepResults.Idx)
+		}
+		if artifactTracker != nil {
+			artifactTracker.FinishStep(stepResults)
+		}
+		if stepProfile != nil {
+			stepProfile.FinishStep(stepResults)
+		}
+
 		bitrise.PrintRunningStepFooter(stepResults, isLastStep)
+
+		plugins.TriggerStepDidFinish(stepResults)
 	}
 
+	workflowTimeout := startWorkflowTimeout(workflow)
+	defer workflowTimeout.Stop()
+
 	// ------------------------------------------
 	// Main - Preparing & running the steps
 	for idx, stepListItm := range workflow.Steps {
+		if resourceGovernor != nil {
+			resourceGovernor.WaitForResources()
+		}
+
 		// Per step variables
 		stepStartTime = time.Now()
+		startStepOutputCapture()
+		if htmlReport != nil {
+			htmlReport.StartStep()
+		}
+		if artifactTracker != nil {
+			artifactTracker.StartStep()
+		}
+		if stepProfile != nil {
+			stepProfile.StartStep()
+		}
 		isLastStep := isLastWorkflow && (idx == len(workflow.Steps)-1)
 		stepInfoPtr := stepmanModels.StepInfoModel{}
 		stepIdxPtr := idx
+		compositeStepID, _, _ = models.GetStepIDStepDataPair(stepListItm)
+
+		// Every further write would fail, skip the step instead of cascading errors
+		if bitrise.IsDiskFull() {
+			if compositeStepIDStr, _, err := models.GetStepIDStepDataPair(stepListItm); err == nil {
+				stepInfoPtr.ID = compositeStepIDStr
+				stepInfoPtr.Title = compositeStepIDStr
+			}
+
+			registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
+				"", models.StepRunStatusCodeSkipped, 1, bitrise.ErrDiskFull, isLastStep, true)
+			continue
+		}
+
+		if bitrise.IsAborted() && !isOnAbortWorkflowRunning {
+			if compositeStepIDStr, _, err := models.GetStepIDStepDataPair(stepListItm); err == nil {
+				stepInfoPtr.ID = compositeStepIDStr
+				stepInfoPtr.Title = compositeStepIDStr
+			}
+
+			registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
+				"", models.StepRunStatusCodeSkipped, 1, bitrise.ErrBuildAborted, isLastStep, true)
+			continue
+		}
+
+		if buildTimeout.IsGraceExpired() {
+			if compositeStepIDStr, _, err := models.GetStepIDStepDataPair(stepListItm); err == nil {
+				stepInfoPtr.ID = compositeStepIDStr
+				stepInfoPtr.Title = compositeStepIDStr
+			}
+
+			registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
+				"", models.StepRunStatusCodeSkipped, 1, bitrise.ErrBuildTimeoutGraceExpired, isLastStep, true)
+			continue
+		}
+
+		if isRerun, err := runContext.isStepRerun(workflowID, buildRunResults.ResultsCount(), compositeStepID, environments); !isRerun {
+			stepInfoPtr.ID = compositeStepID
+			stepInfoPtr.Title = compositeStepID
+
+			if err == nil {
+				registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
+					"", models.StepRunStatusCodeSkipped, 0, bitrise.ErrStepNotRerun, isLastStep, true)
+			} else if err == bitrise.ErrRerunRefused {
+				registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
+					"", models.StepRunStatusCodeSkipped, 0, err, isLastStep, true)
+			} else {
+				registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
+					"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
+			}
+			continue
+		}
+		runContext.registerStepStart(*environments)
 
 		// Per step cleanup
 		if err := bitrise.SetBuildFailedEnv(buildRunResults.IsBuildFailed()); err != nil {
@@ -532,7 +769,7 @@ func activateAndRunSteps(workflow models.WorkflowModel, defaultStepLibSource str
 		}
 
 		if err := bitrise.CleanupStepWorkDir(); err != nil {
-			registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+			registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
 				"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
 			continue
 		}
@@ -540,13 +777,13 @@ func activateAndRunSteps(workflow models.WorkflowModel, defaultStepLibSource str
 		//
 		// Preparing the step
 		if err := tools.EnvmanInitAtPath(configs.InputEnvstorePath); err != nil {
-			registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+			registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
 				"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
 			continue
 		}
 
 		if err := bitrise.ExportEnvironmentsList(*environments); err != nil {
-			registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+			registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
 				"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
 			continue
 		}
@@ -554,7 +791,7 @@ func activateAndRunSteps(workflow models.WorkflowModel, defaultStepLibSource str
 		// Get step id & version data
 		compositeStepIDStr, workflowStep, err := models.GetStepIDStepDataPair(stepListItm)
 		if err != nil {
-			registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+			registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
 				"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
 			continue
 		}
@@ -565,9 +802,9 @@ func activateAndRunSteps(workflow models.WorkflowModel, defaultStepLibSource str
 			stepInfoPtr.Title = compositeStepIDStr
 		}
 
-		stepIDData, err := models.CreateStepIDDataFromString(compositeStepIDStr, defaultStepLibSource)
+		stepIDData, err := resolveStepIDData(compositeStepIDStr, defaultStepLibSource)
 		if err != nil {
-			registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+			registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
 				"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
 			continue
 		}
@@ -578,6 +815,12 @@ func activateAndRunSteps(workflow models.WorkflowModel, defaultStepLibSource str
 		stepInfoPtr.Version = stepIDData.Version
 		stepInfoPtr.StepLib = stepIDData.SteplibSource
 
+		if !bitrise.ActivePolicy().IsStepAllowed(stepIDData) {
+			registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
+				"", models.StepRunStatusCodeFailed, 1, fmt.Errorf("Step (%s) is not allowed by the organization policy", compositeStepIDStr), isLastStep, true)
+			continue
+		}
+
 		//
 		// Activating the step
 		stepDir := configs.BitriseWorkStepsDirPath
@@ -587,7 +830,7 @@ func activateAndRunSteps(workflow models.WorkflowModel, defaultStepLibSource str
 			log.Debugf("[BITRISE_CLI] - Local step found: (path:%s)", stepIDData.IDorURI)
 			stepAbsLocalPth, err := pathutil.AbsPath(stepIDData.IDorURI)
 			if err != nil {
-				registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+				registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
 					"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
 				continue
 			}
@@ -595,32 +838,38 @@ func activateAndRunSteps(workflow models.WorkflowModel, defaultStepLibSource str
 			log.Debugln("stepAbsLocalPth:", stepAbsLocalPth, "|stepDir:", stepDir)
 
 			if err := cmdex.CopyDir(stepAbsLocalPth, stepDir, true); err != nil {
-				registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+				registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
 					"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
 				continue
 			}
 
 			if err := cmdex.CopyFile(filepath.Join(stepAbsLocalPth, "step.yml"), stepYMLPth); err != nil {
-				registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+				registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
 					"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
 				continue
 			}
 		} else if stepIDData.SteplibSource == "git" {
 			log.Debugf("[BITRISE_CLI] - Remote step, with direct git uri: (uri:%s) (tag-or-branch:%s)", stepIDData.IDorURI, stepIDData.Version)
-			if err := cmdex.GitCloneTagOrBranch(stepIDData.IDorURI, stepDir, stepIDData.Version); err != nil {
+			if isPreActivated, err := bitrise.ActivatePreActivatedStep(stepIDData, stepDir, ""); isPreActivated {
+				if err != nil {
+					registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
+						"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
+					continue
+				}
+			} else if err := tools.GitCloneTagOrBranch(stepIDData.IDorURI, stepDir, stepIDData.Version); err != nil {
 				if strings.HasPrefix(stepIDData.IDorURI, "git@") {
-					fmt.Println(colorstring.Yellow(`Note: if the step's repository is an open source one,`))
-					fmt.Println(colorstring.Yellow(`you should probably use a "https://..." git clone URL,`))
-					fmt.Println(colorstring.Yellow(`instead of the "git@..." git clone URL which usually requires authentication`))
-					fmt.Println(colorstring.Yellow(`even if the repository is open source!`))
+					fmt.Println(output.Yellow(`Note: if the step's repository is an open source one,`))
+					fmt.Println(output.Yellow(`you should probably use a "https://..." git clone URL,`))
+					fmt.Println(output.Yellow(`instead of the "git@..." git clone URL which usually requires authentication`))
+					fmt.Println(output.Yellow(`even if the repository is open source!`))
 				}
-				registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+				registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
 					"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
 				continue
 			}
 
 			if err := cmdex.CopyFile(filepath.Join(stepDir, "step.yml"), stepYMLPth); err != nil {
-				registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+				registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
 					"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
 				continue
 			}
@@ -630,20 +879,52 @@ func activateAndRunSteps(workflow models.WorkflowModel, defaultStepLibSource str
 			// Steplib independent steps are completly defined in workflow
 			stepYMLPth = ""
 			if err := workflowStep.FillMissingDefaults(); err != nil {
-				registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+				registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
 					"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
 				continue
 			}
 
-			if err := cmdex.GitCloneTagOrBranch(stepIDData.IDorURI, stepDir, stepIDData.Version); err != nil {
-				registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+			if isPreActivated, err := bitrise.ActivatePreActivatedStep(stepIDData, stepDir, ""); isPreActivated {
+				if err != nil {
+					registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
+						"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
+					continue
+				}
+			} else if err := tools.GitCloneTagOrBranch(stepIDData.IDorURI, stepDir, stepIDData.Version); err != nil {
+				registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
+					"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
+				continue
+			}
+		} else if stepIDData.SteplibSource == models.OCIStepSource {
+			log.Debugf("[BITRISE_CLI] - OCI artifact step: (name:%s) (reference:%s)", stepIDData.IDorURI, stepIDData.Version)
+			if isPreActivated, err := bitrise.ActivatePreActivatedStep(stepIDData, stepDir, stepYMLPth); isPreActivated {
+				if err != nil {
+					registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
+						"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
+					continue
+				}
+			} else if err := bitrise.ActivateOCIStep(stepIDData, stepDir, stepYMLPth); err != nil {
+				registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
+					"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
+				continue
+			}
+		} else if stepIDData.SteplibSource == models.ArchiveStepSource {
+			log.Debugf("[BITRISE_CLI] - Archive step: (url:%s) (checksum:%s)", stepIDData.IDorURI, stepIDData.Version)
+			if isPreActivated, err := bitrise.ActivatePreActivatedStep(stepIDData, stepDir, stepYMLPth); isPreActivated {
+				if err != nil {
+					registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
+						"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
+					continue
+				}
+			} else if err := bitrise.ActivateArchiveStep(stepIDData, stepDir, stepYMLPth); err != nil {
+				registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
 					"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
 				continue
 			}
 		} else if stepIDData.SteplibSource != "" {
 			log.Debugf("[BITRISE_CLI] - Steplib (%s) step (id:%s) (version:%s) found, activating step", stepIDData.SteplibSource, stepIDData.IDorURI, stepIDData.Version)
 			if err := tools.StepmanSetup(stepIDData.SteplibSource); err != nil {
-				registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+				registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
 					"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
 				continue
 			}
@@ -658,37 +939,30 @@ func activateAndRunSteps(workflow models.WorkflowModel, defaultStepLibSource str
 				}
 			}
 
-			outStr, err := tools.StepmanJSONStepLibStepInfo(stepIDData.SteplibSource, stepIDData.IDorURI, stepIDData.Version)
+			stepInfo, err := tools.StepmanStepLibStepInfo(stepIDData.SteplibSource, stepIDData.IDorURI, stepIDData.Version)
 			if err != nil {
 				if buildRunResults.IsStepLibUpdated(stepIDData.SteplibSource) {
-					registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
-						"", models.StepRunStatusCodeFailed, 1, fmt.Errorf("StepmanJSONStepLibStepInfo failed, err: %s", err), isLastStep, true)
+					registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
+						"", models.StepRunStatusCodeFailed, 1, fmt.Errorf("StepmanStepLibStepInfo failed, err: %s", err), isLastStep, true)
 					continue
 				}
 				// May StepLib should be updated
 				log.Infof("Step info not found in StepLib (%s) -- Updating ...", stepIDData.SteplibSource)
 				if err := tools.StepmanUpdate(stepIDData.SteplibSource); err != nil {
-					registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+					registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
 						"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
 					continue
 				}
 				buildRunResults.StepmanUpdates[stepIDData.SteplibSource]++
 
-				outStr, err = tools.StepmanJSONStepLibStepInfo(stepIDData.SteplibSource, stepIDData.IDorURI, stepIDData.Version)
+				stepInfo, err = tools.StepmanStepLibStepInfo(stepIDData.SteplibSource, stepIDData.IDorURI, stepIDData.Version)
 				if err != nil {
-					registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
-						"", models.StepRunStatusCodeFailed, 1, fmt.Errorf("StepmanJSONStepLibStepInfo failed, err: %s", err), isLastStep, true)
+					registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
+						"", models.StepRunStatusCodeFailed, 1, fmt.Errorf("StepmanStepLibStepInfo failed, err: %s", err), isLastStep, true)
 					continue
 				}
 			}
 
-			stepInfo, err := stepmanModels.StepInfoModel{}.CreateFromJSON(outStr)
-			if err != nil {
-				registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
-					"", models.StepRunStatusCodeFailed, 1, fmt.Errorf("CreateFromJSON failed, err: %s", err), isLastStep, true)
-				continue
-			}
-
 			stepInfoPtr.ID = stepInfo.ID
 			if stepInfoPtr.Title == "" {
 				stepInfoPtr.Title = stepInfo.ID
@@ -697,15 +971,21 @@ func activateAndRunSteps(workflow models.WorkflowModel, defaultStepLibSource str
 			stepInfoPtr.Latest = stepInfo.Latest
 			stepInfoPtr.GlobalInfo = stepInfo.GlobalInfo
 
-			if err := tools.StepmanActivate(stepIDData.SteplibSource, stepIDData.IDorURI, stepIDData.Version, stepDir, stepYMLPth); err != nil {
-				registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+			if isPreActivated, err := bitrise.ActivatePreActivatedStep(stepIDData, stepDir, stepYMLPth); isPreActivated {
+				if err != nil {
+					registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
+						"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
+					continue
+				}
+			} else if err := tools.StepmanActivate(stepIDData.SteplibSource, stepIDData.IDorURI, stepIDData.Version, stepDir, stepYMLPth); err != nil {
+				registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
 					"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
 				continue
 			} else {
 				log.Debugf("[BITRISE_CLI] - Step activated: (ID:%s) (version:%s)", stepIDData.IDorURI, stepIDData.Version)
 			}
 		} else {
-			registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+			registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
 				"", models.StepRunStatusCodeFailed, 1, fmt.Errorf("Invalid stepIDData: No SteplibSource or LocalPath defined (%v)", stepIDData), isLastStep, true)
 			continue
 		}
@@ -716,17 +996,43 @@ func activateAndRunSteps(workflow models.WorkflowModel, defaultStepLibSource str
 			specStep, err := bitrise.ReadSpecStep(stepYMLPth)
 			log.Debugf("Spec read from YML: %#v\n", specStep)
 			if err != nil {
-				registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+				registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
 					"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
 				continue
 			}
 
-			mergedStep, err = models.MergeStepWith(specStep, workflowStep)
+			mergedStep.StepModel, err = models.MergeStepWith(specStep, workflowStep.StepModel)
 			if err != nil {
-				registerStepRunResults(stepmanModels.StepModel{}, stepInfoPtr, stepIdxPtr,
+				registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
 					"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
 				continue
 			}
+
+			if deprecation, err := bitrise.ReadStepYMLDeprecation(stepYMLPth); err != nil {
+				log.Warnf("Failed to read the deprecation notice of the step, error: %s", err)
+			} else {
+				stepInfoPtr.GlobalInfo = bitrise.MergeStepDeprecation(stepInfoPtr.GlobalInfo, deprecation)
+			}
+		}
+
+		if stepYMLPth != "" && configs.StepAuditMode != bitrise.StepAuditModeOff {
+			if err := auditStep(compositeStepIDStr, stepYMLPth, stepDir); err != nil {
+				registerStepRunResults(mergedStep, stepInfoPtr, stepIdxPtr,
+					"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
+				continue
+			}
+		}
+
+		if configs.IsNoDeprecatedMode && bitrise.IsStepDeprecated(stepInfoPtr) {
+			registerStepRunResults(mergedStep, stepInfoPtr, stepIdxPtr,
+				"", models.StepRunStatusCodeFailed, 1, fmt.Errorf("Step (%s) is deprecated, deprecated steps are not allowed (--%s)", compositeStepIDStr, NoDeprecatedKey), isLastStep, true)
+			continue
+		}
+
+		if err := registerResolvedStep(compositeStepIDStr, stepIDData, stepInfoPtr.Version, mergedStep.StepModel, stepDir); err != nil {
+			registerStepRunResults(models.WorkflowStepModel{}, stepInfoPtr, stepIdxPtr,
+				"", models.StepRunStatusCodeFailed, 1, err, isLastStep, true)
+			continue
 		}
 
 		if mergedStep.SupportURL != nil {
@@ -738,7 +1044,7 @@ func activateAndRunSteps(workflow models.WorkflowModel, defaultStepLibSource str
 
 		//
 		// Run step
-		bitrise.PrintRunningStepHeader(stepInfoPtr, mergedStep, idx)
+		bitrise.PrintRunningStepHeader(stepInfoPtr, mergedStep.StepModel, idx)
 		if mergedStep.RunIf != nil && *mergedStep.RunIf != "" {
 			outStr, err := tools.EnvmanJSONPrint(configs.InputEnvstorePath)
 			if err != nil {
@@ -767,26 +1073,79 @@ func activateAndRunSteps(workflow models.WorkflowModel, defaultStepLibSource str
 			}
 		}
 
-		isAlwaysRun := stepmanModels.DefaultIsAlwaysRun
-		if mergedStep.IsAlwaysRun != nil {
-			isAlwaysRun = *mergedStep.IsAlwaysRun
-		} else {
+		if mergedStep.IsAlwaysRun == nil {
 			log.Warn("Step (%s) mergedStep.IsAlwaysRun is nil, should not!", stepIDData.IDorURI)
 		}
 
-		if buildRunResults.IsBuildFailed() && !isAlwaysRun {
+		if !isStepRunWithBuildStatus(mergedStep, buildRunResults.IsBuildFailed()) {
 			registerStepRunResults(mergedStep, stepInfoPtr, stepIdxPtr,
 				*mergedStep.RunIf, models.StepRunStatusCodeSkipped, 0, err, isLastStep, false)
+		} else if timeoutErr := reportExpiredTimeoutOfStep(mergedStep, workflowTimeout); timeoutErr != nil {
+			registerStepRunResults(mergedStep, stepInfoPtr, stepIdxPtr,
+				*mergedStep.RunIf, models.StepRunStatusCodeFailed, 1, timeoutErr, isLastStep, false)
 		} else {
-			exit, outEnvironments, err := runStep(mergedStep, stepIDData, stepDir, *environments, buildRunResults)
+			plugins.TriggerStepWillStart(stepInfoPtr, mergedStep.StepModel, idx)
+
+			timeoutState := timeoutStateAtStepStart(workflowTimeout)
+			exit, outEnvironments, err := runStep(mergedStep, stepIDData, stepDir, workflow.WorkingDir, *environments, buildRunResults)
+			if err == nil {
+				err = checkStepOutputContract(mergedStep.StepModel, workflowStep.StepModel, outEnvironments)
+				if err != nil {
+					exit = 1
+				}
+			}
 
 			if err := tools.EnvmanClear(configs.OutputEnvstorePath); err != nil {
 				log.Errorf("Failed to clear output envstore, error: %s", err)
 			}
 
+			isolateEnvs := models.DefaultIsolateEnvs
+			if mergedStep.IsolateEnvs != nil {
+				isolateEnvs = *mergedStep.IsolateEnvs
+			}
+			if isolateEnvs {
+				exportedEnvironments, filterErr := bitrise.FilterExportedEnvironments(outEnvironments, mergedStep.Exports)
+				if filterErr != nil {
+					log.Errorf("Failed to filter the exported envs of the step, error: %s", filterErr)
+				}
+				log.Debugf("Step envs are isolated, exporting %d of %d envs", len(exportedEnvironments), len(outEnvironments))
+				outEnvironments = exportedEnvironments
+			}
+
+			if htmlReport != nil {
+				htmlReport.SetStepEnvDiff(*environments, outEnvironments)
+			}
+
 			*environments = append(*environments, outEnvironments...)
+			var timeoutErr error
+			if err != nil {
+				timeoutErr = reportTimeoutExpiredDuringStep(timeoutState, workflowTimeout)
+			}
+			isTimedOut := timeoutErr != nil
+			if isTimedOut {
+				// killed by the build or workflow timeout
+				err = timeoutErr
+			} else if err != nil && buildTimeout.IsGraceExpired() {
+				// killed at the end of the build timeout's grace period
+				err = fmt.Errorf("%s: %s", bitrise.ErrBuildTimeoutGraceExpired, err)
+			} else if err != nil && bitrise.IsAborted() && !isOnAbortWorkflowRunning {
+				// killed by the abort, not crashed
+				err = fmt.Errorf("%s: %s", bitrise.ErrBuildAborted, err)
+			} else if signal, isCrash := bitrise.StepCrashSignal(exit, err); isCrash {
+				crashErr := fmt.Errorf("Step crashed, killed by signal: %s", bitrise.CrashSignalName(signal))
+				if pth, saveErr := bitrise.SaveStepCrashDiagnostics(signal, stepInfoPtr.ID, idx, stepStartTime); saveErr != nil {
+					log.Warnf("Failed to save step crash diagnostics, error: %s", saveErr)
+				} else {
+					crashErr = fmt.Errorf("%s, diagnostics saved to: %s", crashErr, pth)
+				}
+				err = crashErr
+			}
+			if stepEnvHistory != nil && !bitrise.IsAborted() {
+				recordStepEnvHistory(workflow.Title, stepInfoPtr, mergedStep.StepModel, stepIDData, err == nil)
+			}
+
 			if err != nil {
-				if *mergedStep.IsSkippable {
+				if *mergedStep.IsSkippable && !isTimedOut {
 					registerStepRunResults(mergedStep, stepInfoPtr, stepIdxPtr,
 						*mergedStep.RunIf, models.StepRunStatusCodeFailedSkippable, exit, err, isLastStep, false)
 				} else {
@@ -803,25 +1162,278 @@ func activateAndRunSteps(workflow models.WorkflowModel, defaultStepLibSource str
 	return buildRunResults
 }
 
-func runWorkflow(workflow models.WorkflowModel, steplibSource string, buildRunResults models.BuildRunResultsModel, environments *[]envmanModels.EnvironmentItemModel, isLastWorkflow bool) models.BuildRunResultsModel {
-	bitrise.PrintRunningWorkflow(workflow.Title)
+// htmlReport collects the step logs and env changes for the HTML run report, if requested
+var htmlReport *bitrise.HTMLReportModel
+
+// artifactTracker tracks the files placed into the deploy dir by the steps
+var artifactTracker *bitrise.ArtifactTrackerModel
+
+// stepProfile collects the timing profiles of the steps, if requested (see: --profile)
+var stepProfile *bitrise.StepProfileModel
+
+// stepProfileReportLimit is the number of steps listed in the slowest steps report
+const stepProfileReportLimit = 10
+
+// resourceGovernor pauses the run between steps, if the host is under resource pressure
+var resourceGovernor *bitrise.ResourceGovernorModel
+
+// carriedOverEnvironments are added to the envs of the run, after the app envs:
+// the envs of the previous workflow, if multiple workflows are run with shared envs
+var carriedOverEnvironments = []envmanModels.EnvironmentItemModel{}
+
+// lastRunEnvironments are the envs at the end of the last workflow run
+var lastRunEnvironments = []envmanModels.EnvironmentItemModel{}
+
+// workflowEnvFileEnvironments are the envs of the env_files of the workflows, added before the workflows' envs
+var workflowEnvFileEnvironments = map[string][]envmanModels.EnvironmentItemModel{}
+
+// loadEnvFiles loads the env_files of the app, and of the workflows run by the given workflow,
+// and returns the envs of the app env files and the envs of all the secret env files.
+func loadEnvFiles(bitriseConfig models.BitriseDataModel, workflowToRunID string) ([]envmanModels.EnvironmentItemModel, []envmanModels.EnvironmentItemModel, error) {
+	appEnvironments, secretEnvironments, err := bitrise.LoadEnvFiles(bitriseConfig.App.EnvFiles, configs.CurrentDir)
+	if err != nil {
+		return []envmanModels.EnvironmentItemModel{}, []envmanModels.EnvironmentItemModel{}, fmt.Errorf("app: %s", err)
+	}
+
+	workflowEnvFileEnvironments = map[string][]envmanModels.EnvironmentItemModel{}
+	for _, workflowID := range models.WorkflowChain(bitriseConfig, workflowToRunID) {
+		environments, workflowSecretEnvironments, err := bitrise.LoadEnvFiles(bitriseConfig.Workflows[workflowID].EnvFiles, configs.CurrentDir)
+		if err != nil {
+			return []envmanModels.EnvironmentItemModel{}, []envmanModels.EnvironmentItemModel{}, fmt.Errorf("workflow (%s): %s", workflowID, err)
+		}
+		workflowEnvFileEnvironments[workflowID] = environments
+		secretEnvironments = append(secretEnvironments, workflowSecretEnvironments...)
+	}
+
+	return appEnvironments, secretEnvironments, nil
+}
+
+// checkRequiredSecrets checks that the secrets, required by the workflows and steps of the run, are provided:
+// by the secrets (inventory, secret env files), or by the environment of the process, with a non empty value.
+func checkRequiredSecrets(bitriseConfig models.BitriseDataModel, workflowToRunID string, secretEnvironments []envmanModels.EnvironmentItemModel) error {
+	providedSecrets := map[string]bool{}
+	for _, secret := range secretEnvironments {
+		if key, value, err := secret.GetKeyValuePair(); err == nil && value != "" {
+			providedSecrets[key] = true
+		}
+	}
+
+	return bitriseConfig.CheckRequiredSecrets(workflowToRunID, func(key string) bool {
+		return providedSecrets[key] || os.Getenv(key) != ""
+	})
+}
+
+// stepEnvHistory stores the inputs and envs of the successful step runs, to diff the failed runs against
+var stepEnvHistory *bitrise.StepEnvHistoryModel
+
+// registerStepAuditMode sets the step audit mode of the run, the organization policy's audit mode is the minimum
+func registerStepAuditMode(mode string) error {
+	mode, err := bitrise.ParseStepAuditMode(mode)
+	if err != nil {
+		return err
+	}
+	configs.StepAuditMode = bitrise.StricterStepAuditMode(mode, bitrise.ActivePolicy().StepAudit)
+	return nil
+}
+
+// registerDependencyManager sets the package manager of the step dependencies (see: bitrise.NewDependencyProvider)
+func registerDependencyManager(manager string) error {
+	manager, err := bitrise.ParseDependencyManager(manager)
+	if err != nil {
+		return err
+	}
+	configs.DependencyManager = manager
+	return nil
+}
+
+func registerArtifactUpload(uploadURL string, retentionDays int) error {
+	if uploadURL != "" {
+		if err := bitrise.ValidateArtifactUploadURL(uploadURL); err != nil {
+			return err
+		}
+	}
+	if retentionDays < 0 {
+		return fmt.Errorf("invalid artifact retention days (%d), should not be negative", retentionDays)
+	}
+	configs.ArtifactUploadURL = uploadURL
+	configs.ArtifactRetentionDays = retentionDays
+	return nil
+}
+
+// uploadArtifacts uploads the content of the deploy dir, if an upload URL is set (see: --artifact-upload)
+func uploadArtifacts(workflowID string, buildRunResults models.BuildRunResultsModel, artifacts []bitrise.ArtifactModel) error {
+	if configs.ArtifactUploadURL == "" || artifactTracker == nil {
+		return nil
+	}
+
+	manifest, err := bitrise.NewArtifactUploadManifest(workflowID, bitrise.BuildStatus(buildRunResults), artifactTracker.DeployDir,
+		artifacts, configs.ArtifactRetentionDays, time.Now())
+	if err != nil {
+		return fmt.Errorf("Failed to list the artifacts to upload, error: %s", err)
+	}
+
+	log.Infof("Uploading %d artifact(s) to: %s", len(manifest.Artifacts), configs.ArtifactUploadURL)
+	if err := bitrise.UploadArtifacts(configs.ArtifactUploadURL, artifactTracker.DeployDir, manifest); err != nil {
+		return fmt.Errorf("Failed to upload artifacts, error: %s", err)
+	}
+	log.Infof("Artifacts uploaded (%s)", manifest.UploadID)
+	return nil
+}
+
+// auditStep audits the activated step (see: bitrise.AuditStep), the problems are printed as warnings,
+// or the returned error contains them, in fail mode.
+func auditStep(compositeStepIDStr, stepYMLPth, stepDir string) error {
+	specStep, err := bitrise.ReadSpecStep(stepYMLPth)
+	if err != nil {
+		return err
+	}
+	problems, err := bitrise.AuditStep(specStep, stepDir)
+	if err != nil {
+		return fmt.Errorf("Failed to audit step (%s), error: %s", compositeStepIDStr, err)
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+
+	if configs.StepAuditMode == bitrise.StepAuditModeFail {
+		return fmt.Errorf("Step (%s) audit failed:\n- %s", compositeStepIDStr, strings.Join(problems, "\n- "))
+	}
+	for _, problem := range problems {
+		log.Warnf("Step (%s) audit: %s", compositeStepIDStr, problem)
+	}
+	return nil
+}
+
+// checkStepOutputContract returns an error, if the step didn't export one of its required outputs
+func checkStepOutputContract(step, workflowStep stepmanModels.StepModel, outEnvironments []envmanModels.EnvironmentItemModel) error {
+	expectedOutputs, err := bitrise.ExpectedStepOutputs(step, workflowStep)
+	if err != nil {
+		return fmt.Errorf("Failed to get the required outputs of the step, error: %s", err)
+	}
+
+	missingOutputs, err := bitrise.MissingStepOutputs(expectedOutputs, outEnvironments)
+	if err != nil {
+		return fmt.Errorf("Failed to check the outputs of the step, error: %s", err)
+	}
+	if len(missingOutputs) > 0 {
+		return fmt.Errorf("Step finished without exporting its required outputs: %s", strings.Join(missingOutputs, ", "))
+	}
+	return nil
+}
+
+// checkStepInputs validates the step inputs of the workflow (and of its before and after run workflows)
+// against the steps' definitions, before any of the steps runs.
+func checkStepInputs(bitriseConfig models.BitriseDataModel, workflowToRunID string) error {
+	issues, warnings, err := bitrise.ValidateWorkflowStepInputs(bitriseConfig, models.WorkflowChain(bitriseConfig, workflowToRunID))
+	if err != nil {
+		return fmt.Errorf("Failed to validate step inputs, error: %s", err)
+	}
+	for _, warning := range warnings {
+		log.Warn(warning)
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("Invalid step inputs:\n- %s", strings.Join(issues, "\n- "))
+	}
+	return nil
+}
+
+// recordStepEnvHistory stores the resolved inputs and envs of a successful step run,
+// or prints the diff against the step's last successful run, if the step failed.
+func recordStepEnvHistory(workflowID string, stepInfo stepmanModels.StepInfoModel, step stepmanModels.StepModel, stepIDData models.StepIDData, isSuccess bool) {
+	outStr, err := tools.EnvmanJSONPrint(configs.InputEnvstorePath)
+	if err != nil {
+		log.Debugf("Failed to read the step envs, error: %s", err)
+		return
+	}
+	envList, err := envmanModels.NewEnvJSONList(outStr)
+	if err != nil {
+		log.Debugf("Failed to parse the step envs, error: %s", err)
+		return
+	}
+
+	inputKeys := []string{}
+	for _, input := range step.Inputs {
+		if key, _, err := input.GetKeyValuePair(); err == nil {
+			inputKeys = append(inputKeys, key)
+		}
+	}
+
+	key := bitrise.StepEnvHistoryKey(configs.CurrentDir, workflowID, stepInfo.Title, stepIDData.IDorURI)
+	snapshot := stepEnvHistory.NewSnapshot(stepIDData.IDorURI, stepIDData.Version, inputKeys, envList)
+
+	if isSuccess {
+		if err := stepEnvHistory.Save(key, snapshot); err != nil {
+			log.Debugf("Failed to save the step env history, error: %s", err)
+		}
+		return
+	}
+
+	if lastSuccessful, found := stepEnvHistory.LastSuccessful(key); found {
+		log.Warn(bitrise.FormattedStepEnvDiff(lastSuccessful, snapshot))
+	}
+}
+
+func runWorkflow(runContext *workflowRunContext, workflowID string, workflow models.WorkflowModel, steplibSource string, buildRunResults models.BuildRunResultsModel, environments *[]envmanModels.EnvironmentItemModel, isLastWorkflow bool) models.BuildRunResultsModel {
+	if !configs.IsQuietMode {
+		bitrise.PrintRunningWorkflowWithMeta(workflow.Title, workflow.Owner, workflow.Tags)
+	}
 
 	*environments = append(*environments, workflow.Environments...)
-	return activateAndRunSteps(workflow, steplibSource, buildRunResults, environments, isLastWorkflow)
+	return activateAndRunSteps(runContext, workflowID, workflow, steplibSource, buildRunResults, environments, isLastWorkflow)
 }
 
-func activateAndRunWorkflow(workflowID string, workflow models.WorkflowModel, bitriseConfig models.BitriseDataModel, buildRunResults models.BuildRunResultsModel, environments *[]envmanModels.EnvironmentItemModel, lastWorkflowID string) (models.BuildRunResultsModel, error) {
+// isWorkflowRunItemEnabled evaluates the run_if condition of a before_run / after_run item,
+// with the environments available at the time the workflow would start.
+func isWorkflowRunItemEnabled(item models.WorkflowRunItemModel, buildRunResults models.BuildRunResultsModel, environments *[]envmanModels.EnvironmentItemModel) (bool, error) {
+	if item.RunIf == "" {
+		return true, nil
+	}
+
+	if err := tools.EnvmanInitAtPath(configs.InputEnvstorePath); err != nil {
+		return false, fmt.Errorf("Failed to init envman for workflow (%s) run_if, error: %s", item.WorkflowID, err)
+	}
+	if err := bitrise.ExportEnvironmentsList(*environments); err != nil {
+		return false, fmt.Errorf("Failed to export envs for workflow (%s) run_if, error: %s", item.WorkflowID, err)
+	}
+
+	outStr, err := tools.EnvmanJSONPrint(configs.InputEnvstorePath)
+	if err != nil {
+		return false, fmt.Errorf("EnvmanJSONPrint failed, err: %s", err)
+	}
+
+	envList, err := envmanModels.NewEnvJSONList(outStr)
+	if err != nil {
+		return false, fmt.Errorf("CreateFromJSON failed, err: %s", err)
+	}
+
+	isRun, err := bitrise.EvaluateTemplateToBool(item.RunIf, configs.IsCIMode, configs.IsPullRequestMode, buildRunResults, envList)
+	if err != nil {
+		return false, fmt.Errorf("Failed to evaluate workflow (%s) run_if expression, error: %s", item.WorkflowID, err)
+	}
+	if !isRun {
+		log.Infof("Skipping workflow (%s), its run_if expression evaluated to false: %s", item.WorkflowID, item.RunIf)
+	}
+	return isRun, nil
+}
+
+func activateAndRunWorkflow(runContext *workflowRunContext, workflowID string, workflow models.WorkflowModel, bitriseConfig models.BitriseDataModel, buildRunResults models.BuildRunResultsModel, environments *[]envmanModels.EnvironmentItemModel, lastWorkflowID string) (models.BuildRunResultsModel, error) {
 	var err error
 	// Run these workflows before running the target workflow
-	for _, beforeWorkflowID := range workflow.BeforeRun {
+	for _, beforeWorkflowItem := range workflow.BeforeRun {
+		beforeWorkflowID := beforeWorkflowItem.WorkflowID
 		beforeWorkflow, exist := bitriseConfig.Workflows[beforeWorkflowID]
 		if !exist {
 			return buildRunResults, fmt.Errorf("Specified Workflow (%s) does not exist!", beforeWorkflowID)
 		}
+		if isRun, err := isWorkflowRunItemEnabled(beforeWorkflowItem, buildRunResults, environments); err != nil {
+			return buildRunResults, err
+		} else if !isRun {
+			continue
+		}
 		if beforeWorkflow.Title == "" {
 			beforeWorkflow.Title = beforeWorkflowID
 		}
-		buildRunResults, err = activateAndRunWorkflow(beforeWorkflowID, beforeWorkflow, bitriseConfig, buildRunResults, environments, lastWorkflowID)
+		buildRunResults, err = activateAndRunWorkflow(runContext, beforeWorkflowID, beforeWorkflow, bitriseConfig, buildRunResults, environments, lastWorkflowID)
 		if err != nil {
 			return buildRunResults, err
 		}
@@ -829,18 +1441,26 @@ func activateAndRunWorkflow(workflowID string, workflow models.WorkflowModel, bi
 
 	// Run the target workflow
 	isLastWorkflow := (workflowID == lastWorkflowID)
-	buildRunResults = runWorkflow(workflow, bitriseConfig.DefaultStepLibSource, buildRunResults, environments, isLastWorkflow)
+	*environments = append(*environments, workflowEnvFileEnvironments[workflowID]...)
+	workflow.WorkingDir = bitrise.WorkflowWorkingDir(bitriseConfig, workflow)
+	buildRunResults = runWorkflow(runContext, workflowID, workflow, bitriseConfig.DefaultStepLibSource, buildRunResults, environments, isLastWorkflow)
 
 	// Run these workflows after running the target workflow
-	for _, afterWorkflowID := range workflow.AfterRun {
+	for _, afterWorkflowItem := range workflow.AfterRun {
+		afterWorkflowID := afterWorkflowItem.WorkflowID
 		afterWorkflow, exist := bitriseConfig.Workflows[afterWorkflowID]
 		if !exist {
 			return buildRunResults, fmt.Errorf("Specified Workflow (%s) does not exist!", afterWorkflowID)
 		}
+		if isRun, err := isWorkflowRunItemEnabled(afterWorkflowItem, buildRunResults, environments); err != nil {
+			return buildRunResults, err
+		} else if !isRun {
+			continue
+		}
 		if afterWorkflow.Title == "" {
 			afterWorkflow.Title = afterWorkflowID
 		}
-		buildRunResults, err = activateAndRunWorkflow(afterWorkflowID, afterWorkflow, bitriseConfig, buildRunResults, environments, lastWorkflowID)
+		buildRunResults, err = activateAndRunWorkflow(runContext, afterWorkflowID, afterWorkflow, bitriseConfig, buildRunResults, environments, lastWorkflowID)
 		if err != nil {
 			return buildRunResults, err
 		}
@@ -856,7 +1476,7 @@ func lastWorkflowIDInConfig(workflowToRunID string, bitriseConfig models.Bitrise
 	}
 
 	if len(workflowToRun.AfterRun) > 0 {
-		lastAfterID := workflowToRun.AfterRun[len(workflowToRun.AfterRun)-1]
+		lastAfterID := workflowToRun.AfterRun[len(workflowToRun.AfterRun)-1].WorkflowID
 		wfID, err := lastWorkflowIDInConfig(lastAfterID, bitriseConfig)
 		if err != nil {
 			return "", err
@@ -872,16 +1492,57 @@ func runWorkflowWithConfiguration(
 	workflowToRunID string,
 	bitriseConfig models.BitriseDataModel,
 	secretEnvironments []envmanModels.EnvironmentItemModel) (models.BuildRunResultsModel, error) {
+	return runWorkflowWithConfigurationAndRerun(startTime, workflowToRunID, bitriseConfig, secretEnvironments, nil)
+}
+
+// runWorkflowWithConfigurationAndRerun runs the workflow, rerunLastRun is the last run of the workflow,
+// if only its failed steps have to be run (see: --rerun-failed), nil otherwise.
+func runWorkflowWithConfigurationAndRerun(
+	startTime time.Time,
+	workflowToRunID string,
+	bitriseConfig models.BitriseDataModel,
+	secretEnvironments []envmanModels.EnvironmentItemModel,
+	rerunLastRun *bitrise.LastRunModel) (models.BuildRunResultsModel, error) {
+
+	bitriseConfig, err := effectiveRunConfig(bitriseConfig)
+	if err != nil {
+		return models.BuildRunResultsModel{}, exitcode.New(exitcode.ConfigInvalid, err)
+	}
+
+	if err := bitrise.ActivePolicy().CheckRequiredHooks(); err != nil {
+		return models.BuildRunResultsModel{}, exitcode.Errorf(exitcode.ConfigInvalid, "Organization policy check failed, error: %s", err)
+	}
 
 	workflowToRun, exist := bitriseConfig.Workflows[workflowToRunID]
 	if !exist {
-		return models.BuildRunResultsModel{}, fmt.Errorf("Specified Workflow (%s) does not exist!", workflowToRunID)
+		return models.BuildRunResultsModel{}, exitcode.Errorf(exitcode.Usage, "Specified Workflow (%s) does not exist!", workflowToRunID)
 	}
 
 	if workflowToRun.Title == "" {
 		workflowToRun.Title = workflowToRunID
 	}
 
+	if err := checkStepInputs(bitriseConfig, workflowToRunID); err != nil {
+		return models.BuildRunResultsModel{}, exitcode.New(exitcode.ConfigInvalid, err)
+	}
+
+	// the secret references are resolved only right before the run,
+	// the other commands (validate, envs, trigger-check ...) don't need the secret backends
+	secretEnvironments, err = bitrise.ResolveSecretReferences(secretEnvironments, bitrise.SecretsProviders())
+	if err != nil {
+		return models.BuildRunResultsModel{}, exitcode.Errorf(exitcode.ConfigInvalid, "Failed to resolve secret references, error: %s", err)
+	}
+
+	plugins.TriggerConfigDidLoad(bitriseConfig)
+
+	if workflowToRun.IsPreventSleep() {
+		releaseSleepPrevention, err := bitrise.PreventSleep()
+		if err != nil {
+			log.Warnf("Failed to prevent the host from sleeping, error: %s", err)
+		}
+		defer releaseSleepPrevention()
+	}
+
 	// Envman setup
 	if err := os.Setenv(configs.EnvstorePathEnvKey, configs.OutputEnvstorePath); err != nil {
 		return models.BuildRunResultsModel{}, fmt.Errorf("Failed to add env, err: %s", err)
@@ -892,11 +1553,64 @@ func runWorkflowWithConfiguration(
 	}
 
 	if err := tools.EnvmanInit(); err != nil {
-		return models.BuildRunResultsModel{}, errors.New("Failed to run envman init")
+		// on a full disk every step will be skipped, but the summary still has to be printed
+		if !bitrise.CheckDiskFull(err) {
+			return models.BuildRunResultsModel{}, fmt.Errorf("Failed to run envman init, error: %s", err)
+		}
+	}
+
+	appEnvFileEnvironments, envFileSecretEnvironments, err := loadEnvFiles(bitriseConfig, workflowToRunID)
+	if err != nil {
+		return models.BuildRunResultsModel{}, exitcode.Errorf(exitcode.ConfigInvalid, "Failed to load env files, error: %s", err)
+	}
+	redactedEnvironments := append(append([]envmanModels.EnvironmentItemModel{}, secretEnvironments...), envFileSecretEnvironments...)
+
+	if err := checkRequiredSecrets(bitriseConfig, workflowToRunID, redactedEnvironments); err != nil {
+		return models.BuildRunResultsModel{}, exitcode.New(exitcode.ConfigInvalid, err)
+	}
+
+	runContext := newWorkflowRunContext(rerunLastRun, redactedEnvironments)
+
+	if configs.HTMLReportPath != "" {
+		htmlReport = bitrise.NewHTMLReport(redactedEnvironments)
+	}
+
+	if configs.IsStepProfileMode || configs.StepProfilePath != "" {
+		stepProfile = bitrise.NewStepProfile()
+	}
+
+	if logStream != nil {
+		logStream.SetSecrets(redactedEnvironments)
+		logStream.Publish(bitrise.LogStreamEventRunStarted, map[string]interface{}{
+			"workflow": workflowToRunID,
+		})
+	}
+
+	if !configs.IsStepEnvHistoryDisabled() {
+		stepEnvHistory = bitrise.NewStepEnvHistory(configs.GetBitriseStepEnvHistoryDirPath(), redactedEnvironments)
+	}
+
+	if testResultDir := os.Getenv(configs.BitriseTestResultDirEnvKey); testResultDir != "" {
+		if err := os.MkdirAll(testResultDir, 0777); err != nil {
+			log.Warnf("Failed to create test result dir (%s), error: %s", testResultDir, err)
+		}
+	}
+
+	artifactTracker = nil
+	if deployDir := os.Getenv(configs.BitriseDeployDirEnvKey); deployDir != "" {
+		if tracker, err := bitrise.NewArtifactTracker(deployDir); err != nil {
+			log.Warnf("Failed to track the artifacts of the run, error: %s", err)
+		} else {
+			artifactTracker = tracker
+		}
 	}
 
 	// App level environment
-	environments := append(secretEnvironments, bitriseConfig.App.Environments...)
+	environments := append(append([]envmanModels.EnvironmentItemModel{}, secretEnvironments...), appEnvFileEnvironments...)
+	environments = append(environments, bitriseConfig.App.Environments...)
+	environments = append(environments, carriedOverEnvironments...)
+	environments = append(environments, cliEnvironments...)
+	environments = append(environments, workflowInputEnvironments...)
 
 	if err := os.Setenv("BITRISE_TRIGGERED_WORKFLOW_ID", workflowToRunID); err != nil {
 		return models.BuildRunResultsModel{}, fmt.Errorf("Failed to set BITRISE_TRIGGERED_WORKFLOW_ID env: %s", err)
@@ -905,11 +1619,15 @@ func runWorkflowWithConfiguration(
 		return models.BuildRunResultsModel{}, fmt.Errorf("Failed to set BITRISE_TRIGGERED_WORKFLOW_TITLE env: %s", err)
 	}
 
+	if err := bitrise.ValidateWorkingDirs(bitriseConfig, workflowToRunID, environments, workflowEnvFileEnvironments); err != nil {
+		return models.BuildRunResultsModel{}, exitcode.New(exitcode.ConfigInvalid, err)
+	}
+
 	environments = append(environments, workflowToRun.Environments...)
 
 	lastWorkflowID, err := lastWorkflowIDInConfig(workflowToRunID, bitriseConfig)
 	if err != nil {
-		return models.BuildRunResultsModel{}, fmt.Errorf("Failed to get last workflow id: %s", err)
+		return models.BuildRunResultsModel{}, exitcode.Errorf(exitcode.ConfigInvalid, "Failed to get last workflow id: %s", err)
 	}
 
 	// Bootstrap Toolkits
@@ -926,13 +1644,47 @@ func runWorkflowWithConfiguration(
 		}
 	}
 
+	if err := initStepLocks(); err != nil {
+		return models.BuildRunResultsModel{}, exitcode.New(exitcode.ConfigInvalid, err)
+	}
+	stepLibSources = bitriseConfig.StepLibSearchList()
+
+	if err := checkLockedSteps(bitriseConfig, workflowToRunID); err != nil {
+		return models.BuildRunResultsModel{}, exitcode.New(exitcode.ConfigInvalid, err)
+	}
+
+	resourceGovernor = nil
+	if governor, enabled, err := bitrise.NewResourceGovernorFromEnv(); err != nil {
+		log.Warnf("Failed to configure the resource governor, error: %s", err)
+	} else if enabled {
+		resourceGovernor = &governor
+	}
+
+	if workerCount, err := bitrise.StepPreActivationWorkersFromEnv(); err != nil {
+		log.Warnf("Failed to configure the step pre-activation, error: %s", err)
+	} else if workerCount > 0 {
+		if stepIDDatas, err := bitrise.CollectWorkflowSteps(bitriseConfig, workflowToRunID); err != nil {
+			log.Warnf("Failed to collect the steps to pre-activate, error: %s", err)
+		} else {
+			bitrise.PreActivateSteps(stepIDDatas, workerCount)
+		}
+		defer func() {
+			if err := bitrise.CleanupPreActivatedSteps(); err != nil {
+				log.Warnf("Failed to cleanup the pre-activated steps, error: %s", err)
+			}
+		}()
+	}
+
 	//
 	buildRunResults := models.BuildRunResultsModel{
 		StartTime:      startTime,
 		StepmanUpdates: map[string]int{},
 	}
 
-	buildRunResults, err = activateAndRunWorkflow(workflowToRunID, workflowToRun, bitriseConfig, buildRunResults, &environments, lastWorkflowID)
+	stopAbortHandler := registerAbortHandler()
+	defer stopAbortHandler()
+
+	buildRunResults, err = activateAndRunWorkflow(runContext, workflowToRunID, workflowToRun, bitriseConfig, buildRunResults, &environments, lastWorkflowID)
 	if err != nil {
 		return buildRunResults, errors.New("[BITRISE_CLI] - Failed to activate and run workflow " + workflowToRunID)
 	}
@@ -940,10 +1692,92 @@ func runWorkflowWithConfiguration(
 	// Build finished
 	bitrise.PrintSummary(buildRunResults)
 
+	artifacts := []bitrise.ArtifactModel{}
+	if artifactTracker != nil {
+		artifacts = artifactTracker.Artifacts()
+		if len(artifacts) > 0 {
+			fmt.Println(bitrise.ArtifactsReport(artifactTracker.DeployDir, artifacts))
+			fmt.Println()
+		}
+	}
+
+	var testResults *bitrise.TestResultsSummaryModel
+	if testResultDir := os.Getenv(configs.BitriseTestResultDirEnvKey); testResultDir != "" {
+		if testResults, err = bitrise.CollectTestResults(testResultDir); err != nil {
+			log.Warnf("Failed to collect test results, error: %s", err)
+		} else if testResults != nil {
+			fmt.Println(bitrise.TestResultsReport(*testResults))
+			fmt.Println()
+		}
+	}
+
+	if bitrise.IsDiskFull() {
+		log.Error(output.Red("The build ran out of disk space, some of the steps were not started."))
+	}
+
+	if buildTimeout.IsGraceExpired() {
+		log.Error(output.Red("The build timed out, and the grace period of the is_always_run steps is exceeded, some of the steps were not started."))
+	}
+
+	if bitrise.IsAborted() {
+		buildRunResults.IsAborted = true
+		log.Error(output.Red("The build was aborted."))
+
+		runOnAbortWorkflow(bitriseConfig, environments)
+	}
+
+	summary := bitrise.NewBuildSummary(workflowToRunID, buildRunResults, time.Now().Sub(startTime))
+	summary.Artifacts = artifacts
+	summary.TestResults = testResults
+	if configs.BuildSummaryPath != "" {
+		if err := bitrise.WriteBuildSummary(configs.BuildSummaryPath, summary); err != nil {
+			log.Warnf("Failed to write build summary, error: %s", err)
+		} else {
+			log.Infof("Build summary written to: %s", configs.BuildSummaryPath)
+		}
+	}
+	runContext.writeLastRun(workflowToRunID, summary)
+
+	// the rest of the reports are written even if the upload fails, the run fails with the upload's error at the end
+	uploadErr := uploadArtifacts(workflowToRunID, buildRunResults, artifacts)
+	if uploadErr != nil {
+		log.Error(uploadErr)
+	}
+
+	if htmlReport != nil {
+		if err := htmlReport.WriteHTMLReport(configs.HTMLReportPath, workflowToRunID, buildRunResults, time.Now().Sub(startTime)); err != nil {
+			log.Warnf("Failed to write HTML report, error: %s", err)
+		} else if !bitrise.IsDiskFull() {
+			log.Infof("HTML report written to: %s", configs.HTMLReportPath)
+		}
+	}
+
+	if stepProfile != nil {
+		if configs.IsStepProfileMode {
+			fmt.Println()
+			fmt.Println(bitrise.StepProfileReport(stepProfile, stepProfileReportLimit))
+			fmt.Println()
+		}
+		if configs.StepProfilePath != "" {
+			if err := bitrise.WriteStepProfile(configs.StepProfilePath, stepProfile); err != nil {
+				log.Warnf("Failed to write step profile, error: %s", err)
+			} else if !bitrise.IsDiskFull() {
+				log.Infof("Step profile written to: %s", configs.StepProfilePath)
+			}
+		}
+	}
+
+	lastRunEnvironments = environments
+
+	saveResolvedSteps()
+
 	// Trigger WorkflowRunDidFinish
 	if err := plugins.TriggerEvent(plugins.DidFinishRun, buildRunResults); err != nil {
 		log.Warnf("Failed to trigger WorkflowRunDidFinish, error: %s", err)
 	}
 
+	if uploadErr != nil {
+		return buildRunResults, uploadErr
+	}
 	return buildRunResults, nil
 }
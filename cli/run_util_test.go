@@ -3,13 +3,18 @@ package cli
 import (
 	"encoding/base64"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/bitrise-io/bitrise/bitrise"
 	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
 	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
 	"github.com/bitrise-io/go-utils/pointers"
+	stepmanModels "github.com/bitrise-io/stepman/models"
 	"github.com/stretchr/testify/require"
 )
 
@@ -411,6 +416,55 @@ workflows:
 	workflow, found := config.Workflows["target"]
 	require.Equal(t, true, found)
 	require.Equal(t, "target", workflow.Title)
+
+	t.Log("JSON config")
+	{
+		configJSONStr := `{"format_version": "0.9.10", "workflows": {"target": {"title": "target"}}}`
+		configBase64Str := base64.StdEncoding.EncodeToString([]byte(configJSONStr))
+
+		config, warnings, err := GetBitriseConfigFromBase64Data(configBase64Str)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(warnings))
+		require.Equal(t, "target", config.Workflows["target"].Title)
+	}
+}
+
+func TestGetBitriseConfigFilePath(t *testing.T) {
+	currentDir := configs.CurrentDir
+	defer func() {
+		configs.CurrentDir = currentDir
+	}()
+
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("config_path")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	configs.CurrentDir = tmpDir
+
+	t.Log("no config")
+	{
+		_, err := GetBitriseConfigFilePath("")
+		require.EqualError(t, err, "No workflow yml found")
+	}
+
+	t.Log("bitrise.json")
+	{
+		require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "bitrise.json"), "{}"))
+
+		pth, err := GetBitriseConfigFilePath("")
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(tmpDir, "bitrise.json"), pth)
+	}
+
+	t.Log("bitrise.yml is preferred")
+	{
+		require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "bitrise.yml"), ""))
+
+		pth, err := GetBitriseConfigFilePath("")
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(tmpDir, "bitrise.yml"), pth)
+	}
 }
 
 func TestGetInventoryFromBase64Data(t *testing.T) {
@@ -461,3 +515,29 @@ workflows:
 	results, err := runWorkflowWithConfiguration(time.Now(), "target", config, []envmanModels.EnvironmentItemModel{})
 	require.Equal(t, 1, len(results.StepmanUpdates))
 }
+
+func TestIsStepRunWithBuildStatus(t *testing.T) {
+	t.Log("default step")
+	{
+		step := models.WorkflowStepModel{}
+		require.Equal(t, true, isStepRunWithBuildStatus(step, false))
+		require.Equal(t, false, isStepRunWithBuildStatus(step, true))
+	}
+
+	t.Log("is_always_run step")
+	{
+		step := models.WorkflowStepModel{StepModel: stepmanModels.StepModel{IsAlwaysRun: pointers.NewBoolPtr(true)}}
+		require.Equal(t, true, isStepRunWithBuildStatus(step, false))
+		require.Equal(t, true, isStepRunWithBuildStatus(step, true))
+	}
+
+	t.Log("run_on_failure_only step")
+	{
+		step := models.WorkflowStepModel{RunOnFailureOnly: pointers.NewBoolPtr(true)}
+		require.Equal(t, false, isStepRunWithBuildStatus(step, false))
+		require.Equal(t, true, isStepRunWithBuildStatus(step, true))
+
+		step.IsAlwaysRun = pointers.NewBoolPtr(false)
+		require.Equal(t, true, isStepRunWithBuildStatus(step, true))
+	}
+}
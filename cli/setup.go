@@ -5,12 +5,19 @@ import (
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/bitrise-io/bitrise/bitrise"
-	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/exitcode"
+	"github.com/bitrise-io/bitrise/output"
 	"github.com/urfave/cli"
 )
 
 // PrintBitriseHeaderASCIIArt ...
 func PrintBitriseHeaderASCIIArt(appVersion string) {
+	if configs.IsAccessibleMode {
+		fmt.Printf("Bitrise, version: %s\n\n", appVersion)
+		return
+	}
+
 	// generated here: http://patorjk.com/software/taag/#p=display&f=ANSI%20Shadow&t=Bitrise
 	fmt.Println(`
   ██████╗ ██╗████████╗██████╗ ██╗███████╗███████╗
@@ -20,24 +27,51 @@ func PrintBitriseHeaderASCIIArt(appVersion string) {
   ██████╔╝██║   ██║   ██║  ██║██║███████║███████╗
   ╚═════╝ ╚═╝   ╚═╝   ╚═╝  ╚═╝╚═╝╚══════╝╚══════╝`)
 	fmt.Println()
-	fmt.Println(colorstring.Greenf("Version: %s", appVersion))
+	fmt.Println(output.Greenf("Version: %s", appVersion))
 	fmt.Println()
 }
 
 func setup(c *cli.Context) error {
 	PrintBitriseHeaderASCIIArt(c.App.Version)
 
-	if c.IsSet(MinimalModeKey) {
-		log.Warn("'minimal' flag is deprecated")
-		log.Warn("currently setup without any flag does the same as minimal setup in previous versions")
-		log.Warn("use 'full' flag to achive the full setup process (which includes the 'brew doctor' call)")
-		fmt.Println()
+	if bundlePth := c.String(FromBundleKey); bundlePth != "" {
+		if c.Bool(MinimalModeKey) || c.Bool(FullModeKey) {
+			log.Fatalf("--%s can't be used with --%s or --%s", FromBundleKey, MinimalModeKey, FullModeKey)
+		}
+		if err := bitrise.RunSetupFromBundle(c.App.Version, bundlePth); err != nil {
+			log.Fatalf("Setup failed, error: %s", err)
+		}
+		return nil
+	}
+
+	mode := bitrise.SetupModeDefault
+	if c.Bool(MinimalModeKey) && c.Bool(FullModeKey) {
+		log.Fatalf("Only one of --%s and --%s can be set", MinimalModeKey, FullModeKey)
+	} else if c.Bool(MinimalModeKey) {
+		mode = bitrise.SetupModeMinimal
+	} else if c.Bool(FullModeKey) {
+		mode = bitrise.SetupModeFull
 	}
 
-	if err := bitrise.RunSetup(c.App.Version, c.Bool(FullModeKey)); err != nil {
+	if err := bitrise.RunSetup(c.App.Version, mode); err != nil {
 		log.Fatalf("Setup failed, error: %s", err)
 	}
 
+	// Tool versions pinned by the project in the current directory
+	if _, err := GetBitriseConfigFilePath(""); err == nil {
+		bitriseConfig, _, err := CreateBitriseConfigFromCLIParams("", "")
+		if err != nil {
+			exitcode.Fatalf(exitcode.ConfigInvalid, "Failed to read bitrise config, error: %s", err)
+		}
+		if err := bitrise.SetupPinnedTools(bitriseConfig.Tools); err != nil {
+			log.Fatalf("Failed to setup the tool versions pinned in the config, error: %s", err)
+		}
+		for _, tool := range bitrise.PinnedTools(bitriseConfig.Tools) {
+			log.Infof(" * %s %s (pinned by %s)", output.Green("[OK]"), tool.Name+" "+tool.Version, DefaultBitriseConfigFileName)
+		}
+		fmt.Println()
+	}
+
 	log.Infoln("To start using bitrise:")
 	log.Infoln("* cd into your project's directory (if you're not there already)")
 	log.Infoln("* call: bitrise init")
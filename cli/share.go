@@ -3,11 +3,22 @@ package cli
 import (
 	"log"
 
+	"github.com/bitrise-io/bitrise/configs"
 	"github.com/bitrise-io/bitrise/tools"
 	"github.com/urfave/cli"
 )
 
+// registerShareMode makes the stepman share commands non-interactive,
+// if --non-interactive is specified, or in CI mode.
+func registerShareMode(c *cli.Context) {
+	if c.Bool(NonInteractiveKey) || configs.IsCIMode {
+		tools.SetStepmanShareNonInteractive()
+	}
+}
+
 func share(c *cli.Context) error {
+	registerShareMode(c)
+
 	if err := tools.StepmanShare(); err != nil {
 		log.Fatalf("Bitrise share failed, error: %s", err)
 	}
@@ -8,6 +8,8 @@ import (
 )
 
 func shareAudit(c *cli.Context) error {
+	registerShareMode(c)
+
 	if err := tools.StepmanShareAudit(); err != nil {
 		log.Fatalf("Bitrise share audit failed, error: %s", err)
 	}
@@ -20,6 +20,8 @@ func create(c *cli.Context) error {
 
 	stepID := c.String(StepIDKey)
 
+	registerShareMode(c)
+
 	if err := tools.StepmanShareCreate(tag, gitURI, stepID); err != nil {
 		log.Fatalf("Bitrise share create failed, error: %s", err)
 	}
@@ -8,6 +8,8 @@ import (
 )
 
 func finish(c *cli.Context) error {
+	registerShareMode(c)
+
 	if err := tools.StepmanShareFinish(); err != nil {
 		log.Fatalf("Bitrise share finish failed, error: %s", err)
 	}
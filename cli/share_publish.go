@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/tools"
+	"github.com/urfave/cli"
+)
+
+func sharePublish(c *cli.Context) error {
+	// Input validation
+	collectionURI := c.String(CollectionKey)
+	tag := c.String(TagKey)
+	gitURI := c.String(GitKey)
+	stepID := c.String(StepIDKey)
+
+	missingFlags := []string{}
+	if collectionURI == "" {
+		missingFlags = append(missingFlags, "--"+CollectionKey)
+	}
+	if tag == "" {
+		missingFlags = append(missingFlags, "--"+TagKey)
+	}
+	if gitURI == "" {
+		missingFlags = append(missingFlags, "--"+GitKey)
+	}
+	if len(missingFlags) > 0 {
+		log.Fatalf("Missing required flags: %s", strings.Join(missingFlags, ", "))
+	}
+
+	// the publish is always non-interactive
+	tools.SetStepmanShareNonInteractive()
+
+	log.Infof("Starting the share, collection: %s", collectionURI)
+	if err := tools.StepmanShareStart(collectionURI); err != nil {
+		log.Fatalf("Bitrise share start failed, error: %s", err)
+	}
+
+	log.Infof("Adding the step (git: %s, tag: %s) to the collection", gitURI, tag)
+	if err := tools.StepmanShareCreate(tag, gitURI, stepID); err != nil {
+		log.Fatalf("Bitrise share create failed, error: %s", err)
+	}
+
+	log.Info("Validating the collection")
+	if err := tools.StepmanShareAudit(); err != nil {
+		log.Fatalf("Bitrise share audit failed, error: %s", err)
+	}
+
+	log.Info("Finishing the share")
+	if err := tools.StepmanShareFinish(); err != nil {
+		log.Fatalf("Bitrise share finish failed, error: %s", err)
+	}
+
+	return nil
+}
@@ -13,6 +13,8 @@ func start(c *cli.Context) error {
 		log.Fatal("No step collection specified")
 	}
 
+	registerShareMode(c)
+
 	if err := tools.StepmanShareStart(collectionURI); err != nil {
 		log.Fatalf("Bitrise share start failed, error: %s", err)
 	}
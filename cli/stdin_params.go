@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// StdinPath as the --config or --inventory path reads the config or the secrets from the stdin
+const StdinPath = "-"
+
+// stdinReader is read (at most once) by the --config - and the --inventory - flags
+var stdinReader io.Reader = os.Stdin
+
+var (
+	stdinContent []byte
+	// stdinUsedBy is the flag, which read the stdin
+	stdinUsedBy string
+)
+
+// readStdinFor returns the content of the stdin, for the given flag.
+// The stdin can be read only once, so only one of the flags can read from it,
+// the same flag gets the same (cached) content on subsequent calls.
+func readStdinFor(flag string) ([]byte, error) {
+	if stdinUsedBy != "" {
+		if stdinUsedBy != flag {
+			return []byte{}, fmt.Errorf("stdin is already read by --%s, only one of --%s and --%s can be %s", stdinUsedBy, ConfigKey, InventoryKey, StdinPath)
+		}
+		return stdinContent, nil
+	}
+
+	content, err := ioutil.ReadAll(stdinReader)
+	if err != nil {
+		return []byte{}, fmt.Errorf("Failed to read stdin, error: %s", err)
+	}
+	if len(content) == 0 {
+		return []byte{}, fmt.Errorf("--%s %s: empty stdin", flag, StdinPath)
+	}
+
+	stdinContent = content
+	stdinUsedBy = flag
+	return content, nil
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func resetStdin(content string) {
+	stdinReader = strings.NewReader(content)
+	stdinContent = nil
+	stdinUsedBy = ""
+}
+
+func TestCreateBitriseConfigFromStdin(t *testing.T) {
+	defer resetStdin("")
+
+	configStr := `
+format_version: 1.3.1
+default_step_lib_source: "https://github.com/bitrise-io/bitrise-steplib.git"
+
+workflows:
+  target:
+    steps:
+    - script: {}
+`
+
+	t.Log("config from stdin")
+	{
+		resetStdin(configStr)
+
+		config, warnings, err := CreateBitriseConfigFromCLIParams("", StdinPath)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(warnings))
+		require.Equal(t, 1, len(config.Workflows["target"].Steps))
+
+		t.Log("the same flag gets the cached content")
+		config, _, err = CreateBitriseConfigFromCLIParams("", StdinPath)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(config.Workflows["target"].Steps))
+
+		t.Log("the stdin can't be read by another flag")
+		_, err = CreateInventoryFromCLIParams("", StdinPath)
+		require.EqualError(t, err, "stdin is already read by --config, only one of --config and --inventory can be -")
+	}
+
+	t.Log("secrets from stdin")
+	{
+		resetStdin("envs:\n- API_TOKEN: token\n")
+
+		environments, err := CreateInventoryFromCLIParams("", StdinPath)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(environments))
+
+		key, value, err := environments[0].GetKeyValuePair()
+		require.NoError(t, err)
+		require.Equal(t, "API_TOKEN", key)
+		require.Equal(t, "token", value)
+	}
+
+	t.Log("empty stdin")
+	{
+		resetStdin("")
+
+		_, _, err := CreateBitriseConfigFromCLIParams("", StdinPath)
+		require.EqualError(t, err, "--config -: empty stdin")
+	}
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/goinp/goinp"
+	"github.com/urfave/cli"
+)
+
+const (
+	// StepTitleKey ...
+	StepTitleKey = "title"
+	// StepSummaryKey ...
+	StepSummaryKey = "summary"
+	// StepWebsiteKey ...
+	StepWebsiteKey = "website"
+	// StepToolkitKey ...
+	StepToolkitKey = "toolkit"
+	// StepGoPackageKey ...
+	StepGoPackageKey = "go-package"
+	// StepDirKey ...
+	StepDirKey = "dir"
+)
+
+// askStepScaffold asks the step's details, which are not specified by the flags
+func askStepScaffold(scaffold bitrise.StepScaffoldModel) (bitrise.StepScaffoldModel, error) {
+	var err error
+	if scaffold.ID == "" {
+		if scaffold.ID, err = goinp.AskForString("Step ID (lowercase letters, numbers and dashes, e.g. my-step):"); err != nil {
+			return bitrise.StepScaffoldModel{}, err
+		}
+	}
+	if scaffold.Title == "" {
+		if scaffold.Title, err = goinp.AskForStringWithDefault("Step title:", scaffold.ID); err != nil {
+			return bitrise.StepScaffoldModel{}, err
+		}
+	}
+	if scaffold.Summary == "" {
+		if scaffold.Summary, err = goinp.AskForStringWithDefault("One line summary of the step:", scaffold.Title); err != nil {
+			return bitrise.StepScaffoldModel{}, err
+		}
+	}
+	if scaffold.Toolkit == "" {
+		if scaffold.Toolkit, err = goinp.SelectFromStrings("Which toolkit do you want to use?", bitrise.StepScaffoldToolkits); err != nil {
+			return bitrise.StepScaffoldModel{}, err
+		}
+	}
+	if scaffold.Toolkit == bitrise.StepToolkitGo && scaffold.GoPackageName == "" {
+		if scaffold.GoPackageName, err = goinp.AskForString("Go package name of the step (e.g. github.com/user/steps-my-step):"); err != nil {
+			return bitrise.StepScaffoldModel{}, err
+		}
+	}
+	return scaffold, nil
+}
+
+func stepCreate(c *cli.Context) error {
+	scaffold := bitrise.StepScaffoldModel{
+		ID:            c.String(IDKey),
+		Title:         c.String(StepTitleKey),
+		Summary:       c.String(StepSummaryKey),
+		Website:       c.String(StepWebsiteKey),
+		Toolkit:       c.String(StepToolkitKey),
+		GoPackageName: c.String(StepGoPackageKey),
+	}
+
+	// in CI mode (--ci) no user input is required, the missing details are defaulted or the validation fails
+	if !configs.IsCIMode {
+		var err error
+		if scaffold, err = askStepScaffold(scaffold); err != nil {
+			log.Fatalf("Failed to ask for input, error: %s", err)
+		}
+	} else if scaffold.Toolkit == "" {
+		scaffold.Toolkit = bitrise.StepToolkitBash
+	}
+	if scaffold.Title == "" {
+		scaffold.Title = scaffold.ID
+	}
+
+	if err := scaffold.Validate(); err != nil {
+		log.Fatalf("Invalid step: %s", err)
+	}
+
+	dir := c.String(StepDirKey)
+	if dir == "" {
+		dir = "./steps-" + scaffold.ID
+	}
+
+	pths, err := bitrise.WriteStepScaffold(scaffold, dir)
+	if err != nil {
+		log.Fatalf("Failed to create the step, error: %s", err)
+	}
+
+	fmt.Println(output.Green(fmt.Sprintf("Step (%s) created with the %s toolkit:", scaffold.ID, scaffold.Toolkit)))
+	for _, pth := range pths {
+		fmt.Printf("- %s\n", pth)
+	}
+	fmt.Println()
+	fmt.Println("To test the step, run its test workflow:")
+	fmt.Printf("  cd %s && bitrise run test\n", filepath.Clean(dir))
+
+	return nil
+}
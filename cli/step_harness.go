@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/bitrise/version"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/urfave/cli"
+)
+
+const (
+	// StepTestDirKey ...
+	StepTestDirKey = "step-dir"
+	// StepInputKey ...
+	StepInputKey = "input"
+	// StepOutputKey ...
+	StepOutputKey = "output"
+)
+
+func stepTest(c *cli.Context) error {
+	PrintBitriseHeaderASCIIArt(version.VERSION)
+
+	stepDir := c.String(StepTestDirKey)
+	if stepDir == "" {
+		stepDir = "."
+	}
+
+	inputs, err := bitrise.ParseStepTestInputs(c.StringSlice(StepInputKey))
+	if err != nil {
+		log.Fatalf("Invalid --%s flag: %s", StepInputKey, err)
+	}
+	assertions, err := bitrise.ParseStepOutputAssertions(c.StringSlice(StepOutputKey))
+	if err != nil {
+		log.Fatalf("Invalid --%s flag: %s", StepOutputKey, err)
+	}
+
+	bitriseConfig, err := bitrise.StepTestConfig(stepDir, inputs)
+	if err != nil {
+		log.Fatalf("Failed to create the step test config, error: %s", err)
+	}
+
+	lastRunEnvironments = []envmanModels.EnvironmentItemModel{}
+	buildRunResults, err := runWorkflowWithSetup(bitriseConfig, []envmanModels.EnvironmentItemModel{}, bitrise.StepTestWorkflowID, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if buildRunResults.IsAborted {
+		os.Exit(bitrise.BuildAbortedExitCode)
+	}
+	if buildRunResults.IsBuildFailed() {
+		log.Error("Step failed")
+		os.Exit(bitrise.BuildExitCode(buildRunResults))
+	}
+
+	failures, err := bitrise.CheckStepOutputAssertions(assertions, lastRunEnvironments)
+	if err != nil {
+		log.Fatalf("Failed to check the step's outputs, error: %s", err)
+	}
+	if len(failures) > 0 {
+		log.Error("Step output assertions failed:")
+		for _, failure := range failures {
+			log.Errorf("- %s", failure)
+		}
+		os.Exit(1)
+	}
+
+	fmt.Println(output.Green(fmt.Sprintf("Step succeeded, %d output assertion(s) passed", len(assertions))))
+	return nil
+}
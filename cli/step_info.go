@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/bitrise-io/bitrise/output"
@@ -18,12 +19,22 @@ func printStepLibStep(collectionURI, id, version, format string) error {
 		}
 		return err
 	case output.FormatJSON:
-		outStr, err := tools.StepmanJSONStepLibStepInfo(collectionURI, id, version)
+		stepInfo, err := tools.StepmanStepLibStepInfo(collectionURI, id, version)
 		if err != nil {
-			return fmt.Errorf("StepmanJSONStepLibStepInfo failed, err: %s", err)
+			return fmt.Errorf("StepmanStepLibStepInfo failed, err: %s", err)
 		}
-		fmt.Println(outStr)
+		bytes, err := json.Marshal(stepInfo)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(bytes))
 		break
+	case output.FormatMarkdown:
+		out, err := tools.StepmanMarkdownStepLibStepInfo(collectionURI, id, version)
+		if err != nil {
+			return fmt.Errorf("StepmanStepLibStepInfo failed, err: %s", err)
+		}
+		fmt.Print(out)
 	default:
 		return fmt.Errorf("Invalid format: %s", format)
 	}
@@ -40,12 +51,22 @@ func printLocalStepInfo(pth, format string) error {
 		}
 		return err
 	case output.FormatJSON:
-		outStr, err := tools.StepmanJSONLocalStepInfo(pth)
+		stepInfo, err := tools.StepmanLocalStepInfo(pth)
 		if err != nil {
-			return fmt.Errorf("StepmanJSONLocalStepInfo failed, err: %s", err)
+			return fmt.Errorf("StepmanLocalStepInfo failed, err: %s", err)
 		}
-		fmt.Println(outStr)
+		bytes, err := json.Marshal(stepInfo)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(bytes))
 		break
+	case output.FormatMarkdown:
+		out, err := tools.StepmanMarkdownLocalStepInfo(pth)
+		if err != nil {
+			return fmt.Errorf("StepmanLocalStepInfo failed, err: %s", err)
+		}
+		fmt.Print(out)
 	default:
 		return fmt.Errorf("Invalid format: %s", format)
 	}
@@ -82,7 +103,7 @@ func stepInfo(c *cli.Context) error {
 
 	if format == "" {
 		format = output.FormatRaw
-	} else if !(format == output.FormatRaw || format == output.FormatJSON) {
+	} else if !(format == output.FormatRaw || format == output.FormatJSON || format == output.FormatMarkdown) {
 		registerFatal(fmt.Sprintf("Invalid format: %s", format), warnings, output.FormatJSON)
 	}
 
@@ -1,13 +1,87 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 
+	"github.com/bitrise-io/bitrise/bitrise"
 	"github.com/bitrise-io/bitrise/output"
 	"github.com/bitrise-io/bitrise/tools"
+	"github.com/bitrise-io/go-utils/parseutil"
+	"github.com/bitrise-io/go-utils/pointers"
 	"github.com/urfave/cli"
 )
 
+const (
+	// CategoryKey ...
+	CategoryKey = "category"
+	// MaintainerKey ...
+	MaintainerKey = "maintainer"
+	// ToolkitKey ...
+	ToolkitKey = "toolkit"
+	// DeprecatedKey ...
+	DeprecatedKey = "deprecated"
+)
+
+func stepListFilter(c *cli.Context) (bitrise.StepListFilterModel, error) {
+	filter := bitrise.StepListFilterModel{
+		Category:   c.String(CategoryKey),
+		Maintainer: c.String(MaintainerKey),
+		Toolkit:    c.String(ToolkitKey),
+	}
+
+	if filter.Toolkit != "" && filter.Toolkit != bitrise.StepToolkitBash && filter.Toolkit != bitrise.StepToolkitGo {
+		return bitrise.StepListFilterModel{}, fmt.Errorf("invalid --%s (%s), accepted: %s", ToolkitKey, filter.Toolkit, strings.Join(bitrise.StepScaffoldToolkits, ", "))
+	}
+
+	if deprecated := c.String(DeprecatedKey); deprecated != "" {
+		isDeprecated, err := parseutil.ParseBool(deprecated)
+		if err != nil {
+			return bitrise.StepListFilterModel{}, fmt.Errorf("invalid --%s (%s), accepted: true, false", DeprecatedKey, deprecated)
+		}
+		filter.Deprecated = pointers.NewBoolPtr(isDeprecated)
+	}
+
+	return filter, nil
+}
+
+func printFilteredStepList(collectionURI string, filter bitrise.StepListFilterModel, format string) error {
+	if err := tools.StepmanSetup(collectionURI); err != nil {
+		return fmt.Errorf("Failed to setup steplib (%s), error: %s", collectionURI, err)
+	}
+	spec, err := tools.StepmanStepLibSpec(collectionURI)
+	if err != nil {
+		return err
+	}
+
+	steps := bitrise.FilterStepLibSteps(spec, filter)
+
+	switch format {
+	case output.FormatRaw:
+		fmt.Printf("Step list (%d steps):\n", len(steps))
+		for _, step := range steps {
+			line := fmt.Sprintf("- %s (%s)", step.ID, step.Version)
+			if step.Title != "" {
+				line += ": " + step.Title
+			}
+			if step.Deprecated {
+				line += " [deprecated]"
+			}
+			fmt.Println(line)
+		}
+	case output.FormatJSON:
+		bytes, err := json.Marshal(steps)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(bytes))
+	default:
+		return fmt.Errorf("Invalid format: %s", format)
+	}
+	return nil
+}
+
 func stepList(c *cli.Context) error {
 	warnings := []string{}
 
@@ -47,6 +121,17 @@ func stepList(c *cli.Context) error {
 		collectionURI = bitriseConfig.DefaultStepLibSource
 	}
 
+	filter, err := stepListFilter(c)
+	if err != nil {
+		registerFatal(err.Error(), warnings, format)
+	}
+	if !filter.IsEmpty() {
+		if err := printFilteredStepList(collectionURI, filter, format); err != nil {
+			registerFatal(fmt.Sprintf("Failed to print step list, err: %s", err), warnings, format)
+		}
+		return nil
+	}
+
 	switch format {
 	case output.FormatRaw:
 		out, err := tools.StepmanRawStepList(collectionURI)
@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/cmdex"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// Step lock state of the current run
+var (
+	lockedSteps   = bitrise.NewStepLockfile()
+	resolvedSteps = bitrise.NewStepLockfile()
+)
+
+func registerLockedMode(isLocked, isUpdateLock bool, bitriseConfigBase64Data, bitriseConfigPath string) error {
+	if isLocked && isUpdateLock {
+		return fmt.Errorf("--%s and --%s can't be used together", LockedKey, UpdateLockKey)
+	}
+	configs.IsLockedMode = isLocked
+	configs.IsUpdateLockMode = isUpdateLock
+
+	configPth := ""
+	// the lockfile of a config from base 64 data or from stdin is in the current dir
+	if bitriseConfigBase64Data == "" && bitriseConfigPath != StdinPath {
+		pth, err := GetBitriseConfigFilePath(bitriseConfigPath)
+		if err != nil {
+			return err
+		}
+		configPth = pth
+	}
+	configs.StepLockfilePath = bitrise.StepLockfilePathForConfig(configPth, configs.CurrentDir)
+
+	return nil
+}
+
+func initStepLocks() error {
+	resolvedSteps = bitrise.NewStepLockfile()
+	lockedSteps = bitrise.NewStepLockfile()
+
+	if !configs.IsLockedMode {
+		return nil
+	}
+
+	lockfile, found, err := bitrise.ReadStepLockfile(configs.StepLockfilePath)
+	if err != nil {
+		return fmt.Errorf("Failed to read step lockfile, error: %s", err)
+	}
+	if !found {
+		return fmt.Errorf("Locked mode requires a step lockfile (%s), run with --%s to generate it", configs.StepLockfilePath, UpdateLockKey)
+	}
+	lockedSteps = lockfile
+
+	return nil
+}
+
+// checkLockedSteps checks every step of the workflows, run by the given workflow, against the step lockfile in locked mode,
+// before the first step runs (see: bitrise.StepLockfileModel.CheckStepIDData), so a mismatch doesn't fail the build halfway.
+// The commit hash of the steps is checked at their activation (see: registerResolvedStep).
+func checkLockedSteps(config models.BitriseDataModel, workflowID string) error {
+	if !configs.IsLockedMode {
+		return nil
+	}
+
+	for _, chainWorkflowID := range models.WorkflowChain(config, workflowID) {
+		for idx, stepListItem := range config.Workflows[chainWorkflowID].Steps {
+			compositeStepID, _, err := models.GetStepIDStepDataPair(stepListItem)
+			if err != nil {
+				return models.NewConfigPathError(fmt.Sprintf("workflows.%s.steps[%d]", chainWorkflowID, idx), err)
+			}
+			if _, found := lockedSteps.Steps[compositeStepID]; !found {
+				return fmt.Errorf("Locked mode: step (%s) of workflow (%s) not found in the lockfile (%s), run with --%s to update it",
+					compositeStepID, chainWorkflowID, configs.StepLockfilePath, UpdateLockKey)
+			}
+
+			stepIDData, err := resolveStepIDData(compositeStepID, config.DefaultStepLibSource)
+			if err != nil {
+				return fmt.Errorf("Locked mode: failed to resolve step (%s) of workflow (%s), error: %s", compositeStepID, chainWorkflowID, err)
+			}
+			if err := lockedSteps.CheckStepIDData(compositeStepID, stepIDData); err != nil {
+				return fmt.Errorf("Locked mode: %s", err)
+			}
+		}
+	}
+	return nil
+}
+
+// stepLibSources are the StepLibs of the run, searched in order for the steps without an explicit StepLib source
+var stepLibSources = []string{}
+
+// resolveStepIDData returns the step ID data of the workflow step,
+// in locked mode the step's StepLib source and version (if it's not an exact version, see: bitrise.IsExactStepVersion) are the locked ones,
+// otherwise the step is searched in the run's StepLibs, and its version constraint is resolved.
+func resolveStepIDData(compositeStepID, defaultStepLibSource string) (models.StepIDData, error) {
+	if configs.IsLockedMode {
+		if stepLock, found := lockedSteps.Steps[compositeStepID]; found && stepLock.Source != "" {
+			stepIDData, err := models.CreateStepIDDataFromString(compositeStepID, stepLock.Source)
+			if err != nil {
+				return models.StepIDData{}, err
+			}
+			if !bitrise.IsExactStepVersion(stepIDData.Version) && stepLock.Version != "" {
+				stepIDData.Version = stepLock.Version
+			}
+			return stepIDData, nil
+		}
+	}
+
+	var stepIDData models.StepIDData
+	var err error
+	if len(stepLibSources) > 1 {
+		stepIDData, err = bitrise.ResolveStepIDData(compositeStepID, stepLibSources)
+	} else {
+		stepIDData, err = models.CreateStepIDDataFromString(compositeStepID, defaultStepLibSource)
+	}
+	if err != nil {
+		return models.StepIDData{}, err
+	}
+	return bitrise.ResolveStepVersion(stepIDData)
+}
+
+func resolvedStepLock(stepIDData models.StepIDData, resolvedVersion string, step stepmanModels.StepModel, stepDir string) (bitrise.StepLockModel, error) {
+	stepLock := bitrise.StepLockModel{
+		Source:  stepIDData.SteplibSource,
+		ID:      stepIDData.IDorURI,
+		Version: resolvedVersion,
+	}
+
+	switch stepIDData.SteplibSource {
+	case "path":
+		// local steps can't be locked
+	case "git", "_":
+		commitHash, err := cmdex.GitGetCommitHashOfHEAD(stepDir)
+		if err != nil {
+			return bitrise.StepLockModel{}, fmt.Errorf("Failed to get the step's commit hash, error: %s", err)
+		}
+		stepLock.CommitHash = commitHash
+	case models.OCIStepSource:
+		// the tag of an OCI artifact can be moved, the manifest digest identifies the pulled artifact
+		digest, found := bitrise.OCIStepDigest(stepIDData)
+		if !found {
+			return bitrise.StepLockModel{}, fmt.Errorf("No pulled artifact found for step (%s)", stepIDData.IDorURI)
+		}
+		stepLock.CommitHash = digest
+	case models.ArchiveStepSource:
+		// the archive is identified by its (verified) checksum
+		stepLock.CommitHash = stepIDData.Version
+	default:
+		stepLock.CommitHash = step.Source.Commit
+	}
+
+	return stepLock, nil
+}
+
+func registerResolvedStep(compositeStepID string, stepIDData models.StepIDData, resolvedVersion string, step stepmanModels.StepModel, stepDir string) error {
+	stepLock, err := resolvedStepLock(stepIDData, resolvedVersion, step, stepDir)
+	if err != nil {
+		return err
+	}
+
+	if configs.IsLockedMode {
+		if err := lockedSteps.CheckStep(compositeStepID, stepLock); err != nil {
+			return fmt.Errorf("Locked mode: %s", err)
+		}
+	}
+
+	resolvedSteps.Steps[compositeStepID] = stepLock
+	return nil
+}
+
+// saveResolvedSteps writes the resolved steps into the step lockfile, only if it's requested (see: --update-lock),
+// so a run doesn't modify the user's working tree
+func saveResolvedSteps() {
+	if !configs.IsUpdateLockMode || configs.StepLockfilePath == "" || len(resolvedSteps.Steps) == 0 {
+		return
+	}
+
+	lockfile, _, err := bitrise.ReadStepLockfile(configs.StepLockfilePath)
+	if err != nil {
+		log.Warnf("Failed to read step lockfile, error: %s", err)
+		lockfile = bitrise.NewStepLockfile()
+	}
+	lockfile.Merge(resolvedSteps)
+
+	if err := bitrise.SaveStepLockfile(configs.StepLockfilePath, lockfile); err != nil {
+		bitrise.CheckDiskFull(err)
+		log.Warnf("Failed to save step lockfile, error: %s", err)
+		return
+	}
+	log.Infof("Step lockfile saved: %s", configs.StepLockfilePath)
+}
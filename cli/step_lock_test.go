@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterLockedMode(t *testing.T) {
+	defer func() {
+		configs.IsLockedMode = false
+		configs.IsUpdateLockMode = false
+	}()
+
+	t.Log("--locked and --update-lock")
+	{
+		require.Error(t, registerLockedMode(true, true, "", ""))
+	}
+}
+
+func TestSaveResolvedSteps(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("step_lock_test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+		configs.StepLockfilePath = ""
+		configs.IsUpdateLockMode = false
+		resolvedSteps = bitrise.NewStepLockfile()
+	}()
+
+	configs.StepLockfilePath = filepath.Join(tmpDir, bitrise.StepLockfileName)
+	resolvedSteps = bitrise.NewStepLockfile()
+	resolvedSteps.Steps["script@1"] = bitrise.StepLockModel{Source: "https://github.com/bitrise-io/bitrise-steplib.git", ID: "script", Version: "1.1.3"}
+
+	t.Log("the lockfile is not written by default")
+	{
+		configs.IsUpdateLockMode = false
+		saveResolvedSteps()
+
+		_, found, err := bitrise.ReadStepLockfile(configs.StepLockfilePath)
+		require.NoError(t, err)
+		require.Equal(t, false, found)
+	}
+
+	t.Log("--update-lock")
+	{
+		configs.IsUpdateLockMode = true
+		saveResolvedSteps()
+
+		lockfile, found, err := bitrise.ReadStepLockfile(configs.StepLockfilePath)
+		require.NoError(t, err)
+		require.Equal(t, true, found)
+		require.Equal(t, resolvedSteps.Steps["script@1"], lockfile.Steps["script@1"])
+	}
+}
+
+func TestCheckLockedSteps(t *testing.T) {
+	defer func() {
+		configs.IsLockedMode = false
+		lockedSteps = bitrise.NewStepLockfile()
+	}()
+
+	config := models.BitriseDataModel{
+		DefaultStepLibSource: "https://github.com/bitrise-io/bitrise-steplib.git",
+		Workflows: map[string]models.WorkflowModel{
+			"setup": models.WorkflowModel{
+				Steps: []models.StepListItemModel{
+					models.StepListItemModel{"script@1": models.WorkflowStepModel{}},
+				},
+			},
+			"primary": models.WorkflowModel{
+				BeforeRun: []models.WorkflowRunItemModel{{WorkflowID: "setup"}},
+				Steps: []models.StepListItemModel{
+					models.StepListItemModel{"deploy-to-bitrise-io@2.0.0": models.WorkflowStepModel{}},
+				},
+			},
+		},
+	}
+
+	configs.IsLockedMode = true
+	lockedSteps = bitrise.NewStepLockfile()
+	lockedSteps.Steps["script@1"] = bitrise.StepLockModel{Source: "https://github.com/bitrise-io/bitrise-steplib.git", ID: "script", Version: "1.1.3"}
+
+	t.Log("a step of the run is not locked")
+	{
+		err := checkLockedSteps(config, "primary")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "step (deploy-to-bitrise-io@2.0.0) of workflow (primary) not found in the lockfile")
+	}
+
+	t.Log("a step of the run is locked to an other version")
+	{
+		lockedSteps.Steps["deploy-to-bitrise-io@2.0.0"] = bitrise.StepLockModel{Source: "https://github.com/bitrise-io/bitrise-steplib.git", ID: "deploy-to-bitrise-io", Version: "1.9.0"}
+		err := checkLockedSteps(config, "primary")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "locked to version (1.9.0)")
+	}
+
+	t.Log("every step of the run is locked")
+	{
+		lockedSteps.Steps["deploy-to-bitrise-io@2.0.0"] = bitrise.StepLockModel{Source: "https://github.com/bitrise-io/bitrise-steplib.git", ID: "deploy-to-bitrise-io", Version: "2.0.0"}
+		require.NoError(t, checkLockedSteps(config, "primary"))
+	}
+
+	t.Log("not in locked mode")
+	{
+		configs.IsLockedMode = false
+		require.NoError(t, checkLockedSteps(config, "setup"))
+	}
+}
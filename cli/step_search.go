@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/exitcode"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/bitrise/tools"
+	"github.com/urfave/cli"
+)
+
+func stepSearch(c *cli.Context) error {
+	// Expand cli.Context
+	bitriseConfigBase64Data := c.String(ConfigBase64Key)
+	bitriseConfigPath := c.String(ConfigKey)
+
+	collectionURI := c.String(CollectionKey)
+	format := c.String(OuputFormatKey)
+	query := strings.Join(c.Args(), " ")
+	//
+
+	if format == "" {
+		format = output.FormatRaw
+	} else if format != output.FormatRaw && format != output.FormatJSON {
+		log.Fatalf("Invalid format: %s", format)
+	}
+	if strings.TrimSpace(query) == "" {
+		log.Fatal("No search query specified")
+	}
+
+	if collectionURI == "" {
+		bitriseConfig, warnings, err := CreateBitriseConfigFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath)
+		for _, warning := range warnings {
+			log.Warnf("warning: %s", warning)
+		}
+		if err != nil {
+			exitcode.Fatalf(exitcode.ConfigInvalid, "No collection defined and failed to read bitrise config, err: %s", err)
+		}
+		if bitriseConfig.DefaultStepLibSource == "" {
+			log.Fatal("No collection defined and no default collection found in bitrise config")
+		}
+		collectionURI = bitriseConfig.DefaultStepLibSource
+	}
+
+	// the cached spec is searched, the StepLib is only set up, if it's not cached yet
+	if err := tools.StepmanSetup(collectionURI); err != nil {
+		log.Fatalf("Failed to setup steplib (%s), error: %s", collectionURI, err)
+	}
+	spec, err := tools.StepmanStepLibSpec(collectionURI)
+	if err != nil {
+		log.Fatalf("Failed to read steplib (%s) spec, error: %s", collectionURI, err)
+	}
+
+	results := bitrise.SearchStepLibSteps(spec, query)
+
+	if format == output.FormatJSON {
+		bytes, err := json.Marshal(results)
+		if err != nil {
+			log.Fatalf("Failed to serialize search results, error: %s", err)
+		}
+		fmt.Println(string(bytes))
+		return nil
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("No step found for: %s\n", query)
+		return nil
+	}
+
+	fmt.Printf("%d step(s) found for: %s\n", len(results), query)
+	for _, result := range results {
+		line := fmt.Sprintf("- %s (%s)", output.Blue(result.ID), result.Version)
+		if result.Title != "" {
+			line += ": " + result.Title
+		}
+		if result.Deprecated {
+			line += " " + output.Yellow("[deprecated]")
+		}
+		fmt.Println(line)
+		if result.Summary != "" {
+			fmt.Println(indentLines(strings.TrimSpace(result.Summary), "  "))
+		}
+	}
+	return nil
+}
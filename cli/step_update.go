@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/exitcode"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/bitrise/tools"
+	"github.com/bitrise-io/go-utils/fileutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/urfave/cli"
+)
+
+// StepUpdateModel ...
+type StepUpdateModel struct {
+	CompositeID    string
+	NewCompositeID string
+	StepID         string
+	CurrentVersion string
+	NewVersion     string
+}
+
+func collectPinnedSteplibSteps(config models.BitriseDataModel) (map[string]models.StepIDData, error) {
+	stepLists := [][]models.StepListItemModel{}
+	for _, workflow := range config.Workflows {
+		stepLists = append(stepLists, workflow.Steps)
+	}
+	for _, bundle := range config.StepBundles {
+		stepLists = append(stepLists, bundle.Steps)
+	}
+
+	pinnedSteps := map[string]models.StepIDData{}
+	for _, steps := range stepLists {
+		for _, stepListItem := range steps {
+			compositeStepID, _, err := models.GetStepIDStepDataPair(stepListItem)
+			if err != nil {
+				return map[string]models.StepIDData{}, err
+			}
+			if models.IsStepBundleReference(compositeStepID) {
+				continue
+			}
+
+			stepIDData, err := models.CreateStepIDDataFromString(compositeStepID, config.DefaultStepLibSource)
+			if err != nil {
+				return map[string]models.StepIDData{}, err
+			}
+
+			switch stepIDData.SteplibSource {
+			case "path", "git", "_", "", models.OCIStepSource, models.ArchiveStepSource:
+				continue
+			}
+			if stepIDData.Version == "" {
+				// referenced without version, always uses the latest one
+				continue
+			}
+			if bitrise.IsStepVersionConstraint(stepIDData.Version) {
+				// referenced with a version constraint, resolved at every run
+				continue
+			}
+
+			pinnedSteps[compositeStepID] = stepIDData
+		}
+	}
+	return pinnedSteps, nil
+}
+
+func stepUpdates(config models.BitriseDataModel, allowMajor bool) ([]StepUpdateModel, error) {
+	pinnedSteps, err := collectPinnedSteplibSteps(config)
+	if err != nil {
+		return []StepUpdateModel{}, err
+	}
+
+	compositeStepIDs := []string{}
+	for compositeStepID := range pinnedSteps {
+		compositeStepIDs = append(compositeStepIDs, compositeStepID)
+	}
+	sort.Strings(compositeStepIDs)
+
+	specs := map[string]stepmanModels.StepCollectionModel{}
+	updates := []StepUpdateModel{}
+	for _, compositeStepID := range compositeStepIDs {
+		stepIDData := pinnedSteps[compositeStepID]
+		spec, found := specs[stepIDData.SteplibSource]
+		if !found {
+			log.Infof("Updating StepLib (%s) ...", stepIDData.SteplibSource)
+			if err := tools.StepmanSetup(stepIDData.SteplibSource); err != nil {
+				return []StepUpdateModel{}, fmt.Errorf("Failed to setup StepLib (%s), error: %s", stepIDData.SteplibSource, err)
+			}
+			if err := tools.StepmanUpdate(stepIDData.SteplibSource); err != nil {
+				log.Warnf("Failed to update StepLib (%s), error: %s", stepIDData.SteplibSource, err)
+			}
+
+			spec, err = tools.StepmanStepLibSpec(stepIDData.SteplibSource)
+			if err != nil {
+				return []StepUpdateModel{}, err
+			}
+			specs[stepIDData.SteplibSource] = spec
+		}
+
+		versions := bitrise.StepLibStepVersions(spec, stepIDData.IDorURI)
+		if len(versions) == 0 {
+			log.Warnf("Step (%s) not found in StepLib (%s)", stepIDData.IDorURI, stepIDData.SteplibSource)
+			continue
+		}
+
+		newVersion, err := bitrise.LatestAllowedStepVersion(versions, stepIDData.Version, allowMajor)
+		if err != nil {
+			log.Warnf("Step (%s): %s", compositeStepID, err)
+			continue
+		}
+		if newVersion == "" {
+			continue
+		}
+
+		updates = append(updates, StepUpdateModel{
+			CompositeID:    compositeStepID,
+			NewCompositeID: strings.TrimSuffix(compositeStepID, "@"+stepIDData.Version) + "@" + newVersion,
+			StepID:         stepIDData.IDorURI,
+			CurrentVersion: stepIDData.Version,
+			NewVersion:     newVersion,
+		})
+	}
+
+	return updates, nil
+}
+
+func stepUpdate(c *cli.Context) error {
+	// Expand cli.Context
+	bitriseConfigPath := c.String(ConfigKey)
+	deprecatedBitriseConfigPath := c.String(PathKey)
+	if bitriseConfigPath == "" && deprecatedBitriseConfigPath != "" {
+		log.Warn("'path' key is deprecated, use 'config' instead!")
+		bitriseConfigPath = deprecatedBitriseConfigPath
+	}
+
+	isDryRun := c.Bool(DryRunKey)
+	isMajor := c.Bool(MajorKey)
+	//
+
+	// Input validation
+	if bitriseConfigPath == StdinPath {
+		log.Fatal("The config can not be read from stdin, step-update writes the config file")
+	}
+
+	bitriseConfigPath, err := GetBitriseConfigFilePath(bitriseConfigPath)
+	if err != nil {
+		log.Fatalf("Failed to get config (bitrise.yml) path: %s", err)
+	}
+
+	bitriseConfig, warnings, err := CreateBitriseConfigFromCLIParams("", bitriseConfigPath)
+	for _, warning := range warnings {
+		log.Warnf("warning: %s", warning)
+	}
+	if err != nil {
+		exitcode.Fatalf(exitcode.ConfigInvalid, "Failed to create bitrise config, error: %s", err)
+	}
+
+	// Main
+	updates, err := stepUpdates(bitriseConfig, isMajor)
+	if err != nil {
+		log.Fatalf("Failed to check step updates, error: %s", err)
+	}
+
+	fmt.Println()
+	if len(updates) == 0 {
+		log.Infoln(output.Green("Every pinned step is up to date"))
+		return nil
+	}
+
+	log.Infoln("Step updates:")
+	updateMap := map[string]string{}
+	for _, update := range updates {
+		log.Infof(" * %s: %s -> %s", update.StepID, update.CurrentVersion, output.Green(update.NewVersion))
+		updateMap[update.CompositeID] = update.NewCompositeID
+	}
+	fmt.Println()
+
+	if isDryRun {
+		log.Infoln("Dry run, the config is not modified")
+		return nil
+	}
+
+	content, err := fileutil.ReadStringFromFile(bitriseConfigPath)
+	if err != nil {
+		log.Fatalf("Failed to read config (%s), error: %s", bitriseConfigPath, err)
+	}
+
+	newContent, count := bitrise.RewriteStepVersions(content, updateMap)
+	if err := fileutil.WriteStringToFile(bitriseConfigPath, newContent); err != nil {
+		log.Fatalf("Failed to write config (%s), error: %s", bitriseConfigPath, err)
+	}
+
+	log.Infoln(output.Greenf("%d step reference(s) updated in %s", count, bitriseConfigPath))
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/exitcode"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/tools"
+)
+
+// buildTimeout is the timeout of the whole run (see: --timeout), nil if not set
+var buildTimeout *bitrise.TimeoutModel
+
+// registerBuildTimeout ...
+func registerBuildTimeout(timeoutSecs, graceSecs int) error {
+	if timeoutSecs < 0 {
+		return fmt.Errorf("invalid timeout (%d), should not be negative", timeoutSecs)
+	}
+	if graceSecs < 0 {
+		return fmt.Errorf("invalid timeout grace period (%d), should not be negative", graceSecs)
+	}
+	configs.BuildTimeoutSecs = timeoutSecs
+	configs.BuildTimeoutGraceSecs = graceSecs
+	return nil
+}
+
+func killRunningStep() {
+	if err := tools.KillRunningProcessGroup(); err != nil {
+		log.Errorf("Failed to kill the running step, error: %s", err)
+	}
+}
+
+// startBuildTimeout starts the timeout of the whole run, if set.
+// Once the grace period after the timeout is exceeded as well, the running step is aborted,
+// and the rest of the steps are skipped (see: bitrise.TimeoutModel.IsGraceExpired), so the build still finishes its reporting.
+// If the build does not finish in bitrise.BuildTimeoutReportingSecs after that (e.g. it hangs outside of a step process),
+// the CLI exits with exitcode.StepTimedOut.
+func startBuildTimeout() {
+	buildTimeout = bitrise.StartBuildTimeout(configs.BuildTimeoutSecs, configs.BuildTimeoutGraceSecs, bitrise.BuildTimeoutReportingSecs, func() {
+		log.Warnf("The build timed out after %d sec, aborting the running step, the is_always_run steps get %d sec to finish...",
+			configs.BuildTimeoutSecs, configs.BuildTimeoutGraceSecs)
+		killRunningStep()
+	}, func() {
+		log.Errorf("The build timed out, and the is_always_run steps did not finish in %d sec, aborting the running step", configs.BuildTimeoutGraceSecs)
+		killRunningStep()
+	}, func() {
+		killRunningStep()
+		exitcode.Fatalf(exitcode.StepTimedOut, "The build timed out, and it did not finish in %d sec after the grace period, exiting", bitrise.BuildTimeoutReportingSecs)
+	})
+}
+
+// stopBuildTimeout ...
+func stopBuildTimeout() {
+	buildTimeout.Stop()
+}
+
+// startWorkflowTimeout starts the timeout of the workflow's steps, if the workflow has a timeout_secs
+func startWorkflowTimeout(workflow models.WorkflowModel) *bitrise.TimeoutModel {
+	return bitrise.StartWorkflowTimeout(workflow.TimeoutSecs, func() {
+		log.Warnf("The workflow (%s) timed out after %d sec, aborting the running step...", workflow.Title, workflow.TimeoutSecs)
+		killRunningStep()
+	})
+}
+
+// reportExpiredTimeout returns the error of the build or workflow timeout, if it's exceeded and not reported yet
+// (see: bitrise.TimeoutModel.ReportExpired), nil otherwise.
+func reportExpiredTimeout(workflowTimeout *bitrise.TimeoutModel) error {
+	if buildTimeout.ReportExpired() {
+		return buildTimeout.Err()
+	}
+	if workflowTimeout.ReportExpired() {
+		return workflowTimeout.Err()
+	}
+	return nil
+}
+
+// stepTimeoutState is the state of the build and workflow timeouts at the start of a step
+type stepTimeoutState struct {
+	isBuildTimeoutExpired    bool
+	isWorkflowTimeoutExpired bool
+}
+
+// timeoutStateAtStepStart ...
+func timeoutStateAtStepStart(workflowTimeout *bitrise.TimeoutModel) stepTimeoutState {
+	return stepTimeoutState{
+		isBuildTimeoutExpired:    buildTimeout.IsExpired(),
+		isWorkflowTimeoutExpired: workflowTimeout.IsExpired(),
+	}
+}
+
+// reportTimeoutExpiredDuringStep returns the error of the build or workflow timeout (see: reportExpiredTimeout) for a failed step,
+// only if the timeout was exceeded while the step was running (the step was aborted by the timeout).
+// An is_always_run step, which started after the timeout, fails with its own error.
+func reportTimeoutExpiredDuringStep(startState stepTimeoutState, workflowTimeout *bitrise.TimeoutModel) error {
+	if !startState.isBuildTimeoutExpired && buildTimeout.ReportExpired() {
+		return buildTimeout.Err()
+	}
+	if !startState.isWorkflowTimeoutExpired && workflowTimeout.ReportExpired() {
+		return workflowTimeout.Err()
+	}
+	return nil
+}
+
+// reportExpiredTimeoutOfStep returns the error of the build or workflow timeout (see: reportExpiredTimeout) for the step,
+// which is about to run, if the step would not run on a failed build. The is_always_run steps still run after a timeout.
+func reportExpiredTimeoutOfStep(step models.WorkflowStepModel, workflowTimeout *bitrise.TimeoutModel) error {
+	if isStepRunWithBuildStatus(step, true) {
+		return nil
+	}
+	return reportExpiredTimeout(workflowTimeout)
+}
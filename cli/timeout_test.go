@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/exitcode"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/pointers"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportExpiredTimeoutOfStep(t *testing.T) {
+	step := models.WorkflowStepModel{StepModel: stepmanModels.StepModel{IsAlwaysRun: pointers.NewBoolPtr(false)}}
+	alwaysRunStep := models.WorkflowStepModel{StepModel: stepmanModels.StepModel{IsAlwaysRun: pointers.NewBoolPtr(true)}}
+
+	t.Log("no timeout")
+	{
+		require.NoError(t, reportExpiredTimeoutOfStep(step, nil))
+	}
+
+	t.Log("expired workflow timeout")
+	{
+		expired := make(chan bool, 1)
+		workflowTimeout := bitrise.StartWorkflowTimeout(1, func() {
+			expired <- true
+		})
+		defer workflowTimeout.Stop()
+
+		select {
+		case <-expired:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the timeout did not expire")
+		}
+
+		// the is_always_run steps still run
+		require.NoError(t, reportExpiredTimeoutOfStep(alwaysRunStep, workflowTimeout))
+
+		err := reportExpiredTimeoutOfStep(step, workflowTimeout)
+		require.Error(t, err)
+		require.True(t, exitcode.Is(err, exitcode.StepTimedOut))
+
+		// reported only once
+		require.NoError(t, reportExpiredTimeoutOfStep(step, workflowTimeout))
+	}
+}
+
+func TestReportTimeoutExpiredDuringStep(t *testing.T) {
+	t.Log("no timeout")
+	{
+		require.NoError(t, reportTimeoutExpiredDuringStep(timeoutStateAtStepStart(nil), nil))
+	}
+
+	t.Log("expired while the step was running")
+	{
+		expired := make(chan bool, 1)
+		workflowTimeout := bitrise.StartWorkflowTimeout(1, func() {
+			expired <- true
+		})
+		defer workflowTimeout.Stop()
+
+		timeoutState := timeoutStateAtStepStart(workflowTimeout)
+		select {
+		case <-expired:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the timeout did not expire")
+		}
+
+		err := reportTimeoutExpiredDuringStep(timeoutState, workflowTimeout)
+		require.Error(t, err)
+		require.True(t, exitcode.Is(err, exitcode.StepTimedOut))
+	}
+
+	t.Log("expired before the step started")
+	{
+		expired := make(chan bool, 1)
+		workflowTimeout := bitrise.StartWorkflowTimeout(1, func() {
+			expired <- true
+		})
+		defer workflowTimeout.Stop()
+
+		select {
+		case <-expired:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the timeout did not expire")
+		}
+
+		// an is_always_run step fails with its own error
+		timeoutState := timeoutStateAtStepStart(workflowTimeout)
+		require.NoError(t, reportTimeoutExpiredDuringStep(timeoutState, workflowTimeout))
+
+		// the expired timeout is still reported for the next step
+		require.Error(t, reportExpiredTimeout(workflowTimeout))
+	}
+}
@@ -3,9 +3,10 @@ package cli
 import (
 	"fmt"
 	"os"
-	"strings"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/exitcode"
 	"github.com/bitrise-io/bitrise/models"
 	"github.com/bitrise-io/bitrise/version"
 	"github.com/bitrise-io/go-utils/pointers"
@@ -31,6 +32,9 @@ func printAvailableTriggerFilters(triggerMap []models.TriggerMapItemModel) {
 			} else if triggerItem.PullRequestSourceBranch != "" || triggerItem.PullRequestTargetBranch != "" {
 				log.Infof(" * pull_request_source_branch: %s", triggerItem.PullRequestSourceBranch)
 				log.Infof("   pull_request_target_branch: %s", triggerItem.PullRequestTargetBranch)
+				if !triggerItem.IsDraftPullRequestEnabled() {
+					log.Infof("   draft_pull_request_enabled: false")
+				}
 				log.Infof("   workflow: %s", triggerItem.WorkflowID)
 			} else if triggerItem.Tag != "" {
 				log.Infof(" * tag: %s", triggerItem.Tag)
@@ -45,7 +49,9 @@ func printAvailableTriggerFilters(triggerMap []models.TriggerMapItemModel) {
 // --------------------
 
 func trigger(c *cli.Context) error {
-	PrintBitriseHeaderASCIIArt(version.VERSION)
+	if !c.Bool(QuietKey) {
+		PrintBitriseHeaderASCIIArt(version.VERSION)
+	}
 
 	// Expand cli.Context
 	var prGlobalFlagPtr *bool
@@ -91,20 +97,24 @@ func trigger(c *cli.Context) error {
 	if err != nil {
 		return fmt.Errorf("Failed to parse trigger command params, error: %s", err)
 	}
+	if c.Bool(PRDraftKey) {
+		triggerParams.PRDraft = true
+	}
 
 	// Inventory validation
 	inventoryEnvironments, err := CreateInventoryFromCLIParams(triggerParams.InventoryBase64Data, triggerParams.InventoryPath)
 	if err != nil {
-		log.Fatalf("Failed to create inventory, error: %s", err)
+		exitcode.Fatalf(exitcode.ConfigInvalid, "Failed to create inventory, error: %s", err)
 	}
 
 	// Config validation
+	configs.IsStrictConfigMode = c.Bool(StrictKey)
 	bitriseConfig, warnings, err := CreateBitriseConfigFromCLIParams(triggerParams.BitriseConfigBase64Data, triggerParams.BitriseConfigPath)
 	for _, warning := range warnings {
 		log.Warnf("warning: %s", warning)
 	}
 	if err != nil {
-		log.Fatalf("Failed to create bitrise config, error: %s", err)
+		exitcode.Fatalf(exitcode.ConfigInvalid, "Failed to create bitrise config, error: %s", err)
 	}
 
 	// Trigger filter validation
@@ -112,7 +122,7 @@ func trigger(c *cli.Context) error {
 		triggerParams.PushBranch == "" && triggerParams.PRSourceBranch == "" && triggerParams.PRTargetBranch == "" && triggerParams.Tag == "" {
 		log.Error("No trigger pattern nor trigger params specified")
 		printAvailableTriggerFilters(bitriseConfig.TriggerMap)
-		os.Exit(1)
+		os.Exit(exitcode.Usage)
 	}
 	//
 
@@ -135,14 +145,46 @@ func trigger(c *cli.Context) error {
 		log.Fatalf("Failed to register  CI mode, error: %s", err)
 	}
 
-	workflowToRunID, err := getWorkflowIDByParamsInCompatibleMode(bitriseConfig.TriggerMap, triggerParams, isPRMode)
+	configs.BuildSummaryPath = c.String(SummaryPathKey)
+	configs.IsStepProfileMode = c.Bool(ProfileKey)
+	configs.StepProfilePath = c.String(ProfilePathKey)
+	configs.LogStreamAddress = c.String(LogStreamKey)
+	if err := registerQuietMode(c.Bool(QuietKey)); err != nil {
+		log.Fatalf("Failed to register quiet mode, error: %s", err)
+	}
+	configs.RunLogDir = c.String(LogDirKey)
+	configs.RunLogMaxSizeMB = c.Int(LogMaxSizeKey)
+	configs.RunLogRetention = c.Int(LogRetentionKey)
+	configs.IsNoDeprecatedMode = c.Bool(NoDeprecatedKey)
+	if err := registerOrgPolicy(); err != nil {
+		exitcode.Fatalf(exitcode.ConfigInvalid, "Failed to load organization policy, error: %s", err)
+	}
+	if err := registerStepAuditMode(c.String(StepAuditKey)); err != nil {
+		exitcode.Fatalf(exitcode.Usage, "Failed to register step audit mode, error: %s", err)
+	}
+	if err := registerDependencyManager(c.String(DependencyManagerKey)); err != nil {
+		exitcode.Fatalf(exitcode.Usage, "Failed to register dependency manager, error: %s", err)
+	}
+	if err := registerArtifactUpload(c.String(ArtifactUploadKey), c.Int(ArtifactRetentionDaysKey)); err != nil {
+		exitcode.Fatalf(exitcode.Usage, "Failed to register artifact upload, error: %s", err)
+	}
+	if err := registerBuildTimeout(c.Int(TimeoutKey), c.Int(TimeoutGraceKey)); err != nil {
+		exitcode.Fatalf(exitcode.Usage, "Failed to register build timeout, error: %s", err)
+	}
+
+	if err := registerLockedMode(c.Bool(LockedKey), c.Bool(UpdateLockKey), triggerParams.BitriseConfigBase64Data, triggerParams.BitriseConfigPath); err != nil {
+		exitcode.Fatalf(exitcode.Usage, "Failed to register locked mode, error: %s", err)
+	}
+
+	triggerItem, err := getTriggerItemByParamsInCompatibleMode(bitriseConfig.TriggerMap, triggerParams, isPRMode)
 	if err != nil {
 		log.Errorf("Failed to get workflow id by pattern, error: %s", err)
-		if strings.Contains(err.Error(), "no matching workflow found with trigger params:") {
+		if exitcode.Is(err, exitcode.TriggerNotMatched) {
 			printAvailableTriggerFilters(bitriseConfig.TriggerMap)
 		}
-		os.Exit(1)
+		os.Exit(exitcode.Code(err))
 	}
+	workflowToRunID := triggerItem.WorkflowID
 
 	if triggerParams.TriggerPattern != "" {
 		log.Infof("pattern (%s) triggered workflow (%s)", triggerParams.TriggerPattern, workflowToRunID)
@@ -152,11 +194,34 @@ func trigger(c *cli.Context) error {
 		} else if triggerParams.PRSourceBranch != "" || triggerParams.PRTargetBranch != "" {
 			log.Infof("pr-source-branch (%s) and pr-target-branch (%s) triggered workflow (%s)", triggerParams.PRSourceBranch, triggerParams.PRTargetBranch, workflowToRunID)
 		} else if triggerParams.Tag != "" {
-			log.Infof("tag (%s) triggered workflow (%s)", triggerParams.Tag, workflowToRunID)
+			log.Infof("tag (%s) matched tag pattern (%s), triggered workflow (%s)", triggerParams.Tag, triggerItem.Tag, workflowToRunID)
 		}
 	}
 
-	runAndExit(bitriseConfig, inventoryEnvironments, workflowToRunID)
+	if err := registerCLIEnvironments(c.StringSlice(EnvKey), c.StringSlice(EnvFileKey)); err != nil {
+		exitcode.Fatalf(exitcode.Usage, "Failed to parse envs, error: %s", err)
+	}
+
+	inputValues, err := parseWorkflowInputValues(c.StringSlice(InputKey))
+	if err != nil {
+		exitcode.Fatalf(exitcode.Usage, "Failed to parse workflow inputs, error: %s", err)
+	}
+	for key, value := range triggerItem.Inputs {
+		if _, found := inputValues[key]; !found {
+			inputValues[key] = value
+		}
+	}
+
+	workflowToRunID, err = resolveWorkflowAlias(bitriseConfig, workflowToRunID)
+	if err != nil {
+		exitcode.Fatal(exitcode.Usage, err)
+	}
+
+	if err := registerWorkflowInputs(bitriseConfig, workflowToRunID, inputValues); err != nil {
+		exitcode.Fatalf(exitcode.ConfigInvalid, "Invalid workflow inputs, error: %s", err)
+	}
+
+	runAndExit(bitriseConfig, inventoryEnvironments, workflowToRunID, nil)
 	//
 
 	return nil
@@ -6,9 +6,9 @@ import (
 	"os"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/exitcode"
 	"github.com/bitrise-io/bitrise/models"
 	"github.com/bitrise-io/bitrise/output"
-	"github.com/bitrise-io/go-utils/colorstring"
 	"github.com/bitrise-io/go-utils/pointers"
 	"github.com/urfave/cli"
 )
@@ -18,17 +18,22 @@ import (
 // --------------------
 
 func registerFatal(errorMsg string, warnings []string, format string) {
+	registerFatalWithExitCode(exitcode.InternalError, errorMsg, warnings, format)
+}
+
+// registerFatalWithExitCode prints the error in the given format, then exits with the exit code of the failure's class
+func registerFatalWithExitCode(code int, errorMsg string, warnings []string, format string) {
 	message := ValidationItemModel{
 		IsValid:  (len(errorMsg) > 0),
 		Error:    errorMsg,
 		Warnings: warnings,
 	}
 
-	if format == output.FormatRaw {
+	if format == output.FormatRaw || format == output.FormatMarkdown || format == output.FormatJUnit {
 		for _, warning := range message.Warnings {
 			log.Warnf("warning: %s", warning)
 		}
-		log.Fatal(message.Error)
+		exitcode.Fatal(code, message.Error)
 	} else {
 		bytes, err := json.Marshal(message)
 		if err != nil {
@@ -36,7 +41,7 @@ func registerFatal(errorMsg string, warnings []string, format string) {
 		}
 
 		fmt.Println(string(bytes))
-		os.Exit(1)
+		os.Exit(code)
 	}
 }
 
@@ -58,28 +63,101 @@ func migratePatternToParams(params RunAndTriggerParamsModel, isPullRequestMode b
 	return params
 }
 
-func getWorkflowIDByParams(triggerMap models.TriggerMapModel, params RunAndTriggerParamsModel) (string, error) {
+func getTriggerItemByParams(triggerMap models.TriggerMapModel, params RunAndTriggerParamsModel) (models.TriggerMapItemModel, error) {
 	for _, item := range triggerMap {
-		match, err := item.MatchWithParams(params.PushBranch, params.PRSourceBranch, params.PRTargetBranch, params.Tag)
+		match, err := item.MatchWithPullRequestDraftParams(params.PushBranch, params.PRSourceBranch, params.PRTargetBranch, params.Tag, params.PRDraft)
 		if err != nil {
-			return "", err
+			return models.TriggerMapItemModel{}, err
 		}
 		if match {
-			return item.WorkflowID, nil
+			return item, nil
 		}
 	}
 
-	return "", fmt.Errorf("no matching workflow found with trigger params: push-branch: %s, pr-source-branch: %s, pr-target-branch: %s, tag: %s", params.PushBranch, params.PRSourceBranch, params.PRTargetBranch, params.Tag)
+	return models.TriggerMapItemModel{}, exitcode.Errorf(exitcode.TriggerNotMatched, "no matching workflow found with trigger params: push-branch: %s, pr-source-branch: %s, pr-target-branch: %s, tag: %s", params.PushBranch, params.PRSourceBranch, params.PRTargetBranch, params.Tag)
+}
+
+func getWorkflowIDByParams(triggerMap models.TriggerMapModel, params RunAndTriggerParamsModel) (string, error) {
+	item, err := getTriggerItemByParams(triggerMap, params)
+	if err != nil {
+		return "", err
+	}
+	return item.WorkflowID, nil
+}
+
+// migrates deprecated params.TriggerPattern to params.PushBranch or params.PRSourceBranch based on isPullRequestMode
+// and returns the matching trigger map item
+func getTriggerItemByParamsInCompatibleMode(triggerMap models.TriggerMapModel, params RunAndTriggerParamsModel, isPullRequestMode bool) (models.TriggerMapItemModel, error) {
+	if params.TriggerPattern != "" {
+		params = migratePatternToParams(params, isPullRequestMode)
+	}
+
+	return getTriggerItemByParams(triggerMap, params)
 }
 
 // migrates deprecated params.TriggerPattern to params.PushBranch or params.PRSourceBranch based on isPullRequestMode
 // and returns the triggered workflow id
 func getWorkflowIDByParamsInCompatibleMode(triggerMap models.TriggerMapModel, params RunAndTriggerParamsModel, isPullRequestMode bool) (string, error) {
+	item, err := getTriggerItemByParamsInCompatibleMode(triggerMap, params, isPullRequestMode)
+	if err != nil {
+		return "", err
+	}
+	return item.WorkflowID, nil
+}
+
+// TriggerCheckItemExplanationModel is the evaluation of a trigger map item
+type TriggerCheckItemExplanationModel struct {
+	Index      int    `json:"index"`
+	WorkflowID string `json:"workflow"`
+	Evaluated  bool   `json:"evaluated"`
+	models.TriggerMatchExplanationModel
+}
+
+// TriggerCheckExplanationModel is the output of trigger-check --explain
+type TriggerCheckExplanationModel struct {
+	Params           map[string]string                  `json:"params"`
+	Items            []TriggerCheckItemExplanationModel `json:"items"`
+	SelectedWorkflow string                             `json:"selected_workflow"`
+}
+
+// explainTriggerMap evaluates the trigger map items in order, like getTriggerItemByParamsInCompatibleMode,
+// the items after the first matching one are not evaluated
+func explainTriggerMap(triggerMap models.TriggerMapModel, params RunAndTriggerParamsModel, isPullRequestMode bool) TriggerCheckExplanationModel {
 	if params.TriggerPattern != "" {
 		params = migratePatternToParams(params, isPullRequestMode)
 	}
 
-	return getWorkflowIDByParams(triggerMap, params)
+	explanation := TriggerCheckExplanationModel{
+		Params: map[string]string{
+			"push-branch":      params.PushBranch,
+			"pr-source-branch": params.PRSourceBranch,
+			"pr-target-branch": params.PRTargetBranch,
+			"tag":              params.Tag,
+			"pr-draft":         fmt.Sprintf("%t", params.PRDraft),
+		},
+		Items: []TriggerCheckItemExplanationModel{},
+	}
+
+	for idx, item := range triggerMap {
+		itemExplanation := TriggerCheckItemExplanationModel{
+			Index:      idx,
+			WorkflowID: item.WorkflowID,
+		}
+
+		if explanation.SelectedWorkflow != "" {
+			itemExplanation.Reason = "not evaluated, a previous item matched"
+		} else {
+			itemExplanation.Evaluated = true
+			itemExplanation.TriggerMatchExplanationModel = item.ExplainMatch(params.PushBranch, params.PRSourceBranch, params.PRTargetBranch, params.Tag, params.PRDraft)
+			if itemExplanation.Matched {
+				explanation.SelectedWorkflow = item.WorkflowID
+			}
+		}
+
+		explanation.Items = append(explanation.Items, itemExplanation)
+	}
+
+	return explanation
 }
 
 // --------------------
@@ -132,19 +210,22 @@ func triggerCheck(c *cli.Context) error {
 	if err != nil {
 		registerFatal(fmt.Sprintf("Failed to parse trigger check params, err: %s", err), warnings, triggerParams.Format)
 	}
+	if c.Bool(PRDraftKey) {
+		triggerParams.PRDraft = true
+	}
 	//
 
 	// Inventory validation
 	inventoryEnvironments, err := CreateInventoryFromCLIParams(triggerParams.InventoryBase64Data, triggerParams.InventoryPath)
 	if err != nil {
-		registerFatal(fmt.Sprintf("Failed to create inventory, err: %s", err), warnings, triggerParams.Format)
+		registerFatalWithExitCode(exitcode.ConfigInvalid, fmt.Sprintf("Failed to create inventory, err: %s", err), warnings, triggerParams.Format)
 	}
 
 	// Config validation
 	bitriseConfig, warns, err := CreateBitriseConfigFromCLIParams(triggerParams.BitriseConfigBase64Data, triggerParams.BitriseConfigPath)
 	warnings = append(warnings, warns...)
 	if err != nil {
-		registerFatal(fmt.Sprintf("Failed to create config, err: %s", err), warnings, triggerParams.Format)
+		registerFatalWithExitCode(exitcode.ConfigInvalid, fmt.Sprintf("Failed to create config, err: %s", err), warnings, triggerParams.Format)
 	}
 
 	// Format validation
@@ -168,10 +249,24 @@ func triggerCheck(c *cli.Context) error {
 		registerFatal(fmt.Sprintf("Failed to check  PR mode, err: %s", err), warnings, triggerParams.Format)
 	}
 
-	workflowToRunID, err := getWorkflowIDByParamsInCompatibleMode(bitriseConfig.TriggerMap, triggerParams, isPRMode)
+	if c.Bool(ExplainKey) {
+		explanation := explainTriggerMap(bitriseConfig.TriggerMap, triggerParams, isPRMode)
+		bytes, err := json.MarshalIndent(explanation, "", "  ")
+		if err != nil {
+			registerFatal(fmt.Sprintf("Failed to serialize trigger explanation, err: %s", err), warnings, output.FormatJSON)
+		}
+		fmt.Println(string(bytes))
+		if explanation.SelectedWorkflow == "" {
+			os.Exit(exitcode.TriggerNotMatched)
+		}
+		return nil
+	}
+
+	triggerItem, err := getTriggerItemByParamsInCompatibleMode(bitriseConfig.TriggerMap, triggerParams, isPRMode)
 	if err != nil {
-		registerFatal(err.Error(), warnings, triggerParams.Format)
+		registerFatalWithExitCode(exitcode.Code(err), err.Error(), warnings, triggerParams.Format)
 	}
+	workflowToRunID := triggerItem.WorkflowID
 
 	triggerModel := map[string]string{"workflow": workflowToRunID}
 
@@ -187,8 +282,12 @@ func triggerCheck(c *cli.Context) error {
 			if triggerParams.PRTargetBranch != "" {
 				triggerModel["pr-target-branch"] = triggerParams.PRTargetBranch
 			}
+			if triggerParams.PRDraft {
+				triggerModel["pr-draft"] = "true"
+			}
 		} else if triggerParams.Tag != "" {
 			triggerModel["tag"] = triggerParams.Tag
+			triggerModel["tag-pattern"] = triggerItem.Tag
 		}
 	}
 
@@ -197,7 +296,7 @@ func triggerCheck(c *cli.Context) error {
 		msg := ""
 		for key, value := range triggerModel {
 			if key == "workflow" {
-				msg = msg + fmt.Sprintf("-> %s", colorstring.Blue(value))
+				msg = msg + fmt.Sprintf("-> %s", output.Blue(value))
 			} else {
 				msg = fmt.Sprintf("%s: %s ", key, value) + msg
 			}
@@ -408,6 +408,49 @@ workflows:
 	}
 }
 
+func TestGetTriggerItemByParamsInCompatibleMode_tag(t *testing.T) {
+	configStr := `
+trigger_map:
+- push_branch: "*"
+  workflow: primary
+- tag: "re:^v[0-9]+\\.[0-9]+\\.[0-9]+$"
+  workflow: release
+- tag: "v*"
+  workflow: prerelease
+
+workflows:
+  primary:
+  release:
+  prerelease:
+`
+
+	config, warnings, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	t.Log("tag matches the regex tag pattern")
+	{
+		item, err := getTriggerItemByParamsInCompatibleMode(config.TriggerMap, RunAndTriggerParamsModel{Tag: "v1.2.0"}, false)
+		require.NoError(t, err)
+		require.Equal(t, "release", item.WorkflowID)
+		require.Equal(t, "re:^v[0-9]+\\.[0-9]+\\.[0-9]+$", item.Tag)
+	}
+
+	t.Log("tag matches the glob tag pattern")
+	{
+		item, err := getTriggerItemByParamsInCompatibleMode(config.TriggerMap, RunAndTriggerParamsModel{Tag: "v1.2.0-beta"}, false)
+		require.NoError(t, err)
+		require.Equal(t, "prerelease", item.WorkflowID)
+		require.Equal(t, "v*", item.Tag)
+	}
+
+	t.Log("tag - no match")
+	{
+		_, err := getTriggerItemByParamsInCompatibleMode(config.TriggerMap, RunAndTriggerParamsModel{Tag: "1.2.0"}, false)
+		require.Error(t, err)
+	}
+}
+
 func TestGetWorkflowIDByParamsInCompatibleMode_migration_test(t *testing.T) {
 	t.Log("deprecated code push trigger item")
 	{
@@ -620,3 +663,109 @@ workflows:
 		require.Equal(t, "", workflowID)
 	}
 }
+
+func TestGetTriggerItemByParamsInCompatibleMode_draftPR(t *testing.T) {
+	configStr := `
+trigger_map:
+- pull_request_source_branch: "*"
+  pull_request_target_branch: release/*
+  workflow: release_check
+- pull_request_target_branch: master
+  draft_pull_request_enabled: false
+  workflow: primary
+- pull_request_target_branch: "*"
+  workflow: draft
+
+workflows:
+  release_check:
+  primary:
+  draft:
+`
+
+	config, warnings, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	t.Log("routed by target branch")
+	{
+		params := RunAndTriggerParamsModel{PRSourceBranch: "feature", PRTargetBranch: "release/1.0", PRDraft: true}
+		workflowID, err := getWorkflowIDByParamsInCompatibleMode(config.TriggerMap, params, false)
+		require.NoError(t, err)
+		require.Equal(t, "release_check", workflowID)
+	}
+
+	t.Log("ready pull request")
+	{
+		params := RunAndTriggerParamsModel{PRSourceBranch: "feature", PRTargetBranch: "master"}
+		workflowID, err := getWorkflowIDByParamsInCompatibleMode(config.TriggerMap, params, false)
+		require.NoError(t, err)
+		require.Equal(t, "primary", workflowID)
+	}
+
+	t.Log("draft pull request skips the item, which disables drafts")
+	{
+		params := RunAndTriggerParamsModel{PRSourceBranch: "feature", PRTargetBranch: "master", PRDraft: true}
+		workflowID, err := getWorkflowIDByParamsInCompatibleMode(config.TriggerMap, params, false)
+		require.NoError(t, err)
+		require.Equal(t, "draft", workflowID)
+	}
+
+	t.Log("pr-draft json param")
+	{
+		params, err := parseRunAndTriggerJSONParams(`{"pr-target-branch":"master","pr-draft":true}`)
+		require.NoError(t, err)
+		require.Equal(t, true, params.PRDraft)
+	}
+}
+
+func TestExplainTriggerMap(t *testing.T) {
+	configStr := `
+trigger_map:
+- tag: v*
+  workflow: release
+- push_branch: master
+  workflow: deploy
+- push_branch: "*"
+  workflow: primary
+
+workflows:
+  release:
+  deploy:
+  primary:
+`
+
+	config, warnings, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	t.Log("items after the matching one are not evaluated")
+	{
+		explanation := explainTriggerMap(config.TriggerMap, RunAndTriggerParamsModel{PushBranch: "master"}, false)
+		require.Equal(t, "deploy", explanation.SelectedWorkflow)
+		require.Equal(t, 3, len(explanation.Items))
+
+		require.Equal(t, true, explanation.Items[0].Evaluated)
+		require.Equal(t, false, explanation.Items[0].Matched)
+		require.Contains(t, explanation.Items[0].Reason, "event type mismatch")
+
+		require.Equal(t, true, explanation.Items[1].Evaluated)
+		require.Equal(t, true, explanation.Items[1].Matched)
+
+		require.Equal(t, false, explanation.Items[2].Evaluated)
+		require.Equal(t, "primary", explanation.Items[2].WorkflowID)
+	}
+
+	t.Log("deprecated trigger pattern")
+	{
+		explanation := explainTriggerMap(config.TriggerMap, RunAndTriggerParamsModel{TriggerPattern: "feature"}, false)
+		require.Equal(t, "primary", explanation.SelectedWorkflow)
+		require.Equal(t, "feature", explanation.Params["push-branch"])
+	}
+
+	t.Log("no match")
+	{
+		explanation := explainTriggerMap(config.TriggerMap, RunAndTriggerParamsModel{Tag: "1.0"}, false)
+		require.Equal(t, "", explanation.SelectedWorkflow)
+		require.Equal(t, "tag pattern (v*) does not match (1.0)", explanation.Items[0].Reason)
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/exitcode"
+	"github.com/bitrise-io/bitrise/models"
 	"github.com/bitrise-io/bitrise/output"
-	"github.com/bitrise-io/go-utils/colorstring"
 	"github.com/urfave/cli"
 )
 
@@ -16,39 +20,50 @@ type ValidationItemModel struct {
 	IsValid  bool     `json:"is_valid" yaml:"is_valid"`
 	Error    string   `json:"error,omitempty" yaml:"error,omitempty"`
 	Warnings []string `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+	Lints    []string `json:"lints,omitempty" yaml:"lints,omitempty"`
+	// StepInputs lists the step inputs, which don't match the steps' definitions (validate --deep)
+	StepInputs []string `json:"step_inputs,omitempty" yaml:"step_inputs,omitempty"`
 }
 
 // ValidationModel ...
 type ValidationModel struct {
 	Config  *ValidationItemModel `json:"config,omitempty" yaml:"config,omitempty"`
 	Secrets *ValidationItemModel `json:"secrets,omitempty" yaml:"secrets,omitempty"`
+	// Issues lists every problem of the config and the secrets, with the path of the invalid element
+	Issues []ValidationIssueModel `json:"issues,omitempty" yaml:"issues,omitempty"`
 }
 
 func printRawValidation(validation ValidationModel) error {
 	validConfig := true
 	if validation.Config != nil {
-		fmt.Println(colorstring.Blue("Config validation result:"))
+		fmt.Println(output.Blue("Config validation result:"))
 		configValidation := *validation.Config
 		if configValidation.IsValid {
-			fmt.Printf("is valid: %s\n", colorstring.Greenf("%v", configValidation.IsValid))
+			fmt.Printf("is valid: %s\n", output.Greenf("%v", configValidation.IsValid))
 		} else {
-			fmt.Printf("is valid: %s\n", colorstring.Redf("%v", configValidation.IsValid))
-			fmt.Printf("error: %s\n", colorstring.Red(configValidation.Error))
+			fmt.Printf("is valid: %s\n", output.Redf("%v", configValidation.IsValid))
+			fmt.Printf("error: %s\n", output.Red(configValidation.Error))
 
 			validConfig = false
 		}
+		for _, issue := range configValidation.StepInputs {
+			fmt.Printf("step input: %s\n", output.Red(issue))
+		}
+		for _, lint := range configValidation.Lints {
+			fmt.Printf("lint: %s\n", output.Yellow(lint))
+		}
 		fmt.Println()
 	}
 
 	validSecrets := true
 	if validation.Secrets != nil {
-		fmt.Println(colorstring.Blue("Secret validation result:"))
+		fmt.Println(output.Blue("Secret validation result:"))
 		secretValidation := *validation.Secrets
 		if secretValidation.IsValid {
-			fmt.Printf("is valid: %s\n", colorstring.Greenf("%v", secretValidation.IsValid))
+			fmt.Printf("is valid: %s\n", output.Greenf("%v", secretValidation.IsValid))
 		} else {
-			fmt.Printf("is valid: %s\n", colorstring.Redf("%v", secretValidation.IsValid))
-			fmt.Printf("error: %s\n", colorstring.Red(secretValidation.Error))
+			fmt.Printf("is valid: %s\n", output.Redf("%v", secretValidation.IsValid))
+			fmt.Printf("error: %s\n", output.Red(secretValidation.Error))
 
 			validSecrets = false
 		}
@@ -73,53 +88,100 @@ func printJSONValidation(validation ValidationModel) {
 	fmt.Println(string(bytes))
 }
 
-func validate(c *cli.Context) error {
-	warnings := []string{}
-
-	// Expand cli.Context
-	inventoryBase64Data := c.String(InventoryBase64Key)
-	inventoryPath := c.String(InventoryKey)
+// lintConfig returns the structural problems of the config (found by the node based config parser, see: bitrise.ParseConfigNodes)
+func lintConfig(bitriseConfigBase64Data, bitriseConfigPath string) ([]bitrise.ConfigParseErrorModel, error) {
+	configBytes, _, err := readBitriseConfigBytes(bitriseConfigBase64Data, bitriseConfigPath)
+	if err != nil {
+		return []bitrise.ConfigParseErrorModel{}, err
+	}
 
-	bitriseConfigBase64Data := c.String(ConfigBase64Key)
+	_, parseErrors := bitrise.ParseConfigNodes(string(configBytes))
+	return parseErrors, nil
+}
 
-	bitriseConfigPath := c.String(ConfigKey)
-	deprecatedBitriseConfigPath := c.String(PathKey)
-	if bitriseConfigPath == "" && deprecatedBitriseConfigPath != "" {
-		warnings = append(warnings, "'path' key is deprecated, use 'config' instead!")
-		bitriseConfigPath = deprecatedBitriseConfigPath
+// validateStepInputs validates the step inputs of every workflow against the steps' definitions (step.yml).
+func validateStepInputs(config models.BitriseDataModel) ([]string, []string, error) {
+	if err := config.ExpandStepBundles(); err != nil {
+		return []string{}, []string{}, fmt.Errorf("Failed to expand step bundles, error: %s", err)
+	}
+	if err := config.ApplyStepDefaults(); err != nil {
+		return []string{}, []string{}, fmt.Errorf("Failed to apply step defaults, error: %s", err)
 	}
 
-	format := c.String(OuputFormatKey)
-	//
-
-	if format == "" {
-		format = output.FormatRaw
-	} else if !(format == output.FormatRaw || format == output.FormatJSON) {
-		registerFatal(fmt.Sprintf("Invalid format: %s", format), warnings, output.FormatJSON)
+	issues, warnings, err := bitrise.ValidateWorkflowStepInputs(config, bitrise.SortedWorkflowIDs(config))
+	if err != nil {
+		return []string{}, []string{}, fmt.Errorf("Failed to validate step inputs, error: %s", err)
 	}
+	return issues, warnings, nil
+}
 
+func validateConfigAndSecrets(bitriseConfigBase64Data, bitriseConfigPath, inventoryBase64Data, inventoryPath string, lint, deep bool) (ValidationModel, error) {
 	validation := ValidationModel{}
 
 	pth, err := GetBitriseConfigFilePath(bitriseConfigPath)
 	if err != nil && err.Error() != "No workflow yml found" {
-		registerFatal(fmt.Sprintf("Failed to get config path, err: %s", err), warnings, format)
+		return ValidationModel{}, fmt.Errorf("Failed to get config path, err: %s", err)
 	}
 	if pth != "" || (pth == "" && bitriseConfigBase64Data != "") {
 		// Config validation
 		isValid := true
 		errMsg := ""
 
-		_, warns, err := CreateBitriseConfigFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath)
-		warnings = append(warnings, warns...)
+		stepInputIssues := []string{}
+
+		config, warnings, err := CreateBitriseConfigFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath)
 		if err != nil {
 			isValid = false
 			errMsg = err.Error()
+		} else if deep {
+			issues, stepInputWarnings, err := validateStepInputs(config)
+			stepInputIssues = issues
+			warnings = append(warnings, stepInputWarnings...)
+			if err != nil {
+				isValid = false
+				errMsg = err.Error()
+			} else if len(stepInputIssues) > 0 {
+				isValid = false
+				errMsg = "Invalid step inputs"
+			}
 		}
 
 		validation.Config = &ValidationItemModel{
-			IsValid:  isValid,
-			Error:    errMsg,
-			Warnings: warnings,
+			IsValid:    isValid,
+			Error:      errMsg,
+			Warnings:   warnings,
+			StepInputs: stepInputIssues,
+		}
+
+		if len(stepInputIssues) > 0 {
+			for _, issue := range stepInputIssues {
+				validation.Issues = append(validation.Issues, ValidationIssueModel{Source: ValidationIssueSourceConfig, Severity: ValidationIssueSeverityError, Message: issue})
+			}
+		} else if !isValid {
+			validation.Issues = append(validation.Issues, configValidationErrorIssues(bitriseConfigBase64Data, bitriseConfigPath, errMsg)...)
+		}
+		for _, warning := range warnings {
+			validation.Issues = append(validation.Issues, ValidationIssueModel{Source: ValidationIssueSourceConfig, Severity: ValidationIssueSeverityWarning, Message: warning})
+		}
+
+		if lint {
+			// an unreadable config is already reported as invalid
+			parseErrors, err := lintConfig(bitriseConfigBase64Data, bitriseConfigPath)
+			if err != nil && isValid {
+				return ValidationModel{}, fmt.Errorf("Failed to lint config, err: %s", err)
+			}
+			lints := []string{}
+			for _, parseErr := range parseErrors {
+				lints = append(lints, parseErr.Error())
+				validation.Issues = append(validation.Issues, ValidationIssueModel{
+					Source:   ValidationIssueSourceConfig,
+					Severity: ValidationIssueSeverityWarning,
+					Line:     parseErr.Line,
+					Column:   parseErr.Column,
+					Message:  parseErr.Message,
+				})
+			}
+			validation.Config.Lints = lints
 		}
 	} else {
 		log.Debug("No config found for validation")
@@ -127,7 +189,7 @@ func validate(c *cli.Context) error {
 
 	pth, err = GetInventoryFilePath(inventoryPath)
 	if err != nil {
-		registerFatal(fmt.Sprintf("Failed to get secrets path, err: %s", err), warnings, format)
+		return ValidationModel{}, fmt.Errorf("Failed to get secrets path, err: %s", err)
 	}
 	if pth != "" || inventoryBase64Data != "" {
 		// Inventory validation
@@ -144,6 +206,69 @@ func validate(c *cli.Context) error {
 			IsValid: isValid,
 			Error:   errMsg,
 		}
+		if !isValid {
+			validation.Issues = append(validation.Issues, ValidationIssueModel{Source: ValidationIssueSourceSecrets, Severity: ValidationIssueSeverityError, Message: errMsg})
+		}
+	}
+
+	return validation, nil
+}
+
+func validate(c *cli.Context) error {
+	warnings := []string{}
+
+	// Expand cli.Context
+	inventoryBase64Data := c.String(InventoryBase64Key)
+	inventoryPath := c.String(InventoryKey)
+
+	bitriseConfigBase64Data := c.String(ConfigBase64Key)
+
+	bitriseConfigPath := c.String(ConfigKey)
+	deprecatedBitriseConfigPath := c.String(PathKey)
+	if bitriseConfigPath == "" && deprecatedBitriseConfigPath != "" {
+		warnings = append(warnings, "'path' key is deprecated, use 'config' instead!")
+		bitriseConfigPath = deprecatedBitriseConfigPath
+	}
+
+	format := c.String(OuputFormatKey)
+
+	isWatch := c.Bool(WatchKey)
+	isLint := c.Bool(LintKey)
+	isDeep := c.Bool(DeepKey)
+	configs.IsStrictConfigMode = c.Bool(StrictKey)
+	//
+
+	if format == "" {
+		format = output.FormatRaw
+	} else if !(format == output.FormatRaw || format == output.FormatJSON || format == output.FormatJUnit) {
+		registerFatal(fmt.Sprintf("Invalid format: %s", format), warnings, output.FormatJSON)
+	}
+
+	if isWatch {
+		if format == output.FormatJUnit {
+			registerFatal("Watch mode can not be used with junit format", warnings, format)
+		}
+		if bitriseConfigBase64Data != "" || inventoryBase64Data != "" {
+			registerFatal("Watch mode can not be used with base 64 config or secrets data", warnings, format)
+		}
+		if bitriseConfigPath == StdinPath || inventoryPath == StdinPath {
+			registerFatal("Watch mode can not be used with config or secrets read from stdin", warnings, format)
+		}
+		if err := watchValidation(bitriseConfigPath, inventoryPath, isLint, isDeep, format); err != nil {
+			registerFatal(fmt.Sprintf("Failed to watch config, err: %s", err), warnings, format)
+		}
+		return nil
+	}
+
+	validation, err := validateConfigAndSecrets(bitriseConfigBase64Data, bitriseConfigPath, inventoryBase64Data, inventoryPath, isLint, isDeep)
+	if err != nil {
+		registerFatal(err.Error(), warnings, format)
+	}
+	if validation.Config != nil {
+		validation.Config.Warnings = append(warnings, validation.Config.Warnings...)
+		for _, warning := range warnings {
+			validation.Issues = append(validation.Issues, ValidationIssueModel{Source: ValidationIssueSourceConfig, Severity: ValidationIssueSeverityWarning, Message: warning})
+		}
 	}
 
 	if validation.Config == nil && validation.Secrets == nil {
@@ -153,12 +278,22 @@ func validate(c *cli.Context) error {
 	switch format {
 	case output.FormatRaw:
 		if err := printRawValidation(validation); err != nil {
-			registerFatal(fmt.Sprintf("Validation failed, err: %s", err), warnings, format)
+			registerFatalWithExitCode(exitcode.ConfigInvalid, fmt.Sprintf("Validation failed, err: %s", err), warnings, format)
 		}
 		break
 	case output.FormatJSON:
 		printJSONValidation(validation)
 		break
+	case output.FormatJUnit:
+		bytes, err := validationJUnitReport(validation)
+		if err != nil {
+			registerFatal(fmt.Sprintf("Failed to create JUnit report, err: %s", err), warnings, format)
+		}
+		fmt.Println(string(bytes))
+		// the JUnit report is used as a CI gate, an invalid config fails the command
+		if !isValidationValid(validation) {
+			os.Exit(exitcode.ConfigInvalid)
+		}
 	default:
 		registerFatal(fmt.Sprintf("Invalid format: %s", format), warnings, output.FormatJSON)
 	}
@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+)
+
+// Structured validation report: every problem of the config and the secrets is an issue,
+// with the YAML path (workflows.primary.steps[1]) and the location (line, column) of the invalid element, if known.
+// The issues are included in the JSON output, and the JUnit output (--format junit) has a test suite per validated file,
+// with a failing test case per error, so validate can run as a CI gate with the CI's test reporting.
+
+const (
+	// ValidationIssueSourceConfig ...
+	ValidationIssueSourceConfig = "config"
+	// ValidationIssueSourceSecrets ...
+	ValidationIssueSourceSecrets = "secrets"
+
+	// ValidationIssueSeverityError ...
+	ValidationIssueSeverityError = "error"
+	// ValidationIssueSeverityWarning ...
+	ValidationIssueSeverityWarning = "warning"
+)
+
+// ValidationIssueModel is a problem of the config or the secrets
+type ValidationIssueModel struct {
+	Source   string `json:"source" yaml:"source"`
+	Severity string `json:"severity" yaml:"severity"`
+	Path     string `json:"path,omitempty" yaml:"path,omitempty"`
+	Line     int    `json:"line,omitempty" yaml:"line,omitempty"`
+	Column   int    `json:"column,omitempty" yaml:"column,omitempty"`
+	Message  string `json:"message" yaml:"message"`
+}
+
+// newConfigErrorIssues returns the issues of a config error, with the path and location of the invalid element(s)
+func newConfigErrorIssues(err error) []ValidationIssueModel {
+	newIssue := func(pth string, line, column int, message string) ValidationIssueModel {
+		return ValidationIssueModel{
+			Source:   ValidationIssueSourceConfig,
+			Severity: ValidationIssueSeverityError,
+			Path:     pth,
+			Line:     line,
+			Column:   column,
+			Message:  message,
+		}
+	}
+
+	switch typedErr := err.(type) {
+	case bitrise.ConfigSyntaxErrorModel:
+		if len(typedErr.Errors) == 0 {
+			return []ValidationIssueModel{newIssue("", 0, 0, typedErr.Err.Error())}
+		}
+		issues := []ValidationIssueModel{}
+		for _, parseErr := range typedErr.Errors {
+			issues = append(issues, newIssue("", parseErr.Line, parseErr.Column, parseErr.Message))
+		}
+		return issues
+	case bitrise.ConfigErrorModel:
+		return []ValidationIssueModel{newIssue(typedErr.Path, typedErr.Line, typedErr.Column, typedErr.Err.Error())}
+	case models.ConfigPathError:
+		return []ValidationIssueModel{newIssue(typedErr.Path, 0, 0, typedErr.Err.Error())}
+	}
+	return []ValidationIssueModel{newIssue("", 0, 0, err.Error())}
+}
+
+// configValidationErrorIssues re-parses the invalid config, to get the typed error(s) of the config,
+// errMsg is reported, if the config's errors can't be determined.
+func configValidationErrorIssues(bitriseConfigBase64Data, bitriseConfigPath, errMsg string) []ValidationIssueModel {
+	fallback := []ValidationIssueModel{
+		ValidationIssueModel{Source: ValidationIssueSourceConfig, Severity: ValidationIssueSeverityError, Message: errMsg},
+	}
+
+	configBytes, format, err := readBitriseConfigBytes(bitriseConfigBase64Data, bitriseConfigPath)
+	if err != nil {
+		return fallback
+	}
+
+	if _, _, err := bitrise.ConfigModelFromBytes(configBytes, format); err != nil {
+		return newConfigErrorIssues(err)
+	}
+
+	if configs.IsStrictConfigMode {
+		strictErrs, err := bitrise.StrictConfigErrors(configBytes, format)
+		if err == nil && len(strictErrs) > 0 {
+			issues := []ValidationIssueModel{}
+			for _, strictErr := range strictErrs {
+				issues = append(issues, newConfigErrorIssues(strictErr)...)
+			}
+			return issues
+		}
+	}
+
+	return fallback
+}
+
+// JUnitTestSuitesModel ...
+type JUnitTestSuitesModel struct {
+	XMLName    xml.Name              `xml:"testsuites"`
+	Name       string                `xml:"name,attr"`
+	Tests      int                   `xml:"tests,attr"`
+	Failures   int                   `xml:"failures,attr"`
+	TestSuites []JUnitTestSuiteModel `xml:"testsuite"`
+}
+
+// JUnitTestSuiteModel ...
+type JUnitTestSuiteModel struct {
+	Name      string               `xml:"name,attr"`
+	Tests     int                  `xml:"tests,attr"`
+	Failures  int                  `xml:"failures,attr"`
+	TestCases []JUnitTestCaseModel `xml:"testcase"`
+	SystemOut string               `xml:"system-out,omitempty"`
+}
+
+// JUnitTestCaseModel ...
+type JUnitTestCaseModel struct {
+	ClassName string             `xml:"classname,attr"`
+	Name      string             `xml:"name,attr"`
+	Failure   *JUnitFailureModel `xml:"failure,omitempty"`
+}
+
+// JUnitFailureModel ...
+type JUnitFailureModel struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Content string `xml:",chardata"`
+}
+
+func validationIssueLocation(issue ValidationIssueModel) string {
+	location := issue.Path
+	if issue.Line > 0 {
+		lineColumn := fmt.Sprintf("line %d, column %d", issue.Line, issue.Column)
+		if location == "" {
+			location = lineColumn
+		} else {
+			location = fmt.Sprintf("%s (%s)", location, lineColumn)
+		}
+	}
+	return location
+}
+
+func newValidationJUnitTestSuite(source string, item ValidationItemModel, issues []ValidationIssueModel) JUnitTestSuiteModel {
+	suite := JUnitTestSuiteModel{Name: source, TestCases: []JUnitTestCaseModel{}}
+
+	systemOut := ""
+	for _, issue := range issues {
+		if issue.Source != source {
+			continue
+		}
+		if issue.Severity != ValidationIssueSeverityError {
+			systemOut += fmt.Sprintf("%s: %s\n", issue.Severity, issue.Message)
+			continue
+		}
+
+		name := validationIssueLocation(issue)
+		if name == "" {
+			name = issue.Message
+		}
+		content := issue.Message
+		if location := validationIssueLocation(issue); location != "" {
+			content = fmt.Sprintf("%s: %s", location, issue.Message)
+		}
+		suite.TestCases = append(suite.TestCases, JUnitTestCaseModel{
+			ClassName: "bitrise.validate." + source,
+			Name:      name,
+			Failure:   &JUnitFailureModel{Message: issue.Message, Type: issue.Severity, Content: content},
+		})
+		suite.Failures++
+	}
+
+	if item.IsValid && suite.Failures == 0 {
+		suite.TestCases = append(suite.TestCases, JUnitTestCaseModel{ClassName: "bitrise.validate." + source, Name: "valid"})
+	}
+	suite.Tests = len(suite.TestCases)
+	suite.SystemOut = systemOut
+	return suite
+}
+
+// validationJUnitReport returns the validation as a JUnit XML report
+func validationJUnitReport(validation ValidationModel) ([]byte, error) {
+	report := JUnitTestSuitesModel{Name: "bitrise validate", TestSuites: []JUnitTestSuiteModel{}}
+	if validation.Config != nil {
+		report.TestSuites = append(report.TestSuites, newValidationJUnitTestSuite(ValidationIssueSourceConfig, *validation.Config, validation.Issues))
+	}
+	if validation.Secrets != nil {
+		report.TestSuites = append(report.TestSuites, newValidationJUnitTestSuite(ValidationIssueSourceSecrets, *validation.Secrets, validation.Issues))
+	}
+	for _, suite := range report.TestSuites {
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+	}
+
+	bytes, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return []byte{}, err
+	}
+	return append([]byte(xml.Header), bytes...), nil
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationIssues(t *testing.T) {
+	t.Log("invalid config element")
+	{
+		configStr := `format_version: 1.3.0
+
+workflows:
+  primary:
+    tags:
+    - ios
+    - ios
+`
+		validation, err := validateConfigAndSecrets(base64.StdEncoding.EncodeToString([]byte(configStr)), "", "", "", false, false)
+		require.NoError(t, err)
+		require.Equal(t, []ValidationIssueModel{
+			ValidationIssueModel{
+				Source:   ValidationIssueSourceConfig,
+				Severity: ValidationIssueSeverityError,
+				Path:     "workflows.primary.tags[1]",
+				Line:     7,
+				Column:   5,
+				Message:  "duplicated tag (ios)",
+			},
+		}, validation.Issues)
+	}
+
+	t.Log("syntax error")
+	{
+		configStr := `format_version: 1.3.0
+workflows:
+  primary:
+    steps: [
+`
+		validation, err := validateConfigAndSecrets(base64.StdEncoding.EncodeToString([]byte(configStr)), "", "", "", false, false)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(validation.Issues))
+		require.Equal(t, ValidationIssueSeverityError, validation.Issues[0].Severity)
+		require.NotEqual(t, "", validation.Issues[0].Message)
+	}
+
+	t.Log("unknown keys in strict mode")
+	{
+		configStr := `format_version: 1.3.0
+
+workflows:
+  primary:
+    before_rub:
+    - setup
+    titl: Primary
+`
+		configs.IsStrictConfigMode = true
+		defer func() {
+			configs.IsStrictConfigMode = false
+		}()
+
+		validation, err := validateConfigAndSecrets(base64.StdEncoding.EncodeToString([]byte(configStr)), "", "", "", false, false)
+		require.NoError(t, err)
+		require.Equal(t, []ValidationIssueModel{
+			ValidationIssueModel{Source: "config", Severity: "error", Path: "workflows.primary.before_rub", Line: 5, Column: 5, Message: "unknown key (before_rub)"},
+			ValidationIssueModel{Source: "config", Severity: "error", Path: "workflows.primary.titl", Line: 7, Column: 5, Message: "unknown key (titl)"},
+		}, validation.Issues)
+	}
+}
+
+func TestValidationJUnitReport(t *testing.T) {
+	t.Log("invalid config, valid secrets")
+	{
+		validation := ValidationModel{
+			Config:  &ValidationItemModel{IsValid: false, Error: "duplicated tag (ios)"},
+			Secrets: &ValidationItemModel{IsValid: true},
+			Issues: []ValidationIssueModel{
+				ValidationIssueModel{Source: "config", Severity: "error", Path: "workflows.primary.tags[1]", Line: 7, Column: 5, Message: "duplicated tag (ios)"},
+				ValidationIssueModel{Source: "config", Severity: "warning", Message: "step (script) has no version"},
+			},
+		}
+
+		bytes, err := validationJUnitReport(validation)
+		require.NoError(t, err)
+		require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="bitrise validate" tests="2" failures="1">
+  <testsuite name="config" tests="1" failures="1">
+    <testcase classname="bitrise.validate.config" name="workflows.primary.tags[1] (line 7, column 5)">
+      <failure message="duplicated tag (ios)" type="error">workflows.primary.tags[1] (line 7, column 5): duplicated tag (ios)</failure>
+    </testcase>
+    <system-out>warning: step (script) has no version&#xA;</system-out>
+  </testsuite>
+  <testsuite name="secrets" tests="1" failures="0">
+    <testcase classname="bitrise.validate.secrets" name="valid"></testcase>
+  </testsuite>
+</testsuites>`, string(bytes))
+	}
+
+	t.Log("valid config")
+	{
+		bytes, err := validationJUnitReport(ValidationModel{Config: &ValidationItemModel{IsValid: true}})
+		require.NoError(t, err)
+		require.Equal(t, true, strings.Contains(string(bytes), `<testsuites name="bitrise validate" tests="1" failures="0">`))
+	}
+}
@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/output"
+)
+
+// validateWatchPollInterval is the interval of checking the watched files for changes
+// (polling works with every editor's save strategy, e.g. write to a temp file and rename).
+var validateWatchPollInterval = 500 * time.Millisecond
+
+type watchedFileStateModel struct {
+	Exists  bool
+	ModTime time.Time
+	Size    int64
+}
+
+func watchedFileStates(pths []string) map[string]watchedFileStateModel {
+	states := map[string]watchedFileStateModel{}
+	for _, pth := range pths {
+		info, err := os.Stat(pth)
+		if err != nil {
+			states[pth] = watchedFileStateModel{}
+			continue
+		}
+		states[pth] = watchedFileStateModel{
+			Exists:  true,
+			ModTime: info.ModTime(),
+			Size:    info.Size(),
+		}
+	}
+	return states
+}
+
+func changedWatchedFiles(previous, current map[string]watchedFileStateModel) []string {
+	changed := []string{}
+	for pth, state := range current {
+		if previous[pth] != state {
+			changed = append(changed, pth)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// validationDiagnostics returns every problem of the validation as a list of messages
+func validationDiagnostics(validation ValidationModel) []string {
+	diagnostics := []string{}
+	if validation.Config != nil {
+		if validation.Config.Error != "" {
+			diagnostics = append(diagnostics, "config error: "+validation.Config.Error)
+		}
+		for _, warning := range validation.Config.Warnings {
+			diagnostics = append(diagnostics, "config warning: "+warning)
+		}
+		for _, issue := range validation.Config.StepInputs {
+			diagnostics = append(diagnostics, "config step input: "+issue)
+		}
+		for _, lint := range validation.Config.Lints {
+			diagnostics = append(diagnostics, "config lint: "+lint)
+		}
+	}
+	if validation.Secrets != nil && validation.Secrets.Error != "" {
+		diagnostics = append(diagnostics, "secrets error: "+validation.Secrets.Error)
+	}
+	return diagnostics
+}
+
+// diffDiagnostics returns the new and the resolved diagnostics
+func diffDiagnostics(previous, current []string) ([]string, []string) {
+	previousMap := map[string]bool{}
+	for _, diagnostic := range previous {
+		previousMap[diagnostic] = true
+	}
+	currentMap := map[string]bool{}
+	for _, diagnostic := range current {
+		currentMap[diagnostic] = true
+	}
+
+	added := []string{}
+	for _, diagnostic := range current {
+		if !previousMap[diagnostic] {
+			added = append(added, diagnostic)
+		}
+	}
+	resolved := []string{}
+	for _, diagnostic := range previous {
+		if !currentMap[diagnostic] {
+			resolved = append(resolved, diagnostic)
+		}
+	}
+	return added, resolved
+}
+
+func isValidationValid(validation ValidationModel) bool {
+	if validation.Config != nil && !validation.Config.IsValid {
+		return false
+	}
+	if validation.Secrets != nil && !validation.Secrets.IsValid {
+		return false
+	}
+	return true
+}
+
+func printRawValidationChanges(changedFiles []string, previous, current []string, validation ValidationModel) {
+	added, resolved := diffDiagnostics(previous, current)
+
+	fmt.Println()
+	fmt.Printf("%s %s changed\n", output.Blue(time.Now().Format("15:04:05")), strings.Join(changedFiles, ", "))
+	for _, diagnostic := range resolved {
+		fmt.Printf("  %s %s\n", output.Green("- resolved:"), diagnostic)
+	}
+	for _, diagnostic := range added {
+		fmt.Printf("  %s %s\n", output.Red("+ new:"), diagnostic)
+	}
+	if len(added) == 0 && len(resolved) == 0 {
+		fmt.Println("  no changes")
+	}
+
+	if isValidationValid(validation) {
+		fmt.Printf("  %s (%d diagnostics)\n", output.Green("valid"), len(current))
+	} else {
+		fmt.Printf("  %s (%d diagnostics)\n", output.Red("invalid"), len(current))
+	}
+}
+
+// watchValidation validates the config and secrets, then re-validates them on every change of the files,
+// until interrupted.
+func watchValidation(bitriseConfigPath, inventoryPath string, lint, deep bool, format string) error {
+	if bitriseConfigPath == "" {
+		bitriseConfigPath = filepath.Join(configs.CurrentDir, DefaultBitriseConfigFileName)
+		if pth, err := GetBitriseConfigFilePath(""); err == nil {
+			bitriseConfigPath = pth
+		}
+	}
+	if inventoryPath == "" {
+		inventoryPath = filepath.Join(configs.CurrentDir, DefaultSecretsFileName)
+	}
+	watchedPths := []string{bitriseConfigPath, inventoryPath}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	if format == output.FormatRaw {
+		fmt.Printf("Watching %s for changes, press Ctrl+C to stop\n", strings.Join(watchedPths, ", "))
+		fmt.Println()
+	}
+
+	states := map[string]watchedFileStateModel{}
+	diagnostics := []string{}
+	isFirstValidation := true
+
+	for {
+		currentStates := watchedFileStates(watchedPths)
+		changedFiles := changedWatchedFiles(states, currentStates)
+		states = currentStates
+
+		if isFirstValidation || len(changedFiles) > 0 {
+			validationInventoryPath := inventoryPath
+			if !states[inventoryPath].Exists {
+				validationInventoryPath = ""
+			}
+
+			validation, err := validateConfigAndSecrets("", bitriseConfigPath, "", validationInventoryPath, lint, deep)
+			if err != nil {
+				return err
+			}
+			currentDiagnostics := validationDiagnostics(validation)
+
+			switch format {
+			case output.FormatRaw:
+				if isFirstValidation {
+					if err := printRawValidation(validation); err != nil {
+						fmt.Println(output.Red(err.Error()))
+					}
+				} else {
+					printRawValidationChanges(changedFiles, diagnostics, currentDiagnostics, validation)
+				}
+			case output.FormatJSON:
+				printJSONValidation(validation)
+			}
+
+			diagnostics = currentDiagnostics
+			isFirstValidation = false
+		}
+
+		select {
+		case <-signals:
+			return nil
+		case <-time.After(validateWatchPollInterval):
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"encoding/base64"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffDiagnostics(t *testing.T) {
+	t.Log("no changes")
+	{
+		added, resolved := diffDiagnostics([]string{"a", "b"}, []string{"b", "a"})
+		require.Equal(t, []string{}, added)
+		require.Equal(t, []string{}, resolved)
+	}
+
+	t.Log("new and resolved diagnostics")
+	{
+		added, resolved := diffDiagnostics([]string{"a", "b"}, []string{"b", "c"})
+		require.Equal(t, []string{"c"}, added)
+		require.Equal(t, []string{"a"}, resolved)
+	}
+}
+
+func TestChangedWatchedFiles(t *testing.T) {
+	now := time.Now()
+	previous := map[string]watchedFileStateModel{
+		"bitrise.yml":          watchedFileStateModel{Exists: true, ModTime: now, Size: 10},
+		".bitrise.secrets.yml": watchedFileStateModel{},
+	}
+
+	t.Log("unchanged")
+	{
+		current := map[string]watchedFileStateModel{
+			"bitrise.yml":          watchedFileStateModel{Exists: true, ModTime: now, Size: 10},
+			".bitrise.secrets.yml": watchedFileStateModel{},
+		}
+		require.Equal(t, []string{}, changedWatchedFiles(previous, current))
+	}
+
+	t.Log("modified and created")
+	{
+		current := map[string]watchedFileStateModel{
+			"bitrise.yml":          watchedFileStateModel{Exists: true, ModTime: now.Add(time.Second), Size: 10},
+			".bitrise.secrets.yml": watchedFileStateModel{Exists: true, ModTime: now, Size: 5},
+		}
+		require.Equal(t, []string{".bitrise.secrets.yml", "bitrise.yml"}, changedWatchedFiles(previous, current))
+	}
+}
+
+func TestValidateConfigAndSecretsLint(t *testing.T) {
+	configStr := `format_version: 1.3.0
+default_step_lib_source: "https://github.com/bitrise-io/bitrise-steplib.git"
+
+workflows:
+  primary:
+    title: Primary
+    title: Duplicated
+`
+	configBase64 := base64.StdEncoding.EncodeToString([]byte(configStr))
+
+	t.Log("without lint")
+	{
+		validation, err := validateConfigAndSecrets(configBase64, "", "", "", false, false)
+		require.NoError(t, err)
+		require.NotNil(t, validation.Config)
+		require.Equal(t, 0, len(validation.Config.Lints))
+	}
+
+	t.Log("with lint")
+	{
+		validation, err := validateConfigAndSecrets(configBase64, "", "", "", true, false)
+		require.NoError(t, err)
+		require.NotNil(t, validation.Config)
+		require.Equal(t, []string{"line 7, column 5: duplicate key (title), already defined at line 6"}, validation.Config.Lints)
+		require.Equal(t, true, containsString(validationDiagnostics(validation), "config lint: line 7, column 5: duplicate key (title), already defined at line 6"))
+	}
+}
+
+func TestValidateConfigAndSecretsDeep(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("validate_deep")
+	require.NoError(t, err)
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "step.yml"), `title: Test step
+inputs:
+- mode: debug
+  opts:
+    value_options:
+    - debug
+    - release
+`))
+
+	configStr := `format_version: 1.3.0
+
+workflows:
+  primary:
+    steps:
+    - path::` + tmpDir + `:
+        inputs:
+        - mode: profile
+`
+	configBase64 := base64.StdEncoding.EncodeToString([]byte(configStr))
+
+	t.Log("without deep")
+	{
+		validation, err := validateConfigAndSecrets(configBase64, "", "", "", false, false)
+		require.NoError(t, err)
+		require.NotNil(t, validation.Config)
+		require.Equal(t, true, validation.Config.IsValid)
+	}
+
+	t.Log("with deep")
+	{
+		validation, err := validateConfigAndSecrets(configBase64, "", "", "", false, true)
+		require.NoError(t, err)
+		require.NotNil(t, validation.Config)
+		require.Equal(t, false, validation.Config.IsValid)
+		require.Equal(t, "Invalid step inputs", validation.Config.Error)
+		require.Equal(t, []string{
+			"workflow (primary) step #1 (path::" + tmpDir + "): input (mode) value (profile) is not one of the value_options: debug, release",
+		}, validation.Config.StepInputs)
+	}
+}
+
+func TestValidateConfigAndSecretsStrict(t *testing.T) {
+	configStr := `format_version: 1.3.0
+
+workflows:
+  primary:
+    before_rub:
+    - setup
+`
+	configBase64 := base64.StdEncoding.EncodeToString([]byte(configStr))
+	defer func() {
+		configs.IsStrictConfigMode = false
+	}()
+
+	t.Log("without strict mode")
+	{
+		configs.IsStrictConfigMode = false
+		validation, err := validateConfigAndSecrets(configBase64, "", "", "", false, false)
+		require.NoError(t, err)
+		require.NotNil(t, validation.Config)
+		require.Equal(t, true, validation.Config.IsValid)
+	}
+
+	t.Log("strict mode")
+	{
+		configs.IsStrictConfigMode = true
+		validation, err := validateConfigAndSecrets(configBase64, "", "", "", false, false)
+		require.NoError(t, err)
+		require.NotNil(t, validation.Config)
+		require.Equal(t, false, validation.Config.IsValid)
+		require.Equal(t, "Config has unknown keys (strict mode):\n- unknown key (before_rub) (line 5, column 5: workflows.primary.before_rub)", validation.Config.Error)
+	}
+}
+
+func containsString(list []string, item string) bool {
+	for _, s := range list {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+)
+
+// workflowInputEnvironments are the envs of the workflow inputs, validated before the run starts
+var workflowInputEnvironments = []envmanModels.EnvironmentItemModel{}
+
+// parseWorkflowInputValues parses the --input KEY=VALUE flags
+func parseWorkflowInputValues(inputs []string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, input := range inputs {
+		split := strings.SplitN(input, "=", 2)
+		if len(split) != 2 || split[0] == "" {
+			return map[string]string{}, fmt.Errorf("invalid input (%s), should be in KEY=VALUE format", input)
+		}
+		values[split[0]] = split[1]
+	}
+	return values, nil
+}
+
+func registerWorkflowInputs(bitriseConfig models.BitriseDataModel, workflowID string, values map[string]string) error {
+	environments, err := models.ResolveWorkflowInputs(bitriseConfig, workflowID, values)
+	if err != nil {
+		return err
+	}
+	workflowInputEnvironments = environments
+	return nil
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWorkflowInputValues(t *testing.T) {
+	t.Log("valid inputs")
+	{
+		values, err := parseWorkflowInputValues([]string{"CONFIGURATION=Release", "ARGS=--flag=value", "EMPTY="})
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"CONFIGURATION": "Release", "ARGS": "--flag=value", "EMPTY": ""}, values)
+	}
+
+	t.Log("invalid input")
+	{
+		_, err := parseWorkflowInputValues([]string{"CONFIGURATION"})
+		require.EqualError(t, err, "invalid input (CONFIGURATION), should be in KEY=VALUE format")
+
+		_, err = parseWorkflowInputValues([]string{"=Release"})
+		require.Error(t, err)
+	}
+}
@@ -6,17 +6,63 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/bitrise-io/bitrise/models"
 	"github.com/bitrise-io/bitrise/output"
-	"github.com/bitrise-io/go-utils/colorstring"
 	"github.com/urfave/cli"
 )
 
-func printWorkflList(workflowList map[string]map[string]string, format string, minimal bool) error {
-	printRawWorkflowMap := func(name string, workflow map[string]string) {
-		fmt.Printf("⚡️ %s\n", colorstring.Green(name))
-		fmt.Printf("  %s: %s\n", colorstring.Yellow("Summary"), workflow["summary"])
+// WorkflowListItemModel is a workflow of the workflow list,
+// the JSON output is keyed by the workflow's ID
+type WorkflowListItemModel struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title,omitempty"`
+	Summary     string   `json:"summary"`
+	Description string   `json:"description,omitempty"`
+	BeforeRun   []string `json:"before_run"`
+	AfterRun    []string `json:"after_run"`
+	Owner       string   `json:"owner,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	// IsUtility is true for the utility workflows (the workflows with an underscore prefixed ID)
+	IsUtility bool `json:"is_utility"`
+}
+
+func workflowRunItemIDs(items []models.WorkflowRunItemModel) []string {
+	ids := []string{}
+	for _, item := range items {
+		ids = append(ids, item.WorkflowID)
+	}
+	return ids
+}
+
+func newWorkflowListItem(workflowID string, workflow models.WorkflowModel, minimal bool) WorkflowListItemModel {
+	item := WorkflowListItemModel{
+		ID:        workflowID,
+		Title:     workflow.Title,
+		Summary:   workflow.Summary,
+		BeforeRun: workflowRunItemIDs(workflow.BeforeRun),
+		AfterRun:  workflowRunItemIDs(workflow.AfterRun),
+		IsUtility: strings.HasPrefix(workflowID, "_"),
+		Owner:     workflow.Owner,
+		Tags:      workflow.Tags,
+	}
+	if !minimal {
+		item.Description = workflow.Description
+	}
+	return item
+}
+
+func printWorkflList(workflowList map[string]WorkflowListItemModel, format string, minimal bool) error {
+	printRawWorkflowMap := func(name string, workflow WorkflowListItemModel) {
+		fmt.Printf("⚡️ %s\n", output.Green(name))
+		fmt.Printf("  %s: %s\n", output.Yellow("Summary"), workflow.Summary)
+		if workflow.Owner != "" {
+			fmt.Printf("  %s: %s\n", output.Yellow("Owner"), workflow.Owner)
+		}
+		if len(workflow.Tags) > 0 {
+			fmt.Printf("  %s: %s\n", output.Yellow("Tags"), strings.Join(workflow.Tags, ", "))
+		}
 		if !minimal {
-			fmt.Printf("  %s: %s\n", colorstring.Yellow("Description"), workflow["description"])
+			fmt.Printf("  %s: %s\n", output.Yellow("Description"), workflow.Description)
 		}
 		fmt.Println()
 	}
@@ -26,8 +72,8 @@ func printWorkflList(workflowList map[string]map[string]string, format string, m
 		workflowNames := []string{}
 		utilityWorkflowNames := []string{}
 
-		for wfName := range workflowList {
-			if strings.HasPrefix(wfName, "_") {
+		for wfName, workflow := range workflowList {
+			if workflow.IsUtility {
 				utilityWorkflowNames = append(utilityWorkflowNames, wfName)
 			} else {
 				workflowNames = append(workflowNames, wfName)
@@ -90,6 +136,8 @@ func workflowList(c *cli.Context) error {
 	format := c.String(OuputFormatKey)
 
 	minimal := c.Bool(MinimalModeKey)
+
+	tags := c.StringSlice(TagKey)
 	//
 
 	// Input validation
@@ -106,17 +154,12 @@ func workflowList(c *cli.Context) error {
 		registerFatal(fmt.Sprintf("Failed to create bitrise config, err: %s", err), warnings, output.FormatJSON)
 	}
 
-	workflowList := map[string]map[string]string{}
-	if len(bitriseConfig.Workflows) > 0 {
-		for workflowID, workflow := range bitriseConfig.Workflows {
-			workflowMap := map[string]string{}
-			workflowMap["summary"] = workflow.Summary
-			if !minimal {
-				workflowMap["description"] = workflow.Description
-			}
-
-			workflowList[workflowID] = workflowMap
+	workflowList := map[string]WorkflowListItemModel{}
+	for workflowID, workflow := range bitriseConfig.Workflows {
+		if !workflow.HasTags(tags) {
+			continue
 		}
+		workflowList[workflowID] = newWorkflowListItem(workflowID, workflow, minimal)
 	}
 
 	if err := printWorkflList(workflowList, format, minimal); err != nil {
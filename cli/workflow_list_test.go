@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWorkflowListItem(t *testing.T) {
+	workflow := models.WorkflowModel{
+		Title:       "Deploy",
+		Summary:     "Deploys the app",
+		Description: "Builds and deploys the app",
+		BeforeRun:   []models.WorkflowRunItemModel{models.WorkflowRunItemModel{WorkflowID: "_setup"}, models.WorkflowRunItemModel{WorkflowID: "_test", RunIf: ".IsCI"}},
+		AfterRun:    []models.WorkflowRunItemModel{models.WorkflowRunItemModel{WorkflowID: "_notify"}},
+		Owner:       "mobile-team",
+		Tags:        []string{"ios", "release"},
+	}
+
+	item := newWorkflowListItem("deploy", workflow, false)
+	require.Equal(t, WorkflowListItemModel{
+		ID:          "deploy",
+		Title:       "Deploy",
+		Summary:     "Deploys the app",
+		Description: "Builds and deploys the app",
+		BeforeRun:   []string{"_setup", "_test"},
+		AfterRun:    []string{"_notify"},
+		IsUtility:   false,
+		Owner:       "mobile-team",
+		Tags:        []string{"ios", "release"},
+	}, item)
+
+	bytes, err := json.Marshal(map[string]WorkflowListItemModel{
+		"deploy": newWorkflowListItem("deploy", workflow, true),
+		"_setup": newWorkflowListItem("_setup", models.WorkflowModel{}, true),
+	})
+	require.NoError(t, err)
+	require.Equal(t, `{"_setup":{"id":"_setup","summary":"","before_run":[],"after_run":[],"is_utility":true},`+
+		`"deploy":{"id":"deploy","title":"Deploy","summary":"Deploys the app","before_run":["_setup","_test"],"after_run":["_notify"],"owner":"mobile-team","tags":["ios","release"],"is_utility":false}}`, string(bytes))
+}
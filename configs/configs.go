@@ -13,8 +13,17 @@ import (
 
 // ConfigModel ...
 type ConfigModel struct {
-	SetupVersion          string    `json:"setup_version"`
-	LastPluginUpdateCheck time.Time `json:"last_plugin_update_check"`
+	SetupVersion          string             `json:"setup_version"`
+	LastPluginUpdateCheck time.Time          `json:"last_plugin_update_check"`
+	Mirrors               map[string]string  `json:"mirrors,omitempty"`
+	Policy                *PolicyConfigModel `json:"policy,omitempty"`
+	AccessibleOutput      bool               `json:"accessible_output,omitempty"`
+	// GitCredentials are the credentials of the git hosts, by host name (see: GitCredentialEnvs)
+	GitCredentials map[string]GitCredentialModel `json:"git_credentials,omitempty"`
+	// RegistryCredentials are the credentials of the OCI registries of the OCI artifact steps, by registry host
+	RegistryCredentials map[string]RegistryCredentialModel `json:"registry_credentials,omitempty"`
+	// HTTPClient is the config of the shared HTTP client of the network operations (see: HTTPClient)
+	HTTPClient *HTTPClientConfigModel `json:"http_client,omitempty"`
 }
 
 // ---------------------------
@@ -27,6 +36,49 @@ var (
 	IsDebugMode = false
 	// IsPullRequestMode ...
 	IsPullRequestMode = false
+	// IsLockedMode ...
+	IsLockedMode = false
+	// IsUpdateLockMode writes the resolved step versions into the step lockfile, at the end of the run
+	IsUpdateLockMode = false
+	// IsStrictConfigMode fails the config validation on the unknown config keys
+	IsStrictConfigMode = false
+	// IsNoDeprecatedMode fails the deprecated steps, instead of running them
+	IsNoDeprecatedMode = false
+	// StepAuditMode is the audit mode of the activated steps (off, warn or fail), see: bitrise.AuditStep
+	StepAuditMode = ""
+	// DependencyManager is the package manager of the step dependencies (brew, apt, dnf or none), selected by the platform if empty
+	DependencyManager = ""
+	// StepLockfilePath ...
+	StepLockfilePath = ""
+	// BuildSummaryPath is the path of the machine-readable build summary (JSON), written at the end of the run
+	BuildSummaryPath = ""
+	// HTMLReportPath is the path of the single-file HTML run report, written at the end of the run
+	HTMLReportPath = ""
+	// IsStepProfileMode prints the slowest steps report (with the activation, prepare and execution time of the steps) at the end of the run
+	IsStepProfileMode = false
+	// IsQuietMode prints the output of the failed steps and the summary only (see: bitrise.StartOutputCapture)
+	IsQuietMode = false
+	// StepProfilePath is the path of the step timing profile (JSON), written at the end of the run
+	StepProfilePath = ""
+	// LogStreamAddress is the address of the live log stream (Server-Sent Events) of the run, if set
+	LogStreamAddress = ""
+	// RunLogDir is the directory of the per-run log files, if set
+	RunLogDir = ""
+	// RunLogMaxSizeMB is the size (in MB) the run's log file is rotated at
+	RunLogMaxSizeMB = 0
+	// RunLogRetention is the number of the latest per-run log directories kept in RunLogDir
+	RunLogRetention = 0
+	// ArtifactUploadURL is the destination of the artifact upload (s3://, gs://, http(s):// or file://), if set
+	ArtifactUploadURL = ""
+	// ArtifactRetentionDays is the number of days the uploaded artifacts should be kept for, 0 means forever
+	ArtifactRetentionDays = 0
+	// BuildTimeoutSecs is the time limit of the whole run (every chained workflow), 0 means no limit
+	BuildTimeoutSecs = 0
+	// BuildTimeoutGraceSecs is the time the is_always_run steps get to finish, once the build timed out
+	BuildTimeoutGraceSecs = 0
+	// IsAccessibleMode is the screen reader friendly output mode:
+	// no box drawings, progress dots or colors, linear statements with textual status markers
+	IsAccessibleMode = false
 )
 
 // ---------------------------
@@ -48,12 +100,157 @@ const (
 
 	// DebugUseSystemTools ...
 	DebugUseSystemTools = "BITRISE_DEBUG_USE_SYSTEM_TOOLS"
+
+	// --- Resource governor
+
+	// GovernorMaxLoadEnvKey ...
+	GovernorMaxLoadEnvKey = "BITRISE_GOVERNOR_MAX_LOAD"
+	// GovernorMaxMemoryPercentEnvKey ...
+	GovernorMaxMemoryPercentEnvKey = "BITRISE_GOVERNOR_MAX_MEMORY_PERCENT"
+
+	// --- Config cache
+
+	// ConfigCacheDisabledEnvKey ...
+	ConfigCacheDisabledEnvKey = "BITRISE_CONFIG_CACHE_DISABLED"
+
+	// --- Strict config mode
+
+	// StrictConfigEnvKey ...
+	StrictConfigEnvKey = "BITRISE_STRICT_CONFIG"
+	// StepAuditModeEnvKey ...
+	StepAuditModeEnvKey = "BITRISE_STEP_AUDIT"
+
+	// --- Step dependencies
+
+	// DependencyManagerEnvKey ...
+	DependencyManagerEnvKey = "BITRISE_DEPENDENCY_MANAGER"
+
+	// --- Tool installation
+
+	// VerifyToolSignaturesEnvKey ...
+	VerifyToolSignaturesEnvKey = "BITRISE_VERIFY_TOOL_SIGNATURES"
+	// GitHubTokenEnvKey is the token of the GitHub API requests (resolving the latest tool releases), for higher rate limits
+	GitHubTokenEnvKey = "BITRISE_GITHUB_TOKEN"
+	// GitHubTokenFallbackEnvKey is used if GitHubTokenEnvKey is not set
+	GitHubTokenFallbackEnvKey = "GITHUB_TOKEN"
+
+	// --- HTTP client
+
+	// CACertPathEnvKey is a PEM file of trusted root CAs, in addition to the http_client config's ca_cert_paths
+	CACertPathEnvKey = "BITRISE_CA_CERT_PATH"
+
+	// --- Step pre-activation
+
+	// StepPreActivationWorkersEnvKey ...
+	StepPreActivationWorkersEnvKey = "BITRISE_STEP_PREACTIVATION_WORKERS"
+
+	// --- Step outputs
+
+	// OutputSpillThresholdEnvKey ...
+	OutputSpillThresholdEnvKey = "BITRISE_OUTPUT_SPILL_THRESHOLD"
+
+	// --- Build summary
+
+	// BuildSummaryPathEnvKey ...
+	BuildSummaryPathEnvKey = "BITRISE_BUILD_SUMMARY_PATH"
+	// HTMLReportPathEnvKey ...
+	HTMLReportPathEnvKey = "BITRISE_HTML_REPORT_PATH"
+	// LogStreamAddressEnvKey ...
+	LogStreamAddressEnvKey = "BITRISE_LOG_STREAM_ADDRESS"
+	// RunLogDirEnvKey ...
+	RunLogDirEnvKey = "BITRISE_LOG_DIR"
+
+	// --- Build timeout
+
+	// BuildTimeoutEnvKey ...
+	BuildTimeoutEnvKey = "BITRISE_BUILD_TIMEOUT"
+	// BuildTimeoutGraceEnvKey ...
+	BuildTimeoutGraceEnvKey = "BITRISE_BUILD_TIMEOUT_GRACE"
+
+	// --- Artifacts
+
+	// ArtifactUploadURLEnvKey ...
+	ArtifactUploadURLEnvKey = "BITRISE_ARTIFACT_UPLOAD_URL"
+	// ArtifactRetentionDaysEnvKey ...
+	ArtifactRetentionDaysEnvKey = "BITRISE_ARTIFACT_RETENTION_DAYS"
+
+	// --- Organization policy
+
+	// PolicyURLEnvKey ...
+	PolicyURLEnvKey = "BITRISE_POLICY_URL"
+
+	// --- Encrypted secrets
+
+	// SecretsKeyPathEnvKey ...
+	SecretsKeyPathEnvKey = "BITRISE_SECRETS_KEY_PATH"
+	// AgeKeyFileEnvKey is the age identity file, used to decrypt the age and SOPS encrypted secrets files
+	AgeKeyFileEnvKey = "BITRISE_AGE_KEY_FILE"
+	// SOPSAgeKeyFileEnvKey is the age identity file of SOPS, used if BITRISE_AGE_KEY_FILE is not set
+	SOPSAgeKeyFileEnvKey = "SOPS_AGE_KEY_FILE"
+
+	// --- Output
+
+	// AccessibleOutputEnvKey ...
+	AccessibleOutputEnvKey = "BITRISE_ACCESSIBLE_OUTPUT"
+
+	// --- Step env history
+
+	// StepEnvHistoryDisabledEnvKey ...
+	StepEnvHistoryDisabledEnvKey = "BITRISE_STEP_ENV_HISTORY_DISABLED"
+
+	// --- Step resource limits
+
+	// StepCgroupMoveCLIEnvKey allows the CLI to move itself into a leaf cgroup (bitrise-cli) of its cgroup, if set to "true".
+	// The cpu and memory controllers can't be enabled for the steps' cgroups, while the CLI's cgroup has member processes.
+	StepCgroupMoveCLIEnvKey = "BITRISE_STEP_CGROUP_MOVE_CLI"
+	// StepResourceLimitsOptionalEnvKey allows the steps to run without their resource limits, if set to "true",
+	// when the limits can't be enforced (a warning is printed), instead of failing the step.
+	StepResourceLimitsOptionalEnvKey = "BITRISE_STEP_RESOURCE_LIMITS_OPTIONAL"
 )
 
 const (
 	bitriseConfigFileName = "config.json"
 )
 
+// IsConfigCacheDisabled ...
+func IsConfigCacheDisabled() bool {
+	return os.Getenv(ConfigCacheDisabledEnvKey) == "true"
+}
+
+// IsStepEnvHistoryDisabled ...
+func IsStepEnvHistoryDisabled() bool {
+	return os.Getenv(StepEnvHistoryDisabledEnvKey) == "true"
+}
+
+// IsStepResourceLimitsOptional ...
+func IsStepResourceLimitsOptional() bool {
+	return os.Getenv(StepResourceLimitsOptionalEnvKey) == "true"
+}
+
+// IsToolSignatureVerificationRequired returns true if the downloaded tools have to be signed
+// (for security-hardened environments)
+func IsToolSignatureVerificationRequired() bool {
+	return os.Getenv(VerifyToolSignaturesEnvKey) == "true"
+}
+
+// IsAccessibleOutputRequested returns true if the accessible output mode is enabled
+// by the BITRISE_ACCESSIBLE_OUTPUT env, or by the accessible_output field of the bitrise config (~/.bitrise/config.json).
+// The env overrides the config.
+func IsAccessibleOutputRequested() bool {
+	switch os.Getenv(AccessibleOutputEnvKey) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	config, err := loadBitriseConfig()
+	if err != nil {
+		return false
+	}
+	return config.AccessibleOutput
+}
+
 // IsDebugUseSystemTools ...
 func IsDebugUseSystemTools() bool {
 	return os.Getenv(DebugUseSystemTools) == "true"
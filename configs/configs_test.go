@@ -28,3 +28,37 @@ func TestSetupForVersionChecks(t *testing.T) {
 
 	require.Equal(t, false, CheckIsSetupWasDoneForVersion("0.9.8"))
 }
+
+func TestIsAccessibleOutputRequested(t *testing.T) {
+	fakeHomePth, err := pathutil.NormalizedOSTempDirPath("_FAKE_HOME")
+	require.Equal(t, nil, err)
+	originalHome := os.Getenv("HOME")
+
+	defer func() {
+		require.Equal(t, nil, os.Setenv("HOME", originalHome))
+		require.Equal(t, nil, os.Unsetenv(AccessibleOutputEnvKey))
+		require.Equal(t, nil, os.RemoveAll(fakeHomePth))
+	}()
+
+	require.Equal(t, nil, os.Setenv("HOME", fakeHomePth))
+	require.Equal(t, nil, os.Unsetenv(AccessibleOutputEnvKey))
+
+	require.Equal(t, false, IsAccessibleOutputRequested())
+
+	t.Log("enabled by the config")
+	{
+		require.Equal(t, nil, EnsureBitriseConfigDirExists())
+		require.Equal(t, nil, saveBitriseConfig(ConfigModel{AccessibleOutput: true}))
+		require.Equal(t, true, IsAccessibleOutputRequested())
+	}
+
+	t.Log("env overrides the config")
+	{
+		require.Equal(t, nil, os.Setenv(AccessibleOutputEnvKey, "false"))
+		require.Equal(t, false, IsAccessibleOutputRequested())
+
+		require.Equal(t, nil, saveBitriseConfig(ConfigModel{}))
+		require.Equal(t, nil, os.Setenv(AccessibleOutputEnvKey, "true"))
+		require.Equal(t, true, IsAccessibleOutputRequested())
+	}
+}
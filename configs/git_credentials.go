@@ -0,0 +1,113 @@
+package configs
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+)
+
+// Git credentials of the private StepLibs and git:: step sources.
+// The git_credentials section of the bitrise config (~/.bitrise/config.json) maps git host names
+// to the credential, used by the stepman setup / update / activate and the git clone of the steps:
+// "git_credentials": {
+//   "github.com": { "token_env": "GITHUB_TOKEN" },
+//   "git.example.com": { "ssh_key_path": "/home/ci/.ssh/steplib_key" }
+// }
+// The token itself is never stored in the config, it's read from the given env.
+
+// DefaultGitCredentialUsername is the username of the token authentication, if not specified
+const DefaultGitCredentialUsername = "x-access-token"
+
+// GitCredentialModel is the credential of a git host: either an HTTPS token, or an SSH key
+type GitCredentialModel struct {
+	// Username of the token authentication (default: DefaultGitCredentialUsername)
+	Username string `json:"username,omitempty"`
+	// TokenEnv is the key of the env, which holds the token of the HTTPS authentication
+	TokenEnv string `json:"token_env,omitempty"`
+	// SSHKeyPath is the path of the private key of the SSH authentication
+	SSHKeyPath string `json:"ssh_key_path,omitempty"`
+}
+
+// Validate ...
+func (credential GitCredentialModel) Validate() error {
+	if credential.TokenEnv == "" && credential.SSHKeyPath == "" {
+		return errors.New("neither token_env nor ssh_key_path specified")
+	}
+	if credential.TokenEnv != "" && credential.SSHKeyPath != "" {
+		return errors.New("both token_env and ssh_key_path specified")
+	}
+	return nil
+}
+
+// GitCredentials returns the git credentials of the bitrise config, by host name
+func GitCredentials() (map[string]GitCredentialModel, error) {
+	config, err := loadBitriseConfig()
+	if err != nil {
+		return map[string]GitCredentialModel{}, err
+	}
+	if config.GitCredentials == nil {
+		return map[string]GitCredentialModel{}, nil
+	}
+	return config.GitCredentials, nil
+}
+
+// GitCredentialEnvs returns the envs, which make git use the given credentials:
+// the tokens are sent as the authorization header of the host's HTTPS requests (GIT_CONFIG_* envs),
+// the SSH keys are selected by host, with the SSH config written to sshConfigPth (GIT_SSH_COMMAND env).
+func GitCredentialEnvs(credentials map[string]GitCredentialModel, sshConfigPth string) ([]string, error) {
+	hosts := []string{}
+	for host := range credentials {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	envs := []string{}
+	headerCount := 0
+	sshConfig := ""
+	for _, host := range hosts {
+		credential := credentials[host]
+		if host == "" {
+			return []string{}, errors.New("git credential with empty host")
+		}
+		if err := credential.Validate(); err != nil {
+			return []string{}, fmt.Errorf("invalid git credential of host (%s): %s", host, err)
+		}
+
+		if credential.TokenEnv != "" {
+			token := os.Getenv(credential.TokenEnv)
+			if token == "" {
+				return []string{}, fmt.Errorf("token env (%s) of git host (%s) is not set", credential.TokenEnv, host)
+			}
+			username := credential.Username
+			if username == "" {
+				username = DefaultGitCredentialUsername
+			}
+
+			auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + token))
+			envs = append(envs,
+				fmt.Sprintf("GIT_CONFIG_KEY_%d=http.https://%s/.extraheader", headerCount, host),
+				fmt.Sprintf("GIT_CONFIG_VALUE_%d=Authorization: Basic %s", headerCount, auth))
+			headerCount++
+		} else {
+			sshConfig += fmt.Sprintf("Host %s\n  IdentityFile %s\n  IdentitiesOnly yes\n\n", host, credential.SSHKeyPath)
+		}
+	}
+
+	if headerCount > 0 {
+		envs = append([]string{fmt.Sprintf("GIT_CONFIG_COUNT=%d", headerCount)}, envs...)
+	}
+	if sshConfig != "" {
+		// the user's SSH config is still used for the other hosts
+		sshConfig += "Host *\n  Include ~/.ssh/config\n"
+		if err := fileutil.WriteStringToFile(sshConfigPth, sshConfig); err != nil {
+			return []string{}, fmt.Errorf("failed to write SSH config (%s), error: %s", sshConfigPth, err)
+		}
+		envs = append(envs, "GIT_SSH_COMMAND=ssh -F "+strings.Replace(sshConfigPth, " ", "\\ ", -1))
+	}
+	return envs, nil
+}
@@ -0,0 +1,264 @@
+package configs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// HTTP client of the network operations (tool and plugin downloads, GitHub API, policy, secrets providers, registries).
+// The http_client section of the bitrise config (~/.bitrise/config.json) configures the shared transport:
+// "http_client": {
+//   "connect_timeout_sec": 30,
+//   "read_timeout_sec": 60,
+//   "max_idle_conns": 100,
+//   "max_idle_conns_per_host": 10,
+//   "tls_min_version": "1.2",
+//   "ca_cert_paths": ["/etc/ssl/corporate-proxy-ca.pem"],
+//   "client_cert_path": "/home/ci/.bitrise/client.crt",
+//   "client_key_path": "/home/ci/.bitrise/client.key"
+// }
+// The read timeout is the max time of waiting for the response headers, and for each read of the response body,
+// so the long downloads are not limited, only the stalled connections are aborted.
+// The CA certificates (PEM) are trusted in addition to the system roots (e.g. the CA of a TLS-intercepting proxy),
+// the client certificate (PEM) is presented to the servers requesting mutual TLS authentication.
+// An invalid http_client config (e.g. an unreadable CA or client certificate) fails the requests, they are not sent without it.
+
+const (
+	defaultHTTPConnectTimeout      = 30 * time.Second
+	defaultHTTPReadTimeout         = 60 * time.Second
+	defaultHTTPMaxIdleConns        = 100
+	defaultHTTPMaxIdleConnsPerHost = 10
+	httpIdleConnTimeout            = 90 * time.Second
+	httpTLSHandshakeTimeout        = 10 * time.Second
+)
+
+// HTTPClientConfigModel is the config of the shared HTTP client, the zero values mean the defaults
+type HTTPClientConfigModel struct {
+	// ConnectTimeoutSec is the timeout of the TCP connection (default: 30)
+	ConnectTimeoutSec int `json:"connect_timeout_sec,omitempty"`
+	// ReadTimeoutSec is the timeout of waiting for the response headers and each read of the body (default: 60)
+	ReadTimeoutSec int `json:"read_timeout_sec,omitempty"`
+	// MaxIdleConns is the max number of the idle (keep-alive) connections (default: 100)
+	MaxIdleConns int `json:"max_idle_conns,omitempty"`
+	// MaxIdleConnsPerHost is the max number of the idle (keep-alive) connections per host (default: 10)
+	MaxIdleConnsPerHost int `json:"max_idle_conns_per_host,omitempty"`
+	// TLSMinVersion is the minimum accepted TLS version: 1.0, 1.1 or 1.2 (default: 1.2)
+	TLSMinVersion string `json:"tls_min_version,omitempty"`
+	// CACertPaths are the PEM files of the trusted root CAs, in addition to the system roots
+	CACertPaths []string `json:"ca_cert_paths,omitempty"`
+	// ClientCertPath is the PEM file of the client certificate of the mutual TLS authentication
+	ClientCertPath string `json:"client_cert_path,omitempty"`
+	// ClientKeyPath is the PEM file of the client certificate's private key
+	ClientKeyPath string `json:"client_key_path,omitempty"`
+}
+
+// Validate ...
+func (config HTTPClientConfigModel) Validate() error {
+	if config.ConnectTimeoutSec < 0 {
+		return fmt.Errorf("invalid connect_timeout_sec (%d), should not be negative", config.ConnectTimeoutSec)
+	}
+	if config.ReadTimeoutSec < 0 {
+		return fmt.Errorf("invalid read_timeout_sec (%d), should not be negative", config.ReadTimeoutSec)
+	}
+	if config.MaxIdleConns < 0 {
+		return fmt.Errorf("invalid max_idle_conns (%d), should not be negative", config.MaxIdleConns)
+	}
+	if config.MaxIdleConnsPerHost < 0 {
+		return fmt.Errorf("invalid max_idle_conns_per_host (%d), should not be negative", config.MaxIdleConnsPerHost)
+	}
+	if _, err := parseTLSVersion(config.TLSMinVersion); err != nil {
+		return err
+	}
+	if (config.ClientCertPath == "") != (config.ClientKeyPath == "") {
+		return fmt.Errorf("both client_cert_path and client_key_path should be specified")
+	}
+	return nil
+}
+
+func parseTLSVersion(version string) (uint16, error) {
+	switch version {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.0":
+		return tls.VersionTLS10, nil
+	}
+	return 0, fmt.Errorf("invalid tls_min_version (%s), accepted: 1.0, 1.1, 1.2", version)
+}
+
+// caCertPaths returns the CA certificate files of the config, and the one of the BITRISE_CA_CERT_PATH env
+func (config HTTPClientConfigModel) caCertPaths() []string {
+	pths := append([]string{}, config.CACertPaths...)
+	if pth := os.Getenv(CACertPathEnvKey); pth != "" {
+		pths = append(pths, pth)
+	}
+	return pths
+}
+
+func newTLSConfig(config HTTPClientConfigModel) (*tls.Config, error) {
+	tlsMinVersion, err := parseTLSVersion(config.TLSMinVersion)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{MinVersion: tlsMinVersion}
+
+	if caCertPaths := config.caCertPaths(); len(caCertPaths) > 0 {
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			log.Debugf("Failed to load the system root CAs, error: %s", err)
+			rootCAs = x509.NewCertPool()
+		}
+
+		for _, pth := range caCertPaths {
+			pemBytes, err := ioutil.ReadFile(pth)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to read CA certificate (%s), error: %s", pth, err)
+			}
+			if !rootCAs.AppendCertsFromPEM(pemBytes) {
+				return nil, fmt.Errorf("no PEM certificate found in CA certificate file (%s)", pth)
+			}
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if config.ClientCertPath != "" {
+		clientCert, err := tls.LoadX509KeyPair(config.ClientCertPath, config.ClientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load client certificate (%s), error: %s", config.ClientCertPath, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	return tlsConfig, nil
+}
+
+func durationOrDefault(sec int, defaultDuration time.Duration) time.Duration {
+	if sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return defaultDuration
+}
+
+func intOrDefault(value, defaultValue int) int {
+	if value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// NewHTTPTransport returns the transport, configured by the http client config
+func NewHTTPTransport(config HTTPClientConfigModel) (*http.Transport, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	readTimeout := durationOrDefault(config.ReadTimeoutSec, defaultHTTPReadTimeout)
+	dialer := &net.Dialer{
+		Timeout:   durationOrDefault(config.ConnectTimeoutSec, defaultHTTPConnectTimeout),
+		KeepAlive: 30 * time.Second,
+	}
+
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		Dial: func(network, address string) (net.Conn, error) {
+			conn, err := dialer.Dial(network, address)
+			if err != nil {
+				return nil, err
+			}
+			return &readTimeoutConn{Conn: conn, timeout: readTimeout}, nil
+		},
+		MaxIdleConns:          intOrDefault(config.MaxIdleConns, defaultHTTPMaxIdleConns),
+		MaxIdleConnsPerHost:   intOrDefault(config.MaxIdleConnsPerHost, defaultHTTPMaxIdleConnsPerHost),
+		IdleConnTimeout:       httpIdleConnTimeout,
+		TLSHandshakeTimeout:   httpTLSHandshakeTimeout,
+		ResponseHeaderTimeout: readTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig:       tlsConfig,
+	}, nil
+}
+
+// readTimeoutConn aborts the reads, which don't receive any data within the timeout
+type readTimeoutConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (conn *readTimeoutConn) Read(p []byte) (int, error) {
+	if err := conn.Conn.SetReadDeadline(time.Now().Add(conn.timeout)); err != nil {
+		return 0, err
+	}
+	return conn.Conn.Read(p)
+}
+
+var (
+	sharedHTTPTransport     http.RoundTripper
+	sharedHTTPTransportOnce sync.Once
+)
+
+// invalidConfigTransport fails every request with the error of the invalid http_client config,
+// the requests are not sent without the configured CA certificates or client certificate.
+type invalidConfigTransport struct {
+	err error
+}
+
+// RoundTrip ...
+func (transport invalidConfigTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Body != nil {
+		if err := request.Body.Close(); err != nil {
+			log.Debugf("Failed to close request body, error: %s", err)
+		}
+	}
+	return nil, transport.err
+}
+
+// newSharedHTTPTransport returns the transport of the config,
+// or a transport failing every request, if the config is invalid (e.g. a CA certificate can't be read).
+func newSharedHTTPTransport(config HTTPClientConfigModel) http.RoundTripper {
+	transport, err := NewHTTPTransport(config)
+	if err != nil {
+		return invalidConfigTransport{err: fmt.Errorf("Invalid http_client config, error: %s", err)}
+	}
+	return transport
+}
+
+func httpTransport() http.RoundTripper {
+	sharedHTTPTransportOnce.Do(func() {
+		config, err := loadBitriseConfig()
+		if err != nil {
+			log.Warnf("Failed to read bitrise config, error: %s", err)
+		}
+
+		httpClientConfig := HTTPClientConfigModel{}
+		if config.HTTPClient != nil {
+			httpClientConfig = *config.HTTPClient
+		}
+
+		sharedHTTPTransport = newSharedHTTPTransport(httpClientConfig)
+	})
+	return sharedHTTPTransport
+}
+
+// HTTPClient returns the shared HTTP client of the network operations, without overall timeout (e.g. for downloads)
+func HTTPClient() *http.Client {
+	return &http.Client{Transport: httpTransport()}
+}
+
+// NewHTTPClient returns a client of the shared transport, with the given overall timeout of the requests
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: httpTransport(), Timeout: timeout}
+}
@@ -0,0 +1,217 @@
+package configs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPClientConfigModelValidate(t *testing.T) {
+	require.NoError(t, HTTPClientConfigModel{}.Validate())
+	require.NoError(t, HTTPClientConfigModel{ConnectTimeoutSec: 5, ReadTimeoutSec: 10, TLSMinVersion: "1.1"}.Validate())
+
+	require.Error(t, HTTPClientConfigModel{ConnectTimeoutSec: -1}.Validate())
+	require.Error(t, HTTPClientConfigModel{ReadTimeoutSec: -1}.Validate())
+	require.Error(t, HTTPClientConfigModel{MaxIdleConns: -1}.Validate())
+	require.Error(t, HTTPClientConfigModel{MaxIdleConnsPerHost: -1}.Validate())
+	require.Error(t, HTTPClientConfigModel{TLSMinVersion: "1.3.1"}.Validate())
+	require.Error(t, HTTPClientConfigModel{ClientCertPath: "client.crt"}.Validate())
+	require.Error(t, HTTPClientConfigModel{ClientKeyPath: "client.key"}.Validate())
+}
+
+func TestNewHTTPTransport(t *testing.T) {
+	t.Log("defaults")
+	{
+		transport, err := NewHTTPTransport(HTTPClientConfigModel{})
+		require.NoError(t, err)
+		require.Equal(t, defaultHTTPMaxIdleConns, transport.MaxIdleConns)
+		require.Equal(t, defaultHTTPMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+		require.Equal(t, defaultHTTPReadTimeout, transport.ResponseHeaderTimeout)
+		require.Equal(t, uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+	}
+
+	t.Log("configured")
+	{
+		transport, err := NewHTTPTransport(HTTPClientConfigModel{ReadTimeoutSec: 5, MaxIdleConns: 3, MaxIdleConnsPerHost: 1, TLSMinVersion: "1.0"})
+		require.NoError(t, err)
+		require.Equal(t, 3, transport.MaxIdleConns)
+		require.Equal(t, 1, transport.MaxIdleConnsPerHost)
+		require.Equal(t, 5*time.Second, transport.ResponseHeaderTimeout)
+		require.Equal(t, uint16(tls.VersionTLS10), transport.TLSClientConfig.MinVersion)
+	}
+
+	t.Log("invalid config")
+	{
+		_, err := NewHTTPTransport(HTTPClientConfigModel{TLSMinVersion: "2.0"})
+		require.Error(t, err)
+	}
+}
+
+func TestNewSharedHTTPTransport(t *testing.T) {
+	t.Log("valid config")
+	{
+		transport := newSharedHTTPTransport(HTTPClientConfigModel{})
+		_, isTransport := transport.(*http.Transport)
+		require.True(t, isTransport)
+	}
+
+	t.Log("unreadable CA certificate fails the requests")
+	{
+		transport := newSharedHTTPTransport(HTTPClientConfigModel{CACertPaths: []string{"/not/existing/ca.pem"}})
+
+		client := &http.Client{Transport: transport}
+		_, err := client.Get("https://bitrise.io")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Invalid http_client config")
+		require.Contains(t, err.Error(), "Failed to read CA certificate (/not/existing/ca.pem)")
+	}
+}
+
+func TestReadTimeoutConn(t *testing.T) {
+	release := make(chan bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write([]byte("partial"))
+		require.NoError(t, err)
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	transport, err := NewHTTPTransport(HTTPClientConfigModel{ReadTimeoutSec: 1})
+	require.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, resp.Body.Close())
+	}()
+
+	// the body is not completed, the stalled read is aborted by the read timeout
+	start := time.Now()
+	_, err = ioutil.ReadAll(resp.Body)
+	require.Error(t, err)
+	require.True(t, time.Since(start) < 10*time.Second)
+}
+
+// writeTestCertificate writes a self-signed certificate (valid for 127.0.0.1) and its key as PEM files
+func writeTestCertificate(t *testing.T, dir, name string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPth := filepath.Join(dir, name+".crt")
+	keyPth := filepath.Join(dir, name+".key")
+	require.NoError(t, ioutil.WriteFile(certPth, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0600))
+	require.NoError(t, ioutil.WriteFile(keyPth, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certPth, keyPth
+}
+
+func TestHTTPClientTLS(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__http_client_tls__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	serverCertPth, serverKeyPth := writeTestCertificate(t, tmpDir, "server")
+	clientCertPth, clientKeyPth := writeTestCertificate(t, tmpDir, "client")
+
+	serverCert, err := tls.LoadX509KeyPair(serverCertPth, serverKeyPth)
+	require.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	clientCAPEM, err := ioutil.ReadFile(clientCertPth)
+	require.NoError(t, err)
+	require.True(t, clientCAs.AppendCertsFromPEM(clientCAPEM))
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	get := func(config HTTPClientConfigModel) error {
+		transport, err := NewHTTPTransport(config)
+		require.NoError(t, err)
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	t.Log("server CA is not trusted")
+	{
+		require.Error(t, get(HTTPClientConfigModel{ClientCertPath: clientCertPth, ClientKeyPath: clientKeyPth}))
+	}
+
+	t.Log("no client certificate")
+	{
+		require.Error(t, get(HTTPClientConfigModel{CACertPaths: []string{serverCertPth}}))
+	}
+
+	t.Log("trusted CA and client certificate")
+	{
+		require.NoError(t, get(HTTPClientConfigModel{
+			CACertPaths:    []string{serverCertPth},
+			ClientCertPath: clientCertPth,
+			ClientKeyPath:  clientKeyPth,
+		}))
+	}
+
+	t.Log("CA from env")
+	{
+		require.NoError(t, os.Setenv(CACertPathEnvKey, serverCertPth))
+		defer func() {
+			require.NoError(t, os.Unsetenv(CACertPathEnvKey))
+		}()
+		require.NoError(t, get(HTTPClientConfigModel{ClientCertPath: clientCertPth, ClientKeyPath: clientKeyPth}))
+	}
+
+	t.Log("invalid CA and client certificate files")
+	{
+		_, err := NewHTTPTransport(HTTPClientConfigModel{CACertPaths: []string{filepath.Join(tmpDir, "not-exist.pem")}})
+		require.Error(t, err)
+
+		_, err = NewHTTPTransport(HTTPClientConfigModel{CACertPaths: []string{serverKeyPth}})
+		require.Error(t, err)
+
+		_, err = NewHTTPTransport(HTTPClientConfigModel{ClientCertPath: clientCertPth, ClientKeyPath: serverKeyPth})
+		require.Error(t, err)
+	}
+}
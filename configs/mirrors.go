@@ -0,0 +1,41 @@
+package configs
+
+import (
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Download mirrors, for air-gapped / enterprise networks.
+// The mirrors section of the bitrise config (~/.bitrise/config.json) maps URL prefixes
+// (e.g. https://github.com/ or the default StepLib's git URL) to the prefix of the internal mirror:
+// "mirrors": { "https://github.com/": "https://mirror.example.com/github/" }
+
+// MirrorURL returns the mirrored version of the given URL,
+// based on the longest matching prefix of the configured mirrors.
+// Returns the original URL if no mirror configured for it.
+func MirrorURL(url string) string {
+	config, err := loadBitriseConfig()
+	if err != nil {
+		log.Warnf("Failed to read bitrise config, error: %s", err)
+		return url
+	}
+	return mirrorURL(url, config.Mirrors)
+}
+
+func mirrorURL(url string, mirrors map[string]string) string {
+	matchingPrefix := ""
+	for prefix := range mirrors {
+		if prefix != "" && strings.HasPrefix(url, prefix) && len(prefix) > len(matchingPrefix) {
+			matchingPrefix = prefix
+		}
+	}
+
+	if matchingPrefix == "" {
+		return url
+	}
+
+	mirroredURL := mirrors[matchingPrefix] + strings.TrimPrefix(url, matchingPrefix)
+	log.Debugf("Using mirror (%s) for (%s)", mirroredURL, url)
+	return mirroredURL
+}
@@ -0,0 +1,55 @@
+package configs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirrorURL(t *testing.T) {
+	t.Log("longest matching prefix")
+	{
+		mirrors := map[string]string{
+			"https://github.com/":                                    "https://mirror.example.com/github/",
+			"https://github.com/bitrise-io/bitrise-steplib.git":      "https://git.example.com/steplib.git",
+			"https://github.com/bitrise-io/envman/releases/download": "https://mirror.example.com/envman",
+		}
+
+		require.Equal(t, "https://mirror.example.com/github/bitrise-io/stepman/releases/download/0.9.30/stepman-Linux-x86_64",
+			mirrorURL("https://github.com/bitrise-io/stepman/releases/download/0.9.30/stepman-Linux-x86_64", mirrors))
+		require.Equal(t, "https://mirror.example.com/envman/1.1.3/envman-Linux-x86_64",
+			mirrorURL("https://github.com/bitrise-io/envman/releases/download/1.1.3/envman-Linux-x86_64", mirrors))
+		require.Equal(t, "https://git.example.com/steplib.git",
+			mirrorURL("https://github.com/bitrise-io/bitrise-steplib.git", mirrors))
+		require.Equal(t, "https://bitbucket.org/steplib.git",
+			mirrorURL("https://bitbucket.org/steplib.git", mirrors))
+	}
+
+	t.Log("no mirrors")
+	{
+		require.Equal(t, "https://github.com/bitrise-io/bitrise-steplib.git", mirrorURL("https://github.com/bitrise-io/bitrise-steplib.git", nil))
+	}
+
+	t.Log("mirrors from the bitrise config")
+	{
+		fakeHomePth, err := pathutil.NormalizedOSTempDirPath("_FAKE_HOME")
+		require.NoError(t, err)
+		originalHome := os.Getenv("HOME")
+
+		defer func() {
+			require.NoError(t, os.Setenv("HOME", originalHome))
+			require.NoError(t, os.RemoveAll(fakeHomePth))
+		}()
+
+		require.NoError(t, os.Setenv("HOME", fakeHomePth))
+
+		require.Equal(t, "https://github.com/bitrise-io/bitrise-steplib.git", MirrorURL("https://github.com/bitrise-io/bitrise-steplib.git"))
+
+		require.NoError(t, EnsureBitriseConfigDirExists())
+		require.NoError(t, saveBitriseConfig(ConfigModel{Mirrors: map[string]string{"https://github.com/": "https://mirror.example.com/"}}))
+
+		require.Equal(t, "https://mirror.example.com/bitrise-io/bitrise-steplib.git", MirrorURL("https://github.com/bitrise-io/bitrise-steplib.git"))
+	}
+}
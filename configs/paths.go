@@ -21,6 +21,9 @@ var (
 	BitriseWorkDirPath string
 	// BitriseWorkStepsDirPath ...
 	BitriseWorkStepsDirPath string
+	// BitriseDebugDirPath is the dir of the run's debug files (e.g. profiles of the CLI),
+	// created only if required
+	BitriseDebugDirPath string
 	// CurrentDir ...
 	CurrentDir string
 )
@@ -36,6 +39,8 @@ const (
 	BitriseDeployDirEnvKey = "BITRISE_DEPLOY_DIR"
 	// BitriseCacheDirEnvKey ...
 	BitriseCacheDirEnvKey = "BITRISE_CACHE_DIR"
+	// BitriseTestResultDirEnvKey is the dir the steps place their test results (JUnit XML, XCResult) into
+	BitriseTestResultDirEnvKey = "BITRISE_TEST_RESULT_DIR"
 )
 
 // GetBitriseHomeDirPath ...
@@ -52,6 +57,48 @@ func GetBitriseToolsDirPath() string {
 	return filepath.Join(GetBitriseHomeDirPath(), "tools")
 }
 
+// GetBitriseToolVersionDirPath returns the directory of the given version of a bitrise tool,
+// pinned by a project's config (~/.bitrise/tools/versions/TOOL/VERSION)
+func GetBitriseToolVersionDirPath(toolname, version string) string {
+	return filepath.Join(GetBitriseToolsDirPath(), "versions", toolname, version)
+}
+
+// GetBitriseConfigCacheDirPath ...
+func GetBitriseConfigCacheDirPath() string {
+	return filepath.Join(GetBitriseHomeDirPath(), "cache", "configs")
+}
+
+// GetBitriseStepEnvHistoryDirPath returns the dir of the inputs and envs of the last successful step runs
+func GetBitriseStepEnvHistoryDirPath() string {
+	return filepath.Join(GetBitriseHomeDirPath(), "history", "steps")
+}
+
+// GetBitriseLastRunDirPath returns the dir of the last runs of the workflows (see: --rerun-failed)
+func GetBitriseLastRunDirPath() string {
+	return filepath.Join(GetBitriseHomeDirPath(), "history", "runs")
+}
+
+// GetBitriseSecretsKeyPath returns the path of the key, used to encrypt the secrets files,
+// ~/.bitrise/secrets.key by default.
+func GetBitriseSecretsKeyPath() string {
+	if pth := os.Getenv(SecretsKeyPathEnvKey); pth != "" {
+		return pth
+	}
+	return filepath.Join(GetBitriseHomeDirPath(), "secrets.key")
+}
+
+// GetAgeKeyFilePath returns the path of the age identity file, used to decrypt the age and SOPS encrypted secrets files:
+// BITRISE_AGE_KEY_FILE, SOPS_AGE_KEY_FILE, or the default key file of SOPS (~/.config/sops/age/keys.txt).
+func GetAgeKeyFilePath() string {
+	if pth := os.Getenv(AgeKeyFileEnvKey); pth != "" {
+		return pth
+	}
+	if pth := os.Getenv(SOPSAgeKeyFileEnvKey); pth != "" {
+		return pth
+	}
+	return filepath.Join(pathutil.UserHomeDir(), ".config", "sops", "age", "keys.txt")
+}
+
 // GetBitriseToolkitsDirPath ...
 func GetBitriseToolkitsDirPath() string {
 	return filepath.Join(GetBitriseHomeDirPath(), "toolkits")
@@ -84,6 +131,8 @@ func initBitriseWorkPaths() error {
 	}
 	BitriseWorkStepsDirPath = bitriseWorkStepsDirPath
 
+	BitriseDebugDirPath = filepath.Join(BitriseWorkDirPath, "debug")
+
 	return nil
 }
 
@@ -99,10 +148,11 @@ func GeneratePATHEnvString(currentPATHEnv, pathToInclude string) string {
 		return currentPATHEnv
 	}
 
+	separator := string(os.PathListSeparator)
 	pthWithPathIncluded := currentPATHEnv
 	if !strings.HasSuffix(pthWithPathIncluded, pathToInclude) &&
-		!strings.Contains(pthWithPathIncluded, pathToInclude+":") {
-		pthWithPathIncluded = pathToInclude + ":" + pthWithPathIncluded
+		!strings.Contains(pthWithPathIncluded, pathToInclude+separator) {
+		pthWithPathIncluded = pathToInclude + separator + pthWithPathIncluded
 	}
 	return pthWithPathIncluded
 }
@@ -173,6 +223,18 @@ func InitPaths() error {
 		}
 	}
 
+	// BITRISE_TEST_RESULT_DIR
+	if os.Getenv(BitriseTestResultDirEnvKey) == "" {
+		testResultDir, err := pathutil.NormalizedOSTempDirPath("test_results")
+		if err != nil {
+			return fmt.Errorf("Failed to set test result dir, error: %s", err)
+		}
+
+		if err := os.Setenv(BitriseTestResultDirEnvKey, testResultDir); err != nil {
+			return fmt.Errorf("Failed to set BITRISE_TEST_RESULT_DIR, error: %s", err)
+		}
+	}
+
 	// BITRISE_CACHE_DIR
 	if os.Getenv(BitriseCacheDirEnvKey) == "" {
 		cacheDir, err := pathutil.NormalizedOSTempDirPath("cache")
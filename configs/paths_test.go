@@ -71,4 +71,19 @@ func TestInitPaths(t *testing.T) {
 	require.Equal(t, nil, os.Setenv(BitriseDeployDirEnvKey, "$HOME/test"))
 	require.Equal(t, nil, InitPaths())
 	require.Equal(t, "$HOME/test", os.Getenv(BitriseDeployDirEnvKey))
+
+	//
+	// BITRISE_TEST_RESULT_DIR
+
+	// Unset BITRISE_TEST_RESULT_DIR -> after InitPaths BITRISE_TEST_RESULT_DIR should be temp dir
+	if os.Getenv(BitriseTestResultDirEnvKey) != "" {
+		require.Equal(t, nil, os.Unsetenv(BitriseTestResultDirEnvKey))
+	}
+	require.Equal(t, nil, InitPaths())
+	require.NotEqual(t, "", os.Getenv(BitriseTestResultDirEnvKey))
+
+	// Set BITRISE_TEST_RESULT_DIR -> after InitPaths BITRISE_TEST_RESULT_DIR should keep content
+	require.Equal(t, nil, os.Setenv(BitriseTestResultDirEnvKey, "$HOME/test"))
+	require.Equal(t, nil, InitPaths())
+	require.Equal(t, "$HOME/test", os.Getenv(BitriseTestResultDirEnvKey))
 }
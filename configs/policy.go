@@ -0,0 +1,70 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Organization policy bundle.
+// The policy section of the bitrise config (~/.bitrise/config.json) points to the signed policy bundle of the organization:
+// "policy": { "url": "https://policy.example.com/bitrise.yml", "public_key": "BASE64 PKIX ECDSA KEY", "ttl_sec": 3600, "max_stale_sec": 86400 }
+// The url can be overridden with the BITRISE_POLICY_URL env, the public_key can only be set in the bitrise config,
+// so the signature verification can't be bypassed from the environment of the run.
+
+const (
+	// DefaultPolicyTTL ...
+	DefaultPolicyTTL = 1 * time.Hour
+	// DefaultPolicyMaxStale ...
+	DefaultPolicyMaxStale = 24 * time.Hour
+)
+
+// PolicyConfigModel ...
+type PolicyConfigModel struct {
+	URL       string `json:"url,omitempty"`
+	PublicKey string `json:"public_key,omitempty"`
+	TTLSec    int    `json:"ttl_sec,omitempty"`
+	// MaxStaleSec is the maximum age of the cached bundle, which is used if the bundle can't be downloaded
+	MaxStaleSec int `json:"max_stale_sec,omitempty"`
+}
+
+// TTL is the time, the fetched policy bundle is used from the cache
+func (policyConfig PolicyConfigModel) TTL() time.Duration {
+	if policyConfig.TTLSec <= 0 {
+		return DefaultPolicyTTL
+	}
+	return time.Duration(policyConfig.TTLSec) * time.Second
+}
+
+// MaxStale is the maximum age of the cached policy bundle, which is used if the download fails
+func (policyConfig PolicyConfigModel) MaxStale() time.Duration {
+	if policyConfig.MaxStaleSec <= 0 {
+		return DefaultPolicyMaxStale
+	}
+	return time.Duration(policyConfig.MaxStaleSec) * time.Second
+}
+
+// PolicyConfig returns the organization policy settings of the bitrise config, the URL is overridden by the env.
+// The returned URL is empty, if no policy configured.
+func PolicyConfig() (PolicyConfigModel, error) {
+	config, err := loadBitriseConfig()
+	if err != nil {
+		return PolicyConfigModel{}, err
+	}
+
+	policyConfig := PolicyConfigModel{}
+	if config.Policy != nil {
+		policyConfig = *config.Policy
+	}
+
+	if url := os.Getenv(PolicyURLEnvKey); url != "" {
+		policyConfig.URL = url
+	}
+
+	return policyConfig, nil
+}
+
+// GetBitrisePolicyCacheDirPath ...
+func GetBitrisePolicyCacheDirPath() string {
+	return filepath.Join(GetBitriseHomeDirPath(), "cache", "policy")
+}
@@ -0,0 +1,49 @@
+package configs
+
+import (
+	"fmt"
+	"os"
+)
+
+// Credentials of the OCI registries, which distribute the oci:// steps.
+// The registry_credentials section of the bitrise config (~/.bitrise/config.json) maps registry hosts
+// to the credential, used for the registry's basic or token authentication:
+// "registry_credentials": {
+//   "ghcr.io": { "username": "ci-bot", "token_env": "GHCR_TOKEN" }
+// }
+// The token itself is never stored in the config, it's read from the given env.
+
+// RegistryCredentialModel is the credential of an OCI registry
+type RegistryCredentialModel struct {
+	// Username of the authentication (default: DefaultGitCredentialUsername)
+	Username string `json:"username,omitempty"`
+	// TokenEnv is the key of the env, which holds the password or token of the authentication
+	TokenEnv string `json:"token_env"`
+}
+
+// RegistryCredential returns the username and the password of the registry's credential,
+// found is false, if the bitrise config has no credential for the registry.
+func RegistryCredential(registry string) (string, string, bool, error) {
+	config, err := loadBitriseConfig()
+	if err != nil {
+		return "", "", false, err
+	}
+	credential, found := config.RegistryCredentials[registry]
+	if !found {
+		return "", "", false, nil
+	}
+
+	if credential.TokenEnv == "" {
+		return "", "", false, fmt.Errorf("invalid registry credential of registry (%s): token_env not specified", registry)
+	}
+	token := os.Getenv(credential.TokenEnv)
+	if token == "" {
+		return "", "", false, fmt.Errorf("invalid registry credential of registry (%s): env (%s) is not set", registry, credential.TokenEnv)
+	}
+
+	username := credential.Username
+	if username == "" {
+		username = DefaultGitCredentialUsername
+	}
+	return username, token, true, nil
+}
@@ -0,0 +1,143 @@
+package exitcode
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Exit codes of the bitrise CLI, by failure class, so the wrappers of the CLI can branch on the type of the failure.
+// The unexpected errors of the CLI (log.Fatal) exit with InternalError, once RegisterFatalExitHandler is called.
+const (
+	// Success ...
+	Success = 0
+	// StepFailed is the exit code of a failed build (a not skippable step failed)
+	StepFailed = 1
+	// InternalError is the exit code of an unexpected CLI error (the same code a Go program exits with on panic)
+	InternalError = 2
+	// ConfigInvalid is the exit code of an invalid config (bitrise.yml) or secrets (.bitrise.secrets.yml)
+	ConfigInvalid = 3
+	// TriggerNotMatched is the exit code of a trigger, which doesn't match any item of the trigger map
+	TriggerNotMatched = 4
+	// StepTimedOut is the exit code of a build, which failed because a step (or its workflow) timed out
+	StepTimedOut = 5
+	// Usage is the exit code of an invalid command line: an unknown command, an invalid flag value, a missing or not runnable workflow.
+	// It's the EX_USAGE code of sysexits.h.
+	Usage = 64
+	// Aborted is the exit code of a build aborted by a signal,
+	// the same code a shell uses for a command interrupted by SIGINT.
+	Aborted = 130
+)
+
+// Error is an error, with the exit code of its failure class
+type Error struct {
+	Code int
+	Err  error
+}
+
+// Error ...
+func (err Error) Error() string {
+	return err.Err.Error()
+}
+
+// ExitCode returns the exit code of the error,
+// so the error exits with its code, if it's returned by a command (ExitCoder of github.com/urfave/cli)
+func (err Error) ExitCode() int {
+	return err.Code
+}
+
+// Unwrap returns the underlying error
+func (err Error) Unwrap() error {
+	return err.Err
+}
+
+// New returns the error with the exit code, or nil if the error is nil
+func New(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return Error{Code: code, Err: err}
+}
+
+// Errorf ...
+func Errorf(code int, format string, args ...interface{}) error {
+	return Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// Code returns the exit code of the error: Success for nil, the code of the first Error in the error's chain,
+// and InternalError for any other error.
+func Code(err error) int {
+	if err == nil {
+		return Success
+	}
+	if codeErr, ok := find(err); ok {
+		return codeErr.Code
+	}
+	return InternalError
+}
+
+// Is returns true if the first Error in the error's chain has the given exit code
+func Is(err error, code int) bool {
+	codeErr, ok := find(err)
+	return ok && codeErr.Code == code
+}
+
+// find returns the first Error in the error's chain,
+// the chain is followed by the Unwrap() error and the Cause() error (github.com/pkg/errors) methods.
+func find(err error) (Error, bool) {
+	for err != nil {
+		switch typed := err.(type) {
+		case Error:
+			return typed, true
+		case interface{ Unwrap() error }:
+			err = typed.Unwrap()
+		case interface{ Cause() error }:
+			err = typed.Cause()
+		default:
+			return Error{}, false
+		}
+	}
+	return Error{}, false
+}
+
+var exitHandlers = []func(){}
+
+// RegisterExitHandler registers a handler, which is called before the CLI exits with Exit, Fatal, Fatalf or log.Fatal
+// (e.g. to write the profiles of the run)
+func RegisterExitHandler(handler func()) {
+	exitHandlers = append(exitHandlers, handler)
+}
+
+func runExitHandlers() {
+	for _, handler := range exitHandlers {
+		handler()
+	}
+}
+
+// Exit runs the exit handlers (see: RegisterExitHandler), then exits with the given code
+func Exit(code int) {
+	runExitHandlers()
+	os.Exit(code)
+}
+
+// Fatal logs the message as an error, then exits with the given code
+func Fatal(code int, args ...interface{}) {
+	log.Error(args...)
+	Exit(code)
+}
+
+// Fatalf ...
+func Fatalf(code int, format string, args ...interface{}) {
+	log.Errorf(format, args...)
+	Exit(code)
+}
+
+// RegisterFatalExitHandler makes log.Fatal exit with InternalError, instead of the default exit code (1),
+// so an unexpected error is not reported as a failed build.
+// The known failures (e.g. an invalid config) should exit with their own code, with Fatal or Fatalf.
+func RegisterFatalExitHandler() {
+	log.RegisterExitHandler(func() {
+		Exit(InternalError)
+	})
+}
@@ -0,0 +1,70 @@
+package exitcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCode(t *testing.T) {
+	require.Equal(t, Success, Code(nil))
+	require.Equal(t, InternalError, Code(errors.New("unexpected")))
+	require.Equal(t, ConfigInvalid, Code(New(ConfigInvalid, errors.New("invalid config"))))
+	require.Equal(t, TriggerNotMatched, Code(Errorf(TriggerNotMatched, "no matching workflow for (%s)", "master")))
+
+	t.Log("wrapped errors")
+	{
+		codeErr := New(ConfigInvalid, errors.New("invalid config"))
+		require.Equal(t, ConfigInvalid, Code(fmt.Errorf("failed to run, error: %w", codeErr)))
+		require.Equal(t, ConfigInvalid, Code(causeError{err: codeErr}))
+		require.Equal(t, InternalError, Code(fmt.Errorf("failed to run, error: %s", codeErr)))
+	}
+
+	require.Nil(t, New(ConfigInvalid, nil))
+	require.EqualError(t, Errorf(TriggerNotMatched, "no matching workflow for (%s)", "master"), "no matching workflow for (master)")
+}
+
+func TestCodesAreDistinct(t *testing.T) {
+	codes := map[int]bool{}
+	for _, code := range []int{Success, StepFailed, InternalError, ConfigInvalid, TriggerNotMatched, StepTimedOut, Usage, Aborted} {
+		require.False(t, codes[code], "%d", code)
+		codes[code] = true
+	}
+}
+
+func TestIs(t *testing.T) {
+	require.True(t, Is(New(StepTimedOut, errors.New("timed out")), StepTimedOut))
+	require.False(t, Is(New(StepTimedOut, errors.New("timed out")), StepFailed))
+	require.False(t, Is(errors.New("timed out"), StepTimedOut))
+	require.False(t, Is(nil, Success))
+	require.True(t, Is(fmt.Errorf("failed to run, error: %w", New(StepTimedOut, errors.New("timed out"))), StepTimedOut))
+	require.True(t, Is(causeError{err: New(StepTimedOut, errors.New("timed out"))}, StepTimedOut))
+}
+
+// causeError is a github.com/pkg/errors style wrapper error
+type causeError struct {
+	err error
+}
+
+func (err causeError) Error() string {
+	return "wrapped: " + err.err.Error()
+}
+
+func (err causeError) Cause() error {
+	return err.err
+}
+
+func TestRegisterExitHandler(t *testing.T) {
+	defer func() {
+		exitHandlers = []func(){}
+	}()
+
+	calls := []string{}
+	RegisterExitHandler(func() { calls = append(calls, "first") })
+	RegisterExitHandler(func() { calls = append(calls, "second") })
+
+	runExitHandlers()
+	require.Equal(t, []string{"first", "second"}, calls)
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Steps distributed as archives: a step can be referenced as archive::https://host/step-1.2.3.tar.gz@sha256:<checksum>,
+// the archive (.tar.gz, .tgz, .tar or .zip) is downloaded and extracted, instead of cloning the step's git repository.
+// The checksum is required, it's verified before the archive is extracted.
+
+// ArchiveStepSource is the SteplibSource of the archive steps
+const ArchiveStepSource = "archive"
+
+// ArchiveStepChecksum returns the (sha256:<hex>) checksum of an archive step's ID data
+func (sIDData StepIDData) ArchiveStepChecksum() (string, error) {
+	if sIDData.SteplibSource != ArchiveStepSource {
+		return "", fmt.Errorf("step (%s) is not an archive step", sIDData.IDorURI)
+	}
+	if sIDData.Version == "" {
+		return "", fmt.Errorf("no checksum specified for archive step (%s), should be: archive::<url>@sha256:<checksum>", sIDData.IDorURI)
+	}
+
+	checksum := strings.ToLower(sIDData.Version)
+	hexChecksum := strings.TrimPrefix(checksum, OCIDigestPrefix)
+	if !strings.HasPrefix(checksum, OCIDigestPrefix) || len(hexChecksum) != 64 {
+		return "", fmt.Errorf("invalid checksum (%s) of archive step (%s), should be: sha256:<checksum>", sIDData.Version, sIDData.IDorURI)
+	}
+	if _, err := hex.DecodeString(hexChecksum); err != nil {
+		return "", fmt.Errorf("invalid checksum (%s) of archive step (%s), should be: sha256:<checksum>", sIDData.Version, sIDData.IDorURI)
+	}
+	return checksum, nil
+}
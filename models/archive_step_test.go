@@ -0,0 +1,32 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveStepChecksum(t *testing.T) {
+	checksum := "sha256:" + strings.Repeat("ab", 32)
+
+	stepIDData, err := CreateStepIDDataFromString("archive::https://example.com/steps/my-step-1.2.3.tar.gz@"+checksum, "")
+	require.NoError(t, err)
+	require.Equal(t, StepIDData{SteplibSource: "archive", IDorURI: "https://example.com/steps/my-step-1.2.3.tar.gz", Version: checksum}, stepIDData)
+	require.Equal(t, true, stepIDData.IsUniqueResourceID())
+
+	actual, err := stepIDData.ArchiveStepChecksum()
+	require.NoError(t, err)
+	require.Equal(t, checksum, actual)
+
+	stepIDData, err = CreateStepIDDataFromString("archive::https://example.com/steps/my-step-1.2.3.tar.gz", "")
+	require.NoError(t, err)
+	require.Equal(t, false, stepIDData.IsUniqueResourceID())
+	_, err = stepIDData.ArchiveStepChecksum()
+	require.EqualError(t, err, "no checksum specified for archive step (https://example.com/steps/my-step-1.2.3.tar.gz), should be: archive::<url>@sha256:<checksum>")
+
+	for _, invalid := range []string{"md5:abcd", "sha256:abcd", "sha256:" + strings.Repeat("zz", 32)} {
+		_, err := StepIDData{SteplibSource: "archive", IDorURI: "https://example.com/step.zip", Version: invalid}.ArchiveStepChecksum()
+		require.Error(t, err, invalid)
+	}
+}
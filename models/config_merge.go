@@ -0,0 +1,290 @@
+package models
+
+import (
+	"fmt"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/versions"
+)
+
+// Layered configs: an overlay config is deep-merged into a base config with the following rules
+// (the overlay wins every conflict):
+// - format_version: the higher version
+// - default_step_lib_source, title, summary, description, on_abort, tools, app.working_dir: the overlay's value, if set
+// - step_lib_sources: the overlay's list, if not empty
+// - app.envs, workflow envs, step bundle inputs, step_defaults inputs: merged by key,
+//   an overlay env replaces the base env with the same key (at the base env's position), the new envs are appended
+// - env_files, required_secrets, workflow tags: the union of the lists, an overlay env file replaces the base one with the same path
+// - trigger_map: the overlay items come first (the first matching item wins),
+//   a base item with the same trigger (push_branch, pull request branches, tag, pattern) as an overlay item is dropped
+// - workflows: a workflow defined by only one of the configs is kept as it is,
+//   a workflow defined by both is merged: title, summary, description, owner, working_dir, timeout_secs, prevent_sleep: the overlay's value, if set;
+//   before_run, after_run, steps: the overlay's list, if not empty (lists of steps are not merged item by item);
+//   inputs: merged by input name; if any of the two workflows is an alias (alias_of), the overlay workflow replaces the base one
+// - step_defaults: merged by step ID
+// - step_bundles: merged by bundle ID, like the workflows
+
+// MergeConfigs deep-merges the overlay config into the base config (see the rules above),
+// and returns the merged config, and the paths of the base config values, overridden by the overlay.
+func MergeConfigs(base, overlay BitriseDataModel) (BitriseDataModel, []string, error) {
+	overrides := []string{}
+	override := func(path string, isOverridden bool) {
+		if isOverridden {
+			overrides = append(overrides, path)
+		}
+	}
+
+	merged := base
+
+	if base.FormatVersion == "" {
+		merged.FormatVersion = overlay.FormatVersion
+	} else if overlay.FormatVersion != "" {
+		isOverlayNewer, err := versions.IsVersionGreaterOrEqual(overlay.FormatVersion, base.FormatVersion)
+		if err != nil {
+			return BitriseDataModel{}, []string{}, fmt.Errorf("Failed to compare format versions (%s, %s), error: %s", base.FormatVersion, overlay.FormatVersion, err)
+		}
+		if isOverlayNewer {
+			merged.FormatVersion = overlay.FormatVersion
+		}
+	}
+
+	merged.DefaultStepLibSource = mergeString(base.DefaultStepLibSource, overlay.DefaultStepLibSource, "default_step_lib_source", override)
+	if len(overlay.StepLibSources) > 0 {
+		override("step_lib_sources", len(base.StepLibSources) > 0)
+		merged.StepLibSources = overlay.StepLibSources
+	}
+	merged.Title = mergeString(base.Title, overlay.Title, "title", override)
+	merged.Summary = mergeString(base.Summary, overlay.Summary, "summary", override)
+	merged.Description = mergeString(base.Description, overlay.Description, "description", override)
+	merged.OnAbort = mergeString(base.OnAbort, overlay.OnAbort, "on_abort", override)
+
+	if overlay.Tools != nil {
+		tools := ToolsModel{}
+		if base.Tools != nil {
+			tools = *base.Tools
+		}
+		tools.Stepman = mergeString(tools.Stepman, overlay.Tools.Stepman, "tools.stepman", override)
+		tools.Envman = mergeString(tools.Envman, overlay.Tools.Envman, "tools.envman", override)
+		merged.Tools = &tools
+	}
+
+	merged.App.Title = mergeString(base.App.Title, overlay.App.Title, "app.title", override)
+	merged.App.Summary = mergeString(base.App.Summary, overlay.App.Summary, "app.summary", override)
+	merged.App.Description = mergeString(base.App.Description, overlay.App.Description, "app.description", override)
+	merged.App.WorkingDir = mergeString(base.App.WorkingDir, overlay.App.WorkingDir, "app.working_dir", override)
+	var err error
+	if merged.App.Environments, err = mergeEnvironments(base.App.Environments, overlay.App.Environments, "app.envs", override); err != nil {
+		return BitriseDataModel{}, []string{}, err
+	}
+	merged.App.EnvFiles = mergeEnvFiles(base.App.EnvFiles, overlay.App.EnvFiles, "app.env_files", override)
+
+	merged.TriggerMap = mergeTriggerMaps(base.TriggerMap, overlay.TriggerMap, override)
+
+	merged.Workflows = map[string]WorkflowModel{}
+	for workflowID, workflow := range base.Workflows {
+		merged.Workflows[workflowID] = workflow
+	}
+	for workflowID, overlayWorkflow := range overlay.Workflows {
+		baseWorkflow, found := base.Workflows[workflowID]
+		if !found {
+			merged.Workflows[workflowID] = overlayWorkflow
+			continue
+		}
+
+		workflow, err := mergeWorkflows(baseWorkflow, overlayWorkflow, "workflows."+workflowID, override)
+		if err != nil {
+			return BitriseDataModel{}, []string{}, err
+		}
+		merged.Workflows[workflowID] = workflow
+	}
+
+	merged.StepDefaults = map[string]StepDefaultsModel{}
+	for stepID, defaults := range base.StepDefaults {
+		merged.StepDefaults[stepID] = defaults
+	}
+	for stepID, overlayDefaults := range overlay.StepDefaults {
+		defaults := merged.StepDefaults[stepID]
+		path := "step_defaults." + stepID
+		if defaults.Inputs, err = mergeEnvironments(defaults.Inputs, overlayDefaults.Inputs, path+".inputs", override); err != nil {
+			return BitriseDataModel{}, []string{}, err
+		}
+		defaults.RequiredSecrets = mergeStrings(defaults.RequiredSecrets, overlayDefaults.RequiredSecrets)
+		merged.StepDefaults[stepID] = defaults
+	}
+
+	merged.StepBundles = map[string]StepBundleModel{}
+	for bundleID, bundle := range base.StepBundles {
+		merged.StepBundles[bundleID] = bundle
+	}
+	for bundleID, overlayBundle := range overlay.StepBundles {
+		bundle, found := merged.StepBundles[bundleID]
+		if !found {
+			merged.StepBundles[bundleID] = overlayBundle
+			continue
+		}
+
+		path := "step_bundles." + bundleID
+		bundle.Title = mergeString(bundle.Title, overlayBundle.Title, path+".title", override)
+		if bundle.Inputs, err = mergeEnvironments(bundle.Inputs, overlayBundle.Inputs, path+".inputs", override); err != nil {
+			return BitriseDataModel{}, []string{}, err
+		}
+		if len(overlayBundle.Steps) > 0 {
+			override(path+".steps", len(bundle.Steps) > 0)
+			bundle.Steps = overlayBundle.Steps
+		}
+		merged.StepBundles[bundleID] = bundle
+	}
+
+	return merged, overrides, nil
+}
+
+func mergeString(base, overlay, path string, override func(string, bool)) string {
+	if overlay == "" {
+		return base
+	}
+	override(path, base != "" && base != overlay)
+	return overlay
+}
+
+func mergeStrings(base, overlay []string) []string {
+	merged := append([]string{}, base...)
+	for _, item := range overlay {
+		isFound := false
+		for _, baseItem := range base {
+			isFound = isFound || baseItem == item
+		}
+		if !isFound {
+			merged = append(merged, item)
+		}
+	}
+	return merged
+}
+
+func mergeEnvironments(base, overlay []envmanModels.EnvironmentItemModel, path string, override func(string, bool)) ([]envmanModels.EnvironmentItemModel, error) {
+	merged := append([]envmanModels.EnvironmentItemModel{}, base...)
+	for _, overlayEnv := range overlay {
+		overlayKey, _, err := overlayEnv.GetKeyValuePair()
+		if err != nil {
+			return []envmanModels.EnvironmentItemModel{}, fmt.Errorf("invalid env in %s, error: %s", path, err)
+		}
+
+		isReplaced := false
+		for idx, env := range merged {
+			key, _, err := env.GetKeyValuePair()
+			if err != nil {
+				return []envmanModels.EnvironmentItemModel{}, fmt.Errorf("invalid env in %s, error: %s", path, err)
+			}
+			if key == overlayKey {
+				override(path+"."+key, true)
+				merged[idx] = overlayEnv
+				isReplaced = true
+				break
+			}
+		}
+		if !isReplaced {
+			merged = append(merged, overlayEnv)
+		}
+	}
+	return merged, nil
+}
+
+func mergeEnvFiles(base, overlay []EnvFileModel, path string, override func(string, bool)) []EnvFileModel {
+	merged := append([]EnvFileModel{}, base...)
+	for _, overlayEnvFile := range overlay {
+		isReplaced := false
+		for idx, envFile := range merged {
+			if envFile.Path == overlayEnvFile.Path {
+				override(path+"."+envFile.Path, envFile != overlayEnvFile)
+				merged[idx] = overlayEnvFile
+				isReplaced = true
+				break
+			}
+		}
+		if !isReplaced {
+			merged = append(merged, overlayEnvFile)
+		}
+	}
+	return merged
+}
+
+func triggerMapItemKey(item TriggerMapItemModel) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%s|%t", item.PushBranch, item.PullRequestSourceBranch, item.PullRequestTargetBranch, item.Tag, item.Pattern, item.IsPullRequestAllowed)
+}
+
+func mergeTriggerMaps(base, overlay TriggerMapModel, override func(string, bool)) TriggerMapModel {
+	if len(overlay) == 0 {
+		return base
+	}
+
+	merged := append(TriggerMapModel{}, overlay...)
+	for idx, item := range base {
+		isOverridden := false
+		for _, overlayItem := range overlay {
+			isOverridden = isOverridden || triggerMapItemKey(item) == triggerMapItemKey(overlayItem)
+		}
+		if isOverridden {
+			override(fmt.Sprintf("trigger_map[%d]", idx), true)
+			continue
+		}
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+func mergeWorkflows(base, overlay WorkflowModel, path string, override func(string, bool)) (WorkflowModel, error) {
+	if base.IsAlias() || overlay.IsAlias() {
+		override(path, true)
+		return overlay, nil
+	}
+
+	merged := base
+	merged.Title = mergeString(base.Title, overlay.Title, path+".title", override)
+	merged.Summary = mergeString(base.Summary, overlay.Summary, path+".summary", override)
+	merged.Description = mergeString(base.Description, overlay.Description, path+".description", override)
+	if overlay.PreventSleep != nil {
+		override(path+".prevent_sleep", base.PreventSleep != nil)
+		merged.PreventSleep = overlay.PreventSleep
+	}
+
+	if len(overlay.BeforeRun) > 0 {
+		override(path+".before_run", len(base.BeforeRun) > 0)
+		merged.BeforeRun = overlay.BeforeRun
+	}
+	if len(overlay.AfterRun) > 0 {
+		override(path+".after_run", len(base.AfterRun) > 0)
+		merged.AfterRun = overlay.AfterRun
+	}
+	if len(overlay.Steps) > 0 {
+		override(path+".steps", len(base.Steps) > 0)
+		merged.Steps = overlay.Steps
+	}
+
+	environments, err := mergeEnvironments(base.Environments, overlay.Environments, path+".envs", override)
+	if err != nil {
+		return WorkflowModel{}, err
+	}
+	merged.Environments = environments
+	merged.EnvFiles = mergeEnvFiles(base.EnvFiles, overlay.EnvFiles, path+".env_files", override)
+	merged.RequiredSecrets = mergeStrings(base.RequiredSecrets, overlay.RequiredSecrets)
+	merged.Owner = mergeString(base.Owner, overlay.Owner, path+".owner", override)
+	merged.WorkingDir = mergeString(base.WorkingDir, overlay.WorkingDir, path+".working_dir", override)
+	if overlay.TimeoutSecs != 0 {
+		override(path+".timeout_secs", base.TimeoutSecs != 0 && base.TimeoutSecs != overlay.TimeoutSecs)
+		merged.TimeoutSecs = overlay.TimeoutSecs
+	}
+	merged.Tags = mergeStrings(base.Tags, overlay.Tags)
+
+	if len(overlay.Inputs) > 0 {
+		merged.Inputs = map[string]WorkflowInputModel{}
+		for name, input := range base.Inputs {
+			merged.Inputs[name] = input
+		}
+		for name, input := range overlay.Inputs {
+			_, found := base.Inputs[name]
+			override(path+".inputs."+name, found)
+			merged.Inputs[name] = input
+		}
+	}
+
+	return merged, nil
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeConfigs(t *testing.T) {
+	base := BitriseDataModel{
+		FormatVersion:        "1.3.0",
+		DefaultStepLibSource: "https://github.com/bitrise-io/bitrise-steplib.git",
+		App: AppModel{
+			Environments: []envmanModels.EnvironmentItemModel{
+				envmanModels.EnvironmentItemModel{"PROJECT": "base"},
+				envmanModels.EnvironmentItemModel{"SCHEME": "base"},
+			},
+			EnvFiles: []EnvFileModel{EnvFileModel{Path: ".env"}},
+		},
+		TriggerMap: TriggerMapModel{
+			TriggerMapItemModel{PushBranch: "master", WorkflowID: "deploy"},
+			TriggerMapItemModel{PushBranch: "*", WorkflowID: "primary"},
+		},
+		Workflows: map[string]WorkflowModel{
+			"primary": WorkflowModel{
+				Title:           "Primary",
+				Steps:           []StepListItemModel{StepListItemModel{"script": {}}},
+				Environments:    []envmanModels.EnvironmentItemModel{envmanModels.EnvironmentItemModel{"STAGE": "test"}},
+				RequiredSecrets: []string{"API_TOKEN"},
+			},
+			"deploy": WorkflowModel{
+				Steps: []StepListItemModel{StepListItemModel{"script": {}}},
+			},
+			"ship": WorkflowModel{AliasOf: "deploy"},
+		},
+		StepDefaults: map[string]StepDefaultsModel{
+			"script": StepDefaultsModel{Inputs: []envmanModels.EnvironmentItemModel{envmanModels.EnvironmentItemModel{"runner_bin": "/bin/bash"}}},
+		},
+	}
+
+	overlay := BitriseDataModel{
+		FormatVersion: "1.3.1",
+		App: AppModel{
+			Environments: []envmanModels.EnvironmentItemModel{
+				envmanModels.EnvironmentItemModel{"PROJECT": "overlay"},
+				envmanModels.EnvironmentItemModel{"TEAM": "mobile"},
+			},
+			EnvFiles: []EnvFileModel{EnvFileModel{Path: ".env", IsOptional: true}},
+		},
+		TriggerMap: TriggerMapModel{
+			TriggerMapItemModel{PushBranch: "master", WorkflowID: "ship"},
+		},
+		Workflows: map[string]WorkflowModel{
+			"primary": WorkflowModel{
+				Summary:         "Runs the tests",
+				Environments:    []envmanModels.EnvironmentItemModel{envmanModels.EnvironmentItemModel{"LINT": "true"}},
+				RequiredSecrets: []string{"API_TOKEN", "SLACK_WEBHOOK"},
+			},
+			"ship": WorkflowModel{
+				Steps: []StepListItemModel{StepListItemModel{"deploy-to-bitrise-io": {}}},
+			},
+			"lint": WorkflowModel{},
+		},
+		StepDefaults: map[string]StepDefaultsModel{
+			"script":    StepDefaultsModel{Inputs: []envmanModels.EnvironmentItemModel{envmanModels.EnvironmentItemModel{"is_debug": "yes"}}},
+			"git-clone": StepDefaultsModel{RequiredSecrets: []string{"SSH_KEY"}},
+		},
+	}
+
+	merged, overrides, err := MergeConfigs(base, overlay)
+	require.NoError(t, err)
+
+	require.Equal(t, "1.3.1", merged.FormatVersion)
+	require.Equal(t, "https://github.com/bitrise-io/bitrise-steplib.git", merged.DefaultStepLibSource)
+
+	t.Log("envs are merged by key")
+	require.Equal(t, []envmanModels.EnvironmentItemModel{
+		envmanModels.EnvironmentItemModel{"PROJECT": "overlay"},
+		envmanModels.EnvironmentItemModel{"SCHEME": "base"},
+		envmanModels.EnvironmentItemModel{"TEAM": "mobile"},
+	}, merged.App.Environments)
+	require.Equal(t, []EnvFileModel{EnvFileModel{Path: ".env", IsOptional: true}}, merged.App.EnvFiles)
+
+	t.Log("overlay trigger map items come first")
+	require.Equal(t, TriggerMapModel{
+		TriggerMapItemModel{PushBranch: "master", WorkflowID: "ship"},
+		TriggerMapItemModel{PushBranch: "*", WorkflowID: "primary"},
+	}, merged.TriggerMap)
+
+	t.Log("workflows are merged")
+	primary := merged.Workflows["primary"]
+	require.Equal(t, "Primary", primary.Title)
+	require.Equal(t, "Runs the tests", primary.Summary)
+	require.Equal(t, 1, len(primary.Steps))
+	require.Equal(t, 2, len(primary.Environments))
+	require.Equal(t, []string{"API_TOKEN", "SLACK_WEBHOOK"}, primary.RequiredSecrets)
+
+	require.Equal(t, 1, len(merged.Workflows["deploy"].Steps))
+	require.Equal(t, WorkflowModel{}, merged.Workflows["lint"])
+
+	t.Log("an alias is replaced")
+	require.Equal(t, overlay.Workflows["ship"], merged.Workflows["ship"])
+
+	t.Log("step_defaults are merged by step ID")
+	require.Equal(t, 2, len(merged.StepDefaults["script"].Inputs))
+	require.Equal(t, []string{"SSH_KEY"}, merged.StepDefaults["git-clone"].RequiredSecrets)
+
+	require.Equal(t, []string{
+		"app.envs.PROJECT",
+		"app.env_files..env",
+		"trigger_map[0]",
+		"workflows.ship",
+	}, filterOverrides(overrides, "app.", "trigger_map", "workflows.ship"))
+
+	t.Log("the base is not modified")
+	require.Equal(t, "base", base.App.Environments[0]["PROJECT"])
+	require.Equal(t, "", base.Workflows["primary"].Summary)
+}
+
+func filterOverrides(overrides []string, prefixes ...string) []string {
+	filtered := []string{}
+	for _, override := range overrides {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(override, prefix) {
+				filtered = append(filtered, override)
+				break
+			}
+		}
+	}
+	return filtered
+}
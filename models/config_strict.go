@@ -0,0 +1,133 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"gopkg.in/yaml.v2"
+)
+
+// The known keys of the config are the yaml tags of the config model,
+// used by the strict config mode to find the unknown keys (typos like before_rub, run_iff).
+
+var (
+	environmentItemType    = reflect.TypeOf(envmanModels.EnvironmentItemModel{})
+	environmentOptionsType = reflect.TypeOf(envmanModels.EnvironmentItemOptionsModel{})
+	yamlUnmarshalerType    = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+)
+
+// optionsItemTypes are the list item types, which are either a scalar (- .env),
+// or a single key map with the options of the item (- .env.secrets: {is_secret: true}).
+var optionsItemTypes = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(WorkflowRunItemModel{}): reflect.TypeOf(workflowRunItemOptionsModel{}),
+	reflect.TypeOf(EnvFileModel{}):         reflect.TypeOf(envFileOptionsModel{}),
+}
+
+// UnknownConfigKeys returns the config paths (e.g. workflows.primary.before_rub) of the keys of the decoded
+// (generic, map[interface{}]interface{} based) config, which are not known by the config model, in the order of their path.
+func UnknownConfigKeys(config interface{}) []string {
+	unknownKeys := []string{}
+	collectUnknownConfigKeys(config, reflect.TypeOf(BitriseDataModel{}), "", &unknownKeys)
+	sort.Strings(unknownKeys)
+	return unknownKeys
+}
+
+func joinConfigPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// structFieldTypes returns the field types of the struct by their yaml key
+func structFieldTypes(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+		tag := field.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		if strings.Contains(tag, ",inline") {
+			for key, fieldType := range structFieldTypes(field.Type) {
+				fields[key] = fieldType
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+func collectUnknownConfigKeys(value interface{}, t reflect.Type, pth string, unknownKeys *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if optionsType, found := optionsItemTypes[t]; found {
+		if items, ok := value.(map[interface{}]interface{}); ok {
+			for key, options := range items {
+				collectUnknownConfigKeys(options, optionsType, joinConfigPath(pth, fmt.Sprintf("%v", key)), unknownKeys)
+			}
+		}
+		return
+	}
+
+	if t == environmentItemType {
+		if items, ok := value.(map[interface{}]interface{}); ok {
+			if options, found := items[envmanModels.OptionsKey]; found {
+				collectUnknownConfigKeys(options, environmentOptionsType, joinConfigPath(pth, envmanModels.OptionsKey), unknownKeys)
+			}
+		}
+		return
+	}
+
+	if reflect.PtrTo(t).Implements(yamlUnmarshalerType) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		items, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return
+		}
+
+		fields := structFieldTypes(t)
+		for key, item := range items {
+			keyStr := fmt.Sprintf("%v", key)
+			fieldType, found := fields[keyStr]
+			if !found {
+				*unknownKeys = append(*unknownKeys, joinConfigPath(pth, keyStr))
+				continue
+			}
+			collectUnknownConfigKeys(item, fieldType, joinConfigPath(pth, keyStr), unknownKeys)
+		}
+	case reflect.Map:
+		items, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return
+		}
+
+		for key, item := range items {
+			collectUnknownConfigKeys(item, t.Elem(), joinConfigPath(pth, fmt.Sprintf("%v", key)), unknownKeys)
+		}
+	case reflect.Slice:
+		items, ok := value.([]interface{})
+		if !ok {
+			return
+		}
+
+		for idx, item := range items {
+			collectUnknownConfigKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", pth, idx), unknownKeys)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestUnknownConfigKeys(t *testing.T) {
+	t.Log("known keys")
+	{
+		configStr := `
+format_version: 1.3.0
+app:
+  envs:
+  - PROJECT: demo
+    opts:
+      is_expand: false
+  env_files:
+  - .env
+  - .env.secrets:
+      is_secret: true
+trigger_map:
+- push_branch: master
+  workflow: primary
+workflows:
+  primary:
+    before_run:
+    - setup
+    - notify:
+        run_if: .IsCI
+    steps:
+    - script@1.1.0:
+        title: Script
+        inputs:
+        - content: echo hello
+          opts:
+            is_required: true
+`
+		var config interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(configStr), &config))
+		require.Equal(t, []string{}, UnknownConfigKeys(config))
+	}
+
+	t.Log("unknown keys")
+	{
+		configStr := `
+format_version: 1.3.0
+wokflows: {}
+app:
+  envs:
+  - PROJECT: demo
+    opts:
+      is_expnd: false
+  env_files:
+  - .env.secrets:
+      is_secrt: true
+workflows:
+  primary:
+    before_rub:
+    - setup
+    after_run:
+    - notify:
+        runif: .IsCI
+    steps:
+    - script@1.1.0:
+        run_iff: .IsCI
+        inputs:
+        - content: echo hello
+          opts:
+            is_requird: true
+`
+		var config interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(configStr), &config))
+		require.Equal(t, []string{
+			"app.env_files[0]..env.secrets.is_secrt",
+			"app.envs[0].opts.is_expnd",
+			"wokflows",
+			"workflows.primary.after_run[0].notify.runif",
+			"workflows.primary.before_rub",
+			"workflows.primary.steps[0].script@1.1.0.inputs[0].opts.is_requird",
+			"workflows.primary.steps[0].script@1.1.0.run_iff",
+		}, UnknownConfigKeys(config))
+	}
+}
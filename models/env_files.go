@@ -0,0 +1,109 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// EnvFileModel is an item of the env_files lists:
+// either a .env file path (- .env), or a path with options
+// (- .env.secrets: {is_secret: true, is_optional: true}).
+// The envs of a secret env file are handled like the secrets of the inventory (their values are redacted),
+// a missing optional env file is skipped.
+type EnvFileModel struct {
+	Path       string
+	IsSecret   bool
+	IsOptional bool
+}
+
+type envFileOptionsModel struct {
+	IsSecret   bool `json:"is_secret,omitempty" yaml:"is_secret,omitempty"`
+	IsOptional bool `json:"is_optional,omitempty" yaml:"is_optional,omitempty"`
+}
+
+func (envFile *EnvFileModel) fromOptionsMap(optionsMap map[string]envFileOptionsModel) error {
+	if len(optionsMap) != 1 {
+		return fmt.Errorf("an env file item should contain exactly one path, but it contains: %d", len(optionsMap))
+	}
+	for pth, options := range optionsMap {
+		envFile.Path = pth
+		envFile.IsSecret = options.IsSecret
+		envFile.IsOptional = options.IsOptional
+	}
+	if envFile.Path == "" {
+		return errors.New("empty env file path")
+	}
+	return nil
+}
+
+func (envFile EnvFileModel) isPathOnly() bool {
+	return !envFile.IsSecret && !envFile.IsOptional
+}
+
+func (envFile EnvFileModel) toOptionsMap() map[string]envFileOptionsModel {
+	return map[string]envFileOptionsModel{
+		envFile.Path: envFileOptionsModel{IsSecret: envFile.IsSecret, IsOptional: envFile.IsOptional},
+	}
+}
+
+// UnmarshalYAML ...
+func (envFile *EnvFileModel) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var pth string
+	if err := unmarshal(&pth); err == nil {
+		*envFile = EnvFileModel{Path: pth}
+		return nil
+	}
+
+	optionsMap := map[string]envFileOptionsModel{}
+	if err := unmarshal(&optionsMap); err != nil {
+		return err
+	}
+	return envFile.fromOptionsMap(optionsMap)
+}
+
+// MarshalYAML ...
+func (envFile EnvFileModel) MarshalYAML() (interface{}, error) {
+	if envFile.isPathOnly() {
+		return envFile.Path, nil
+	}
+	return envFile.toOptionsMap(), nil
+}
+
+// UnmarshalJSON ...
+func (envFile *EnvFileModel) UnmarshalJSON(data []byte) error {
+	var pth string
+	if err := json.Unmarshal(data, &pth); err == nil {
+		*envFile = EnvFileModel{Path: pth}
+		return nil
+	}
+
+	optionsMap := map[string]envFileOptionsModel{}
+	if err := json.Unmarshal(data, &optionsMap); err != nil {
+		return err
+	}
+	return envFile.fromOptionsMap(optionsMap)
+}
+
+// MarshalJSON ...
+func (envFile EnvFileModel) MarshalJSON() ([]byte, error) {
+	if envFile.isPathOnly() {
+		return json.Marshal(envFile.Path)
+	}
+	return json.Marshal(envFile.toOptionsMap())
+}
+
+// validateEnvFiles ...
+func validateEnvFiles(envFiles []EnvFileModel) error {
+	paths := map[string]bool{}
+	for idx, envFile := range envFiles {
+		if envFile.Path == "" {
+			return NewConfigPathError(fmt.Sprintf("[%d]", idx), errors.New("empty env file path"))
+		}
+		if paths[envFile.Path] {
+			return NewConfigPathError(fmt.Sprintf("[%d]", idx), fmt.Errorf("duplicated env file (%s)", envFile.Path))
+		}
+		paths[envFile.Path] = true
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestEnvFilesYAML(t *testing.T) {
+	t.Log("paths and paths with options")
+	{
+		configStr := `
+env_files:
+- .env
+- .env.secrets:
+    is_secret: true
+    is_optional: true
+`
+
+		workflow := WorkflowModel{}
+		require.NoError(t, yaml.Unmarshal([]byte(configStr), &workflow))
+		require.Equal(t, []EnvFileModel{
+			EnvFileModel{Path: ".env"},
+			EnvFileModel{Path: ".env.secrets", IsSecret: true, IsOptional: true},
+		}, workflow.EnvFiles)
+
+		bytes, err := yaml.Marshal(workflow)
+		require.NoError(t, err)
+
+		parsed := WorkflowModel{}
+		require.NoError(t, yaml.Unmarshal(bytes, &parsed))
+		require.Equal(t, workflow.EnvFiles, parsed.EnvFiles)
+
+		bytes, err = json.Marshal(workflow)
+		require.NoError(t, err)
+		require.Contains(t, string(bytes), `"env_files":[".env",{".env.secrets":{"is_secret":true,"is_optional":true}}]`)
+
+		parsed = WorkflowModel{}
+		require.NoError(t, json.Unmarshal(bytes, &parsed))
+		require.Equal(t, workflow.EnvFiles, parsed.EnvFiles)
+	}
+
+	t.Log("invalid - duplicated env file")
+	{
+		workflow := WorkflowModel{EnvFiles: []EnvFileModel{EnvFileModel{Path: ".env"}, EnvFileModel{Path: ".env", IsSecret: true}}}
+		_, err := workflow.Validate()
+		require.Error(t, err)
+		configPathErr, ok := err.(ConfigPathError)
+		require.True(t, ok)
+		require.Equal(t, "env_files[1]", configPathErr.Path)
+	}
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/bitrise-io/go-utils/versions"
+)
+
+// Compatibility layer for the configs of legacy format versions.
+// A config with an older format version is converted to the current format, before it's validated,
+// and every semantic difference applied by the conversion is reported.
+
+// ConfigMigrationModel is a semantic difference, applied by converting a legacy config.
+type ConfigMigrationModel struct {
+	// FormatVersion is the format version, which introduced the change
+	FormatVersion string `json:"format_version" yaml:"format_version"`
+	// Path is the path of the converted config element (for example: trigger_map[0])
+	Path        string `json:"path" yaml:"path"`
+	Description string `json:"description" yaml:"description"`
+}
+
+func (migration ConfigMigrationModel) String() string {
+	return fmt.Sprintf("%s: %s (format version %s)", migration.Path, migration.Description, migration.FormatVersion)
+}
+
+type configMigration struct {
+	formatVersion string
+	migrate       func(config *BitriseDataModel) []ConfigMigrationModel
+}
+
+// configMigrations are ordered by format version
+var configMigrations = []configMigration{
+	configMigration{formatVersion: "1.3.0", migrate: migrateDeprecatedTriggerMap},
+}
+
+// MigrateLegacyFormat converts the config to the current format version,
+// if it was created with an older format version, and returns the applied changes.
+// A config without format version is not converted.
+func (config *BitriseDataModel) MigrateLegacyFormat() ([]ConfigMigrationModel, error) {
+	applied := []ConfigMigrationModel{}
+	if config.FormatVersion == "" {
+		return applied, nil
+	}
+
+	for _, migration := range configMigrations {
+		isMigrated, err := versions.IsVersionGreaterOrEqual(config.FormatVersion, migration.formatVersion)
+		if err != nil {
+			return []ConfigMigrationModel{}, NewConfigPathError("format_version", fmt.Errorf("invalid format version (%s), error: %s", config.FormatVersion, err))
+		}
+		if isMigrated {
+			continue
+		}
+
+		for _, change := range migration.migrate(config) {
+			change.FormatVersion = migration.formatVersion
+			applied = append(applied, change)
+		}
+	}
+
+	return applied, nil
+}
+
+// migrateDeprecatedTriggerMap converts the pattern based trigger items
+// to push_branch and pull_request_source_branch items.
+func migrateDeprecatedTriggerMap(config *BitriseDataModel) []ConfigMigrationModel {
+	applied := []ConfigMigrationModel{}
+	if len(config.TriggerMap) == 0 {
+		return applied
+	}
+
+	triggerMap := TriggerMapModel{}
+	for idx, triggerItem := range config.TriggerMap {
+		if triggerItem.Pattern == "" {
+			triggerMap = append(triggerMap, triggerItem)
+			continue
+		}
+
+		description := fmt.Sprintf("pattern (%s) converted to push_branch (%s)", triggerItem.Pattern, triggerItem.Pattern)
+		if triggerItem.IsPullRequestAllowed {
+			description += fmt.Sprintf(" and pull_request_source_branch (%s) items", triggerItem.Pattern)
+		} else {
+			description += " item, pull requests are not triggered (is_pull_request_allowed: false)"
+		}
+
+		triggerMap = append(triggerMap, migrateDeprecatedTriggerItem(triggerItem)...)
+		applied = append(applied, ConfigMigrationModel{
+			Path:        fmt.Sprintf("trigger_map[%d]", idx),
+			Description: description,
+		})
+	}
+
+	config.TriggerMap = triggerMap
+	return applied
+}
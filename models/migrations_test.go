@@ -0,0 +1,74 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateLegacyFormat(t *testing.T) {
+	legacyTriggerMap := func() TriggerMapModel {
+		return TriggerMapModel{
+			TriggerMapItemModel{Pattern: "master", WorkflowID: "master"},
+			TriggerMapItemModel{Pattern: "*", IsPullRequestAllowed: true, WorkflowID: "primary"},
+			TriggerMapItemModel{Tag: "*", WorkflowID: "deploy"},
+		}
+	}
+
+	t.Log("legacy format version")
+	{
+		config := BitriseDataModel{FormatVersion: "1.2.0", TriggerMap: legacyTriggerMap()}
+
+		migrations, err := config.MigrateLegacyFormat()
+		require.NoError(t, err)
+		require.Equal(t, []ConfigMigrationModel{
+			ConfigMigrationModel{
+				FormatVersion: "1.3.0",
+				Path:          "trigger_map[0]",
+				Description:   "pattern (master) converted to push_branch (master) item, pull requests are not triggered (is_pull_request_allowed: false)",
+			},
+			ConfigMigrationModel{
+				FormatVersion: "1.3.0",
+				Path:          "trigger_map[1]",
+				Description:   "pattern (*) converted to push_branch (*) and pull_request_source_branch (*) items",
+			},
+		}, migrations)
+
+		require.Equal(t, TriggerMapModel{
+			TriggerMapItemModel{PushBranch: "master", WorkflowID: "master"},
+			TriggerMapItemModel{PushBranch: "*", WorkflowID: "primary"},
+			TriggerMapItemModel{PullRequestSourceBranch: "*", WorkflowID: "primary"},
+			TriggerMapItemModel{Tag: "*", WorkflowID: "deploy"},
+		}, config.TriggerMap)
+
+		require.Equal(t, "trigger_map[1]: pattern (*) converted to push_branch (*) and pull_request_source_branch (*) items (format version 1.3.0)", migrations[1].String())
+	}
+
+	t.Log("current format version")
+	{
+		config := BitriseDataModel{FormatVersion: Version, TriggerMap: legacyTriggerMap()}
+
+		migrations, err := config.MigrateLegacyFormat()
+		require.NoError(t, err)
+		require.Equal(t, 0, len(migrations))
+		require.Equal(t, legacyTriggerMap(), config.TriggerMap)
+	}
+
+	t.Log("no format version")
+	{
+		config := BitriseDataModel{TriggerMap: legacyTriggerMap()}
+
+		migrations, err := config.MigrateLegacyFormat()
+		require.NoError(t, err)
+		require.Equal(t, 0, len(migrations))
+		require.Equal(t, legacyTriggerMap(), config.TriggerMap)
+	}
+
+	t.Log("invalid format version")
+	{
+		config := BitriseDataModel{FormatVersion: "invalid"}
+
+		_, err := config.MigrateLegacyFormat()
+		require.Error(t, err)
+	}
+}
@@ -21,20 +21,72 @@ const (
 
 	// Version ...
 	Version = "1.3.1"
+
+	// DefaultPreventSleep keeping the (macOS) host awake while the workflow runs is opt-in (see: WorkflowModel.PreventSleep)
+	DefaultPreventSleep = false
+	// DefaultIsolateEnvs ...
+	DefaultIsolateEnvs = false
+	// DefaultRunOnFailureOnly ...
+	DefaultRunOnFailureOnly = false
 )
 
+// ConfigPathError is an error of a config element, with the path of the element
+// (for example: workflows.primary.steps[1].script).
+// The error message is the message of the wrapped error,
+// the path is used for locating the element in the config file.
+type ConfigPathError struct {
+	Path string
+	Err  error
+}
+
+// WorkflowStepModel is a step of a workflow: the step model of stepman,
+// extended with the step options, which are only available in the bitrise config (not in the step.yml).
+type WorkflowStepModel struct {
+	stepmanModels.StepModel `yaml:",inline"`
+
+	// RunOnFailureOnly : if true then this step will only run,
+	//  if a previous step failed (the build is failing).
+	RunOnFailureOnly *bool `json:"run_on_failure_only,omitempty" yaml:"run_on_failure_only,omitempty"`
+	// IsolateEnvs : if true the outputs and env changes of this step
+	//  are not available for the subsequent steps, except the ones listed in Exports.
+	IsolateEnvs *bool `json:"isolate_envs,omitempty" yaml:"isolate_envs,omitempty"`
+	// Exports : env keys which are still propagated, if IsolateEnvs is true
+	Exports []string `json:"exports,omitempty" yaml:"exports,omitempty"`
+	// WorkingDir : the working dir of the step, overrides the workflow's working_dir
+	WorkingDir *string `json:"working_dir,omitempty" yaml:"working_dir,omitempty"`
+	// ResourceLimits : the CPU and memory limits of the step's processes
+	ResourceLimits *ResourceLimitsModel `json:"resource_limits,omitempty" yaml:"resource_limits,omitempty"`
+}
+
 // StepListItemModel ...
-type StepListItemModel map[string]stepmanModels.StepModel
+type StepListItemModel map[string]WorkflowStepModel
 
 // WorkflowModel ...
 type WorkflowModel struct {
 	Title        string                              `json:"title,omitempty" yaml:"title,omitempty"`
 	Summary      string                              `json:"summary,omitempty" yaml:"summary,omitempty"`
 	Description  string                              `json:"description,omitempty" yaml:"description,omitempty"`
-	BeforeRun    []string                            `json:"before_run,omitempty" yaml:"before_run,omitempty"`
-	AfterRun     []string                            `json:"after_run,omitempty" yaml:"after_run,omitempty"`
+	BeforeRun    []WorkflowRunItemModel              `json:"before_run,omitempty" yaml:"before_run,omitempty"`
+	AfterRun     []WorkflowRunItemModel              `json:"after_run,omitempty" yaml:"after_run,omitempty"`
 	Environments []envmanModels.EnvironmentItemModel `json:"envs,omitempty" yaml:"envs,omitempty"`
 	Steps        []StepListItemModel                 `json:"steps,omitempty" yaml:"steps,omitempty"`
+	PreventSleep *bool                               `json:"prevent_sleep,omitempty" yaml:"prevent_sleep,omitempty"`
+	// Inputs are the typed parameters of the workflow
+	Inputs map[string]WorkflowInputModel `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+	// AliasOf is the ID of the workflow, this workflow is an alias of
+	AliasOf string `json:"alias_of,omitempty" yaml:"alias_of,omitempty"`
+	// EnvFiles are loaded before the workflow's envs
+	EnvFiles []EnvFileModel `json:"env_files,omitempty" yaml:"env_files,omitempty"`
+	// RequiredSecrets are the secret envs, the workflow requires
+	RequiredSecrets []string `json:"required_secrets,omitempty" yaml:"required_secrets,omitempty"`
+	// Owner is the team or person, who maintains the workflow
+	Owner string `json:"owner,omitempty" yaml:"owner,omitempty"`
+	// Tags are free form labels of the workflow, the workflow listing can be filtered by them
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// WorkingDir is the default working dir of the workflow's steps (overrides the app's working_dir)
+	WorkingDir string `json:"working_dir,omitempty" yaml:"working_dir,omitempty"`
+	// TimeoutSecs is the time limit of running the workflow's steps, the running step is aborted once it's exceeded (0: no limit)
+	TimeoutSecs int `json:"timeout_secs,omitempty" yaml:"timeout_secs,omitempty"`
 }
 
 // AppModel ...
@@ -43,6 +95,10 @@ type AppModel struct {
 	Summary      string                              `json:"summary,omitempty" yaml:"summary,omitempty"`
 	Description  string                              `json:"description,omitempty" yaml:"description,omitempty"`
 	Environments []envmanModels.EnvironmentItemModel `json:"envs,omitempty" yaml:"envs,omitempty"`
+	// EnvFiles are loaded before the app envs
+	EnvFiles []EnvFileModel `json:"env_files,omitempty" yaml:"env_files,omitempty"`
+	// WorkingDir is the default working dir of the steps
+	WorkingDir string `json:"working_dir,omitempty" yaml:"working_dir,omitempty"`
 }
 
 // TriggerEventType ...
@@ -66,6 +122,10 @@ type TriggerMapItemModel struct {
 	PullRequestTargetBranch string `json:"pull_request_target_branch,omitempty" yaml:"pull_request_target_branch,omitempty"`
 	Tag                     string `json:"tag,omitempty" yaml:"tag,omitempty"`
 	WorkflowID              string `json:"workflow,omitempty" yaml:"workflow,omitempty"`
+	// DraftPullRequestEnabled controls whether draft pull requests trigger the workflow (default: true)
+	DraftPullRequestEnabled *bool `json:"draft_pull_request_enabled,omitempty" yaml:"draft_pull_request_enabled,omitempty"`
+	// Inputs are the input values of the triggered workflow
+	Inputs map[string]string `json:"inputs,omitempty" yaml:"inputs,omitempty"`
 
 	// deprecated
 	Pattern              string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
@@ -79,6 +139,8 @@ type TriggerMapModel []TriggerMapItemModel
 type BitriseDataModel struct {
 	FormatVersion        string `json:"format_version" yaml:"format_version"`
 	DefaultStepLibSource string `json:"default_step_lib_source,omitempty" yaml:"default_step_lib_source,omitempty"`
+	// StepLibSources are the StepLibs, searched in order for the steps without an explicit StepLib source (see: StepLibSearchList)
+	StepLibSources []string `json:"step_lib_sources,omitempty" yaml:"step_lib_sources,omitempty"`
 	//
 	Title       string `json:"title,omitempty" yaml:"title,omitempty"`
 	Summary     string `json:"summary,omitempty" yaml:"summary,omitempty"`
@@ -87,6 +149,20 @@ type BitriseDataModel struct {
 	App        AppModel                 `json:"app,omitempty" yaml:"app,omitempty"`
 	TriggerMap TriggerMapModel          `json:"trigger_map,omitempty" yaml:"trigger_map,omitempty"`
 	Workflows  map[string]WorkflowModel `json:"workflows,omitempty" yaml:"workflows,omitempty"`
+	// OnAbort is the ID of the workflow to run if the build is aborted (SIGINT/SIGTERM)
+	OnAbort string `json:"on_abort,omitempty" yaml:"on_abort,omitempty"`
+	// Tools pins the versions of the bitrise tools used for the project
+	Tools *ToolsModel `json:"tools,omitempty" yaml:"tools,omitempty"`
+	// StepDefaults maps step IDs to the default inputs of every use of the step
+	StepDefaults map[string]StepDefaultsModel `json:"step_defaults,omitempty" yaml:"step_defaults,omitempty"`
+	// StepBundles are the named step sequences, referenced by the workflows as: - bundle::BUNDLE_ID
+	StepBundles map[string]StepBundleModel `json:"step_bundles,omitempty" yaml:"step_bundles,omitempty"`
+}
+
+// ToolsModel ...
+type ToolsModel struct {
+	Stepman string `json:"stepman,omitempty" yaml:"stepman,omitempty"`
+	Envman  string `json:"envman,omitempty" yaml:"envman,omitempty"`
 }
 
 // StepIDData ...
@@ -109,6 +185,7 @@ type BuildRunResultsModel struct {
 	FailedSteps          []StepRunResultsModel
 	FailedSkippableSteps []StepRunResultsModel
 	SkippedSteps         []StepRunResultsModel
+	IsAborted            bool
 }
 
 // StepRunResultsModel ...
@@ -119,4 +196,6 @@ type StepRunResultsModel struct {
 	RunTime  time.Duration
 	Error    error
 	ExitCode int
+	// SkipReason is the reason of skipping the step (see: bitrise.StepSkipReason), empty if the step was not skipped
+	SkipReason string
 }
@@ -4,12 +4,15 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
 	envmanModels "github.com/bitrise-io/envman/models"
 	"github.com/bitrise-io/go-utils/pointers"
 	stepmanModels "github.com/bitrise-io/stepman/models"
-	"github.com/ryanuber/go-glob"
+	"github.com/hashicorp/go-version"
 )
 
 func triggerEventType(pushBranch, prSourceBranch, prTargetBranch, tag string) (TriggerEventType, error) {
@@ -47,12 +50,14 @@ func migrateDeprecatedTriggerItem(triggerItem TriggerMapItemModel) []TriggerMapI
 		TriggerMapItemModel{
 			PushBranch: triggerItem.Pattern,
 			WorkflowID: triggerItem.WorkflowID,
+			Inputs:     triggerItem.Inputs,
 		},
 	}
 	if triggerItem.IsPullRequestAllowed {
 		migratedItems = append(migratedItems, TriggerMapItemModel{
 			PullRequestSourceBranch: triggerItem.Pattern,
 			WorkflowID:              triggerItem.WorkflowID,
+			Inputs:                  triggerItem.Inputs,
 		})
 	}
 	return migratedItems
@@ -82,33 +87,57 @@ func (triggerItem TriggerMapItemModel) MatchWithParams(pushBranch, prSourceBranc
 
 		switch itemEventType {
 		case TriggerEventTypeCodePush:
-			match := glob.Glob(migratedTriggerItem.PushBranch, pushBranch)
-			return match, nil
+			return matchTriggerPattern(migratedTriggerItem.PushBranch, pushBranch)
 		case TriggerEventTypePullRequest:
 			sourceMatch := false
 			if migratedTriggerItem.PullRequestSourceBranch == "" {
 				sourceMatch = true
 			} else {
-				sourceMatch = glob.Glob(migratedTriggerItem.PullRequestSourceBranch, prSourceBranch)
+				sourceMatch, err = matchTriggerPattern(migratedTriggerItem.PullRequestSourceBranch, prSourceBranch)
+				if err != nil {
+					return false, err
+				}
 			}
 
 			targetMatch := false
 			if migratedTriggerItem.PullRequestTargetBranch == "" {
 				targetMatch = true
 			} else {
-				targetMatch = glob.Glob(migratedTriggerItem.PullRequestTargetBranch, prTargetBranch)
+				targetMatch, err = matchTriggerPattern(migratedTriggerItem.PullRequestTargetBranch, prTargetBranch)
+				if err != nil {
+					return false, err
+				}
 			}
 
 			return (sourceMatch && targetMatch), nil
 		case TriggerEventTypeTag:
-			match := glob.Glob(migratedTriggerItem.Tag, tag)
-			return match, nil
+			return matchTriggerPattern(migratedTriggerItem.Tag, tag)
 		}
 	}
 
 	return false, nil
 }
 
+// IsDraftPullRequestEnabled ...
+func (triggerItem TriggerMapItemModel) IsDraftPullRequestEnabled() bool {
+	return triggerItem.DraftPullRequestEnabled == nil || *triggerItem.DraftPullRequestEnabled
+}
+
+// MatchWithPullRequestDraftParams is MatchWithParams, extended with the draft state of the pull request:
+// draft pull requests match only the items, which enable them
+func (triggerItem TriggerMapItemModel) MatchWithPullRequestDraftParams(pushBranch, prSourceBranch, prTargetBranch, tag string, isDraftPullRequest bool) (bool, error) {
+	match, err := triggerItem.MatchWithParams(pushBranch, prSourceBranch, prTargetBranch, tag)
+	if err != nil || !match {
+		return match, err
+	}
+
+	isPullRequest := (prSourceBranch != "" || prTargetBranch != "")
+	if isPullRequest && isDraftPullRequest && !triggerItem.IsDraftPullRequestEnabled() {
+		return false, nil
+	}
+	return true, nil
+}
+
 func containsWorkflowName(title string, workflowStack []string) bool {
 	for _, t := range workflowStack {
 		if t == title {
@@ -128,7 +157,10 @@ func removeWorkflowName(title string, workflowStack []string) []string {
 	return newStack
 }
 
-func checkWorkflowReferenceCycle(workflowID string, workflow WorkflowModel, bitriseConfig BitriseDataModel, workflowStack []string) error {
+// checkWorkflowReferenceCycle walks the before_run and after_run references of the workflow.
+// The workflows with an already checked (cycle free) reference graph are collected in checkedWorkflows,
+// so that every workflow is resolved only once, even if it's referenced by many workflows.
+func checkWorkflowReferenceCycle(workflowID string, workflow WorkflowModel, bitriseConfig BitriseDataModel, workflowStack []string, checkedWorkflows map[string]bool) error {
 	if containsWorkflowName(workflowID, workflowStack) {
 		stackStr := ""
 		for _, aWorkflowID := range workflowStack {
@@ -137,55 +169,91 @@ func checkWorkflowReferenceCycle(workflowID string, workflow WorkflowModel, bitr
 		stackStr += workflowID
 		return fmt.Errorf("Workflow reference cycle found: %s", stackStr)
 	}
+	if checkedWorkflows[workflowID] {
+		return nil
+	}
 	workflowStack = append(workflowStack, workflowID)
 
-	for _, beforeWorkflowName := range workflow.BeforeRun {
+	for _, beforeWorkflowItem := range workflow.BeforeRun {
+		beforeWorkflowName := beforeWorkflowItem.WorkflowID
 		beforeWorkflow, exist := bitriseConfig.Workflows[beforeWorkflowName]
 		if !exist {
 			return errors.New("Workflow does not exist with name " + beforeWorkflowName)
 		}
 
-		err := checkWorkflowReferenceCycle(beforeWorkflowName, beforeWorkflow, bitriseConfig, workflowStack)
+		err := checkWorkflowReferenceCycle(beforeWorkflowName, beforeWorkflow, bitriseConfig, workflowStack, checkedWorkflows)
 		if err != nil {
 			return err
 		}
 	}
 
-	for _, afterWorkflowName := range workflow.AfterRun {
+	for _, afterWorkflowItem := range workflow.AfterRun {
+		afterWorkflowName := afterWorkflowItem.WorkflowID
 		afterWorkflow, exist := bitriseConfig.Workflows[afterWorkflowName]
 		if !exist {
 			return errors.New("Workflow does not exist with name " + afterWorkflowName)
 		}
 
-		err := checkWorkflowReferenceCycle(afterWorkflowName, afterWorkflow, bitriseConfig, workflowStack)
+		err := checkWorkflowReferenceCycle(afterWorkflowName, afterWorkflow, bitriseConfig, workflowStack, checkedWorkflows)
 		if err != nil {
 			return err
 		}
 	}
 
-	workflowStack = removeWorkflowName(workflowID, workflowStack)
+	checkedWorkflows[workflowID] = true
 
 	return nil
 }
 
+// ----------------------------
+// --- ConfigPathError
+
+// Error ...
+func (err ConfigPathError) Error() string {
+	return err.Err.Error()
+}
+
+// NewConfigPathError wraps the error with the path of the config element.
+// If the error is already a ConfigPathError the paths are joined (path is the parent path).
+func NewConfigPathError(path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if pathErr, ok := err.(ConfigPathError); ok {
+		if strings.HasPrefix(pathErr.Path, "[") {
+			return ConfigPathError{Path: path + pathErr.Path, Err: pathErr.Err}
+		}
+		return ConfigPathError{Path: path + "." + pathErr.Path, Err: pathErr.Err}
+	}
+	return ConfigPathError{Path: path, Err: err}
+}
+
+// IsPreventSleep returns true if the host has to be kept awake while the workflow runs
+func (workflow WorkflowModel) IsPreventSleep() bool {
+	if workflow.PreventSleep == nil {
+		return DefaultPreventSleep
+	}
+	return *workflow.PreventSleep
+}
+
 // ----------------------------
 // --- Normalize
 
 // Normalize ...
 func (workflow *WorkflowModel) Normalize() error {
-	for _, env := range workflow.Environments {
+	for idx, env := range workflow.Environments {
 		if err := env.Normalize(); err != nil {
-			return err
+			return NewConfigPathError(fmt.Sprintf("envs[%d]", idx), err)
 		}
 	}
 
-	for _, stepListItem := range workflow.Steps {
+	for idx, stepListItem := range workflow.Steps {
 		stepID, step, err := GetStepIDStepDataPair(stepListItem)
 		if err != nil {
-			return err
+			return NewConfigPathError(fmt.Sprintf("steps[%d]", idx), err)
 		}
 		if err := step.Normalize(); err != nil {
-			return err
+			return NewConfigPathError(fmt.Sprintf("steps[%d].%s", idx, stepID), err)
 		}
 		stepListItem[stepID] = step
 	}
@@ -195,9 +263,9 @@ func (workflow *WorkflowModel) Normalize() error {
 
 // Normalize ...
 func (app *AppModel) Normalize() error {
-	for _, env := range app.Environments {
+	for idx, env := range app.Environments {
 		if err := env.Normalize(); err != nil {
-			return err
+			return NewConfigPathError(fmt.Sprintf("envs[%d]", idx), err)
 		}
 	}
 	return nil
@@ -206,13 +274,27 @@ func (app *AppModel) Normalize() error {
 // Normalize ...
 func (config *BitriseDataModel) Normalize() error {
 	if err := config.App.Normalize(); err != nil {
-		return err
+		return NewConfigPathError("app", err)
 	}
 
-	for _, workflow := range config.Workflows {
+	for ID, workflow := range config.Workflows {
 		if err := workflow.Normalize(); err != nil {
-			return err
+			return NewConfigPathError("workflows."+ID, err)
+		}
+	}
+
+	for ID, defaults := range config.StepDefaults {
+		if err := defaults.Normalize(); err != nil {
+			return NewConfigPathError("step_defaults."+ID, err)
 		}
+		config.StepDefaults[ID] = defaults
+	}
+
+	for ID, bundle := range config.StepBundles {
+		if err := bundle.Normalize(); err != nil {
+			return NewConfigPathError("step_bundles."+ID, err)
+		}
+		config.StepBundles[ID] = bundle
 	}
 
 	return nil
@@ -223,21 +305,49 @@ func (config *BitriseDataModel) Normalize() error {
 
 // Validate ...
 func (workflow *WorkflowModel) Validate() ([]string, error) {
-	for _, env := range workflow.Environments {
+	for idx, env := range workflow.Environments {
 		if err := env.Validate(); err != nil {
-			return []string{}, err
+			return []string{}, NewConfigPathError(fmt.Sprintf("envs[%d]", idx), err)
 		}
 	}
 
+	if err := validateWorkflowInputs(workflow.Inputs); err != nil {
+		return []string{}, NewConfigPathError("inputs", err)
+	}
+
+	if err := validateEnvFiles(workflow.EnvFiles); err != nil {
+		return []string{}, NewConfigPathError("env_files", err)
+	}
+
+	if err := validateRequiredSecrets(workflow.RequiredSecrets); err != nil {
+		return []string{}, NewConfigPathError("required_secrets", err)
+	}
+
+	if err := validateWorkflowOwner(workflow.Owner); err != nil {
+		return []string{}, NewConfigPathError("owner", err)
+	}
+
+	if err := validateWorkflowTags(workflow.Tags); err != nil {
+		return []string{}, NewConfigPathError("tags", err)
+	}
+
+	if workflow.TimeoutSecs < 0 {
+		return []string{}, NewConfigPathError("timeout_secs", fmt.Errorf("invalid timeout (%d), it can't be negative", workflow.TimeoutSecs))
+	}
+
 	warnings := []string{}
-	for _, stepListItem := range workflow.Steps {
+	for idx, stepListItem := range workflow.Steps {
 		stepID, step, err := GetStepIDStepDataPair(stepListItem)
 		if err != nil {
-			return warnings, err
+			return warnings, NewConfigPathError(fmt.Sprintf("steps[%d]", idx), err)
 		}
 
 		if err := step.ValidateInputAndOutputEnvs(false); err != nil {
-			return warnings, err
+			return warnings, NewConfigPathError(fmt.Sprintf("steps[%d].%s", idx, stepID), err)
+		}
+
+		if err := validateStepResourceLimits(step.ResourceLimits); err != nil {
+			return warnings, NewConfigPathError(fmt.Sprintf("steps[%d].%s.resource_limits", idx, stepID), err)
 		}
 
 		stepInputMap := map[string]bool{}
@@ -262,11 +372,14 @@ func (workflow *WorkflowModel) Validate() ([]string, error) {
 
 // Validate ...
 func (app *AppModel) Validate() error {
-	for _, env := range app.Environments {
+	for idx, env := range app.Environments {
 		if err := env.Validate(); err != nil {
-			return err
+			return NewConfigPathError(fmt.Sprintf("envs[%d]", idx), err)
 		}
 	}
+	if err := validateEnvFiles(app.EnvFiles); err != nil {
+		return NewConfigPathError("env_files", err)
+	}
 	return nil
 }
 
@@ -286,50 +399,177 @@ func (triggerItem TriggerMapItemModel) Validate() error {
 		return fmt.Errorf("deprecated trigger item (pattern defined), mixed with trigger params (push_branch: %s, pull_request_source_branch: %s, pull_request_target_branch: %s, tag: %s)", triggerItem.PushBranch, triggerItem.PullRequestSourceBranch, triggerItem.PullRequestTargetBranch, triggerItem.Tag)
 	}
 
+	if triggerItem.DraftPullRequestEnabled != nil {
+		isPullRequestItem := triggerItem.PullRequestSourceBranch != "" || triggerItem.PullRequestTargetBranch != "" ||
+			(triggerItem.Pattern != "" && triggerItem.IsPullRequestAllowed)
+		if !isPullRequestItem {
+			return fmt.Errorf("trigger map item (%v) validate failed, error: draft_pull_request_enabled is only allowed for pull request items", triggerItem)
+		}
+	}
+
+	for _, pattern := range []string{triggerItem.Pattern, triggerItem.PushBranch, triggerItem.PullRequestSourceBranch, triggerItem.PullRequestTargetBranch, triggerItem.Tag} {
+		if err := validateTriggerPattern(pattern); err != nil {
+			return fmt.Errorf("trigger map item (%v) validate failed, error: %s", triggerItem, err)
+		}
+	}
+
 	return nil
 }
 
 // Validate ...
 func (triggerMap TriggerMapModel) Validate() error {
-	for _, item := range triggerMap {
+	for idx, item := range triggerMap {
 		if err := item.Validate(); err != nil {
-			return err
+			return NewConfigPathError(fmt.Sprintf("[%d]", idx), err)
 		}
 	}
 
 	return nil
 }
 
+var workflowIDRegexp = regexp.MustCompile(`[A-Za-z0-9-_.]+`)
+
+type workflowValidationResult struct {
+	warnings []string
+	err      error
+}
+
+// validateWorkflows validates the given workflows in parallel,
+// the results are in the order of the given workflow IDs.
+func validateWorkflows(workflows map[string]WorkflowModel, workflowIDs []string) []workflowValidationResult {
+	results := make([]workflowValidationResult, len(workflowIDs))
+
+	workerCount := runtime.NumCPU()
+	if workerCount > len(workflowIDs) {
+		workerCount = len(workflowIDs)
+	}
+
+	idxChan := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workerCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range idxChan {
+				workflow := workflows[workflowIDs[idx]]
+				warnings, err := workflow.Validate()
+				results[idx] = workflowValidationResult{warnings: warnings, err: err}
+			}
+		}()
+	}
+
+	for idx := range workflowIDs {
+		idxChan <- idx
+	}
+	close(idxChan)
+	wg.Wait()
+
+	return results
+}
+
+// Validate ...
+func (tools ToolsModel) Validate() error {
+	pins := map[string]string{
+		"stepman": tools.Stepman,
+		"envman":  tools.Envman,
+	}
+	for _, toolname := range []string{"stepman", "envman"} {
+		toolVersion := pins[toolname]
+		// latest: the tool's latest release (see: tools.LatestToolVersion)
+		if toolVersion == "" || toolVersion == "latest" {
+			continue
+		}
+		if _, err := version.NewVersion(toolVersion); err != nil {
+			return NewConfigPathError(toolname, fmt.Errorf("invalid %s version (%s): %s", toolname, toolVersion, err))
+		}
+	}
+	return nil
+}
+
 // Validate ...
 func (config *BitriseDataModel) Validate() ([]string, error) {
 	warnings := []string{}
 
-	if err := config.TriggerMap.Validate(); err != nil {
+	if config.Tools != nil {
+		if err := config.Tools.Validate(); err != nil {
+			return warnings, NewConfigPathError("tools", err)
+		}
+	}
+
+	if err := config.validateStepLibSources(); err != nil {
 		return warnings, err
 	}
 
+	if err := config.TriggerMap.Validate(); err != nil {
+		return warnings, NewConfigPathError("trigger_map", err)
+	}
+
 	if err := config.App.Validate(); err != nil {
+		return warnings, NewConfigPathError("app", err)
+	}
+
+	for ID, defaults := range config.StepDefaults {
+		if ID == "" {
+			return warnings, NewConfigPathError("step_defaults", errors.New("empty step ID"))
+		}
+		if err := defaults.Validate(); err != nil {
+			return warnings, NewConfigPathError("step_defaults."+ID, err)
+		}
+	}
+
+	for ID, bundle := range config.StepBundles {
+		if ID == "" {
+			return warnings, NewConfigPathError("step_bundles", errors.New("empty step bundle ID"))
+		}
+		if err := bundle.Validate(); err != nil {
+			return warnings, NewConfigPathError("step_bundles."+ID, err)
+		}
+	}
+
+	if err := config.validateStepBundleReferences(); err != nil {
 		return warnings, err
 	}
 
-	for ID, workflow := range config.Workflows {
+	workflowIDs := []string{}
+	for ID := range config.Workflows {
+		workflowIDs = append(workflowIDs, ID)
+	}
+	sort.Strings(workflowIDs)
+
+	validationResults := validateWorkflows(config.Workflows, workflowIDs)
+
+	checkedWorkflows := map[string]bool{}
+	for idx, ID := range workflowIDs {
+		workflow := config.Workflows[ID]
+
 		if ID == "" {
 			warnings = append(warnings, fmt.Sprintf("invalid workflow ID (%s): empty", ID))
 		}
 
-		r := regexp.MustCompile(`[A-Za-z0-9-_.]+`)
-		if find := r.FindString(ID); find != ID {
+		if find := workflowIDRegexp.FindString(ID); find != ID {
 			warnings = append(warnings, fmt.Sprintf("invalid workflow ID (%s): doesn't conform to: [A-Za-z0-9-_.]", ID))
 		}
 
-		warns, err := workflow.Validate()
-		warnings = append(warnings, warns...)
-		if err != nil {
-			return warnings, err
+		result := validationResults[idx]
+		warnings = append(warnings, result.warnings...)
+		if result.err != nil {
+			return warnings, NewConfigPathError("workflows."+ID, result.err)
+		}
+
+		if err := checkWorkflowReferenceCycle(ID, workflow, *config, []string{}, checkedWorkflows); err != nil {
+			return warnings, NewConfigPathError("workflows."+ID, err)
 		}
+	}
 
-		if err := checkWorkflowReferenceCycle(ID, workflow, *config, []string{}); err != nil {
-			return warnings, err
+	aliasWarnings, err := config.validateWorkflowAliases()
+	warnings = append(warnings, aliasWarnings...)
+	if err != nil {
+		return warnings, err
+	}
+
+	if config.OnAbort != "" {
+		if _, found := config.Workflows[config.OnAbort]; !found {
+			return warnings, NewConfigPathError("on_abort", fmt.Errorf("on_abort workflow (%s) does not exist", config.OnAbort))
 		}
 	}
 
@@ -671,14 +911,14 @@ func MergeStepWith(step, otherStep stepmanModels.StepModel) (stepmanModels.StepM
 // --- StepIDData
 
 // GetStepIDStepDataPair ...
-func GetStepIDStepDataPair(stepListItem StepListItemModel) (string, stepmanModels.StepModel, error) {
+func GetStepIDStepDataPair(stepListItem StepListItemModel) (string, WorkflowStepModel, error) {
 	if len(stepListItem) > 1 {
-		return "", stepmanModels.StepModel{}, errors.New("StepListItem contains more than 1 key-value pair!")
+		return "", WorkflowStepModel{}, errors.New("StepListItem contains more than 1 key-value pair!")
 	}
 	for key, value := range stepListItem {
 		return key, value, nil
 	}
-	return "", stepmanModels.StepModel{}, errors.New("StepListItem does not contain a key-value pair!")
+	return "", WorkflowStepModel{}, errors.New("StepListItem does not contain a key-value pair!")
 }
 
 // CreateStepIDDataFromString ...
@@ -695,7 +935,16 @@ func GetStepIDStepDataPair(stepListItem StepListItemModel) (string, stepmanModel
 //    * script@2.0.0
 //  * only stepid, latest version will be used (requires a default steplib source to be provided):
 //    * script
+//  * step distributed as an OCI artifact, by tag or by digest:
+//    * oci://ghcr.io/my-org/my-step:1.0.0
+//    * oci://ghcr.io/my-org/my-step@sha256:...
+//  * step archive URL and its checksum:
+//    * archive::https://example.com/steps/my-step-1.2.3.tar.gz@sha256:...
 func CreateStepIDDataFromString(compositeVersionStr, defaultStepLibSource string) (StepIDData, error) {
+	if strings.HasPrefix(compositeVersionStr, OCIStepReferencePrefix) {
+		return createOCIStepIDData(compositeVersionStr)
+	}
+
 	// first, determine the steplib-source/type
 	stepSrc := ""
 	stepIDAndVersionOrURIStr := ""
@@ -770,6 +1019,13 @@ func (sIDData StepIDData) IsUniqueResourceID() bool {
 		return false
 	case "":
 		return false
+	case OCIStepSource:
+		// only a digest identifies the same artifact every time, a tag can be moved
+		return strings.HasPrefix(sIDData.Version, OCIDigestPrefix)
+	case ArchiveStepSource:
+		// the checksum guarantees the same archive every time
+		_, err := sIDData.ArchiveStepChecksum()
+		return err == nil
 	}
 
 	// in any other case, it's a StepLib URL
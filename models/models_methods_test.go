@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -672,6 +673,101 @@ func TestValidateConfig(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, 1, len(warnings))
 	}
+
+	t.Log("Workflow reference cycle")
+	{
+		bitriseData := BitriseDataModel{
+			Workflows: map[string]WorkflowModel{
+				"a": WorkflowModel{BeforeRun: NewWorkflowRunItems("b")},
+				"b": WorkflowModel{AfterRun: NewWorkflowRunItems("c")},
+				"c": WorkflowModel{BeforeRun: NewWorkflowRunItems("a")},
+			},
+		}
+
+		_, err := bitriseData.Validate()
+		require.EqualError(t, err, "Workflow reference cycle found: a -> b -> c -> a")
+	}
+
+	t.Log("Deep, shared workflow references are resolved once")
+	{
+		// every workflow references the next two, walking every path would take 2^60 steps
+		workflows := map[string]WorkflowModel{}
+		for i := 0; i < 60; i++ {
+			workflows[fmt.Sprintf("wf-%d", i)] = WorkflowModel{
+				BeforeRun: NewWorkflowRunItems(fmt.Sprintf("wf-%d", i+1)),
+				AfterRun:  NewWorkflowRunItems(fmt.Sprintf("wf-%d", i+2)),
+			}
+		}
+		workflows["wf-60"] = WorkflowModel{}
+		workflows["wf-61"] = WorkflowModel{}
+
+		bitriseData := BitriseDataModel{Workflows: workflows}
+
+		warnings, err := bitriseData.Validate()
+		require.NoError(t, err)
+		require.Equal(t, 0, len(warnings))
+	}
+
+	t.Log("Valid on_abort workflow")
+	{
+		bitriseData := BitriseDataModel{
+			Workflows: map[string]WorkflowModel{
+				"primary": WorkflowModel{},
+				"cleanup": WorkflowModel{},
+			},
+			OnAbort: "cleanup",
+		}
+
+		_, err := bitriseData.Validate()
+		require.NoError(t, err)
+	}
+
+	t.Log("Invalid on_abort workflow - does not exist")
+	{
+		bitriseData := BitriseDataModel{
+			Workflows: map[string]WorkflowModel{
+				"primary": WorkflowModel{},
+			},
+			OnAbort: "cleanup",
+		}
+
+		_, err := bitriseData.Validate()
+		require.EqualError(t, err, "on_abort workflow (cleanup) does not exist")
+	}
+
+	t.Log("Valid pinned tool versions")
+	{
+		bitriseData := BitriseDataModel{
+			Tools: &ToolsModel{Stepman: "0.9.30", Envman: "1.1.3"},
+		}
+
+		_, err := bitriseData.Validate()
+		require.NoError(t, err)
+	}
+
+	t.Log("Latest pinned tool version")
+	{
+		bitriseData := BitriseDataModel{
+			Tools: &ToolsModel{Stepman: "latest"},
+		}
+
+		_, err := bitriseData.Validate()
+		require.NoError(t, err)
+	}
+
+	t.Log("Invalid pinned tool version")
+	{
+		bitriseData := BitriseDataModel{
+			Tools: &ToolsModel{Stepman: "newest"},
+		}
+
+		_, err := bitriseData.Validate()
+		require.Error(t, err)
+
+		pathErr, ok := err.(ConfigPathError)
+		require.Equal(t, true, ok)
+		require.Equal(t, "tools.stepman", pathErr.Path)
+	}
 }
 
 // Workflow
@@ -679,8 +775,8 @@ func TestValidateWorkflow(t *testing.T) {
 	t.Log("before-afetr test")
 	{
 		workflow := WorkflowModel{
-			BeforeRun: []string{"befor1", "befor2", "befor3"},
-			AfterRun:  []string{"after1", "after2", "after3"},
+			BeforeRun: NewWorkflowRunItems("befor1", "befor2", "befor3"),
+			AfterRun:  NewWorkflowRunItems("after1", "after2", "after3"),
 		}
 
 		warnings, err := workflow.Validate()
@@ -945,7 +1041,7 @@ func Test_StepIDData_IsUniqueResourceID(t *testing.T) {
 }
 
 func TestGetStepIDStepDataPair(t *testing.T) {
-	stepData := stepmanModels.StepModel{}
+	stepData := WorkflowStepModel{}
 
 	t.Log("valid steplist item")
 	{
@@ -969,6 +1065,37 @@ func TestGetStepIDStepDataPair(t *testing.T) {
 		require.Error(t, err)
 		require.Equal(t, "", id)
 	}
+
+	t.Log("step options of the bitrise config")
+	{
+		stepListItem := StepListItemModel{}
+		require.NoError(t, yaml.Unmarshal([]byte(`script:
+  title: Build
+  run_on_failure_only: true
+  isolate_envs: true
+  exports:
+  - BUILD_PATH
+  working_dir: $BITRISE_SOURCE_DIR/ios
+  resource_limits:
+    cpus: 2
+    memory_mb: 4096
+`), &stepListItem))
+
+		id, step, err := GetStepIDStepDataPair(stepListItem)
+		require.NoError(t, err)
+		require.Equal(t, "script", id)
+		require.Equal(t, "Build", *step.Title)
+		require.Equal(t, true, *step.RunOnFailureOnly)
+		require.Equal(t, true, *step.IsolateEnvs)
+		require.Equal(t, []string{"BUILD_PATH"}, step.Exports)
+		require.Equal(t, "$BITRISE_SOURCE_DIR/ios", *step.WorkingDir)
+		require.Equal(t, ResourceLimitsModel{CPUs: 2, MemoryMB: 4096}, *step.ResourceLimits)
+
+		bytes, err := yaml.Marshal(stepListItem)
+		require.NoError(t, err)
+		require.Contains(t, string(bytes), "isolate_envs: true")
+		require.Contains(t, string(bytes), "title: Build")
+	}
 }
 
 func TestCreateStepIDDataFromString(t *testing.T) {
@@ -1306,3 +1433,66 @@ workflows:
 		}
 	}
 }
+
+func TestMatchWithPullRequestDraftParams(t *testing.T) {
+	t.Log("draft pull request against an item, which disables drafts - NO MATCH")
+	{
+		item := TriggerMapItemModel{
+			PullRequestTargetBranch: "master",
+			DraftPullRequestEnabled: pointers.NewBoolPtr(false),
+			WorkflowID:              "primary",
+		}
+
+		match, err := item.MatchWithPullRequestDraftParams("", "feature", "master", "", true)
+		require.NoError(t, err)
+		require.Equal(t, false, match)
+
+		match, err = item.MatchWithPullRequestDraftParams("", "feature", "master", "", false)
+		require.NoError(t, err)
+		require.Equal(t, true, match)
+	}
+
+	t.Log("draft pull request against an item, which enables drafts by default - MATCH")
+	{
+		item := TriggerMapItemModel{
+			PullRequestSourceBranch: "feature/*",
+			PullRequestTargetBranch: "master",
+			WorkflowID:              "primary",
+		}
+
+		match, err := item.MatchWithPullRequestDraftParams("", "feature/login", "master", "", true)
+		require.NoError(t, err)
+		require.Equal(t, true, match)
+	}
+
+	t.Log("draft_pull_request_enabled is only allowed for pull request items")
+	{
+		item := TriggerMapItemModel{
+			PushBranch:              "master",
+			DraftPullRequestEnabled: pointers.NewBoolPtr(false),
+			WorkflowID:              "primary",
+		}
+		require.Error(t, item.Validate())
+
+		item = TriggerMapItemModel{
+			Pattern:                 "*",
+			IsPullRequestAllowed:    true,
+			DraftPullRequestEnabled: pointers.NewBoolPtr(false),
+			WorkflowID:              "primary",
+		}
+		require.NoError(t, item.Validate())
+	}
+}
+
+func TestIsPreventSleep(t *testing.T) {
+	t.Log("opt-in")
+	{
+		require.Equal(t, false, WorkflowModel{}.IsPreventSleep())
+	}
+
+	t.Log("prevent_sleep")
+	{
+		require.Equal(t, true, WorkflowModel{PreventSleep: pointers.NewBoolPtr(true)}.IsPreventSleep())
+		require.Equal(t, false, WorkflowModel{PreventSleep: pointers.NewBoolPtr(false)}.IsPreventSleep())
+	}
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Steps distributed as OCI artifacts: a step can be referenced as oci://registry/repository:tag
+// (or oci://registry/repository@sha256:digest), the step (step.yml and its prebuilt binaries)
+// is pulled from the container registry, instead of being cloned from git.
+
+const (
+	// OCIStepReferencePrefix is the prefix of the OCI artifact step references
+	OCIStepReferencePrefix = "oci://"
+	// OCIStepSource is the SteplibSource of the OCI artifact steps
+	OCIStepSource = "oci"
+	// OCIDigestPrefix is the prefix of the (sha256) digest references
+	OCIDigestPrefix = "sha256:"
+	// OCIDefaultTag is the tag used, if the reference has neither a tag nor a digest
+	OCIDefaultTag = "latest"
+)
+
+// OCIStepReferenceModel ...
+type OCIStepReferenceModel struct {
+	Registry   string
+	Repository string
+	// Reference is either a tag or a digest (sha256:...)
+	Reference string
+}
+
+// IsDigest ...
+func (ref OCIStepReferenceModel) IsDigest() bool {
+	return strings.HasPrefix(ref.Reference, OCIDigestPrefix)
+}
+
+// String ...
+func (ref OCIStepReferenceModel) String() string {
+	if ref.IsDigest() {
+		return OCIStepReferencePrefix + ref.Registry + "/" + ref.Repository + "@" + ref.Reference
+	}
+	return OCIStepReferencePrefix + ref.Registry + "/" + ref.Repository + ":" + ref.Reference
+}
+
+// OCIStepReference returns the OCI reference of an OCI artifact step's ID data
+func (sIDData StepIDData) OCIStepReference() (OCIStepReferenceModel, error) {
+	if sIDData.SteplibSource != OCIStepSource {
+		return OCIStepReferenceModel{}, fmt.Errorf("step (%s) is not an OCI artifact step", sIDData.IDorURI)
+	}
+
+	split := strings.SplitN(sIDData.IDorURI, "/", 2)
+	if len(split) != 2 || split[0] == "" || split[1] == "" {
+		return OCIStepReferenceModel{}, fmt.Errorf("invalid OCI step reference (%s), should be in oci://registry/repository:tag format", sIDData.IDorURI)
+	}
+
+	reference := sIDData.Version
+	if reference == "" {
+		reference = OCIDefaultTag
+	}
+
+	return OCIStepReferenceModel{
+		Registry:   split[0],
+		Repository: split[1],
+		Reference:  reference,
+	}, nil
+}
+
+// createOCIStepIDData returns the step ID data of an oci://registry/repository[:tag|@sha256:digest] reference:
+// the IDorURI is the registry/repository part, the Version is the tag or the digest.
+func createOCIStepIDData(compositeVersionStr string) (StepIDData, error) {
+	nameAndReference := strings.TrimPrefix(compositeVersionStr, OCIStepReferencePrefix)
+
+	name := nameAndReference
+	reference := ""
+	if idx := strings.Index(nameAndReference, "@"); idx != -1 {
+		name = nameAndReference[:idx]
+		reference = nameAndReference[idx+1:]
+		if !strings.HasPrefix(reference, OCIDigestPrefix) || len(reference) == len(OCIDigestPrefix) {
+			return StepIDData{}, fmt.Errorf("invalid OCI step reference (%s), the digest should be in sha256:... format", compositeVersionStr)
+		}
+	} else if idx := strings.LastIndex(nameAndReference, ":"); idx > strings.LastIndex(nameAndReference, "/") {
+		// the tag separator is after the last path component, a colon before it is a registry port
+		name = nameAndReference[:idx]
+		reference = nameAndReference[idx+1:]
+		if reference == "" {
+			return StepIDData{}, fmt.Errorf("invalid OCI step reference (%s), empty tag", compositeVersionStr)
+		}
+	}
+
+	stepIDData := StepIDData{
+		SteplibSource: OCIStepSource,
+		IDorURI:       name,
+		Version:       reference,
+	}
+	if _, err := stepIDData.OCIStepReference(); err != nil {
+		return StepIDData{}, fmt.Errorf("invalid OCI step reference (%s), should be in oci://registry/repository:tag format", compositeVersionStr)
+	}
+	return stepIDData, nil
+}
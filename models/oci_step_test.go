@@ -0,0 +1,54 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateOCIStepIDData(t *testing.T) {
+	t.Log("tag")
+	{
+		stepIDData, err := CreateStepIDDataFromString("oci://ghcr.io/my-org/my-step:1.0.0", "")
+		require.NoError(t, err)
+		require.Equal(t, StepIDData{SteplibSource: "oci", IDorURI: "ghcr.io/my-org/my-step", Version: "1.0.0"}, stepIDData)
+		require.Equal(t, false, stepIDData.IsUniqueResourceID())
+
+		ref, err := stepIDData.OCIStepReference()
+		require.NoError(t, err)
+		require.Equal(t, OCIStepReferenceModel{Registry: "ghcr.io", Repository: "my-org/my-step", Reference: "1.0.0"}, ref)
+		require.Equal(t, "oci://ghcr.io/my-org/my-step:1.0.0", ref.String())
+	}
+
+	t.Log("registry with port, without tag")
+	{
+		stepIDData, err := CreateStepIDDataFromString("oci://localhost:5000/my-step", "")
+		require.NoError(t, err)
+		require.Equal(t, StepIDData{SteplibSource: "oci", IDorURI: "localhost:5000/my-step", Version: ""}, stepIDData)
+
+		ref, err := stepIDData.OCIStepReference()
+		require.NoError(t, err)
+		require.Equal(t, OCIStepReferenceModel{Registry: "localhost:5000", Repository: "my-step", Reference: "latest"}, ref)
+	}
+
+	t.Log("digest")
+	{
+		stepIDData, err := CreateStepIDDataFromString("oci://localhost:5000/my-org/my-step@sha256:abcd", "")
+		require.NoError(t, err)
+		require.Equal(t, StepIDData{SteplibSource: "oci", IDorURI: "localhost:5000/my-org/my-step", Version: "sha256:abcd"}, stepIDData)
+		require.Equal(t, true, stepIDData.IsUniqueResourceID())
+
+		ref, err := stepIDData.OCIStepReference()
+		require.NoError(t, err)
+		require.Equal(t, true, ref.IsDigest())
+		require.Equal(t, "oci://localhost:5000/my-org/my-step@sha256:abcd", ref.String())
+	}
+
+	t.Log("invalid references")
+	{
+		for _, reference := range []string{"oci://my-step:1.0.0", "oci://ghcr.io/my-step:", "oci://ghcr.io/my-step@1.0.0", "oci:///my-step"} {
+			_, err := CreateStepIDDataFromString(reference, "")
+			require.Error(t, err, reference)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var requiredSecretKeyRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// RequiredSecretModel is a secret env, required by the workflows and steps of a run
+type RequiredSecretModel struct {
+	Key string `json:"key"`
+	// RequiredBy lists the requiring workflows (workflow (deploy)) and steps (step (deploy-to-itunesconnect))
+	RequiredBy []string `json:"required_by"`
+}
+
+// validateRequiredSecrets ...
+func validateRequiredSecrets(keys []string) error {
+	isDefined := map[string]bool{}
+	for idx, key := range keys {
+		if key == "" {
+			return NewConfigPathError(fmt.Sprintf("[%d]", idx), errors.New("empty secret key"))
+		}
+		if !requiredSecretKeyRegexp.MatchString(key) {
+			return NewConfigPathError(fmt.Sprintf("[%d]", idx), fmt.Errorf("invalid secret key (%s)", key))
+		}
+		if isDefined[key] {
+			return NewConfigPathError(fmt.Sprintf("[%d]", idx), fmt.Errorf("duplicated secret key (%s)", key))
+		}
+		isDefined[key] = true
+	}
+	return nil
+}
+
+// workflowStepIDs returns the IDs (see: StepDefaultsID) of the workflow's steps, including the steps of the referenced step bundles
+func (config BitriseDataModel) workflowStepIDs(workflowID string) []string {
+	stepListItems := []StepListItemModel{}
+	for _, stepListItem := range config.Workflows[workflowID].Steps {
+		compositeStepID, _, err := GetStepIDStepDataPair(stepListItem)
+		if err == nil && IsStepBundleReference(compositeStepID) {
+			stepListItems = append(stepListItems, config.StepBundles[StepBundleIDFromReference(compositeStepID)].Steps...)
+		} else {
+			stepListItems = append(stepListItems, stepListItem)
+		}
+	}
+
+	stepIDs := []string{}
+	for _, stepListItem := range stepListItems {
+		if compositeStepID, _, err := GetStepIDStepDataPair(stepListItem); err == nil {
+			stepIDs = append(stepIDs, config.StepDefaultsID(compositeStepID))
+		}
+	}
+	return stepIDs
+}
+
+// RequiredSecrets returns the secrets, required by the workflow, by its before_run and after_run workflows,
+// and by their steps (step_defaults), in key order.
+func (config BitriseDataModel) RequiredSecrets(workflowID string) []RequiredSecretModel {
+	requiredBy := map[string][]string{}
+	addRequirements := func(keys []string, requirer string) {
+		for _, key := range keys {
+			isAdded := false
+			for _, existing := range requiredBy[key] {
+				isAdded = isAdded || existing == requirer
+			}
+			if !isAdded {
+				requiredBy[key] = append(requiredBy[key], requirer)
+			}
+		}
+	}
+
+	for _, chainWorkflowID := range WorkflowChain(config, workflowID) {
+		addRequirements(config.Workflows[chainWorkflowID].RequiredSecrets, fmt.Sprintf("workflow (%s)", chainWorkflowID))
+
+		for _, stepID := range config.workflowStepIDs(chainWorkflowID) {
+			addRequirements(config.StepDefaults[stepID].RequiredSecrets, fmt.Sprintf("step (%s)", stepID))
+		}
+	}
+
+	keys := []string{}
+	for key := range requiredBy {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	requiredSecrets := []RequiredSecretModel{}
+	for _, key := range keys {
+		requiredSecrets = append(requiredSecrets, RequiredSecretModel{Key: key, RequiredBy: requiredBy[key]})
+	}
+	return requiredSecrets
+}
+
+// MissingSecretsError lists the required secrets, which are not provided
+type MissingSecretsError struct {
+	Missing []RequiredSecretModel
+}
+
+// Error ...
+func (err MissingSecretsError) Error() string {
+	lines := []string{fmt.Sprintf("%d required secret(s) missing:", len(err.Missing))}
+	for _, secret := range err.Missing {
+		lines = append(lines, fmt.Sprintf("- %s (required by: %s)", secret.Key, strings.Join(secret.RequiredBy, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CheckRequiredSecrets returns a MissingSecretsError, if any of the secrets, required by the workflow,
+// is not provided (isProvided returns false for its key).
+func (config BitriseDataModel) CheckRequiredSecrets(workflowID string, isProvided func(key string) bool) error {
+	missing := []RequiredSecretModel{}
+	for _, secret := range config.RequiredSecrets(workflowID) {
+		if !isProvided(secret.Key) {
+			missing = append(missing, secret)
+		}
+	}
+
+	if len(missing) > 0 {
+		return MissingSecretsError{Missing: missing}
+	}
+	return nil
+}
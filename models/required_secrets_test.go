@@ -0,0 +1,76 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestRequiredSecrets(t *testing.T) {
+	configStr := `
+format_version: 1.3.1
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+step_defaults:
+  deploy-to-itunesconnect-deliver:
+    required_secrets:
+    - ITUNES_CONNECT_PASSWORD
+step_bundles:
+  notify:
+    steps:
+    - slack@2.6: {}
+workflows:
+  _setup:
+    required_secrets:
+    - GIT_TOKEN
+  deploy:
+    before_run:
+    - _setup
+    required_secrets:
+    - ITUNES_CONNECT_PASSWORD
+    - SLACK_WEBHOOK
+    steps:
+    - deploy-to-itunesconnect-deliver@2.10.0: {}
+    - bundle::notify: {}
+`
+	config := BitriseDataModel{}
+	require.NoError(t, yaml.Unmarshal([]byte(configStr), &config))
+	require.NoError(t, config.Normalize())
+	_, err := config.Validate()
+	require.NoError(t, err)
+
+	config.StepDefaults["slack"] = StepDefaultsModel{RequiredSecrets: []string{"SLACK_WEBHOOK"}}
+
+	t.Log("secrets of the workflow chain and of the steps")
+	{
+		require.Equal(t, []RequiredSecretModel{
+			RequiredSecretModel{Key: "GIT_TOKEN", RequiredBy: []string{"workflow (_setup)"}},
+			RequiredSecretModel{Key: "ITUNES_CONNECT_PASSWORD", RequiredBy: []string{"workflow (deploy)", "step (deploy-to-itunesconnect-deliver)"}},
+			RequiredSecretModel{Key: "SLACK_WEBHOOK", RequiredBy: []string{"workflow (deploy)", "step (slack)"}},
+		}, config.RequiredSecrets("deploy"))
+	}
+
+	t.Log("missing secrets")
+	{
+		err := config.CheckRequiredSecrets("deploy", func(key string) bool { return key == "GIT_TOKEN" })
+		require.Error(t, err)
+		missingErr, ok := err.(MissingSecretsError)
+		require.True(t, ok)
+		require.Equal(t, 2, len(missingErr.Missing))
+		require.Equal(t, `2 required secret(s) missing:
+- ITUNES_CONNECT_PASSWORD (required by: workflow (deploy), step (deploy-to-itunesconnect-deliver))
+- SLACK_WEBHOOK (required by: workflow (deploy), step (slack))`, err.Error())
+
+		require.NoError(t, config.CheckRequiredSecrets("deploy", func(key string) bool { return true }))
+	}
+
+	t.Log("invalid secret key")
+	{
+		workflow := WorkflowModel{RequiredSecrets: []string{"API_TOKEN", "API TOKEN"}}
+		_, err := workflow.Validate()
+		require.Error(t, err)
+		configPathErr, ok := err.(ConfigPathError)
+		require.True(t, ok)
+		require.Equal(t, "required_secrets[1]", configPathErr.Path)
+	}
+}
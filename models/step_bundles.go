@@ -0,0 +1,255 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+)
+
+// StepBundleReferencePrefix is the prefix of the step bundle references in the workflows' step lists (- bundle::BUNDLE_ID)
+const StepBundleReferencePrefix = "bundle::"
+
+// StepBundleModel is a named sequence of steps (step_bundles: section),
+// used in the workflows' step lists like a step: - bundle::BUNDLE_ID.
+// The inputs are the default inputs of the bundle's steps, the reference's inputs override them,
+// e.g.: - bundle::install_deps: {inputs: [{NODE_VERSION: "10"}]}
+type StepBundleModel struct {
+	Title  string                              `json:"title,omitempty" yaml:"title,omitempty"`
+	Inputs []envmanModels.EnvironmentItemModel `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+	Steps  []StepListItemModel                 `json:"steps,omitempty" yaml:"steps,omitempty"`
+}
+
+// IsStepBundleReference ...
+func IsStepBundleReference(compositeStepID string) bool {
+	return strings.HasPrefix(compositeStepID, StepBundleReferencePrefix)
+}
+
+// StepBundleIDFromReference ...
+func StepBundleIDFromReference(compositeStepID string) string {
+	return strings.TrimPrefix(compositeStepID, StepBundleReferencePrefix)
+}
+
+// Normalize ...
+func (bundle *StepBundleModel) Normalize() error {
+	for idx, input := range bundle.Inputs {
+		if err := input.Normalize(); err != nil {
+			return NewConfigPathError(fmt.Sprintf("inputs[%d]", idx), err)
+		}
+	}
+
+	for idx, stepListItem := range bundle.Steps {
+		stepID, step, err := GetStepIDStepDataPair(stepListItem)
+		if err != nil {
+			return NewConfigPathError(fmt.Sprintf("steps[%d]", idx), err)
+		}
+		if err := step.Normalize(); err != nil {
+			return NewConfigPathError(fmt.Sprintf("steps[%d].%s", idx, stepID), err)
+		}
+		stepListItem[stepID] = step
+	}
+
+	return nil
+}
+
+// Validate ...
+func (bundle StepBundleModel) Validate() error {
+	inputKeys := map[string]bool{}
+	for idx, input := range bundle.Inputs {
+		if err := input.Validate(); err != nil {
+			return NewConfigPathError(fmt.Sprintf("inputs[%d]", idx), err)
+		}
+
+		key, _, err := input.GetKeyValuePair()
+		if err != nil {
+			return NewConfigPathError(fmt.Sprintf("inputs[%d]", idx), err)
+		}
+		if inputKeys[key] {
+			return NewConfigPathError(fmt.Sprintf("inputs[%d]", idx), fmt.Errorf("duplicated input (%s)", key))
+		}
+		inputKeys[key] = true
+	}
+
+	if len(bundle.Steps) == 0 {
+		return errors.New("no steps defined")
+	}
+
+	for idx, stepListItem := range bundle.Steps {
+		stepID, step, err := GetStepIDStepDataPair(stepListItem)
+		if err != nil {
+			return NewConfigPathError(fmt.Sprintf("steps[%d]", idx), err)
+		}
+		if IsStepBundleReference(stepID) {
+			return NewConfigPathError(fmt.Sprintf("steps[%d]", idx), fmt.Errorf("step bundles can't reference other step bundles (%s)", stepID))
+		}
+		if err := step.ValidateInputAndOutputEnvs(false); err != nil {
+			return NewConfigPathError(fmt.Sprintf("steps[%d].%s", idx, stepID), err)
+		}
+	}
+
+	return nil
+}
+
+// validateStepBundleReferences checks whether the step bundles, referenced by the workflows exist
+func (config BitriseDataModel) validateStepBundleReferences() error {
+	for workflowID, workflow := range config.Workflows {
+		for idx, stepListItem := range workflow.Steps {
+			stepID, _, err := GetStepIDStepDataPair(stepListItem)
+			if err != nil {
+				return NewConfigPathError(fmt.Sprintf("workflows.%s.steps[%d]", workflowID, idx), err)
+			}
+			if !IsStepBundleReference(stepID) {
+				continue
+			}
+			if _, found := config.StepBundles[StepBundleIDFromReference(stepID)]; !found {
+				return NewConfigPathError(fmt.Sprintf("workflows.%s.steps[%d]", workflowID, idx), fmt.Errorf("step bundle (%s) does not exist", StepBundleIDFromReference(stepID)))
+			}
+		}
+	}
+	return nil
+}
+
+// ExpandStepBundles replaces the step bundle references of the workflows with the steps of the bundles.
+// The inputs of a bundle step are: the bundle's inputs, not defined by the step, and the step's own inputs,
+// where the inputs of the reference override both.
+func (config *BitriseDataModel) ExpandStepBundles() error {
+	if len(config.StepBundles) == 0 {
+		return nil
+	}
+
+	workflowIDs := []string{}
+	for workflowID := range config.Workflows {
+		workflowIDs = append(workflowIDs, workflowID)
+	}
+	sort.Strings(workflowIDs)
+
+	for _, workflowID := range workflowIDs {
+		workflow := config.Workflows[workflowID]
+
+		isExpanded := false
+		steps := []StepListItemModel{}
+		for idx, stepListItem := range workflow.Steps {
+			stepID, step, err := GetStepIDStepDataPair(stepListItem)
+			if err != nil {
+				return NewConfigPathError(fmt.Sprintf("workflows.%s.steps[%d]", workflowID, idx), err)
+			}
+			if !IsStepBundleReference(stepID) {
+				steps = append(steps, stepListItem)
+				continue
+			}
+
+			bundleID := StepBundleIDFromReference(stepID)
+			bundle, found := config.StepBundles[bundleID]
+			if !found {
+				return NewConfigPathError(fmt.Sprintf("workflows.%s.steps[%d]", workflowID, idx), fmt.Errorf("step bundle (%s) does not exist", bundleID))
+			}
+
+			bundleSteps, err := bundle.expand(step.Inputs)
+			if err != nil {
+				return NewConfigPathError(fmt.Sprintf("workflows.%s.steps[%d].%s", workflowID, idx, stepID), err)
+			}
+			steps = append(steps, bundleSteps...)
+			isExpanded = true
+		}
+
+		if isExpanded {
+			workflow.Steps = steps
+			config.Workflows[workflowID] = workflow
+		}
+	}
+
+	return nil
+}
+
+// expand returns the bundle's steps, with the bundle's inputs and the given overrides
+func (bundle StepBundleModel) expand(overrides []envmanModels.EnvironmentItemModel) ([]StepListItemModel, error) {
+	overrideByKey := map[string]envmanModels.EnvironmentItemModel{}
+	for _, override := range overrides {
+		key, _, err := override.GetKeyValuePair()
+		if err != nil {
+			return []StepListItemModel{}, err
+		}
+		overrideByKey[key] = override
+	}
+
+	declaredKeys := map[string]bool{}
+	withOverride := func(input envmanModels.EnvironmentItemModel) (envmanModels.EnvironmentItemModel, error) {
+		key, _, err := input.GetKeyValuePair()
+		if err != nil {
+			return envmanModels.EnvironmentItemModel{}, err
+		}
+		declaredKeys[key] = true
+
+		if override, found := overrideByKey[key]; found {
+			return copyEnvironmentItem(override), nil
+		}
+		return copyEnvironmentItem(input), nil
+	}
+
+	steps := []StepListItemModel{}
+	for _, stepListItem := range bundle.Steps {
+		stepID, step, err := GetStepIDStepDataPair(stepListItem)
+		if err != nil {
+			return []StepListItemModel{}, err
+		}
+
+		stepInputKeys := map[string]bool{}
+		for _, input := range step.Inputs {
+			key, _, err := input.GetKeyValuePair()
+			if err != nil {
+				return []StepListItemModel{}, err
+			}
+			stepInputKeys[key] = true
+		}
+
+		inputs := []envmanModels.EnvironmentItemModel{}
+		for _, bundleInput := range bundle.Inputs {
+			key, _, err := bundleInput.GetKeyValuePair()
+			if err != nil {
+				return []StepListItemModel{}, err
+			}
+			if stepInputKeys[key] {
+				continue
+			}
+
+			input, err := withOverride(bundleInput)
+			if err != nil {
+				return []StepListItemModel{}, err
+			}
+			inputs = append(inputs, input)
+		}
+		for _, stepInput := range step.Inputs {
+			input, err := withOverride(stepInput)
+			if err != nil {
+				return []StepListItemModel{}, err
+			}
+			inputs = append(inputs, input)
+		}
+
+		expandedStep := step
+		expandedStep.Inputs = inputs
+		steps = append(steps, StepListItemModel{stepID: expandedStep})
+	}
+
+	for _, override := range overrides {
+		key, _, err := override.GetKeyValuePair()
+		if err != nil {
+			return []StepListItemModel{}, err
+		}
+		if !declaredKeys[key] {
+			return []StepListItemModel{}, fmt.Errorf("input (%s) is not an input of the step bundle", key)
+		}
+	}
+
+	return steps, nil
+}
+
+func copyEnvironmentItem(env envmanModels.EnvironmentItemModel) envmanModels.EnvironmentItemModel {
+	envCopy := envmanModels.EnvironmentItemModel{}
+	for key, value := range env {
+		envCopy[key] = value
+	}
+	return envCopy
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"testing"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func testStepBundlesConfig(t *testing.T, configStr string) BitriseDataModel {
+	config := BitriseDataModel{}
+	require.NoError(t, yaml.Unmarshal([]byte(configStr), &config))
+	require.NoError(t, config.Normalize())
+	return config
+}
+
+func TestExpandStepBundles(t *testing.T) {
+	configStr := `
+format_version: 1.3.1
+step_bundles:
+  install_deps:
+    title: Install dependencies
+    inputs:
+    - NODE_VERSION: "8"
+    - IS_CACHED: "true"
+    steps:
+    - nvm:
+        inputs:
+        - NODE_VERSION: "6"
+    - script:
+        inputs:
+        - content: npm install
+workflows:
+  test:
+    steps:
+    - bundle::install_deps:
+        inputs:
+        - NODE_VERSION: "10"
+    - script:
+        inputs:
+        - content: npm test
+  deploy:
+    steps:
+    - bundle::install_deps: {}
+`
+
+	t.Log("bundle references are replaced with the bundle steps, the reference inputs override the bundle and step inputs")
+	{
+		config := testStepBundlesConfig(t, configStr)
+		_, err := config.Validate()
+		require.NoError(t, err)
+		require.NoError(t, config.ExpandStepBundles())
+
+		steps := config.Workflows["test"].Steps
+		require.Equal(t, 3, len(steps))
+		require.Equal(t, []envmanModels.EnvironmentItemModel{
+			envmanModels.EnvironmentItemModel{"IS_CACHED": "true", envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{}},
+			envmanModels.EnvironmentItemModel{"NODE_VERSION": "10", envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{}},
+		}, steps[0]["nvm"].Inputs)
+		require.Equal(t, 3, len(steps[1]["script"].Inputs))
+		require.Equal(t, "npm install", steps[1]["script"].Inputs[2]["content"])
+		require.Equal(t, "npm test", steps[2]["script"].Inputs[0]["content"])
+
+		steps = config.Workflows["deploy"].Steps
+		require.Equal(t, 2, len(steps))
+		require.Equal(t, "6", steps[0]["nvm"].Inputs[1]["NODE_VERSION"])
+		require.Equal(t, "8", steps[1]["script"].Inputs[0]["NODE_VERSION"])
+
+		// the bundle itself is not modified
+		require.Equal(t, 1, len(config.StepBundles["install_deps"].Steps[0]["nvm"].Inputs))
+	}
+
+	t.Log("override of an undeclared input")
+	{
+		config := testStepBundlesConfig(t, configStr)
+		deploy := config.Workflows["deploy"]
+		deploy.Steps = []StepListItemModel{
+			StepListItemModel{"bundle::install_deps": WorkflowStepModel{
+				StepModel: stepmanModels.StepModel{
+					Inputs: []envmanModels.EnvironmentItemModel{
+						envmanModels.EnvironmentItemModel{"XCODE_VERSION": "9"},
+					},
+				},
+			}},
+		}
+		config.Workflows["deploy"] = deploy
+
+		err := config.ExpandStepBundles()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "input (XCODE_VERSION) is not an input of the step bundle")
+	}
+}
+
+func TestValidateStepBundles(t *testing.T) {
+	t.Log("referenced bundle does not exist")
+	{
+		config := testStepBundlesConfig(t, `
+format_version: 1.3.1
+workflows:
+  test:
+    steps:
+    - bundle::install_deps: {}
+`)
+		_, err := config.Validate()
+		require.Error(t, err)
+		configPathErr, ok := err.(ConfigPathError)
+		require.True(t, ok)
+		require.Equal(t, "workflows.test.steps[0]", configPathErr.Path)
+	}
+
+	t.Log("nested bundle reference")
+	{
+		config := testStepBundlesConfig(t, `
+format_version: 1.3.1
+step_bundles:
+  first:
+    steps:
+    - script: {}
+  second:
+    steps:
+    - bundle::first: {}
+`)
+		_, err := config.Validate()
+		require.Error(t, err)
+		configPathErr, ok := err.(ConfigPathError)
+		require.True(t, ok)
+		require.Equal(t, "step_bundles.second.steps[0]", configPathErr.Path)
+	}
+
+	t.Log("bundle without steps")
+	{
+		config := testStepBundlesConfig(t, `
+format_version: 1.3.1
+step_bundles:
+  empty:
+    title: Empty
+`)
+		_, err := config.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no steps defined")
+	}
+}
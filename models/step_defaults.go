@@ -0,0 +1,119 @@
+package models
+
+import (
+	"fmt"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+)
+
+// StepDefaultsModel is the repository-level default of a step (step_defaults: section),
+// applied to every use of the step in the config.
+type StepDefaultsModel struct {
+	Inputs []envmanModels.EnvironmentItemModel `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+	// RequiredSecrets are the secret envs, the step requires
+	RequiredSecrets []string `json:"required_secrets,omitempty" yaml:"required_secrets,omitempty"`
+}
+
+// Normalize ...
+func (defaults *StepDefaultsModel) Normalize() error {
+	for idx, input := range defaults.Inputs {
+		if err := input.Normalize(); err != nil {
+			return NewConfigPathError(fmt.Sprintf("inputs[%d]", idx), err)
+		}
+	}
+	return nil
+}
+
+// Validate ...
+func (defaults StepDefaultsModel) Validate() error {
+	inputKeys := map[string]bool{}
+	for idx, input := range defaults.Inputs {
+		if err := input.Validate(); err != nil {
+			return NewConfigPathError(fmt.Sprintf("inputs[%d]", idx), err)
+		}
+
+		key, _, err := input.GetKeyValuePair()
+		if err != nil {
+			return NewConfigPathError(fmt.Sprintf("inputs[%d]", idx), err)
+		}
+		if inputKeys[key] {
+			return NewConfigPathError(fmt.Sprintf("inputs[%d]", idx), fmt.Errorf("duplicated input (%s)", key))
+		}
+		inputKeys[key] = true
+	}
+
+	if err := validateRequiredSecrets(defaults.RequiredSecrets); err != nil {
+		return NewConfigPathError("required_secrets", err)
+	}
+	return nil
+}
+
+// StepDefaultsID returns the ID of the step in the step_defaults: its ID (or URI), without the steplib source and version
+func (config BitriseDataModel) StepDefaultsID(compositeStepID string) string {
+	if stepIDData, err := CreateStepIDDataFromString(compositeStepID, config.DefaultStepLibSource); err == nil {
+		return stepIDData.IDorURI
+	}
+	return compositeStepID
+}
+
+// ApplyStepDefaults adds the step_defaults inputs to every use of the steps in the workflows.
+// The step is identified by its ID (or URI, in case of git and path steps), without the steplib source and version.
+// An input defined by the step instance overrides the default one.
+func (config *BitriseDataModel) ApplyStepDefaults() error {
+	if len(config.StepDefaults) == 0 {
+		return nil
+	}
+
+	for workflowID, workflow := range config.Workflows {
+		for idx, stepListItem := range workflow.Steps {
+			compositeStepID, step, err := GetStepIDStepDataPair(stepListItem)
+			if err != nil {
+				return NewConfigPathError(fmt.Sprintf("workflows.%s.steps[%d]", workflowID, idx), err)
+			}
+
+			defaults, found := config.StepDefaults[config.StepDefaultsID(compositeStepID)]
+			if !found {
+				continue
+			}
+
+			inputs, err := mergeDefaultInputs(defaults.Inputs, step.Inputs)
+			if err != nil {
+				return NewConfigPathError(fmt.Sprintf("workflows.%s.steps[%d].%s", workflowID, idx, compositeStepID), err)
+			}
+			step.Inputs = inputs
+			stepListItem[compositeStepID] = step
+		}
+	}
+
+	return nil
+}
+
+func mergeDefaultInputs(defaultInputs, inputs []envmanModels.EnvironmentItemModel) ([]envmanModels.EnvironmentItemModel, error) {
+	definedKeys := map[string]bool{}
+	for _, input := range inputs {
+		key, _, err := input.GetKeyValuePair()
+		if err != nil {
+			return []envmanModels.EnvironmentItemModel{}, err
+		}
+		definedKeys[key] = true
+	}
+
+	merged := append([]envmanModels.EnvironmentItemModel{}, inputs...)
+	for _, defaultInput := range defaultInputs {
+		key, _, err := defaultInput.GetKeyValuePair()
+		if err != nil {
+			return []envmanModels.EnvironmentItemModel{}, err
+		}
+		if definedKeys[key] {
+			continue
+		}
+
+		input := envmanModels.EnvironmentItemModel{}
+		for k, v := range defaultInput {
+			input[k] = v
+		}
+		merged = append(merged, input)
+	}
+
+	return merged, nil
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"testing"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyStepDefaults(t *testing.T) {
+	t.Log("defaults are applied to every use of the step, overridable per instance")
+	{
+		config := BitriseDataModel{
+			FormatVersion:        Version,
+			DefaultStepLibSource: "https://github.com/bitrise-io/bitrise-steplib.git",
+			StepDefaults: map[string]StepDefaultsModel{
+				"xcode-archive": StepDefaultsModel{
+					Inputs: []envmanModels.EnvironmentItemModel{
+						envmanModels.EnvironmentItemModel{"team_id": "TEAM"},
+						envmanModels.EnvironmentItemModel{"project_path": "./App.xcodeproj"},
+					},
+				},
+			},
+			Workflows: map[string]WorkflowModel{
+				"deploy": WorkflowModel{
+					Steps: []StepListItemModel{
+						StepListItemModel{"xcode-archive@2.0.0": WorkflowStepModel{}},
+						StepListItemModel{"https://github.com/bitrise-io/bitrise-steplib.git::xcode-archive": WorkflowStepModel{
+							StepModel: stepmanModels.StepModel{
+								Inputs: []envmanModels.EnvironmentItemModel{
+									envmanModels.EnvironmentItemModel{"team_id": "OTHER_TEAM"},
+								},
+							},
+						}},
+						StepListItemModel{"script": WorkflowStepModel{}},
+					},
+				},
+			},
+		}
+
+		require.NoError(t, config.ApplyStepDefaults())
+
+		steps := config.Workflows["deploy"].Steps
+		require.Equal(t, []envmanModels.EnvironmentItemModel{
+			envmanModels.EnvironmentItemModel{"team_id": "TEAM"},
+			envmanModels.EnvironmentItemModel{"project_path": "./App.xcodeproj"},
+		}, steps[0]["xcode-archive@2.0.0"].Inputs)
+		require.Equal(t, []envmanModels.EnvironmentItemModel{
+			envmanModels.EnvironmentItemModel{"team_id": "OTHER_TEAM"},
+			envmanModels.EnvironmentItemModel{"project_path": "./App.xcodeproj"},
+		}, steps[1]["https://github.com/bitrise-io/bitrise-steplib.git::xcode-archive"].Inputs)
+		require.Equal(t, 0, len(steps[2]["script"].Inputs))
+	}
+
+	t.Log("duplicated default input")
+	{
+		defaults := StepDefaultsModel{
+			Inputs: []envmanModels.EnvironmentItemModel{
+				envmanModels.EnvironmentItemModel{"team_id": "TEAM"},
+				envmanModels.EnvironmentItemModel{"team_id": "OTHER_TEAM"},
+			},
+		}
+		err := defaults.Validate()
+		require.EqualError(t, err, "duplicated input (team_id)")
+
+		pathErr, ok := err.(ConfigPathError)
+		require.Equal(t, true, ok)
+		require.Equal(t, "inputs[1]", pathErr.Path)
+	}
+}
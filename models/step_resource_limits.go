@@ -0,0 +1,31 @@
+package models
+
+import (
+	"fmt"
+)
+
+// Step resource limits (resource_limits): the CPU cores and the memory (in MB) the step's processes can use.
+// The limits are enforced with cgroups on Linux, they are not supported on the other platforms,
+// a step fails if its limits can't be enforced (see: tools.EnvmanRunWithResourceLimits).
+
+// ResourceLimitsModel ...
+type ResourceLimitsModel struct {
+	// CPUs : the number of CPU cores the step can use (e.g. 0.5 or 2)
+	CPUs float64 `json:"cpus,omitempty" yaml:"cpus,omitempty"`
+	// MemoryMB : the memory the step can use, in megabytes
+	MemoryMB int `json:"memory_mb,omitempty" yaml:"memory_mb,omitempty"`
+}
+
+// validateStepResourceLimits ...
+func validateStepResourceLimits(limits *ResourceLimitsModel) error {
+	if limits == nil {
+		return nil
+	}
+	if limits.CPUs < 0 {
+		return NewConfigPathError("cpus", fmt.Errorf("invalid CPU limit (%g), it can't be negative", limits.CPUs))
+	}
+	if limits.MemoryMB < 0 {
+		return NewConfigPathError("memory_mb", fmt.Errorf("invalid memory limit (%d), it can't be negative", limits.MemoryMB))
+	}
+	return nil
+}
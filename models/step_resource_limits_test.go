@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateStepResourceLimits(t *testing.T) {
+	require.NoError(t, validateStepResourceLimits(nil))
+	require.NoError(t, validateStepResourceLimits(&ResourceLimitsModel{CPUs: 0.5, MemoryMB: 2048}))
+
+	err := validateStepResourceLimits(&ResourceLimitsModel{CPUs: -1})
+	require.EqualError(t, err, "invalid CPU limit (-1), it can't be negative")
+	require.Equal(t, "cpus", err.(ConfigPathError).Path)
+
+	err = validateStepResourceLimits(&ResourceLimitsModel{MemoryMB: -512})
+	require.EqualError(t, err, "invalid memory limit (-512), it can't be negative")
+	require.Equal(t, "memory_mb", err.(ConfigPathError).Path)
+
+	t.Log("workflow validation")
+	{
+		workflow := WorkflowModel{
+			Steps: []StepListItemModel{
+				StepListItemModel{"script": WorkflowStepModel{
+					ResourceLimits: &ResourceLimitsModel{MemoryMB: -1},
+				}},
+			},
+		}
+		_, err := workflow.Validate()
+		require.EqualError(t, err, "invalid memory limit (-1), it can't be negative")
+		require.Equal(t, "steps[0].script.resource_limits.memory_mb", err.(ConfigPathError).Path)
+	}
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Multiple StepLib sources: the config can declare an ordered list of StepLibs (step_lib_sources),
+// e.g. a company-internal StepLib first and the public StepLib second.
+// The steps without an explicit StepLib source (step-lib-src::step-id) are searched in these StepLibs, in order,
+// followed by the default_step_lib_source, if it's not listed.
+
+// StepLibSearchList returns the StepLibs, in the order the steps without an explicit StepLib source are searched in:
+// the step_lib_sources, followed by the default_step_lib_source, if it's not listed.
+func (config BitriseDataModel) StepLibSearchList() []string {
+	sources := []string{}
+	isDefaultListed := false
+	for _, source := range config.StepLibSources {
+		if source == config.DefaultStepLibSource {
+			isDefaultListed = true
+		}
+		sources = append(sources, source)
+	}
+	if config.DefaultStepLibSource != "" && !isDefaultListed {
+		sources = append(sources, config.DefaultStepLibSource)
+	}
+	return sources
+}
+
+func (config BitriseDataModel) validateStepLibSources() error {
+	listed := map[string]bool{}
+	for idx, source := range config.StepLibSources {
+		path := fmt.Sprintf("step_lib_sources[%d]", idx)
+		if source == "" {
+			return NewConfigPathError(path, errors.New("empty StepLib source"))
+		}
+		if source == "path" || source == "git" || source == "_" || source == OCIStepSource || source == ArchiveStepSource {
+			return NewConfigPathError(path, fmt.Errorf("invalid StepLib source (%s), it's a step source type", source))
+		}
+		if listed[source] {
+			return NewConfigPathError(path, fmt.Errorf("duplicated StepLib source (%s)", source))
+		}
+		listed[source] = true
+	}
+	return nil
+}
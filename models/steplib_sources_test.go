@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepLibSearchList(t *testing.T) {
+	t.Log("default StepLib only")
+	{
+		config := BitriseDataModel{DefaultStepLibSource: "https://github.com/bitrise-io/bitrise-steplib.git"}
+		require.Equal(t, []string{"https://github.com/bitrise-io/bitrise-steplib.git"}, config.StepLibSearchList())
+	}
+
+	t.Log("the default StepLib is searched last, if not listed")
+	{
+		config := BitriseDataModel{
+			DefaultStepLibSource: "https://github.com/bitrise-io/bitrise-steplib.git",
+			StepLibSources:       []string{"https://git.example.com/steplib.git"},
+		}
+		require.Equal(t, []string{"https://git.example.com/steplib.git", "https://github.com/bitrise-io/bitrise-steplib.git"}, config.StepLibSearchList())
+	}
+
+	t.Log("listed default StepLib keeps its position")
+	{
+		config := BitriseDataModel{
+			DefaultStepLibSource: "https://github.com/bitrise-io/bitrise-steplib.git",
+			StepLibSources:       []string{"https://github.com/bitrise-io/bitrise-steplib.git", "https://git.example.com/steplib.git"},
+		}
+		require.Equal(t, []string{"https://github.com/bitrise-io/bitrise-steplib.git", "https://git.example.com/steplib.git"}, config.StepLibSearchList())
+	}
+
+	t.Log("no StepLib")
+	{
+		require.Equal(t, []string{}, BitriseDataModel{}.StepLibSearchList())
+	}
+}
+
+func TestValidateStepLibSources(t *testing.T) {
+	t.Log("valid")
+	{
+		config := BitriseDataModel{StepLibSources: []string{"https://git.example.com/steplib.git", "https://github.com/bitrise-io/bitrise-steplib.git"}}
+		require.NoError(t, config.validateStepLibSources())
+	}
+
+	t.Log("empty source")
+	{
+		config := BitriseDataModel{StepLibSources: []string{"https://git.example.com/steplib.git", ""}}
+		err := config.validateStepLibSources()
+		require.EqualError(t, err, "empty StepLib source")
+		require.Equal(t, "step_lib_sources[1]", err.(ConfigPathError).Path)
+	}
+
+	t.Log("step source type")
+	{
+		config := BitriseDataModel{StepLibSources: []string{"git"}}
+		err := config.validateStepLibSources()
+		require.EqualError(t, err, "invalid StepLib source (git), it's a step source type")
+		require.Equal(t, "step_lib_sources[0]", err.(ConfigPathError).Path)
+	}
+
+	t.Log("duplicated source")
+	{
+		config := BitriseDataModel{StepLibSources: []string{"https://git.example.com/steplib.git", "https://git.example.com/steplib.git"}}
+		err := config.validateStepLibSources()
+		require.EqualError(t, err, "duplicated StepLib source (https://git.example.com/steplib.git)")
+		require.Equal(t, "step_lib_sources[1]", err.(ConfigPathError).Path)
+	}
+}
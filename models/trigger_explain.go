@@ -0,0 +1,110 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TriggerPatternComparisonModel is a trigger map item pattern, compared to a trigger param
+type TriggerPatternComparisonModel struct {
+	Field   string `json:"field"`
+	Pattern string `json:"pattern"`
+	Value   string `json:"value"`
+	Matched bool   `json:"matched"`
+}
+
+// TriggerMatchExplanationModel describes why a trigger map item matched the trigger params, or why not
+type TriggerMatchExplanationModel struct {
+	Matched     bool                            `json:"matched"`
+	Reason      string                          `json:"reason"`
+	EventType   TriggerEventType                `json:"event_type,omitempty"`
+	Comparisons []TriggerPatternComparisonModel `json:"comparisons,omitempty"`
+}
+
+type triggerPatternField struct {
+	name, pattern, value string
+}
+
+func explainPatternComparison(field, pattern, value string) (TriggerPatternComparisonModel, error) {
+	matched, err := matchTriggerPattern(pattern, value)
+	if err != nil {
+		return TriggerPatternComparisonModel{}, err
+	}
+	return TriggerPatternComparisonModel{Field: field, Pattern: pattern, Value: value, Matched: matched}, nil
+}
+
+// ExplainMatch evaluates the item against the trigger params like MatchWithPullRequestDraftParams,
+// and returns the details of the evaluation.
+func (triggerItem TriggerMapItemModel) ExplainMatch(pushBranch, prSourceBranch, prTargetBranch, tag string, isDraftPullRequest bool) TriggerMatchExplanationModel {
+	paramsEventType, err := triggerEventType(pushBranch, prSourceBranch, prTargetBranch, tag)
+	if err != nil {
+		return TriggerMatchExplanationModel{Reason: fmt.Sprintf("invalid trigger params: %s", err)}
+	}
+
+	migratedTriggerItems := []TriggerMapItemModel{triggerItem}
+	if triggerItem.Pattern != "" {
+		migratedTriggerItems = migrateDeprecatedTriggerItem(triggerItem)
+	}
+
+	itemEventTypes := []string{}
+	for _, migratedTriggerItem := range migratedTriggerItems {
+		itemEventType, err := triggerEventType(migratedTriggerItem.PushBranch, migratedTriggerItem.PullRequestSourceBranch, migratedTriggerItem.PullRequestTargetBranch, migratedTriggerItem.Tag)
+		if err != nil {
+			return TriggerMatchExplanationModel{Reason: fmt.Sprintf("invalid trigger map item: %s", err)}
+		}
+
+		if paramsEventType != itemEventType {
+			itemEventTypes = append(itemEventTypes, string(itemEventType))
+			continue
+		}
+
+		explanation := TriggerMatchExplanationModel{EventType: itemEventType, Comparisons: []TriggerPatternComparisonModel{}}
+
+		fields := []triggerPatternField{}
+		switch itemEventType {
+		case TriggerEventTypeCodePush:
+			fields = append(fields, triggerPatternField{"push_branch", migratedTriggerItem.PushBranch, pushBranch})
+		case TriggerEventTypePullRequest:
+			if migratedTriggerItem.PullRequestSourceBranch != "" {
+				fields = append(fields, triggerPatternField{"pull_request_source_branch", migratedTriggerItem.PullRequestSourceBranch, prSourceBranch})
+			}
+			if migratedTriggerItem.PullRequestTargetBranch != "" {
+				fields = append(fields, triggerPatternField{"pull_request_target_branch", migratedTriggerItem.PullRequestTargetBranch, prTargetBranch})
+			}
+		case TriggerEventTypeTag:
+			fields = append(fields, triggerPatternField{"tag", migratedTriggerItem.Tag, tag})
+		}
+
+		if triggerItem.Pattern != "" {
+			for idx := range fields {
+				fields[idx].name = "pattern"
+			}
+		}
+
+		explanation.Matched = true
+		for _, field := range fields {
+			comparison, err := explainPatternComparison(field.name, field.pattern, field.value)
+			if err != nil {
+				return TriggerMatchExplanationModel{EventType: itemEventType, Reason: fmt.Sprintf("invalid %s pattern: %s", field.name, err)}
+			}
+			explanation.Comparisons = append(explanation.Comparisons, comparison)
+
+			if !comparison.Matched && explanation.Matched {
+				explanation.Matched = false
+				explanation.Reason = fmt.Sprintf("%s pattern (%s) does not match (%s)", field.name, field.pattern, field.value)
+			}
+		}
+
+		if explanation.Matched && itemEventType == TriggerEventTypePullRequest && isDraftPullRequest && !triggerItem.IsDraftPullRequestEnabled() {
+			explanation.Matched = false
+			explanation.Reason = "draft pull requests are disabled (draft_pull_request_enabled: false)"
+		}
+
+		if explanation.Matched {
+			explanation.Reason = "all patterns matched"
+		}
+		return explanation
+	}
+
+	return TriggerMatchExplanationModel{Reason: fmt.Sprintf("event type mismatch: the item selects %s events, the params are %s event", strings.Join(itemEventTypes, ", "), paramsEventType)}
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/go-utils/pointers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainMatch(t *testing.T) {
+	t.Log("matching code-push item")
+	{
+		item := TriggerMapItemModel{PushBranch: "release/*", WorkflowID: "release"}
+		explanation := item.ExplainMatch("release/1.0", "", "", "", false)
+		require.Equal(t, true, explanation.Matched)
+		require.Equal(t, TriggerEventTypeCodePush, explanation.EventType)
+		require.Equal(t, []TriggerPatternComparisonModel{
+			TriggerPatternComparisonModel{Field: "push_branch", Pattern: "release/*", Value: "release/1.0", Matched: true},
+		}, explanation.Comparisons)
+	}
+
+	t.Log("event type mismatch")
+	{
+		item := TriggerMapItemModel{Tag: "v*", WorkflowID: "release"}
+		explanation := item.ExplainMatch("master", "", "", "", false)
+		require.Equal(t, false, explanation.Matched)
+		require.Equal(t, "event type mismatch: the item selects tag events, the params are code-push event", explanation.Reason)
+	}
+
+	t.Log("pull request target branch does not match")
+	{
+		item := TriggerMapItemModel{PullRequestSourceBranch: "*", PullRequestTargetBranch: "re:^release/", WorkflowID: "release_check"}
+		explanation := item.ExplainMatch("", "feature", "master", "", false)
+		require.Equal(t, false, explanation.Matched)
+		require.Equal(t, 2, len(explanation.Comparisons))
+		require.Equal(t, true, explanation.Comparisons[0].Matched)
+		require.Equal(t, false, explanation.Comparisons[1].Matched)
+		require.Equal(t, "pull_request_target_branch pattern (re:^release/) does not match (master)", explanation.Reason)
+	}
+
+	t.Log("draft pull request")
+	{
+		item := TriggerMapItemModel{PullRequestTargetBranch: "master", DraftPullRequestEnabled: pointers.NewBoolPtr(false), WorkflowID: "primary"}
+		explanation := item.ExplainMatch("", "feature", "master", "", true)
+		require.Equal(t, false, explanation.Matched)
+		require.Contains(t, explanation.Reason, "draft pull requests are disabled")
+	}
+
+	t.Log("deprecated pattern item")
+	{
+		item := TriggerMapItemModel{Pattern: "feature/*", IsPullRequestAllowed: true, WorkflowID: "primary"}
+		explanation := item.ExplainMatch("", "feature/login", "", "", false)
+		require.Equal(t, true, explanation.Matched)
+		require.Equal(t, "pattern", explanation.Comparisons[0].Field)
+	}
+
+	t.Log("explanation is consistent with the matching")
+	{
+		items := []TriggerMapItemModel{
+			TriggerMapItemModel{PushBranch: "*", WorkflowID: "primary"},
+			TriggerMapItemModel{PullRequestTargetBranch: "master", WorkflowID: "primary"},
+			TriggerMapItemModel{PullRequestSourceBranch: "feature/*", DraftPullRequestEnabled: pointers.NewBoolPtr(false), WorkflowID: "primary"},
+			TriggerMapItemModel{Tag: "re:^v[0-9]", WorkflowID: "release"},
+			TriggerMapItemModel{Pattern: "*", WorkflowID: "primary"},
+		}
+		paramsList := [][]string{
+			{"master", "", "", ""},
+			{"", "feature/login", "master", ""},
+			{"", "feature/login", "develop", ""},
+			{"", "", "", "v1.0"},
+			{"", "", "", "1.0"},
+		}
+
+		for _, item := range items {
+			for _, params := range paramsList {
+				for _, isDraft := range []bool{true, false} {
+					match, err := item.MatchWithPullRequestDraftParams(params[0], params[1], params[2], params[3], isDraft)
+					require.NoError(t, err)
+					require.Equal(t, match, item.ExplainMatch(params[0], params[1], params[2], params[3], isDraft).Matched)
+				}
+			}
+		}
+	}
+}
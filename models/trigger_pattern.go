@@ -0,0 +1,49 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ryanuber/go-glob"
+)
+
+// TriggerPatternRegexPrefix marks the trigger map patterns, which are regular expressions instead of globs,
+// e.g.: push_branch: "re:^release/.*-hotfix$"
+const TriggerPatternRegexPrefix = "re:"
+
+// IsRegexTriggerPattern ...
+func IsRegexTriggerPattern(pattern string) bool {
+	return strings.HasPrefix(pattern, TriggerPatternRegexPrefix)
+}
+
+func compileTriggerPatternRegex(pattern string) (*regexp.Regexp, error) {
+	expression := strings.TrimPrefix(pattern, TriggerPatternRegexPrefix)
+	re, err := regexp.Compile(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex pattern (%s), error: %s", expression, err)
+	}
+	return re, nil
+}
+
+// matchTriggerPattern matches the value against the glob, or the regex (re: prefixed) pattern.
+// The regex is not anchored, use ^ and $ to match the whole value.
+func matchTriggerPattern(pattern, value string) (bool, error) {
+	if !IsRegexTriggerPattern(pattern) {
+		return glob.Glob(pattern, value), nil
+	}
+
+	re, err := compileTriggerPatternRegex(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(value), nil
+}
+
+func validateTriggerPattern(pattern string) error {
+	if !IsRegexTriggerPattern(pattern) {
+		return nil
+	}
+	_, err := compileTriggerPatternRegex(pattern)
+	return err
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchWithParamsRegexPattern(t *testing.T) {
+	t.Log("code-push against regex push_branch")
+	{
+		item := TriggerMapItemModel{
+			PushBranch: "re:^release/.*-hotfix$",
+			WorkflowID: "hotfix",
+		}
+
+		match, err := item.MatchWithParams("release/1.2-hotfix", "", "", "")
+		require.NoError(t, err)
+		require.Equal(t, true, match)
+
+		match, err = item.MatchWithParams("release/1.2-hotfix-2", "", "", "")
+		require.NoError(t, err)
+		require.Equal(t, false, match)
+	}
+
+	t.Log("pull-request against regex source and glob target")
+	{
+		item := TriggerMapItemModel{
+			PullRequestSourceBranch: "re:^(feature|bugfix)/",
+			PullRequestTargetBranch: "master",
+			WorkflowID:              "primary",
+		}
+
+		match, err := item.MatchWithParams("", "bugfix/crash", "master", "")
+		require.NoError(t, err)
+		require.Equal(t, true, match)
+
+		match, err = item.MatchWithParams("", "chore/deps", "master", "")
+		require.NoError(t, err)
+		require.Equal(t, false, match)
+	}
+
+	t.Log("tag against regex tag")
+	{
+		item := TriggerMapItemModel{
+			Tag:        `re:^v\d+\.\d+\.\d+$`,
+			WorkflowID: "release",
+		}
+
+		match, err := item.MatchWithParams("", "", "", "v1.10.0")
+		require.NoError(t, err)
+		require.Equal(t, true, match)
+
+		match, err = item.MatchWithParams("", "", "", "v1.10.0-beta")
+		require.NoError(t, err)
+		require.Equal(t, false, match)
+	}
+
+	t.Log("glob pattern is not treated as regex")
+	{
+		item := TriggerMapItemModel{
+			PushBranch: "release/*",
+			WorkflowID: "release",
+		}
+
+		match, err := item.MatchWithParams("release/1.0", "", "", "")
+		require.NoError(t, err)
+		require.Equal(t, true, match)
+	}
+}
+
+func TestTriggerMapItemValidateRegexPattern(t *testing.T) {
+	t.Log("valid regex")
+	{
+		item := TriggerMapItemModel{
+			PushBranch: "re:^release/.*$",
+			WorkflowID: "release",
+		}
+		require.NoError(t, item.Validate())
+	}
+
+	t.Log("invalid regex")
+	{
+		item := TriggerMapItemModel{
+			PushBranch: "re:^release/(.*$",
+			WorkflowID: "release",
+		}
+		err := item.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid regex pattern (^release/(.*$)")
+	}
+
+	t.Log("invalid regex in deprecated pattern")
+	{
+		item := TriggerMapItemModel{
+			Pattern:    "re:[",
+			WorkflowID: "release",
+		}
+		require.Error(t, item.Validate())
+	}
+}
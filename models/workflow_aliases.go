@@ -0,0 +1,104 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IsAlias returns true if the workflow is an alias of another workflow (alias_of)
+func (workflow WorkflowModel) IsAlias() bool {
+	return workflow.AliasOf != ""
+}
+
+// workflowAliasChain returns the workflow IDs, from the given workflow to the first non alias workflow
+func (config BitriseDataModel) workflowAliasChain(workflowID string) ([]string, error) {
+	chain := []string{workflowID}
+	for {
+		workflow, found := config.Workflows[workflowID]
+		if !found {
+			return chain, fmt.Errorf("workflow (%s) does not exist", workflowID)
+		}
+		if !workflow.IsAlias() {
+			return chain, nil
+		}
+
+		workflowID = workflow.AliasOf
+		for _, chainWorkflowID := range chain {
+			if chainWorkflowID == workflowID {
+				return chain, fmt.Errorf("workflow alias cycle found: %s -> %s", strings.Join(chain, " -> "), workflowID)
+			}
+		}
+		chain = append(chain, workflowID)
+	}
+}
+
+// ResolveWorkflowAlias returns the ID of the workflow, the given workflow is an alias of
+// (following the alias chain), or the given workflow ID if it's not an alias.
+func (config BitriseDataModel) ResolveWorkflowAlias(workflowID string) (string, error) {
+	chain, err := config.workflowAliasChain(workflowID)
+	if err != nil {
+		return "", err
+	}
+	return chain[len(chain)-1], nil
+}
+
+// validateWorkflowAlias checks that the alias workflow defines nothing else, but the aliased workflow
+func validateWorkflowAlias(workflow WorkflowModel) error {
+	if len(workflow.Steps) > 0 || len(workflow.Environments) > 0 || len(workflow.BeforeRun) > 0 || len(workflow.AfterRun) > 0 ||
+		len(workflow.Inputs) > 0 || len(workflow.EnvFiles) > 0 || len(workflow.RequiredSecrets) > 0 || workflow.PreventSleep != nil || workflow.WorkingDir != "" ||
+		workflow.TimeoutSecs != 0 {
+		return errors.New("an alias workflow can't define steps, envs, env_files, required_secrets, before_run, after_run, inputs, prevent_sleep, working_dir or timeout_secs")
+	}
+	return nil
+}
+
+// validateWorkflowAliases validates the alias workflows and the references to them.
+// The alias of an alias works, but it's reported as a warning.
+func (config BitriseDataModel) validateWorkflowAliases() ([]string, error) {
+	warnings := []string{}
+
+	workflowIDs := []string{}
+	for ID := range config.Workflows {
+		workflowIDs = append(workflowIDs, ID)
+	}
+	sort.Strings(workflowIDs)
+
+	for _, ID := range workflowIDs {
+		workflow := config.Workflows[ID]
+
+		for _, item := range append(append([]WorkflowRunItemModel{}, workflow.BeforeRun...), workflow.AfterRun...) {
+			if referenced, found := config.Workflows[item.WorkflowID]; found && referenced.IsAlias() {
+				return warnings, NewConfigPathError("workflows."+ID, fmt.Errorf("workflow alias (%s) can't be referenced in before_run and after_run, reference its workflow (%s) instead", item.WorkflowID, referenced.AliasOf))
+			}
+		}
+
+		if !workflow.IsAlias() {
+			continue
+		}
+
+		if err := validateWorkflowAlias(workflow); err != nil {
+			return warnings, NewConfigPathError("workflows."+ID, err)
+		}
+
+		chain, err := config.workflowAliasChain(ID)
+		if err != nil {
+			return warnings, NewConfigPathError("workflows."+ID+".alias_of", err)
+		}
+
+		targetID := chain[len(chain)-1]
+		if strings.HasPrefix(targetID, "_") && !strings.HasPrefix(ID, "_") {
+			return warnings, NewConfigPathError("workflows."+ID+".alias_of", fmt.Errorf("workflow (%s) can't be an alias of the utility workflow (%s)", ID, targetID))
+		}
+		if len(chain) > 2 {
+			warnings = append(warnings, fmt.Sprintf("workflow alias chain: %s, use alias_of: %s in workflow (%s)", strings.Join(chain, " -> "), targetID, ID))
+		}
+	}
+
+	if onAbort, found := config.Workflows[config.OnAbort]; found && onAbort.IsAlias() {
+		return warnings, NewConfigPathError("on_abort", fmt.Errorf("workflow alias (%s) can't be the on_abort workflow, use its workflow (%s) instead", config.OnAbort, onAbort.AliasOf))
+	}
+
+	return warnings, nil
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func testWorkflowAliasesConfig(t *testing.T, configStr string) BitriseDataModel {
+	config := BitriseDataModel{}
+	require.NoError(t, yaml.Unmarshal([]byte(configStr), &config))
+	require.NoError(t, config.Normalize())
+	return config
+}
+
+func TestResolveWorkflowAlias(t *testing.T) {
+	config := testWorkflowAliasesConfig(t, `
+format_version: 1.3.1
+workflows:
+  primary-ci:
+    steps:
+    - script: {}
+  primary:
+    alias_of: primary-ci
+  ci:
+    alias_of: primary
+`)
+
+	t.Log("alias")
+	{
+		workflowID, err := config.ResolveWorkflowAlias("primary")
+		require.NoError(t, err)
+		require.Equal(t, "primary-ci", workflowID)
+	}
+
+	t.Log("alias chain")
+	{
+		workflowID, err := config.ResolveWorkflowAlias("ci")
+		require.NoError(t, err)
+		require.Equal(t, "primary-ci", workflowID)
+	}
+
+	t.Log("not an alias")
+	{
+		workflowID, err := config.ResolveWorkflowAlias("primary-ci")
+		require.NoError(t, err)
+		require.Equal(t, "primary-ci", workflowID)
+	}
+
+	t.Log("workflow does not exist")
+	{
+		_, err := config.ResolveWorkflowAlias("deploy")
+		require.EqualError(t, err, "workflow (deploy) does not exist")
+	}
+
+	t.Log("alias chains are warned")
+	{
+		warnings, err := config.Validate()
+		require.NoError(t, err)
+		require.Equal(t, []string{"workflow alias chain: ci -> primary -> primary-ci, use alias_of: primary-ci in workflow (ci)"}, warnings)
+	}
+}
+
+func TestValidateWorkflowAliases(t *testing.T) {
+	t.Log("aliased workflow does not exist")
+	{
+		config := testWorkflowAliasesConfig(t, `
+format_version: 1.3.1
+workflows:
+  primary:
+    alias_of: primary-ci
+`)
+		_, err := config.Validate()
+		require.Error(t, err)
+		configPathErr, ok := err.(ConfigPathError)
+		require.True(t, ok)
+		require.Equal(t, "workflows.primary.alias_of", configPathErr.Path)
+		require.Equal(t, "workflow (primary-ci) does not exist", configPathErr.Err.Error())
+	}
+
+	t.Log("alias cycle")
+	{
+		config := testWorkflowAliasesConfig(t, `
+format_version: 1.3.1
+workflows:
+  first:
+    alias_of: second
+  second:
+    alias_of: first
+`)
+		_, err := config.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "workflow alias cycle found: first -> second -> first")
+	}
+
+	t.Log("alias with steps")
+	{
+		config := testWorkflowAliasesConfig(t, `
+format_version: 1.3.1
+workflows:
+  primary-ci:
+    steps:
+    - script: {}
+  primary:
+    alias_of: primary-ci
+    steps:
+    - script: {}
+`)
+		_, err := config.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "an alias workflow can't define steps")
+	}
+
+	t.Log("alias referenced in before_run")
+	{
+		config := testWorkflowAliasesConfig(t, `
+format_version: 1.3.1
+workflows:
+  primary-ci:
+    steps:
+    - script: {}
+  primary:
+    alias_of: primary-ci
+  deploy:
+    before_run:
+    - primary
+`)
+		_, err := config.Validate()
+		require.Error(t, err)
+		configPathErr, ok := err.(ConfigPathError)
+		require.True(t, ok)
+		require.Equal(t, "workflows.deploy", configPathErr.Path)
+	}
+
+	t.Log("alias of a utility workflow")
+	{
+		config := testWorkflowAliasesConfig(t, `
+format_version: 1.3.1
+workflows:
+  _setup:
+    steps:
+    - script: {}
+  setup:
+    alias_of: _setup
+`)
+		_, err := config.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "can't be an alias of the utility workflow (_setup)")
+	}
+}
@@ -0,0 +1,209 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/pointers"
+)
+
+const (
+	// WorkflowInputTypeString ...
+	WorkflowInputTypeString = "string"
+	// WorkflowInputTypeBool ...
+	WorkflowInputTypeBool = "bool"
+	// WorkflowInputTypeInt ...
+	WorkflowInputTypeInt = "int"
+	// WorkflowInputTypeFloat ...
+	WorkflowInputTypeFloat = "float"
+)
+
+// WorkflowInputModel is a typed parameter of a workflow (inputs: section of the workflow).
+// The value is provided by: bitrise run WORKFLOW --input KEY=VALUE, or by the matching trigger_map item's inputs,
+// and is exposed to the steps as an env.
+type WorkflowInputModel struct {
+	Title        string   `json:"title,omitempty" yaml:"title,omitempty"`
+	Type         string   `json:"type,omitempty" yaml:"type,omitempty"`
+	Default      string   `json:"default,omitempty" yaml:"default,omitempty"`
+	IsRequired   bool     `json:"is_required,omitempty" yaml:"is_required,omitempty"`
+	ValueOptions []string `json:"value_options,omitempty" yaml:"value_options,omitempty"`
+}
+
+// InputType returns the type of the input, string by default
+func (input WorkflowInputModel) InputType() string {
+	if input.Type == "" {
+		return WorkflowInputTypeString
+	}
+	return input.Type
+}
+
+// Validate ...
+func (input WorkflowInputModel) Validate() error {
+	switch input.InputType() {
+	case WorkflowInputTypeString, WorkflowInputTypeBool, WorkflowInputTypeInt, WorkflowInputTypeFloat:
+	default:
+		return fmt.Errorf("invalid input type (%s), available types: string, bool, int, float", input.Type)
+	}
+
+	for _, option := range input.ValueOptions {
+		if err := input.validateType(option); err != nil {
+			return fmt.Errorf("invalid value option (%s): %s", option, err)
+		}
+	}
+
+	if input.Default != "" {
+		if err := input.ValidateValue(input.Default); err != nil {
+			return fmt.Errorf("invalid default value (%s): %s", input.Default, err)
+		}
+	}
+
+	return nil
+}
+
+func (input WorkflowInputModel) validateType(value string) error {
+	var err error
+	switch input.InputType() {
+	case WorkflowInputTypeBool:
+		_, err = strconv.ParseBool(value)
+	case WorkflowInputTypeInt:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case WorkflowInputTypeFloat:
+		_, err = strconv.ParseFloat(value, 64)
+	}
+	if err != nil {
+		return fmt.Errorf("not a valid %s", input.InputType())
+	}
+	return nil
+}
+
+// ValidateValue checks whether the value conforms to the type and the value options of the input
+func (input WorkflowInputModel) ValidateValue(value string) error {
+	if err := input.validateType(value); err != nil {
+		return err
+	}
+
+	if len(input.ValueOptions) == 0 {
+		return nil
+	}
+	for _, option := range input.ValueOptions {
+		if option == value {
+			return nil
+		}
+	}
+	return fmt.Errorf("not in the value options: %v", input.ValueOptions)
+}
+
+func validateWorkflowInputs(inputs map[string]WorkflowInputModel) error {
+	for key, input := range inputs {
+		if key == "" {
+			return errors.New("empty input key")
+		}
+		if err := input.Validate(); err != nil {
+			return NewConfigPathError(key, err)
+		}
+	}
+	return nil
+}
+
+// workflowChain returns the IDs of the workflows, run by the given workflow (including the workflow itself),
+// in run order (before_run workflows, the workflow, after_run workflows).
+func workflowChain(config BitriseDataModel, workflowID string, visited map[string]bool) []string {
+	if visited[workflowID] {
+		return []string{}
+	}
+	visited[workflowID] = true
+
+	workflow := config.Workflows[workflowID]
+
+	chain := []string{}
+	for _, beforeWorkflow := range workflow.BeforeRun {
+		chain = append(chain, workflowChain(config, beforeWorkflow.WorkflowID, visited)...)
+	}
+	chain = append(chain, workflowID)
+	for _, afterWorkflow := range workflow.AfterRun {
+		chain = append(chain, workflowChain(config, afterWorkflow.WorkflowID, visited)...)
+	}
+	return chain
+}
+
+// WorkflowChain returns the IDs of the workflows, run by the given workflow (including the workflow itself), in run order.
+func WorkflowChain(config BitriseDataModel, workflowID string) []string {
+	return workflowChain(config, workflowID, map[string]bool{})
+}
+
+// DeclaredWorkflowInputKeys returns the keys of the inputs, declared by the workflow
+// and by its before_run and after_run workflows.
+func DeclaredWorkflowInputKeys(config BitriseDataModel, workflowID string) []string {
+	keys := []string{}
+	for _, chainWorkflowID := range workflowChain(config, workflowID, map[string]bool{}) {
+		for key := range config.Workflows[chainWorkflowID].Inputs {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ResolveWorkflowInputs validates the provided input values against the inputs, declared by the workflow
+// and by its before_run and after_run workflows, and returns the inputs' envs.
+// The default value is used for the not provided inputs.
+func ResolveWorkflowInputs(config BitriseDataModel, workflowID string, values map[string]string) ([]envmanModels.EnvironmentItemModel, error) {
+	if _, found := config.Workflows[workflowID]; !found {
+		return []envmanModels.EnvironmentItemModel{}, fmt.Errorf("workflow (%s) does not exist", workflowID)
+	}
+
+	resolvedValues := map[string]string{}
+	for _, chainWorkflowID := range workflowChain(config, workflowID, map[string]bool{}) {
+		inputs := config.Workflows[chainWorkflowID].Inputs
+
+		keys := []string{}
+		for key := range inputs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			input := inputs[key]
+
+			value, isProvided := values[key]
+			if !isProvided {
+				value = input.Default
+			}
+
+			if value == "" {
+				if input.IsRequired {
+					return []envmanModels.EnvironmentItemModel{}, fmt.Errorf("input (%s) of workflow (%s) is required", key, chainWorkflowID)
+				}
+			} else if err := input.ValidateValue(value); err != nil {
+				return []envmanModels.EnvironmentItemModel{}, fmt.Errorf("invalid value (%s) for input (%s) of workflow (%s): %s", value, key, chainWorkflowID, err)
+			}
+
+			resolvedValues[key] = value
+		}
+	}
+
+	keys := []string{}
+	for key := range values {
+		if _, found := resolvedValues[key]; !found {
+			return []envmanModels.EnvironmentItemModel{}, fmt.Errorf("input (%s) is not declared by workflow (%s)", key, workflowID)
+		}
+	}
+	for key := range resolvedValues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	environments := []envmanModels.EnvironmentItemModel{}
+	for _, key := range keys {
+		environments = append(environments, envmanModels.EnvironmentItemModel{
+			key: resolvedValues[key],
+			envmanModels.OptionsKey: envmanModels.EnvironmentItemOptionsModel{
+				IsExpand: pointers.NewBoolPtr(false),
+			},
+		})
+	}
+	return environments, nil
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"testing"
+
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func testWorkflowInputsConfig(t *testing.T) BitriseDataModel {
+	configStr := `
+format_version: 1.3.1
+workflows:
+  setup:
+    inputs:
+      XCODE_SCHEME:
+        is_required: true
+  deploy:
+    before_run:
+    - setup
+    inputs:
+      CONFIGURATION:
+        default: Release
+        value_options:
+        - Debug
+        - Release
+      RETRY_COUNT:
+        type: int
+        default: 3
+      IS_DRY_RUN:
+        type: bool
+`
+	config := BitriseDataModel{}
+	require.NoError(t, yaml.Unmarshal([]byte(configStr), &config))
+	return config
+}
+
+func inputValue(t *testing.T, environments []envmanModels.EnvironmentItemModel, key string) string {
+	for _, env := range environments {
+		envKey, value, err := env.GetKeyValuePair()
+		require.NoError(t, err)
+		if envKey == key {
+			return value
+		}
+	}
+	require.FailNow(t, "input not found: "+key)
+	return ""
+}
+
+func TestValidateWorkflowInputs(t *testing.T) {
+	config := testWorkflowInputsConfig(t)
+	_, err := config.Validate()
+	require.NoError(t, err)
+
+	t.Log("invalid type")
+	{
+		require.EqualError(t, WorkflowInputModel{Type: "list"}.Validate(), "invalid input type (list), available types: string, bool, int, float")
+	}
+
+	t.Log("invalid default")
+	{
+		require.EqualError(t, WorkflowInputModel{Type: "int", Default: "three"}.Validate(), "invalid default value (three): not a valid int")
+	}
+
+	t.Log("default is not a value option")
+	{
+		require.EqualError(t, WorkflowInputModel{Default: "Beta", ValueOptions: []string{"Debug", "Release"}}.Validate(), "invalid default value (Beta): not in the value options: [Debug Release]")
+	}
+}
+
+func TestResolveWorkflowInputs(t *testing.T) {
+	config := testWorkflowInputsConfig(t)
+
+	t.Log("defaults and provided values")
+	{
+		environments, err := ResolveWorkflowInputs(config, "deploy", map[string]string{"XCODE_SCHEME": "App", "IS_DRY_RUN": "true"})
+		require.NoError(t, err)
+		require.Equal(t, 4, len(environments))
+		require.Equal(t, "Release", inputValue(t, environments, "CONFIGURATION"))
+		require.Equal(t, "true", inputValue(t, environments, "IS_DRY_RUN"))
+		require.Equal(t, "3", inputValue(t, environments, "RETRY_COUNT"))
+		require.Equal(t, "App", inputValue(t, environments, "XCODE_SCHEME"))
+	}
+
+	t.Log("required input of a before_run workflow")
+	{
+		_, err := ResolveWorkflowInputs(config, "deploy", map[string]string{})
+		require.EqualError(t, err, "input (XCODE_SCHEME) of workflow (setup) is required")
+	}
+
+	t.Log("invalid value")
+	{
+		_, err := ResolveWorkflowInputs(config, "deploy", map[string]string{"XCODE_SCHEME": "App", "RETRY_COUNT": "many"})
+		require.EqualError(t, err, "invalid value (many) for input (RETRY_COUNT) of workflow (deploy): not a valid int")
+
+		_, err = ResolveWorkflowInputs(config, "deploy", map[string]string{"XCODE_SCHEME": "App", "CONFIGURATION": "Beta"})
+		require.EqualError(t, err, "invalid value (Beta) for input (CONFIGURATION) of workflow (deploy): not in the value options: [Debug Release]")
+	}
+
+	t.Log("undeclared input")
+	{
+		_, err := ResolveWorkflowInputs(config, "setup", map[string]string{"XCODE_SCHEME": "App", "CONFIGURATION": "Debug"})
+		require.EqualError(t, err, "input (CONFIGURATION) is not declared by workflow (setup)")
+	}
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Workflow metadata: the optional owner (the team or person, who maintains the workflow)
+// and tags (free form labels, like ios, release, nightly) of the workflows.
+// The metadata is printed in the run's workflow header, and in the workflow listing,
+// which can be filtered by tags.
+
+var workflowTagRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:/-]*$`)
+
+// validateWorkflowOwner ...
+func validateWorkflowOwner(owner string) error {
+	if owner != "" && strings.TrimSpace(owner) == "" {
+		return errors.New("blank owner")
+	}
+	return nil
+}
+
+// validateWorkflowTags ...
+func validateWorkflowTags(tags []string) error {
+	isDefined := map[string]bool{}
+	for idx, tag := range tags {
+		if tag == "" {
+			return NewConfigPathError(fmt.Sprintf("[%d]", idx), errors.New("empty tag"))
+		}
+		if !workflowTagRegexp.MatchString(tag) {
+			return NewConfigPathError(fmt.Sprintf("[%d]", idx), fmt.Errorf("invalid tag (%s), a tag starts with a letter or digit, and contains only letters, digits and the _.:/- characters", tag))
+		}
+		if isDefined[tag] {
+			return NewConfigPathError(fmt.Sprintf("[%d]", idx), fmt.Errorf("duplicated tag (%s)", tag))
+		}
+		isDefined[tag] = true
+	}
+	return nil
+}
+
+// HasTags returns true if the workflow has all of the given tags (case insensitive)
+func (workflow WorkflowModel) HasTags(tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, workflowTag := range workflow.Tags {
+			if strings.EqualFold(workflowTag, tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateWorkflowMeta(t *testing.T) {
+	t.Log("valid owner and tags")
+	{
+		workflow := WorkflowModel{Owner: "mobile-team", Tags: []string{"ios", "release-1.0", "team:mobile"}}
+		_, err := workflow.Validate()
+		require.NoError(t, err)
+	}
+
+	t.Log("blank owner")
+	{
+		workflow := WorkflowModel{Owner: "  "}
+		_, err := workflow.Validate()
+		require.EqualError(t, err, "blank owner")
+		require.Equal(t, "owner", err.(ConfigPathError).Path)
+	}
+
+	t.Log("invalid tags")
+	{
+		workflow := WorkflowModel{Tags: []string{"ios", ""}}
+		_, err := workflow.Validate()
+		require.EqualError(t, err, "empty tag")
+		require.Equal(t, "tags[1]", err.(ConfigPathError).Path)
+
+		workflow = WorkflowModel{Tags: []string{"nightly build"}}
+		_, err = workflow.Validate()
+		require.EqualError(t, err, "invalid tag (nightly build), a tag starts with a letter or digit, and contains only letters, digits and the _.:/- characters")
+
+		workflow = WorkflowModel{Tags: []string{"ios", "ios"}}
+		_, err = workflow.Validate()
+		require.EqualError(t, err, "duplicated tag (ios)")
+	}
+	t.Log("invalid timeout")
+	{
+		workflow := WorkflowModel{TimeoutSecs: -1}
+		_, err := workflow.Validate()
+		require.EqualError(t, err, "invalid timeout (-1), it can't be negative")
+		require.Equal(t, "timeout_secs", err.(ConfigPathError).Path)
+	}
+}
+
+func TestWorkflowHasTags(t *testing.T) {
+	workflow := WorkflowModel{Tags: []string{"ios", "Release"}}
+	require.True(t, workflow.HasTags([]string{}))
+	require.True(t, workflow.HasTags([]string{"release"}))
+	require.True(t, workflow.HasTags([]string{"ios", "release"}))
+	require.False(t, workflow.HasTags([]string{"ios", "android"}))
+	require.False(t, WorkflowModel{}.HasTags([]string{"ios"}))
+}
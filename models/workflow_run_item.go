@@ -0,0 +1,96 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// WorkflowRunItemModel is an item of the before_run / after_run lists:
+// either a workflow ID (- notify), or a workflow ID with a run_if condition
+// (- notify: {run_if: '{{enveq "BITRISE_GIT_BRANCH" "main"}}'}).
+type WorkflowRunItemModel struct {
+	WorkflowID string
+	RunIf      string
+}
+
+type workflowRunItemOptionsModel struct {
+	RunIf string `json:"run_if,omitempty" yaml:"run_if,omitempty"`
+}
+
+// NewWorkflowRunItems creates unconditional run items from the given workflow IDs
+func NewWorkflowRunItems(workflowIDs ...string) []WorkflowRunItemModel {
+	items := []WorkflowRunItemModel{}
+	for _, workflowID := range workflowIDs {
+		items = append(items, WorkflowRunItemModel{WorkflowID: workflowID})
+	}
+	return items
+}
+
+func (item *WorkflowRunItemModel) fromOptionsMap(optionsMap map[string]workflowRunItemOptionsModel) error {
+	if len(optionsMap) != 1 {
+		return fmt.Errorf("a workflow run item should contain exactly one workflow ID, but it contains: %d", len(optionsMap))
+	}
+	for workflowID, options := range optionsMap {
+		item.WorkflowID = workflowID
+		item.RunIf = options.RunIf
+	}
+	if item.WorkflowID == "" {
+		return errors.New("empty workflow ID")
+	}
+	return nil
+}
+
+func (item WorkflowRunItemModel) toOptionsMap() map[string]workflowRunItemOptionsModel {
+	return map[string]workflowRunItemOptionsModel{
+		item.WorkflowID: workflowRunItemOptionsModel{RunIf: item.RunIf},
+	}
+}
+
+// UnmarshalYAML ...
+func (item *WorkflowRunItemModel) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var workflowID string
+	if err := unmarshal(&workflowID); err == nil {
+		item.WorkflowID = workflowID
+		item.RunIf = ""
+		return nil
+	}
+
+	optionsMap := map[string]workflowRunItemOptionsModel{}
+	if err := unmarshal(&optionsMap); err != nil {
+		return err
+	}
+	return item.fromOptionsMap(optionsMap)
+}
+
+// MarshalYAML ...
+func (item WorkflowRunItemModel) MarshalYAML() (interface{}, error) {
+	if item.RunIf == "" {
+		return item.WorkflowID, nil
+	}
+	return item.toOptionsMap(), nil
+}
+
+// UnmarshalJSON ...
+func (item *WorkflowRunItemModel) UnmarshalJSON(data []byte) error {
+	var workflowID string
+	if err := json.Unmarshal(data, &workflowID); err == nil {
+		item.WorkflowID = workflowID
+		item.RunIf = ""
+		return nil
+	}
+
+	optionsMap := map[string]workflowRunItemOptionsModel{}
+	if err := json.Unmarshal(data, &optionsMap); err != nil {
+		return err
+	}
+	return item.fromOptionsMap(optionsMap)
+}
+
+// MarshalJSON ...
+func (item WorkflowRunItemModel) MarshalJSON() ([]byte, error) {
+	if item.RunIf == "" {
+		return json.Marshal(item.WorkflowID)
+	}
+	return json.Marshal(item.toOptionsMap())
+}
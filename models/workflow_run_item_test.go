@@ -0,0 +1,75 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestWorkflowRunItemYAML(t *testing.T) {
+	t.Log("workflow IDs and workflows with run_if")
+	{
+		configStr := `
+before_run:
+- setup
+- notify:
+    run_if: '{{enveq "BITRISE_GIT_BRANCH" "main"}}'
+after_run:
+- cleanup
+`
+
+		workflow := WorkflowModel{}
+		require.NoError(t, yaml.Unmarshal([]byte(configStr), &workflow))
+		require.Equal(t, []WorkflowRunItemModel{
+			WorkflowRunItemModel{WorkflowID: "setup"},
+			WorkflowRunItemModel{WorkflowID: "notify", RunIf: `{{enveq "BITRISE_GIT_BRANCH" "main"}}`},
+		}, workflow.BeforeRun)
+		require.Equal(t, NewWorkflowRunItems("cleanup"), workflow.AfterRun)
+
+		bytes, err := yaml.Marshal(workflow)
+		require.NoError(t, err)
+
+		parsed := WorkflowModel{}
+		require.NoError(t, yaml.Unmarshal(bytes, &parsed))
+		require.Equal(t, workflow.BeforeRun, parsed.BeforeRun)
+		require.Equal(t, workflow.AfterRun, parsed.AfterRun)
+	}
+
+	t.Log("unconditional item is marshalled as workflow ID")
+	{
+		bytes, err := yaml.Marshal(NewWorkflowRunItems("setup"))
+		require.NoError(t, err)
+		require.Equal(t, "- setup\n", string(bytes))
+	}
+
+	t.Log("invalid - more than one workflow ID in an item")
+	{
+		configStr := `
+before_run:
+- setup:
+    run_if: "true"
+  notify:
+    run_if: "true"
+`
+
+		workflow := WorkflowModel{}
+		require.Error(t, yaml.Unmarshal([]byte(configStr), &workflow))
+	}
+}
+
+func TestWorkflowRunItemJSON(t *testing.T) {
+	items := []WorkflowRunItemModel{
+		WorkflowRunItemModel{WorkflowID: "setup"},
+		WorkflowRunItemModel{WorkflowID: "notify", RunIf: ".IsCI"},
+	}
+
+	bytes, err := json.Marshal(items)
+	require.NoError(t, err)
+	require.Equal(t, `["setup",{"notify":{"run_if":".IsCI"}}]`, string(bytes))
+
+	parsed := []WorkflowRunItemModel{}
+	require.NoError(t, json.Unmarshal(bytes, &parsed))
+	require.Equal(t, items, parsed)
+}
@@ -0,0 +1,63 @@
+package models
+
+import "sort"
+
+// Lazy workflow resolution: a run needs only the workflows it runs, the rest of the workflows of a large config
+// don't have to be normalized, validated and filled with the defaults (see: ScopeToWorkflows).
+
+// ReferencedWorkflowIDs returns the IDs of the given workflows and of the workflows they reference (recursively):
+// the before_run and after_run workflows and the workflows they are an alias of, plus the on_abort workflow.
+// The not existing workflows are not included, the IDs are sorted.
+func (config BitriseDataModel) ReferencedWorkflowIDs(workflowIDs []string) []string {
+	queue := append([]string{}, workflowIDs...)
+	if config.OnAbort != "" {
+		queue = append(queue, config.OnAbort)
+	}
+
+	referenced := map[string]bool{}
+	for len(queue) > 0 {
+		workflowID := queue[0]
+		queue = queue[1:]
+
+		workflow, found := config.Workflows[workflowID]
+		if !found || referenced[workflowID] {
+			continue
+		}
+		referenced[workflowID] = true
+
+		if workflow.IsAlias() {
+			queue = append(queue, workflow.AliasOf)
+		}
+		for _, item := range workflow.BeforeRun {
+			queue = append(queue, item.WorkflowID)
+		}
+		for _, item := range workflow.AfterRun {
+			queue = append(queue, item.WorkflowID)
+		}
+	}
+
+	referencedIDs := []string{}
+	for workflowID := range referenced {
+		referencedIDs = append(referencedIDs, workflowID)
+	}
+	sort.Strings(referencedIDs)
+	return referencedIDs
+}
+
+// ScopeToWorkflows removes the workflows from the config, which are not referenced by the given workflows
+// (see: ReferencedWorkflowIDs). Returns false, and keeps every workflow, if any of the given workflows does not exist,
+// so the error of the missing workflow can list the available ones.
+func (config *BitriseDataModel) ScopeToWorkflows(workflowIDs []string) bool {
+	for _, workflowID := range workflowIDs {
+		if _, found := config.Workflows[workflowID]; !found {
+			return false
+		}
+	}
+
+	workflows := map[string]WorkflowModel{}
+	for _, workflowID := range config.ReferencedWorkflowIDs(workflowIDs) {
+		workflows[workflowID] = config.Workflows[workflowID]
+	}
+	config.Workflows = workflows
+	return true
+}
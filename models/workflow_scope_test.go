@@ -0,0 +1,78 @@
+package models
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testWorkflowScopeConfig = `
+format_version: 1.3.1
+on_abort: cleanup
+workflows:
+  _setup:
+    steps:
+    - script: {}
+  _notify:
+    steps:
+    - script: {}
+  cleanup:
+    steps:
+    - script: {}
+  primary-ci:
+    before_run:
+    - _setup
+    after_run:
+    - _notify
+    steps:
+    - script: {}
+  primary:
+    alias_of: primary-ci
+  deploy:
+    before_run:
+    - _setup
+    steps:
+    - script: {}
+`
+
+func TestReferencedWorkflowIDs(t *testing.T) {
+	config := testWorkflowAliasesConfig(t, testWorkflowScopeConfig)
+
+	t.Log("before_run, after_run, alias_of and on_abort")
+	{
+		require.Equal(t, []string{"_notify", "_setup", "cleanup", "primary", "primary-ci"}, config.ReferencedWorkflowIDs([]string{"primary"}))
+	}
+
+	t.Log("multiple workflows")
+	{
+		require.Equal(t, []string{"_notify", "_setup", "cleanup", "deploy", "primary-ci"}, config.ReferencedWorkflowIDs([]string{"deploy", "primary-ci"}))
+	}
+
+	t.Log("not existing workflow")
+	{
+		require.Equal(t, []string{"cleanup"}, config.ReferencedWorkflowIDs([]string{"not-existing"}))
+	}
+}
+
+func TestScopeToWorkflows(t *testing.T) {
+	t.Log("keeps the referenced workflows")
+	{
+		config := testWorkflowAliasesConfig(t, testWorkflowScopeConfig)
+		require.Equal(t, true, config.ScopeToWorkflows([]string{"deploy"}))
+
+		workflowIDs := []string{}
+		for workflowID := range config.Workflows {
+			workflowIDs = append(workflowIDs, workflowID)
+		}
+		sort.Strings(workflowIDs)
+		require.Equal(t, []string{"_setup", "cleanup", "deploy"}, workflowIDs)
+	}
+
+	t.Log("not existing workflow")
+	{
+		config := testWorkflowAliasesConfig(t, testWorkflowScopeConfig)
+		require.Equal(t, false, config.ScopeToWorkflows([]string{"deploy", "not-existing"}))
+		require.Equal(t, 6, len(config.Workflows))
+	}
+}
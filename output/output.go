@@ -19,6 +19,10 @@ const (
 	FormatJSON = "json"
 	// FormatYML ...
 	FormatYML = "yml"
+	// FormatMarkdown ...
+	FormatMarkdown = "markdown"
+	// FormatJUnit ...
+	FormatJUnit = "junit"
 )
 
 // Format ...
@@ -0,0 +1,191 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Console theme: the colored console messages of the CLI are formatted by this package (output.Red, output.Greenf, ...),
+// with the escape sequences of the active theme:
+//  * default: the classic bitrise colors
+//  * high-contrast: bold, bright colors, readable on both dark and light terminal backgrounds
+//  * plain: no colors (no ANSI escape sequences at all), for CI logs and log files
+// The plain theme is used whatever theme is selected, if the NO_COLOR env is set (https://no-color.org) or --no-ansi is passed.
+
+const (
+	// ThemeDefault ...
+	ThemeDefault = "default"
+	// ThemeHighContrast ...
+	ThemeHighContrast = "high-contrast"
+	// ThemePlain ...
+	ThemePlain = "plain"
+
+	// ThemeEnvKey ...
+	ThemeEnvKey = "BITRISE_THEME"
+	// NoColorEnvKey disables the colors, if set to any value (https://no-color.org)
+	NoColorEnvKey = "NO_COLOR"
+)
+
+// Color is the role of a colored console message, the theme decides how it's displayed
+type Color string
+
+const (
+	// ColorRed ...
+	ColorRed Color = "red"
+	// ColorGreen ...
+	ColorGreen Color = "green"
+	// ColorYellow ...
+	ColorYellow Color = "yellow"
+	// ColorBlue ...
+	ColorBlue Color = "blue"
+	// ColorCyan ...
+	ColorCyan Color = "cyan"
+)
+
+const ansiReset = "\x1b[0m"
+
+// ThemeModel maps the colors to ANSI escape sequences, a color without sequence is printed as it is
+type ThemeModel struct {
+	Name   string
+	Colors map[Color]string
+}
+
+var themes = map[string]ThemeModel{
+	ThemeDefault: ThemeModel{
+		Name: ThemeDefault,
+		Colors: map[Color]string{
+			ColorRed:    "\x1b[31;1m",
+			ColorGreen:  "\x1b[32;1m",
+			ColorYellow: "\x1b[33;1m",
+			ColorBlue:   "\x1b[34;1m",
+			ColorCyan:   "\x1b[36;1m",
+		},
+	},
+	ThemeHighContrast: ThemeModel{
+		Name: ThemeHighContrast,
+		Colors: map[Color]string{
+			ColorRed:    "\x1b[91;1m",
+			ColorGreen:  "\x1b[92;1m",
+			ColorYellow: "\x1b[93;1m",
+			// the dark blue is hard to read on dark backgrounds
+			ColorBlue: "\x1b[96;1m",
+			ColorCyan: "\x1b[97;1;4m",
+		},
+	},
+	ThemePlain: ThemeModel{
+		Name:   ThemePlain,
+		Colors: map[Color]string{},
+	},
+}
+
+var activeTheme = themes[ThemeDefault]
+
+var ansiEscapeRegexp = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// ThemeNames returns the names of the available themes
+func ThemeNames() []string {
+	names := []string{}
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ActiveTheme ...
+func ActiveTheme() ThemeModel {
+	return activeTheme
+}
+
+// SetTheme activates the theme
+func SetTheme(name string) error {
+	theme, found := themes[name]
+	if !found {
+		return fmt.Errorf("invalid theme (%s), available: %s", name, strings.Join(ThemeNames(), ", "))
+	}
+	activeTheme = theme
+	return nil
+}
+
+// ConfigureTheme activates the selected theme (the default theme, if empty),
+// or the plain theme, if the NO_COLOR env is set or the ANSI output is disabled.
+func ConfigureTheme(themeName string, isNoANSI bool) error {
+	if isNoANSI || os.Getenv(NoColorEnvKey) != "" {
+		themeName = ThemePlain
+	} else if themeName == "" {
+		themeName = ThemeDefault
+	}
+	return SetTheme(themeName)
+}
+
+// IsANSIEnabled returns false if the console output must not contain ANSI escape sequences
+func IsANSIEnabled() bool {
+	return activeTheme.Name != ThemePlain
+}
+
+// StripANSI removes the ANSI escape sequences (e.g. colors) of the string
+func StripANSI(str string) string {
+	return ansiEscapeRegexp.ReplaceAllString(str, "")
+}
+
+// Colorize returns the message in the color of the active theme
+func Colorize(color Color, msg string) string {
+	sequence := activeTheme.Colors[color]
+	if sequence == "" {
+		return msg
+	}
+	return sequence + msg + ansiReset
+}
+
+// Red ...
+func Red(a ...interface{}) string {
+	return Colorize(ColorRed, fmt.Sprint(a...))
+}
+
+// Green ...
+func Green(a ...interface{}) string {
+	return Colorize(ColorGreen, fmt.Sprint(a...))
+}
+
+// Yellow ...
+func Yellow(a ...interface{}) string {
+	return Colorize(ColorYellow, fmt.Sprint(a...))
+}
+
+// Blue ...
+func Blue(a ...interface{}) string {
+	return Colorize(ColorBlue, fmt.Sprint(a...))
+}
+
+// Cyan ...
+func Cyan(a ...interface{}) string {
+	return Colorize(ColorCyan, fmt.Sprint(a...))
+}
+
+// Redf ...
+func Redf(format string, a ...interface{}) string {
+	return Colorize(ColorRed, fmt.Sprintf(format, a...))
+}
+
+// Greenf ...
+func Greenf(format string, a ...interface{}) string {
+	return Colorize(ColorGreen, fmt.Sprintf(format, a...))
+}
+
+// Yellowf ...
+func Yellowf(format string, a ...interface{}) string {
+	return Colorize(ColorYellow, fmt.Sprintf(format, a...))
+}
+
+// Bluef ...
+func Bluef(format string, a ...interface{}) string {
+	return Colorize(ColorBlue, fmt.Sprintf(format, a...))
+}
+
+// Cyanf ...
+func Cyanf(format string, a ...interface{}) string {
+	return Colorize(ColorCyan, fmt.Sprintf(format, a...))
+}
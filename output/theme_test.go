@@ -0,0 +1,58 @@
+package output
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureTheme(t *testing.T) {
+	originalNoColor := os.Getenv(NoColorEnvKey)
+	defer func() {
+		require.NoError(t, os.Setenv(NoColorEnvKey, originalNoColor))
+		require.NoError(t, SetTheme(ThemeDefault))
+	}()
+	require.NoError(t, os.Unsetenv(NoColorEnvKey))
+
+	t.Log("default theme")
+	{
+		require.NoError(t, ConfigureTheme("", false))
+		require.Equal(t, true, IsANSIEnabled())
+		require.Equal(t, "\x1b[31;1mfailed\x1b[0m", Red("failed"))
+		require.Equal(t, "\x1b[32;1mstep: 1\x1b[0m", Greenf("step: %d", 1))
+	}
+
+	t.Log("high-contrast theme")
+	{
+		require.NoError(t, ConfigureTheme(ThemeHighContrast, false))
+		require.Equal(t, true, IsANSIEnabled())
+		require.Equal(t, "\x1b[91;1mfailed\x1b[0m", Red("failed"))
+	}
+
+	t.Log("--no-ansi overrides the selected theme")
+	{
+		require.NoError(t, ConfigureTheme(ThemeHighContrast, true))
+		require.Equal(t, ThemePlain, ActiveTheme().Name)
+		require.Equal(t, false, IsANSIEnabled())
+		require.Equal(t, "failed", Red("failed"))
+	}
+
+	t.Log("NO_COLOR overrides the selected theme")
+	{
+		require.NoError(t, os.Setenv(NoColorEnvKey, "1"))
+		require.NoError(t, ConfigureTheme(ThemeDefault, false))
+		require.Equal(t, ThemePlain, ActiveTheme().Name)
+		require.Equal(t, "step: 1", Yellowf("step: %d", 1))
+		require.NoError(t, os.Unsetenv(NoColorEnvKey))
+	}
+
+	t.Log("invalid theme")
+	{
+		require.EqualError(t, ConfigureTheme("neon", false), "invalid theme (neon), available: default, high-contrast, plain")
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	require.Equal(t, "green text, cleared line", StripANSI("\x1b[32;1mgreen text\x1b[0m, cleared line\x1b[2K"))
+}
@@ -13,8 +13,19 @@ const (
 	DidFinishRun TriggerEventName = "DidFinishRun"
 )
 
+// disabledEventPlugins are not triggered by the events (e.g. the telemetry plugins, disabled by the organization policy)
+var disabledEventPlugins = map[string]bool{}
+
+// DisableEventPlugin disables the triggering of the given plugin by the events.
+func DisableEventPlugin(name string) {
+	disabledEventPlugins[name] = true
+}
+
 // TriggerEvent ...
 func TriggerEvent(name TriggerEventName, payload interface{}) error {
+	// In-process plugins
+	triggerInProcessEvent(name, payload)
+
 	// Create plugin input
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
@@ -51,6 +62,9 @@ func LoadPlugins(eventName string) ([]Plugin, error) {
 
 	pluginNames := []string{}
 	for name, route := range routing.RouteMap {
+		if disabledEventPlugins[name] {
+			continue
+		}
 		if route.TriggerEvent == eventName {
 			pluginNames = append(pluginNames, name)
 		}
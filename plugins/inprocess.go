@@ -0,0 +1,136 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/models"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// InProcessPlugin is the base interface of the Go extensions,
+// which are compiled into the CLI (or loaded by a go-plugin host)
+// instead of running as an external plugin binary.
+// An InProcessPlugin should implement one or more of the handler interfaces below.
+type InProcessPlugin interface {
+	Name() string
+}
+
+// EventHandler receives every event triggered by TriggerEvent (e.g. DidFinishRun).
+type EventHandler interface {
+	HandleEvent(name TriggerEventName, payload interface{}) error
+}
+
+// ConfigHandler gets access to the config (bitrise.yml), which will be used for the run.
+type ConfigHandler interface {
+	ConfigDidLoad(config models.BitriseDataModel) error
+}
+
+// StepLifecycleHandler is called before and after every step run.
+type StepLifecycleHandler interface {
+	StepWillStart(stepInfo stepmanModels.StepInfoModel, step stepmanModels.StepModel, idx int) error
+	StepDidFinish(result models.StepRunResultsModel) error
+}
+
+var (
+	inProcessPluginsMutex sync.Mutex
+	inProcessPlugins      = map[string]InProcessPlugin{}
+)
+
+// RegisterInProcessPlugin ...
+func RegisterInProcessPlugin(plugin InProcessPlugin) error {
+	inProcessPluginsMutex.Lock()
+	defer inProcessPluginsMutex.Unlock()
+
+	name := plugin.Name()
+	if name == "" {
+		return fmt.Errorf("in-process plugin name is empty")
+	}
+	if _, found := inProcessPlugins[name]; found {
+		return fmt.Errorf("in-process plugin (%s) already registered", name)
+	}
+
+	inProcessPlugins[name] = plugin
+	return nil
+}
+
+// UnregisterInProcessPlugin ...
+func UnregisterInProcessPlugin(name string) {
+	inProcessPluginsMutex.Lock()
+	defer inProcessPluginsMutex.Unlock()
+
+	delete(inProcessPlugins, name)
+}
+
+// InProcessPlugins returns the registered in-process plugins, sorted by name.
+func InProcessPlugins() []InProcessPlugin {
+	inProcessPluginsMutex.Lock()
+	defer inProcessPluginsMutex.Unlock()
+
+	names := []string{}
+	for name := range inProcessPlugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	plugins := []InProcessPlugin{}
+	for _, name := range names {
+		plugins = append(plugins, inProcessPlugins[name])
+	}
+	return plugins
+}
+
+// triggerInProcessEvent passes the event to every in-process plugin,
+// a failing plugin doesn't prevent the rest of the plugins (incl. the installed ones) from handling the event
+func triggerInProcessEvent(name TriggerEventName, payload interface{}) {
+	for _, plugin := range InProcessPlugins() {
+		handler, ok := plugin.(EventHandler)
+		if !ok {
+			continue
+		}
+		if err := handler.HandleEvent(name, payload); err != nil {
+			log.Warnf("In-process plugin (%s) failed to handle event (%s), error: %s", plugin.Name(), name, err)
+		}
+	}
+}
+
+// TriggerConfigDidLoad ...
+func TriggerConfigDidLoad(config models.BitriseDataModel) {
+	for _, plugin := range InProcessPlugins() {
+		handler, ok := plugin.(ConfigHandler)
+		if !ok {
+			continue
+		}
+		if err := handler.ConfigDidLoad(config); err != nil {
+			log.Warnf("In-process plugin (%s) failed to handle config, error: %s", plugin.Name(), err)
+		}
+	}
+}
+
+// TriggerStepWillStart ...
+func TriggerStepWillStart(stepInfo stepmanModels.StepInfoModel, step stepmanModels.StepModel, idx int) {
+	for _, plugin := range InProcessPlugins() {
+		handler, ok := plugin.(StepLifecycleHandler)
+		if !ok {
+			continue
+		}
+		if err := handler.StepWillStart(stepInfo, step, idx); err != nil {
+			log.Warnf("In-process plugin (%s) failed to handle step start, error: %s", plugin.Name(), err)
+		}
+	}
+}
+
+// TriggerStepDidFinish ...
+func TriggerStepDidFinish(result models.StepRunResultsModel) {
+	for _, plugin := range InProcessPlugins() {
+		handler, ok := plugin.(StepLifecycleHandler)
+		if !ok {
+			continue
+		}
+		if err := handler.StepDidFinish(result); err != nil {
+			log.Warnf("In-process plugin (%s) failed to handle step finish, error: %s", plugin.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package plugins
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/models"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+type testInProcessPlugin struct {
+	name          string
+	events        []TriggerEventName
+	startedSteps  []int
+	finishedSteps []int
+	eventErr      error
+}
+
+func (plugin *testInProcessPlugin) Name() string {
+	return plugin.name
+}
+
+func (plugin *testInProcessPlugin) HandleEvent(name TriggerEventName, payload interface{}) error {
+	plugin.events = append(plugin.events, name)
+	return plugin.eventErr
+}
+
+func (plugin *testInProcessPlugin) StepWillStart(stepInfo stepmanModels.StepInfoModel, step stepmanModels.StepModel, idx int) error {
+	plugin.startedSteps = append(plugin.startedSteps, idx)
+	return nil
+}
+
+func (plugin *testInProcessPlugin) StepDidFinish(result models.StepRunResultsModel) error {
+	plugin.finishedSteps = append(plugin.finishedSteps, result.Idx)
+	return nil
+}
+
+func TestRegisterInProcessPlugin(t *testing.T) {
+	t.Log("register")
+	{
+		plugin := &testInProcessPlugin{name: "test"}
+		require.NoError(t, RegisterInProcessPlugin(plugin))
+
+		require.Equal(t, 1, len(InProcessPlugins()))
+		require.Equal(t, "test", InProcessPlugins()[0].Name())
+
+		UnregisterInProcessPlugin("test")
+		require.Equal(t, 0, len(InProcessPlugins()))
+	}
+
+	t.Log("already registered")
+	{
+		require.NoError(t, RegisterInProcessPlugin(&testInProcessPlugin{name: "test"}))
+		require.Error(t, RegisterInProcessPlugin(&testInProcessPlugin{name: "test"}))
+
+		UnregisterInProcessPlugin("test")
+	}
+
+	t.Log("empty name")
+	{
+		require.Error(t, RegisterInProcessPlugin(&testInProcessPlugin{}))
+	}
+}
+
+func TestInProcessPluginHandlers(t *testing.T) {
+	plugin := &testInProcessPlugin{name: "test"}
+	require.NoError(t, RegisterInProcessPlugin(plugin))
+	defer UnregisterInProcessPlugin("test")
+
+	t.Log("event")
+	{
+		triggerInProcessEvent(DidFinishRun, models.BuildRunResultsModel{})
+		require.Equal(t, []TriggerEventName{DidFinishRun}, plugin.events)
+	}
+
+	t.Log("event error")
+	{
+		failingPlugin := &testInProcessPlugin{name: "failing", eventErr: errors.New("failed")}
+		require.NoError(t, RegisterInProcessPlugin(failingPlugin))
+		defer UnregisterInProcessPlugin("failing")
+
+		// the failing plugin is sorted before the other plugin, which still handles the event
+		triggerInProcessEvent(DidFinishRun, models.BuildRunResultsModel{})
+		require.Equal(t, []TriggerEventName{DidFinishRun}, failingPlugin.events)
+		require.Equal(t, []TriggerEventName{DidFinishRun, DidFinishRun}, plugin.events)
+	}
+
+	t.Log("step lifecycle")
+	{
+		TriggerStepWillStart(stepmanModels.StepInfoModel{}, stepmanModels.StepModel{}, 2)
+		TriggerStepDidFinish(models.StepRunResultsModel{Idx: 2})
+		require.Equal(t, []int{2}, plugin.startedSteps)
+		require.Equal(t, []int{2}, plugin.finishedSteps)
+	}
+}
@@ -2,14 +2,13 @@ package plugins
 
 import (
 	"fmt"
-	"io"
-	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/tools"
 	"github.com/bitrise-io/bitrise/version"
 	"github.com/bitrise-io/go-utils/cmdex"
 	"github.com/bitrise-io/go-utils/pathutil"
@@ -73,16 +72,36 @@ func validateRequirements(requirements []Requirement, currentVersionMap map[stri
 	return nil
 }
 
-func clonePluginSrc(sourceURL, versionTag, destinationDir string) (*ver.Version, string, error) {
+// LocalPluginSourceDir returns the plugin source's local directory path,
+// if the source is a file:// url or a path of an existing local directory.
+func LocalPluginSourceDir(sourceURL string) (string, bool) {
 	url, err := url.Parse(sourceURL)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to parse url (%s), error: %s", sourceURL, err)
+		return "", false
 	}
 
-	// Download local source dir
+	sourceDir := ""
 	if url.Scheme == "file" {
-		sourceDir := strings.Replace(sourceURL, url.Scheme+"://", "", -1)
+		sourceDir = strings.Replace(sourceURL, url.Scheme+"://", "", -1)
+	} else if url.Scheme == "" {
+		if exist, err := pathutil.IsDirExists(sourceURL); err != nil || !exist {
+			return "", false
+		}
+		sourceDir = sourceURL
+	} else {
+		return "", false
+	}
+
+	absSourceDir, err := pathutil.AbsPath(sourceDir)
+	if err != nil {
+		return "", false
+	}
+	return absSourceDir, true
+}
 
+func clonePluginSrc(sourceURL, versionTag, destinationDir string) (*ver.Version, string, error) {
+	// Download local source dir
+	if sourceDir, isLocal := LocalPluginSourceDir(sourceURL); isLocal {
 		if err := cmdex.CopyDir(sourceDir, destinationDir, true); err != nil {
 			return nil, "", fmt.Errorf("failed to copy (%s) to (%s), error: %s", sourceDir, destinationDir, err)
 		}
@@ -117,32 +136,7 @@ func downloadPluginBin(sourceURL, destinationPth string) error {
 	}
 
 	// Download remote binary
-	out, err := os.Create(destinationPth)
-	defer func() {
-		if err := out.Close(); err != nil {
-			log.Warnf("failed to close (%s)", destinationPth)
-		}
-	}()
-	if err != nil {
-		return fmt.Errorf("failed to create (%s), error: %s", destinationPth, err)
-	}
-
-	resp, err := http.Get(sourceURL)
-	if err != nil {
-		return fmt.Errorf("failed to download from (%s), error: %s", sourceURL, err)
-	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			log.Warnf("failed to close (%s) body", sourceURL)
-		}
-	}()
-
-	_, err = io.Copy(out, resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to download from (%s), error: %s", sourceURL, err)
-	}
-
-	return nil
+	return tools.DownloadFile(sourceURL, destinationPth)
 }
 
 //=======================================
@@ -151,24 +145,54 @@ func downloadPluginBin(sourceURL, destinationPth string) error {
 
 // InstallPlugin ...
 func InstallPlugin(srcURL, binURL, versionTag string) (Plugin, string, error) {
-	//
-	// Download plugin src
-	pluginSrcTmpDir, err := pathutil.NormalizedOSTempDirPath("plugin-src-tmp")
-	if err != nil {
-		return Plugin{}, "", fmt.Errorf("failed to create plugin src temp directory, error: %s", err)
-	}
-	defer func() {
-		if err := os.RemoveAll(pluginSrcTmpDir); err != nil {
-			log.Warnf("Failed to remove path (%s)", pluginSrcTmpDir)
+	return installPlugin(srcURL, binURL, versionTag, false)
+}
+
+// LinkPlugin installs a plugin from a local directory, by symlinking the plugin source
+// instead of copying it, so every change in the directory takes effect without re-installing the plugin.
+func LinkPlugin(srcDir, binURL string) (Plugin, string, error) {
+	return installPlugin(srcDir, binURL, "", true)
+}
+
+func installPlugin(srcURL, binURL, versionTag string, isLink bool) (Plugin, string, error) {
+	var newVersionPtr *ver.Version
+	newVersinHash := ""
+	pluginSrcTmpDir := ""
+
+	if isLink {
+		sourceDir, isLocal := LocalPluginSourceDir(srcURL)
+		if !isLocal {
+			return Plugin{}, "", fmt.Errorf("plugin source (%s) is not a local directory, only local plugins can be linked", srcURL)
 		}
-	}()
 
-	newVersionPtr, newVersinHash, err := clonePluginSrc(srcURL, versionTag, pluginSrcTmpDir)
-	if err != nil {
-		return Plugin{}, "", fmt.Errorf("failed to download plugin, error: %s", err)
-	}
+		// the linked source dir is used directly, it should not be removed
+		pluginSrcTmpDir = sourceDir
+		srcURL = "file://" + sourceDir
+	} else {
+		//
+		// Download plugin src
+		tmpDir, err := pathutil.NormalizedOSTempDirPath("plugin-src-tmp")
+		if err != nil {
+			return Plugin{}, "", fmt.Errorf("failed to create plugin src temp directory, error: %s", err)
+		}
+		defer func() {
+			if err := os.RemoveAll(tmpDir); err != nil {
+				log.Warnf("Failed to remove path (%s)", tmpDir)
+			}
+		}()
+		pluginSrcTmpDir = tmpDir
+
+		if sourceDir, isLocal := LocalPluginSourceDir(srcURL); isLocal {
+			srcURL = "file://" + sourceDir
+		}
+
+		newVersionPtr, newVersinHash, err = clonePluginSrc(srcURL, versionTag, pluginSrcTmpDir)
+		if err != nil {
+			return Plugin{}, "", fmt.Errorf("failed to download plugin, error: %s", err)
+		}
 
-	log.Debugf("Plugin downloaded from (%s) to (%s)", srcURL, pluginSrcTmpDir)
+		log.Debugf("Plugin downloaded from (%s) to (%s)", srcURL, pluginSrcTmpDir)
+	}
 
 	//
 	// Parse and validate plugin.yml
@@ -256,14 +280,26 @@ func InstallPlugin(srcURL, binURL, versionTag string) (Plugin, string, error) {
 	// Install plugin src
 	plginSrcDir := GetPluginSrcDir(newPlugin.Name)
 
-	if err := os.MkdirAll(plginSrcDir, 0777); err != nil {
-		installSuccess = false
-		return Plugin{}, "", fmt.Errorf("failed to create plugin src dir (%s), error: %s", plginSrcDir, err)
-	}
+	if isLink {
+		if err := os.MkdirAll(pluginDir, 0777); err != nil {
+			installSuccess = false
+			return Plugin{}, "", fmt.Errorf("failed to create plugin dir (%s), error: %s", pluginDir, err)
+		}
 
-	if err := cmdex.CopyDir(pluginSrcTmpDir, plginSrcDir, true); err != nil {
-		installSuccess = false
-		return Plugin{}, "", fmt.Errorf("failed to copy plugin from temp dir (%s) to (%s), error: %s", pluginSrcTmpDir, plginSrcDir, err)
+		if err := os.Symlink(pluginSrcTmpDir, plginSrcDir); err != nil {
+			installSuccess = false
+			return Plugin{}, "", fmt.Errorf("failed to link plugin source (%s) to (%s), error: %s", pluginSrcTmpDir, plginSrcDir, err)
+		}
+	} else {
+		if err := os.MkdirAll(plginSrcDir, 0777); err != nil {
+			installSuccess = false
+			return Plugin{}, "", fmt.Errorf("failed to create plugin src dir (%s), error: %s", plginSrcDir, err)
+		}
+
+		if err := cmdex.CopyDir(pluginSrcTmpDir, plginSrcDir, true); err != nil {
+			installSuccess = false
+			return Plugin{}, "", fmt.Errorf("failed to copy plugin from temp dir (%s) to (%s), error: %s", pluginSrcTmpDir, plginSrcDir, err)
+		}
 	}
 
 	executableURL := newPlugin.ExecutableURL()
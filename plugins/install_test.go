@@ -240,3 +240,37 @@ func TestDownloadPluginBin(t *testing.T) {
 		require.Equal(t, true, exist)
 	}
 }
+
+func TestLocalPluginSourceDir(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("local_plugin_src")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	t.Log("file url")
+	{
+		dir, isLocal := LocalPluginSourceDir("file://" + tmpDir)
+		require.Equal(t, true, isLocal)
+		require.Equal(t, tmpDir, dir)
+	}
+
+	t.Log("local path")
+	{
+		dir, isLocal := LocalPluginSourceDir(tmpDir)
+		require.Equal(t, true, isLocal)
+		require.Equal(t, tmpDir, dir)
+	}
+
+	t.Log("not existing local path")
+	{
+		_, isLocal := LocalPluginSourceDir(filepath.Join(tmpDir, "not-existing"))
+		require.Equal(t, false, isLocal)
+	}
+
+	t.Log("git url")
+	{
+		_, isLocal := LocalPluginSourceDir(examplePluginGitURL)
+		require.Equal(t, false, isLocal)
+	}
+}
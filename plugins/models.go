@@ -34,6 +34,7 @@ type Plugin struct {
 		Linux string `yaml:"linux"`
 	}
 	TriggerEvent string        `yaml:"trigger"`
+	StepKinds    []string      `yaml:"step_kinds"`
 	Requirements []Requirement `yaml:"requirements"`
 }
 
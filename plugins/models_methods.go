@@ -6,8 +6,8 @@ import (
 
 	"gopkg.in/yaml.v2"
 
+	"github.com/bitrise-io/bitrise/output"
 	"github.com/bitrise-io/go-utils/cmdex"
-	"github.com/bitrise-io/go-utils/colorstring"
 	"github.com/bitrise-io/go-utils/fileutil"
 	"github.com/bitrise-io/go-utils/pathutil"
 	ver "github.com/hashicorp/go-version"
@@ -53,7 +53,7 @@ func (plugin Plugin) Validate() error {
 }
 
 func (plugin Plugin) String() string {
-	pluginStr := colorstring.Green(plugin.Name)
+	pluginStr := output.Green(plugin.Name)
 	pluginStr += fmt.Sprintf("\n  Description: %s", plugin.Description)
 	return pluginStr
 }
@@ -118,6 +118,11 @@ func readPluginRouting() (PluginRouting, error) {
 // --- Paths
 // -----------------------
 
+// GetPluginsDir ...
+func GetPluginsDir() string {
+	return pluginsDir
+}
+
 // GetPluginDir ...
 func GetPluginDir(name string) string {
 	return filepath.Join(pluginsDir, name)
@@ -22,8 +22,9 @@ const (
 const bitrisePluginPrefix = ":"
 
 const (
-	triggerMode PluginMode = "trigger"
-	commandMode PluginMode = "command"
+	triggerMode  PluginMode = "trigger"
+	commandMode  PluginMode = "command"
+	stepKindMode PluginMode = "step_kind"
 )
 
 // PluginMode ...
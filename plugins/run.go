@@ -85,7 +85,8 @@ func command(dir, name string, args ...string) error {
 func RunPluginByEvent(plugin Plugin, pluginInput PluginInput) error {
 	pluginInput[pluginInputPluginModeKey] = string(triggerMode)
 
-	return runPlugin(plugin, []string{}, pluginInput)
+	_, err := runPlugin(plugin, []string{}, pluginInput)
+	return err
 }
 
 // RunPluginByCommand ...
@@ -94,10 +95,12 @@ func RunPluginByCommand(plugin Plugin, args []string) error {
 		pluginInputPluginModeKey: string(commandMode),
 	}
 
-	return runPlugin(plugin, args, pluginInput)
+	_, err := runPlugin(plugin, args, pluginInput)
+	return err
 }
 
-func runPlugin(plugin Plugin, args []string, pluginInput PluginInput) error {
+// runPlugin runs the plugin and returns its output (BITRISE_PLUGIN_OUTPUT)
+func runPlugin(plugin Plugin, args []string, pluginInput PluginInput) (string, error) {
 	if !configs.IsCIMode && configs.CheckIsPluginUpdateCheckRequired() {
 		// Check for new version
 		log.Infof("Checking for plugin (%s) new version...", plugin.Name)
@@ -111,33 +114,33 @@ func runPlugin(plugin Plugin, args []string, pluginInput PluginInput) error {
 
 			route, found, err := ReadPluginRoute(plugin.Name)
 			if err != nil {
-				return err
+				return "", err
 			}
 			if !found {
-				return fmt.Errorf("no route found for already loaded plugin (%s)", plugin.Name)
+				return "", fmt.Errorf("no route found for already loaded plugin (%s)", plugin.Name)
 			}
 
 			route.LatestAvailableVersion = newVersion
 
 			if err := AddPluginRoute(route); err != nil {
-				return fmt.Errorf("failed to register available plugin (%s) update (%s), error: %s", plugin.Name, newVersion, err)
+				return "", fmt.Errorf("failed to register available plugin (%s) update (%s), error: %s", plugin.Name, newVersion, err)
 			}
 		} else {
 			log.Debugf("No new version of plugin (%s) available", plugin.Name)
 		}
 
 		if err := configs.SavePluginUpdateCheck(); err != nil {
-			return err
+			return "", err
 		}
 
 		fmt.Println()
 	} else {
 		route, found, err := ReadPluginRoute(plugin.Name)
 		if err != nil {
-			return err
+			return "", err
 		}
 		if !found {
-			return fmt.Errorf("no route found for already loaded plugin (%s)", plugin.Name)
+			return "", fmt.Errorf("no route found for already loaded plugin (%s)", plugin.Name)
 		}
 
 		if route.LatestAvailableVersion != "" {
@@ -149,7 +152,7 @@ func runPlugin(plugin Plugin, args []string, pluginInput PluginInput) error {
 	// Append common data to plugin iputs
 	bitriseVersion, err := version.BitriseCliVersion()
 	if err != nil {
-		return err
+		return "", err
 	}
 	pluginInput[pluginInputBitriseVersionKey] = bitriseVersion.String()
 	pluginInput[pluginInputDataDirKey] = GetPluginDataDir(plugin.Name)
@@ -157,7 +160,7 @@ func runPlugin(plugin Plugin, args []string, pluginInput PluginInput) error {
 	// Prepare plugin envstore
 	pluginWorkDir, err := pathutil.NormalizedOSTempDirPath("plugin-work-dir")
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer func() {
 		if err := os.RemoveAll(pluginWorkDir); err != nil {
@@ -168,11 +171,11 @@ func runPlugin(plugin Plugin, args []string, pluginInput PluginInput) error {
 	pluginEnvstorePath := filepath.Join(pluginWorkDir, "envstore.yml")
 
 	if err := tools.EnvmanInitAtPath(pluginEnvstorePath); err != nil {
-		return err
+		return "", err
 	}
 
 	if err := tools.EnvmanAdd(pluginEnvstorePath, configs.EnvstorePathEnvKey, pluginEnvstorePath, false, false); err != nil {
-		return err
+		return "", err
 	}
 
 	log.Debugf("plugin evstore path (%s)", pluginEnvstorePath)
@@ -180,14 +183,14 @@ func runPlugin(plugin Plugin, args []string, pluginInput PluginInput) error {
 	// Add plugin inputs
 	for key, value := range pluginInput {
 		if err := tools.EnvmanAdd(pluginEnvstorePath, key, value, false, false); err != nil {
-			return err
+			return "", err
 		}
 	}
 
 	// Run plugin executable
 	pluginExecutable, isBin, err := GetPluginExecutablePath(plugin.Name)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	cmd := []string{}
@@ -203,18 +206,18 @@ func runPlugin(plugin Plugin, args []string, pluginInput PluginInput) error {
 	exitCode, err := tools.EnvmanRun(pluginEnvstorePath, "", cmd)
 	log.Debugf("Plugin run finished with exit code (%d)", exitCode)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// Read plugin output
 	outStr, err := tools.EnvmanJSONPrint(pluginEnvstorePath)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	envList, err := envmanModels.NewEnvJSONList(outStr)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	pluginOutputStr, found := envList[bitrisePluginOutputEnvKey]
@@ -222,5 +225,5 @@ func runPlugin(plugin Plugin, args []string, pluginInput PluginInput) error {
 		log.Debugf("Plugin output: %s", pluginOutputStr)
 	}
 
-	return nil
+	return pluginOutputStr, nil
 }
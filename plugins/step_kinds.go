@@ -0,0 +1,196 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/models"
+)
+
+// StepKindProvider is implemented by the in-process plugins, which extend the config
+// with custom step kinds (e.g. terraform:, helm:).
+// A step list item, which's ID is a provided kind, is resolved into concrete steps before the run.
+type StepKindProvider interface {
+	StepKinds() []string
+	ResolveStepKind(kind string, step models.WorkflowStepModel) ([]models.StepListItemModel, error)
+}
+
+// StepKindPayloadModel is the payload (BITRISE_PLUGIN_INPUT_PAYLOAD) of the external plugins in step_kind mode.
+// The plugin should export the resolved step list (in YAML or JSON format) as BITRISE_PLUGIN_OUTPUT.
+type StepKindPayloadModel struct {
+	Kind string                   `json:"kind"`
+	Step models.WorkflowStepModel `json:"step"`
+}
+
+type stepKindResolver func(kind string, step models.WorkflowStepModel) ([]models.StepListItemModel, error)
+
+// StepKinds returns the custom step kinds and the name of the plugin, which provides them.
+func StepKinds() (map[string]string, error) {
+	kinds := map[string]string{}
+
+	// the installed plugins' step kinds are optional, the in-process ones are still available
+	pluginList, err := InstalledPluginList()
+	if err != nil {
+		log.Warnf("Failed to list installed plugins, error: %s", err)
+	}
+	for _, plugin := range pluginList {
+		for _, kind := range plugin.StepKinds {
+			if providerName, found := kinds[kind]; found {
+				return map[string]string{}, fmt.Errorf("step kind (%s) is provided by multiple plugins: %s, %s", kind, providerName, plugin.Name)
+			}
+			kinds[kind] = plugin.Name
+		}
+	}
+
+	for _, plugin := range InProcessPlugins() {
+		provider, ok := plugin.(StepKindProvider)
+		if !ok {
+			continue
+		}
+		for _, kind := range provider.StepKinds() {
+			if providerName, found := kinds[kind]; found {
+				return map[string]string{}, fmt.Errorf("step kind (%s) is provided by multiple plugins: %s, %s", kind, providerName, plugin.Name())
+			}
+			kinds[kind] = plugin.Name()
+		}
+	}
+
+	return kinds, nil
+}
+
+func stepKindResolvers() (map[string]stepKindResolver, error) {
+	kinds, err := StepKinds()
+	if err != nil {
+		return map[string]stepKindResolver{}, err
+	}
+
+	inProcessProviders := map[string]StepKindProvider{}
+	for _, plugin := range InProcessPlugins() {
+		if provider, ok := plugin.(StepKindProvider); ok {
+			inProcessProviders[plugin.Name()] = provider
+		}
+	}
+
+	resolvers := map[string]stepKindResolver{}
+	for kind, pluginName := range kinds {
+		if provider, found := inProcessProviders[pluginName]; found {
+			resolvers[kind] = provider.ResolveStepKind
+			continue
+		}
+
+		plugin, found, err := LoadPlugin(pluginName)
+		if err != nil {
+			return map[string]stepKindResolver{}, err
+		}
+		if !found {
+			return map[string]stepKindResolver{}, fmt.Errorf("Plugin (%s) exist in routing, but not found", pluginName)
+		}
+		resolvers[kind] = func(kind string, step models.WorkflowStepModel) ([]models.StepListItemModel, error) {
+			return resolveStepKindByPlugin(plugin, kind, step)
+		}
+	}
+
+	return resolvers, nil
+}
+
+func resolveStepKindByPlugin(plugin Plugin, kind string, step models.WorkflowStepModel) ([]models.StepListItemModel, error) {
+	payloadBytes, err := json.Marshal(StepKindPayloadModel{Kind: kind, Step: step})
+	if err != nil {
+		return []models.StepListItemModel{}, err
+	}
+
+	pluginInput := PluginInput{
+		pluginInputPluginModeKey: string(stepKindMode),
+		pluginInputPayloadKey:    string(payloadBytes),
+	}
+
+	output, err := runPlugin(plugin, []string{}, pluginInput)
+	if err != nil {
+		return []models.StepListItemModel{}, err
+	}
+
+	// YAML is a superset of JSON, so both formats are accepted
+	stepList := []models.StepListItemModel{}
+	if err := yaml.Unmarshal([]byte(output), &stepList); err != nil {
+		return []models.StepListItemModel{}, fmt.Errorf("failed to parse plugin output, error: %s", err)
+	}
+	return stepList, nil
+}
+
+// ResolveStepKinds replaces the custom step kind items of the config's workflows
+// with the concrete steps, provided by the plugins.
+func ResolveStepKinds(config models.BitriseDataModel) (models.BitriseDataModel, error) {
+	resolvers, err := stepKindResolvers()
+	if err != nil {
+		return models.BitriseDataModel{}, err
+	}
+	return resolveStepKinds(config, resolvers)
+}
+
+func resolveStepKinds(config models.BitriseDataModel, resolvers map[string]stepKindResolver) (models.BitriseDataModel, error) {
+	if len(resolvers) == 0 {
+		return config, nil
+	}
+
+	workflowIDs := []string{}
+	for workflowID := range config.Workflows {
+		workflowIDs = append(workflowIDs, workflowID)
+	}
+	sort.Strings(workflowIDs)
+
+	workflows := map[string]models.WorkflowModel{}
+	for _, workflowID := range workflowIDs {
+		workflow := config.Workflows[workflowID]
+
+		isResolved := false
+		steps := []models.StepListItemModel{}
+		for idx, stepListItem := range workflow.Steps {
+			stepID, step, err := models.GetStepIDStepDataPair(stepListItem)
+			if err != nil {
+				return models.BitriseDataModel{}, err
+			}
+
+			resolver, found := resolvers[stepID]
+			if !found {
+				steps = append(steps, stepListItem)
+				continue
+			}
+
+			log.Debugf("Resolving step kind (%s) of workflow (%s)", stepID, workflowID)
+
+			resolvedSteps, err := resolver(stepID, step)
+			if err != nil {
+				return models.BitriseDataModel{}, models.NewConfigPathError(fmt.Sprintf("workflows.%s.steps[%d].%s", workflowID, idx, stepID),
+					fmt.Errorf("failed to resolve step kind (%s), error: %s", stepID, err))
+			}
+
+			for _, resolvedStepListItem := range resolvedSteps {
+				resolvedStepID, _, err := models.GetStepIDStepDataPair(resolvedStepListItem)
+				if err != nil {
+					return models.BitriseDataModel{}, fmt.Errorf("step kind (%s) resolved to an invalid step, error: %s", stepID, err)
+				}
+				if _, found := resolvers[resolvedStepID]; found {
+					return models.BitriseDataModel{}, fmt.Errorf("step kind (%s) resolved to an other step kind (%s)", stepID, resolvedStepID)
+				}
+			}
+
+			steps = append(steps, resolvedSteps...)
+			isResolved = true
+		}
+
+		if isResolved {
+			workflow.Steps = steps
+			if err := workflow.Normalize(); err != nil {
+				return models.BitriseDataModel{}, models.NewConfigPathError("workflows."+workflowID, err)
+			}
+		}
+		workflows[workflowID] = workflow
+	}
+
+	config.Workflows = workflows
+	return config, nil
+}
@@ -0,0 +1,130 @@
+package plugins
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/pointers"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+type testStepKindPlugin struct {
+	name  string
+	kinds []string
+}
+
+func (plugin testStepKindPlugin) Name() string {
+	return plugin.name
+}
+
+func (plugin testStepKindPlugin) StepKinds() []string {
+	return plugin.kinds
+}
+
+func (plugin testStepKindPlugin) ResolveStepKind(kind string, step models.WorkflowStepModel) ([]models.StepListItemModel, error) {
+	return []models.StepListItemModel{
+		models.StepListItemModel{"script": models.WorkflowStepModel{StepModel: stepmanModels.StepModel{Title: pointers.NewStringPtr(kind + " init")}}},
+		models.StepListItemModel{"script": step},
+	}, nil
+}
+
+func testStepKindConfig() models.BitriseDataModel {
+	return models.BitriseDataModel{
+		FormatVersion: "1.3.0",
+		Workflows: map[string]models.WorkflowModel{
+			"deploy": models.WorkflowModel{
+				Steps: []models.StepListItemModel{
+					models.StepListItemModel{"git-clone": models.WorkflowStepModel{}},
+					models.StepListItemModel{"terraform": models.WorkflowStepModel{StepModel: stepmanModels.StepModel{Title: pointers.NewStringPtr("apply")}}},
+				},
+			},
+			"test": models.WorkflowModel{
+				Steps: []models.StepListItemModel{
+					models.StepListItemModel{"script": models.WorkflowStepModel{}},
+				},
+			},
+		},
+	}
+}
+
+func TestResolveStepKinds(t *testing.T) {
+	t.Log("in-process step kind provider")
+	{
+		require.NoError(t, RegisterInProcessPlugin(testStepKindPlugin{name: "terraform-plugin", kinds: []string{"terraform"}}))
+		defer UnregisterInProcessPlugin("terraform-plugin")
+
+		kinds, err := StepKinds()
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"terraform": "terraform-plugin"}, kinds)
+
+		config, err := ResolveStepKinds(testStepKindConfig())
+		require.NoError(t, err)
+
+		steps := config.Workflows["deploy"].Steps
+		require.Equal(t, 3, len(steps))
+
+		stepID, _, err := models.GetStepIDStepDataPair(steps[0])
+		require.NoError(t, err)
+		require.Equal(t, "git-clone", stepID)
+
+		stepID, step, err := models.GetStepIDStepDataPair(steps[1])
+		require.NoError(t, err)
+		require.Equal(t, "script", stepID)
+		require.Equal(t, "terraform init", *step.Title)
+
+		stepID, step, err = models.GetStepIDStepDataPair(steps[2])
+		require.NoError(t, err)
+		require.Equal(t, "script", stepID)
+		require.Equal(t, "apply", *step.Title)
+
+		require.Equal(t, 1, len(config.Workflows["test"].Steps))
+	}
+
+	t.Log("step kind provided by multiple plugins")
+	{
+		require.NoError(t, RegisterInProcessPlugin(testStepKindPlugin{name: "plugin1", kinds: []string{"helm"}}))
+		defer UnregisterInProcessPlugin("plugin1")
+		require.NoError(t, RegisterInProcessPlugin(testStepKindPlugin{name: "plugin2", kinds: []string{"helm"}}))
+		defer UnregisterInProcessPlugin("plugin2")
+
+		_, err := StepKinds()
+		require.EqualError(t, err, "step kind (helm) is provided by multiple plugins: plugin1, plugin2")
+	}
+
+	t.Log("resolver error")
+	{
+		resolvers := map[string]stepKindResolver{
+			"terraform": func(kind string, step models.WorkflowStepModel) ([]models.StepListItemModel, error) {
+				return []models.StepListItemModel{}, errors.New("missing workspace")
+			},
+		}
+
+		_, err := resolveStepKinds(testStepKindConfig(), resolvers)
+		require.EqualError(t, err, "failed to resolve step kind (terraform), error: missing workspace")
+
+		pathErr, ok := err.(models.ConfigPathError)
+		require.Equal(t, true, ok)
+		require.Equal(t, "workflows.deploy.steps[1].terraform", pathErr.Path)
+	}
+
+	t.Log("step kind resolved to an other step kind")
+	{
+		resolvers := map[string]stepKindResolver{
+			"terraform": func(kind string, step models.WorkflowStepModel) ([]models.StepListItemModel, error) {
+				return []models.StepListItemModel{models.StepListItemModel{"terraform": step}}, nil
+			},
+		}
+
+		_, err := resolveStepKinds(testStepKindConfig(), resolvers)
+		require.EqualError(t, err, "step kind (terraform) resolved to an other step kind (terraform)")
+	}
+
+	t.Log("no step kinds")
+	{
+		config, err := resolveStepKinds(testStepKindConfig(), map[string]stepKindResolver{})
+		require.NoError(t, err)
+		require.Equal(t, testStepKindConfig(), config)
+	}
+}
@@ -17,7 +17,6 @@ import (
 	"github.com/bitrise-io/bitrise/utils"
 	"github.com/bitrise-io/go-utils/cmdex"
 	"github.com/bitrise-io/go-utils/pathutil"
-	"github.com/bitrise-io/go-utils/progress"
 	"github.com/bitrise-io/go-utils/retry"
 	"github.com/bitrise-io/go-utils/versions"
 	stepmanModels "github.com/bitrise-io/stepman/models"
@@ -214,6 +213,10 @@ func (toolkit GoToolkit) Install() error {
 	versionStr := minGoVersionForToolkit
 	osStr := runtime.GOOS
 	archStr := runtime.GOARCH
+	if archStr == "arm" {
+		// the official 32-bit ARM Go release runs on ARMv6 and later
+		archStr = "armv6l"
+	}
 	extentionStr := "tar.gz"
 	if osStr == "windows" {
 		extentionStr = "zip"
@@ -230,17 +233,14 @@ func (toolkit GoToolkit) Install() error {
 	localFileName := "go." + extentionStr
 	goArchiveDownloadPath := filepath.Join(goTmpDirPath, localFileName)
 
-	var downloadErr error
-	fmt.Print("=> Downloading ...")
-	progress.SimpleProgress(".", 2*time.Second, func() {
-		downloadErr = retry.Times(2).Wait(5 * time.Second).Try(func(attempt uint) error {
-			if attempt > 0 {
-				fmt.Println()
-				fmt.Println("==> Download failed, retrying ...")
-				fmt.Println()
-			}
-			return tools.DownloadFile(downloadURL, goArchiveDownloadPath)
-		})
+	fmt.Println("=> Downloading ...")
+	downloadErr := retry.Times(2).Wait(5 * time.Second).Try(func(attempt uint) error {
+		if attempt > 0 {
+			fmt.Println()
+			fmt.Println("==> Download failed, retrying ...")
+			fmt.Println()
+		}
+		return tools.DownloadFile(downloadURL, goArchiveDownloadPath)
 	})
 	if downloadErr != nil {
 		return fmt.Errorf("Failed to download toolkit (%s), error: %s", downloadURL, downloadErr)
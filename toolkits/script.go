@@ -0,0 +1,106 @@
+package toolkits
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/utils"
+	"github.com/bitrise-io/go-utils/cmdex"
+	"github.com/bitrise-io/go-utils/stringutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// scriptEntryFiles are the Windows entry files of a step, in the order of preference
+var scriptEntryFiles = []string{"step.ps1", "step.cmd", "step.bat"}
+
+// ScriptToolkit runs the Windows script entry file of the step: a PowerShell script (.ps1) with PowerShell,
+// a batch file (.cmd, .bat) with cmd. The entry file is the toolkit.bash.entry_file of the step, if it's a Windows script,
+// or the first existing one of step.ps1, step.cmd and step.bat.
+// A step without Windows script runs with bash (e.g. Git Bash), like on the other platforms.
+type ScriptToolkit struct {
+}
+
+// Check ...
+func (toolkit ScriptToolkit) Check() (bool, ToolkitCheckResult, error) {
+	binPath, err := utils.CheckProgramInstalledPath("powershell")
+	if err != nil {
+		return false, ToolkitCheckResult{}, fmt.Errorf("Failed to get powershell binary path, error: %s", err)
+	}
+
+	verOut, err := cmdex.RunCommandAndReturnStdout("powershell", "-NoProfile", "-Command", "$PSVersionTable.PSVersion.ToString()")
+	if err != nil {
+		return false, ToolkitCheckResult{}, fmt.Errorf("Failed to check powershell version, error: %s", err)
+	}
+
+	return false, ToolkitCheckResult{
+		Path:    binPath,
+		Version: stringutil.ReadFirstLine(verOut, true),
+	}, nil
+}
+
+// IsToolAvailableInPATH ...
+func (toolkit ScriptToolkit) IsToolAvailableInPATH() bool {
+	binPath, err := utils.CheckProgramInstalledPath("powershell")
+	if err != nil {
+		return false
+	}
+	return len(binPath) > 0
+}
+
+// Bootstrap ...
+func (toolkit ScriptToolkit) Bootstrap() error {
+	return nil
+}
+
+// Install ...
+func (toolkit ScriptToolkit) Install() error {
+	return nil
+}
+
+// ToolkitName ...
+func (toolkit ScriptToolkit) ToolkitName() string {
+	return "script"
+}
+
+// PrepareForStepRun ...
+func (toolkit ScriptToolkit) PrepareForStepRun(step stepmanModels.StepModel, sIDData models.StepIDData, stepAbsDirPath string) error {
+	return nil
+}
+
+// StepRunCommandArguments ...
+func (toolkit ScriptToolkit) StepRunCommandArguments(step stepmanModels.StepModel, sIDData models.StepIDData, stepAbsDirPath string) ([]string, error) {
+	entryFile := ""
+	if step.Toolkit != nil && step.Toolkit.Bash != nil && isScriptEntryFile(step.Toolkit.Bash.EntryFile) {
+		entryFile = step.Toolkit.Bash.EntryFile
+	} else {
+		for _, name := range scriptEntryFiles {
+			if _, err := os.Stat(filepath.Join(stepAbsDirPath, name)); err == nil {
+				entryFile = name
+				break
+			}
+		}
+	}
+
+	if entryFile == "" {
+		return BashToolkit{}.StepRunCommandArguments(step, sIDData, stepAbsDirPath)
+	}
+	return scriptRunCommand(filepath.Join(stepAbsDirPath, entryFile)), nil
+}
+
+func isScriptEntryFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ps1", ".cmd", ".bat":
+		return true
+	}
+	return false
+}
+
+func scriptRunCommand(pth string) []string {
+	if strings.ToLower(filepath.Ext(pth)) == ".ps1" {
+		return []string{"powershell", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-File", pth}
+	}
+	return []string{"cmd", "/C", pth}
+}
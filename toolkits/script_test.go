@@ -0,0 +1,65 @@
+package toolkits
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/pathutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolkitForStep(t *testing.T) {
+	goStep := stepmanModels.StepModel{Toolkit: &stepmanModels.StepToolkitModel{Go: &stepmanModels.GoStepToolkitModel{PackageName: "example.com/step"}}}
+
+	require.Equal(t, "bash", toolkitForStep(stepmanModels.StepModel{}, "linux").ToolkitName())
+	require.Equal(t, "script", toolkitForStep(stepmanModels.StepModel{}, "windows").ToolkitName())
+	require.Equal(t, "go", toolkitForStep(goStep, "windows").ToolkitName())
+}
+
+func TestScriptToolkitStepRunCommandArguments(t *testing.T) {
+	stepDir, err := pathutil.NormalizedOSTempDirPath("__script_toolkit__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(stepDir))
+	}()
+
+	toolkit := ScriptToolkit{}
+
+	t.Log("no Windows script - runs with bash")
+	{
+		cmd, err := toolkit.StepRunCommandArguments(stepmanModels.StepModel{}, models.StepIDData{}, stepDir)
+		require.NoError(t, err)
+		require.Equal(t, []string{"bash", filepath.Join(stepDir, "step.sh")}, cmd)
+	}
+
+	t.Log("batch file")
+	{
+		require.NoError(t, ioutil.WriteFile(filepath.Join(stepDir, "step.cmd"), []byte("echo hello"), 0644))
+
+		cmd, err := toolkit.StepRunCommandArguments(stepmanModels.StepModel{}, models.StepIDData{}, stepDir)
+		require.NoError(t, err)
+		require.Equal(t, []string{"cmd", "/C", filepath.Join(stepDir, "step.cmd")}, cmd)
+	}
+
+	t.Log("PowerShell script is preferred")
+	{
+		require.NoError(t, ioutil.WriteFile(filepath.Join(stepDir, "step.ps1"), []byte("Write-Host hello"), 0644))
+
+		cmd, err := toolkit.StepRunCommandArguments(stepmanModels.StepModel{}, models.StepIDData{}, stepDir)
+		require.NoError(t, err)
+		require.Equal(t, []string{"powershell", "-NoProfile", "-NonInteractive", "-ExecutionPolicy", "Bypass", "-File", filepath.Join(stepDir, "step.ps1")}, cmd)
+	}
+
+	t.Log("entry file of the step")
+	{
+		step := stepmanModels.StepModel{Toolkit: &stepmanModels.StepToolkitModel{Bash: &stepmanModels.BashStepToolkitModel{EntryFile: "run.bat"}}}
+
+		cmd, err := toolkit.StepRunCommandArguments(step, models.StepIDData{}, stepDir)
+		require.NoError(t, err)
+		require.Equal(t, []string{"cmd", "/C", filepath.Join(stepDir, "run.bat")}, cmd)
+	}
+}
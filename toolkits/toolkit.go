@@ -1,6 +1,8 @@
 package toolkits
 
 import (
+	"runtime"
+
 	"github.com/bitrise-io/bitrise/models"
 	stepmanModels "github.com/bitrise-io/stepman/models"
 )
@@ -63,20 +65,25 @@ type Toolkit interface {
 
 // ToolkitForStep ...
 func ToolkitForStep(step stepmanModels.StepModel) Toolkit {
-	if step.Toolkit != nil {
-		stepToolkit := step.Toolkit
-		if stepToolkit.Go != nil {
-			return GoToolkit{}
-		} else if stepToolkit.Bash != nil {
-			return BashToolkit{}
-		}
+	return toolkitForStep(step, runtime.GOOS)
+}
+
+func toolkitForStep(step stepmanModels.StepModel, goos string) Toolkit {
+	if step.Toolkit != nil && step.Toolkit.Go != nil {
+		return GoToolkit{}
 	}
 
 	// default
+	if goos == "windows" {
+		return ScriptToolkit{}
+	}
 	return BashToolkit{}
 }
 
 // AllSupportedToolkits ...
 func AllSupportedToolkits() []Toolkit {
+	if runtime.GOOS == "windows" {
+		return []Toolkit{GoToolkit{}, ScriptToolkit{}}
+	}
 	return []Toolkit{GoToolkit{}, BashToolkit{}}
 }
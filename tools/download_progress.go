@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+)
+
+// Download progress: the progress of the tool and plugin downloads (DownloadFile) is reported,
+// so the long downloads on slow networks don't look hung. On a terminal the progress line is updated in place
+// (at most every downloadProgressTTYInterval), otherwise (e.g. in CI logs or in accessible output mode)
+// a progress log line is printed every downloadProgressLogInterval.
+
+const (
+	downloadProgressTTYInterval = 200 * time.Millisecond
+	downloadProgressLogInterval = 10 * time.Second
+)
+
+// downloadProgressWriter counts the downloaded bytes, and reports the progress, throttled
+type downloadProgressWriter struct {
+	mutex sync.Mutex
+
+	name       string
+	total      int64
+	downloaded int64
+	isTTY      bool
+	out        io.Writer
+	interval   time.Duration
+	lastReport time.Time
+	now        func() time.Time
+}
+
+func newDownloadProgressWriter(downloadURL string, offset, total int64) *downloadProgressWriter {
+	isTTY := !configs.IsAccessibleMode && isTerminal(os.Stdout)
+	interval := downloadProgressLogInterval
+	if isTTY {
+		interval = downloadProgressTTYInterval
+	}
+
+	name := downloadURL
+	if parsedURL, err := url.Parse(downloadURL); err == nil {
+		name = path.Base(parsedURL.Path)
+	}
+
+	return &downloadProgressWriter{
+		name:       name,
+		total:      total,
+		downloaded: offset,
+		isTTY:      isTTY,
+		out:        os.Stdout,
+		interval:   interval,
+		lastReport: time.Now(),
+		now:        time.Now,
+	}
+}
+
+// isTerminal returns true if the file is a terminal (character device)
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func (writer *downloadProgressWriter) Write(p []byte) (int, error) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	writer.downloaded += int64(len(p))
+	if now := writer.now(); now.Sub(writer.lastReport) >= writer.interval {
+		writer.lastReport = now
+		writer.report()
+	}
+	return len(p), nil
+}
+
+// Finish reports the final state of the download
+func (writer *downloadProgressWriter) Finish() {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	writer.report()
+	if writer.isTTY {
+		fmt.Fprintln(writer.out)
+	}
+}
+
+func (writer *downloadProgressWriter) report() {
+	if writer.isTTY {
+		// the spaces clear the end of the previous, longer line
+		fmt.Fprintf(writer.out, "\r%s    ", writer.progress())
+		return
+	}
+	log.Infof("%s", writer.progress())
+}
+
+func (writer *downloadProgressWriter) progress() string {
+	if writer.total <= 0 {
+		return fmt.Sprintf("Downloading %s: %s", writer.name, formatDownloadSize(writer.downloaded))
+	}
+	return fmt.Sprintf("Downloading %s: %d%% (%s / %s)", writer.name, writer.downloaded*100/writer.total,
+		formatDownloadSize(writer.downloaded), formatDownloadSize(writer.total))
+}
+
+func formatDownloadSize(size int64) string {
+	switch {
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	}
+	return fmt.Sprintf("%d B", size)
+}
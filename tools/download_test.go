@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadFileResume(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	ranges := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "tool", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("__download__")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	targetPth := filepath.Join(tmpDir, "tool")
+	partPth := targetPth + downloadPartFileSuffix
+
+	t.Log("resumes the partial download of the same URL")
+	{
+		require.NoError(t, ioutil.WriteFile(partPth, content[:10], 0644))
+		require.NoError(t, ioutil.WriteFile(partPth+downloadPartURLFileSuffix, []byte(server.URL+"/tool"), 0644))
+
+		require.NoError(t, DownloadFile(server.URL+"/tool", targetPth))
+		require.Equal(t, []string{"bytes=10-"}, ranges)
+
+		downloaded, err := ioutil.ReadFile(targetPth)
+		require.NoError(t, err)
+		require.Equal(t, content, downloaded)
+
+		exists, err := pathutil.IsPathExists(partPth)
+		require.NoError(t, err)
+		require.False(t, exists)
+	}
+
+	t.Log("starts over, if the partial download is of another URL")
+	{
+		ranges = []string{}
+		require.NoError(t, ioutil.WriteFile(partPth, []byte("other"), 0644))
+		require.NoError(t, ioutil.WriteFile(partPth+downloadPartURLFileSuffix, []byte(server.URL+"/other-tool"), 0644))
+
+		require.NoError(t, DownloadFile(server.URL+"/tool", targetPth))
+		require.Equal(t, []string{""}, ranges)
+
+		downloaded, err := ioutil.ReadFile(targetPth)
+		require.NoError(t, err)
+		require.Equal(t, content, downloaded)
+	}
+
+	t.Log("starts over, if the partial download is larger than the file")
+	{
+		ranges = []string{}
+		require.NoError(t, ioutil.WriteFile(partPth, append(content, content...), 0644))
+		require.NoError(t, ioutil.WriteFile(partPth+downloadPartURLFileSuffix, []byte(server.URL+"/tool"), 0644))
+
+		require.NoError(t, DownloadFile(server.URL+"/tool", targetPth))
+		require.Equal(t, []string{"bytes=40-", ""}, ranges)
+
+		downloaded, err := ioutil.ReadFile(targetPth)
+		require.NoError(t, err)
+		require.Equal(t, content, downloaded)
+	}
+}
+
+func TestDownloadProgressWriter(t *testing.T) {
+	t.Log("progress with known total")
+	{
+		writer := &downloadProgressWriter{name: "tool", total: 2 * 1024 * 1024, downloaded: 1024 * 1024}
+		require.Equal(t, "Downloading tool: 50% (1.0 MB / 2.0 MB)", writer.progress())
+	}
+
+	t.Log("progress with unknown total")
+	{
+		writer := &downloadProgressWriter{name: "tool", downloaded: 1536}
+		require.Equal(t, "Downloading tool: 1.5 KB", writer.progress())
+	}
+
+	t.Log("reports are throttled")
+	{
+		current := time.Unix(0, 0)
+		var out bytes.Buffer
+		writer := &downloadProgressWriter{
+			name:       "tool",
+			total:      4,
+			isTTY:      true,
+			out:        &out,
+			interval:   time.Second,
+			lastReport: current,
+			now:        func() time.Time { return current },
+		}
+
+		_, err := writer.Write([]byte("a"))
+		require.NoError(t, err)
+		require.Equal(t, "", out.String())
+
+		current = current.Add(time.Second)
+		_, err = writer.Write([]byte("b"))
+		require.NoError(t, err)
+		require.Equal(t, "\rDownloading tool: 50% (2 B / 4 B)    ", out.String())
+
+		writer.Finish()
+		require.Equal(t, "\rDownloading tool: 50% (2 B / 4 B)    \rDownloading tool: 50% (2 B / 4 B)    \n", out.String())
+	}
+}
+
+func TestFormatDownloadSize(t *testing.T) {
+	require.Equal(t, "10 B", formatDownloadSize(10))
+	require.Equal(t, "2.0 KB", formatDownloadSize(2048))
+	require.Equal(t, "3.5 MB", formatDownloadSize(3*1024*1024+512*1024))
+}
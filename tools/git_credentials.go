@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/go-utils/cmdex"
+)
+
+// The git credentials (see: configs.GitCredentialEnvs) are only set for the commands,
+// which clone the StepLibs and the steps (stepman setup / update / activate, git clone of the git:: steps),
+// the steps themselves don't get them.
+
+var (
+	gitCredentialEnvs     []string
+	gitCredentialEnvsErr  error
+	gitCredentialEnvsOnce sync.Once
+)
+
+// GitCredentialEnvs returns the envs of the configured git credentials
+func GitCredentialEnvs() ([]string, error) {
+	gitCredentialEnvsOnce.Do(func() {
+		credentials, err := configs.GitCredentials()
+		if err != nil {
+			gitCredentialEnvsErr = fmt.Errorf("Failed to read git credentials, error: %s", err)
+			return
+		}
+		if len(credentials) == 0 {
+			return
+		}
+
+		sshConfigPth := filepath.Join(configs.GetBitriseHomeDirPath(), "git_credentials_ssh_config")
+		gitCredentialEnvs, gitCredentialEnvsErr = configs.GitCredentialEnvs(credentials, sshConfigPth)
+		if gitCredentialEnvsErr == nil {
+			log.Debugf("Git credentials configured for hosts: %d", len(credentials))
+		}
+	})
+	return gitCredentialEnvs, gitCredentialEnvsErr
+}
+
+// runWithGitCredentials runs the command with the git credentials, and with the standard input and outputs
+func runWithGitCredentials(dir, name string, args ...string) error {
+	envs, err := GitCredentialEnvs()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Dir = dir
+	if len(envs) > 0 {
+		cmd.Env = append(os.Environ(), envs...)
+	}
+	return cmd.Run()
+}
+
+// GitCloneTagOrBranch clones the given tag or branch of the repository (like cmdex.GitCloneTagOrBranch), with the git credentials
+func GitCloneTagOrBranch(uri, pth, tagOrBranch string) error {
+	if uri == "" {
+		return errors.New("Git Clone 'uri' missing")
+	}
+	if pth == "" {
+		return errors.New("Git Clone 'path' missing")
+	}
+	if tagOrBranch == "" {
+		return errors.New("Git Clone 'tag or branch' missing")
+	}
+	return runWithGitCredentials("", "git", "clone", "--recursive", "--branch", tagOrBranch, uri, pth)
+}
+
+// GitCloneTagOrBranchAndValidateCommitHash clones the given version of the repository (like cmdex.GitCloneTagOrBranchAndValidateCommitHash),
+// with the git credentials, and checks the commit hash of the version.
+func GitCloneTagOrBranchAndValidateCommitHash(uri, pth, version, commitHash string) error {
+	if commitHash == "" {
+		return errors.New("Git Clone 'commithash' missing")
+	}
+	if err := GitCloneTagOrBranch(uri, pth, version); err != nil {
+		return err
+	}
+
+	latestCommit, err := cmdex.GitGetLatestCommitHashOnHead(pth)
+	if err == nil && strings.TrimSpace(latestCommit) != commitHash {
+		err = fmt.Errorf("Commit hash doesn't match the one specified for the version tag. (version tag: %s) (expected commit hash: %s) (got: %s)", version, commitHash, latestCommit)
+	}
+	if err != nil {
+		if removeErr := os.RemoveAll(pth); removeErr != nil {
+			log.Warnf("Failed to cleanup path (%s), error: %s", pth, removeErr)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bitrise-io/bitrise/configs"
+)
+
+// pinnedToolDirs holds the directories of the tool versions pinned by the project's config
+var pinnedToolDirs = map[string]string{}
+
+// UsePinnedTool makes the tool installed in binDirPth the one used by the CLI:
+// the directory is added to the front of PATH.
+func UsePinnedTool(toolname, binDirPth string) error {
+	if err := os.Setenv("PATH", configs.GeneratePATHEnvString(os.Getenv("PATH"), binDirPth)); err != nil {
+		return fmt.Errorf("Failed to add pinned %s to PATH, error: %s", toolname, err)
+	}
+	pinnedToolDirs[toolname] = binDirPth
+	return nil
+}
+
+// IsToolPinned ...
+func IsToolPinned(toolname string) bool {
+	_, found := pinnedToolDirs[toolname]
+	return found
+}
@@ -0,0 +1,23 @@
+package tools
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsePinnedTool(t *testing.T) {
+	originalPath := os.Getenv("PATH")
+	defer func() {
+		delete(pinnedToolDirs, "envman")
+		require.NoError(t, os.Setenv("PATH", originalPath))
+	}()
+
+	require.Equal(t, false, IsToolPinned("envman"))
+
+	require.NoError(t, UsePinnedTool("envman", "/tmp/tools/versions/envman/1.1.3"))
+	require.Equal(t, true, IsToolPinned("envman"))
+	require.Equal(t, true, strings.HasPrefix(os.Getenv("PATH"), "/tmp/tools/versions/envman/1.1.3:"))
+}
@@ -0,0 +1,40 @@
+//go:build !windows
+// +build !windows
+
+package tools
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the command in its own process group, and returns true.
+// If the stdin of the command is a terminal, the command stays in the CLI's (foreground) process group, and false is returned:
+// a process of a background process group is stopped (SIGTTIN), when it reads from the terminal.
+func setProcessGroup(command *exec.Cmd) bool {
+	if stdin, ok := command.Stdin.(*os.File); ok && isTerminal(stdin) {
+		return false
+	}
+	command.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return true
+}
+
+// killProcessGroup kills the process group of the process (started by setProcessGroup),
+// or only the process, if it was not started in its own process group
+// (its child processes get the terminal's signals, like the CLI itself).
+func killProcessGroup(pid int, isGroup bool) error {
+	if !isGroup {
+		if err := syscall.Kill(pid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+			return fmt.Errorf("failed to kill process (%d), error: %s", pid, err)
+		}
+		return nil
+	}
+
+	// negative pid addresses the process group
+	if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+		return fmt.Errorf("failed to kill process group (%d), error: %s", pid, err)
+	}
+	return nil
+}
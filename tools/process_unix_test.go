@@ -0,0 +1,56 @@
+//go:build !windows
+// +build !windows
+
+package tools
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetProcessGroup(t *testing.T) {
+	t.Log("not a terminal stdin - own process group")
+	{
+		reader, writer, err := os.Pipe()
+		require.NoError(t, err)
+		defer func() {
+			require.NoError(t, reader.Close())
+			require.NoError(t, writer.Close())
+		}()
+
+		command := exec.Command("true")
+		command.Stdin = reader
+		require.Equal(t, true, setProcessGroup(command))
+		require.Equal(t, true, command.SysProcAttr.Setpgid)
+	}
+
+	t.Log("terminal stdin - stays in the foreground process group")
+	{
+		terminal, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+		if err != nil {
+			t.Skipf("no pseudo terminal available, error: %s", err)
+		}
+		defer func() {
+			require.NoError(t, terminal.Close())
+		}()
+
+		command := exec.Command("true")
+		command.Stdin = terminal
+		require.Equal(t, false, setProcessGroup(command))
+		require.Nil(t, command.SysProcAttr)
+	}
+}
+
+func TestKillProcessGroup(t *testing.T) {
+	command := exec.Command("sleep", "10")
+	require.NoError(t, command.Start())
+
+	require.NoError(t, killProcessGroup(command.Process.Pid, false))
+	require.Error(t, command.Wait())
+
+	// the process is already gone
+	require.NoError(t, killProcessGroup(command.Process.Pid, false))
+}
@@ -0,0 +1,25 @@
+//go:build windows
+// +build windows
+
+package tools
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// setProcessGroup starts the command in a new process group
+func setProcessGroup(command *exec.Cmd) bool {
+	command.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+	return true
+}
+
+// killProcessGroup kills the process with its child processes (the whole process tree)
+func killProcessGroup(pid int, isGroup bool) error {
+	if out, err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(pid)).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to kill process tree (%d), output: %s, error: %s", pid, out, err)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package tools
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/go-utils/progress"
+)
+
+// RunWithProgress runs the action, printing a progress dot every tickInterval, while it's running.
+// In accessible output mode no progress dots are printed, only a single line break at the end,
+// so the output stays a linear list of statements.
+func RunWithProgress(tickInterval time.Duration, action func() error) error {
+	if configs.IsAccessibleMode {
+		err := action()
+		fmt.Println()
+		return err
+	}
+	return progress.SimpleProgressE(".", tickInterval, action)
+}
@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// lineRedactWriter redacts the output line by line, before writing it to the underlying writer,
+// so the redacted values, split between writes, are redacted too.
+// The incomplete last line is written by Flush.
+type lineRedactWriter struct {
+	mutex  sync.Mutex
+	writer io.Writer
+	redact func(string) string
+	buffer []byte
+}
+
+func newLineRedactWriter(writer io.Writer, redact func(string) string) *lineRedactWriter {
+	return &lineRedactWriter{writer: writer, redact: redact}
+}
+
+// Write ...
+func (writer *lineRedactWriter) Write(p []byte) (int, error) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	writer.buffer = append(writer.buffer, p...)
+	idx := bytes.LastIndexByte(writer.buffer, '\n')
+	if idx < 0 {
+		return len(p), nil
+	}
+
+	lines := string(writer.buffer[:idx+1])
+	writer.buffer = append([]byte{}, writer.buffer[idx+1:]...)
+	if _, err := io.WriteString(writer.writer, writer.redact(lines)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes the incomplete last line
+func (writer *lineRedactWriter) Flush() error {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	if len(writer.buffer) == 0 {
+		return nil
+	}
+	line := string(writer.buffer)
+	writer.buffer = nil
+	_, err := io.WriteString(writer.writer, writer.redact(line))
+	return err
+}
@@ -0,0 +1,29 @@
+package tools
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineRedactWriter(t *testing.T) {
+	var buffer bytes.Buffer
+	writer := newLineRedactWriter(&buffer, func(str string) string {
+		return strings.Replace(str, "ghp_abc123", "[REDACTED]", -1)
+	})
+
+	_, err := writer.Write([]byte("token: ghp_a"))
+	require.NoError(t, err)
+	require.Equal(t, "", buffer.String())
+
+	_, err = writer.Write([]byte("bc123\nnext: ghp_"))
+	require.NoError(t, err)
+	require.Equal(t, "token: [REDACTED]\n", buffer.String())
+
+	_, err = writer.Write([]byte("abc123"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Flush())
+	require.Equal(t, "token: [REDACTED]\nnext: [REDACTED]", buffer.String())
+}
@@ -0,0 +1,194 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+)
+
+// Release asset fallback: if a tool's release has no asset with the expected name (<tool>-<OS>-<arch>),
+// the assets of the release are listed (GitHub releases API), and the best match of the platform is installed:
+//  * an asset matching both the OS and the architecture (e.g. envman_linux_amd64, envman-macos-x86_64)
+//  * if there's none, an asset matching the OS, without any architecture in its name (e.g. a universal MacOS binary)
+// The checksum and signature files of the release are never selected.
+//
+// The tools can be installed from the latest release as well (version: latest), its tag is resolved by the GitHub API.
+// The GitHub API requests are authenticated with the BITRISE_GITHUB_TOKEN (or GITHUB_TOKEN) env, if set,
+// to avoid the low rate limit of the unauthenticated requests.
+
+// LatestToolVersion can be used as the version of a tool, to install its latest release
+const LatestToolVersion = "latest"
+
+var githubAPIURL = "https://api.github.com"
+
+var (
+	releaseAssetOSAliases = map[string][]string{
+		"darwin":  []string{"darwin", "macos", "osx", "mac"},
+		"linux":   []string{"linux"},
+		"windows": []string{"windows", "win", "win64", "win32"},
+	}
+	releaseAssetArchAliases = map[string][]string{
+		"amd64": []string{"amd64", "x64"},
+		"386":   []string{"i386", "386", "x86"},
+		"arm":   []string{"armv7l", "armv7", "armv6l", "armhf", "arm"},
+		"arm64": []string{"aarch64", "arm64"},
+	}
+	releaseAssetIgnoredExtensions = []string{".sig", ".asc", ".sha256", ".sha512", ".md5", ".txt", ".json"}
+
+	releaseAssetNameSeparatorRegexp = regexp.MustCompile(`[-._ ]`)
+)
+
+// ReleaseAssetModel ...
+type ReleaseAssetModel struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+type releaseModel struct {
+	TagName string              `json:"tag_name"`
+	Assets  []ReleaseAssetModel `json:"assets"`
+}
+
+func githubToken() string {
+	if token := os.Getenv(configs.GitHubTokenEnvKey); token != "" {
+		return token
+	}
+	return os.Getenv(configs.GitHubTokenFallbackEnvKey)
+}
+
+// getRelease returns the release of the tool with the given tag, or the latest release
+func getRelease(toolname, githubUser, toolVersion string) (releaseModel, error) {
+	releaseURL := fmt.Sprintf("%s/repos/%s/%s/releases/tags/%s", githubAPIURL, githubUser, toolname, toolVersion)
+	if toolVersion == LatestToolVersion {
+		releaseURL = fmt.Sprintf("%s/repos/%s/%s/releases/latest", githubAPIURL, githubUser, toolname)
+	}
+	releaseURL = configs.MirrorURL(releaseURL)
+
+	request, err := http.NewRequest("GET", releaseURL, nil)
+	if err != nil {
+		return releaseModel{}, fmt.Errorf("failed to create request (%s), error: %s", releaseURL, err)
+	}
+	request.Header.Set("Accept", "application/vnd.github.v3+json")
+	if token := githubToken(); token != "" {
+		request.Header.Set("Authorization", "token "+token)
+	}
+
+	resp, err := configs.HTTPClient().Do(request)
+	if err != nil {
+		return releaseModel{}, fmt.Errorf("failed to get release (%s), error: %s", releaseURL, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Warnf("failed to close (%s) body", releaseURL)
+		}
+	}()
+
+	if resp.StatusCode == http.StatusForbidden && githubToken() == "" {
+		return releaseModel{}, fmt.Errorf("failed to get release (%s), status code: %d (rate limit exceeded? set the %s env)", releaseURL, resp.StatusCode, configs.GitHubTokenEnvKey)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return releaseModel{}, fmt.Errorf("failed to get release (%s), status code: %d", releaseURL, resp.StatusCode)
+	}
+
+	release := releaseModel{}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return releaseModel{}, fmt.Errorf("failed to parse release (%s), error: %s", releaseURL, err)
+	}
+	return release, nil
+}
+
+// ResolveToolVersion returns the tag of the tool's latest release, if the version is latest,
+// otherwise the version itself
+func ResolveToolVersion(toolname, githubUser, toolVersion string) (string, error) {
+	if toolVersion != LatestToolVersion {
+		return toolVersion, nil
+	}
+
+	release, err := getRelease(toolname, githubUser, toolVersion)
+	if err != nil {
+		return "", fmt.Errorf("Failed to resolve the latest version of %s, error: %s", toolname, err)
+	}
+	if release.TagName == "" {
+		return "", fmt.Errorf("Failed to resolve the latest version of %s: the release has no tag", toolname)
+	}
+	return release.TagName, nil
+}
+
+// findReleaseAssetURL returns the download URL of the release's asset, matching the platform
+func findReleaseAssetURL(toolname, githubUser, toolVersion, goos, goarch string) (string, error) {
+	release, err := getRelease(toolname, githubUser, toolVersion)
+	if err != nil {
+		return "", err
+	}
+
+	asset, err := selectReleaseAsset(release.Assets, goos, goarch)
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %s", toolname, toolVersion, err)
+	}
+	return asset.BrowserDownloadURL, nil
+}
+
+func releaseAssetNameHasAlias(tokens []string, aliases []string) bool {
+	for _, token := range tokens {
+		for _, alias := range aliases {
+			if token == alias {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// selectReleaseAsset returns the asset matching both the OS and the architecture,
+// or the asset matching the OS without any architecture in its name.
+func selectReleaseAsset(assets []ReleaseAssetModel, goos, goarch string) (ReleaseAssetModel, error) {
+	osOnlyAssets := []ReleaseAssetModel{}
+	names := []string{}
+
+	for _, asset := range assets {
+		names = append(names, asset.Name)
+
+		name := strings.ToLower(asset.Name)
+		isIgnored := false
+		for _, extension := range releaseAssetIgnoredExtensions {
+			if strings.HasSuffix(name, extension) {
+				isIgnored = true
+				break
+			}
+		}
+		if isIgnored {
+			continue
+		}
+
+		// x86_64 contains a separator
+		tokens := releaseAssetNameSeparatorRegexp.Split(strings.Replace(name, "x86_64", "amd64", -1), -1)
+		if !releaseAssetNameHasAlias(tokens, releaseAssetOSAliases[goos]) {
+			continue
+		}
+		if releaseAssetNameHasAlias(tokens, releaseAssetArchAliases[goarch]) {
+			return asset, nil
+		}
+
+		hasOtherArch := false
+		for _, aliases := range releaseAssetArchAliases {
+			if releaseAssetNameHasAlias(tokens, aliases) {
+				hasOtherArch = true
+				break
+			}
+		}
+		if !hasOtherArch {
+			osOnlyAssets = append(osOnlyAssets, asset)
+		}
+	}
+
+	if len(osOnlyAssets) > 0 {
+		return osOnlyAssets[0], nil
+	}
+	return ReleaseAssetModel{}, fmt.Errorf("no release asset matches %s/%s, available assets: %s", goos, goarch, strings.Join(names, ", "))
+}
@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectReleaseAsset(t *testing.T) {
+	assets := []ReleaseAssetModel{}
+	for _, name := range []string{"envman_linux_amd64.sha256", "envman_linux_amd64", "envman_linux_armv7l", "envman-macos", "envman-Windows-x86_64.exe"} {
+		assets = append(assets, ReleaseAssetModel{Name: name, BrowserDownloadURL: "https://example.com/" + name})
+	}
+
+	for _, testCase := range []struct {
+		goos     string
+		goarch   string
+		expected string
+	}{
+		{goos: "linux", goarch: "amd64", expected: "envman_linux_amd64"},
+		{goos: "linux", goarch: "arm", expected: "envman_linux_armv7l"},
+		{goos: "darwin", goarch: "amd64", expected: "envman-macos"},
+		{goos: "windows", goarch: "amd64", expected: "envman-Windows-x86_64.exe"},
+	} {
+		asset, err := selectReleaseAsset(assets, testCase.goos, testCase.goarch)
+		require.NoError(t, err)
+		require.Equal(t, testCase.expected, asset.Name)
+	}
+
+	t.Log("no matching asset")
+	{
+		_, err := selectReleaseAsset(assets, "linux", "386")
+		require.EqualError(t, err, "no release asset matches linux/386, available assets: envman_linux_amd64.sha256, envman_linux_amd64, envman_linux_armv7l, envman-macos, envman-Windows-x86_64.exe")
+	}
+}
+
+func TestFindReleaseAssetURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/bitrise-io/envman/releases/tags/1.1.1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"assets":[{"name":"envman-Linux-x86_64","browser_download_url":"https://example.com/envman-Linux-x86_64"}]}`)
+	}))
+	defer server.Close()
+
+	originalAPIURL := githubAPIURL
+	githubAPIURL = server.URL
+	defer func() {
+		githubAPIURL = originalAPIURL
+	}()
+
+	assetURL, err := findReleaseAssetURL("envman", "bitrise-io", "1.1.1", "linux", "amd64")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/envman-Linux-x86_64", assetURL)
+
+	_, err = findReleaseAssetURL("envman", "bitrise-io", "0.0.1", "linux", "amd64")
+	require.Error(t, err)
+}
+
+func TestDownloadFileStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "<html>Not Found</html>")
+	}))
+	defer server.Close()
+
+	err := DownloadFile(server.URL+"/envman-Linux-x86_64", "/not/written")
+	statusErr, ok := err.(*DownloadStatusError)
+	require.True(t, ok)
+	require.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+}
+
+func TestResolveToolVersion(t *testing.T) {
+	authorization := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if r.URL.Path != "/repos/bitrise-io/envman/releases/latest" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"tag_name":"1.2.0","assets":[]}`)
+	}))
+	defer server.Close()
+
+	originalAPIURL := githubAPIURL
+	githubAPIURL = server.URL
+	defer func() {
+		githubAPIURL = originalAPIURL
+	}()
+
+	originalToken := os.Getenv(configs.GitHubTokenEnvKey)
+	defer func() {
+		require.NoError(t, os.Setenv(configs.GitHubTokenEnvKey, originalToken))
+	}()
+
+	t.Log("pinned version")
+	{
+		version, err := ResolveToolVersion("envman", "bitrise-io", "1.1.1")
+		require.NoError(t, err)
+		require.Equal(t, "1.1.1", version)
+	}
+
+	t.Log("latest version, with token")
+	{
+		require.NoError(t, os.Setenv(configs.GitHubTokenEnvKey, "my-token"))
+
+		version, err := ResolveToolVersion("envman", "bitrise-io", LatestToolVersion)
+		require.NoError(t, err)
+		require.Equal(t, "1.2.0", version)
+		require.Equal(t, "token my-token", authorization)
+	}
+
+	t.Log("no release")
+	{
+		_, err := ResolveToolVersion("stepman", "bitrise-io", LatestToolVersion)
+		require.Error(t, err)
+	}
+}
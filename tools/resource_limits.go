@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+)
+
+// Step resource limits (resource_limits of the step).
+// On Linux the step's process tree is started in its own cgroup (cgroup v2), with the cpu.max and memory.max limits.
+// If it's not available, or on the other platforms, the step fails, as its limits can't be enforced,
+// unless the steps are allowed to run without them (see: configs.StepResourceLimitsOptionalEnvKey, a warning is printed).
+// (ulimit is not an alternative: it limits the virtual memory, which kills the steps reserving large address spaces, like Go or JVM.)
+// The step's cgroup is created in the CLI's cgroup. The controllers can only be enabled for it, if the CLI's cgroup
+// has no member processes, so the CLI has to move itself into a leaf cgroup (<cgroup>/bitrise-cli) first.
+// The CLI stays in that cgroup until it exits, so this is opt-in (see: configs.StepCgroupMoveCLIEnvKey).
+
+// processLimiter enforces the resource limits of a step's process,
+// the limits are applied on the command, before it's started (see: newProcessLimiter)
+type processLimiter struct {
+	// release is called once the process exited (or failed to start), nil if there is nothing to cleanup
+	release func()
+}
+
+func isResourceLimitsEmpty(limits models.ResourceLimitsModel) bool {
+	return limits.CPUs <= 0 && limits.MemoryMB <= 0
+}
+
+// handleUnenforcedResourceLimits returns the error of the step, which resource limits can't be enforced,
+// or nil (and prints a warning) if the steps are allowed to run without their limits.
+func handleUnenforcedResourceLimits(err error) error {
+	if configs.IsStepResourceLimitsOptional() {
+		log.Warnf("The resource limits of the step are not enforced, error: %s", err)
+		return nil
+	}
+	return fmt.Errorf("the resource limits of the step can't be enforced, error: %s (set %s=true to run the step without them)",
+		err, configs.StepResourceLimitsOptionalEnvKey)
+}
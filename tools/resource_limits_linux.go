@@ -0,0 +1,199 @@
+//go:build linux
+// +build linux
+
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+)
+
+const (
+	cgroupRootPath = "/sys/fs/cgroup"
+	// cgroupCPUPeriod is the period of the cpu.max limit, in microseconds
+	cgroupCPUPeriod = 100000
+)
+
+func newProcessLimiter(command *exec.Cmd, limits models.ResourceLimitsModel) (processLimiter, error) {
+	if isResourceLimitsEmpty(limits) {
+		return processLimiter{}, nil
+	}
+
+	cgroupPth, err := createStepCgroup(limits)
+	if err != nil {
+		return processLimiter{}, fmt.Errorf("failed to create the cgroup of the step, error: %s", err)
+	}
+
+	cgroupDir, err := os.Open(cgroupPth)
+	if err != nil {
+		removeStepCgroup(cgroupPth)
+		return processLimiter{}, fmt.Errorf("failed to open the cgroup of the step, error: %s", err)
+	}
+
+	// The step is started right in its cgroup (clone3 with CLONE_INTO_CGROUP, requires Linux 5.7+),
+	// so the processes it forks are limited too, from the very start.
+	if command.SysProcAttr == nil {
+		command.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	command.SysProcAttr.UseCgroupFD = true
+	command.SysProcAttr.CgroupFD = int(cgroupDir.Fd())
+
+	return processLimiter{
+		release: func() {
+			if err := cgroupDir.Close(); err != nil {
+				log.Debugf("Failed to close the cgroup of the step (%s), error: %s", cgroupPth, err)
+			}
+			if isCgroupOOMKilled(cgroupPth) {
+				log.Errorf("The step exceeded its memory limit (%d MB), and was killed", limits.MemoryMB)
+			}
+			removeStepCgroup(cgroupPth)
+		},
+	}, nil
+}
+
+func removeStepCgroup(pth string) {
+	if err := os.Remove(pth); err != nil {
+		log.Debugf("Failed to remove the cgroup of the step (%s), error: %s", pth, err)
+	}
+}
+
+// createStepCgroup creates the cgroup of the step, as a child of the CLI's cgroup, with the given limits
+func createStepCgroup(limits models.ResourceLimitsModel) (string, error) {
+	if _, err := os.Stat(filepath.Join(cgroupRootPath, "cgroup.controllers")); err != nil {
+		return "", errors.New("cgroup v2 is not available")
+	}
+
+	procCgroup, err := ioutil.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	cgroup, err := parseCgroupV2Path(procCgroup)
+	if err != nil {
+		return "", err
+	}
+	parentPth := filepath.Join(cgroupRootPath, cgroup)
+
+	controllers := []string{}
+	if limits.CPUs > 0 {
+		controllers = append(controllers, "+cpu")
+	}
+	if limits.MemoryMB > 0 {
+		controllers = append(controllers, "+memory")
+	}
+	if err := enableCgroupControllers(parentPth, controllers); err != nil {
+		return "", err
+	}
+
+	pth := filepath.Join(parentPth, fmt.Sprintf("bitrise-step-%d", os.Getpid()))
+	if err := os.Mkdir(pth, 0755); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+
+	if limits.CPUs > 0 {
+		if err := ioutil.WriteFile(filepath.Join(pth, "cpu.max"), []byte(cgroupCPUMax(limits.CPUs)), 0644); err != nil {
+			_ = os.Remove(pth)
+			return "", fmt.Errorf("failed to set the CPU limit, error: %s", err)
+		}
+	}
+	if limits.MemoryMB > 0 {
+		memoryMax := strconv.FormatInt(int64(limits.MemoryMB)*1024*1024, 10)
+		if err := ioutil.WriteFile(filepath.Join(pth, "memory.max"), []byte(memoryMax), 0644); err != nil {
+			_ = os.Remove(pth)
+			return "", fmt.Errorf("failed to set the memory limit, error: %s", err)
+		}
+		// kill the whole process tree of the step, not only the largest process of it
+		if err := ioutil.WriteFile(filepath.Join(pth, "memory.oom.group"), []byte("1"), 0644); err != nil {
+			log.Debugf("Failed to set memory.oom.group of the step's cgroup, error: %s", err)
+		}
+	}
+
+	return pth, nil
+}
+
+// enableCgroupControllers enables the controllers for the children of the cgroup.
+// The controllers can't be enabled, while the cgroup has member processes (the CLI itself),
+// in this case the CLI is moved into a leaf cgroup first, if it's allowed (see: configs.StepCgroupMoveCLIEnvKey).
+func enableCgroupControllers(pth string, controllers []string) error {
+	subtreeControlPth := filepath.Join(pth, "cgroup.subtree_control")
+	content := []byte(strings.Join(controllers, " "))
+
+	err := ioutil.WriteFile(subtreeControlPth, content, 0644)
+	if err == nil {
+		return nil
+	}
+	if pathErr, ok := err.(*os.PathError); !ok || pathErr.Err != syscall.EBUSY {
+		return fmt.Errorf("failed to enable the cgroup controllers (%s), error: %s", strings.Join(controllers, " "), err)
+	}
+	if os.Getenv(configs.StepCgroupMoveCLIEnvKey) != "true" {
+		return fmt.Errorf("the cgroup controllers (%s) can't be enabled, while the CLI is a member of the cgroup (%s), "+
+			"set %s=true to allow the CLI to move itself into a leaf cgroup", strings.Join(controllers, " "), pth, configs.StepCgroupMoveCLIEnvKey)
+	}
+
+	leafPth := filepath.Join(pth, "bitrise-cli")
+	if err := os.Mkdir(leafPth, 0755); err != nil && !os.IsExist(err) {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(leafPth, "cgroup.procs"), []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		return fmt.Errorf("failed to move the CLI into a leaf cgroup, error: %s", err)
+	}
+
+	if err := ioutil.WriteFile(subtreeControlPth, content, 0644); err != nil {
+		return fmt.Errorf("failed to enable the cgroup controllers (%s), error: %s", strings.Join(controllers, " "), err)
+	}
+	return nil
+}
+
+// parseCgroupV2Path returns the cgroup v2 path of the process, from the content of /proc/<pid>/cgroup (0::<path>)
+func parseCgroupV2Path(content []byte) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "0::") {
+			return strings.TrimPrefix(line, "0::"), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("the process is not in a cgroup v2 hierarchy")
+}
+
+// cgroupCPUMax returns the cpu.max value of the CPU limit: the quota and the period, in microseconds
+func cgroupCPUMax(cpus float64) string {
+	quota := int64(cpus * cgroupCPUPeriod)
+	if quota < 1000 {
+		// the minimum quota of the kernel is 1ms
+		quota = 1000
+	}
+	return fmt.Sprintf("%d %d", quota, cgroupCPUPeriod)
+}
+
+// isCgroupOOMKilled returns true if a process of the cgroup was killed by the OOM killer
+func isCgroupOOMKilled(pth string) bool {
+	content, err := ioutil.ReadFile(filepath.Join(pth, "memory.events"))
+	if err != nil {
+		return false
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "oom_kill" {
+			count, err := strconv.Atoi(fields[1])
+			return err == nil && count > 0
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+//go:build linux
+// +build linux
+
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCgroupV2Path(t *testing.T) {
+	t.Log("unified hierarchy")
+	{
+		pth, err := parseCgroupV2Path([]byte("0::/user.slice/user-1000.slice/session-2.scope\n"))
+		require.NoError(t, err)
+		require.Equal(t, "/user.slice/user-1000.slice/session-2.scope", pth)
+	}
+
+	t.Log("hybrid hierarchy")
+	{
+		pth, err := parseCgroupV2Path([]byte("12:memory:/docker/abc\n1:name=systemd:/docker/abc\n0::/docker/abc\n"))
+		require.NoError(t, err)
+		require.Equal(t, "/docker/abc", pth)
+	}
+
+	t.Log("cgroup v1 only")
+	{
+		_, err := parseCgroupV2Path([]byte("12:memory:/docker/abc\n"))
+		require.Error(t, err)
+	}
+}
+
+func TestCgroupCPUMax(t *testing.T) {
+	require.Equal(t, "200000 100000", cgroupCPUMax(2))
+	require.Equal(t, "50000 100000", cgroupCPUMax(0.5))
+	require.Equal(t, "1000 100000", cgroupCPUMax(0.001))
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+// +build !linux
+
+package tools
+
+import (
+	"errors"
+	"os/exec"
+
+	"github.com/bitrise-io/bitrise/models"
+)
+
+func newProcessLimiter(command *exec.Cmd, limits models.ResourceLimitsModel) (processLimiter, error) {
+	if !isResourceLimitsEmpty(limits) {
+		return processLimiter{}, errors.New("resource limits are not supported on this platform")
+	}
+	return processLimiter{}, nil
+}
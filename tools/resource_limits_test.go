@@ -0,0 +1,38 @@
+package tools
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsResourceLimitsEmpty(t *testing.T) {
+	require.Equal(t, true, isResourceLimitsEmpty(models.ResourceLimitsModel{}))
+	require.Equal(t, false, isResourceLimitsEmpty(models.ResourceLimitsModel{CPUs: 0.5}))
+	require.Equal(t, false, isResourceLimitsEmpty(models.ResourceLimitsModel{MemoryMB: 512}))
+}
+
+func TestHandleUnenforcedResourceLimits(t *testing.T) {
+	defer func() {
+		require.NoError(t, os.Unsetenv(configs.StepResourceLimitsOptionalEnvKey))
+	}()
+
+	t.Log("the step fails by default")
+	{
+		require.NoError(t, os.Unsetenv(configs.StepResourceLimitsOptionalEnvKey))
+		err := handleUnenforcedResourceLimits(errors.New("cgroup v2 is not available"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "cgroup v2 is not available")
+		require.Contains(t, err.Error(), configs.StepResourceLimitsOptionalEnvKey)
+	}
+
+	t.Log("the step runs without its limits, if allowed")
+	{
+		require.NoError(t, os.Setenv(configs.StepResourceLimitsOptionalEnvKey, "true"))
+		require.NoError(t, handleUnenforcedResourceLimits(errors.New("cgroup v2 is not available")))
+	}
+}
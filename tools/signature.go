@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/go-utils/fileutil"
+)
+
+// Signature verification of the downloaded tool binaries.
+// The signatures are detached, cosign (sign-blob) compatible signatures:
+// the base64 encoded, ASN.1 DER ECDSA signature of the binary's SHA256 digest,
+// published next to the release asset (with .sig suffix).
+
+const toolSignatureURLSuffix = ".sig"
+
+// ToolsSigningPublicKey is the base64 encoded (PKIX, DER) ECDSA public key of the tool releases' signing key.
+// It is embedded at build time, with: -ldflags "-X github.com/bitrise-io/bitrise/tools.ToolsSigningPublicKey=..."
+var ToolsSigningPublicKey = ""
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// ParseToolsSigningPublicKey ...
+func ParseToolsSigningPublicKey(encodedKey string) (*ecdsa.PublicKey, error) {
+	if encodedKey == "" {
+		return nil, errors.New("no signing public key embedded in this build")
+	}
+
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encodedKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signing public key, error: %s", err)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing public key, error: %s", err)
+	}
+
+	ecdsaKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("signing public key is not an ECDSA key")
+	}
+	return ecdsaKey, nil
+}
+
+// VerifyToolSignature verifies the detached signature (base64 encoded) of the given content
+func VerifyToolSignature(content, encodedSignature []byte, publicKey *ecdsa.PublicKey) error {
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encodedSignature)))
+	if err != nil {
+		return fmt.Errorf("failed to decode signature, error: %s", err)
+	}
+
+	signature := ecdsaSignature{}
+	if rest, err := asn1.Unmarshal(der, &signature); err != nil {
+		return fmt.Errorf("failed to parse signature, error: %s", err)
+	} else if len(rest) > 0 {
+		return errors.New("failed to parse signature: trailing data")
+	}
+
+	digest := sha256.Sum256(content)
+	if !ecdsa.Verify(publicKey, digest[:], signature.R, signature.S) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func verifyDownloadedToolSignature(binPth, signatureURL string) error {
+	publicKey, err := ParseToolsSigningPublicKey(ToolsSigningPublicKey)
+	if err != nil {
+		return err
+	}
+
+	signature, err := downloadSignature(signatureURL)
+	if err != nil {
+		return err
+	}
+
+	content, err := fileutil.ReadBytesFromFile(binPth)
+	if err != nil {
+		return err
+	}
+
+	return VerifyToolSignature(content, signature, publicKey)
+}
+
+func downloadSignature(signatureURL string) ([]byte, error) {
+	resp, err := configs.HTTPClient().Get(signatureURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download signature from (%s), error: %s", signatureURL, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Warnf("failed to close (%s) body", signatureURL)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download signature from (%s), status code: %d (the release might be unsigned)", signatureURL, resp.StatusCode)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
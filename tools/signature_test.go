@@ -0,0 +1,135 @@
+package tools
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func signContent(t *testing.T, key *ecdsa.PrivateKey, content []byte) []byte {
+	digest := sha256.Sum256(content)
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+
+	der, err := asn1.Marshal(ecdsaSignature{R: r, S: s})
+	require.NoError(t, err)
+
+	return []byte(base64.StdEncoding.EncodeToString(der))
+}
+
+func encodePublicKey(t *testing.T, key *ecdsa.PrivateKey) string {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(der)
+}
+
+func TestVerifyToolSignature(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	publicKey, err := ParseToolsSigningPublicKey(encodePublicKey(t, key))
+	require.NoError(t, err)
+
+	content := []byte("tool binary")
+	signature := signContent(t, key, content)
+
+	t.Log("valid signature")
+	{
+		require.NoError(t, VerifyToolSignature(content, signature, publicKey))
+	}
+
+	t.Log("tampered content")
+	{
+		require.EqualError(t, VerifyToolSignature([]byte("tampered binary"), signature, publicKey), "invalid signature")
+	}
+
+	t.Log("signed by an other key")
+	{
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		require.EqualError(t, VerifyToolSignature(content, signContent(t, otherKey, content), publicKey), "invalid signature")
+	}
+
+	t.Log("malformed signature")
+	{
+		require.Error(t, VerifyToolSignature(content, []byte("not a signature"), publicKey))
+	}
+
+	t.Log("no embedded public key")
+	{
+		_, err := ParseToolsSigningPublicKey("")
+		require.EqualError(t, err, "no signing public key embedded in this build")
+	}
+}
+
+func TestInstallFromURLToDirWithSignatureVerification(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	content := []byte("#!/bin/bash\necho tool\n")
+	signature := signContent(t, key, content)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/signed", "/unsigned":
+			_, err := w.Write(content)
+			require.NoError(t, err)
+		case "/signed.sig":
+			_, err := w.Write(signature)
+			require.NoError(t, err)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	originalKey := ToolsSigningPublicKey
+	ToolsSigningPublicKey = encodePublicKey(t, key)
+	require.NoError(t, os.Setenv(configs.VerifyToolSignaturesEnvKey, "true"))
+	defer func() {
+		ToolsSigningPublicKey = originalKey
+		require.NoError(t, os.Unsetenv(configs.VerifyToolSignaturesEnvKey))
+	}()
+
+	t.Log("signed tool")
+	{
+		dirPth, err := pathutil.NormalizedOSTempDirPath("signed_tool")
+		require.NoError(t, err)
+
+		require.NoError(t, installFromURLToDir("tool", server.URL+"/signed", dirPth))
+
+		exist, err := pathutil.IsPathExists(filepath.Join(dirPth, "tool"))
+		require.NoError(t, err)
+		require.Equal(t, true, exist)
+	}
+
+	t.Log("unsigned tool")
+	{
+		dirPth, err := pathutil.NormalizedOSTempDirPath("unsigned_tool")
+		require.NoError(t, err)
+
+		require.Error(t, installFromURLToDir("tool", server.URL+"/unsigned", dirPth))
+
+		exist, err := pathutil.IsPathExists(filepath.Join(dirPth, "tool"))
+		require.NoError(t, err)
+		require.Equal(t, false, exist)
+
+		exist, err = pathutil.IsPathExists(filepath.Join(dirPth, "tool.download"))
+		require.NoError(t, err)
+		require.Equal(t, false, exist)
+	}
+}
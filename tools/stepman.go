@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/go-utils/cmdex"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// StepmanStepLibStepInfo returns the info of the given step version (or of the latest version if version is empty).
+func StepmanStepLibStepInfo(collection, stepID, stepVersion string) (stepmanModels.StepInfoModel, error) {
+	logLevel := log.GetLevel().String()
+	args := []string{"--debug", "--loglevel", logLevel, "step-info", "--collection", configs.MirrorURL(collection),
+		"--id", stepID, "--version", stepVersion, "--format", "json"}
+
+	info, err := stepmanStepInfoFromCommand(args)
+	if err != nil {
+		return stepmanModels.StepInfoModel{}, err
+	}
+	info.StepLib = collection
+
+	return info, nil
+}
+
+// StepmanLocalStepInfo returns the info of the step defined by the given step.yml.
+func StepmanLocalStepInfo(pth string) (stepmanModels.StepInfoModel, error) {
+	logLevel := log.GetLevel().String()
+	args := []string{"--debug", "--loglevel", logLevel, "step-info", "--step-yml", pth, "--format", "json"}
+
+	return stepmanStepInfoFromCommand(args)
+}
+
+// StepmanActivate copies the given step version's source into dir,
+// and its step.yml to ymlPth (if ymlPth is not empty).
+func StepmanActivate(collection, stepID, stepVersion, dir, ymlPth string) error {
+	logLevel := log.GetLevel().String()
+	args := []string{"--debug", "--loglevel", logLevel, "activate", "--collection", configs.MirrorURL(collection),
+		"--id", stepID, "--version", stepVersion, "--path", dir, "--copyyml", ymlPth}
+
+	return runWithGitCredentials("", "stepman", args...)
+}
+
+func stepmanStepInfoFromCommand(args []string) (stepmanModels.StepInfoModel, error) {
+	var outBuffer bytes.Buffer
+	var errBuffer bytes.Buffer
+
+	if err := cmdex.RunCommandWithWriters(io.Writer(&outBuffer), io.Writer(&errBuffer), "stepman", args...); err != nil {
+		return stepmanModels.StepInfoModel{}, fmt.Errorf("Error: %s, details: %s", err, errBuffer.String())
+	}
+
+	return stepmanModels.StepInfoModel{}.CreateFromJSON(outBuffer.String())
+}
@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// Markdown step info: the step's description, inputs and outputs rendered as a markdown document,
+// with the inputs and outputs as tables, so the step's documentation can be embedded into documentation pages.
+
+// markdownTableCell escapes the value to fit into a single markdown table cell
+func markdownTableCell(value string) string {
+	value = strings.TrimSpace(value)
+	value = strings.Replace(value, "|", `\|`, -1)
+	value = strings.Replace(value, "\r\n", "\n", -1)
+	return strings.Replace(value, "\n", "<br>", -1)
+}
+
+// markdownCode returns the value as inline code, or an empty string if the value is empty
+func markdownCode(value string) string {
+	if value == "" {
+		return ""
+	}
+	if strings.Contains(value, "`") {
+		// a backtick in the code requires a longer delimiter
+		return "`` " + markdownTableCell(value) + " ``"
+	}
+	return "`" + markdownTableCell(value) + "`"
+}
+
+func markdownInputsTable(inputs []stepmanModels.EnvInfoModel) string {
+	lines := []string{
+		"| Key | Title | Description | Default value | Value options |",
+		"| --- | --- | --- | --- | --- |",
+	}
+	for _, input := range inputs {
+		options := []string{}
+		for _, option := range input.ValueOptions {
+			options = append(options, markdownCode(option))
+		}
+		lines = append(lines, fmt.Sprintf("| %s | %s | %s | %s | %s |",
+			markdownCode(input.Key), markdownTableCell(input.Title), markdownTableCell(input.Description),
+			markdownCode(input.DefaultValue), strings.Join(options, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func markdownOutputsTable(outputs []stepmanModels.EnvInfoModel) string {
+	lines := []string{
+		"| Key | Title | Description |",
+		"| --- | --- | --- |",
+	}
+	for _, output := range outputs {
+		lines = append(lines, fmt.Sprintf("| %s | %s | %s |",
+			markdownCode(output.Key), markdownTableCell(output.Title), markdownTableCell(output.Description)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// StepInfoMarkdown renders the step info as a markdown document
+func StepInfoMarkdown(info stepmanModels.StepInfoModel) string {
+	sections := []string{}
+
+	title := info.Title
+	if title == "" {
+		title = info.ID
+	}
+	sections = append(sections, "# "+title)
+
+	details := []string{}
+	if info.ID != "" {
+		details = append(details, "**ID:** "+markdownCode(info.ID))
+	}
+	if info.Version != "" {
+		details = append(details, "**Version:** "+markdownCode(info.Version))
+	}
+	if info.Latest != "" && info.Latest != info.Version {
+		details = append(details, "**Latest version:** "+markdownCode(info.Latest))
+	}
+	if info.StepLib != "" {
+		details = append(details, "**StepLib:** "+info.StepLib)
+	}
+	if info.SourceCodeURL != "" {
+		details = append(details, "**Source code:** "+info.SourceCodeURL)
+	}
+	if info.SupportURL != "" {
+		details = append(details, "**Support:** "+info.SupportURL)
+	}
+	if len(details) > 0 {
+		sections = append(sections, strings.Join(details, "  \n"))
+	}
+
+	if info.Description != "" {
+		sections = append(sections, "## Description\n\n"+strings.TrimSpace(info.Description))
+	}
+
+	if len(info.Inputs) > 0 {
+		sections = append(sections, "## Inputs\n\n"+markdownInputsTable(info.Inputs))
+	}
+
+	if len(info.Outputs) > 0 {
+		sections = append(sections, "## Outputs\n\n"+markdownOutputsTable(info.Outputs))
+	}
+
+	return strings.Join(sections, "\n\n") + "\n"
+}
+
+// StepmanMarkdownStepLibStepInfo ...
+func StepmanMarkdownStepLibStepInfo(collection, stepID, stepVersion string) (string, error) {
+	info, err := StepmanStepLibStepInfo(collection, stepID, stepVersion)
+	if err != nil {
+		return "", err
+	}
+	return StepInfoMarkdown(info), nil
+}
+
+// StepmanMarkdownLocalStepInfo ...
+func StepmanMarkdownLocalStepInfo(pth string) (string, error) {
+	info, err := StepmanLocalStepInfo(pth)
+	if err != nil {
+		return "", err
+	}
+	return StepInfoMarkdown(info), nil
+}
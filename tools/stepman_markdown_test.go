@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"testing"
+
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepInfoMarkdown(t *testing.T) {
+	info := stepmanModels.StepInfoModel{
+		ID:            "script",
+		Title:         "Script",
+		Version:       "1.1.3",
+		Latest:        "1.2.0",
+		SourceCodeURL: "https://github.com/bitrise-io/steps-script",
+		Description:   "Runs the given script.\n",
+		Inputs: []stepmanModels.EnvInfoModel{
+			stepmanModels.EnvInfoModel{Key: "content", Title: "Script content", Description: "The script,\nwith | pipes.", DefaultValue: "echo `date`"},
+			stepmanModels.EnvInfoModel{Key: "is_debug", Title: "Debug?", DefaultValue: "no", ValueOptions: []string{"yes", "no"}},
+		},
+		Outputs: []stepmanModels.EnvInfoModel{
+			stepmanModels.EnvInfoModel{Key: "SCRIPT_OUTPUT", Title: "Output"},
+		},
+	}
+
+	require.Equal(t, "# Script\n\n"+
+		"**ID:** `script`  \n**Version:** `1.1.3`  \n**Latest version:** `1.2.0`  \n**Source code:** https://github.com/bitrise-io/steps-script\n\n"+
+		"## Description\n\nRuns the given script.\n\n"+
+		"## Inputs\n\n"+
+		"| Key | Title | Description | Default value | Value options |\n"+
+		"| --- | --- | --- | --- | --- |\n"+
+		"| `content` | Script content | The script,<br>with \\| pipes. | `` echo `date` `` |  |\n"+
+		"| `is_debug` | Debug? |  | `no` | `yes`, `no` |\n\n"+
+		"## Outputs\n\n"+
+		"| Key | Title | Description |\n"+
+		"| --- | --- | --- |\n"+
+		"| `SCRIPT_OUTPUT` | Output |  |\n", StepInfoMarkdown(info))
+
+	require.Equal(t, "# my-step\n\n**ID:** `my-step`\n", StepInfoMarkdown(stepmanModels.StepInfoModel{ID: "my-step"}))
+}
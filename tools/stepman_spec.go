@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+// StepmanHomeDirPath ...
+func StepmanHomeDirPath() string {
+	return filepath.Join(pathutil.UserHomeDir(), ".stepman")
+}
+
+func stepmanRoutingFilePath() string {
+	return filepath.Join(StepmanHomeDirPath(), "routing.json")
+}
+
+// StepmanStepLibSpecPath returns the path of the local spec (spec.json) of the given,
+// already set up StepLib collection.
+func StepmanStepLibSpecPath(collection string) (string, error) {
+	routingBytes, err := fileutil.ReadBytesFromFile(stepmanRoutingFilePath())
+	if err != nil {
+		return "", fmt.Errorf("failed to read stepman routing, error: %s", err)
+	}
+
+	routing := map[string]string{}
+	if err := json.Unmarshal(routingBytes, &routing); err != nil {
+		return "", fmt.Errorf("failed to parse stepman routing, error: %s", err)
+	}
+
+	alias, found := routing[collection]
+	if !found {
+		return "", fmt.Errorf("StepLib (%s) is not set up", collection)
+	}
+
+	return filepath.Join(StepmanHomeDirPath(), "step_collections", alias, "spec", "spec.json"), nil
+}
+
+// StepmanStepLibSpec reads the local spec of the given, already set up StepLib collection.
+func StepmanStepLibSpec(collection string) (stepmanModels.StepCollectionModel, error) {
+	specPth, err := StepmanStepLibSpecPath(collection)
+	if err != nil {
+		return stepmanModels.StepCollectionModel{}, err
+	}
+
+	specBytes, err := fileutil.ReadBytesFromFile(specPth)
+	if err != nil {
+		return stepmanModels.StepCollectionModel{}, fmt.Errorf("failed to read StepLib (%s) spec, error: %s", collection, err)
+	}
+
+	spec := stepmanModels.StepCollectionModel{}
+	if err := json.Unmarshal(specBytes, &spec); err != nil {
+		return stepmanModels.StepCollectionModel{}, fmt.Errorf("failed to parse StepLib (%s) spec, error: %s", collection, err)
+	}
+
+	return spec, nil
+}
+
+// StepmanStepLibStep returns the definition (step.yml) of the given step version (of the latest version, if stepVersion is empty),
+// read from the local spec of the given, already set up StepLib collection.
+func StepmanStepLibStep(collection, stepID, stepVersion string) (stepmanModels.StepModel, error) {
+	mirroredCollection := configs.MirrorURL(collection)
+
+	spec, err := StepmanStepLibSpec(mirroredCollection)
+	if err != nil {
+		return stepmanModels.StepModel{}, err
+	}
+
+	if stepVersion == "" {
+		stepVersion, err = spec.GetLatestStepVersion(stepID)
+		if err != nil {
+			return stepmanModels.StepModel{}, err
+		}
+	}
+
+	step, found := spec.GetStep(stepID, stepVersion)
+	if !found {
+		return stepmanModels.StepModel{}, fmt.Errorf("Collection (%s) doesn't contain step (%s) with version (%s)", collection, stepID, stepVersion)
+	}
+	if err := step.FillMissingDefaults(); err != nil {
+		return stepmanModels.StepModel{}, err
+	}
+	return step, nil
+}
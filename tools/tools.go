@@ -4,15 +4,18 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
 	"github.com/bitrise-io/go-utils/cmdex"
 	"github.com/bitrise-io/go-utils/errorutil"
 )
@@ -24,47 +27,128 @@ func UnameGOOS() (string, error) {
 		return "Darwin", nil
 	case "linux":
 		return "Linux", nil
+	case "windows":
+		return "Windows", nil
 	}
 	return "", fmt.Errorf("Unsupported platform (%s)", runtime.GOOS)
 }
 
+// ExecutableFileName returns the file name of the tool's executable on the platform (e.g. envman.exe on Windows)
+func ExecutableFileName(toolname string) string {
+	return executableFileName(toolname, runtime.GOOS)
+}
+
+func executableFileName(toolname, goos string) string {
+	if goos == "windows" && !strings.HasSuffix(strings.ToLower(toolname), ".exe") {
+		return toolname + ".exe"
+	}
+	return toolname
+}
+
 // UnameGOARCH ...
 func UnameGOARCH() (string, error) {
-	switch runtime.GOARCH {
+	return unameGOARCH(runtime.GOARCH)
+}
+
+// unameGOARCH returns the architecture name used in the tools' release assets (the output of uname -m)
+func unameGOARCH(goarch string) (string, error) {
+	switch goarch {
 	case "amd64":
 		return "x86_64", nil
+	case "arm":
+		// 32-bit ARM (e.g. Raspberry Pi), the assets are built for ARMv7 (GOARM=7)
+		return "armv7l", nil
 	}
-	return "", fmt.Errorf("Unsupported architecture (%s)", runtime.GOARCH)
+	return "", fmt.Errorf("Unsupported architecture (%s)", goarch)
 }
 
-// InstallToolFromGitHub ...
-func InstallToolFromGitHub(toolname, githubUser, toolVersion string) error {
+func toolDownloadURL(toolname, githubUser, toolVersion string) (string, error) {
 	unameGOOS, err := UnameGOOS()
 	if err != nil {
-		return fmt.Errorf("Failed to determine OS: %s", err)
+		return "", fmt.Errorf("Failed to determine OS: %s", err)
 	}
 	unameGOARCH, err := UnameGOARCH()
 	if err != nil {
-		return fmt.Errorf("Failed to determine ARCH: %s", err)
+		return "", fmt.Errorf("Failed to determine ARCH: %s", err)
+	}
+	assetName := executableFileName(toolname+"-"+unameGOOS+"-"+unameGOARCH, runtime.GOOS)
+	downloadURL := "https://github.com/" + githubUser + "/" + toolname + "/releases/download/" + toolVersion + "/" + assetName
+	return configs.MirrorURL(downloadURL), nil
+}
+
+// InstallToolFromGitHub installs the given version of the tool (or its latest release, if the version is latest)
+func InstallToolFromGitHub(toolname, githubUser, toolVersion string) error {
+	toolVersion, err := ResolveToolVersion(toolname, githubUser, toolVersion)
+	if err != nil {
+		return err
 	}
-	downloadURL := "https://github.com/" + githubUser + "/" + toolname + "/releases/download/" + toolVersion + "/" + toolname + "-" + unameGOOS + "-" + unameGOARCH
 
-	return InstallFromURL(toolname, downloadURL)
+	return installToolFromGitHubToDir(toolname, githubUser, toolVersion, configs.GetBitriseToolsDirPath())
 }
 
-// DownloadFile ...
+// InstallToolVersionFromGitHub installs the given version of the tool (or its latest release, if the version is latest)
+// into its own directory (configs.GetBitriseToolVersionDirPath), without replacing the globally installed version.
+func InstallToolVersionFromGitHub(toolname, githubUser, toolVersion string) error {
+	toolVersion, err := ResolveToolVersion(toolname, githubUser, toolVersion)
+	if err != nil {
+		return err
+	}
+
+	return installToolFromGitHubToDir(toolname, githubUser, toolVersion, configs.GetBitriseToolVersionDirPath(toolname, toolVersion))
+}
+
+// installToolFromGitHubToDir installs the tool's release asset of the platform,
+// if the release has no asset with the expected name, the best matching asset of the release is installed (see: selectReleaseAsset).
+func installToolFromGitHubToDir(toolname, githubUser, toolVersion, dirPth string) error {
+	downloadURL, err := toolDownloadURL(toolname, githubUser, toolVersion)
+	if err != nil {
+		return err
+	}
+
+	err = installFromURLToDir(toolname, downloadURL, dirPth)
+	if statusErr, ok := err.(*DownloadStatusError); !ok || statusErr.StatusCode != http.StatusNotFound {
+		return err
+	}
+
+	log.Warnf("Release asset not found (%s), looking for a matching asset of the release...", downloadURL)
+	assetURL, err := findReleaseAssetURL(toolname, githubUser, toolVersion, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return err
+	}
+	log.Infof("Installing the matching release asset: %s", assetURL)
+
+	return installFromURLToDir(toolname, configs.MirrorURL(assetURL), dirPth)
+}
+
+// DownloadStatusError is returned by DownloadFile, if the server responds with a non 200 status code
+type DownloadStatusError struct {
+	URL        string
+	StatusCode int
+}
+
+// Error ...
+func (err *DownloadStatusError) Error() string {
+	return fmt.Sprintf("failed to download from (%s), status code: %d", err.URL, err.StatusCode)
+}
+
+// DownloadFile downloads the file into the target path,
+// fails with a *DownloadStatusError if the server responds with a non 200 status code.
+// The file is downloaded into a <target path>.part file first, and renamed to the target path once it's complete.
+// If the download fails, the .part file is kept, and the next download of the same URL resumes it (by a Range request).
 func DownloadFile(downloadURL, targetDirPath string) error {
-	outFile, err := os.Create(targetDirPath)
-	defer func() {
-		if err := outFile.Close(); err != nil {
-			log.Warnf("Failed to close (%s)", targetDirPath)
-		}
-	}()
+	partPth := targetDirPath + downloadPartFileSuffix
+
+	offset := resumableDownloadOffset(downloadURL, partPth)
+	request, err := http.NewRequest("GET", downloadURL, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create (%s), error: %s", targetDirPath, err)
+		return fmt.Errorf("failed to create request (%s), error: %s", downloadURL, err)
+	}
+	if offset > 0 {
+		log.Debugf("Resuming download of (%s) from %d bytes", downloadURL, offset)
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
 	}
 
-	resp, err := http.Get(downloadURL)
+	resp, err := configs.HTTPClient().Do(request)
 	if err != nil {
 		return fmt.Errorf("failed to download from (%s), error: %s", downloadURL, err)
 	}
@@ -74,27 +158,122 @@ func DownloadFile(downloadURL, targetDirPath string) error {
 		}
 	}()
 
-	_, err = io.Copy(outFile, resp.Body)
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+		flag = os.O_WRONLY | os.O_APPEND
+	case offset > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		// the partial file is not part of the current file, start over
+		removeDownloadPartFile(partPth)
+		return DownloadFile(downloadURL, targetDirPath)
+	case resp.StatusCode != http.StatusOK:
+		return &DownloadStatusError{URL: downloadURL, StatusCode: resp.StatusCode}
+	}
+
+	if err := ioutil.WriteFile(partPth+downloadPartURLFileSuffix, []byte(downloadURL), 0644); err != nil {
+		return fmt.Errorf("failed to create (%s), error: %s", partPth+downloadPartURLFileSuffix, err)
+	}
+
+	outFile, err := os.OpenFile(partPth, flag, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create (%s), error: %s", partPth, err)
+	}
+
+	if flag&os.O_APPEND == 0 {
+		offset = 0
+	}
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	progress := newDownloadProgressWriter(downloadURL, offset, total)
+
+	_, err = io.Copy(io.MultiWriter(outFile, progress), resp.Body)
+	progress.Finish()
 	if err != nil {
+		if closeErr := outFile.Close(); closeErr != nil {
+			log.Warnf("Failed to close (%s)", partPth)
+		}
 		return fmt.Errorf("failed to download from (%s), error: %s", downloadURL, err)
 	}
+	if err := outFile.Close(); err != nil {
+		return fmt.Errorf("failed to write (%s), error: %s", partPth, err)
+	}
+
+	if err := os.Rename(partPth, targetDirPath); err != nil {
+		return fmt.Errorf("failed to move (%s) to (%s), error: %s", partPth, targetDirPath, err)
+	}
+	removeDownloadPartFile(partPth)
 
 	return nil
 }
 
+const (
+	downloadPartFileSuffix    = ".part"
+	downloadPartURLFileSuffix = ".url"
+)
+
+// resumableDownloadOffset returns the size of the partially downloaded file,
+// if it's the partial download of the same URL, otherwise 0
+func resumableDownloadOffset(downloadURL, partPth string) int64 {
+	urlBytes, err := ioutil.ReadFile(partPth + downloadPartURLFileSuffix)
+	if err != nil || string(urlBytes) != downloadURL {
+		return 0
+	}
+	info, err := os.Stat(partPth)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func removeDownloadPartFile(partPth string) {
+	for _, pth := range []string{partPth, partPth + downloadPartURLFileSuffix} {
+		if err := os.Remove(pth); err != nil && !os.IsNotExist(err) {
+			log.Warnf("Failed to remove (%s), error: %s", pth, err)
+		}
+	}
+}
+
 // InstallFromURL ...
 func InstallFromURL(toolBinName, downloadURL string) error {
 	if len(toolBinName) < 1 {
 		return fmt.Errorf("No Tool (bin) Name provided! URL was: %s", downloadURL)
 	}
 
-	bitriseToolsDirPath := configs.GetBitriseToolsDirPath()
-	destinationPth := filepath.Join(bitriseToolsDirPath, toolBinName)
+	return installFromURLToDir(toolBinName, downloadURL, configs.GetBitriseToolsDirPath())
+}
+
+func installFromURLToDir(toolBinName, downloadURL, dirPth string) error {
+	if err := os.MkdirAll(dirPth, 0777); err != nil {
+		return fmt.Errorf("Failed to create directory (%s), error: %s", dirPth, err)
+	}
+	destinationPth := filepath.Join(dirPth, ExecutableFileName(toolBinName))
+	downloadPth := destinationPth + ".download"
 
-	if err := DownloadFile(downloadURL, destinationPth); err != nil {
+	defer func() {
+		if err := os.RemoveAll(downloadPth); err != nil {
+			log.Warnf("Failed to remove (%s), error: %s", downloadPth, err)
+		}
+	}()
+	if err := DownloadFile(downloadURL, downloadPth); err != nil {
+		if statusErr, ok := err.(*DownloadStatusError); ok {
+			return statusErr
+		}
 		return fmt.Errorf("Failed to download, error: %s", err)
 	}
 
+	if configs.IsToolSignatureVerificationRequired() {
+		if err := verifyDownloadedToolSignature(downloadPth, downloadURL+toolSignatureURLSuffix); err != nil {
+			return fmt.Errorf("Refusing to install %s: signature verification failed, error: %s", toolBinName, err)
+		}
+		log.Debugf("Signature of %s verified", toolBinName)
+	}
+
+	if err := os.Rename(downloadPth, destinationPth); err != nil {
+		return fmt.Errorf("Failed to move (%s) to (%s), error: %s", downloadPth, destinationPth, err)
+	}
+
 	if err := os.Chmod(destinationPth, 0755); err != nil {
 		return fmt.Errorf("Failed to make file (%s) executable, error: %s", destinationPth, err)
 	}
@@ -107,28 +286,26 @@ func InstallFromURL(toolBinName, downloadURL string) error {
 
 // StepmanSetup ...
 func StepmanSetup(collection string) error {
-	logLevel := log.GetLevel().String()
-	args := []string{"--debug", "--loglevel", logLevel, "setup", "--collection", collection}
-	return cmdex.RunCommand("stepman", args...)
-}
+	collection = configs.MirrorURL(collection)
 
-// StepmanActivate ...
-func StepmanActivate(collection, stepID, stepVersion, dir, ymlPth string) error {
 	logLevel := log.GetLevel().String()
-	args := []string{"--debug", "--loglevel", logLevel, "activate", "--collection", collection,
-		"--id", stepID, "--version", stepVersion, "--path", dir, "--copyyml", ymlPth}
-	return cmdex.RunCommand("stepman", args...)
+	args := []string{"--debug", "--loglevel", logLevel, "setup", "--collection", collection}
+	return runWithGitCredentials("", "stepman", args...)
 }
 
 // StepmanUpdate ...
 func StepmanUpdate(collection string) error {
+	collection = configs.MirrorURL(collection)
+
 	logLevel := log.GetLevel().String()
 	args := []string{"--debug", "--loglevel", logLevel, "update", "--collection", collection}
-	return cmdex.RunCommand("stepman", args...)
+	return runWithGitCredentials("", "stepman", args...)
 }
 
 // StepmanRawStepLibStepInfo ...
 func StepmanRawStepLibStepInfo(collection, stepID, stepVersion string) (string, error) {
+	collection = configs.MirrorURL(collection)
+
 	logLevel := log.GetLevel().String()
 	args := []string{"--debug", "--loglevel", logLevel, "step-info", "--collection", collection,
 		"--id", stepID, "--version", stepVersion, "--format", "raw"}
@@ -142,39 +319,10 @@ func StepmanRawLocalStepInfo(pth string) (string, error) {
 	return cmdex.RunCommandAndReturnCombinedStdoutAndStderr("stepman", args...)
 }
 
-// StepmanJSONStepLibStepInfo ...
-func StepmanJSONStepLibStepInfo(collection, stepID, stepVersion string) (string, error) {
-	logLevel := log.GetLevel().String()
-	args := []string{"--debug", "--loglevel", logLevel, "step-info", "--collection", collection,
-		"--id", stepID, "--version", stepVersion, "--format", "json"}
-
-	var outBuffer bytes.Buffer
-	var errBuffer bytes.Buffer
-
-	if err := cmdex.RunCommandWithWriters(io.Writer(&outBuffer), io.Writer(&errBuffer), "stepman", args...); err != nil {
-		return outBuffer.String(), fmt.Errorf("Error: %s, details: %s", err, errBuffer.String())
-	}
-
-	return outBuffer.String(), nil
-}
-
-// StepmanJSONLocalStepInfo ...
-func StepmanJSONLocalStepInfo(pth string) (string, error) {
-	logLevel := log.GetLevel().String()
-	args := []string{"--debug", "--loglevel", logLevel, "step-info", "--step-yml", pth, "--format", "json"}
-
-	var outBuffer bytes.Buffer
-	var errBuffer bytes.Buffer
-
-	if err := cmdex.RunCommandWithWriters(io.Writer(&outBuffer), io.Writer(&errBuffer), "stepman", args...); err != nil {
-		return outBuffer.String(), fmt.Errorf("Error: %s, details: %s", err, errBuffer.String())
-	}
-
-	return outBuffer.String(), nil
-}
-
 // StepmanRawStepList ...
 func StepmanRawStepList(collection string) (string, error) {
+	collection = configs.MirrorURL(collection)
+
 	logLevel := log.GetLevel().String()
 	args := []string{"--debug", "--loglevel", logLevel, "step-list", "--collection", collection, "--format", "raw"}
 	return cmdex.RunCommandAndReturnCombinedStdoutAndStderr("stepman", args...)
@@ -182,6 +330,8 @@ func StepmanRawStepList(collection string) (string, error) {
 
 // StepmanJSONStepList ...
 func StepmanJSONStepList(collection string) (string, error) {
+	collection = configs.MirrorURL(collection)
+
 	logLevel := log.GetLevel().String()
 	args := []string{"--debug", "--loglevel", logLevel, "step-list", "--collection", collection, "--format", "json"}
 
@@ -198,39 +348,92 @@ func StepmanJSONStepList(collection string) (string, error) {
 //
 // Share
 
+var isShareNonInteractive = false
+
+// SetStepmanShareNonInteractive makes the stepman share commands non-interactive:
+// the commands get an empty stdin, and a prompt of stepman fails the command, instead of waiting for a user input.
+// Every input of the share commands can be specified with flags.
+func SetStepmanShareNonInteractive() {
+	isShareNonInteractive = true
+}
+
+// stepmanPromptWriter forwards the output of stepman, and keeps its last, unterminated line:
+// stepman prints a prompt (question [yes/no]: ) without a line break, right before it reads the answer.
+type stepmanPromptWriter struct {
+	writer   io.Writer
+	lastLine []byte
+}
+
+// stepmanPromptMaxLength is the max length of the kept (last) output line
+const stepmanPromptMaxLength = 1024
+
+func (writer *stepmanPromptWriter) Write(p []byte) (int, error) {
+	if idx := bytes.LastIndexByte(p, '\n'); idx >= 0 {
+		writer.lastLine = append([]byte{}, p[idx+1:]...)
+	} else {
+		writer.lastLine = append(writer.lastLine, p...)
+	}
+	if len(writer.lastLine) > stepmanPromptMaxLength {
+		writer.lastLine = writer.lastLine[len(writer.lastLine)-stepmanPromptMaxLength:]
+	}
+	return writer.writer.Write(p)
+}
+
+// prompt returns the prompt stepman printed last, or an empty string
+func (writer *stepmanPromptWriter) prompt() string {
+	line := strings.TrimSpace(string(writer.lastLine))
+	if !strings.HasSuffix(line, ":") {
+		return ""
+	}
+	return line
+}
+
+func runStepmanShare(args ...string) error {
+	logLevel := log.GetLevel().String()
+	commandArgs := append([]string{"--loglevel", logLevel, "share"}, args...)
+	commandArgs = append(commandArgs, "--toolmode")
+
+	if !isShareNonInteractive {
+		return cmdex.NewCommand("stepman", commandArgs...).SetStdin(os.Stdin).SetStdout(os.Stdout).SetStderr(os.Stderr).Run()
+	}
+
+	stdout := &stepmanPromptWriter{writer: os.Stdout}
+	err := cmdex.NewCommand("stepman", commandArgs...).SetStdout(stdout).SetStderr(os.Stderr).Run()
+	if err != nil {
+		if prompt := stdout.prompt(); prompt != "" {
+			command := "share"
+			if len(args) > 0 {
+				command += " " + args[0]
+			}
+			return fmt.Errorf("stepman %s asked for an input (%s), it can't be answered in a non-interactive run, run the command interactively", command, prompt)
+		}
+	}
+	return err
+}
+
 // StepmanShare ...
 func StepmanShare() error {
-	logLevel := log.GetLevel().String()
-	args := []string{"--loglevel", logLevel, "share", "--toolmode"}
-	return cmdex.RunCommand("stepman", args...)
+	return runStepmanShare()
 }
 
 // StepmanShareAudit ...
 func StepmanShareAudit() error {
-	logLevel := log.GetLevel().String()
-	args := []string{"--loglevel", logLevel, "share", "audit", "--toolmode"}
-	return cmdex.RunCommand("stepman", args...)
+	return runStepmanShare("audit")
 }
 
 // StepmanShareCreate ...
 func StepmanShareCreate(tag, git, stepID string) error {
-	logLevel := log.GetLevel().String()
-	args := []string{"--loglevel", logLevel, "share", "create", "--tag", tag, "--git", git, "--stepid", stepID, "--toolmode"}
-	return cmdex.RunCommand("stepman", args...)
+	return runStepmanShare("create", "--tag", tag, "--git", git, "--stepid", stepID)
 }
 
 // StepmanShareFinish ...
 func StepmanShareFinish() error {
-	logLevel := log.GetLevel().String()
-	args := []string{"--loglevel", logLevel, "share", "finish", "--toolmode"}
-	return cmdex.RunCommand("stepman", args...)
+	return runStepmanShare("finish")
 }
 
 // StepmanShareStart ...
 func StepmanShareStart(collection string) error {
-	logLevel := log.GetLevel().String()
-	args := []string{"--loglevel", logLevel, "share", "start", "--collection", collection, "--toolmode"}
-	return cmdex.RunCommand("stepman", args...)
+	return runStepmanShare("start", "--collection", collection)
 }
 
 // ------------------
@@ -240,14 +443,30 @@ func StepmanShareStart(collection string) error {
 func EnvmanInit() error {
 	logLevel := log.GetLevel().String()
 	args := []string{"--loglevel", logLevel, "init"}
-	return cmdex.RunCommand("envman", args...)
+	out, err := cmdex.NewCommand("envman", args...).RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		errorMsg := err.Error()
+		if errorutil.IsExitStatusError(err) && out != "" {
+			errorMsg = out
+		}
+		return fmt.Errorf("failed to init envstore, error: %s", errorMsg)
+	}
+	return nil
 }
 
 // EnvmanInitAtPath ...
 func EnvmanInitAtPath(envstorePth string) error {
 	logLevel := log.GetLevel().String()
 	args := []string{"--loglevel", logLevel, "--path", envstorePth, "init", "--clear"}
-	return cmdex.RunCommand("envman", args...)
+	out, err := cmdex.NewCommand("envman", args...).RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		errorMsg := err.Error()
+		if errorutil.IsExitStatusError(err) && out != "" {
+			errorMsg = out
+		}
+		return fmt.Errorf("failed to init envstore (%s), error: %s", envstorePth, errorMsg)
+	}
+	return nil
 }
 
 // EnvmanAdd ...
@@ -261,11 +480,18 @@ func EnvmanAdd(envstorePth, key, value string, expand, skipIfEmpty bool) error {
 		args = append(args, "--skip-if-empty")
 	}
 
+	var errBuffer bytes.Buffer
 	envman := exec.Command("envman", args...)
 	envman.Stdin = strings.NewReader(value)
 	envman.Stdout = os.Stdout
-	envman.Stderr = os.Stderr
-	return envman.Run()
+	envman.Stderr = io.MultiWriter(os.Stderr, &errBuffer)
+	if err := envman.Run(); err != nil {
+		if errorutil.IsExitStatusError(err) && errBuffer.Len() > 0 {
+			return fmt.Errorf("failed to add env (%s) to envstore (%s), error: %s", key, envstorePth, strings.TrimSpace(errBuffer.String()))
+		}
+		return err
+	}
+	return nil
 }
 
 // EnvmanClear ...
@@ -283,13 +509,163 @@ func EnvmanClear(envstorePth string) error {
 	return nil
 }
 
-// EnvmanRun ...
+// runningProcess is the currently running step (or plugin),
+// isRunningProcessGroup is true if it is the leader of its own process group (see: setProcessGroup)
+var (
+	runningProcessMutex   sync.Mutex
+	runningProcess        *os.Process
+	isRunningProcessGroup bool
+)
+
+// stepLogWriter and stepLogStreamWriter receive a copy of the step's (and plugin's) output, if set
+var (
+	stepLogWriterMutex  sync.Mutex
+	stepLogWriter       io.Writer
+	stepLogStreamWriter io.Writer
+)
+
+// stepOutputRedactor redacts the step's (and plugin's) output, if set
+var stepOutputRedactor func(string) string
+
+// SetStepOutputRedactor sets the function, which redacts the output of the steps (and plugins) line by line,
+// before it's written to the console and to the step log writers. Set it to nil to stop redacting the output.
+func SetStepOutputRedactor(redact func(string) string) {
+	stepLogWriterMutex.Lock()
+	defer stepLogWriterMutex.Unlock()
+
+	stepOutputRedactor = redact
+}
+
+func getStepOutputRedactor() func(string) string {
+	stepLogWriterMutex.Lock()
+	defer stepLogWriterMutex.Unlock()
+
+	return stepOutputRedactor
+}
+
+// SetStepLogWriter sets the writer, which receives a copy of the output of the steps (and plugins),
+// next to the console. Set it to nil to stop capturing the output.
+func SetStepLogWriter(writer io.Writer) {
+	stepLogWriterMutex.Lock()
+	defer stepLogWriterMutex.Unlock()
+
+	stepLogWriter = writer
+}
+
+// SetStepLogStreamWriter sets the writer, which receives a copy of the output of the steps (and plugins),
+// for the whole run (e.g. the live log stream). Set it to nil to stop streaming the output.
+func SetStepLogStreamWriter(writer io.Writer) {
+	stepLogWriterMutex.Lock()
+	defer stepLogWriterMutex.Unlock()
+
+	stepLogStreamWriter = writer
+}
+
+func getStepLogWriter() io.Writer {
+	stepLogWriterMutex.Lock()
+	defer stepLogWriterMutex.Unlock()
+
+	writers := []io.Writer{}
+	for _, writer := range []io.Writer{stepLogWriter, stepLogStreamWriter} {
+		if writer != nil {
+			writers = append(writers, writer)
+		}
+	}
+	if len(writers) == 0 {
+		return nil
+	}
+	return io.MultiWriter(writers...)
+}
+
+func setRunningProcess(process *os.Process, isGroup bool) {
+	runningProcessMutex.Lock()
+	defer runningProcessMutex.Unlock()
+
+	runningProcess = process
+	isRunningProcessGroup = isGroup
+}
+
+// KillRunningProcessGroup kills the process group of the currently running step (or plugin),
+// including every child process the step started.
+func KillRunningProcessGroup() error {
+	runningProcessMutex.Lock()
+	defer runningProcessMutex.Unlock()
+
+	if runningProcess == nil {
+		return nil
+	}
+	return killProcessGroup(runningProcess.Pid, isRunningProcessGroup)
+}
+
+// EnvmanRun runs the command with the envstore's envs, in its own process group.
 func EnvmanRun(envstorePth, workDirPth string, cmd []string) (int, error) {
+	return EnvmanRunWithResourceLimits(envstorePth, workDirPth, cmd, models.ResourceLimitsModel{})
+}
+
+// EnvmanRunWithResourceLimits runs the command with the envstore's envs, in its own process group,
+// with the given resource limits (see: newProcessLimiter).
+func EnvmanRunWithResourceLimits(envstorePth, workDirPth string, cmd []string, limits models.ResourceLimitsModel) (int, error) {
 	logLevel := log.GetLevel().String()
 	args := []string{"--loglevel", logLevel, "--path", envstorePth, "run"}
 	args = append(args, cmd...)
 
-	return cmdex.RunCommandInDirAndReturnExitCode(workDirPth, "envman", args...)
+	command := exec.Command("envman", args...)
+	command.Dir = workDirPth
+
+	return runInProcessGroup(command, limits)
+}
+
+// runInProcessGroup runs the command in its own process group (if its stdin is not a terminal, see: setProcessGroup),
+// so that the whole process tree can be killed if the build is aborted.
+func runInProcessGroup(command *exec.Cmd, limits models.ResourceLimitsModel) (int, error) {
+	command.Stdin = os.Stdin
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	if writer := getStepLogWriter(); writer != nil {
+		command.Stdout = io.MultiWriter(os.Stdout, writer)
+		command.Stderr = io.MultiWriter(os.Stderr, writer)
+	}
+	if redact := getStepOutputRedactor(); redact != nil {
+		stdout := newLineRedactWriter(command.Stdout, redact)
+		stderr := newLineRedactWriter(command.Stderr, redact)
+		command.Stdout = stdout
+		command.Stderr = stderr
+		defer func() {
+			for _, writer := range []*lineRedactWriter{stdout, stderr} {
+				if err := writer.Flush(); err != nil {
+					log.Debugf("Failed to write the output of the step, error: %s", err)
+				}
+			}
+		}()
+	}
+	isGroup := setProcessGroup(command)
+
+	limiter, err := newProcessLimiter(command, limits)
+	if err != nil {
+		if err := handleUnenforcedResourceLimits(err); err != nil {
+			return 1, err
+		}
+	}
+	if limiter.release != nil {
+		defer limiter.release()
+	}
+
+	if err := command.Start(); err != nil {
+		return 1, err
+	}
+
+	setRunningProcess(command.Process, isGroup)
+	err = command.Wait()
+	setRunningProcess(nil, false)
+
+	if err != nil {
+		exitCode, castErr := errorutil.CmdExitCodeFromError(err)
+		if castErr != nil {
+			return 1, fmt.Errorf("failed get exit code from error: %s, error: %s", err, castErr)
+		}
+		return exitCode, err
+	}
+	return 0, nil
 }
 
 // EnvmanJSONPrint ...
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"bytes"
 	"path/filepath"
 	"testing"
 
@@ -9,21 +10,22 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func TestStepmanJSONStepLibStepInfo(t *testing.T) {
+func TestStepmanStepLibStepInfo(t *testing.T) {
 	// setup
 	require.NoError(t, configs.InitPaths())
 
-	// Valid params -- Err should empty, output filled
+	// Valid params -- Err should empty, info filled
 	require.Equal(t, nil, StepmanSetup("https://github.com/bitrise-io/bitrise-steplib"))
 
-	outStr, err := StepmanJSONStepLibStepInfo("https://github.com/bitrise-io/bitrise-steplib", "script", "0.9.0")
+	info, err := StepmanStepLibStepInfo("https://github.com/bitrise-io/bitrise-steplib", "script", "0.9.0")
 	require.Equal(t, nil, err)
-	require.NotEqual(t, "", outStr)
+	require.Equal(t, "script", info.ID)
+	require.Equal(t, "0.9.0", info.Version)
 
-	// Invalid params -- Err should empty, output filled
-	outStr, err = StepmanJSONStepLibStepInfo("https://github.com/bitrise-io/bitrise-steplib", "script", "2")
+	// Invalid params -- Err should filled, info empty
+	info, err = StepmanStepLibStepInfo("https://github.com/bitrise-io/bitrise-steplib", "script", "2")
 	require.NotEqual(t, nil, err)
-	require.Equal(t, "", outStr)
+	require.Equal(t, "", info.ID)
 }
 
 func TestEnvmanJSONPrint(t *testing.T) {
@@ -49,3 +51,46 @@ func TestEnvmanJSONPrint(t *testing.T) {
 	require.NotEqual(t, nil, err)
 	require.Equal(t, "", outStr)
 }
+
+func TestStepmanPromptWriter(t *testing.T) {
+	t.Log("prompt")
+	{
+		var out bytes.Buffer
+		writer := &stepmanPromptWriter{writer: &out}
+		_, err := writer.Write([]byte("Step already exists in path: /steplib/steps/my-step/1.0.0\nWould you like to overwrite "))
+		require.NoError(t, err)
+		_, err = writer.Write([]byte("local version of Step? [yes/no]: "))
+		require.NoError(t, err)
+
+		require.Equal(t, "Would you like to overwrite local version of Step? [yes/no]:", writer.prompt())
+		require.Equal(t, "Step already exists in path: /steplib/steps/my-step/1.0.0\nWould you like to overwrite local version of Step? [yes/no]: ", out.String())
+	}
+
+	t.Log("no prompt")
+	{
+		var out bytes.Buffer
+		writer := &stepmanPromptWriter{writer: &out}
+		_, err := writer.Write([]byte("Failed to share step, error: invalid tag:\n"))
+		require.NoError(t, err)
+		require.Equal(t, "", writer.prompt())
+	}
+}
+
+func TestExecutableFileName(t *testing.T) {
+	require.Equal(t, "envman", executableFileName("envman", "linux"))
+	require.Equal(t, "envman.exe", executableFileName("envman", "windows"))
+	require.Equal(t, "envman.exe", executableFileName("envman.exe", "windows"))
+}
+
+func TestUnameGOARCH(t *testing.T) {
+	arch, err := unameGOARCH("amd64")
+	require.NoError(t, err)
+	require.Equal(t, "x86_64", arch)
+
+	arch, err = unameGOARCH("arm")
+	require.NoError(t, err)
+	require.Equal(t, "armv7l", arch)
+
+	_, err = unameGOARCH("mips")
+	require.Error(t, err)
+}
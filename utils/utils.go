@@ -3,11 +3,17 @@ package utils
 import (
 	"os"
 	"os/exec"
+	"runtime"
 	"strings"
 )
 
 // CheckProgramInstalledPath ...
 func CheckProgramInstalledPath(clcommand string) (string, error) {
+	if runtime.GOOS == "windows" {
+		// there's no which on Windows
+		return exec.LookPath(clcommand)
+	}
+
 	cmd := exec.Command("which", clcommand)
 	cmd.Stderr = os.Stderr
 	outBytes, err := cmd.Output()